@@ -1,17 +1,28 @@
 "use client";
 
 import { useEffect, useMemo, useRef, useState, useTransition } from "react";
-import { Eye, Loader2, Play, RefreshCw, Upload } from "lucide-react";
+import { Eye, GitCompare, Loader2, Play, RefreshCw, Undo2, Upload } from "lucide-react";
 import { toast } from "sonner";
-import type { MarketIngestionRun, MarketRegionAlias, RunMarketIngestionResponse } from "@widia/shared";
+import { MarketIngestionQualityReportSchema } from "@widia/shared";
+import type {
+  MarketCity,
+  MarketIngestionContentType,
+  MarketIngestionFormat,
+  MarketIngestionRun,
+  MarketIngestionRunDiff,
+  MarketRegionAlias,
+  RunMarketIngestionResponse,
+} from "@widia/shared";
 
 import {
   approveMarketRegionAlias,
   getMarketIngestionRun,
+  getMarketIngestionRunDiff,
   getMarketIngestionUploadUrl,
   listMarketIngestionRuns,
   listMarketRegionAliases,
   rejectMarketRegionAlias,
+  rollbackMarketIngestionRun,
   runMarketIngestion,
 } from "@/lib/actions/market-data-admin";
 import { Badge } from "@/components/ui/badge";
@@ -21,6 +32,13 @@ import { Checkbox } from "@/components/ui/checkbox";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
 import { Progress } from "@/components/ui/progress";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
 import {
   Table,
   TableBody,
@@ -31,7 +49,26 @@ import {
 } from "@/components/ui/table";
 
 const XLSX_MIME = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet";
+const CSV_MIME = "text/csv";
+const INGESTION_FORMATS: Array<{ value: MarketIngestionFormat; label: string }> = [
+  { value: "auto", label: "Automático" },
+  { value: "xlsx_monthly", label: "XLSX (uma aba por mês)" },
+  { value: "xlsx", label: "XLSX (aba única)" },
+  { value: "csv", label: "CSV" },
+];
+
+// Browsers report CSV as text/csv or application/vnd.ms-excel; the presigned URL needs one fixed type
+function ingestionContentType(file: File): MarketIngestionContentType {
+  return file.name.toLowerCase().endsWith(".csv") ? CSV_MIME : XLSX_MIME;
+}
+
 const ALIAS_PAGE_SIZE = 10;
+const DIFF_ROW_LIMIT = 50;
+// Cities with an ingestion profile in the API (marketingest)
+const INGESTION_CITIES: Array<{ value: MarketCity; label: string }> = [
+  { value: "sp", label: "São Paulo (SP)" },
+  { value: "curitiba", label: "Curitiba (PR)" },
+];
 
 interface MarketDataAdminClientProps {
   initialRuns: MarketIngestionRun[];
@@ -49,6 +86,7 @@ interface DryRunPreviewRow {
   area_m2: number;
   price_m2: number;
   sql_registration: string;
+  region_method: string;
 }
 
 function monthNow(): string {
@@ -141,9 +179,62 @@ function formatConfidence(value: number | null | undefined): string {
 function runStatusText(status: string): string {
   if (status === "running") return "Executando";
   if (status === "success") return "Sucesso";
+  if (status === "rolled_back") return "Revertido";
   return "Falha";
 }
 
+function formatSignedPercent(value: number | null): string {
+  if (value === null) return "-";
+  const formatted = value.toLocaleString("pt-BR", { minimumFractionDigits: 1, maximumFractionDigits: 1 });
+  return value > 0 ? `+${formatted}%` : `${formatted}%`;
+}
+
+function formatSignedInt(value: number): string {
+  return value > 0 ? `+${formatInt(value)}` : formatInt(value);
+}
+
+const QUALITY_REASON_LABELS: Record<string, string> = {
+  missing_neighborhood: "Sem bairro",
+  unknown_neighborhood: "Bairro não reconhecido",
+  invalid_value: "Valor inválido",
+  invalid_area: "Área inválida",
+  impossible_price_m2: "R$/m² impossível",
+  missing_date: "Sem data",
+  after_as_of_month: "Após o mês de referência",
+  duplicate_row: "Linha repetida",
+  iqr_outlier: "Outlier (IQR)",
+  mad_outlier: "Outlier (MAD)",
+  duplicate_registration: "Inscrição repetida no mês",
+};
+
+function qualityReasonText(reason: string): string {
+  return QUALITY_REASON_LABELS[reason] ?? reason;
+}
+
+const REGION_METHOD_LABELS: Record<string, string> = {
+  geo_cep: "Polígono (CEP)",
+  geo_street: "Polígono (logradouro)",
+  dictionary: "Dicionário",
+  normalized: "Texto normalizado",
+  llm: "LLM",
+};
+
+function regionMethodText(method: string): string {
+  return REGION_METHOD_LABELS[method] ?? method;
+}
+
+function sortedCounts(counts: Record<string, number> | null | undefined): Array<[string, number]> {
+  return Object.entries(counts ?? {})
+    .filter(([, count]) => count > 0)
+    .sort((a, b) => b[1] - a[1]);
+}
+
+function diffChangeText(change: string): string {
+  if (change === "added") return "Novo";
+  if (change === "removed") return "Removido";
+  return "Alterado";
+}
+
 function statusBadge(status: string) {
   if (status === "running") {
     return <Badge className="bg-blue-100 text-blue-700 hover:bg-blue-100">Executando</Badge>;
@@ -151,6 +242,9 @@ function statusBadge(status: string) {
   if (status === "success") {
     return <Badge className="bg-emerald-100 text-emerald-700 hover:bg-emerald-100">Sucesso</Badge>;
   }
+  if (status === "rolled_back") {
+    return <Badge className="bg-amber-100 text-amber-700 hover:bg-amber-100">Revertido</Badge>;
+  }
   return <Badge className="bg-red-100 text-red-700 hover:bg-red-100">Falha</Badge>;
 }
 
@@ -172,7 +266,7 @@ async function uploadThroughProxy(uploadURL: string, file: File, onProgress: (va
     });
     xhr.addEventListener("error", () => reject(new Error("Upload failed")));
     xhr.open("PUT", proxyUrl);
-    xhr.setRequestHeader("Content-Type", file.type || XLSX_MIME);
+    xhr.setRequestHeader("Content-Type", ingestionContentType(file));
     xhr.send(file);
   });
 }
@@ -193,6 +287,8 @@ export function MarketDataAdminClient({
   initialAliasTotal,
   initialCanonicalOptions,
 }: MarketDataAdminClientProps) {
+  const [city, setCity] = useState<MarketCity>("sp");
+  const [format, setFormat] = useState<MarketIngestionFormat>("auto");
   const [runs, setRuns] = useState<MarketIngestionRun[]>(initialRuns);
   const [selectedRunID, setSelectedRunID] = useState<string | null>(initialRuns[0]?.id ?? null);
   const [aliases, setAliases] = useState<MarketRegionAlias[]>(initialAliases);
@@ -209,6 +305,9 @@ export function MarketDataAdminClient({
   const [uploadProgress, setUploadProgress] = useState(0);
   const [uploading, setUploading] = useState(false);
 
+  const [runDiff, setRunDiff] = useState<MarketIngestionRunDiff | null>(null);
+  const [diffLoading, setDiffLoading] = useState(false);
+
   const [pollRunID, setPollRunID] = useState<string | null>(null);
   const [isPending, startTransition] = useTransition();
   const fileRef = useRef<HTMLInputElement>(null);
@@ -236,6 +335,7 @@ export function MarketDataAdminClient({
         area_m2: asNumber(row.area_m2),
         price_m2: asNumber(row.price_m2),
         sql_registration: asString(row.sql_registration),
+        region_method: asString(row.region_method),
       });
       if (rows.length >= 10) break;
     }
@@ -253,6 +353,21 @@ export function MarketDataAdminClient({
     return `${formatMonthReference(months[0])} ... ${formatMonthReference(months[months.length - 1])} (${months.length} meses)`;
   }, [selectedRun]);
 
+  const qualityReport = useMemo(() => {
+    const parsed = MarketIngestionQualityReportSchema.safeParse(selectedRun?.stats?.quality);
+    return parsed.success ? parsed.data : null;
+  }, [selectedRun]);
+
+  const regionMethods = useMemo(() => {
+    const raw = selectedRun?.stats?.region_methods;
+    if (!raw || typeof raw !== "object" || Array.isArray(raw)) return [];
+    const counts: Record<string, number> = {};
+    for (const [method, count] of Object.entries(raw as Record<string, unknown>)) {
+      counts[method] = asNumber(count);
+    }
+    return sortedCounts(counts);
+  }, [selectedRun]);
+
   const qualityRate = useMemo(() => {
     if (!selectedRun) return "0,0%";
     return formatPercent(selectedRun.valid_rows ?? 0, selectedRun.input_rows ?? 0);
@@ -261,9 +376,9 @@ export function MarketDataAdminClient({
   const aliasPage = Math.floor(aliasOffset / ALIAS_PAGE_SIZE) + 1;
   const aliasPageCount = Math.max(1, Math.ceil(aliasTotal / ALIAS_PAGE_SIZE));
 
-  const refreshRuns = () => {
+  const refreshRuns = (nextCity: MarketCity = city) => {
     startTransition(async () => {
-      const result = await listMarketIngestionRuns({ city: "sp", limit: 50, offset: 0 });
+      const result = await listMarketIngestionRuns({ city: nextCity, limit: 50, offset: 0 });
       if (result.error) {
         toast.error("Falha ao atualizar runs", { description: result.error });
         return;
@@ -274,11 +389,12 @@ export function MarketDataAdminClient({
 
   const refreshAliases = (
     nextStatus: "pending" | "approved" | "rejected" = aliasStatus,
-    nextOffset: number = aliasOffset
+    nextOffset: number = aliasOffset,
+    nextCity: MarketCity = city
   ) => {
     startTransition(async () => {
       const result = await listMarketRegionAliases({
-        city: "sp",
+        city: nextCity,
         status: nextStatus,
         limit: ALIAS_PAGE_SIZE,
         offset: nextOffset,
@@ -297,6 +413,13 @@ export function MarketDataAdminClient({
     });
   };
 
+  const handleCityChange = (value: string) => {
+    setCity(value);
+    setSelectedRunID(null);
+    refreshRuns(value);
+    refreshAliases(aliasStatus, 0, value);
+  };
+
   const getAliasDraftCanonical = (alias: MarketRegionAlias): string => {
     const draft = aliasCanonicalDraft[alias.id];
     if (typeof draft === "string" && draft.trim() !== "") {
@@ -352,6 +475,7 @@ export function MarketDataAdminClient({
 
   const handleViewDetails = (runID: string) => {
     setSelectedRunID(runID);
+    setRunDiff(null);
     window.setTimeout(() => {
       detailsRef.current?.scrollIntoView({ behavior: "smooth", block: "start" });
     }, 0);
@@ -359,7 +483,7 @@ export function MarketDataAdminClient({
 
   const handleUploadAndRun = () => {
     if (!file) {
-      toast.error("Selecione um arquivo XLSX");
+      toast.error("Selecione um arquivo XLSX ou CSV");
       return;
     }
     if (!asOfMonth) {
@@ -377,19 +501,19 @@ export function MarketDataAdminClient({
     startTransition(async () => {
       try {
         const uploadInfo = await getMarketIngestionUploadUrl({
-          city: "sp",
+          city,
           filename: file.name,
-          content_type: XLSX_MIME,
+          content_type: ingestionContentType(file),
           size_bytes: file.size,
         });
 
         await uploadThroughProxy(uploadInfo.upload_url, file, setUploadProgress);
 
         const run = await runMarketIngestion({
-          city: "sp",
+          city,
           as_of_month: asOfMonth,
           storage_key: uploadInfo.storage_key,
-          source: "itbi_sp_guias_pagas",
+          format,
           dry_run: dryRun,
         });
 
@@ -403,7 +527,7 @@ export function MarketDataAdminClient({
         }
 
         startRunPolling(run);
-        const refreshed = await listMarketIngestionRuns({ city: "sp", limit: 50, offset: 0 });
+        const refreshed = await listMarketIngestionRuns({ city, limit: 50, offset: 0 });
         if (refreshed.data?.items) {
           setRuns(refreshed.data.items);
         }
@@ -428,10 +552,12 @@ export function MarketDataAdminClient({
     startTransition(async () => {
       try {
         const next = await runMarketIngestion({
-          city: "sp",
+          city: run.city,
           as_of_month: run.as_of_month,
           storage_key: storageKey,
           source: run.source,
+          format: (run.params?.format as MarketIngestionFormat | undefined) || "auto",
+          column_mapping: run.params?.column_mapping ?? undefined,
           dry_run: run.dry_run,
         });
 
@@ -445,6 +571,46 @@ export function MarketDataAdminClient({
     });
   };
 
+  const handleRollback = (run: MarketIngestionRun) => {
+    if (
+      !confirm(
+        `Reverter o run de ${formatMonthReference(run.as_of_month)}? Os agregados e transações anteriores a ele serão restaurados.`
+      )
+    ) {
+      return;
+    }
+
+    startTransition(async () => {
+      try {
+        const result = await rollbackMarketIngestionRun(run.id);
+        toast.success("Run revertido", {
+          description: `${formatInt(result.aggregate_changes)} agregados e ${formatInt(result.transactions_restored)} transações restaurados`,
+        });
+        const refreshed = await getMarketIngestionRun(run.id);
+        if (refreshed) {
+          setRuns((prev) => mergeRunById(prev, refreshed));
+        }
+      } catch (error) {
+        toast.error("Falha ao reverter run", {
+          description: error instanceof Error ? error.message : "Erro desconhecido",
+        });
+      }
+    });
+  };
+
+  const handleLoadDiff = (run: MarketIngestionRun) => {
+    setDiffLoading(true);
+    startTransition(async () => {
+      const result = await getMarketIngestionRunDiff(run.id);
+      setDiffLoading(false);
+      if (result.error) {
+        toast.error("Falha ao comparar runs", { description: result.error });
+        return;
+      }
+      setRunDiff(result.data);
+    });
+  };
+
   useEffect(() => {
     if (!pollRunID) return;
 
@@ -490,26 +656,56 @@ export function MarketDataAdminClient({
         <CardHeader>
           <CardTitle>Upload e execução</CardTitle>
           <CardDescription>
-            Envie a planilha ITBI de SP e dispare a ingestão assíncrona. O processamento roda no backend e você acompanha o status por polling.
+            Envie a planilha ITBI da cidade e dispare a ingestão assíncrona. O processamento roda no backend e você acompanha o status por polling.
           </CardDescription>
         </CardHeader>
         <CardContent className="space-y-4">
-          <div className="grid gap-4 md:grid-cols-3">
+          <div className="grid gap-4 md:grid-cols-4">
             <div className="space-y-2">
               <Label htmlFor="city">Cidade</Label>
-              <Input id="city" value="sp" disabled />
+              <Select value={city} onValueChange={handleCityChange} disabled={uploading || isPending}>
+                <SelectTrigger id="city">
+                  <SelectValue placeholder="Selecione" />
+                </SelectTrigger>
+                <SelectContent>
+                  {INGESTION_CITIES.map((option) => (
+                    <SelectItem key={option.value} value={option.value}>
+                      {option.label}
+                    </SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
             </div>
             <div className="space-y-2">
               <Label htmlFor="month">Mês de referência</Label>
               <Input id="month" type="month" value={asOfMonth} onChange={(e) => setAsOfMonth(e.target.value)} disabled={uploading || isPending} />
             </div>
             <div className="space-y-2">
-              <Label htmlFor="file">Arquivo XLSX</Label>
+              <Label htmlFor="format">Formato</Label>
+              <Select
+                value={format}
+                onValueChange={(value) => setFormat(value as MarketIngestionFormat)}
+                disabled={uploading || isPending}
+              >
+                <SelectTrigger id="format">
+                  <SelectValue placeholder="Selecione" />
+                </SelectTrigger>
+                <SelectContent>
+                  {INGESTION_FORMATS.map((option) => (
+                    <SelectItem key={option.value} value={option.value}>
+                      {option.label}
+                    </SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="file">Arquivo XLSX ou CSV</Label>
               <Input
                 ref={fileRef}
                 id="file"
                 type="file"
-                accept=".xlsx,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                accept=".xlsx,.csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,text/csv"
                 disabled={uploading || isPending}
                 onChange={(e) => setFile(e.target.files?.[0] ?? null)}
               />
@@ -530,7 +726,7 @@ export function MarketDataAdminClient({
               {uploading || isPending ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : <Upload className="mr-2 h-4 w-4" />}
               Upload + Executar
             </Button>
-            <Button variant="outline" onClick={refreshRuns} disabled={uploading || isPending}>
+            <Button variant="outline" onClick={() => refreshRuns()} disabled={uploading || isPending}>
               <RefreshCw className="mr-2 h-4 w-4" />
               Atualizar lista
             </Button>
@@ -723,6 +919,12 @@ export function MarketDataAdminClient({
                             <Play className="mr-1 h-3 w-3" />
                             Reexecutar
                           </Button>
+                          {run.status === "success" && !run.dry_run ? (
+                            <Button size="sm" variant="ghost" onClick={() => handleRollback(run)} disabled={isPending}>
+                              <Undo2 className="mr-1 h-3 w-3" />
+                              Reverter
+                            </Button>
+                          ) : null}
                         </div>
                       </TableCell>
                     </TableRow>
@@ -813,9 +1015,93 @@ export function MarketDataAdminClient({
                   <span className="font-medium text-foreground">Aliases pendentes detectados:</span>{" "}
                   {formatInt(asNumber(selectedRun.stats?.alias_candidates))}
                 </p>
+                <p className="text-muted-foreground">
+                  <span className="font-medium text-foreground">Resolução de bairro:</span>{" "}
+                  {regionMethods.length === 0
+                    ? "-"
+                    : regionMethods.map(([method, count]) => `${regionMethodText(method)} ${formatInt(count)}`).join(", ")}
+                </p>
               </div>
             </div>
 
+            {qualityReport ? (
+              <div className="rounded-md border p-3">
+                <p className="font-medium">Qualidade dos dados</p>
+                <div className="mt-3 grid gap-3 md:grid-cols-3">
+                  <div>
+                    <p className="text-xs font-medium text-muted-foreground">Linhas descartadas</p>
+                    {sortedCounts(qualityReport.dropped_rows).length === 0 ? (
+                      <p className="mt-1 text-xs text-muted-foreground">Nenhuma.</p>
+                    ) : (
+                      sortedCounts(qualityReport.dropped_rows).map(([reason, count]) => (
+                        <p key={reason} className="mt-1 flex justify-between gap-2 text-xs">
+                          <span>{qualityReasonText(reason)}</span>
+                          <span className="font-medium">{formatInt(count)}</span>
+                        </p>
+                      ))
+                    )}
+                  </div>
+                  <div>
+                    <p className="text-xs font-medium text-muted-foreground">Linhas sinalizadas (mantidas)</p>
+                    {sortedCounts(qualityReport.flagged_rows).length === 0 ? (
+                      <p className="mt-1 text-xs text-muted-foreground">Nenhuma.</p>
+                    ) : (
+                      sortedCounts(qualityReport.flagged_rows).map(([reason, count]) => (
+                        <p key={reason} className="mt-1 flex justify-between gap-2 text-xs">
+                          <span>{qualityReasonText(reason)}</span>
+                          <span className="font-medium">{formatInt(count)}</span>
+                        </p>
+                      ))
+                    )}
+                  </div>
+                  <div>
+                    <p className="text-xs font-medium text-muted-foreground">
+                      Bairros abaixo de {formatInt(qualityReport.min_tx_count)} transações ({qualityReport.period_months} meses)
+                    </p>
+                    <p className="mt-1 text-sm font-semibold">{formatInt(qualityReport.low_sample_total)}</p>
+                    {(qualityReport.low_sample_bairros ?? []).length > 0 ? (
+                      <p className="mt-1 line-clamp-3 text-xs text-muted-foreground">
+                        {(qualityReport.low_sample_bairros ?? [])
+                          .slice(0, 12)
+                          .map((item) => `${item.region_name} (${item.tx_count})`)
+                          .join(", ")}
+                      </p>
+                    ) : null}
+                  </div>
+                </div>
+                {(qualityReport.samples ?? []).length > 0 ? (
+                  <div className="mt-3 overflow-x-auto rounded-md border">
+                    <Table>
+                      <TableHeader>
+                        <TableRow>
+                          <TableHead>Motivo</TableHead>
+                          <TableHead>Mês</TableHead>
+                          <TableHead>Bairro</TableHead>
+                          <TableHead>Tipo</TableHead>
+                          <TableHead>Inscrição</TableHead>
+                          <TableHead className="text-right">R$/m²</TableHead>
+                          <TableHead>Situação</TableHead>
+                        </TableRow>
+                      </TableHeader>
+                      <TableBody>
+                        {(qualityReport.samples ?? []).map((sample, idx) => (
+                          <TableRow key={`${sample.reason}-${sample.region_name}-${idx}`}>
+                            <TableCell>{qualityReasonText(sample.reason)}</TableCell>
+                            <TableCell>{sample.month}</TableCell>
+                            <TableCell>{sample.region_name}</TableCell>
+                            <TableCell>{sample.property_class}</TableCell>
+                            <TableCell>{sample.sql_registration || "-"}</TableCell>
+                            <TableCell className="text-right">{formatCurrency(sample.price_m2)}</TableCell>
+                            <TableCell>{sample.excluded ? "Descartada" : "Mantida"}</TableCell>
+                          </TableRow>
+                        ))}
+                      </TableBody>
+                    </Table>
+                  </div>
+                ) : null}
+              </div>
+            ) : null}
+
             {selectedRun.dry_run ? (
               <div className="rounded-md border p-3">
                 <p className="font-medium">Preview (10 primeiros registros válidos)</p>
@@ -839,7 +1125,12 @@ export function MarketDataAdminClient({
                         {previewRows.map((row, idx) => (
                           <TableRow key={`${row.month}-${row.region_name}-${idx}`}>
                             <TableCell>{row.month || "-"}</TableCell>
-                            <TableCell>{row.region_name || "-"}</TableCell>
+                            <TableCell>
+                              {row.region_name || "-"}
+                              {row.region_method ? (
+                                <span className="block text-xs text-muted-foreground">{regionMethodText(row.region_method)}</span>
+                              ) : null}
+                            </TableCell>
                             <TableCell>{row.property_class || "-"}</TableCell>
                             <TableCell>{row.transaction_date || "-"}</TableCell>
                             <TableCell className="text-right">{formatCurrency(row.transaction_value)}</TableCell>
@@ -852,7 +1143,79 @@ export function MarketDataAdminClient({
                   </div>
                 )}
               </div>
-            ) : null}
+            ) : (
+              <div className="rounded-md border p-3">
+                <div className="flex flex-wrap items-center justify-between gap-2">
+                  <p className="font-medium">Comparação com o run anterior</p>
+                  <Button
+                    size="sm"
+                    variant="outline"
+                    onClick={() => handleLoadDiff(selectedRun)}
+                    disabled={diffLoading || isPending}
+                  >
+                    {diffLoading ? <Loader2 className="mr-1 h-3 w-3 animate-spin" /> : <GitCompare className="mr-1 h-3 w-3" />}
+                    Comparar
+                  </Button>
+                </div>
+                {selectedRun.rolled_back_at ? (
+                  <p className="mt-2 text-xs text-muted-foreground">
+                    Revertido em {formatDateTime(selectedRun.rolled_back_at)}
+                  </p>
+                ) : null}
+                {runDiff && runDiff.run_id === selectedRun.id ? (
+                  <div className="mt-3 space-y-2">
+                    <p className="text-xs text-muted-foreground">
+                      Base: {runDiff.base_run_id} · {formatInt(runDiff.summary.changed)} alterados,{" "}
+                      {formatInt(runDiff.summary.added)} novos, {formatInt(runDiff.summary.removed)} removidos,{" "}
+                      {formatInt(runDiff.summary.unchanged)} sem mudança
+                    </p>
+                    {runDiff.rows.length === 0 ? (
+                      <p className="text-xs text-muted-foreground">Nenhuma diferença por bairro e mês.</p>
+                    ) : (
+                      <div className="overflow-x-auto rounded-md border">
+                        <Table>
+                          <TableHeader>
+                            <TableRow>
+                              <TableHead>Bairro</TableHead>
+                              <TableHead>Mês</TableHead>
+                              <TableHead>Mudança</TableHead>
+                              <TableHead className="text-right">Mediana base</TableHead>
+                              <TableHead className="text-right">Mediana</TableHead>
+                              <TableHead className="text-right">Δ mediana</TableHead>
+                              <TableHead className="text-right">Δ transações</TableHead>
+                            </TableRow>
+                          </TableHeader>
+                          <TableBody>
+                            {runDiff.rows.slice(0, DIFF_ROW_LIMIT).map((row) => (
+                              <TableRow key={`${row.region_name}-${row.month}`}>
+                                <TableCell>{row.region_name}</TableCell>
+                                <TableCell>{formatMonthReference(row.month)}</TableCell>
+                                <TableCell>{diffChangeText(row.change)}</TableCell>
+                                <TableCell className="text-right">
+                                  {row.base_median_m2 === null ? "-" : formatCurrency(row.base_median_m2)}
+                                </TableCell>
+                                <TableCell className="text-right">
+                                  {row.median_m2 === null ? "-" : formatCurrency(row.median_m2)}
+                                </TableCell>
+                                <TableCell className="text-right">{formatSignedPercent(row.median_change_pct)}</TableCell>
+                                <TableCell className="text-right">
+                                  {formatSignedInt(row.tx_count_change)} ({formatInt(row.base_tx_count)} → {formatInt(row.tx_count)})
+                                </TableCell>
+                              </TableRow>
+                            ))}
+                          </TableBody>
+                        </Table>
+                      </div>
+                    )}
+                    {runDiff.rows.length > DIFF_ROW_LIMIT ? (
+                      <p className="text-xs text-muted-foreground">
+                        Exibindo {DIFF_ROW_LIMIT} de {formatInt(runDiff.rows.length)} diferenças.
+                      </p>
+                    ) : null}
+                  </div>
+                ) : null}
+              </div>
+            )}
 
             <details className="rounded-md border p-3">
               <summary className="cursor-pointer select-none text-sm font-medium">JSON técnico (stats/params)</summary>
@@ -46,6 +46,7 @@ type OpportunitySignal = {
 };
 
 export function MarketDataClient() {
+  const [city, setCity] = useState<string>("sp");
   const [filters, setFilters] = useState<MarketFiltersResponse | null>(null);
   const [selectedMonth, setSelectedMonth] = useState<string>("");
   const [periodMonths, setPeriodMonths] = useState<number>(6);
@@ -66,7 +67,7 @@ export function MarketDataClient() {
     setError(null);
 
     try {
-      const res = await fetch("/api/market/filters?city=sp", { cache: "no-store" });
+      const res = await fetch(`/api/market/filters?city=${encodeURIComponent(city)}`, { cache: "no-store" });
       const raw = await res.json();
       if (!res.ok) {
         throw new Error(raw?.error?.message ?? "Falha ao carregar filtros de mercado");
@@ -74,18 +75,19 @@ export function MarketDataClient() {
 
       const data = raw as MarketFiltersResponse;
       setFilters(data);
-      if (data.available_months.length > 0) {
-        setSelectedMonth((prev) => prev || data.available_months[0]);
-      }
+      // Months differ per city: keep the selection only when the new city has it
+      setSelectedMonth((prev) =>
+        prev && data.available_months.includes(prev) ? prev : (data.available_months[0] ?? ""),
+      );
     } catch (err) {
       setError(err instanceof Error ? err.message : "Erro ao carregar filtros");
     } finally {
       setLoadingFilters(false);
     }
-  }, []);
+  }, [city]);
 
   const loadPriceData = useCallback(
-    async (month: string, period: number, type: MarketPropertyClass, minSample: number) => {
+    async (cityValue: string, month: string, period: number, type: MarketPropertyClass, minSample: number) => {
       if (!month) return;
 
       setLoadingPrice(true);
@@ -94,7 +96,7 @@ export function MarketDataClient() {
 
       try {
         const params = new URLSearchParams({
-          city: "sp",
+          city: cityValue,
           as_of_month: month,
           period_months: String(period),
           property_class: type,
@@ -125,7 +127,7 @@ export function MarketDataClient() {
 
       try {
         const params = new URLSearchParams({
-          city: "sp",
+          city,
           region_name: regionName,
           period_months: String(periodMonths),
           property_class: propertyClass,
@@ -146,7 +148,7 @@ export function MarketDataClient() {
         setLoadingSeries(false);
       }
     },
-    [periodMonths, propertyClass],
+    [city, periodMonths, propertyClass],
   );
 
   useEffect(() => {
@@ -155,12 +157,24 @@ export function MarketDataClient() {
 
   useEffect(() => {
     if (!selectedMonth) return;
-    void loadPriceData(selectedMonth, periodMonths, propertyClass, minTxCount);
-  }, [selectedMonth, periodMonths, propertyClass, minTxCount, loadPriceData]);
+    void loadPriceData(city, selectedMonth, periodMonths, propertyClass, minTxCount);
+  }, [city, selectedMonth, periodMonths, propertyClass, minTxCount, loadPriceData]);
 
   useEffect(() => {
     setPage(1);
-  }, [selectedMonth, periodMonths, propertyClass, minTxCount]);
+  }, [city, selectedMonth, periodMonths, propertyClass, minTxCount]);
+
+  const cityOptions = useMemo(() => {
+    const available = filters?.available_cities ?? [];
+    if (available.length === 0) {
+      return [{ value: "sp", label: "São Paulo" }];
+    }
+    return available.map((option) => ({
+      value: option.city,
+      label: option.state ? `${option.name} (${option.state})` : option.name,
+    }));
+  }, [filters]);
+  const cityName = filters?.available_cities.find((option) => option.city === city)?.name ?? city.toUpperCase();
 
   const derived = useMemo(() => {
     const items = priceData?.items ?? [];
@@ -253,7 +267,7 @@ export function MarketDataClient() {
         <div className="relative grid gap-6 lg:grid-cols-[1.6fr_1fr] lg:items-end">
           <div className="space-y-3">
             <Badge className="w-fit border border-cyan-200/30 bg-cyan-400/10 text-cyan-100 hover:bg-cyan-400/10">
-              Market Signal Console · SP
+              Market Signal Console · {cityName}
             </Badge>
             <h1 className="text-3xl font-semibold tracking-tight sm:text-4xl">
               Dados consolidados para decidir preço real por bairro
@@ -263,15 +277,15 @@ export function MarketDataClient() {
             </p>
             <p className="inline-flex w-fit items-center gap-2 rounded-md border border-amber-200/40 bg-amber-200/10 px-3 py-2 text-xs font-medium text-amber-50">
               <span aria-hidden="true">⚑</span>
-              Cobertura atual: somente São Paulo (SP). BH e Curitiba estão em validação para liberação progressiva com dados públicos.
+              Cobertura atual: {cityOptions.map((option) => option.label).join(", ")}. Novas cidades entram conforme os dados públicos de ITBI são ingeridos.
             </p>
           </div>
 
           <div className="grid grid-cols-2 gap-3">
             <KpiTile label="Bairros válidos" value={formatInt(priceData?.summary.regions_count ?? 0)} />
             <KpiTile label="Amostra total" value={formatInt(priceData?.summary.total_tx_count ?? 0)} />
-            <KpiTile label="Mediana SP" value={formatCurrency(priceData?.summary.city_median_m2 ?? 0)} />
-            <KpiTile label="Spread SP" value={formatCurrency(priceData?.summary.spread_m2 ?? 0)} />
+            <KpiTile label="Mediana da cidade" value={formatCurrency(priceData?.summary.city_median_m2 ?? 0)} />
+            <KpiTile label="Spread da cidade" value={formatCurrency(priceData?.summary.spread_m2 ?? 0)} />
           </div>
         </div>
       </section>
@@ -287,14 +301,14 @@ export function MarketDataClient() {
           <div className="space-y-2">
             <FilterSelect
               label="Cidade"
-              value="sp"
-              onValueChange={() => {}}
-              disabled
-              options={[{ value: "sp", label: "São Paulo" }]}
+              value={city}
+              onValueChange={setCity}
+              disabled={loadingFilters || cityOptions.length <= 1}
+              options={cityOptions}
             />
             <div className="inline-flex items-center gap-1.5 rounded-full border border-amber-300/40 bg-amber-50/70 px-2.5 py-1 text-[11px] text-amber-700 dark:border-amber-700/40 dark:bg-amber-900/30 dark:text-amber-200">
               <span className="h-1.5 w-1.5 rounded-full bg-amber-500" aria-hidden="true" />
-              Cobertura ativa: SP somente
+              {cityOptions.length} {cityOptions.length === 1 ? "cidade com dados" : "cidades com dados"}
             </div>
           </div>
 
@@ -377,8 +391,8 @@ export function MarketDataClient() {
                     </TableHead>
                     <TableHead className="text-right">
                       <HeaderHint
-                        label="Gap vs SP"
-                        hint="Diferenca percentual da mediana do bairro em relacao a mediana da cidade de SP."
+                        label="Gap vs cidade"
+                        hint="Diferenca percentual da mediana do bairro em relacao a mediana da cidade."
                         align="right"
                       />
                     </TableHead>
@@ -489,8 +503,8 @@ export function MarketDataClient() {
         <div className="grid content-start gap-6">
           <SignalCard
             title="Top oportunidades consolidadas"
-            hint="Ranking de bairros com maior desconto relativo vs mediana SP, ponderando confianca, amostra e dispersao."
-            subtitle="Desconto vs mediana SP com boa confiança"
+            hint="Ranking de bairros com maior desconto relativo vs mediana da cidade, ponderando confianca, amostra e dispersao."
+            subtitle="Desconto vs mediana da cidade com boa confiança"
             items={derived.opportunities}
           />
 
@@ -35,7 +35,7 @@ function parseIntegerList(value: string | undefined): number[] {
 function parseStatusList(value: string | undefined): OpportunityStatus[] {
   if (!value) return [];
 
-  const allowed = new Set<OpportunityStatus>(["new", "viewed", "contacted", "discarded"]);
+  const allowed = new Set<OpportunityStatus>(["new", "viewed", "contacted", "discarded", "converted"]);
   const items = value
     .split(",")
     .map((item) => item.trim().toLowerCase())
@@ -64,6 +64,8 @@ export default async function OpportunitiesPage(props: {
   const bedrooms = parseIntegerList(getParam(searchParams, "bedrooms"));
   const status = parseStatusList(getParam(searchParams, "status"));
   const sort = getParam(searchParams, "sort") || "score_desc";
+  // Deep link from a saved search alert
+  const opportunityId = getParam(searchParams, "opportunity");
 
   const filterState: OpportunityFilterState = {
     state,
@@ -80,6 +82,7 @@ export default async function OpportunitiesPage(props: {
   };
 
   const queryParams = {
+    opportunityId,
     state,
     city,
     neighborhood,
@@ -43,6 +43,16 @@ function getScoreColor(score: number): string {
   return "bg-red-500";
 }
 
+// Fields read from page markup are less reliable than the portal's structured data
+function htmlFieldsTitle(provenance?: Record<string, string>): string | undefined {
+  if (!provenance) return undefined;
+  const htmlFields = Object.entries(provenance)
+    .filter(([, origin]) => origin === "html")
+    .map(([field]) => field);
+  if (htmlFields.length === 0) return "Todos os campos vieram de dados estruturados do portal";
+  return `Extraídos do HTML: ${htmlFields.join(", ")}`;
+}
+
 export function OpportunityDetailModal({
   opportunity,
   open,
@@ -89,6 +99,11 @@ export function OpportunityDetailModal({
                     {discountPct}% abaixo da mediana
                   </span>
                 )}
+                {opportunity.parse_quality !== undefined && (
+                  <span title={htmlFieldsTitle(opportunity.field_provenance)}>
+                    Qualidade dos dados {opportunity.parse_quality}%
+                  </span>
+                )}
               </div>
             </div>
             <OpportunityStatusControl
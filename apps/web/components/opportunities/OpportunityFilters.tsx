@@ -45,6 +45,7 @@ const statusLabel: Record<OpportunityStatus, string> = {
   viewed: "Visto",
   contacted: "Contatado",
   discarded: "Descartado",
+  converted: "Convertido",
 };
 
 const sortOptions = [
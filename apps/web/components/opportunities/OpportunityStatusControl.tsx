@@ -22,11 +22,13 @@ interface OpportunityStatusControlProps {
   compact?: boolean;
 }
 
-const statusOptions: Array<{ value: OpportunityStatus; label: string }> = [
+const statusOptions: Array<{ value: OpportunityStatus; label: string; disabled?: boolean }> = [
   { value: "new", label: "Novo" },
   { value: "viewed", label: "Visto" },
   { value: "contacted", label: "Contatado" },
   { value: "discarded", label: "Descartado" },
+  // Set by converting the opportunity into a prospect, not selectable
+  { value: "converted", label: "Convertido", disabled: true },
 ];
 
 export function OpportunityStatusControl({
@@ -78,7 +80,7 @@ export function OpportunityStatusControl({
         </SelectTrigger>
         <SelectContent>
           {statusOptions.map((option) => (
-            <SelectItem key={option.value} value={option.value}>
+            <SelectItem key={option.value} value={option.value} disabled={option.disabled}>
               {option.label}
             </SelectItem>
           ))}
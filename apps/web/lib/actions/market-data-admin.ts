@@ -6,17 +6,23 @@ import {
   ListMarketIngestionRunsResponseSchema,
   ListMarketRegionAliasesQuerySchema,
   ListMarketRegionAliasesResponseSchema,
+  MarketIngestionRunDiffSchema,
   MarketIngestionRunSchema,
   MarketIngestionUploadUrlRequestSchema,
   MarketIngestionUploadUrlResponseSchema,
   MarketRegionAliasSchema,
+  RollbackMarketIngestionRunResponseSchema,
   RunMarketIngestionRequestSchema,
   RunMarketIngestionResponseSchema,
   type ListMarketIngestionRunsResponse,
   type ListMarketRegionAliasesResponse,
   type MarketIngestionRun,
+  type MarketIngestionRunDiff,
+  type MarketCity,
+  type MarketIngestionContentType,
   type MarketIngestionUploadUrlResponse,
   type MarketRegionAlias,
+  type RollbackMarketIngestionRunResponse,
   type RunMarketIngestionRequest,
   type RunMarketIngestionResponse,
 } from "@widia/shared";
@@ -37,7 +43,7 @@ function rethrowWithEndpointHint(error: unknown): never {
 }
 
 export async function listMarketIngestionRuns(params?: {
-  city?: "sp";
+  city?: MarketCity;
   limit?: number;
   offset?: number;
 }): Promise<{ data: ListMarketIngestionRunsResponse | null; error: string | null }> {
@@ -69,9 +75,9 @@ export async function getMarketIngestionRun(runId: string): Promise<MarketIngest
 }
 
 export async function getMarketIngestionUploadUrl(input: {
-  city?: "sp";
+  city?: MarketCity;
   filename: string;
-  content_type: string;
+  content_type: MarketIngestionContentType;
   size_bytes: number;
 }): Promise<MarketIngestionUploadUrlResponse> {
   try {
@@ -114,8 +120,42 @@ export async function runMarketIngestion(input: RunMarketIngestionRequest): Prom
   }
 }
 
+export async function rollbackMarketIngestionRun(runId: string): Promise<RollbackMarketIngestionRunResponse> {
+  try {
+    const raw = await apiFetch(`/api/v1/admin/market/ingestions/${runId}/rollback`, {
+      method: "POST",
+    });
+
+    const parsed = RollbackMarketIngestionRunResponseSchema.parse(raw);
+
+    revalidatePath("/app/admin/market-data");
+    revalidatePath("/app/market-data");
+
+    return parsed;
+  } catch (error) {
+    rethrowWithEndpointHint(error);
+  }
+}
+
+export async function getMarketIngestionRunDiff(
+  runId: string,
+  baseRunId?: string
+): Promise<{ data: MarketIngestionRunDiff | null; error: string | null }> {
+  try {
+    const searchParams = new URLSearchParams();
+    if (baseRunId) {
+      searchParams.set("base", baseRunId);
+    }
+    const query = searchParams.toString();
+    const raw = await apiFetch(`/api/v1/admin/market/ingestions/${runId}/diff${query ? `?${query}` : ""}`);
+    return { data: MarketIngestionRunDiffSchema.parse(raw), error: null };
+  } catch (error) {
+    return { data: null, error: error instanceof Error ? error.message : "Erro desconhecido" };
+  }
+}
+
 export async function listMarketRegionAliases(params?: {
-  city?: "sp";
+  city?: MarketCity;
   status?: "pending" | "approved" | "rejected";
   limit?: number;
   offset?: number;
@@ -3,15 +3,33 @@
 import { revalidatePath } from "next/cache";
 
 import {
+  ConvertOpportunityToProspectRequestSchema,
+  ConvertOpportunityToProspectResponseSchema,
+  CreateOpportunitySavedSearchRequestSchema,
   ListJobRunsResponseSchema,
+  ListOpportunityAlertsResponseSchema,
+  ListOpportunitySavedSearchesResponseSchema,
+  MarkOpportunityAlertsReadResponseSchema,
   ListOpportunitiesResponseSchema,
   OpportunityFacetsResponseSchema,
+  OpportunityHistoryResponseSchema,
+  OpportunitySavedSearchSchema,
+  UpdateOpportunitySavedSearchRequestSchema,
   UpdateOpportunityStatusRequestSchema,
   UpdateOpportunityStatusResponseSchema,
+  type ConvertOpportunityToProspectResponse,
+  type CreateOpportunitySavedSearchRequest,
   type ListJobRunsResponse,
   type ListOpportunitiesResponse,
+  type ListOpportunityAlertsResponse,
+  type ListOpportunitySavedSearchesResponse,
+  type MarkOpportunityAlertsReadResponse,
   type OpportunityFacetsResponse,
+  type OpportunityHistoryResponse,
+  type OpportunityListingStatus,
+  type OpportunitySavedSearch,
   type OpportunityStatus,
+  type UpdateOpportunitySavedSearchRequest,
   type UpdateOpportunityStatusResponse,
 } from "@widia/shared";
 
@@ -42,6 +60,7 @@ async function internalFetch<T>(path: string): Promise<T> {
 }
 
 export interface ListOpportunitiesParams {
+  opportunityId?: string;
   state?: string;
   city?: string;
   neighborhood?: string;
@@ -52,7 +71,10 @@ export interface ListOpportunitiesParams {
   maxArea?: number;
   bedrooms?: number[];
   status?: string[];
+  listingStatus?: OpportunityListingStatus;
+  minPriceDrop?: number;
   sort?: string;
+  dedupe?: boolean;
   limit?: number;
   offset?: number;
 }
@@ -63,6 +85,7 @@ export async function listOpportunities(
   try {
     const searchParams = new URLSearchParams();
 
+    if (params.opportunityId) searchParams.set("opportunity", params.opportunityId);
     if (params.state) searchParams.set("state", params.state);
     if (params.city) searchParams.set("city", params.city);
     if (params.neighborhood) searchParams.set("neighborhood", params.neighborhood);
@@ -73,7 +96,10 @@ export async function listOpportunities(
     if (params.maxArea !== undefined) searchParams.set("max_area", String(params.maxArea));
     if (params.bedrooms?.length) searchParams.set("bedrooms", params.bedrooms.join(","));
     if (params.status?.length) searchParams.set("status", params.status.join(","));
+    if (params.listingStatus) searchParams.set("listing_status", params.listingStatus);
+    if (params.minPriceDrop !== undefined) searchParams.set("min_price_drop", String(params.minPriceDrop));
     if (params.sort) searchParams.set("sort", params.sort);
+    if (params.dedupe === false) searchParams.set("dedupe", "false");
     if (params.limit !== undefined) searchParams.set("limit", String(params.limit));
     if (params.offset !== undefined) searchParams.set("offset", String(params.offset));
 
@@ -106,6 +132,8 @@ export async function listOpportunityFacetsAction(
     if (params.maxArea !== undefined) searchParams.set("max_area", String(params.maxArea));
     if (params.bedrooms?.length) searchParams.set("bedrooms", params.bedrooms.join(","));
     if (params.status?.length) searchParams.set("status", params.status.join(","));
+    if (params.listingStatus) searchParams.set("listing_status", params.listingStatus);
+    if (params.minPriceDrop !== undefined) searchParams.set("min_price_drop", String(params.minPriceDrop));
 
     const query = searchParams.toString();
     const path = `/api/v1/opportunities/facets${query ? `?${query}` : ""}`;
@@ -147,6 +175,160 @@ export async function updateOpportunityStatusAction(
   }
 }
 
+export async function convertOpportunityToProspectAction(
+  opportunityId: string,
+  workspaceId: string
+): Promise<{ data: ConvertOpportunityToProspectResponse | null; error: string | null }> {
+  const parsedReq = ConvertOpportunityToProspectRequestSchema.safeParse({ workspace_id: workspaceId });
+  if (!parsedReq.success) {
+    return { data: null, error: parsedReq.error.errors[0]?.message ?? "Dados inválidos" };
+  }
+
+  try {
+    const raw = await apiFetch<ConvertOpportunityToProspectResponse>(
+      `/api/v1/opportunities/${opportunityId}/convert`,
+      {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(parsedReq.data),
+      }
+    );
+    const parsed = ConvertOpportunityToProspectResponseSchema.parse(raw);
+    revalidatePath("/app/opportunities");
+    revalidatePath("/app/prospects");
+    return { data: parsed, error: null };
+  } catch (err) {
+    console.error("convertOpportunityToProspectAction error:", err);
+    return { data: null, error: err instanceof Error ? err.message : "Unknown error" };
+  }
+}
+
+export async function getOpportunityHistoryAction(
+  opportunityId: string
+): Promise<{ data: OpportunityHistoryResponse | null; error: string | null }> {
+  try {
+    const raw = await apiFetch<OpportunityHistoryResponse>(`/api/v1/opportunities/${opportunityId}/history`);
+    const parsed = OpportunityHistoryResponseSchema.parse(raw);
+    return { data: parsed, error: null };
+  } catch (err) {
+    console.error("getOpportunityHistoryAction error:", err);
+    return { data: null, error: err instanceof Error ? err.message : "Unknown error" };
+  }
+}
+
+export async function listOpportunitySavedSearchesAction(
+  workspaceId: string
+): Promise<{ data: ListOpportunitySavedSearchesResponse | null; error: string | null }> {
+  try {
+    const params = new URLSearchParams({ workspace_id: workspaceId });
+    const raw = await apiFetch<ListOpportunitySavedSearchesResponse>(
+      `/api/v1/opportunities/saved-searches?${params.toString()}`
+    );
+    const parsed = ListOpportunitySavedSearchesResponseSchema.parse(raw);
+    return { data: parsed, error: null };
+  } catch (err) {
+    console.error("listOpportunitySavedSearchesAction error:", err);
+    return { data: null, error: err instanceof Error ? err.message : "Unknown error" };
+  }
+}
+
+export async function createOpportunitySavedSearchAction(
+  input: CreateOpportunitySavedSearchRequest
+): Promise<{ data: OpportunitySavedSearch | null; error: string | null }> {
+  const parsedReq = CreateOpportunitySavedSearchRequestSchema.safeParse(input);
+  if (!parsedReq.success) {
+    return { data: null, error: parsedReq.error.errors[0]?.message ?? "Dados inválidos" };
+  }
+
+  try {
+    const raw = await apiFetch<OpportunitySavedSearch>("/api/v1/opportunities/saved-searches", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(parsedReq.data),
+    });
+    const parsed = OpportunitySavedSearchSchema.parse(raw);
+    revalidatePath("/app/opportunities");
+    return { data: parsed, error: null };
+  } catch (err) {
+    console.error("createOpportunitySavedSearchAction error:", err);
+    return { data: null, error: err instanceof Error ? err.message : "Unknown error" };
+  }
+}
+
+export async function updateOpportunitySavedSearchAction(
+  savedSearchId: string,
+  input: UpdateOpportunitySavedSearchRequest
+): Promise<{ data: OpportunitySavedSearch | null; error: string | null }> {
+  const parsedReq = UpdateOpportunitySavedSearchRequestSchema.safeParse(input);
+  if (!parsedReq.success) {
+    return { data: null, error: parsedReq.error.errors[0]?.message ?? "Dados inválidos" };
+  }
+
+  try {
+    const raw = await apiFetch<OpportunitySavedSearch>(`/api/v1/opportunities/saved-searches/${savedSearchId}`, {
+      method: "PATCH",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(parsedReq.data),
+    });
+    const parsed = OpportunitySavedSearchSchema.parse(raw);
+    revalidatePath("/app/opportunities");
+    return { data: parsed, error: null };
+  } catch (err) {
+    console.error("updateOpportunitySavedSearchAction error:", err);
+    return { data: null, error: err instanceof Error ? err.message : "Unknown error" };
+  }
+}
+
+export async function deleteOpportunitySavedSearchAction(
+  savedSearchId: string
+): Promise<{ error: string | null }> {
+  try {
+    await apiFetch<void>(`/api/v1/opportunities/saved-searches/${savedSearchId}`, { method: "DELETE" });
+    revalidatePath("/app/opportunities");
+    return { error: null };
+  } catch (err) {
+    console.error("deleteOpportunitySavedSearchAction error:", err);
+    return { error: err instanceof Error ? err.message : "Unknown error" };
+  }
+}
+
+export async function listOpportunityAlertsAction(
+  workspaceId: string,
+  options: { unreadOnly?: boolean; limit?: number } = {}
+): Promise<{ data: ListOpportunityAlertsResponse | null; error: string | null }> {
+  try {
+    const params = new URLSearchParams({ workspace_id: workspaceId });
+    if (options.unreadOnly) params.set("unread", "true");
+    if (options.limit !== undefined) params.set("limit", String(options.limit));
+
+    const raw = await apiFetch<ListOpportunityAlertsResponse>(`/api/v1/opportunities/alerts?${params.toString()}`);
+    const parsed = ListOpportunityAlertsResponseSchema.parse(raw);
+    return { data: parsed, error: null };
+  } catch (err) {
+    console.error("listOpportunityAlertsAction error:", err);
+    return { data: null, error: err instanceof Error ? err.message : "Unknown error" };
+  }
+}
+
+export async function markOpportunityAlertsReadAction(
+  workspaceId: string,
+  alertIds?: string[]
+): Promise<{ data: MarkOpportunityAlertsReadResponse | null; error: string | null }> {
+  try {
+    const raw = await apiFetch<MarkOpportunityAlertsReadResponse>("/api/v1/opportunities/alerts/read", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ workspace_id: workspaceId, ids: alertIds ?? [] }),
+    });
+    const parsed = MarkOpportunityAlertsReadResponseSchema.parse(raw);
+    revalidatePath("/app/opportunities");
+    return { data: parsed, error: null };
+  } catch (err) {
+    console.error("markOpportunityAlertsReadAction error:", err);
+    return { data: null, error: err instanceof Error ? err.message : "Unknown error" };
+  }
+}
+
 export async function listJobRuns(
   limit = 20
 ): Promise<{ data: ListJobRunsResponse | null; error: string | null }> {
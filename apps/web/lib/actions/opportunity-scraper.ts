@@ -8,6 +8,7 @@ import {
   RunOpportunityScraperResponseSchema,
   UpsertOpportunityScraperPlaceholderRequestSchema,
   type OpportunityScraperPlaceholder,
+  type OpportunityScraperSource,
   type RunOpportunityScraperResponse,
   type UpsertOpportunityScraperPlaceholderRequest,
 } from "@widia/shared";
@@ -85,6 +86,7 @@ export async function runOpportunityScraper(input: {
   city?: string;
   neighborhood?: string;
   placeholder_id?: string;
+  sources?: OpportunityScraperSource[];
   limit?: number;
   dry_run?: boolean;
 }): Promise<RunOpportunityScraperResponse> {
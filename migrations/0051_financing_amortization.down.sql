@@ -0,0 +1,13 @@
+SET search_path TO flip, public;
+
+ALTER TABLE analysis_financing_snapshots DROP COLUMN IF EXISTS schedule_json;
+
+ALTER TABLE financing_plans DROP CONSTRAINT IF EXISTS chk_financing_plans_exit_month_non_negative;
+ALTER TABLE financing_plans DROP CONSTRAINT IF EXISTS chk_financing_plans_correction_index;
+ALTER TABLE financing_plans DROP CONSTRAINT IF EXISTS chk_financing_plans_amortization_system;
+
+ALTER TABLE financing_plans
+  DROP COLUMN IF EXISTS exit_month,
+  DROP COLUMN IF EXISTS correction_rate,
+  DROP COLUMN IF EXISTS correction_index,
+  DROP COLUMN IF EXISTS amortization_system;
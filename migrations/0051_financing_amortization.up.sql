@@ -0,0 +1,24 @@
+SET search_path TO flip, public;
+
+-- Amortization engine inputs (SAC / Tabela Price + TR/IPCA correction)
+ALTER TABLE financing_plans
+  ADD COLUMN IF NOT EXISTS amortization_system TEXT NULL,
+  ADD COLUMN IF NOT EXISTS correction_index TEXT NULL,
+  ADD COLUMN IF NOT EXISTS correction_rate NUMERIC NULL,
+  ADD COLUMN IF NOT EXISTS exit_month INTEGER NULL;
+
+ALTER TABLE financing_plans
+  ADD CONSTRAINT chk_financing_plans_amortization_system
+  CHECK (amortization_system IS NULL OR amortization_system IN ('sac', 'price'));
+
+ALTER TABLE financing_plans
+  ADD CONSTRAINT chk_financing_plans_correction_index
+  CHECK (correction_index IS NULL OR correction_index IN ('none', 'tr', 'ipca'));
+
+ALTER TABLE financing_plans
+  ADD CONSTRAINT chk_financing_plans_exit_month_non_negative
+  CHECK (exit_month IS NULL OR exit_month >= 0);
+
+-- Derived amortization schedule captured at snapshot time
+ALTER TABLE analysis_financing_snapshots
+  ADD COLUMN IF NOT EXISTS schedule_json JSONB NULL;
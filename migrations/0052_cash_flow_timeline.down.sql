@@ -0,0 +1,18 @@
+SET search_path TO flip, public;
+
+ALTER TABLE analysis_financing_snapshots DROP COLUMN IF EXISTS cash_flow_json;
+ALTER TABLE analysis_cash_snapshots DROP COLUMN IF EXISTS cash_flow_json;
+
+ALTER TABLE financing_plans
+  DROP COLUMN IF EXISTS iptu,
+  DROP COLUMN IF EXISTS condo_fee;
+
+ALTER TABLE analysis_cash_inputs DROP CONSTRAINT IF EXISTS chk_analysis_cash_inputs_hold_months_positive;
+
+ALTER TABLE analysis_cash_inputs
+  DROP COLUMN IF EXISTS iptu,
+  DROP COLUMN IF EXISTS condo_fee,
+  DROP COLUMN IF EXISTS hold_months;
+
+ALTER TABLE workspace_settings DROP CONSTRAINT IF EXISTS chk_workspace_settings_discount_rate;
+ALTER TABLE workspace_settings DROP COLUMN IF EXISTS discount_rate;
@@ -0,0 +1,31 @@
+SET search_path TO flip, public;
+
+-- Discount rate used for NPV on the cash-flow timeline (annual decimal, 0.12 = 12% a.a.)
+ALTER TABLE workspace_settings
+  ADD COLUMN IF NOT EXISTS discount_rate NUMERIC NOT NULL DEFAULT 0.12;
+
+ALTER TABLE workspace_settings
+  ADD CONSTRAINT chk_workspace_settings_discount_rate
+  CHECK (discount_rate >= 0 AND discount_rate <= 1);
+
+-- Timing and carry assumptions for the cash analysis
+ALTER TABLE analysis_cash_inputs
+  ADD COLUMN IF NOT EXISTS hold_months INTEGER NULL,
+  ADD COLUMN IF NOT EXISTS condo_fee NUMERIC NULL,
+  ADD COLUMN IF NOT EXISTS iptu NUMERIC NULL;
+
+ALTER TABLE analysis_cash_inputs
+  ADD CONSTRAINT chk_analysis_cash_inputs_hold_months_positive
+  CHECK (hold_months IS NULL OR hold_months > 0);
+
+-- Carry assumptions for the financing analysis (hold period comes from exit_month)
+ALTER TABLE financing_plans
+  ADD COLUMN IF NOT EXISTS condo_fee NUMERIC NULL,
+  ADD COLUMN IF NOT EXISTS iptu NUMERIC NULL;
+
+-- Cash-flow timeline and IRR/NPV captured at snapshot time
+ALTER TABLE analysis_cash_snapshots
+  ADD COLUMN IF NOT EXISTS cash_flow_json JSONB NULL;
+
+ALTER TABLE analysis_financing_snapshots
+  ADD COLUMN IF NOT EXISTS cash_flow_json JSONB NULL;
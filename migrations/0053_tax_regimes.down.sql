@@ -0,0 +1,18 @@
+SET search_path TO flip, public;
+
+ALTER TABLE property_tax_rates DROP CONSTRAINT IF EXISTS chk_property_tax_rates_tax_regime;
+
+ALTER TABLE property_tax_rates
+  DROP COLUMN IF EXISTS tax_pf_reinvested_pct,
+  DROP COLUMN IF EXISTS tax_ret_rate,
+  DROP COLUMN IF EXISTS tax_simples_rate,
+  DROP COLUMN IF EXISTS tax_regime;
+
+ALTER TABLE workspace_settings DROP CONSTRAINT IF EXISTS chk_workspace_settings_tax_params;
+ALTER TABLE workspace_settings DROP CONSTRAINT IF EXISTS chk_workspace_settings_tax_regime;
+
+ALTER TABLE workspace_settings
+  DROP COLUMN IF EXISTS tax_pf_reinvested_pct,
+  DROP COLUMN IF EXISTS tax_ret_rate,
+  DROP COLUMN IF EXISTS tax_simples_rate,
+  DROP COLUMN IF EXISTS tax_regime;
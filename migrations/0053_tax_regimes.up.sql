@@ -0,0 +1,32 @@
+SET search_path TO flip, public;
+
+-- Tax regime engine: workspace default regime and its parameters.
+-- 'flat' keeps the legacy pj_tax_rate applied to gross profit.
+ALTER TABLE workspace_settings
+  ADD COLUMN IF NOT EXISTS tax_regime TEXT NOT NULL DEFAULT 'flat',
+  ADD COLUMN IF NOT EXISTS tax_simples_rate NUMERIC NULL,
+  ADD COLUMN IF NOT EXISTS tax_ret_rate NUMERIC NULL,
+  ADD COLUMN IF NOT EXISTS tax_pf_reinvested_pct NUMERIC NULL;
+
+ALTER TABLE workspace_settings
+  ADD CONSTRAINT chk_workspace_settings_tax_regime
+  CHECK (tax_regime IN ('flat', 'pf', 'lucro_presumido', 'ret', 'simples'));
+
+ALTER TABLE workspace_settings
+  ADD CONSTRAINT chk_workspace_settings_tax_params
+  CHECK (
+    (tax_simples_rate IS NULL OR (tax_simples_rate >= 0 AND tax_simples_rate <= 1)) AND
+    (tax_ret_rate IS NULL OR (tax_ret_rate >= 0 AND tax_ret_rate <= 1)) AND
+    (tax_pf_reinvested_pct IS NULL OR (tax_pf_reinvested_pct >= 0 AND tax_pf_reinvested_pct <= 1))
+  );
+
+-- Property-level overrides (NULL = use workspace setting)
+ALTER TABLE property_tax_rates
+  ADD COLUMN IF NOT EXISTS tax_regime TEXT NULL,
+  ADD COLUMN IF NOT EXISTS tax_simples_rate NUMERIC NULL,
+  ADD COLUMN IF NOT EXISTS tax_ret_rate NUMERIC NULL,
+  ADD COLUMN IF NOT EXISTS tax_pf_reinvested_pct NUMERIC NULL;
+
+ALTER TABLE property_tax_rates
+  ADD CONSTRAINT chk_property_tax_rates_tax_regime
+  CHECK (tax_regime IS NULL OR tax_regime IN ('flat', 'pf', 'lucro_presumido', 'ret', 'simples'));
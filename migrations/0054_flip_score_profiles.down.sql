@@ -0,0 +1,3 @@
+SET search_path TO flip, public;
+
+DROP TABLE IF EXISTS flip_score_profiles;
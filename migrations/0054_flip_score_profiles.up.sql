@@ -0,0 +1,31 @@
+SET search_path TO flip, public;
+
+-- Flip Score scoring profile per workspace (no row = built-in default profile).
+-- v0/v2 and v1 component weights must each sum to 1; red-flag category weights and
+-- rehab penalties are stored as JSON maps merged over the defaults.
+CREATE TABLE IF NOT EXISTS flip_score_profiles (
+  workspace_id uuid PRIMARY KEY REFERENCES workspaces(id) ON DELETE CASCADE,
+  name TEXT NOT NULL DEFAULT 'custom',
+  weight_price NUMERIC NOT NULL,
+  weight_carry NUMERIC NOT NULL,
+  weight_liquidity NUMERIC NOT NULL,
+  weight_risk NUMERIC NOT NULL,
+  weight_v1_econ NUMERIC NOT NULL,
+  weight_v1_liquidity NUMERIC NOT NULL,
+  weight_v1_risk NUMERIC NOT NULL,
+  category_weights JSONB NOT NULL DEFAULT '{}'::jsonb,
+  rehab_penalties JSONB NOT NULL DEFAULT '{}'::jsonb,
+  profile_hash TEXT NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  CONSTRAINT chk_flip_score_profiles_weights_non_negative CHECK (
+    weight_price >= 0 AND weight_carry >= 0 AND weight_liquidity >= 0 AND weight_risk >= 0 AND
+    weight_v1_econ >= 0 AND weight_v1_liquidity >= 0 AND weight_v1_risk >= 0
+  ),
+  CONSTRAINT chk_flip_score_profiles_v0_weights_sum CHECK (
+    ABS(weight_price + weight_carry + weight_liquidity + weight_risk - 1) < 0.0001
+  ),
+  CONSTRAINT chk_flip_score_profiles_v1_weights_sum CHECK (
+    ABS(weight_v1_econ + weight_v1_liquidity + weight_v1_risk - 1) < 0.0001
+  )
+);
@@ -0,0 +1,5 @@
+SET search_path TO flip, public;
+
+DROP INDEX IF EXISTS idx_flip_score_outcomes_version;
+DROP INDEX IF EXISTS idx_flip_score_outcomes_workspace_sold;
+DROP TABLE IF EXISTS flip_score_outcomes;
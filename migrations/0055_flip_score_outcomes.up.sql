@@ -0,0 +1,51 @@
+SET search_path TO flip, public;
+
+-- Flip Score backtesting: the score a prospect had when it was converted, and the realized
+-- outcome once the resulting property is sold. Rates/ROI follow the viability units (ROI in %).
+CREATE TABLE IF NOT EXISTS flip_score_outcomes (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  workspace_id uuid NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+  prospect_id uuid NOT NULL REFERENCES prospecting_properties(id) ON DELETE CASCADE,
+  property_id uuid NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+  score INT NULL,
+  score_version TEXT NULL,
+  score_confidence NUMERIC NULL,
+  score_breakdown JSONB NULL,
+  scored_at timestamptz NULL,
+  predicted_purchase_price NUMERIC NULL,
+  predicted_sale_price NUMERIC NULL,
+  converted_at timestamptz NOT NULL DEFAULT now(),
+  realized_sale_price NUMERIC NULL,
+  realized_net_profit NUMERIC NULL,
+  realized_roi NUMERIC NULL,
+  realized_hold_days INT NULL,
+  net_profit_source TEXT NULL,
+  sold_at timestamptz NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  CONSTRAINT uq_flip_score_outcomes_property UNIQUE (property_id),
+  CONSTRAINT chk_flip_score_outcomes_net_profit_source CHECK (
+    net_profit_source IS NULL OR net_profit_source IN ('reported', 'cash_analysis')
+  ),
+  CONSTRAINT chk_flip_score_outcomes_hold_days CHECK (realized_hold_days IS NULL OR realized_hold_days >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flip_score_outcomes_workspace_sold
+  ON flip_score_outcomes (workspace_id, sold_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_flip_score_outcomes_version
+  ON flip_score_outcomes (score_version)
+  WHERE sold_at IS NOT NULL;
+
+-- Backfill already converted prospects with their current score
+INSERT INTO flip_score_outcomes (
+  workspace_id, prospect_id, property_id,
+  score, score_version, score_confidence, score_breakdown, scored_at,
+  predicted_purchase_price, predicted_sale_price, converted_at
+)
+SELECT pr.workspace_id, pp.id, pr.id,
+       pp.flip_score, pp.flip_score_version, pp.flip_score_confidence, pp.flip_score_breakdown, pp.flip_score_updated_at,
+       COALESCE(pp.offer_price, pp.asking_price), pp.expected_sale_price, pr.created_at
+FROM properties pr
+JOIN prospecting_properties pp ON pp.id = pr.origin_prospect_id
+ON CONFLICT (property_id) DO NOTHING;
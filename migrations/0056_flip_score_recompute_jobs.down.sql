@@ -0,0 +1,10 @@
+SET search_path TO flip, public;
+
+DROP INDEX IF EXISTS idx_flip_score_recompute_job_items_pending;
+DROP TABLE IF EXISTS flip_score_recompute_job_items;
+DROP INDEX IF EXISTS idx_flip_score_recompute_jobs_workspace;
+DROP INDEX IF EXISTS uq_flip_score_recompute_jobs_active;
+DROP TABLE IF EXISTS flip_score_recompute_jobs;
+
+ALTER TABLE prospecting_properties
+  DROP COLUMN IF EXISTS flip_score_listing_hash;
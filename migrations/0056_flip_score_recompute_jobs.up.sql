@@ -0,0 +1,59 @@
+SET search_path TO flip, public;
+
+-- Hash of the listing_text the stored LLM risk assessment was extracted from, so bulk
+-- recomputes can reuse the assessment while the text is unchanged
+ALTER TABLE prospecting_properties
+  ADD COLUMN IF NOT EXISTS flip_score_listing_hash TEXT NULL;
+
+-- Workspace-wide Flip Score recompute jobs (processed in background, resumable)
+CREATE TABLE IF NOT EXISTS flip_score_recompute_jobs (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  workspace_id uuid NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'queued',
+  force_version TEXT NULL,
+  triggered_by TEXT NULL,
+  total_items INT NOT NULL DEFAULT 0,
+  processed_items INT NOT NULL DEFAULT 0,
+  failed_items INT NOT NULL DEFAULT 0,
+  llm_calls INT NOT NULL DEFAULT 0,
+  llm_cache_hits INT NOT NULL DEFAULT 0,
+  error_message TEXT NULL,
+  started_at timestamptz NULL,
+  finished_at timestamptz NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  CONSTRAINT chk_flip_score_recompute_jobs_status CHECK (
+    status IN ('queued', 'running', 'completed', 'failed')
+  ),
+  CONSTRAINT chk_flip_score_recompute_jobs_force_version CHECK (
+    force_version IS NULL OR force_version IN ('v0', 'v2')
+  )
+);
+
+-- At most one active job per workspace
+CREATE UNIQUE INDEX IF NOT EXISTS uq_flip_score_recompute_jobs_active
+  ON flip_score_recompute_jobs (workspace_id)
+  WHERE status IN ('queued', 'running');
+
+CREATE INDEX IF NOT EXISTS idx_flip_score_recompute_jobs_workspace
+  ON flip_score_recompute_jobs (workspace_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS flip_score_recompute_job_items (
+  job_id uuid NOT NULL REFERENCES flip_score_recompute_jobs(id) ON DELETE CASCADE,
+  prospect_id uuid NOT NULL REFERENCES prospecting_properties(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'pending',
+  score INT NULL,
+  score_version TEXT NULL,
+  risk_source TEXT NULL,
+  error_message TEXT NULL,
+  processed_at timestamptz NULL,
+  PRIMARY KEY (job_id, prospect_id),
+  CONSTRAINT chk_flip_score_recompute_job_items_status CHECK (status IN ('pending', 'done', 'failed')),
+  CONSTRAINT chk_flip_score_recompute_job_items_risk_source CHECK (
+    risk_source IS NULL OR risk_source IN ('llm', 'cache', 'none')
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_flip_score_recompute_job_items_pending
+  ON flip_score_recompute_job_items (job_id)
+  WHERE status = 'pending';
@@ -0,0 +1,7 @@
+SET search_path TO flip, public;
+
+DROP INDEX IF EXISTS idx_offer_negotiation_rounds_negotiation;
+DROP TABLE IF EXISTS offer_negotiation_rounds;
+DROP INDEX IF EXISTS idx_offer_negotiations_workspace_prospect_created;
+DROP INDEX IF EXISTS uq_offer_negotiations_open_prospect;
+DROP TABLE IF EXISTS offer_negotiations;
@@ -0,0 +1,55 @@
+SET search_path TO flip, public;
+
+-- Offer negotiation with the broker/seller for a prospect: one open negotiation at a time
+CREATE TABLE IF NOT EXISTS offer_negotiations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  workspace_id UUID NOT NULL REFERENCES flip.workspaces(id) ON DELETE CASCADE,
+  prospect_id UUID NOT NULL REFERENCES flip.prospecting_properties(id) ON DELETE CASCADE,
+  property_id UUID NULL REFERENCES flip.properties(id) ON DELETE SET NULL,
+  status TEXT NOT NULL DEFAULT 'open',
+  asking_price NUMERIC(14,2) NULL,
+  created_by_user_id TEXT NOT NULL,
+  closed_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT chk_offer_negotiations_status CHECK (status IN ('open', 'accepted', 'rejected', 'withdrawn'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_offer_negotiations_open_prospect
+  ON offer_negotiations (prospect_id)
+  WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS idx_offer_negotiations_workspace_prospect_created
+  ON offer_negotiations (workspace_id, prospect_id, created_at DESC);
+
+-- Offers sent, broker counteroffers and seller responses. Priced rounds keep the offer
+-- intelligence re-evaluation at that price.
+CREATE TABLE IF NOT EXISTS offer_negotiation_rounds (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  negotiation_id UUID NOT NULL REFERENCES flip.offer_negotiations(id) ON DELETE CASCADE,
+  workspace_id UUID NOT NULL REFERENCES flip.workspaces(id) ON DELETE CASCADE,
+  round_number INT NOT NULL,
+  event_type TEXT NOT NULL,
+  price NUMERIC(14,2) NULL,
+  seller_response TEXT NULL,
+  notes TEXT NULL,
+  occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  decision TEXT NULL,
+  ceiling_offer NUMERIC(14,2) NULL,
+  room_to_ceiling NUMERIC(14,2) NULL,
+  evaluation_json JSONB NULL,
+  created_by_user_id TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT chk_offer_negotiation_rounds_event_type CHECK (
+    event_type IN ('offer_sent', 'counteroffer', 'seller_response', 'withdrawn')
+  ),
+  CONSTRAINT chk_offer_negotiation_rounds_seller_response CHECK (
+    seller_response IS NULL OR seller_response IN ('accepted', 'rejected', 'countered')
+  ),
+  CONSTRAINT chk_offer_negotiation_rounds_decision CHECK (decision IS NULL OR decision IN ('GO', 'REVIEW', 'NO_GO')),
+  CONSTRAINT chk_offer_negotiation_rounds_price CHECK (price IS NULL OR price > 0),
+  CONSTRAINT chk_offer_negotiation_rounds_round_number CHECK (round_number > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_offer_negotiation_rounds_negotiation
+  ON offer_negotiation_rounds (negotiation_id, occurred_at, created_at);
@@ -0,0 +1,8 @@
+SET search_path TO flip, public;
+
+ALTER TABLE workspace_settings DROP CONSTRAINT IF EXISTS chk_workspace_settings_offer_decision_rules_array;
+
+ALTER TABLE workspace_settings
+  DROP COLUMN IF EXISTS offer_decision_rules_updated_at,
+  DROP COLUMN IF EXISTS offer_decision_rules_version,
+  DROP COLUMN IF EXISTS offer_decision_rules_json;
@@ -0,0 +1,11 @@
+SET search_path TO flip, public;
+
+-- Workspace-editable GO/REVIEW/NO_GO decision rules (NULL = default rule set)
+ALTER TABLE workspace_settings
+  ADD COLUMN IF NOT EXISTS offer_decision_rules_json JSONB NULL,
+  ADD COLUMN IF NOT EXISTS offer_decision_rules_version INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS offer_decision_rules_updated_at TIMESTAMPTZ NULL;
+
+ALTER TABLE workspace_settings
+  ADD CONSTRAINT chk_workspace_settings_offer_decision_rules_array
+  CHECK (offer_decision_rules_json IS NULL OR jsonb_typeof(offer_decision_rules_json) = 'array');
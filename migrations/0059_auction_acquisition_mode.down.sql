@@ -0,0 +1,12 @@
+SET search_path TO flip, public;
+
+ALTER TABLE analysis_cash_inputs DROP CONSTRAINT IF EXISTS chk_analysis_cash_inputs_acquisition_mode;
+ALTER TABLE prospecting_properties DROP CONSTRAINT IF EXISTS chk_prospecting_properties_acquisition_mode;
+
+ALTER TABLE analysis_cash_inputs
+  DROP COLUMN IF EXISTS auction_terms_json,
+  DROP COLUMN IF EXISTS acquisition_mode;
+
+ALTER TABLE prospecting_properties
+  DROP COLUMN IF EXISTS auction_terms_json,
+  DROP COLUMN IF EXISTS acquisition_mode;
@@ -0,0 +1,19 @@
+SET search_path TO flip, public;
+
+-- Auction (leilão) acquisition mode: the asking/purchase price is the bid and the notice
+-- terms (commission, arrears, eviction, payment terms) are stored as JSON
+ALTER TABLE prospecting_properties
+  ADD COLUMN IF NOT EXISTS acquisition_mode TEXT NOT NULL DEFAULT 'market',
+  ADD COLUMN IF NOT EXISTS auction_terms_json JSONB NULL;
+
+ALTER TABLE prospecting_properties
+  ADD CONSTRAINT chk_prospecting_properties_acquisition_mode
+  CHECK (acquisition_mode IN ('market', 'auction'));
+
+ALTER TABLE analysis_cash_inputs
+  ADD COLUMN IF NOT EXISTS acquisition_mode TEXT NOT NULL DEFAULT 'market',
+  ADD COLUMN IF NOT EXISTS auction_terms_json JSONB NULL;
+
+ALTER TABLE analysis_cash_inputs
+  ADD CONSTRAINT chk_analysis_cash_inputs_acquisition_mode
+  CHECK (acquisition_mode IN ('market', 'auction'));
@@ -0,0 +1,8 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+ALTER TABLE opportunity_scraper_placeholders
+  DROP CONSTRAINT IF EXISTS chk_opportunity_scraper_placeholders_sources;
+
+ALTER TABLE opportunity_scraper_placeholders
+  DROP COLUMN IF EXISTS sources;
@@ -0,0 +1,10 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Portais coletados por placeholder (ZAP, VIVAREAL, OLX)
+ALTER TABLE opportunity_scraper_placeholders
+  ADD COLUMN sources JSONB NOT NULL DEFAULT '["ZAP"]'::jsonb;
+
+ALTER TABLE opportunity_scraper_placeholders
+  ADD CONSTRAINT chk_opportunity_scraper_placeholders_sources
+  CHECK (jsonb_typeof(sources) = 'array' AND jsonb_array_length(sources) > 0);
@@ -0,0 +1,11 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+DROP INDEX IF EXISTS idx_source_listings_city_area;
+DROP INDEX IF EXISTS idx_source_listings_cluster_id;
+
+ALTER TABLE source_listings
+  DROP COLUMN IF EXISTS cluster_id;
+
+ALTER TABLE source_listings
+  DROP COLUMN IF EXISTS image_hashes;
@@ -0,0 +1,18 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Hashes perceptuais (dHash, hex) das primeiras fotos do anúncio
+ALTER TABLE source_listings
+  ADD COLUMN image_hashes JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Anúncios do mesmo imóvel (portais/imobiliárias diferentes) compartilham cluster_id.
+-- O valor é o id de um dos membros; sem FK para não desfazer o grupo quando ele sai.
+ALTER TABLE source_listings
+  ADD COLUMN cluster_id UUID NULL;
+
+CREATE INDEX idx_source_listings_cluster_id
+  ON source_listings (cluster_id)
+  WHERE cluster_id IS NOT NULL;
+
+CREATE INDEX idx_source_listings_city_area
+  ON source_listings (LOWER(city), area_m2);
@@ -0,0 +1,15 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+DROP TABLE IF EXISTS opportunity_status_changes;
+
+DROP INDEX IF EXISTS idx_source_listings_delisted_at;
+
+ALTER TABLE source_listings
+  DROP COLUMN IF EXISTS delisted_at,
+  DROP COLUMN IF EXISTS missed_runs,
+  DROP COLUMN IF EXISTS last_seen_job_run_id,
+  DROP COLUMN IF EXISTS last_price_drop_at,
+  DROP COLUMN IF EXISTS peak_price_cents;
+
+DROP TABLE IF EXISTS source_listing_price_history;
@@ -0,0 +1,54 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Histórico de preços append-only: uma linha por preço observado (a primeira
+-- observação tem previous_price_cents nulo)
+CREATE TABLE source_listing_price_history (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  source_listing_id UUID NOT NULL REFERENCES source_listings(id) ON DELETE CASCADE,
+  price_cents BIGINT NOT NULL,
+  previous_price_cents BIGINT NULL,
+  job_run_id UUID NULL REFERENCES opportunity_job_runs(id) ON DELETE SET NULL,
+  observed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT chk_source_listing_price_history_price CHECK (price_cents > 0)
+);
+
+CREATE INDEX idx_source_listing_price_history_listing
+  ON source_listing_price_history (source_listing_id, observed_at DESC);
+
+INSERT INTO source_listing_price_history (source_listing_id, price_cents, observed_at)
+SELECT id, price_cents, first_seen_at
+FROM source_listings
+WHERE price_cents > 0;
+
+-- Rastreamento por execução e detecção de anúncios retirados
+ALTER TABLE source_listings
+  ADD COLUMN peak_price_cents BIGINT NULL,
+  ADD COLUMN last_price_drop_at TIMESTAMPTZ NULL,
+  ADD COLUMN last_seen_job_run_id UUID NULL REFERENCES opportunity_job_runs(id) ON DELETE SET NULL,
+  ADD COLUMN missed_runs INT NOT NULL DEFAULT 0,
+  ADD COLUMN delisted_at TIMESTAMPTZ NULL;
+
+UPDATE source_listings SET peak_price_cents = price_cents WHERE price_cents > 0;
+
+CREATE INDEX idx_source_listings_delisted_at
+  ON source_listings (delisted_at)
+  WHERE delisted_at IS NOT NULL;
+
+-- Mudanças de status da oportunidade (usuário) e do anúncio (retirado/relistado)
+CREATE TABLE opportunity_status_changes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  opportunity_id UUID NOT NULL REFERENCES opportunities(id) ON DELETE CASCADE,
+  change_type VARCHAR(20) NOT NULL,
+  from_value VARCHAR(50) NULL,
+  to_value VARCHAR(50) NOT NULL,
+  changed_by VARCHAR(255) NULL,
+  job_run_id UUID NULL REFERENCES opportunity_job_runs(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT chk_opportunity_status_changes_type CHECK (change_type IN ('status', 'listing_status'))
+);
+
+CREATE INDEX idx_opportunity_status_changes_opportunity
+  ON opportunity_status_changes (opportunity_id, created_at DESC);
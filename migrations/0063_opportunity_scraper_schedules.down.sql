@@ -0,0 +1,20 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+DROP INDEX IF EXISTS idx_opportunity_scraper_placeholders_next_run_at;
+
+ALTER TABLE opportunity_scraper_placeholders
+  DROP CONSTRAINT IF EXISTS chk_opportunity_scraper_placeholders_limit,
+  DROP CONSTRAINT IF EXISTS chk_opportunity_scraper_placeholders_jitter,
+  DROP CONSTRAINT IF EXISTS chk_opportunity_scraper_placeholders_interval,
+  DROP CONSTRAINT IF EXISTS chk_opportunity_scraper_placeholders_schedule;
+
+ALTER TABLE opportunity_scraper_placeholders
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS consecutive_failures,
+  DROP COLUMN IF EXISTS next_run_at,
+  DROP COLUMN IF EXISTS schedule_limit,
+  DROP COLUMN IF EXISTS schedule_jitter_seconds,
+  DROP COLUMN IF EXISTS schedule_interval_minutes,
+  DROP COLUMN IF EXISTS schedule_cron,
+  DROP COLUMN IF EXISTS schedule_enabled;
@@ -0,0 +1,37 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Agendamento por placeholder: expressão cron OU intervalo fixo, com jitter.
+-- next_run_at é recalculado a cada execução; falhas consecutivas aplicam backoff.
+ALTER TABLE opportunity_scraper_placeholders
+  ADD COLUMN schedule_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN schedule_cron VARCHAR(100) NULL,
+  ADD COLUMN schedule_interval_minutes INT NULL,
+  ADD COLUMN schedule_jitter_seconds INT NOT NULL DEFAULT 0,
+  ADD COLUMN schedule_limit INT NULL,
+  ADD COLUMN next_run_at TIMESTAMPTZ NULL,
+  ADD COLUMN consecutive_failures INT NOT NULL DEFAULT 0,
+  ADD COLUMN last_error TEXT NULL;
+
+ALTER TABLE opportunity_scraper_placeholders
+  ADD CONSTRAINT chk_opportunity_scraper_placeholders_schedule
+  CHECK (
+    NOT (schedule_cron IS NOT NULL AND schedule_interval_minutes IS NOT NULL)
+    AND (NOT schedule_enabled OR schedule_cron IS NOT NULL OR schedule_interval_minutes IS NOT NULL)
+  );
+
+ALTER TABLE opportunity_scraper_placeholders
+  ADD CONSTRAINT chk_opportunity_scraper_placeholders_interval
+  CHECK (schedule_interval_minutes IS NULL OR schedule_interval_minutes >= 15);
+
+ALTER TABLE opportunity_scraper_placeholders
+  ADD CONSTRAINT chk_opportunity_scraper_placeholders_jitter
+  CHECK (schedule_jitter_seconds BETWEEN 0 AND 3600);
+
+ALTER TABLE opportunity_scraper_placeholders
+  ADD CONSTRAINT chk_opportunity_scraper_placeholders_limit
+  CHECK (schedule_limit IS NULL OR schedule_limit BETWEEN 1 AND 200);
+
+CREATE INDEX idx_opportunity_scraper_placeholders_next_run_at
+  ON opportunity_scraper_placeholders (next_run_at)
+  WHERE schedule_enabled;
@@ -0,0 +1,6 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+DROP TABLE IF EXISTS opportunity_alerts;
+
+DROP TABLE IF EXISTS opportunity_saved_searches;
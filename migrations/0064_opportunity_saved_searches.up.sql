@@ -0,0 +1,53 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Buscas salvas de oportunidades: filtros nomeados por usuário dentro do workspace.
+-- filters usa as mesmas chaves da query de GET /opportunities.
+CREATE TABLE opportunity_saved_searches (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL,
+  name VARCHAR(120) NOT NULL,
+  filters JSONB NOT NULL DEFAULT '{}'::jsonb,
+  alerts_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  email_digest BOOLEAN NOT NULL DEFAULT FALSE,
+  last_alerted_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT uq_opportunity_saved_searches_name UNIQUE (workspace_id, user_id, name)
+);
+
+CREATE INDEX idx_opportunity_saved_searches_user
+  ON opportunity_saved_searches (user_id, workspace_id);
+
+CREATE INDEX idx_opportunity_saved_searches_alerts
+  ON opportunity_saved_searches (id)
+  WHERE alerts_enabled;
+
+-- Alertas in-app: anúncio novo ou com redução de preço que casou com uma busca salva.
+-- emailed_at marca os alertas já enviados no digest por email.
+CREATE TABLE opportunity_alerts (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  saved_search_id UUID NOT NULL REFERENCES opportunity_saved_searches(id) ON DELETE CASCADE,
+  workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL,
+  opportunity_id UUID NOT NULL REFERENCES opportunities(id) ON DELETE CASCADE,
+  reason VARCHAR(20) NOT NULL,
+  price_cents BIGINT NULL,
+  previous_price_cents BIGINT NULL,
+  job_run_id UUID NULL REFERENCES opportunity_job_runs(id) ON DELETE SET NULL,
+  read_at TIMESTAMPTZ NULL,
+  emailed_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT chk_opportunity_alerts_reason CHECK (reason IN ('new', 'price_drop')),
+  CONSTRAINT uq_opportunity_alerts_run UNIQUE (saved_search_id, opportunity_id, reason, job_run_id)
+);
+
+CREATE INDEX idx_opportunity_alerts_user
+  ON opportunity_alerts (user_id, created_at DESC);
+
+CREATE INDEX idx_opportunity_alerts_unread
+  ON opportunity_alerts (user_id, created_at DESC)
+  WHERE read_at IS NULL;
@@ -0,0 +1,7 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+DROP INDEX IF EXISTS uq_prospecting_properties_source_opportunity;
+
+ALTER TABLE prospecting_properties
+  DROP COLUMN IF EXISTS source_opportunity_id;
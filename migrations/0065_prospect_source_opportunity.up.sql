@@ -0,0 +1,11 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Prospect criado a partir de uma oportunidade do scraper (conversão em um clique).
+-- Uma oportunidade vira no máximo um prospect ativo por workspace.
+ALTER TABLE prospecting_properties
+  ADD COLUMN source_opportunity_id UUID NULL REFERENCES opportunities(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX uq_prospecting_properties_source_opportunity
+  ON prospecting_properties (workspace_id, source_opportunity_id)
+  WHERE source_opportunity_id IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,7 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+ALTER TABLE opportunities
+  DROP CONSTRAINT IF EXISTS chk_opportunities_parse_quality,
+  DROP COLUMN IF EXISTS field_provenance,
+  DROP COLUMN IF EXISTS parse_quality;
@@ -0,0 +1,9 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Qualidade do parse do anúncio (0-100) e origem de cada campo extraído
+-- (json_ld, next_data, hydration ou html). Nulo para ingestões anteriores.
+ALTER TABLE opportunities
+  ADD COLUMN parse_quality SMALLINT NULL,
+  ADD COLUMN field_provenance JSONB NOT NULL DEFAULT '{}'::jsonb,
+  ADD CONSTRAINT chk_opportunities_parse_quality CHECK (parse_quality IS NULL OR parse_quality BETWEEN 0 AND 100);
@@ -0,0 +1,5 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+ALTER TABLE market_transactions
+  DROP COLUMN IF EXISTS address;
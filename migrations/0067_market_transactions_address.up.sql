@@ -0,0 +1,7 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Endereço da transação quando a fonte informa (logradouro), preenchido pelos
+-- adaptadores de ingestão (XLSX/CSV). Nulo para ingestões anteriores.
+ALTER TABLE market_transactions
+  ADD COLUMN address TEXT NULL;
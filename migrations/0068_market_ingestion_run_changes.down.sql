@@ -0,0 +1,13 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+DROP TABLE IF EXISTS market_transactions_archive;
+DROP TABLE IF EXISTS market_ingestion_run_aggregate_changes;
+
+UPDATE market_ingestion_runs SET status = 'failed' WHERE status = 'rolled_back';
+
+ALTER TABLE market_ingestion_runs
+  DROP COLUMN IF EXISTS rolled_back_by,
+  DROP COLUMN IF EXISTS rolled_back_at,
+  DROP CONSTRAINT IF EXISTS market_ingestion_runs_status_check,
+  ADD CONSTRAINT market_ingestion_runs_status_check CHECK (status IN ('running', 'success', 'failed'));
@@ -0,0 +1,64 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Execuções revertidas continuam no histórico com status rolled_back
+ALTER TABLE market_ingestion_runs
+  DROP CONSTRAINT IF EXISTS market_ingestion_runs_status_check,
+  ADD CONSTRAINT market_ingestion_runs_status_check CHECK (status IN ('running', 'success', 'failed', 'rolled_back')),
+  ADD COLUMN rolled_back_at TIMESTAMPTZ NULL,
+  ADD COLUMN rolled_back_by TEXT NULL;
+
+-- Agregados inseridos, alterados ou removidos por cada execução, com os valores anteriores.
+-- previous_* nulo = linha inserida; new_* nulo = linha removida.
+CREATE TABLE market_ingestion_run_aggregate_changes (
+  run_id UUID NOT NULL REFERENCES market_ingestion_runs(id) ON DELETE CASCADE,
+  city TEXT NOT NULL,
+  source TEXT NOT NULL,
+  region_id UUID NOT NULL REFERENCES market_regions(id) ON DELETE RESTRICT,
+  region_type TEXT NOT NULL,
+  as_of_month DATE NOT NULL,
+  period_months INT NOT NULL,
+  property_class TEXT NOT NULL,
+  previous_median_m2 NUMERIC(16, 2) NULL,
+  previous_p25_m2 NUMERIC(16, 2) NULL,
+  previous_p75_m2 NUMERIC(16, 2) NULL,
+  previous_tx_count INT NULL,
+  new_median_m2 NUMERIC(16, 2) NULL,
+  new_p25_m2 NUMERIC(16, 2) NULL,
+  new_p75_m2 NUMERIC(16, 2) NULL,
+  new_tx_count INT NULL,
+  PRIMARY KEY (run_id, region_id, period_months, property_class)
+);
+
+-- Transações substituídas por uma reingestão (reason = replaced) ou descartadas por um
+-- rollback (reason = rolled_back). run_id é a execução que as gravou originalmente.
+CREATE TABLE market_transactions_archive (
+  id UUID PRIMARY KEY,
+  run_id UUID NULL,
+  archived_by_run_id UUID NOT NULL REFERENCES market_ingestion_runs(id) ON DELETE CASCADE,
+  reason TEXT NOT NULL CHECK (reason IN ('replaced', 'rolled_back')),
+  archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  city TEXT NOT NULL,
+  source TEXT NOT NULL,
+  month DATE NOT NULL,
+  region_id UUID NOT NULL,
+  region_name_raw TEXT NOT NULL,
+  region_name_normalized TEXT NOT NULL,
+  address TEXT NULL,
+  property_class TEXT NOT NULL,
+  sql_registration TEXT NULL,
+  transaction_date DATE NULL,
+  transaction_value NUMERIC(16, 2) NOT NULL,
+  area_m2 NUMERIC(12, 2) NOT NULL,
+  price_m2 NUMERIC(16, 2) NOT NULL,
+  iptu_use TEXT NULL,
+  iptu_use_description TEXT NULL,
+  row_hash TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_market_transactions_archive_run
+  ON market_transactions_archive (run_id);
+
+CREATE INDEX idx_market_transactions_archive_archived_by
+  ON market_transactions_archive (archived_by_run_id, reason);
@@ -0,0 +1,10 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Sinalizações de qualidade das transações mantidas na agregação
+-- (mad_outlier, duplicate_registration). Outliers IQR são descartados antes da gravação.
+ALTER TABLE market_transactions
+  ADD COLUMN quality_flags TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE market_transactions_archive
+  ADD COLUMN quality_flags TEXT[] NOT NULL DEFAULT '{}';
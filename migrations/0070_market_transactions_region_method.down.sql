@@ -0,0 +1,8 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+ALTER TABLE market_transactions_archive
+  DROP COLUMN IF EXISTS region_method;
+
+ALTER TABLE market_transactions
+  DROP COLUMN IF EXISTS region_method;
@@ -0,0 +1,11 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Método que resolveu o bairro da transação: geo_cep/geo_street (ponto no polígono do
+-- bairro) ou dictionary/normalized/llm (correspondência do texto da coluna bairro).
+-- NULL nas linhas ingeridas antes desta migração.
+ALTER TABLE market_transactions
+  ADD COLUMN region_method TEXT NULL;
+
+ALTER TABLE market_transactions_archive
+  ADD COLUMN region_method TEXT NULL;
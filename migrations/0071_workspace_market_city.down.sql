@@ -0,0 +1,5 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+ALTER TABLE workspace_settings
+  DROP COLUMN IF EXISTS market_city;
@@ -0,0 +1,7 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Cidade (slug do perfil de ingestão, ex.: sp, curitiba) usada para buscar o mercado
+-- ITBI dos prospects cujo endereço não informa a cidade.
+ALTER TABLE workspace_settings
+  ADD COLUMN market_city TEXT NOT NULL DEFAULT 'sp';
@@ -0,0 +1,5 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+ALTER TABLE flip_score_outcomes
+  DROP COLUMN IF EXISTS score_inputs;
@@ -0,0 +1,8 @@
+-- Usar schema flip
+SET search_path TO flip, public;
+
+-- Entradas do Flip Score no momento da conversão (dados do prospect, avaliação de risco,
+-- coorte, mercado, configurações e perfil), para o backtest pontuar cada venda com todas
+-- as versões. Conversões anteriores ficam sem snapshot (NULL).
+ALTER TABLE flip_score_outcomes
+  ADD COLUMN score_inputs JSONB NULL;
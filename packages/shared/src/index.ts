@@ -43,12 +43,61 @@ export type UpdateWorkspaceRequest = z.infer<typeof UpdateWorkspaceRequestSchema
 export const WorkspaceSettingsSchema = z.object({
   workspace_id: z.string(),
   pj_tax_rate: z.number(),
+  discount_rate: z.number().optional(),
   updated_at: z.string(),
+  tax_regime: z.string().optional(),
+  tax_simples_rate: z.number().nullable().optional(),
+  tax_ret_rate: z.number().nullable().optional(),
+  tax_pf_reinvested_pct: z.number().nullable().optional(),
+  // City slug (sp, curitiba) for ITBI market data when a prospect address has no city
+  market_city: z.string().optional(),
 });
 export type WorkspaceSettings = z.infer<typeof WorkspaceSettingsSchema>;
 
 // M1 - Prospects
 
+// Auction acquisition (leilão): the purchase price is the winning bid
+export const AcquisitionModeEnum = z.enum(["market", "auction"]);
+export type AcquisitionMode = z.infer<typeof AcquisitionModeEnum>;
+
+export const AuctionPaymentTermsEnum = z.enum(["a_vista", "parcelado"]);
+export type AuctionPaymentTerms = z.infer<typeof AuctionPaymentTermsEnum>;
+
+export const AuctionTermsSchema = z.object({
+  commission_rate: z.number().min(0).lt(1).optional(), // defaults to 0.05
+  iptu_arrears: z.number().nonnegative().optional(),
+  condo_arrears: z.number().nonnegative().optional(),
+  eviction_cost: z.number().nonnegative().optional(),
+  eviction_delay_months: z.number().int().min(0).max(60).optional(),
+  payment_terms: z.union([AuctionPaymentTermsEnum, z.literal("")]).optional(),
+  down_payment_pct: z.number().gt(0).max(1).optional(),
+  installments: z.number().int().min(1).max(120).optional(),
+  installment_monthly_rate: z.number().min(0).max(0.1).optional(),
+});
+export type AuctionTerms = z.infer<typeof AuctionTermsSchema>;
+
+export const AuctionBreakdownSchema = z.object({
+  bid: z.number(),
+  commission_rate: z.number(),
+  commission: z.number(),
+  iptu_arrears: z.number(),
+  condo_arrears: z.number(),
+  eviction_cost: z.number(),
+  eviction_delay_months: z.number().int(),
+  payment_terms: AuctionPaymentTermsEnum,
+  down_payment: z.number(),
+  installments: z.number().int(),
+  installment_monthly_rate: z.number(),
+  installment_value: z.number(),
+  installments_paid: z.number().int(),
+  balance_at_sale: z.number(),
+  correction_cost: z.number(),
+  extra_costs: z.number(),
+  upfront_cash: z.number(),
+  effective_hold_months: z.number().int(),
+});
+export type AuctionBreakdown = z.infer<typeof AuctionBreakdownSchema>;
+
 export const ProspectStatusEnum = z.enum(["active", "discarded", "converted"]);
 export type ProspectStatus = z.infer<typeof ProspectStatusEnum>;
 
@@ -82,7 +131,7 @@ export const ProspectSchema = z.object({
   flip_score: z.number().int().min(0).max(100).nullable().optional(),
   flip_score_version: z.string().nullable().optional(),
   flip_score_confidence: z.number().min(0).max(1).nullable().optional(),
-  flip_score_breakdown: z.any().nullable().optional(), // FlipScoreBreakdownSchema or FlipScoreBreakdownV1Schema
+  flip_score_breakdown: z.any().nullable().optional(), // FlipScoreBreakdownSchema, FlipScoreBreakdownV1Schema or FlipScoreBreakdownV2Schema
   flip_score_updated_at: z.string().nullable().optional(),
   // M9 - Flip Score v1 investment inputs
   offer_price: z.number().nullable().optional(),
@@ -90,6 +139,8 @@ export const ProspectSchema = z.object({
   renovation_cost_estimate: z.number().nullable().optional(),
   hold_months: z.number().int().nullable().optional(),
   other_costs_estimate: z.number().nullable().optional(),
+  acquisition_mode: AcquisitionModeEnum.optional(),
+  auction_terms: AuctionTermsSchema.optional(),
   created_at: z.string(),
   updated_at: z.string(),
 });
@@ -129,6 +180,8 @@ export const CreateProspectRequestSchema = z.object({
   renovation_cost_estimate: z.number().nonnegative().optional(),
   hold_months: z.number().int().positive().optional(),
   other_costs_estimate: z.number().nonnegative().optional(),
+  acquisition_mode: AcquisitionModeEnum.optional(),
+  auction_terms: AuctionTermsSchema.optional(),
   // URL import tracking
   imported_via_url: z.boolean().optional(),
 });
@@ -162,6 +215,8 @@ export const UpdateProspectRequestSchema = z.object({
   renovation_cost_estimate: z.number().nonnegative().optional(),
   hold_months: z.number().int().positive().optional(),
   other_costs_estimate: z.number().nonnegative().optional(),
+  acquisition_mode: AcquisitionModeEnum.optional(),
+  auction_terms: AuctionTermsSchema.optional(),
 });
 export type UpdateProspectRequest = z.infer<typeof UpdateProspectRequestSchema>;
 
@@ -220,6 +275,9 @@ export type UpdatePropertyRequest = z.infer<typeof UpdatePropertyRequestSchema>;
 
 export const UpdatePropertyStatusRequestSchema = z.object({
   status_pipeline: PropertyStatusEnum,
+  // Realized outcome when a converted property is sold (Flip Score backtesting)
+  realized_sale_price: z.number().positive().optional(),
+  realized_net_profit: z.number().optional(),
 });
 export type UpdatePropertyStatusRequest = z.infer<typeof UpdatePropertyStatusRequestSchema>;
 
@@ -230,9 +288,44 @@ export const CashInputsSchema = z.object({
   renovation_cost: z.number().nullable(),
   other_costs: z.number().nullable(),
   sale_price: z.number().nullable(),
+  hold_months: z.number().nullable().optional(),
+  condo_fee: z.number().nullable().optional(),
+  iptu: z.number().nullable().optional(),
+  acquisition_mode: AcquisitionModeEnum.nullable().optional(),
+  auction_terms: AuctionTermsSchema.nullable().optional(),
 });
 export type CashInputs = z.infer<typeof CashInputsSchema>;
 
+export const TaxRegimeEnum = z.enum(["flat", "pf", "lucro_presumido", "ret", "simples"]);
+export type TaxRegime = z.infer<typeof TaxRegimeEnum>;
+
+export const TaxSettingsSchema = z.object({
+  regime: z.string(),
+  simples_rate: z.number().optional(),
+  ret_rate: z.number().optional(),
+  pf_reinvested_pct: z.number().optional(),
+});
+export type TaxSettings = z.infer<typeof TaxSettingsSchema>;
+
+export const TaxItemSchema = z.object({
+  code: z.string(),
+  label: z.string(),
+  base: z.number(),
+  rate: z.number(),
+  amount: z.number(),
+});
+export type TaxItem = z.infer<typeof TaxItemSchema>;
+
+export const TaxBreakdownSchema = z.object({
+  regime: z.string(),
+  taxable_base: z.number(),
+  items: z.array(TaxItemSchema),
+  total: z.number(),
+  effective_rate: z.number(),
+  notes: z.array(z.string()).optional(),
+});
+export type TaxBreakdown = z.infer<typeof TaxBreakdownSchema>;
+
 export const CashOutputsSchema = z.object({
   itbi_value: z.number(),
   registry_value: z.number(),
@@ -244,6 +337,8 @@ export const CashOutputsSchema = z.object({
   net_profit: z.number(),
   roi: z.number(),
   is_partial: z.boolean(),
+  tax_breakdown: TaxBreakdownSchema.optional(),
+  auction: AuctionBreakdownSchema.optional(),
 });
 export type CashOutputs = z.infer<typeof CashOutputsSchema>;
 
@@ -252,13 +347,53 @@ export const EffectiveRatesSchema = z.object({
   registry_rate: z.number(),
   broker_rate: z.number(),
   pj_tax_rate: z.number(),
+  tax: TaxSettingsSchema.optional(),
 });
 export type EffectiveRates = z.infer<typeof EffectiveRatesSchema>;
 
+export const CashFlowMonthSchema = z.object({
+  month_index: z.number(),
+  acquisition: z.number(),
+  acquisition_fees: z.number(),
+  renovation: z.number(),
+  other_costs: z.number(),
+  carry: z.number(),
+  financing: z.number(),
+  sale_price: z.number(),
+  sale_costs: z.number(),
+  debt_payoff: z.number(),
+  tax: z.number(),
+  net_flow: z.number(),
+  cumulative: z.number(),
+});
+export type CashFlowMonth = z.infer<typeof CashFlowMonthSchema>;
+
+export const CashFlowMetricsSchema = z.object({
+  hold_months: z.number(),
+  total_outflow: z.number(),
+  total_inflow: z.number(),
+  net_profit: z.number(),
+  irr_monthly: z.number().nullable(),
+  irr_annual: z.number().nullable(),
+  annualized_roi: z.number().nullable(),
+  discount_rate: z.number(),
+  npv: z.number(),
+  peak_capital: z.number(),
+  peak_capital_month: z.number(),
+});
+export type CashFlowMetrics = z.infer<typeof CashFlowMetricsSchema>;
+
+export const CashFlowSchema = z.object({
+  months: z.array(CashFlowMonthSchema),
+  metrics: CashFlowMetricsSchema,
+});
+export type CashFlow = z.infer<typeof CashFlowSchema>;
+
 export const CashAnalysisResponseSchema = z.object({
   inputs: CashInputsSchema,
   outputs: CashOutputsSchema,
   effective_rates: EffectiveRatesSchema,
+  cash_flow: CashFlowSchema.optional(),
 });
 export type CashAnalysisResponse = z.infer<typeof CashAnalysisResponseSchema>;
 
@@ -267,6 +402,9 @@ export const UpdateCashInputsRequestSchema = z.object({
   renovation_cost: z.number().nonnegative().optional(),
   other_costs: z.number().nonnegative().optional(),
   sale_price: z.number().nonnegative().optional(),
+  hold_months: z.number().int().positive().optional(),
+  condo_fee: z.number().nonnegative().optional(),
+  iptu: z.number().nonnegative().optional(),
 });
 export type UpdateCashInputsRequest = z.infer<typeof UpdateCashInputsRequestSchema>;
 
@@ -275,6 +413,7 @@ export const CashSnapshotSchema = z.object({
   inputs: CashInputsSchema,
   outputs: CashOutputsSchema,
   effective_rates: EffectiveRatesSchema.optional(),
+  cash_flow: CashFlowSchema.optional(),
   status_pipeline: PropertyStatusEnum.optional(),
   created_at: z.string(),
 });
@@ -291,6 +430,107 @@ export const CreateSnapshotResponseSchema = z.object({
 });
 export type CreateSnapshotResponse = z.infer<typeof CreateSnapshotResponseSchema>;
 
+// Sensitivity & Monte Carlo (properties and prospects)
+
+export const SensitivityVariableEnum = z.enum(["sale_price", "renovation_cost", "hold_months"]);
+export type SensitivityVariable = z.infer<typeof SensitivityVariableEnum>;
+
+export const SensitivityRangeSchema = z.object({
+  low: z.number(),
+  high: z.number(),
+  steps: z.number().int().min(0).max(21).optional(),
+});
+export type SensitivityRange = z.infer<typeof SensitivityRangeSchema>;
+
+export const SensitivityRangesSchema = z.object({
+  sale_price: SensitivityRangeSchema.optional(),
+  renovation_cost: SensitivityRangeSchema.optional(),
+  hold_months: SensitivityRangeSchema.optional(),
+});
+export type SensitivityRanges = z.infer<typeof SensitivityRangesSchema>;
+
+export const DistributionSchema = z.object({
+  type: z.enum(["triangular", "uniform", "normal"]),
+  low: z.number(),
+  mode: z.number(),
+  high: z.number(),
+  std_dev: z.number().nonnegative().optional(),
+});
+export type Distribution = z.infer<typeof DistributionSchema>;
+
+export const MonteCarloParamsSchema = z.object({
+  iterations: z.number().int().min(0).max(20000).optional(),
+  seed: z.number().int().optional(),
+  sale_price: DistributionSchema.optional(),
+  renovation_cost: DistributionSchema.optional(),
+  hold_months: DistributionSchema.optional(),
+});
+export type MonteCarloParams = z.infer<typeof MonteCarloParamsSchema>;
+
+export const SensitivityRequestSchema = z.object({
+  ranges: SensitivityRangesSchema.optional(),
+  two_way: z.object({ x: SensitivityVariableEnum, y: SensitivityVariableEnum }).optional(),
+  monte_carlo: MonteCarloParamsSchema.optional(),
+});
+export type SensitivityRequest = z.infer<typeof SensitivityRequestSchema>;
+
+export const SensitivityPointSchema = z.object({
+  sale_price: z.number(),
+  renovation_cost: z.number(),
+  hold_months: z.number(),
+  net_profit: z.number(),
+  roi: z.number(),
+});
+export type SensitivityPoint = z.infer<typeof SensitivityPointSchema>;
+
+export const TornadoBarSchema = z.object({
+  variable: SensitivityVariableEnum,
+  low_value: z.number(),
+  high_value: z.number(),
+  low_net_profit: z.number(),
+  high_net_profit: z.number(),
+  low_roi: z.number(),
+  high_roi: z.number(),
+  swing: z.number(),
+});
+export type TornadoBar = z.infer<typeof TornadoBarSchema>;
+
+export const TwoWayTableSchema = z.object({
+  x_variable: SensitivityVariableEnum,
+  y_variable: SensitivityVariableEnum,
+  x_values: z.array(z.number()),
+  y_values: z.array(z.number()),
+  net_profit: z.array(z.array(z.number())),
+  roi: z.array(z.array(z.number())),
+});
+export type TwoWayTable = z.infer<typeof TwoWayTableSchema>;
+
+export const MonteCarloResultSchema = z.object({
+  iterations: z.number(),
+  seed: z.number(),
+  probability_of_loss: z.number(),
+  mean_net_profit: z.number(),
+  net_profit_p10: z.number(),
+  net_profit_p50: z.number(),
+  net_profit_p90: z.number(),
+  roi_p10: z.number(),
+  roi_p50: z.number(),
+  roi_p90: z.number(),
+  min_net_profit: z.number(),
+  max_net_profit: z.number(),
+});
+export type MonteCarloResult = z.infer<typeof MonteCarloResultSchema>;
+
+export const SensitivityResponseSchema = z.object({
+  base: SensitivityPointSchema,
+  ranges: SensitivityRangesSchema,
+  tornado: z.array(TornadoBarSchema),
+  two_way: TwoWayTableSchema,
+  monte_carlo: MonteCarloResultSchema.optional(),
+  effective_rates: EffectiveRatesSchema,
+});
+export type SensitivityResponse = z.infer<typeof SensitivityResponseSchema>;
+
 // M3 - Financing Analysis
 
 export const FinancingInputsSchema = z.object({
@@ -306,6 +546,12 @@ export const FinancingInputsSchema = z.object({
   appraisal_fee: z.number().nullable(),
   other_fees: z.number().nullable(),
   remaining_debt: z.number().nullable(),
+  amortization_system: z.enum(["sac", "price"]).nullable().optional(),
+  correction_index: z.enum(["none", "tr", "ipca"]).nullable().optional(),
+  correction_rate: z.number().nullable().optional(),
+  exit_month: z.number().nullable().optional(),
+  condo_fee: z.number().nullable().optional(),
+  iptu: z.number().nullable().optional(),
 });
 export type FinancingInputs = z.infer<typeof FinancingInputsSchema>;
 
@@ -325,7 +571,10 @@ export const FinancingOutputsSchema = z.object({
   net_profit: z.number(),
   roi: z.number(),
   interest_paid_estimate: z.number(),
+  remaining_debt: z.number().optional(),
+  exit_month: z.number().optional(),
   is_partial: z.boolean(),
+  tax_breakdown: TaxBreakdownSchema.optional(),
 });
 export type FinancingOutputs = z.infer<typeof FinancingOutputsSchema>;
 
@@ -343,6 +592,7 @@ export const FinancingAnalysisResponseSchema = z.object({
   payments: z.array(FinancingPaymentSchema),
   outputs: FinancingOutputsSchema,
   effective_rates: EffectiveRatesSchema,
+  cash_flow: CashFlowSchema.optional(),
 });
 export type FinancingAnalysisResponse = z.infer<typeof FinancingAnalysisResponseSchema>;
 
@@ -358,6 +608,12 @@ export const UpdateFinancingInputsRequestSchema = z.object({
   appraisal_fee: z.number().nonnegative().optional(),
   other_fees: z.number().nonnegative().optional(),
   remaining_debt: z.number().nonnegative().optional(),
+  amortization_system: z.enum(["sac", "price"]).optional(),
+  correction_index: z.enum(["none", "tr", "ipca"]).optional(),
+  correction_rate: z.number().min(0).max(1).optional(),
+  exit_month: z.number().int().nonnegative().optional(),
+  condo_fee: z.number().nonnegative().optional(),
+  iptu: z.number().nonnegative().optional(),
 });
 export type UpdateFinancingInputsRequest = z.infer<typeof UpdateFinancingInputsRequestSchema>;
 
@@ -373,12 +629,49 @@ export const ListPaymentsResponseSchema = z.object({
 });
 export type ListPaymentsResponse = z.infer<typeof ListPaymentsResponseSchema>;
 
+export const AmortizationRowSchema = z.object({
+  month_index: z.number(),
+  opening_balance: z.number(),
+  correction: z.number(),
+  interest: z.number(),
+  amortization: z.number(),
+  insurance: z.number(),
+  installment: z.number(),
+  closing_balance: z.number(),
+});
+export type AmortizationRow = z.infer<typeof AmortizationRowSchema>;
+
+export const AmortizationScheduleSchema = z.object({
+  system: z.enum(["sac", "price"]),
+  principal: z.number(),
+  term_months: z.number(),
+  monthly_interest_rate: z.number(),
+  correction_index: z.enum(["none", "tr", "ipca"]),
+  monthly_correction_rate: z.number(),
+  first_installment: z.number(),
+  total_interest: z.number(),
+  total_correction: z.number(),
+  total_insurance: z.number(),
+  total_paid: z.number(),
+  rows: z.array(AmortizationRowSchema),
+});
+export type AmortizationSchedule = z.infer<typeof AmortizationScheduleSchema>;
+
+export const FinancingScheduleResponseSchema = z.object({
+  plan_id: z.string(),
+  exit_month: z.number(),
+  schedule: AmortizationScheduleSchema,
+});
+export type FinancingScheduleResponse = z.infer<typeof FinancingScheduleResponseSchema>;
+
 export const FinancingSnapshotSchema = z.object({
   id: z.string(),
   inputs: FinancingInputsSchema,
   payments: z.array(FinancingPaymentSchema),
   outputs: FinancingOutputsSchema,
   effective_rates: EffectiveRatesSchema.optional(),
+  schedule: AmortizationScheduleSchema.optional(),
+  cash_flow: CashFlowSchema.optional(),
   status_pipeline: PropertyStatusEnum.optional(),
   created_at: z.string(),
 });
@@ -389,6 +682,48 @@ export const ListFinancingSnapshotsResponseSchema = z.object({
 });
 export type ListFinancingSnapshotsResponse = z.infer<typeof ListFinancingSnapshotsResponseSchema>;
 
+// Goal-seek solver (/analysis/{cash|financing}/solve)
+
+export const SolveTargetEnum = z.enum(["roi", "net_profit", "margin", "irr"]);
+export type SolveTarget = z.infer<typeof SolveTargetEnum>;
+
+export const SolveVariableEnum = z.enum(["purchase_price", "renovation_cost", "other_costs", "sale_price"]);
+export type SolveVariable = z.infer<typeof SolveVariableEnum>;
+
+export const SolveRequestSchema = z.object({
+  variable: SolveVariableEnum,
+  target: SolveTargetEnum,
+  target_value: z.number(),
+});
+export type SolveRequest = z.infer<typeof SolveRequestSchema>;
+
+export const SolveResultSchema = z.object({
+  variable: SolveVariableEnum,
+  target: SolveTargetEnum,
+  target_value: z.number(),
+  value: z.number(),
+  achieved: z.number(),
+  feasible: z.boolean(),
+  iterations: z.number(),
+});
+export type SolveResult = z.infer<typeof SolveResultSchema>;
+
+export const CashSolveResponseSchema = z.object({
+  result: SolveResultSchema,
+  inputs: CashInputsSchema,
+  outputs: CashOutputsSchema,
+  effective_rates: EffectiveRatesSchema,
+});
+export type CashSolveResponse = z.infer<typeof CashSolveResponseSchema>;
+
+export const FinancingSolveResponseSchema = z.object({
+  result: SolveResultSchema,
+  inputs: FinancingInputsSchema,
+  outputs: FinancingOutputsSchema,
+  effective_rates: EffectiveRatesSchema,
+});
+export type FinancingSolveResponse = z.infer<typeof FinancingSolveResponseSchema>;
+
 // M2/M3/M4 - Timeline
 
 export const TimelineEventTypeEnum = z.enum([
@@ -401,6 +736,7 @@ export const TimelineEventTypeEnum = z.enum([
   "schedule_item_created",
   "schedule_item_completed",
   "schedule_item_updated",
+  "negotiation_round",
 ]);
 export type TimelineEventType = z.infer<typeof TimelineEventTypeEnum>;
 
@@ -822,6 +1158,10 @@ export const PublicCashSettingsSchema = z.object({
   registry_rate: z.number().min(0).max(1).optional(),
   broker_rate: z.number().min(0).max(1).optional(),
   pj_tax_rate: z.number().min(0).max(1).optional(),
+  tax_regime: TaxRegimeEnum.optional(),
+  tax_simples_rate: z.number().min(0).max(1).optional(),
+  tax_ret_rate: z.number().min(0).max(1).optional(),
+  tax_pf_reinvested_pct: z.number().min(0).max(1).optional(),
 });
 export type PublicCashSettings = z.infer<typeof PublicCashSettingsSchema>;
 
@@ -991,6 +1331,7 @@ export const FlipScoreBreakdownSchema = z.object({
   missing_fields: z.array(z.string()),
   multipliers: FlipScoreMultipliersSchema,
   raw_score: z.number(),
+  profile_hash: z.string().optional(),
 });
 export type FlipScoreBreakdown = z.infer<typeof FlipScoreBreakdownSchema>;
 
@@ -1006,6 +1347,9 @@ export const EconomicsBreakdownSchema = z.object({
   break_even_sale_price: z.number(),
   buffer: z.number(),
   is_partial: z.boolean(),
+  tax_breakdown: TaxBreakdownSchema.optional(),
+  acquisition_mode: AcquisitionModeEnum.optional(),
+  auction: AuctionBreakdownSchema.optional(),
 });
 export type EconomicsBreakdown = z.infer<typeof EconomicsBreakdownSchema>;
 
@@ -1025,9 +1369,175 @@ export const FlipScoreBreakdownV1Schema = z.object({
   missing_fields: z.array(z.string()),
   multipliers: FlipScoreMultipliersSchema,
   raw_score: z.number(),
+  profile_hash: z.string().optional(),
 });
 export type FlipScoreBreakdownV1 = z.infer<typeof FlipScoreBreakdownV1Schema>;
 
+// Flip Score v2 (Market-based price)
+
+export const FlipScorePriceSourceEnum = z.enum(["market", "cohort"]);
+export type FlipScorePriceSource = z.infer<typeof FlipScorePriceSourceEnum>;
+
+export const FlipScoreMarketPriceSchema = z.object({
+  region: z.string(),
+  property_class: z.string(),
+  as_of_month: z.string(),
+  period_months: z.number().int(),
+  median_m2: z.number(),
+  p25_m2: z.number(),
+  p75_m2: z.number(),
+  tx_count: z.number().int(),
+  match_confidence: z.number().min(0).max(1),
+  match_method: z.string(),
+  price_vs_median: z.number().nullable(),
+  reliability: z.number().min(0).max(1),
+});
+export type FlipScoreMarketPrice = z.infer<typeof FlipScoreMarketPriceSchema>;
+
+export const FlipScoreBreakdownV2Schema = z.object({
+  components: FlipScoreComponentsSchema,
+  price_source: FlipScorePriceSourceEnum,
+  market: FlipScoreMarketPriceSchema.nullable(),
+  intermediate: FlipScoreIntermediateSchema,
+  risk_assessment: FlipRiskAssessmentSchema.nullable(),
+  missing_fields: z.array(z.string()),
+  multipliers: FlipScoreMultipliersSchema,
+  raw_score: z.number(),
+  profile_hash: z.string().optional(),
+});
+export type FlipScoreBreakdownV2 = z.infer<typeof FlipScoreBreakdownV2Schema>;
+
+// Flip Score scoring profile (per workspace)
+
+export const FlipScoreWeightsV0Schema = z.object({
+  price: z.number().min(0).max(1),
+  carry: z.number().min(0).max(1),
+  liquidity: z.number().min(0).max(1),
+  risk: z.number().min(0).max(1),
+});
+export type FlipScoreWeightsV0 = z.infer<typeof FlipScoreWeightsV0Schema>;
+
+export const FlipScoreWeightsV1Schema = z.object({
+  econ: z.number().min(0).max(1),
+  liquidity: z.number().min(0).max(1),
+  risk: z.number().min(0).max(1),
+});
+export type FlipScoreWeightsV1 = z.infer<typeof FlipScoreWeightsV1Schema>;
+
+export const FlipScoreProfileSchema = z.object({
+  name: z.string(),
+  weights_v0: FlipScoreWeightsV0Schema,
+  weights_v1: FlipScoreWeightsV1Schema,
+  category_weights: z.record(RedFlagCategoryEnum, z.number().min(0).max(100)),
+  rehab_penalties: z.record(RehabLevelEnum, z.number().min(0).max(100)),
+});
+export type FlipScoreProfile = z.infer<typeof FlipScoreProfileSchema>;
+
+export const FlipScoreProfileResponseSchema = z.object({
+  workspace_id: z.string(),
+  profile: FlipScoreProfileSchema,
+  profile_hash: z.string(),
+  is_default: z.boolean(),
+  updated_at: z.string().nullable(),
+});
+export type FlipScoreProfileResponse = z.infer<typeof FlipScoreProfileResponseSchema>;
+
+export const UpdateFlipScoreProfileRequestSchema = z.object({
+  name: z.string().min(1).optional(),
+  weights_v0: FlipScoreWeightsV0Schema.optional(),
+  weights_v1: FlipScoreWeightsV1Schema.optional(),
+  category_weights: z.record(RedFlagCategoryEnum, z.number().min(0).max(100)).optional(),
+  rehab_penalties: z.record(RehabLevelEnum, z.number().min(0).max(100)).optional(),
+});
+export type UpdateFlipScoreProfileRequest = z.infer<typeof UpdateFlipScoreProfileRequestSchema>;
+
+// Flip Score backtest (realized outcomes)
+
+export const FlipScoreCalibrationBucketSchema = z.object({
+  score_min: z.number().int(),
+  score_max: z.number().int(),
+  n: z.number().int(),
+  mean_realized_roi: z.number().nullable(),
+  good_deal_rate: z.number().min(0).max(1).nullable(),
+});
+export type FlipScoreCalibrationBucket = z.infer<typeof FlipScoreCalibrationBucketSchema>;
+
+export const FlipScoreTopDecileSchema = z.object({
+  n: z.number().int(),
+  min_score: z.number().int(),
+  precision: z.number().min(0).max(1).nullable(),
+  base_rate: z.number().min(0).max(1).nullable(),
+  lift: z.number().nullable(),
+});
+export type FlipScoreTopDecile = z.infer<typeof FlipScoreTopDecileSchema>;
+
+export const FlipScoreVersionBacktestSchema = z.object({
+  version: z.string(),
+  n: z.number().int(),
+  spearman_rho: z.number().min(-1).max(1).nullable(),
+  calibration: z.array(FlipScoreCalibrationBucketSchema),
+  top_decile: FlipScoreTopDecileSchema,
+});
+export type FlipScoreVersionBacktest = z.infer<typeof FlipScoreVersionBacktestSchema>;
+
+export const FlipScoreBacktestResponseSchema = z.object({
+  workspace_id: z.string().nullable(),
+  good_deal_roi: z.number(),
+  sold_deals: z.number().int(),
+  scored_deals: z.number().int(),
+  excluded: z.record(z.string(), z.number().int()),
+  unscored: z.record(z.string(), z.array(z.string())),
+  versions: z.array(FlipScoreVersionBacktestSchema),
+});
+export type FlipScoreBacktestResponse = z.infer<typeof FlipScoreBacktestResponseSchema>;
+
+// Bulk Flip Score recompute jobs
+export const FlipScoreRecomputeJobStatusEnum = z.enum(["queued", "running", "completed", "failed"]);
+export type FlipScoreRecomputeJobStatus = z.infer<typeof FlipScoreRecomputeJobStatusEnum>;
+
+export const FlipScoreRecomputeJobSchema = z.object({
+  id: z.string(),
+  workspace_id: z.string(),
+  status: FlipScoreRecomputeJobStatusEnum,
+  force_version: z.enum(["v0", "v2"]).nullable(),
+  triggered_by: z.string().nullable(),
+  total_items: z.number().int(),
+  processed_items: z.number().int(),
+  failed_items: z.number().int(),
+  llm_calls: z.number().int(),
+  llm_cache_hits: z.number().int(),
+  progress: z.number().min(0).max(1),
+  error_message: z.string().nullable(),
+  started_at: z.string().nullable(),
+  finished_at: z.string().nullable(),
+  created_at: z.string(),
+  updated_at: z.string(),
+});
+export type FlipScoreRecomputeJob = z.infer<typeof FlipScoreRecomputeJobSchema>;
+
+export const FlipScoreRecomputeJobFailureSchema = z.object({
+  prospect_id: z.string(),
+  error_message: z.string().nullable(),
+  processed_at: z.string().nullable(),
+});
+export type FlipScoreRecomputeJobFailure = z.infer<typeof FlipScoreRecomputeJobFailureSchema>;
+
+export const FlipScoreRecomputeJobResponseSchema = z.object({
+  job: FlipScoreRecomputeJobSchema,
+  failures: z.array(FlipScoreRecomputeJobFailureSchema),
+});
+export type FlipScoreRecomputeJobResponse = z.infer<typeof FlipScoreRecomputeJobResponseSchema>;
+
+export const ListFlipScoreRecomputeJobsResponseSchema = z.object({
+  items: z.array(FlipScoreRecomputeJobSchema),
+});
+export type ListFlipScoreRecomputeJobsResponse = z.infer<typeof ListFlipScoreRecomputeJobsResponseSchema>;
+
+export const CreateFlipScoreRecomputeJobRequestSchema = z.object({
+  force_version: z.enum(["v0", "v2"]).optional(),
+});
+export type CreateFlipScoreRecomputeJobRequest = z.infer<typeof CreateFlipScoreRecomputeJobRequestSchema>;
+
 export const RecomputeFlipScoreRequestSchema = z.object({
   force: z.boolean().optional(),
 });
@@ -1287,10 +1797,20 @@ export const OfferReasonCodeEnum = z.enum([
   "MARKET_SAMPLE_TOO_LOW",
   "UNFAVORABLE_BREAK_EVEN",
   "OPTIMISTIC_SALE_PRICE_ESTIMATE",
+  "ABOVE_CEILING",
+  "MAX_BID_BELOW_MINIMUM_BID",
+  "SALE_PRICE_ABOVE_MARKET_P75",
 ]);
 export type OfferReasonCode = z.infer<typeof OfferReasonCodeEnum>;
 
-export const OfferScenarioKeyEnum = z.enum(["aggressive", "recommended", "ceiling"]);
+// Built-in reason codes plus custom codes defined by workspace decision rules
+export const OfferRuleReasonCodeSchema = z.union([
+  OfferReasonCodeEnum,
+  z.string().regex(/^[A-Z][A-Z0-9_]{2,63}$/),
+]);
+export type OfferRuleReasonCode = z.infer<typeof OfferRuleReasonCodeSchema>;
+
+export const OfferScenarioKeyEnum = z.enum(["aggressive", "recommended", "ceiling", "counter"]);
 export type OfferScenarioKey = z.infer<typeof OfferScenarioKeyEnum>;
 
 export const OfferMessageLevelEnum = z.enum(["short", "full"]);
@@ -1317,6 +1837,9 @@ export const OfferScenarioSchema = z.object({
   roi: z.number(),
   margin: z.number(),
   break_even_sale_price: z.number(),
+  tax_value: z.number().optional(),
+  tax_breakdown: TaxBreakdownSchema.optional(),
+  auction: AuctionBreakdownSchema.optional(),
 });
 export type OfferScenario = z.infer<typeof OfferScenarioSchema>;
 
@@ -1344,6 +1867,80 @@ export const OfferIntelligenceSaveRequestSchema = z.object({
 });
 export type OfferIntelligenceSaveRequest = z.infer<typeof OfferIntelligenceSaveRequestSchema>;
 
+// Workspace decision rules: conditions over offer variables, e.g.
+// "renovation_to_ask > 0.3 AND hold_months > 9"
+export const OfferDecisionRuleSchema = z.object({
+  id: z.string().regex(/^[a-z0-9][a-z0-9_-]{0,63}$/),
+  when: z.string().min(1).max(500),
+  decision: z.enum(["REVIEW", "NO_GO"]).optional(),
+  reason: OfferRuleReasonCodeSchema.optional(),
+  label: z.string().max(200).optional(),
+});
+export type OfferDecisionRule = z.infer<typeof OfferDecisionRuleSchema>;
+
+export const OfferRuleOutcomeSchema = z.object({
+  id: z.string(),
+  when: z.string(),
+  matched: z.boolean(),
+  decision: z.enum(["REVIEW", "NO_GO"]).optional(),
+  reason: OfferRuleReasonCodeSchema.optional(),
+  values: z.record(z.number()),
+});
+export type OfferRuleOutcome = z.infer<typeof OfferRuleOutcomeSchema>;
+
+export const OfferRuleVariableSchema = z.object({
+  description: z.string(),
+  boolean: z.boolean(),
+});
+export type OfferRuleVariable = z.infer<typeof OfferRuleVariableSchema>;
+
+export const OfferDecisionRulesResponseSchema = z.object({
+  workspace_id: z.string(),
+  rules: z.array(OfferDecisionRuleSchema),
+  rules_hash: z.string(),
+  is_default: z.boolean(),
+  version: z.number().int(),
+  updated_at: z.string().nullable(),
+  default_rules: z.array(OfferDecisionRuleSchema),
+  variables: z.record(OfferRuleVariableSchema),
+});
+export type OfferDecisionRulesResponse = z.infer<typeof OfferDecisionRulesResponseSchema>;
+
+export const UpdateOfferDecisionRulesRequestSchema = z.object({
+  rules: z.array(OfferDecisionRuleSchema).min(1).max(50),
+});
+export type UpdateOfferDecisionRulesRequest = z.infer<typeof UpdateOfferDecisionRulesRequestSchema>;
+
+// ITBI market evidence for the prospect bairro
+export const OfferMarketCoverageSchema = z.object({
+  resolved: z.boolean(),
+  region: z.string().optional(),
+  match_method: z.string().optional(),
+  match_confidence: z.number(),
+  property_class: z.string().optional(),
+  as_of_month: z.string().optional(),
+  tx_count: z.number().int(),
+  median_m2: z.number(),
+  p75_m2: z.number(),
+  sale_price_m2: z.number(),
+  sale_above_market_p75: z.boolean(),
+});
+export type OfferMarketCoverage = z.infer<typeof OfferMarketCoverageSchema>;
+
+// Auction bidding range: aggressive = minimum bid, ceiling = max bid
+export const OfferAuctionSummarySchema = z.object({
+  minimum_bid: z.number(),
+  max_bid: z.number(),
+  max_bid_for_margin: z.number(),
+  max_bid_feasible: z.boolean(),
+  target_margin_pct: z.number(),
+  bid_room: z.number(),
+  payment_terms: AuctionPaymentTermsEnum,
+  eviction_delay_months: z.number().int(),
+  effective_hold_months: z.number().int(),
+});
+export type OfferAuctionSummary = z.infer<typeof OfferAuctionSummarySchema>;
+
 export const OfferIntelligencePreviewSchema = z.object({
   prospect_id: z.string(),
   workspace_id: z.string(),
@@ -1352,7 +1949,7 @@ export const OfferIntelligencePreviewSchema = z.object({
   decision: OfferDecisionEnum,
   confidence: z.number(),
   confidence_bucket: OfferConfidenceBucketEnum,
-  reason_codes: z.array(OfferReasonCodeEnum),
+  reason_codes: z.array(OfferRuleReasonCodeSchema),
   reason_labels: z.array(z.string()),
   risk_score: z.number(),
   assumptions: z.array(z.string()),
@@ -1364,6 +1961,10 @@ export const OfferIntelligencePreviewSchema = z.object({
   settings_hash: z.string(),
   tier: z.string(),
   limited: z.boolean(),
+  decision_rules: z.array(OfferRuleOutcomeSchema).optional(),
+  market: OfferMarketCoverageSchema.optional(),
+  acquisition_mode: AcquisitionModeEnum.optional(),
+  auction: OfferAuctionSummarySchema.optional(),
 });
 export type OfferIntelligencePreview = z.infer<typeof OfferIntelligencePreviewSchema>;
 
@@ -1385,7 +1986,7 @@ export const OfferIntelligenceHistoryItemSchema = z.object({
   decision: OfferDecisionEnum,
   confidence: z.number(),
   confidence_bucket: OfferConfidenceBucketEnum,
-  reason_codes: z.array(OfferReasonCodeEnum),
+  reason_codes: z.array(OfferRuleReasonCodeSchema),
   reason_labels: z.array(z.string()),
   recommended_offer_price: z.number().nullable(),
   recommended_margin: z.number().nullable(),
@@ -1407,6 +2008,86 @@ export const OfferIntelligenceHistoryResponseSchema = z.object({
 });
 export type OfferIntelligenceHistoryResponse = z.infer<typeof OfferIntelligenceHistoryResponseSchema>;
 
+// Offer negotiation tracker
+
+export const OfferNegotiationStatusEnum = z.enum(["open", "accepted", "rejected", "withdrawn"]);
+export type OfferNegotiationStatus = z.infer<typeof OfferNegotiationStatusEnum>;
+
+export const OfferNegotiationEventTypeEnum = z.enum([
+  "offer_sent",
+  "counteroffer",
+  "seller_response",
+  "withdrawn",
+]);
+export type OfferNegotiationEventType = z.infer<typeof OfferNegotiationEventTypeEnum>;
+
+export const SellerResponseEnum = z.enum(["accepted", "rejected", "countered"]);
+export type SellerResponse = z.infer<typeof SellerResponseEnum>;
+
+export const OfferCounterEvaluationSchema = z.object({
+  price: z.number(),
+  decision: OfferDecisionEnum,
+  confidence: z.number(),
+  risk_score: z.number(),
+  reason_codes: z.array(OfferRuleReasonCodeSchema),
+  reason_labels: z.array(z.string()),
+  ceiling_offer: z.number(),
+  room_to_ceiling: z.number(),
+  room_to_ceiling_pct: z.number(),
+  scenario: OfferScenarioSchema,
+  input_hash: z.string(),
+  settings_hash: z.string(),
+  decision_rules: z.array(OfferRuleOutcomeSchema).optional(),
+});
+export type OfferCounterEvaluation = z.infer<typeof OfferCounterEvaluationSchema>;
+
+export const OfferNegotiationRoundSchema = z.object({
+  id: z.string(),
+  round_number: z.number().int(),
+  event_type: OfferNegotiationEventTypeEnum,
+  price: z.number().nullable(),
+  seller_response: SellerResponseEnum.nullable(),
+  notes: z.string().nullable(),
+  occurred_at: z.string(),
+  decision: OfferDecisionEnum.nullable(),
+  ceiling_offer: z.number().nullable(),
+  room_to_ceiling: z.number().nullable(),
+  evaluation: OfferCounterEvaluationSchema.nullable(),
+  created_by_user_id: z.string(),
+  created_at: z.string(),
+});
+export type OfferNegotiationRound = z.infer<typeof OfferNegotiationRoundSchema>;
+
+export const OfferNegotiationSchema = z.object({
+  id: z.string(),
+  workspace_id: z.string(),
+  prospect_id: z.string(),
+  property_id: z.string().nullable(),
+  status: OfferNegotiationStatusEnum,
+  asking_price: z.number().nullable(),
+  last_price: z.number().nullable(),
+  created_by_user_id: z.string(),
+  closed_at: z.string().nullable(),
+  created_at: z.string(),
+  updated_at: z.string(),
+  rounds: z.array(OfferNegotiationRoundSchema),
+});
+export type OfferNegotiation = z.infer<typeof OfferNegotiationSchema>;
+
+export const OfferNegotiationResponseSchema = z.object({
+  negotiation: OfferNegotiationSchema,
+});
+export type OfferNegotiationResponse = z.infer<typeof OfferNegotiationResponseSchema>;
+
+export const OfferNegotiationEventRequestSchema = z.object({
+  event_type: OfferNegotiationEventTypeEnum,
+  price: z.number().positive().optional(),
+  seller_response: SellerResponseEnum.optional(),
+  notes: z.string().optional(),
+  occurred_at: z.string().optional(),
+});
+export type OfferNegotiationEventRequest = z.infer<typeof OfferNegotiationEventRequestSchema>;
+
 export const OfferIntelligenceHistoryQuerySchema = z.object({
   limit: z.coerce.number().int().min(1).max(100).default(20),
   cursor: z.string().optional(),
@@ -1471,6 +2152,10 @@ export const PropertyRatesSchema = z.object({
   registry_rate: z.number().min(0).max(1).nullable(),
   broker_rate: z.number().min(0).max(1).nullable(),
   pj_tax_rate: z.number().min(0).max(1).nullable(),
+  tax_regime: z.string().nullable().optional(),
+  tax_simples_rate: z.number().min(0).max(1).nullable().optional(),
+  tax_ret_rate: z.number().min(0).max(1).nullable().optional(),
+  tax_pf_reinvested_pct: z.number().min(0).max(1).nullable().optional(),
 });
 export type PropertyRates = z.infer<typeof PropertyRatesSchema>;
 
@@ -1487,6 +2172,10 @@ export const UpdatePropertyRatesRequestSchema = z.object({
   registry_rate: z.number().min(0).max(1).nullable().optional(),
   broker_rate: z.number().min(0).max(1).nullable().optional(),
   pj_tax_rate: z.number().min(0).max(1).nullable().optional(),
+  tax_regime: TaxRegimeEnum.nullable().optional(),
+  tax_simples_rate: z.number().min(0).max(1).nullable().optional(),
+  tax_ret_rate: z.number().min(0).max(1).nullable().optional(),
+  tax_pf_reinvested_pct: z.number().min(0).max(1).nullable().optional(),
 });
 export type UpdatePropertyRatesRequest = z.infer<typeof UpdatePropertyRatesRequestSchema>;
 
@@ -2204,12 +2893,26 @@ export const OpportunityScoreBreakdownSchema = z.object({
   keywords: z.number(),
   penalties: z.number(),
   decay: z.number(),
+  price_cut: z.number().default(0),
 });
 export type OpportunityScoreBreakdown = z.infer<typeof OpportunityScoreBreakdownSchema>;
 
-export const OpportunityStatusEnum = z.enum(["new", "viewed", "contacted", "discarded"]);
+// "converted" is set by the convert-to-prospect endpoint only
+export const OpportunityStatusEnum = z.enum(["new", "viewed", "contacted", "discarded", "converted"]);
 export type OpportunityStatus = z.infer<typeof OpportunityStatusEnum>;
 
+export const OpportunityListingStatusEnum = z.enum(["active", "delisted"]);
+export type OpportunityListingStatus = z.infer<typeof OpportunityListingStatusEnum>;
+
+export const OpportunitySourceLinkSchema = z.object({
+  opportunity_id: z.string(),
+  source: z.string(),
+  source_listing_id: z.string(),
+  canonical_url: z.string(),
+  price_cents: z.number(),
+});
+export type OpportunitySourceLink = z.infer<typeof OpportunitySourceLinkSchema>;
+
 export const OpportunitySchema = z.object({
   id: z.string(),
   source: z.string(),
@@ -2237,6 +2940,25 @@ export const OpportunitySchema = z.object({
   status: OpportunityStatusEnum,
   first_seen_at: z.string(),
   last_seen_at: z.string(),
+  // Parse quality (0-100) and origin of each field: json_ld, next_data, hydration or html
+  parse_quality: z.number().optional(),
+  field_provenance: z.record(z.string()).optional(),
+  // Price history and market time
+  listing_status: OpportunityListingStatusEnum.default("active"),
+  delisted_at: z.string().optional(),
+  peak_price_cents: z.number().optional(),
+  price_drop_pct: z.number().default(0),
+  last_price_drop_at: z.string().optional(),
+  days_on_market: z.number().default(0),
+  status_changed_at: z.string().optional(),
+  // Cross-portal cluster (dedupe=false returns one card per listing)
+  cluster_id: z.string().optional(),
+  listing_count: z.number().default(1),
+  sources: z.array(OpportunitySourceLinkSchema).default([]),
+  lowest_price_cents: z.number().optional(),
+  highest_price_cents: z.number().optional(),
+  price_spread_cents: z.number().default(0),
+  price_spread_pct: z.number().default(0),
 });
 export type Opportunity = z.infer<typeof OpportunitySchema>;
 
@@ -2270,6 +2992,9 @@ export const OpportunityFacetRangesSchema = z.object({
   price_max_cents: z.number(),
   area_min: z.number(),
   area_max: z.number(),
+  price_drop_max_pct: z.number().default(0),
+  days_on_market_min: z.number().default(0),
+  days_on_market_max: z.number().default(0),
 });
 export type OpportunityFacetRanges = z.infer<typeof OpportunityFacetRangesSchema>;
 
@@ -2278,13 +3003,126 @@ export const OpportunityFacetsResponseSchema = z.object({
   cities: z.array(OpportunityFacetItemSchema),
   neighborhoods: z.array(OpportunityFacetItemSchema),
   statuses: z.array(OpportunityFacetItemSchema),
+  listing_statuses: z.array(OpportunityFacetItemSchema).default([]),
+  price_drop_count: z.number().default(0),
   bedrooms: z.array(OpportunityFacetNumberItemSchema),
   ranges: OpportunityFacetRangesSchema,
 });
 export type OpportunityFacetsResponse = z.infer<typeof OpportunityFacetsResponseSchema>;
 
+export const OpportunityPriceHistoryEntrySchema = z.object({
+  price_cents: z.number(),
+  previous_price_cents: z.number().optional(),
+  change_pct: z.number().optional(),
+  job_run_id: z.string().optional(),
+  observed_at: z.string(),
+});
+export type OpportunityPriceHistoryEntry = z.infer<typeof OpportunityPriceHistoryEntrySchema>;
+
+export const OpportunityStatusChangeSchema = z.object({
+  change_type: z.enum(["status", "listing_status"]),
+  from_value: z.string().optional(),
+  to_value: z.string(),
+  changed_by: z.string().optional(),
+  job_run_id: z.string().optional(),
+  created_at: z.string(),
+});
+export type OpportunityStatusChange = z.infer<typeof OpportunityStatusChangeSchema>;
+
+export const OpportunityHistoryResponseSchema = z.object({
+  opportunity_id: z.string(),
+  price_history: z.array(OpportunityPriceHistoryEntrySchema),
+  status_changes: z.array(OpportunityStatusChangeSchema),
+});
+export type OpportunityHistoryResponse = z.infer<typeof OpportunityHistoryResponseSchema>;
+
+// Buscas salvas de oportunidades (mesmas chaves da query de /opportunities)
+export const OpportunitySavedSearchFiltersSchema = z.object({
+  state: z.string().optional(),
+  city: z.string().optional(),
+  neighborhood: z.string().optional(),
+  min_score: z.number().int().min(0).max(100).optional(),
+  min_price: z.number().nonnegative().optional(),
+  max_price: z.number().nonnegative().optional(),
+  min_area: z.number().nonnegative().optional(),
+  max_area: z.number().nonnegative().optional(),
+  bedrooms: z.array(z.number().int().nonnegative()).optional(),
+  status: z.array(OpportunityStatusEnum).optional(),
+  listing_status: OpportunityListingStatusEnum.optional(),
+  min_price_drop: z.number().nonnegative().optional(),
+});
+export type OpportunitySavedSearchFilters = z.infer<typeof OpportunitySavedSearchFiltersSchema>;
+
+export const OpportunitySavedSearchSchema = z.object({
+  id: z.string(),
+  workspace_id: z.string(),
+  name: z.string(),
+  filters: OpportunitySavedSearchFiltersSchema,
+  alerts_enabled: z.boolean(),
+  email_digest: z.boolean(),
+  unread_alerts: z.number().int(),
+  last_alerted_at: z.string().optional(),
+  created_at: z.string(),
+  updated_at: z.string(),
+});
+export type OpportunitySavedSearch = z.infer<typeof OpportunitySavedSearchSchema>;
+
+export const ListOpportunitySavedSearchesResponseSchema = z.object({
+  items: z.array(OpportunitySavedSearchSchema),
+});
+export type ListOpportunitySavedSearchesResponse = z.infer<typeof ListOpportunitySavedSearchesResponseSchema>;
+
+export const CreateOpportunitySavedSearchRequestSchema = z.object({
+  workspace_id: z.string().min(1),
+  name: z.string().trim().min(1).max(120),
+  filters: OpportunitySavedSearchFiltersSchema,
+  alerts_enabled: z.boolean().optional(),
+  email_digest: z.boolean().optional(),
+});
+export type CreateOpportunitySavedSearchRequest = z.infer<typeof CreateOpportunitySavedSearchRequestSchema>;
+
+export const UpdateOpportunitySavedSearchRequestSchema = z.object({
+  name: z.string().trim().min(1).max(120).optional(),
+  filters: OpportunitySavedSearchFiltersSchema.optional(),
+  alerts_enabled: z.boolean().optional(),
+  email_digest: z.boolean().optional(),
+});
+export type UpdateOpportunitySavedSearchRequest = z.infer<typeof UpdateOpportunitySavedSearchRequestSchema>;
+
+export const OpportunityAlertReasonEnum = z.enum(["new", "price_drop"]);
+export type OpportunityAlertReason = z.infer<typeof OpportunityAlertReasonEnum>;
+
+export const OpportunityAlertSchema = z.object({
+  id: z.string(),
+  saved_search_id: z.string(),
+  saved_search_name: z.string(),
+  opportunity_id: z.string(),
+  reason: OpportunityAlertReasonEnum,
+  price_cents: z.number().optional(),
+  previous_price_cents: z.number().optional(),
+  title: z.string(),
+  neighborhood: z.string(),
+  city: z.string(),
+  score: z.number(),
+  deep_link: z.string(),
+  read_at: z.string().optional(),
+  created_at: z.string(),
+});
+export type OpportunityAlert = z.infer<typeof OpportunityAlertSchema>;
+
+export const ListOpportunityAlertsResponseSchema = z.object({
+  items: z.array(OpportunityAlertSchema),
+  unread_count: z.number().int(),
+});
+export type ListOpportunityAlertsResponse = z.infer<typeof ListOpportunityAlertsResponseSchema>;
+
+export const MarkOpportunityAlertsReadResponseSchema = z.object({
+  updated: z.number().int(),
+});
+export type MarkOpportunityAlertsReadResponse = z.infer<typeof MarkOpportunityAlertsReadResponseSchema>;
+
 export const UpdateOpportunityStatusRequestSchema = z.object({
-  status: OpportunityStatusEnum,
+  status: OpportunityStatusEnum.exclude(["converted"]),
 });
 export type UpdateOpportunityStatusRequest = z.infer<typeof UpdateOpportunityStatusRequestSchema>;
 
@@ -2295,10 +3133,32 @@ export const UpdateOpportunityStatusResponseSchema = z.object({
 });
 export type UpdateOpportunityStatusResponse = z.infer<typeof UpdateOpportunityStatusResponseSchema>;
 
+export const ConvertOpportunityToProspectRequestSchema = z.object({
+  workspace_id: z.string().min(1),
+});
+export type ConvertOpportunityToProspectRequest = z.infer<typeof ConvertOpportunityToProspectRequestSchema>;
+
+export const ConvertOpportunityToProspectResponseSchema = z.object({
+  prospect: ProspectSchema,
+  opportunity_id: z.string(),
+  opportunity_status: OpportunityStatusEnum,
+  flip_score_job_id: z.string().optional(),
+});
+export type ConvertOpportunityToProspectResponse = z.infer<typeof ConvertOpportunityToProspectResponseSchema>;
+
 // Market Data (M14)
 
-export const MarketCityEnum = z.enum(["sp"]);
-export type MarketCity = z.infer<typeof MarketCityEnum>;
+// City slug of a registered ingestion profile (sp, curitiba, ...)
+export const MarketCitySchema = z.string().regex(/^[a-z][a-z0-9-]{1,39}$/);
+export type MarketCity = z.infer<typeof MarketCitySchema>;
+
+export const MarketCityOptionSchema = z.object({
+  city: MarketCitySchema,
+  name: z.string(),
+  state: z.string().nullable(),
+  latest_month: z.string(),
+});
+export type MarketCityOption = z.infer<typeof MarketCityOptionSchema>;
 
 export const MarketPropertyClassEnum = z.enum(["geral", "apartamento", "casa", "outros"]);
 export type MarketPropertyClass = z.infer<typeof MarketPropertyClassEnum>;
@@ -2312,12 +3172,12 @@ export const MarketPeriodMonthsSchema = z.union([
 export type MarketPeriodMonths = z.infer<typeof MarketPeriodMonthsSchema>;
 
 export const MarketFiltersQuerySchema = z.object({
-  city: MarketCityEnum.default("sp"),
+  city: MarketCitySchema.default("sp"),
 });
 export type MarketFiltersQuery = z.infer<typeof MarketFiltersQuerySchema>;
 
 export const MarketPriceM2QuerySchema = z.object({
-  city: MarketCityEnum.default("sp"),
+  city: MarketCitySchema.default("sp"),
   as_of_month: z.string().regex(/^\d{4}-\d{2}$/),
   period_months: z.coerce.number().pipe(MarketPeriodMonthsSchema).default(6),
   property_class: MarketPropertyClassEnum.default("geral"),
@@ -2326,7 +3186,7 @@ export const MarketPriceM2QuerySchema = z.object({
 export type MarketPriceM2Query = z.infer<typeof MarketPriceM2QuerySchema>;
 
 export const MarketSeriesQuerySchema = z.object({
-  city: MarketCityEnum.default("sp"),
+  city: MarketCitySchema.default("sp"),
   region_name: z.string().min(1),
   period_months: z.coerce.number().pipe(MarketPeriodMonthsSchema).default(6),
   property_class: MarketPropertyClassEnum.default("geral"),
@@ -2335,8 +3195,9 @@ export const MarketSeriesQuerySchema = z.object({
 export type MarketSeriesQuery = z.infer<typeof MarketSeriesQuerySchema>;
 
 export const MarketFiltersResponseSchema = z.object({
-  city: MarketCityEnum,
+  city: MarketCitySchema,
   source: z.string(),
+  available_cities: z.array(MarketCityOptionSchema),
   available_months: z.array(z.string()),
   period_options: z.array(MarketPeriodMonthsSchema),
   property_classes: z.array(MarketPropertyClassEnum),
@@ -2370,7 +3231,7 @@ export const MarketPriceM2SummarySchema = z.object({
 export type MarketPriceM2Summary = z.infer<typeof MarketPriceM2SummarySchema>;
 
 export const MarketPriceM2ResponseSchema = z.object({
-  city: MarketCityEnum,
+  city: MarketCitySchema,
   as_of_month: z.string().regex(/^\d{4}-\d{2}$/),
   period_months: MarketPeriodMonthsSchema,
   property_class: MarketPropertyClassEnum,
@@ -2393,7 +3254,7 @@ export const MarketSeriesPointSchema = z.object({
 export type MarketSeriesPoint = z.infer<typeof MarketSeriesPointSchema>;
 
 export const MarketSeriesResponseSchema = z.object({
-  city: MarketCityEnum,
+  city: MarketCitySchema,
   region_name: z.string(),
   period_months: MarketPeriodMonthsSchema,
   property_class: MarketPropertyClassEnum,
@@ -2402,13 +3263,35 @@ export const MarketSeriesResponseSchema = z.object({
 });
 export type MarketSeriesResponse = z.infer<typeof MarketSeriesResponseSchema>;
 
-export const MarketIngestionRunStatusEnum = z.enum(["running", "success", "failed"]);
+export const MarketIngestionRunStatusEnum = z.enum(["running", "success", "failed", "rolled_back"]);
 export type MarketIngestionRunStatus = z.infer<typeof MarketIngestionRunStatusEnum>;
 
+export const MarketIngestionContentTypeEnum = z.enum([
+  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+  "text/csv",
+]);
+export type MarketIngestionContentType = z.infer<typeof MarketIngestionContentTypeEnum>;
+
+// auto picks the adapter from the file extension and the city profile
+export const MarketIngestionFormatEnum = z.enum(["auto", "xlsx_monthly", "xlsx", "csv"]);
+export type MarketIngestionFormat = z.infer<typeof MarketIngestionFormatEnum>;
+
+export const MarketIngestionColumnEnum = z.enum([
+  "registration",
+  "neighborhood",
+  "address",
+  "value",
+  "date",
+  "area",
+  "use",
+  "use_description",
+]);
+export type MarketIngestionColumn = z.infer<typeof MarketIngestionColumnEnum>;
+
 export const MarketIngestionUploadUrlRequestSchema = z.object({
-  city: MarketCityEnum.default("sp"),
+  city: MarketCitySchema.default("sp"),
   filename: z.string().min(1),
-  content_type: z.literal("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"),
+  content_type: MarketIngestionContentTypeEnum,
   size_bytes: z.number().int().min(1).max(100 * 1024 * 1024),
 });
 export type MarketIngestionUploadUrlRequest = z.infer<typeof MarketIngestionUploadUrlRequestSchema>;
@@ -2421,10 +3304,14 @@ export const MarketIngestionUploadUrlResponseSchema = z.object({
 export type MarketIngestionUploadUrlResponse = z.infer<typeof MarketIngestionUploadUrlResponseSchema>;
 
 export const RunMarketIngestionRequestSchema = z.object({
-  city: MarketCityEnum.default("sp"),
+  city: MarketCitySchema.default("sp"),
   as_of_month: z.string().regex(/^\d{4}-\d{2}$/),
   storage_key: z.string().min(1),
-  source: z.string().default("itbi_sp_guias_pagas"),
+  // Empty uses the city profile source (itbi_sp_guias_pagas, itbi_curitiba, ...)
+  source: z.string().optional(),
+  format: MarketIngestionFormatEnum.default("auto"),
+  // Overrides the city profile headers: { date: ["DATA DA VENDA"], value: ["VALOR"] }
+  column_mapping: z.record(MarketIngestionColumnEnum, z.array(z.string().min(1)).min(1)).optional(),
   dry_run: z.boolean().default(false),
 });
 export type RunMarketIngestionRequest = z.infer<typeof RunMarketIngestionRequestSchema>;
@@ -2439,7 +3326,7 @@ export type RunMarketIngestionResponse = z.infer<typeof RunMarketIngestionRespon
 export const MarketIngestionRunSchema = z.object({
   id: z.string(),
   source: z.string(),
-  city: MarketCityEnum,
+  city: MarketCitySchema,
   as_of_month: z.string().regex(/^\d{4}-\d{2}$/),
   status: MarketIngestionRunStatusEnum,
   input_rows: z.number(),
@@ -2458,9 +3345,76 @@ export const MarketIngestionRunSchema = z.object({
   file_size_bytes: z.number().nullable(),
   stats: z.record(z.any()).nullable(),
   params: z.record(z.any()).nullable(),
+  rolled_back_at: z.string().nullable().optional(),
+  rolled_back_by: z.string().nullable().optional(),
 });
 export type MarketIngestionRun = z.infer<typeof MarketIngestionRunSchema>;
 
+// Data-quality report stored in market_ingestion_runs.stats.quality
+export const MarketIngestionQualitySampleSchema = z.object({
+  month: z.string(),
+  region_name: z.string(),
+  property_class: z.string(),
+  sql_registration: z.string().optional(),
+  transaction_value: z.number(),
+  area_m2: z.number(),
+  price_m2: z.number(),
+  reason: z.string(),
+  excluded: z.boolean(),
+});
+export type MarketIngestionQualitySample = z.infer<typeof MarketIngestionQualitySampleSchema>;
+
+export const MarketIngestionQualityReportSchema = z.object({
+  dropped_rows: z.record(z.number()).nullable(),
+  flagged_rows: z.record(z.number()).nullable(),
+  samples: z.array(MarketIngestionQualitySampleSchema).optional(),
+  min_tx_count: z.number(),
+  period_months: z.number(),
+  low_sample_total: z.number(),
+  low_sample_bairros: z
+    .array(z.object({ region_name: z.string(), tx_count: z.number() }))
+    .optional(),
+});
+export type MarketIngestionQualityReport = z.infer<typeof MarketIngestionQualityReportSchema>;
+
+export const RollbackMarketIngestionRunResponseSchema = z.object({
+  run_id: z.string(),
+  aggregate_changes: z.number(),
+  transactions_removed: z.number(),
+  transactions_restored: z.number(),
+});
+export type RollbackMarketIngestionRunResponse = z.infer<typeof RollbackMarketIngestionRunResponseSchema>;
+
+export const MarketIngestionRunDiffChangeEnum = z.enum(["added", "removed", "changed"]);
+export type MarketIngestionRunDiffChange = z.infer<typeof MarketIngestionRunDiffChangeEnum>;
+
+export const MarketIngestionRunDiffRowSchema = z.object({
+  region_name: z.string(),
+  month: z.string().regex(/^\d{4}-\d{2}$/),
+  change: MarketIngestionRunDiffChangeEnum,
+  base_median_m2: z.number().nullable(),
+  median_m2: z.number().nullable(),
+  median_change: z.number().nullable(),
+  median_change_pct: z.number().nullable(),
+  base_tx_count: z.number(),
+  tx_count: z.number(),
+  tx_count_change: z.number(),
+});
+export type MarketIngestionRunDiffRow = z.infer<typeof MarketIngestionRunDiffRowSchema>;
+
+export const MarketIngestionRunDiffSchema = z.object({
+  base_run_id: z.string(),
+  run_id: z.string(),
+  rows: z.array(MarketIngestionRunDiffRowSchema),
+  summary: z.object({
+    added: z.number(),
+    removed: z.number(),
+    changed: z.number(),
+    unchanged: z.number(),
+  }),
+});
+export type MarketIngestionRunDiff = z.infer<typeof MarketIngestionRunDiffSchema>;
+
 export const ListMarketIngestionRunsResponseSchema = z.object({
   items: z.array(MarketIngestionRunSchema),
   total: z.number(),
@@ -2471,7 +3425,7 @@ export const MarketRegionAliasStatusEnum = z.enum(["pending", "approved", "rejec
 export type MarketRegionAliasStatus = z.infer<typeof MarketRegionAliasStatusEnum>;
 
 export const ListMarketRegionAliasesQuerySchema = z.object({
-  city: MarketCityEnum.default("sp"),
+  city: MarketCitySchema.default("sp"),
   status: MarketRegionAliasStatusEnum.optional(),
   limit: z.coerce.number().int().min(1).max(200).default(50),
   offset: z.coerce.number().int().min(0).default(0),
@@ -2480,7 +3434,7 @@ export type ListMarketRegionAliasesQuery = z.infer<typeof ListMarketRegionAliase
 
 export const MarketRegionAliasSchema = z.object({
   id: z.string(),
-  city: MarketCityEnum,
+  city: MarketCitySchema,
   alias_raw: z.string().nullable(),
   alias_normalized: z.string(),
   canonical_name: z.string().nullable(),
@@ -2530,13 +3484,41 @@ export type JobRun = z.infer<typeof JobRunSchema>;
 export const ListJobRunsResponseSchema = z.array(JobRunSchema);
 export type ListJobRunsResponse = z.infer<typeof ListJobRunsResponseSchema>;
 
+// Portais suportados pelo scraper de oportunidades
+export const OpportunityScraperSourceEnum = z.enum(["ZAP", "VIVAREAL", "OLX"]);
+export type OpportunityScraperSource = z.infer<typeof OpportunityScraperSourceEnum>;
+
+// Agendamento do placeholder: expressão cron OU intervalo (min. 15 minutos), com jitter
+export const OpportunityScraperScheduleSchema = z.object({
+  enabled: z.boolean(),
+  cron: z.string().optional(),
+  interval_minutes: z.number().int().optional(),
+  jitter_seconds: z.number().int(),
+  limit: z.number().int().optional(),
+  next_run_at: z.string().optional(),
+  consecutive_failures: z.number().int(),
+  last_error: z.string().optional(),
+});
+export type OpportunityScraperSchedule = z.infer<typeof OpportunityScraperScheduleSchema>;
+
+export const OpportunityScraperScheduleRequestSchema = z.object({
+  enabled: z.boolean(),
+  cron: z.string().optional(),
+  interval_minutes: z.number().int().min(15).optional(),
+  jitter_seconds: z.number().int().min(0).max(3600).optional(),
+  limit: z.number().int().min(1).max(200).optional(),
+});
+export type OpportunityScraperScheduleRequest = z.infer<typeof OpportunityScraperScheduleRequestSchema>;
+
 export const OpportunityScraperPlaceholderSchema = z.object({
   id: z.string(),
   state: z.string(),
   city: z.string(),
   neighborhood: z.string(),
+  sources: z.array(OpportunityScraperSourceEnum),
   last_run_at: z.string().nullable(),
   last_job_run_id: z.string().nullable(),
+  schedule: OpportunityScraperScheduleSchema,
   created_at: z.string(),
   updated_at: z.string(),
 });
@@ -2551,6 +3533,8 @@ export const UpsertOpportunityScraperPlaceholderRequestSchema = z.object({
   state: z.string().min(2).max(2),
   city: z.string().min(1),
   neighborhood: z.string().min(1),
+  sources: z.array(OpportunityScraperSourceEnum).min(1).optional(),
+  schedule: OpportunityScraperScheduleRequestSchema.optional(),
 });
 export type UpsertOpportunityScraperPlaceholderRequest = z.infer<typeof UpsertOpportunityScraperPlaceholderRequestSchema>;
 
@@ -2559,12 +3543,14 @@ export const RunOpportunityScraperRequestSchema = z.object({
   city: z.string().optional().default(""),
   neighborhood: z.string().optional().default(""),
   placeholder_id: z.string().uuid().optional(),
+  sources: z.array(OpportunityScraperSourceEnum).min(1).optional(),
   limit: z.number().int().min(1).max(200).optional(),
   dry_run: z.boolean().optional().default(false),
 });
 export type RunOpportunityScraperRequest = z.infer<typeof RunOpportunityScraperRequestSchema>;
 
 export const OpportunityScraperResultListingSchema = z.object({
+  source: z.string(),
   source_listing_id: z.string(),
   canonical_url: z.string(),
   title: z.string(),
@@ -2580,6 +3566,7 @@ export const OpportunityScraperResultListingSchema = z.object({
   price_per_m2: z.number(),
   market_median_m2: z.number(),
   discount_pct: z.number(),
+  parse_quality: z.number().default(0),
 });
 export type OpportunityScraperResultListing = z.infer<typeof OpportunityScraperResultListingSchema>;
 
@@ -2590,7 +3577,18 @@ export const RunOpportunityScraperResponseSchema = z.object({
     total_received: z.number(),
     new_listings: z.number(),
     updated: z.number(),
+    price_drops: z.number().default(0),
+    relisted: z.number().default(0),
+    delisted: z.number().default(0),
+    alerts: z.number().default(0),
     median_price_m2: z.number(),
+    sources: z.array(
+      z.object({
+        source: z.string(),
+        listings: z.number(),
+        error: z.string().optional(),
+      })
+    ),
   }),
   listings: z.array(OpportunityScraperResultListingSchema),
   placeholder: OpportunityScraperPlaceholderSchema.optional(),
@@ -66,6 +66,11 @@ func main() {
 		LLMClient:                llmClient,
 		StorageProvider:          cfg.S3.Provider,
 		OfferIntelligenceRollout: cfg.OfferIntelligenceRollout,
+		ScraperScheduler: httpapi.ScraperSchedulerConfig{
+			Enabled:        cfg.ScraperScheduler.Enabled,
+			MaxConcurrency: cfg.ScraperScheduler.MaxConcurrency,
+		},
+		MarketGeoDir: cfg.MarketGeoDir,
 	})
 
 	srv := &http.Server{
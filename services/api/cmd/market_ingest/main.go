@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -17,6 +19,9 @@ type config struct {
 	FilePath  string
 	City      string
 	Source    string
+	Format    string
+	Columns   marketingest.ColumnMapping
+	Geo       marketingest.GeoConfig
 	AsOfMonth string
 	DBURL     string
 	DryRun    bool
@@ -36,6 +41,9 @@ func main() {
 		FilePath:    cfg.FilePath,
 		City:        cfg.City,
 		Source:      cfg.Source,
+		Format:      cfg.Format,
+		Columns:     cfg.Columns,
+		Geo:         cfg.Geo,
 		AsOfMonth:   asOfMonth,
 		DryRun:      cfg.DryRun,
 		MaxLLMCalls: cfg.LLMMax,
@@ -59,7 +67,8 @@ func main() {
 		if runErr != nil {
 			log.Fatalf("dry-run failed: %v", runErr)
 		}
-		log.Printf("parsed workbook: input_rows=%d valid_rows=%d months=%d records=%d", result.InputRows, result.ValidRows, len(result.TouchedMonths), result.ValidRows)
+		log.Printf("parsed %s: input_rows=%d valid_rows=%d months=%d records=%d encoding=%s", result.Format, result.InputRows, result.ValidRows, len(result.TouchedMonths), result.ValidRows, result.Encoding)
+		logRegionMethods(result)
 		log.Printf("dry-run completed; no database writes performed")
 		return
 	}
@@ -79,16 +88,40 @@ func main() {
 		log.Fatalf("ingestion failed: %v", runErr)
 	}
 
-	log.Printf("parsed workbook: input_rows=%d valid_rows=%d months=%d records=%d", result.InputRows, result.ValidRows, len(result.TouchedMonths), result.ValidRows)
+	log.Printf("parsed %s: input_rows=%d valid_rows=%d months=%d records=%d encoding=%s", result.Format, result.InputRows, result.ValidRows, len(result.TouchedMonths), result.ValidRows, result.Encoding)
+	logRegionMethods(result)
 	log.Printf("ingestion completed: run_id=%s output_groups=%d", result.RunID, result.OutputGroups)
 }
 
+func logRegionMethods(result marketingest.RunResult) {
+	if len(result.RegionMethods) == 0 {
+		return
+	}
+	methods := make([]string, 0, len(result.RegionMethods))
+	for method := range result.RegionMethods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	parts := make([]string, 0, len(methods))
+	for _, method := range methods {
+		parts = append(parts, fmt.Sprintf("%s=%d", method, result.RegionMethods[method]))
+	}
+	log.Printf("bairro resolution: %s", strings.Join(parts, " "))
+}
+
 func parseFlags() config {
 	cfg := config{}
-
-	flag.StringVar(&cfg.FilePath, "file", "docs/reference/GUIAS DE ITBI PAGAS (28012026) XLS.xlsx", "Path to XLSX file")
-	flag.StringVar(&cfg.City, "city", marketingest.DefaultCity, "City slug (M16 supports: sp)")
-	flag.StringVar(&cfg.Source, "source", marketingest.DefaultSource, "Source id")
+	var columnSpec, geoDir string
+
+	flag.StringVar(&cfg.FilePath, "file", "docs/reference/GUIAS DE ITBI PAGAS (28012026) XLS.xlsx", "Path to XLSX or CSV file")
+	flag.StringVar(&cfg.City, "city", marketingest.DefaultCity, "City slug ("+strings.Join(marketingest.SupportedCities(), ", ")+")")
+	flag.StringVar(&cfg.Source, "source", "", "Source id (defaults to the city profile source)")
+	flag.StringVar(&cfg.Format, "format", "auto", "Input format: auto, "+strings.Join(marketingest.SupportedFormats(), ", "))
+	flag.StringVar(&columnSpec, "columns", "", "Column mapping overrides, e.g. date=DATA DA VENDA|DATA,value=VALOR DECLARADO")
+	flag.StringVar(&geoDir, "geo-dir", os.Getenv("MARKET_GEO_DIR"), "Directory with <city>/bairros.geojson (or .shp) and <city>/logradouros.csv")
+	flag.StringVar(&cfg.Geo.BoundariesPath, "boundaries", "", "Bairro polygons (.geojson or .shp); overrides --geo-dir")
+	flag.StringVar(&cfg.Geo.NameField, "boundary-name-field", "", "Polygon attribute holding the bairro name (default: common names such as NOME, NM_BAIRRO)")
+	flag.StringVar(&cfg.Geo.StreetIndexPath, "street-index", "", "CEP/logradouro CSV with coordinates; overrides --geo-dir")
 	flag.StringVar(&cfg.AsOfMonth, "as-of-month", "", "Reference month in YYYY-MM")
 	flag.StringVar(&cfg.DBURL, "db-url", os.Getenv("DATABASE_URL"), "Postgres DATABASE_URL")
 	flag.BoolVar(&cfg.DryRun, "dry-run", false, "Parse workbook without DB writes")
@@ -103,11 +136,23 @@ func parseFlags() config {
 	cfg.AsOfMonth = strings.TrimSpace(cfg.AsOfMonth)
 	cfg.LLMModel = strings.TrimSpace(cfg.LLMModel)
 
-	if cfg.City != marketingest.DefaultCity {
-		log.Fatalf("unsupported city %q (M16 supports only %q)", cfg.City, marketingest.DefaultCity)
+	profile, err := marketingest.CityProfileFor(cfg.City)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
 	if cfg.Source == "" {
-		log.Fatalf("--source is required")
+		cfg.Source = profile.DefaultSource
+	}
+	if cfg.Format, err = marketingest.NormalizeFormat(cfg.Format); err != nil {
+		log.Fatalf("invalid --format: %v", err)
+	}
+	if cfg.Columns, err = marketingest.ParseColumnMapping(columnSpec); err != nil {
+		log.Fatalf("invalid --columns: %v", err)
+	}
+	if strings.TrimSpace(cfg.Geo.BoundariesPath) == "" && strings.TrimSpace(cfg.Geo.StreetIndexPath) == "" {
+		nameField := cfg.Geo.NameField
+		cfg.Geo = marketingest.GeoConfigFromDir(strings.TrimSpace(geoDir), cfg.City)
+		cfg.Geo.NameField = nameField
 	}
 	if cfg.FilePath == "" {
 		log.Fatalf("--file is required")
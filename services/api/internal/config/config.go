@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -11,10 +12,17 @@ type Config struct {
 	BetterAuthJWKSURL        string
 	InternalAPISecret        string
 	OfferIntelligenceRollout string
+	MarketGeoDir             string // <dir>/<city>/bairros.geojson|.shp and logradouros.csv
+	ScraperScheduler         ScraperSchedulerConfig
 	S3                       S3Config
 	LLM                      LLMConfig
 }
 
+type ScraperSchedulerConfig struct {
+	Enabled        bool
+	MaxConcurrency int // Chrome instances shared by manual and scheduled runs
+}
+
 type LLMConfig struct {
 	OpenRouterAPIKey string
 	OpenRouterModel  string
@@ -38,6 +46,11 @@ func Load() (Config, error) {
 		BetterAuthJWKSURL:        getenv("BETTER_AUTH_JWKS_URL", "http://localhost:3000/api/auth/jwks"),
 		InternalAPISecret:        os.Getenv("INTERNAL_API_SECRET"),
 		OfferIntelligenceRollout: getenv("OFFER_INTELLIGENCE_ROLLOUT", "off"),
+		MarketGeoDir:             os.Getenv("MARKET_GEO_DIR"),
+		ScraperScheduler: ScraperSchedulerConfig{
+			Enabled:        getenv("SCRAPER_SCHEDULER_ENABLED", "false") == "true",
+			MaxConcurrency: getenvInt("SCRAPER_MAX_CONCURRENCY", 1),
+		},
 		S3: S3Config{
 			Endpoint:       getenv("S3_ENDPOINT", "http://localhost:8000/storage/v1/s3"),
 			PublicEndpoint: getenv("S3_PUBLIC_ENDPOINT", getenv("S3_ENDPOINT", "http://localhost:8000/storage/v1/s3")),
@@ -57,6 +70,9 @@ func Load() (Config, error) {
 	if cfg.DatabaseURL == "" {
 		return cfg, errors.New("DATABASE_URL is required")
 	}
+	if cfg.ScraperScheduler.MaxConcurrency < 1 {
+		return cfg, errors.New("SCRAPER_MAX_CONCURRENCY must be at least 1")
+	}
 	if cfg.OfferIntelligenceRollout != "off" &&
 		cfg.OfferIntelligenceRollout != "internal" &&
 		cfg.OfferIntelligenceRollout != "all" {
@@ -73,3 +89,11 @@ func getenv(key, fallback string) string {
 	}
 	return v
 }
+
+func getenvInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,206 @@
+package flipscore
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultGoodDealROI is the realized ROI (%) from which a sold deal counts as a good flip
+const DefaultGoodDealROI = 15.0
+
+// calibrationBucketWidth splits scores into 0-19, 20-39, 40-59, 60-79 and 80-100
+const calibrationBucketWidth = 20
+
+// BacktestOutcome is a sold deal with the score a version gave it
+type BacktestOutcome struct {
+	Version     string
+	Score       int
+	RealizedROI float64 // %
+}
+
+// CalibrationBucket summarizes realized outcomes for a score range
+type CalibrationBucket struct {
+	ScoreMin        int      `json:"score_min"`
+	ScoreMax        int      `json:"score_max"`
+	N               int      `json:"n"`
+	MeanRealizedROI *float64 `json:"mean_realized_roi"` // nil when the bucket is empty
+	GoodDealRate    *float64 `json:"good_deal_rate"`    // 0-1
+}
+
+// TopDecilePrecision measures how many of the highest-scored deals were good flips
+type TopDecilePrecision struct {
+	N         int      `json:"n"`
+	MinScore  int      `json:"min_score"`
+	Precision *float64 `json:"precision"` // 0-1, good deals within the top decile
+	BaseRate  *float64 `json:"base_rate"` // 0-1, good deals across all sold deals
+	Lift      *float64 `json:"lift"`      // precision / base_rate
+}
+
+// VersionBacktest is the backtest of one score version
+type VersionBacktest struct {
+	Version     string              `json:"version"`
+	N           int                 `json:"n"`
+	SpearmanRho *float64            `json:"spearman_rho"` // rank correlation score x realized ROI
+	Calibration []CalibrationBucket `json:"calibration"`
+	TopDecile   TopDecilePrecision  `json:"top_decile"`
+}
+
+// Backtest groups the outcomes by score version and measures how well each version ranked
+// the realized ROI. A deal is "good" when its realized ROI is at least goodROI.
+func Backtest(outcomes []BacktestOutcome, goodROI float64) []VersionBacktest {
+	byVersion := make(map[string][]BacktestOutcome)
+	for _, o := range outcomes {
+		byVersion[o.Version] = append(byVersion[o.Version], o)
+	}
+
+	versions := make([]string, 0, len(byVersion))
+	for v := range byVersion {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	out := make([]VersionBacktest, 0, len(versions))
+	for _, v := range versions {
+		out = append(out, backtestVersion(v, byVersion[v], goodROI))
+	}
+	return out
+}
+
+func backtestVersion(version string, outcomes []BacktestOutcome, goodROI float64) VersionBacktest {
+	scores := make([]float64, len(outcomes))
+	rois := make([]float64, len(outcomes))
+	good := 0
+	for i, o := range outcomes {
+		scores[i] = float64(o.Score)
+		rois[i] = o.RealizedROI
+		if o.RealizedROI >= goodROI {
+			good++
+		}
+	}
+
+	result := VersionBacktest{
+		Version:     version,
+		N:           len(outcomes),
+		SpearmanRho: SpearmanRho(scores, rois),
+		Calibration: calibrationBuckets(outcomes, goodROI),
+	}
+
+	if len(outcomes) == 0 {
+		return result
+	}
+
+	// Top decile by score (at least one deal); ties at the cut are broken by input order
+	sorted := append([]BacktestOutcome(nil), outcomes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Score > sorted[j].Score })
+	n := int(math.Ceil(float64(len(sorted)) / 10))
+	topGood := 0
+	for _, o := range sorted[:n] {
+		if o.RealizedROI >= goodROI {
+			topGood++
+		}
+	}
+
+	precision := round2(float64(topGood) / float64(n))
+	baseRate := round2(float64(good) / float64(len(outcomes)))
+	result.TopDecile = TopDecilePrecision{
+		N:         n,
+		MinScore:  sorted[n-1].Score,
+		Precision: &precision,
+		BaseRate:  &baseRate,
+	}
+	if good > 0 {
+		lift := round2((float64(topGood) / float64(n)) / (float64(good) / float64(len(outcomes))))
+		result.TopDecile.Lift = &lift
+	}
+	return result
+}
+
+func calibrationBuckets(outcomes []BacktestOutcome, goodROI float64) []CalibrationBucket {
+	count := 100 / calibrationBucketWidth
+	buckets := make([]CalibrationBucket, count)
+	sums := make([]float64, count)
+	goods := make([]int, count)
+	for i := range buckets {
+		buckets[i].ScoreMin = i * calibrationBucketWidth
+		buckets[i].ScoreMax = (i+1)*calibrationBucketWidth - 1
+	}
+	buckets[count-1].ScoreMax = 100
+
+	for _, o := range outcomes {
+		i := int(clamp(float64(o.Score), 0, 100)) / calibrationBucketWidth
+		if i >= count {
+			i = count - 1
+		}
+		buckets[i].N++
+		sums[i] += o.RealizedROI
+		if o.RealizedROI >= goodROI {
+			goods[i]++
+		}
+	}
+
+	for i := range buckets {
+		if buckets[i].N == 0 {
+			continue
+		}
+		mean := round2(sums[i] / float64(buckets[i].N))
+		rate := round2(float64(goods[i]) / float64(buckets[i].N))
+		buckets[i].MeanRealizedROI = &mean
+		buckets[i].GoodDealRate = &rate
+	}
+	return buckets
+}
+
+// SpearmanRho returns the Spearman rank correlation of x and y (ties get average ranks).
+// Returns nil with fewer than 3 pairs or when either side has no variance.
+func SpearmanRho(x, y []float64) *float64 {
+	if len(x) != len(y) || len(x) < 3 {
+		return nil
+	}
+	rx, ry := averageRanks(x), averageRanks(y)
+
+	// Pearson correlation of the ranks (exact with ties)
+	n := float64(len(rx))
+	var meanX, meanY float64
+	for i := range rx {
+		meanX += rx[i]
+		meanY += ry[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range rx {
+		dx, dy := rx[i]-meanX, ry[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return nil
+	}
+	rho := math.Round(cov/math.Sqrt(varX*varY)*10000) / 10000
+	return &rho
+}
+
+// averageRanks returns 1-based ranks, averaging the ranks of tied values
+func averageRanks(values []float64) []float64 {
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return values[idx[a]] < values[idx[b]] })
+
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(idx); {
+		j := i
+		for j+1 < len(idx) && values[idx[j+1]] == values[idx[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[idx[k]] = rank
+		}
+		i = j + 1
+	}
+	return ranks
+}
@@ -2,7 +2,7 @@ package flipscore
 
 import "math"
 
-// CalculateSPrice computes S_price (40% weight) - "cheap vs. your prospects"
+// CalculateSPrice computes S_price (40% default weight) - "cheap vs. your prospects"
 // Uses percentile rank within cohort (neighborhood or workspace)
 // Lower price_per_sqm = higher score
 func CalculateSPrice(cohort CohortStats) float64 {
@@ -15,7 +15,7 @@ func CalculateSPrice(cohort CohortStats) float64 {
 	return clamp(round2(100*(1-cohort.PercentileRank)), 0, 100)
 }
 
-// CalculateSCarry computes S_carry (15% weight) - recurring cost relative to ticket
+// CalculateSCarry computes S_carry (15% default weight) - recurring cost relative to ticket
 // carry_ratio = carry_month / asking_price
 // Linear interpolation: <= 0.10% → 100, >= 1.00% → 0
 func CalculateSCarry(askingPrice, condoFee, iptu *float64) (float64, *float64) {
@@ -64,7 +64,7 @@ func CalculateSCarry(askingPrice, condoFee, iptu *float64) (float64, *float64) {
 	return round2(score), carryRatioPtr
 }
 
-// CalculateSLiquidity computes S_liquidity (20% weight) - simple "saleability" proxy
+// CalculateSLiquidity computes S_liquidity (20% default weight) - simple "saleability" proxy
 // Base 50, with adjustments clamped 0-100
 func CalculateSLiquidity(bedrooms, parking *int, areaUsable *float64, elevator *bool) float64 {
 	score := 50.0
@@ -106,10 +106,10 @@ func CalculateSLiquidity(bedrooms, parking *int, areaUsable *float64, elevator *
 	return clamp(score, 0, 100)
 }
 
-// CalculateSRisk computes S_risk (25% weight) - risk penalties + rehab level
+// CalculateSRisk computes S_risk (25% default weight) - risk penalties + rehab level
 // No risk_assessment → 50
-// With assessment: 100 - rehab_penalty - risk_penalty
-func CalculateSRisk(assessment *FlipRiskAssessment) float64 {
+// With assessment: 100 - rehab_penalty - risk_penalty, using the profile penalties
+func CalculateSRisk(assessment *FlipRiskAssessment, profile ScoringProfile) float64 {
 	if assessment == nil {
 		return 50 // Default when no LLM assessment
 	}
@@ -117,18 +117,13 @@ func CalculateSRisk(assessment *FlipRiskAssessment) float64 {
 	// Rehab penalty
 	rehabPenalty := float64(0)
 	if assessment.RehabLevel != nil {
-		if penalty, ok := RehabPenalties[*assessment.RehabLevel]; ok {
-			rehabPenalty = penalty
-		}
+		rehabPenalty = profile.rehabPenalty(*assessment.RehabLevel)
 	}
 
 	// Red flag penalty: Σ (weight[category] * severity * confidence)
 	riskPenalty := float64(0)
 	for _, flag := range assessment.RedFlags {
-		weight := CategoryWeights[flag.Category]
-		if weight == 0 {
-			weight = 5 // Default for unknown category
-		}
+		weight := profile.categoryWeight(flag.Category)
 		riskPenalty += weight * float64(flag.Severity) * flag.Confidence
 	}
 
@@ -193,7 +188,7 @@ func interpolate(x, x0, x1, y0, y1 float64) float64 {
 // V1 Components (Economics-based scoring)
 // ============================================================================
 
-// CalculateSEcon computes S_econ (60% weight in v1) - ROI-based score
+// CalculateSEcon computes S_econ (60% default weight in v1) - ROI-based score
 // ROI interpolation: 0%→0, 10%→40, 20%→70, 30%→90, 40%+→100
 func CalculateSEcon(roi float64) float64 {
 	switch {
@@ -212,19 +207,6 @@ func CalculateSEcon(roi float64) float64 {
 	}
 }
 
-// CalculateBreakEvenSalePrice calculates minimum sale price for 0% profit
-// breakEven = investmentTotal / (1 - brokerRate - pjTaxRate)
-func CalculateBreakEvenSalePrice(investmentTotal, brokerRate, pjTaxRate float64) float64 {
-	if investmentTotal <= 0 {
-		return 0
-	}
-	denominator := 1 - brokerRate - pjTaxRate
-	if denominator <= 0 {
-		return 0 // Invalid rates
-	}
-	return round2(investmentTotal / denominator)
-}
-
 // CalculateSDataV1 computes data completeness score for v1
 // Core v1 fields: expected_sale_price (required), offer_price or asking_price
 func CalculateSDataV1(inputs ProspectInputsV1) (float64, []string) {
@@ -270,3 +252,49 @@ func CanCalculateV1(inputs ProspectInputsV1) bool {
 	hasSalePrice := inputs.ExpectedSalePrice != nil
 	return hasPrice && hasSalePrice
 }
+
+// ============================================================================
+// V2 Components (Market-based price)
+// ============================================================================
+
+// HasMarketData reports whether the market aggregates can score the price
+func HasMarketData(market *MarketStats) bool {
+	return market != nil && market.MedianM2 > 0 && market.P25M2 > 0 && market.P75M2 >= market.P25M2 && market.TxCount > 0
+}
+
+// CalculateSPriceMarket computes S_price against ITBI transactions of the same bairro and class.
+// Interpolation over the market quartiles:
+// <= 0.8*P25 → 100, P25 → 85, median → 60, P75 → 35, >= 1.2*P75 → 0
+// The score is then pulled towards 50 by the reliability of the comparison
+// (resolver match confidence × transaction depth, full depth at 30 transactions).
+func CalculateSPriceMarket(pricePerSqm float64, market MarketStats) (float64, float64) {
+	p25, median, p75 := market.P25M2, market.MedianM2, market.P75M2
+
+	var raw float64
+	switch {
+	case pricePerSqm <= 0.8*p25:
+		raw = 100
+	case pricePerSqm <= p25:
+		raw = interpolate(pricePerSqm, 0.8*p25, p25, 100, 85)
+	case pricePerSqm <= median:
+		raw = interpolateOrFlat(pricePerSqm, p25, median, 85, 60)
+	case pricePerSqm <= p75:
+		raw = interpolateOrFlat(pricePerSqm, median, p75, 60, 35)
+	case pricePerSqm < 1.2*p75:
+		raw = interpolate(pricePerSqm, p75, 1.2*p75, 35, 0)
+	default:
+		raw = 0
+	}
+
+	reliability := clamp(market.MatchConfidence, 0, 1) * math.Min(float64(market.TxCount)/30, 1)
+	score := 50 + (raw-50)*reliability
+	return clamp(round2(score), 0, 100), round2(reliability)
+}
+
+// interpolateOrFlat interpolates, returning y1 when the interval is degenerate (x0 == x1)
+func interpolateOrFlat(x, x0, x1, y0, y1 float64) float64 {
+	if x1 <= x0 {
+		return y1
+	}
+	return interpolate(x, x0, x1, y0, y1)
+}
@@ -0,0 +1,202 @@
+package flipscore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultProfileName is the name of the built-in scoring profile
+const DefaultProfileName = "default"
+
+// DefaultCategoryWeight is the red flag weight for categories not in the profile
+const DefaultCategoryWeight = 5
+
+// Weight sums are accepted within this tolerance
+const profileWeightTolerance = 0.0001
+
+// RedFlagCategories lists the red flag categories returned by the LLM
+var RedFlagCategories = []string{
+	"legal", "structural", "moisture", "condo_rules", "security", "listing_inconsistency", "noise", "access",
+}
+
+// RehabLevels lists the rehab levels returned by the LLM
+var RehabLevels = []string{"light", "medium", "heavy"}
+
+// WeightsV0 are the v0/v2 component weights (must sum to 1.0)
+type WeightsV0 struct {
+	Price     float64 `json:"price"`
+	Carry     float64 `json:"carry"`
+	Liquidity float64 `json:"liquidity"`
+	Risk      float64 `json:"risk"`
+}
+
+// WeightsV1 are the v1 component weights (must sum to 1.0)
+type WeightsV1 struct {
+	Econ      float64 `json:"econ"`
+	Liquidity float64 `json:"liquidity"`
+	Risk      float64 `json:"risk"`
+}
+
+// ScoringProfile holds the workspace-tunable parameters of the Flip Score
+type ScoringProfile struct {
+	Name            string             `json:"name"`
+	WeightsV0       WeightsV0          `json:"weights_v0"`
+	WeightsV1       WeightsV1          `json:"weights_v1"`
+	CategoryWeights map[string]float64 `json:"category_weights"` // red flag penalty weight per category
+	RehabPenalties  map[string]float64 `json:"rehab_penalties"`  // S_risk penalty per rehab level
+}
+
+// DefaultProfile returns the built-in scoring profile
+func DefaultProfile() ScoringProfile {
+	return ScoringProfile{
+		Name: DefaultProfileName,
+		WeightsV0: WeightsV0{
+			Price:     0.40,
+			Carry:     0.15,
+			Liquidity: 0.20,
+			Risk:      0.25,
+		},
+		WeightsV1: WeightsV1{
+			Econ:      0.60,
+			Liquidity: 0.20,
+			Risk:      0.20,
+		},
+		CategoryWeights: map[string]float64{
+			"legal":                 10,
+			"structural":            9,
+			"moisture":              8,
+			"condo_rules":           6,
+			"security":              6,
+			"listing_inconsistency": 5,
+			"noise":                 4,
+			"access":                3,
+		},
+		RehabPenalties: map[string]float64{
+			"light":  0,
+			"medium": 8,
+			"heavy":  15,
+		},
+	}
+}
+
+// WithDefaults fills missing red flag categories and rehab levels from the default profile
+func (p ScoringProfile) WithDefaults() ScoringProfile {
+	defaults := DefaultProfile()
+	if p.Name == "" {
+		p.Name = defaults.Name
+	}
+
+	categories := make(map[string]float64, len(defaults.CategoryWeights))
+	for k, v := range defaults.CategoryWeights {
+		categories[k] = v
+	}
+	for k, v := range p.CategoryWeights {
+		categories[k] = v
+	}
+	p.CategoryWeights = categories
+
+	rehab := make(map[string]float64, len(defaults.RehabPenalties))
+	for k, v := range defaults.RehabPenalties {
+		rehab[k] = v
+	}
+	for k, v := range p.RehabPenalties {
+		rehab[k] = v
+	}
+	p.RehabPenalties = rehab
+
+	return p
+}
+
+// Validate checks that both weight sets are non-negative and sum to 1, and that penalties
+// only reference known categories and rehab levels with values between 0 and 100
+func (p ScoringProfile) Validate() error {
+	v0 := []float64{p.WeightsV0.Price, p.WeightsV0.Carry, p.WeightsV0.Liquidity, p.WeightsV0.Risk}
+	if err := validateWeights("weights_v0", v0); err != nil {
+		return err
+	}
+	v1 := []float64{p.WeightsV1.Econ, p.WeightsV1.Liquidity, p.WeightsV1.Risk}
+	if err := validateWeights("weights_v1", v1); err != nil {
+		return err
+	}
+
+	for _, category := range sortedKeys(p.CategoryWeights) {
+		if !contains(RedFlagCategories, category) {
+			return fmt.Errorf("category_weights.%s is not a known red flag category", category)
+		}
+		if w := p.CategoryWeights[category]; w < 0 || w > 100 || math.IsNaN(w) {
+			return fmt.Errorf("category_weights.%s must be between 0 and 100", category)
+		}
+	}
+	for _, level := range sortedKeys(p.RehabPenalties) {
+		if !contains(RehabLevels, level) {
+			return fmt.Errorf("rehab_penalties.%s must be light, medium or heavy", level)
+		}
+		if v := p.RehabPenalties[level]; v < 0 || v > 100 || math.IsNaN(v) {
+			return fmt.Errorf("rehab_penalties.%s must be between 0 and 100", level)
+		}
+	}
+	return nil
+}
+
+// Hash returns a stable hash of everything that affects the score (the name is excluded),
+// recorded in the breakdown so a score can be reproduced with the same profile
+func (p ScoringProfile) Hash() string {
+	p = p.WithDefaults()
+	payload, _ := json.Marshal(struct {
+		WeightsV0       WeightsV0          `json:"weights_v0"`
+		WeightsV1       WeightsV1          `json:"weights_v1"`
+		CategoryWeights map[string]float64 `json:"category_weights"`
+		RehabPenalties  map[string]float64 `json:"rehab_penalties"`
+	}{p.WeightsV0, p.WeightsV1, p.CategoryWeights, p.RehabPenalties})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// categoryWeight returns the red flag weight for a category
+func (p ScoringProfile) categoryWeight(category string) float64 {
+	if w, ok := p.CategoryWeights[category]; ok {
+		return w
+	}
+	return DefaultCategoryWeight
+}
+
+// rehabPenalty returns the S_risk penalty for a rehab level (0 when unknown)
+func (p ScoringProfile) rehabPenalty(level string) float64 {
+	return p.RehabPenalties[level]
+}
+
+func validateWeights(name string, weights []float64) error {
+	sum := 0.0
+	for _, w := range weights {
+		if w < 0 || math.IsNaN(w) {
+			return fmt.Errorf("%s must be non-negative", name)
+		}
+		sum += w
+	}
+	if math.Abs(sum-1) > profileWeightTolerance {
+		return fmt.Errorf("%s must sum to 1 (got %.4f)", name, sum)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
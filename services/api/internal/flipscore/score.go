@@ -6,25 +6,32 @@ import (
 	"github.com/widia-projects/widia-flip/services/api/internal/viability"
 )
 
-// Calculate computes the Flip Score v0 using the inputs, optional risk assessment, and cohort stats.
-// The score is deterministic given the same inputs.
+// Calculate computes the Flip Score v0 using the inputs, optional risk assessment, cohort stats
+// and the workspace scoring profile.
+// The score is deterministic given the same inputs and profile.
 // If riskAssessment is nil, S_risk defaults to 50 and llm_confidence to 0.
-func Calculate(inputs ProspectInputs, riskAssessment *FlipRiskAssessment, cohort CohortStats) Result {
-	// Calculate all components
-	sPrice := CalculateSPrice(cohort)
+func Calculate(inputs ProspectInputs, riskAssessment *FlipRiskAssessment, cohort CohortStats, profile ScoringProfile) Result {
+	score, confidence, breakdown := scoreWithPrice(inputs, riskAssessment, CalculateSPrice(cohort), cohort, profile)
+	return Result{
+		Score:      score,
+		Version:    VersionV0,
+		Confidence: confidence,
+		Breakdown:  breakdown,
+		ComputedAt: time.Now().UTC(),
+	}
+}
+
+// scoreWithPrice is the scoring shared by v0 and v2, which only differ in how S_price is
+// measured: the other components, the profile v0 weights, the m_data/m_llm multipliers and
+// the confidence. Returns the final score, the confidence and the breakdown.
+func scoreWithPrice(inputs ProspectInputs, riskAssessment *FlipRiskAssessment, sPrice float64, cohort CohortStats, profile ScoringProfile) (int, float64, Breakdown) {
+	profile = profile.WithDefaults()
+
 	sCarry, carryRatio := CalculateSCarry(inputs.AskingPrice, inputs.CondoFee, inputs.IPTU)
 	sLiquidity := CalculateSLiquidity(inputs.Bedrooms, inputs.Parking, inputs.AreaUsable, inputs.Elevator)
-	sRisk := CalculateSRisk(riskAssessment)
+	sRisk := CalculateSRisk(riskAssessment, profile)
 	sData, missingFields := CalculateSData(inputs)
 
-	// Calculate price per sqm for intermediate values
-	var pricePerSqm *float64
-	if inputs.AskingPrice != nil && inputs.AreaUsable != nil && *inputs.AreaUsable > 0 {
-		pps := *inputs.AskingPrice / *inputs.AreaUsable
-		pricePerSqm = &pps
-	}
-
-	// Build components struct
 	components := Components{
 		SPrice:     sPrice,
 		SCarry:     sCarry,
@@ -34,12 +41,11 @@ func Calculate(inputs ProspectInputs, riskAssessment *FlipRiskAssessment, cohort
 	}
 
 	// Calculate raw score (weighted average)
-	rawScore := WeightPrice*sPrice +
-		WeightCarry*sCarry +
-		WeightLiquidity*sLiquidity +
-		WeightRisk*sRisk
+	rawScore := profile.WeightsV0.Price*sPrice +
+		profile.WeightsV0.Carry*sCarry +
+		profile.WeightsV0.Liquidity*sLiquidity +
+		profile.WeightsV0.Risk*sRisk
 
-	// Calculate multipliers
 	// Data quality multiplier: m_data = 0.6 + 0.4*(S_data/100)
 	mData := 0.6 + 0.4*(sData/100)
 
@@ -51,34 +57,27 @@ func Calculate(inputs ProspectInputs, riskAssessment *FlipRiskAssessment, cohort
 	}
 	mLLM := 0.7 + 0.3*llmConfidence
 
-	// Final score
 	finalScore := rawScore * mData * mLLM
-	finalScoreInt := int(clamp(round2(finalScore), 0, 100))
 
-	// Calculate overall confidence (combination of data completeness and LLM confidence)
+	// Overall confidence combines data completeness and LLM confidence
 	confidence := (sData/100)*0.5 + llmConfidence*0.5
 
-	return Result{
-		Score:      finalScoreInt,
-		Version:    VersionV0,
-		Confidence: round2(confidence),
-		Breakdown: Breakdown{
-			Components: components,
-			Intermediate: Intermediate{
-				PricePerSqm: pricePerSqm,
-				CarryRatio:  carryRatio,
-				CohortN:     cohort.N,
-				CohortScope: cohort.Scope,
-			},
-			RiskAssessment: riskAssessment,
-			MissingFields:  missingFields,
-			Multipliers: Multipliers{
-				MData: round2(mData),
-				MLLM:  round2(mLLM),
-			},
-			RawScore: round2(rawScore),
+	return int(clamp(round2(finalScore), 0, 100)), round2(confidence), Breakdown{
+		Components: components,
+		Intermediate: Intermediate{
+			PricePerSqm: ComputePricePerSqm(inputs.AskingPrice, inputs.AreaUsable),
+			CarryRatio:  carryRatio,
+			CohortN:     cohort.N,
+			CohortScope: cohort.Scope,
 		},
-		ComputedAt: time.Now().UTC(),
+		RiskAssessment: riskAssessment,
+		MissingFields:  missingFields,
+		Multipliers: Multipliers{
+			MData: round2(mData),
+			MLLM:  round2(mLLM),
+		},
+		RawScore:    round2(rawScore),
+		ProfileHash: profile.Hash(),
 	}
 }
 
@@ -98,7 +97,9 @@ func ComputePricePerSqm(askingPrice, areaUsable *float64) *float64 {
 // CalculateV1 computes Flip Score v1 using economics-based scoring.
 // Requires: ExpectedSalePrice and (OfferPrice or AskingPrice)
 // Uses viability.CalculateCash for ROI calculation.
-func CalculateV1(inputs ProspectInputsV1, riskAssessment *FlipRiskAssessment, cohort CohortStats, settings viability.CashSettings) ResultV1 {
+func CalculateV1(inputs ProspectInputsV1, riskAssessment *FlipRiskAssessment, cohort CohortStats, settings viability.CashSettings, profile ScoringProfile) ResultV1 {
+	profile = profile.WithDefaults()
+
 	// Determine purchase price (offer_price or asking_price)
 	var purchasePrice *float64
 	if inputs.OfferPrice != nil {
@@ -113,14 +114,18 @@ func CalculateV1(inputs ProspectInputsV1, riskAssessment *FlipRiskAssessment, co
 		holdMonths = *inputs.HoldMonths
 	}
 
-	// Calculate carry cost for hold period
+	// Calculate carry cost for hold period (plus the eviction delay at an auction)
 	// carry = (condo_fee + iptu/12) * hold_months
+	carryMonths := holdMonths
+	if inputs.Auction != nil {
+		carryMonths += inputs.Auction.EvictionDelayMonths
+	}
 	carryCost := float64(0)
 	if inputs.CondoFee != nil {
-		carryCost += *inputs.CondoFee * float64(holdMonths)
+		carryCost += *inputs.CondoFee * float64(carryMonths)
 	}
 	if inputs.IPTU != nil {
-		carryCost += (*inputs.IPTU / 12) * float64(holdMonths)
+		carryCost += (*inputs.IPTU / 12) * float64(carryMonths)
 	}
 
 	// Combine other costs: carry + user-provided other costs
@@ -135,17 +140,15 @@ func CalculateV1(inputs ProspectInputsV1, riskAssessment *FlipRiskAssessment, co
 		RenovationCost: inputs.RenovationCostEstimate,
 		OtherCosts:     &otherCosts,
 		SalePrice:      inputs.ExpectedSalePrice,
+		HoldMonths:     &holdMonths,
+		Auction:        inputs.Auction,
 	}
 
 	// Calculate cash viability
 	cashOutputs := viability.CalculateCash(viabilityInputs, settings)
 
-	// Calculate break-even sale price
-	breakEven := CalculateBreakEvenSalePrice(
-		cashOutputs.InvestmentTotal,
-		settings.BrokerRate,
-		settings.PJTaxRate,
-	)
+	// Calculate break-even sale price under the workspace tax regime
+	breakEven := viability.BreakEvenSalePrice(viabilityInputs, settings)
 
 	// Calculate buffer (safety margin)
 	buffer := float64(0)
@@ -161,15 +164,20 @@ func CalculateV1(inputs ProspectInputsV1, riskAssessment *FlipRiskAssessment, co
 		InvestmentTotal:    cashOutputs.InvestmentTotal,
 		BrokerFee:          cashOutputs.BrokerFee,
 		PJTaxValue:         cashOutputs.PJTaxValue,
+		TaxBreakdown:       cashOutputs.TaxBreakdown,
 		BreakEvenSalePrice: breakEven,
 		Buffer:             round2(buffer),
 		IsPartial:          cashOutputs.IsPartial,
+		Auction:            cashOutputs.Auction,
+	}
+	if inputs.Auction != nil {
+		economics.AcquisitionMode = "auction"
 	}
 
 	// Calculate components
 	sEcon := CalculateSEcon(cashOutputs.ROI)
 	sLiquidity := CalculateSLiquidity(inputs.Bedrooms, inputs.Parking, inputs.AreaUsable, inputs.Elevator)
-	sRisk := CalculateSRisk(riskAssessment)
+	sRisk := CalculateSRisk(riskAssessment, profile)
 	sData, missingFields := CalculateSDataV1(inputs)
 
 	components := ComponentsV1{
@@ -179,10 +187,10 @@ func CalculateV1(inputs ProspectInputsV1, riskAssessment *FlipRiskAssessment, co
 		SData:      round2(sData),
 	}
 
-	// Calculate raw score (v1 weights: 60/20/20)
-	rawScore := WeightV1Econ*sEcon +
-		WeightV1Liquidity*sLiquidity +
-		WeightV1Risk*sRisk
+	// Calculate raw score (profile v1 weights, default 60/20/20)
+	rawScore := profile.WeightsV1.Econ*sEcon +
+		profile.WeightsV1.Liquidity*sLiquidity +
+		profile.WeightsV1.Risk*sRisk
 
 	// Calculate multipliers
 	mData := 0.6 + 0.4*(sData/100)
@@ -226,7 +234,63 @@ func CalculateV1(inputs ProspectInputsV1, riskAssessment *FlipRiskAssessment, co
 				MData: round2(mData),
 				MLLM:  round2(mLLM),
 			},
-			RawScore: round2(rawScore),
+			RawScore:    round2(rawScore),
+			ProfileHash: profile.Hash(),
+		},
+		ComputedAt: time.Now().UTC(),
+	}
+}
+
+// ============================================================================
+// V2 Score Calculation (Market-based price)
+// ============================================================================
+
+// CalculateV2 computes Flip Score v2: the v0 components and profile weights, with S_price measured
+// against ITBI market aggregates for the prospect's bairro and property class.
+// Falls back to the workspace cohort (v0 S_price) when market is nil or has no usable data.
+func CalculateV2(inputs ProspectInputs, riskAssessment *FlipRiskAssessment, market *MarketStats, cohort CohortStats, profile ScoringProfile) ResultV2 {
+	pricePerSqm := ComputePricePerSqm(inputs.AskingPrice, inputs.AreaUsable)
+
+	// S_price: market quartiles when available, else cohort percentile
+	priceSource := PriceSourceCohort
+	sPrice := CalculateSPrice(cohort)
+	var marketBreakdown *MarketPriceBreakdown
+	if HasMarketData(market) && pricePerSqm != nil {
+		score, reliability := CalculateSPriceMarket(*pricePerSqm, *market)
+		vsMedian := round2((*pricePerSqm/market.MedianM2 - 1) * 100)
+		priceSource = PriceSourceMarket
+		sPrice = score
+		marketBreakdown = &MarketPriceBreakdown{
+			Region:          market.Region,
+			PropertyClass:   market.PropertyClass,
+			AsOfMonth:       market.AsOfMonth,
+			PeriodMonths:    market.PeriodMonths,
+			MedianM2:        market.MedianM2,
+			P25M2:           market.P25M2,
+			P75M2:           market.P75M2,
+			TxCount:         market.TxCount,
+			MatchConfidence: round2(market.MatchConfidence),
+			MatchMethod:     market.MatchMethod,
+			PriceVsMedian:   &vsMedian,
+			Reliability:     reliability,
+		}
+	}
+
+	score, confidence, breakdown := scoreWithPrice(inputs, riskAssessment, sPrice, cohort, profile)
+	return ResultV2{
+		Score:      score,
+		Version:    VersionV2,
+		Confidence: confidence,
+		Breakdown: BreakdownV2{
+			Components:     breakdown.Components,
+			PriceSource:    priceSource,
+			Market:         marketBreakdown,
+			Intermediate:   breakdown.Intermediate,
+			RiskAssessment: breakdown.RiskAssessment,
+			MissingFields:  breakdown.MissingFields,
+			Multipliers:    breakdown.Multipliers,
+			RawScore:       breakdown.RawScore,
+			ProfileHash:    breakdown.ProfileHash,
 		},
 		ComputedAt: time.Now().UTC(),
 	}
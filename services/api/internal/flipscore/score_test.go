@@ -0,0 +1,118 @@
+package flipscore
+
+import "testing"
+
+func testMarket() MarketStats {
+	return MarketStats{
+		Region:          "VILA MARIANA",
+		PropertyClass:   "apartamento",
+		MedianM2:        12000,
+		P25M2:           10000,
+		P75M2:           14000,
+		TxCount:         30,
+		MatchConfidence: 1,
+	}
+}
+
+func TestCalculateSPriceMarketInterpolatesQuartiles(t *testing.T) {
+	market := testMarket()
+	tests := []struct {
+		pricePerSqm float64
+		want        float64
+	}{
+		{pricePerSqm: 7000, want: 100},
+		{pricePerSqm: 8000, want: 100}, // 0.8 * P25
+		{pricePerSqm: 9000, want: 92.5},
+		{pricePerSqm: 10000, want: 85}, // P25
+		{pricePerSqm: 11000, want: 72.5},
+		{pricePerSqm: 12000, want: 60}, // median
+		{pricePerSqm: 14000, want: 35}, // P75
+		{pricePerSqm: 15400, want: 17.5},
+		{pricePerSqm: 16800, want: 0}, // 1.2 * P75
+		{pricePerSqm: 20000, want: 0},
+	}
+	for _, tt := range tests {
+		score, reliability := CalculateSPriceMarket(tt.pricePerSqm, market)
+		if score != tt.want || reliability != 1 {
+			t.Fatalf("price/m²=%.0f: score=%.2f reliability=%.2f, want %.2f/1", tt.pricePerSqm, score, reliability, tt.want)
+		}
+	}
+}
+
+func TestCalculateSPriceMarketDiscountsUnreliableComparisons(t *testing.T) {
+	market := testMarket()
+	market.TxCount = 15
+	market.MatchConfidence = 0.8
+
+	// reliability = 0.8 * 15/30 = 0.4, so raw 100 is pulled to 50 + 50*0.4
+	score, reliability := CalculateSPriceMarket(8000, market)
+	if reliability != 0.4 || score != 70 {
+		t.Fatalf("score=%.2f reliability=%.2f want=70/0.4", score, reliability)
+	}
+	// Above the median the discount works the other way: raw 35 becomes 50 - 15*0.4
+	if score, _ := CalculateSPriceMarket(14000, market); score != 44 {
+		t.Fatalf("score=%.2f want=44", score)
+	}
+}
+
+func TestCalculateV2UsesMarketPrice(t *testing.T) {
+	asking := 550000.0
+	area := 50.0 // 11000/m²
+	inputs := ProspectInputs{AskingPrice: &asking, AreaUsable: &area}
+	cohort := CohortStats{Scope: "workspace", N: 10, PercentileRank: 0.9}
+	market := testMarket()
+
+	result := CalculateV2(inputs, nil, &market, cohort, DefaultProfile())
+
+	if result.Version != VersionV2 || result.Breakdown.PriceSource != PriceSourceMarket {
+		t.Fatalf("version=%s price_source=%s", result.Version, result.Breakdown.PriceSource)
+	}
+	if result.Breakdown.Components.SPrice != 72.5 {
+		t.Fatalf("s_price=%.2f want=72.5", result.Breakdown.Components.SPrice)
+	}
+	m := result.Breakdown.Market
+	if m == nil || m.PriceVsMedian == nil || *m.PriceVsMedian != -8.33 || m.Reliability != 1 {
+		t.Fatalf("market breakdown=%+v", m)
+	}
+
+	// Everything but S_price is the shared v0 scoring
+	v0 := Calculate(inputs, nil, cohort, DefaultProfile())
+	if v0.Breakdown.Components.SPrice == result.Breakdown.Components.SPrice {
+		t.Fatal("cohort and market S_price should differ in this fixture")
+	}
+	if v0.Breakdown.Components.SCarry != result.Breakdown.Components.SCarry ||
+		v0.Breakdown.Multipliers != result.Breakdown.Multipliers ||
+		v0.Confidence != result.Confidence {
+		t.Fatalf("v0 breakdown=%+v v2 breakdown=%+v", v0.Breakdown, result.Breakdown)
+	}
+	if result.Score <= v0.Score {
+		t.Fatalf("v2 score=%d should beat the cohort-priced v0 score=%d", result.Score, v0.Score)
+	}
+}
+
+func TestCalculateV2FallsBackToCohort(t *testing.T) {
+	asking := 550000.0
+	area := 50.0
+	inputs := ProspectInputs{AskingPrice: &asking, AreaUsable: &area}
+	cohort := CohortStats{Scope: "neighborhood", N: 12, PercentileRank: 0.25}
+
+	thin := testMarket()
+	thin.TxCount = 0
+	for name, market := range map[string]*MarketStats{"nil": nil, "no transactions": &thin} {
+		result := CalculateV2(inputs, nil, market, cohort, DefaultProfile())
+		v0 := Calculate(inputs, nil, cohort, DefaultProfile())
+
+		if result.Breakdown.PriceSource != PriceSourceCohort || result.Breakdown.Market != nil {
+			t.Fatalf("%s: price_source=%s market=%+v", name, result.Breakdown.PriceSource, result.Breakdown.Market)
+		}
+		if result.Breakdown.Components != v0.Breakdown.Components || result.Score != v0.Score {
+			t.Fatalf("%s: v2=%+v/%d v0=%+v/%d", name, result.Breakdown.Components, result.Score, v0.Breakdown.Components, v0.Score)
+		}
+	}
+
+	// Without an area there is no price/m² to compare against the market
+	market := testMarket()
+	if result := CalculateV2(ProspectInputs{AskingPrice: &asking}, nil, &market, cohort, DefaultProfile()); result.Breakdown.PriceSource != PriceSourceCohort {
+		t.Fatalf("price_source=%s want=cohort", result.Breakdown.PriceSource)
+	}
+}
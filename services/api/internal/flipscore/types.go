@@ -1,6 +1,10 @@
 package flipscore
 
-import "time"
+import (
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
 
 // RedFlag represents a risk flag identified by the LLM
 type RedFlag struct {
@@ -68,6 +72,7 @@ type Breakdown struct {
 	MissingFields  []string            `json:"missing_fields"`
 	Multipliers    Multipliers         `json:"multipliers"`
 	RawScore       float64             `json:"raw_score"`
+	ProfileHash    string              `json:"profile_hash"`
 }
 
 // Result is the final output of the flip score calculation
@@ -83,42 +88,9 @@ type Result struct {
 const (
 	VersionV0 = "v0"
 	VersionV1 = "v1"
+	VersionV2 = "v2"
 )
 
-// Weights for v0 score components (must sum to 1.0)
-const (
-	WeightPrice     = 0.40
-	WeightCarry     = 0.15
-	WeightLiquidity = 0.20
-	WeightRisk      = 0.25
-)
-
-// Weights for v1 score components (must sum to 1.0)
-const (
-	WeightV1Econ      = 0.60
-	WeightV1Liquidity = 0.20
-	WeightV1Risk      = 0.20
-)
-
-// Category weights for red flag penalty calculation
-var CategoryWeights = map[string]float64{
-	"legal":                 10,
-	"structural":            9,
-	"moisture":              8,
-	"condo_rules":           6,
-	"security":              6,
-	"listing_inconsistency": 5,
-	"noise":                 4,
-	"access":                3,
-}
-
-// Rehab level penalties
-var RehabPenalties = map[string]float64{
-	"light":  0,
-	"medium": 8,
-	"heavy":  15,
-}
-
 // ============================================================================
 // V1 Types (Economics-based scoring)
 // ============================================================================
@@ -133,22 +105,26 @@ type ProspectInputsV1 struct {
 	RenovationCostEstimate *float64
 	HoldMonths             *int // Default 6 if nil
 	OtherCostsEstimate     *float64
+	Auction                *viability.AuctionTerms // Auction acquisition mode (offer/asking price is the bid); nil for a market purchase
 }
 
 // EconomicsBreakdown contains ROI-based calculation outputs
 type EconomicsBreakdown struct {
-	ROI                float64 `json:"roi"`
-	NetProfit          float64 `json:"net_profit"`
-	GrossProfit        float64 `json:"gross_profit"`
-	InvestmentTotal    float64 `json:"investment_total"`
-	BrokerFee          float64 `json:"broker_fee"`
-	PJTaxValue         float64 `json:"pj_tax_value"`
-	BreakEvenSalePrice float64 `json:"break_even_sale_price"`
-	Buffer             float64 `json:"buffer"` // expected_sale_price - break_even_sale_price
-	IsPartial          bool    `json:"is_partial"`
-}
-
-// ComponentsV1 holds v1 score components (60/20/20 weights)
+	ROI                float64                     `json:"roi"`
+	NetProfit          float64                     `json:"net_profit"`
+	GrossProfit        float64                     `json:"gross_profit"`
+	InvestmentTotal    float64                     `json:"investment_total"`
+	BrokerFee          float64                     `json:"broker_fee"`
+	PJTaxValue         float64                     `json:"pj_tax_value"`
+	TaxBreakdown       *viability.TaxBreakdown     `json:"tax_breakdown,omitempty"`
+	BreakEvenSalePrice float64                     `json:"break_even_sale_price"`
+	Buffer             float64                     `json:"buffer"` // expected_sale_price - break_even_sale_price
+	IsPartial          bool                        `json:"is_partial"`
+	AcquisitionMode    string                      `json:"acquisition_mode,omitempty"` // "auction" when bought at an auction
+	Auction            *viability.AuctionBreakdown `json:"auction,omitempty"`
+}
+
+// ComponentsV1 holds v1 score components (default 60/20/20 weights)
 type ComponentsV1 struct {
 	SEcon      float64 `json:"s_econ"`      // 60% - ROI-based
 	SLiquidity float64 `json:"s_liquidity"` // 20% - same as v0
@@ -165,6 +141,7 @@ type BreakdownV1 struct {
 	MissingFields  []string            `json:"missing_fields"`
 	Multipliers    Multipliers         `json:"multipliers"`
 	RawScore       float64             `json:"raw_score"`
+	ProfileHash    string              `json:"profile_hash"`
 }
 
 // ResultV1 is the final output of the v1 flip score calculation
@@ -175,3 +152,66 @@ type ResultV1 struct {
 	Breakdown  BreakdownV1 `json:"breakdown"`
 	ComputedAt time.Time   `json:"computed_at"`
 }
+
+// ============================================================================
+// V2 Types (Market-based price component)
+// ============================================================================
+
+// Price sources for the v2 S_price component
+const (
+	PriceSourceMarket = "market"
+	PriceSourceCohort = "cohort"
+)
+
+// MarketStats holds the ITBI price/m² aggregates for the prospect's bairro and property class
+type MarketStats struct {
+	Region          string  // canonical market region (bairro)
+	PropertyClass   string  // apartamento, casa, outros or geral
+	AsOfMonth       string  // YYYY-MM
+	PeriodMonths    int     // aggregation window
+	MedianM2        float64 // median transaction price/m²
+	P25M2           float64
+	P75M2           float64
+	TxCount         int     // transactions behind the aggregate
+	MatchConfidence float64 // 0-1, neighborhood resolver confidence
+	MatchMethod     string  // dictionary or normalized
+}
+
+// MarketPriceBreakdown explains the market-based S_price
+type MarketPriceBreakdown struct {
+	Region          string   `json:"region"`
+	PropertyClass   string   `json:"property_class"`
+	AsOfMonth       string   `json:"as_of_month"`
+	PeriodMonths    int      `json:"period_months"`
+	MedianM2        float64  `json:"median_m2"`
+	P25M2           float64  `json:"p25_m2"`
+	P75M2           float64  `json:"p75_m2"`
+	TxCount         int      `json:"tx_count"`
+	MatchConfidence float64  `json:"match_confidence"`
+	MatchMethod     string   `json:"match_method"`
+	PriceVsMedian   *float64 `json:"price_vs_median"` // % above (+) or below (-) the median
+	Reliability     float64  `json:"reliability"`     // 0-1, shrinks S_price towards 50
+}
+
+// BreakdownV2 contains all components to explain the v2 score.
+// Components keep the v0 shape; only S_price changes source.
+type BreakdownV2 struct {
+	Components     Components            `json:"components"`
+	PriceSource    string                `json:"price_source"` // "market" or "cohort"
+	Market         *MarketPriceBreakdown `json:"market"`
+	Intermediate   Intermediate          `json:"intermediate"`
+	RiskAssessment *FlipRiskAssessment   `json:"risk_assessment"`
+	MissingFields  []string              `json:"missing_fields"`
+	Multipliers    Multipliers           `json:"multipliers"`
+	RawScore       float64               `json:"raw_score"`
+	ProfileHash    string                `json:"profile_hash"`
+}
+
+// ResultV2 is the final output of the v2 flip score calculation
+type ResultV2 struct {
+	Score      int         `json:"score"`      // 0-100
+	Version    string      `json:"version"`    // "v2"
+	Confidence float64     `json:"confidence"` // 0-1
+	Breakdown  BreakdownV2 `json:"breakdown"`
+	ComputedAt time.Time   `json:"computed_at"`
+}
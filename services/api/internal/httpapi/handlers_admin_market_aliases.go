@@ -92,14 +92,12 @@ func (a *api) handleAdminMarketAliasesSubroutes(w http.ResponseWriter, r *http.R
 }
 
 func (a *api) handleAdminListMarketAliases(w http.ResponseWriter, r *http.Request) {
-	city := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("city")))
-	if city == "" {
-		city = marketingest.DefaultCity
-	}
-	if city != marketingest.DefaultCity {
-		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "city must be sp"})
+	profile, err := marketingest.CityProfileFor(r.URL.Query().Get("city"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
 		return
 	}
+	city := profile.City
 
 	status := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("status")))
 	if status != "" && status != "pending" && status != "approved" && status != "rejected" {
@@ -201,7 +199,7 @@ func (a *api) handleAdminListMarketAliases(w http.ResponseWriter, r *http.Reques
 	writeJSON(w, http.StatusOK, listMarketRegionAliasesResponse{
 		Items:            items,
 		Total:            total,
-		CanonicalOptions: marketingest.GoldenNeighborhoodCanonicalList(),
+		CanonicalOptions: profile.CanonicalNeighborhoods(),
 	})
 }
 
@@ -220,7 +218,19 @@ func (a *api) handleAdminApproveMarketAlias(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	canonical := marketingest.CanonicalNeighborhoodFromGolden(req.CanonicalName)
+	// Golden names are per city, so resolve against the alias' own city
+	var city string
+	err := a.db.QueryRowContext(r.Context(), `SELECT city FROM market_region_aliases WHERE id = $1`, aliasID).Scan(&city)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "alias not found"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to load alias"})
+		return
+	}
+
+	canonical := marketingest.CanonicalNeighborhoodFromGolden(city, req.CanonicalName)
 	if canonical == "" {
 		canonical = marketingest.NormalizeNeighborhoodKey(req.CanonicalName)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -18,14 +19,22 @@ import (
 )
 
 const (
-	marketIngestionAllowedContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
-	marketIngestionMaxFileSizeBytes   = int64(100 * 1024 * 1024)
-	marketIngestionPrefixSP           = "market-data/sp/raw/"
-	marketIngestionDefaultLimit       = 50
-	marketIngestionMaxLimit           = 200
+	marketIngestionXLSXContentType  = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	marketIngestionCSVContentType   = "text/csv"
+	marketIngestionMaxFileSizeBytes = int64(100 * 1024 * 1024)
+	marketIngestionStoragePrefix    = "market-data/"
+	marketIngestionDefaultLimit     = 50
+	marketIngestionMaxLimit         = 200
 )
 
-var marketFilenameSanitizeRE = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+var (
+	marketFilenameSanitizeRE = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+	// Uploaded file extension by content type; the extension later selects the parser adapter
+	marketIngestionExtensions = map[string]string{
+		marketIngestionXLSXContentType: ".xlsx",
+		marketIngestionCSVContentType:  ".csv",
+	}
+)
 
 type marketIngestionUploadURLRequest struct {
 	City        string `json:"city"`
@@ -41,11 +50,13 @@ type marketIngestionUploadURLResponse struct {
 }
 
 type runMarketIngestionRequest struct {
-	City       string `json:"city"`
-	AsOfMonth  string `json:"as_of_month"`
-	StorageKey string `json:"storage_key"`
-	Source     string `json:"source"`
-	DryRun     bool   `json:"dry_run"`
+	City          string                     `json:"city"`
+	AsOfMonth     string                     `json:"as_of_month"`
+	StorageKey    string                     `json:"storage_key"`
+	Source        string                     `json:"source"`
+	Format        string                     `json:"format"`
+	ColumnMapping marketingest.ColumnMapping `json:"column_mapping"`
+	DryRun        bool                       `json:"dry_run"`
 }
 
 type runMarketIngestionResponse struct {
@@ -76,6 +87,8 @@ type marketIngestionRunResponse struct {
 	FileSizeBytes    *int64         `json:"file_size_bytes"`
 	Stats            map[string]any `json:"stats"`
 	Params           map[string]any `json:"params"`
+	RolledBackAt     *string        `json:"rolled_back_at"`
+	RolledBackBy     *string        `json:"rolled_back_by"`
 }
 
 type listMarketIngestionRunsResponse struct {
@@ -87,6 +100,8 @@ type asyncMarketIngestionJob struct {
 	RunID      string
 	City       string
 	Source     string
+	Format     string
+	Columns    marketingest.ColumnMapping
 	AsOfMonth  time.Time
 	StorageKey string
 	DryRun     bool
@@ -120,13 +135,29 @@ func (a *api) handleAdminMarketIngestionsSubroutes(w http.ResponseWriter, r *htt
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	default:
-		id := strings.Trim(path, "/")
-		if id == "" || strings.Contains(id, "/") {
+		id, action, _ := strings.Cut(strings.Trim(path, "/"), "/")
+		if id == "" || strings.Contains(action, "/") {
 			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "endpoint not found"})
 			return
 		}
-		if r.Method == http.MethodGet {
-			a.handleAdminGetMarketIngestionRun(w, r, id)
+		switch action {
+		case "":
+			if r.Method == http.MethodGet {
+				a.handleAdminGetMarketIngestionRun(w, r, id)
+				return
+			}
+		case "rollback":
+			if r.Method == http.MethodPost {
+				a.handleAdminRollbackMarketIngestionRun(w, r, id)
+				return
+			}
+		case "diff":
+			if r.Method == http.MethodGet {
+				a.handleAdminDiffMarketIngestionRuns(w, r, id)
+				return
+			}
+		default:
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "endpoint not found"})
 			return
 		}
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -152,22 +183,21 @@ func (a *api) handleAdminMarketIngestionUploadURL(w http.ResponseWriter, r *http
 		return
 	}
 
-	city := strings.ToLower(strings.TrimSpace(req.City))
-	if city == "" {
-		city = marketingest.DefaultCity
-	}
-	if city != marketingest.DefaultCity {
-		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "city must be sp"})
+	profile, err := marketingest.CityProfileFor(req.City)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
 		return
 	}
+	city := profile.City
 
 	filename := strings.TrimSpace(req.Filename)
 	if filename == "" {
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "filename is required"})
 		return
 	}
-	if req.ContentType != marketIngestionAllowedContentType {
-		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "content_type must be xlsx"})
+	extension, ok := marketIngestionExtensions[req.ContentType]
+	if !ok {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "content_type must be xlsx or csv"})
 		return
 	}
 	if req.SizeBytes <= 0 || req.SizeBytes > marketIngestionMaxFileSizeBytes {
@@ -176,8 +206,8 @@ func (a *api) handleAdminMarketIngestionUploadURL(w http.ResponseWriter, r *http
 	}
 
 	now := time.Now().UTC()
-	safeName := sanitizeMarketFilename(filename)
-	storageKey := fmt.Sprintf("market-data/sp/raw/%s/%s/%s_%s", now.Format("2006"), now.Format("01"), now.Format("20060102T150405Z"), safeName)
+	safeName := sanitizeMarketFilename(filename, extension)
+	storageKey := fmt.Sprintf("%s%s/raw/%s/%s/%s_%s", marketIngestionStoragePrefix, city, now.Format("2006"), now.Format("01"), now.Format("20060102T150405Z"), safeName)
 	uploadURL, err := a.s3Client.GeneratePresignedUploadURL(r.Context(), storageKey, req.ContentType, 15*time.Minute)
 	if err != nil {
 		log.Printf("admin market upload-url: presign error: %v", err)
@@ -212,14 +242,12 @@ func (a *api) handleAdminRunMarketIngestion(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	city := strings.ToLower(strings.TrimSpace(req.City))
-	if city == "" {
-		city = marketingest.DefaultCity
-	}
-	if city != marketingest.DefaultCity {
-		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "city must be sp"})
+	profile, err := marketingest.CityProfileFor(req.City)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
 		return
 	}
+	city := profile.City
 
 	asOfMonth, err := parseMarketMonth(req.AsOfMonth)
 	if err != nil {
@@ -229,15 +257,30 @@ func (a *api) handleAdminRunMarketIngestion(w http.ResponseWriter, r *http.Reque
 
 	source := strings.TrimSpace(req.Source)
 	if source == "" {
-		source = marketingest.DefaultSource
+		source = profile.DefaultSource
 	}
 
 	storageKey := strings.TrimSpace(req.StorageKey)
-	if !isValidMarketStorageKey(storageKey) {
+	if !isValidMarketStorageKey(city, storageKey) {
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "storage_key inválido"})
 		return
 	}
 
+	format, err := marketingest.NormalizeFormat(req.Format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+		return
+	}
+	isCSV := marketIngestionContentType(storageKey) == marketIngestionCSVContentType
+	if format != marketingest.FormatAuto && (format == marketingest.FormatCSV) != isCSV {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "format does not match the uploaded file"})
+		return
+	}
+	if err := req.ColumnMapping.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid column_mapping", Details: []string{err.Error()}})
+		return
+	}
+
 	lockConn, err := a.db.Conn(r.Context())
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to allocate db connection"})
@@ -257,11 +300,13 @@ func (a *api) handleAdminRunMarketIngestion(w http.ResponseWriter, r *http.Reque
 	}
 
 	paramsJSON, err := json.Marshal(map[string]any{
-		"city":        city,
-		"as_of_month": asOfMonth.Format("2006-01"),
-		"storage_key": storageKey,
-		"source":      source,
-		"dry_run":     req.DryRun,
+		"city":           city,
+		"as_of_month":    asOfMonth.Format("2006-01"),
+		"storage_key":    storageKey,
+		"source":         source,
+		"format":         format,
+		"column_mapping": req.ColumnMapping,
+		"dry_run":        req.DryRun,
 	})
 	if err != nil {
 		a.releaseMarketIngestionLockAndClose(lockConn, city)
@@ -289,7 +334,7 @@ func (a *api) handleAdminRunMarketIngestion(w http.ResponseWriter, r *http.Reque
 			$1,$2,$3,'running','admin',$4,$5,$6,$7,$8,$9,NOW(),NOW()
 		)
 		RETURNING id
-	`, source, city, asOfMonth, userID, req.DryRun, storageKey, filepath.Base(storageKey), marketIngestionAllowedContentType, paramsJSON).Scan(&runID)
+	`, source, city, asOfMonth, userID, req.DryRun, storageKey, filepath.Base(storageKey), marketIngestionContentType(storageKey), paramsJSON).Scan(&runID)
 	if err != nil {
 		a.releaseMarketIngestionLockAndClose(lockConn, city)
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create ingestion run"})
@@ -300,6 +345,8 @@ func (a *api) handleAdminRunMarketIngestion(w http.ResponseWriter, r *http.Reque
 		RunID:      runID,
 		City:       city,
 		Source:     source,
+		Format:     format,
+		Columns:    req.ColumnMapping,
 		AsOfMonth:  asOfMonth,
 		StorageKey: storageKey,
 		DryRun:     req.DryRun,
@@ -315,14 +362,12 @@ func (a *api) handleAdminRunMarketIngestion(w http.ResponseWriter, r *http.Reque
 }
 
 func (a *api) handleAdminListMarketIngestionRuns(w http.ResponseWriter, r *http.Request) {
-	city := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("city")))
-	if city == "" {
-		city = marketingest.DefaultCity
-	}
-	if city != marketingest.DefaultCity {
-		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "city must be sp"})
+	profile, err := marketingest.CityProfileFor(r.URL.Query().Get("city"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
 		return
 	}
+	city := profile.City
 
 	limit := marketIngestionDefaultLimit
 	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
@@ -375,7 +420,9 @@ func (a *api) handleAdminListMarketIngestionRuns(w http.ResponseWriter, r *http.
 			content_type,
 			file_size_bytes,
 			stats,
-			params
+			params,
+			rolled_back_at,
+			rolled_back_by
 		FROM market_ingestion_runs
 		WHERE city = $1
 		ORDER BY created_at DESC
@@ -428,7 +475,9 @@ func (a *api) handleAdminGetMarketIngestionRun(w http.ResponseWriter, r *http.Re
 			content_type,
 			file_size_bytes,
 			stats,
-			params
+			params,
+			rolled_back_at,
+			rolled_back_by
 		FROM market_ingestion_runs
 		WHERE id = $1
 	`, id)
@@ -446,6 +495,97 @@ func (a *api) handleAdminGetMarketIngestionRun(w http.ResponseWriter, r *http.Re
 	writeJSON(w, http.StatusOK, item)
 }
 
+// handleAdminRollbackMarketIngestionRun restores what a successful run replaced. It holds the
+// city ingestion lock so no run writes the same tables meanwhile.
+func (a *api) handleAdminRollbackMarketIngestionRun(w http.ResponseWriter, r *http.Request, id string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth context"})
+		return
+	}
+
+	var city string
+	err := a.db.QueryRowContext(r.Context(), `SELECT city FROM market_ingestion_runs WHERE id = $1`, id).Scan(&city)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "ingestion run not found"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to load ingestion run"})
+		return
+	}
+
+	lockConn, err := a.db.Conn(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to allocate db connection"})
+		return
+	}
+	locked, err := tryAcquireMarketIngestionLock(r.Context(), lockConn, city)
+	if err != nil {
+		_ = lockConn.Close()
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to acquire ingestion lock"})
+		return
+	}
+	if !locked {
+		_ = lockConn.Close()
+		writeError(w, http.StatusConflict, apiError{Code: "RUN_ALREADY_IN_PROGRESS", Message: "já existe ingestão em execução para esta cidade"})
+		return
+	}
+	defer a.releaseMarketIngestionLockAndClose(lockConn, city)
+
+	result, err := marketingest.RollbackRun(r.Context(), a.db, id, userID)
+	switch {
+	case errors.Is(err, marketingest.ErrRunNotFound):
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "ingestion run not found"})
+		return
+	case errors.Is(err, marketingest.ErrRunNotRollbackable):
+		writeError(w, http.StatusConflict, apiError{Code: "RUN_NOT_ROLLBACKABLE", Message: err.Error()})
+		return
+	case errors.Is(err, marketingest.ErrRunSuperseded):
+		writeError(w, http.StatusConflict, apiError{Code: "RUN_SUPERSEDED", Message: err.Error()})
+		return
+	case err != nil:
+		log.Printf("market ingestion: rollback of run %s failed: %v", id, err)
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to roll back ingestion run"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleAdminDiffMarketIngestionRuns compares a run with ?base=<run id>, defaulting to the
+// previous run of the same city and source
+func (a *api) handleAdminDiffMarketIngestionRuns(w http.ResponseWriter, r *http.Request, id string) {
+	baseID := strings.TrimSpace(r.URL.Query().Get("base"))
+	if baseID == "" {
+		previous, err := marketingest.PreviousRunID(r.Context(), a.db, id)
+		if errors.Is(err, marketingest.ErrRunNotFound) {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: err.Error()})
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to load previous ingestion run"})
+			return
+		}
+		baseID = previous
+	}
+
+	diff, err := marketingest.DiffRuns(r.Context(), a.db, baseID, id)
+	switch {
+	case errors.Is(err, marketingest.ErrRunNotFound):
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "ingestion run not found"})
+		return
+	case errors.Is(err, marketingest.ErrRunsNotComparable):
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to diff ingestion runs"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, diff)
+}
+
 func (a *api) runMarketIngestionAsync(job asyncMarketIngestionJob) {
 	defer a.releaseMarketIngestionLockAndClose(job.LockConn, job.City)
 
@@ -465,11 +605,14 @@ func (a *api) runMarketIngestionAsync(job asyncMarketIngestionJob) {
 		FilePath:               tempPath,
 		City:                   job.City,
 		Source:                 job.Source,
+		Format:                 job.Format,
+		Columns:                job.Columns,
 		AsOfMonth:              job.AsOfMonth,
 		DryRun:                 job.DryRun,
 		RunID:                  job.RunID,
 		NeighborhoodNormalizer: a.llmClient,
 		MaxLLMCalls:            120,
+		Geo:                    marketingest.GeoConfigFromDir(a.marketGeoDir, job.City),
 	})
 	if err != nil {
 		a.finishMarketIngestionRun(ctx, job.RunID, startedAt, result, err, job.DryRun)
@@ -490,6 +633,10 @@ func (a *api) finishMarketIngestionRun(ctx context.Context, runID string, starte
 		"llm_calls":        result.LLMCalls,
 		"llm_resolved":     result.LLMResolved,
 		"alias_candidates": result.AliasCandidates,
+		"format":           result.Format,
+		"encoding":         result.Encoding,
+		"quality":          result.Quality,
+		"region_methods":   result.RegionMethods,
 	}
 	if dryRun {
 		stats["preview_rows"] = result.PreviewRows
@@ -552,6 +699,8 @@ func scanMarketIngestionRun(scanner interface {
 	var fileSizeBytes sql.NullInt64
 	var statsRaw []byte
 	var paramsRaw []byte
+	var rolledBackAt sql.NullTime
+	var rolledBackBy sql.NullString
 	var createdAt time.Time
 
 	err := scanner.Scan(
@@ -576,6 +725,8 @@ func scanMarketIngestionRun(scanner interface {
 		&fileSizeBytes,
 		&statsRaw,
 		&paramsRaw,
+		&rolledBackAt,
+		&rolledBackBy,
 	)
 	if err != nil {
 		return marketIngestionRunResponse{}, err
@@ -616,6 +767,14 @@ func scanMarketIngestionRun(scanner interface {
 		value := fileSizeBytes.Int64
 		out.FileSizeBytes = &value
 	}
+	if rolledBackAt.Valid {
+		value := rolledBackAt.Time.UTC().Format(time.RFC3339)
+		out.RolledBackAt = &value
+	}
+	if rolledBackBy.Valid {
+		value := rolledBackBy.String
+		out.RolledBackBy = &value
+	}
 	if len(statsRaw) > 0 {
 		_ = json.Unmarshal(statsRaw, &out.Stats)
 	}
@@ -626,17 +785,17 @@ func scanMarketIngestionRun(scanner interface {
 	return out, nil
 }
 
-func sanitizeMarketFilename(filename string) string {
+func sanitizeMarketFilename(filename, extension string) string {
 	base := filepath.Base(strings.TrimSpace(filename))
 	base = transliterateToASCII(base)
 	base = strings.ToLower(base)
 	base = marketFilenameSanitizeRE.ReplaceAllString(base, "_")
 	base = strings.Trim(base, "._-")
 	if base == "" {
-		base = "itbi_sp"
+		base = "itbi"
 	}
-	if !strings.HasSuffix(base, ".xlsx") {
-		base = base + ".xlsx"
+	if !strings.HasSuffix(base, extension) {
+		base = base + extension
 	}
 	if len(base) > 160 {
 		base = base[len(base)-160:]
@@ -644,15 +803,16 @@ func sanitizeMarketFilename(filename string) string {
 	return base
 }
 
-func isValidMarketStorageKey(value string) bool {
+// isValidMarketStorageKey only accepts uploads made for the same city
+func isValidMarketStorageKey(city, value string) bool {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
 		return false
 	}
-	if !strings.HasPrefix(trimmed, marketIngestionPrefixSP) {
+	if !strings.HasPrefix(trimmed, marketIngestionStoragePrefix+city+"/raw/") {
 		return false
 	}
-	if !strings.HasSuffix(strings.ToLower(trimmed), ".xlsx") {
+	if marketIngestionContentType(trimmed) == "" {
 		return false
 	}
 	if strings.Contains(trimmed, "..") {
@@ -660,3 +820,14 @@ func isValidMarketStorageKey(value string) bool {
 	}
 	return true
 }
+
+// marketIngestionContentType is derived from the storage key extension, "" when unsupported
+func marketIngestionContentType(storageKey string) string {
+	extension := strings.ToLower(filepath.Ext(storageKey))
+	for contentType, ext := range marketIngestionExtensions {
+		if ext == extension {
+			return contentType
+		}
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/schedule"
 	"github.com/widia-projects/widia-flip/services/api/internal/zapscraper"
 )
 
@@ -27,39 +29,78 @@ type opportunityScraperPlaceholderResponse struct {
 	State        string     `json:"state"`
 	City         string     `json:"city"`
 	Neighborhood string     `json:"neighborhood"`
+	Sources      []string   `json:"sources"`
 	LastRunAt    *time.Time `json:"last_run_at"`
 	LastJobRunID *string    `json:"last_job_run_id"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
+
+	Schedule opportunityScraperScheduleResponse `json:"schedule"`
+}
+
+type opportunityScraperScheduleResponse struct {
+	Enabled             bool       `json:"enabled"`
+	Cron                *string    `json:"cron,omitempty"`
+	IntervalMinutes     *int       `json:"interval_minutes,omitempty"`
+	JitterSeconds       int        `json:"jitter_seconds"`
+	Limit               *int       `json:"limit,omitempty"`
+	NextRunAt           *time.Time `json:"next_run_at,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           *string    `json:"last_error,omitempty"`
 }
 
+const opportunityScraperPlaceholderColumns = `id, state, city, neighborhood, sources, last_run_at, last_job_run_id, created_at, updated_at,
+	schedule_enabled, schedule_cron, schedule_interval_minutes, schedule_jitter_seconds, schedule_limit,
+	next_run_at, consecutive_failures, last_error`
+
 type listOpportunityScraperPlaceholdersResponse struct {
 	Items []opportunityScraperPlaceholderResponse `json:"items"`
 }
 
 type upsertOpportunityScraperPlaceholderRequest struct {
-	State        string `json:"state"`
-	City         string `json:"city"`
-	Neighborhood string `json:"neighborhood"`
+	State        string   `json:"state"`
+	City         string   `json:"city"`
+	Neighborhood string   `json:"neighborhood"`
+	Sources      []string `json:"sources,omitempty"`
+
+	// Without schedule in the body the current one is kept
+	Schedule *opportunityScraperScheduleRequest `json:"schedule,omitempty"`
+}
+
+// opportunityScraperScheduleRequest sets either a cron expression or an interval
+type opportunityScraperScheduleRequest struct {
+	Enabled         bool   `json:"enabled"`
+	Cron            string `json:"cron,omitempty"`
+	IntervalMinutes int    `json:"interval_minutes,omitempty"`
+	JitterSeconds   int    `json:"jitter_seconds,omitempty"`
+	Limit           int    `json:"limit,omitempty"`
 }
 
 type runOpportunityScraperRequest struct {
-	State         string  `json:"state"`
-	City          string  `json:"city"`
-	Neighborhood  string  `json:"neighborhood"`
-	PlaceholderID *string `json:"placeholder_id,omitempty"`
-	Limit         int     `json:"limit,omitempty"`
-	DryRun        bool    `json:"dry_run"`
+	State         string   `json:"state"`
+	City          string   `json:"city"`
+	Neighborhood  string   `json:"neighborhood"`
+	PlaceholderID *string  `json:"placeholder_id,omitempty"`
+	Sources       []string `json:"sources,omitempty"`
+	Limit         int      `json:"limit,omitempty"`
+	DryRun        bool     `json:"dry_run"`
 }
 
 type runOpportunityScraperStats struct {
 	TotalReceived int     `json:"total_received"`
 	NewListings   int     `json:"new_listings"`
 	Updated       int     `json:"updated"`
+	PriceDrops    int     `json:"price_drops"`
+	Relisted      int     `json:"relisted"`
+	Delisted      int     `json:"delisted"`
+	Alerts        int     `json:"alerts"`
 	MedianPriceM2 float64 `json:"median_price_m2"`
+
+	Sources []zapscraper.SourceRunStats `json:"sources"`
 }
 
 type runOpportunityScraperListing struct {
+	Source          string  `json:"source"`
 	SourceListingID string  `json:"source_listing_id"`
 	CanonicalURL    string  `json:"canonical_url"`
 	Title           string  `json:"title"`
@@ -75,6 +116,7 @@ type runOpportunityScraperListing struct {
 	PricePerM2      float64 `json:"price_per_m2"`
 	MedianPriceM2   float64 `json:"market_median_m2"`
 	DiscountPct     float64 `json:"discount_pct"`
+	ParseQuality    int     `json:"parse_quality"`
 }
 
 type runOpportunityScraperResponse struct {
@@ -126,7 +168,7 @@ func (a *api) handleAdminOpportunityScraperSubroutes(w http.ResponseWriter, r *h
 // GET /api/v1/admin/opportunities/scraper/placeholders
 func (a *api) handleAdminListOpportunityScraperPlaceholders(w http.ResponseWriter, r *http.Request) {
 	rows, err := a.db.QueryContext(r.Context(), `
-		SELECT id, state, city, neighborhood, last_run_at, last_job_run_id, created_at, updated_at
+		SELECT `+opportunityScraperPlaceholderColumns+`
 		FROM opportunity_scraper_placeholders
 		ORDER BY state ASC, city ASC, neighborhood ASC
 	`)
@@ -175,12 +217,28 @@ func (a *api) handleAdminCreateOpportunityScraperPlaceholder(w http.ResponseWrit
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "state, city and neighborhood are required"})
 		return
 	}
+	sources, sourcesErr := normalizeScraperSources(req.Sources)
+	if sourcesErr != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: sourcesErr.Error()})
+		return
+	}
+	sourcesJSON, _ := json.Marshal(sources)
+
+	var scheduleSpec *schedule.Spec
+	if req.Schedule != nil {
+		spec, scheduleErr := validateOpportunityScraperSchedule(*req.Schedule)
+		if scheduleErr != nil {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: scheduleErr.Error()})
+			return
+		}
+		scheduleSpec = spec
+	}
 
 	placeholderID := uuid.New().String()
 	_, err := a.db.ExecContext(r.Context(), `
-		INSERT INTO opportunity_scraper_placeholders (id, state, city, neighborhood, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, NOW(), NOW())
-	`, placeholderID, state, city, neighborhood)
+		INSERT INTO opportunity_scraper_placeholders (id, state, city, neighborhood, sources, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+	`, placeholderID, state, city, neighborhood, sourcesJSON)
 
 	if err != nil {
 		if isDuplicateKeyError(err) {
@@ -191,6 +249,13 @@ func (a *api) handleAdminCreateOpportunityScraperPlaceholder(w http.ResponseWrit
 		return
 	}
 
+	if req.Schedule != nil {
+		if err := a.saveOpportunityScraperSchedule(r.Context(), placeholderID, *req.Schedule, scheduleSpec); err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to save placeholder schedule"})
+			return
+		}
+	}
+
 	stored, err := a.getOpportunityScraperPlaceholderByID(r.Context(), placeholderID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to load created placeholder"})
@@ -227,14 +292,36 @@ func (a *api) handleAdminUpdateOpportunityScraperPlaceholder(w http.ResponseWrit
 		return
 	}
 
+	// Without sources in the body the current portals are kept
+	var sourcesJSON []byte
+	if req.Sources != nil {
+		sources, sourcesErr := normalizeScraperSources(req.Sources)
+		if sourcesErr != nil {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: sourcesErr.Error()})
+			return
+		}
+		sourcesJSON, _ = json.Marshal(sources)
+	}
+
+	var scheduleSpec *schedule.Spec
+	if req.Schedule != nil {
+		spec, scheduleErr := validateOpportunityScraperSchedule(*req.Schedule)
+		if scheduleErr != nil {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: scheduleErr.Error()})
+			return
+		}
+		scheduleSpec = spec
+	}
+
 	result, err := a.db.ExecContext(r.Context(), `
 		UPDATE opportunity_scraper_placeholders
 		SET state = $1,
 			city = $2,
 			neighborhood = $3,
+			sources = COALESCE($4::jsonb, sources),
 			updated_at = NOW()
-		WHERE id = $4
-	`, state, city, neighborhood, id)
+		WHERE id = $5
+	`, state, city, neighborhood, sourcesJSON, id)
 	if err != nil {
 		if isDuplicateKeyError(err) {
 			writeError(w, http.StatusConflict, apiError{Code: "CONFLICT", Message: "placeholder already exists"})
@@ -254,6 +341,13 @@ func (a *api) handleAdminUpdateOpportunityScraperPlaceholder(w http.ResponseWrit
 		return
 	}
 
+	if req.Schedule != nil {
+		if err := a.saveOpportunityScraperSchedule(r.Context(), id, *req.Schedule, scheduleSpec); err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to save placeholder schedule"})
+			return
+		}
+	}
+
 	stored, err := a.getOpportunityScraperPlaceholderByID(r.Context(), id)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to load updated placeholder"})
@@ -321,6 +415,16 @@ func (a *api) handleAdminRunOpportunityScraper(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	requestedSources := req.Sources
+	if len(requestedSources) == 0 && existingPlaceholder != nil {
+		requestedSources = existingPlaceholder.Sources
+	}
+	sources, sourcesErr := normalizeScraperSources(requestedSources)
+	if sourcesErr != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: sourcesErr.Error()})
+		return
+	}
+
 	limit := req.Limit
 	if limit <= 0 {
 		limit = defaultOpportunityScraperLimit
@@ -340,46 +444,99 @@ func (a *api) handleAdminRunOpportunityScraper(w http.ResponseWriter, r *http.Re
 		triggerType = "admin_dry_run"
 	}
 
+	resp, err := a.executeOpportunityScraperRun(r.Context(), opportunityScraperRunParams{
+		State:         state,
+		City:          city,
+		Neighborhood:  neighborhood,
+		Sources:       sources,
+		Limit:         limit,
+		DryRun:        req.DryRun,
+		TriggerType:   triggerType,
+		TriggeredBy:   &userID,
+		PlaceholderID: placeholderID,
+	})
+	if errors.Is(err, errOpportunityJobRunCreate) {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create job run"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "SCRAPER_ERROR", Message: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type opportunityScraperRunParams struct {
+	State         string
+	City          string
+	Neighborhood  string
+	Sources       []string
+	Limit         int
+	DryRun        bool
+	TriggerType   string // admin, admin_dry_run or schedule
+	TriggeredBy   *string
+	PlaceholderID string
+}
+
+var errOpportunityJobRunCreate = errors.New("failed to create job run")
+
+// executeOpportunityScraperRun runs the scraper within the Chrome concurrency cap,
+// ingests the listings and records the run in opportunity_job_runs. Failures
+// after the job run exists are recorded there and returned.
+func (a *api) executeOpportunityScraperRun(ctx context.Context, params opportunityScraperRunParams) (runOpportunityScraperResponse, error) {
+	release, err := a.acquireScraperSlot(ctx)
+	if err != nil {
+		return runOpportunityScraperResponse{}, err
+	}
+	defer release()
+
+	state := params.State
+	city := params.City
+	neighborhood := params.Neighborhood
+	sources := params.Sources
+	limit := params.Limit
+	placeholderID := params.PlaceholderID
+
 	jobRunID := uuid.New().String()
 	startedAt := time.Now()
 	paramsJSON, _ := json.Marshal(map[string]interface{}{
-		"source":       "ZAP",
+		"sources":      sources,
 		"city":         city,
 		"neighborhood": neighborhood,
 		"state":        state,
 		"limit":        limit,
-		"dry_run":      req.DryRun,
+		"dry_run":      params.DryRun,
 	})
 
-	_, err := a.db.ExecContext(r.Context(), `
+	_, err = a.db.ExecContext(ctx, `
 		INSERT INTO opportunity_job_runs (id, job_name, status, trigger_type, triggered_by, started_at, params, created_at)
 		VALUES ($1, 'ZapOpportunityWorker', 'running', $2, $3, $4, $5, $4)
-	`, jobRunID, triggerType, userID, startedAt, paramsJSON)
+	`, jobRunID, params.TriggerType, params.TriggeredBy, startedAt, paramsJSON)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create job run"})
-		return
+		return runOpportunityScraperResponse{}, fmt.Errorf("%w: %v", errOpportunityJobRunCreate, err)
 	}
 
-	runResult, runErr := zapscraper.Run(r.Context(), zapscraper.RunParams{
+	runResult, runErr := zapscraper.Run(ctx, zapscraper.RunParams{
 		City:         city,
 		Neighborhood: neighborhood,
 		State:        state,
 		MaxListings:  limit,
+		Sources:      sources,
 	})
 
 	finishedAt := time.Now()
 	if runErr != nil {
 		errMessage := runErr.Error()
-		if updateErr := a.finishOpportunityJobRun(r.Context(), jobRunID, "failed", nil, &errMessage, finishedAt); updateErr != nil {
+		if updateErr := a.finishOpportunityJobRun(ctx, jobRunID, "failed", nil, &errMessage, finishedAt); updateErr != nil {
 			log.Printf("admin scraper: failed to finalize job run %s after error: %v", jobRunID, updateErr)
 		}
 		if placeholderID != "" {
-			if _, touchErr := a.touchOpportunityScraperPlaceholderRun(r.Context(), placeholderID, jobRunID, finishedAt); touchErr != nil {
+			if _, touchErr := a.touchOpportunityScraperPlaceholderRun(ctx, placeholderID, jobRunID, finishedAt); touchErr != nil {
 				log.Printf("admin scraper: failed to touch placeholder %s after failure: %v", placeholderID, touchErr)
 			}
 		}
-		writeError(w, http.StatusInternalServerError, apiError{Code: "SCRAPER_ERROR", Message: runErr.Error()})
-		return
+		return runOpportunityScraperResponse{JobRunID: jobRunID, DryRun: params.DryRun}, runErr
 	}
 
 	ingestListings := make([]IngestListing, 0, len(runResult.Listings))
@@ -389,27 +546,44 @@ func (a *api) handleAdminRunOpportunityScraper(w http.ResponseWriter, r *http.Re
 		resultListings = append(resultListings, mapScraperOpportunityToRunListing(listing))
 	}
 
-	newCount := 0
-	updatedCount := 0
-	if !req.DryRun {
-		newCount, updatedCount = a.ingestOpportunityListings(ingestListings)
+	ingestStats := IngestStats{TotalReceived: len(ingestListings)}
+	delistedCount := 0
+	if !params.DryRun {
+		ingestStats = a.ingestOpportunityListings(ingestListings, jobRunID)
+
+		delisted, delistErr := a.detectDelistedListings(ctx, listingRunScope{
+			JobRunID:     jobRunID,
+			State:        state,
+			City:         city,
+			Neighborhood: neighborhood,
+			Sources:      completeScraperSources(runResult.Sources, limit),
+		})
+		if delistErr != nil {
+			log.Printf("admin scraper: failed to detect delisted listings for job run %s: %v", jobRunID, delistErr)
+		}
+		delistedCount = delisted
 	}
 
 	stats := map[string]interface{}{
 		"total_received":  len(ingestListings),
-		"new_listings":    newCount,
-		"updated":         updatedCount,
+		"new_listings":    ingestStats.NewListings,
+		"updated":         ingestStats.Updated,
+		"price_drops":     ingestStats.PriceDrops,
+		"relisted":        ingestStats.Relisted,
+		"delisted":        delistedCount,
+		"alerts":          ingestStats.Alerts,
 		"median_price_m2": runResult.MedianPriceM2,
-		"dry_run":         req.DryRun,
+		"sources":         runResult.Sources,
+		"dry_run":         params.DryRun,
 	}
 
-	if updateErr := a.finishOpportunityJobRun(r.Context(), jobRunID, "completed", stats, nil, finishedAt); updateErr != nil {
+	if updateErr := a.finishOpportunityJobRun(ctx, jobRunID, "completed", stats, nil, finishedAt); updateErr != nil {
 		log.Printf("admin scraper: failed to finalize job run %s: %v", jobRunID, updateErr)
 	}
 
 	var updatedPlaceholder *opportunityScraperPlaceholderResponse
 	if placeholderID != "" {
-		placeholder, touchErr := a.touchOpportunityScraperPlaceholderRun(r.Context(), placeholderID, jobRunID, finishedAt)
+		placeholder, touchErr := a.touchOpportunityScraperPlaceholderRun(ctx, placeholderID, jobRunID, finishedAt)
 		if touchErr != nil {
 			log.Printf("admin scraper: failed to touch placeholder %s: %v", placeholderID, touchErr)
 		} else {
@@ -417,23 +591,28 @@ func (a *api) handleAdminRunOpportunityScraper(w http.ResponseWriter, r *http.Re
 		}
 	}
 
-	writeJSON(w, http.StatusOK, runOpportunityScraperResponse{
+	return runOpportunityScraperResponse{
 		JobRunID: jobRunID,
-		DryRun:   req.DryRun,
+		DryRun:   params.DryRun,
 		Stats: runOpportunityScraperStats{
 			TotalReceived: len(ingestListings),
-			NewListings:   newCount,
-			Updated:       updatedCount,
+			NewListings:   ingestStats.NewListings,
+			Updated:       ingestStats.Updated,
+			PriceDrops:    ingestStats.PriceDrops,
+			Relisted:      ingestStats.Relisted,
+			Delisted:      delistedCount,
+			Alerts:        ingestStats.Alerts,
 			MedianPriceM2: runResult.MedianPriceM2,
+			Sources:       runResult.Sources,
 		},
 		Listings:    resultListings,
 		Placeholder: updatedPlaceholder,
-	})
+	}, nil
 }
 
 func (a *api) getOpportunityScraperPlaceholderByID(ctx context.Context, id string) (opportunityScraperPlaceholderResponse, error) {
 	row := a.db.QueryRowContext(ctx, `
-		SELECT id, state, city, neighborhood, last_run_at, last_job_run_id, created_at, updated_at
+		SELECT `+opportunityScraperPlaceholderColumns+`
 		FROM opportunity_scraper_placeholders
 		WHERE id = $1
 	`, id)
@@ -458,7 +637,7 @@ func (a *api) touchOpportunityScraperPlaceholderRun(
 			last_job_run_id = $2,
 			updated_at = NOW()
 		WHERE id = $3
-		RETURNING id, state, city, neighborhood, last_run_at, last_job_run_id, created_at, updated_at
+		RETURNING `+opportunityScraperPlaceholderColumns+`
 	`, runAt, jobRunID, id)
 
 	placeholder, err := scanOpportunityScraperPlaceholder(row)
@@ -497,28 +676,71 @@ func (a *api) finishOpportunityJobRun(
 	return nil
 }
 
-func (a *api) ingestOpportunityListings(listings []IngestListing) (int, int) {
-	newCount := 0
-	updatedCount := 0
+func (a *api) ingestOpportunityListings(listings []IngestListing, jobRunID string) IngestStats {
+	stats := IngestStats{TotalReceived: len(listings)}
+	alertCandidates := make([]opportunityAlertCandidate, 0)
 
 	for _, listing := range listings {
-		isNew, err := a.upsertListing(listing)
+		result, err := a.upsertListing(listing, jobRunID)
 		if err != nil {
-			log.Printf("admin scraper: failed to upsert listing source=%s listing_id=%s: %v", listing.Source, listing.SourceListingID, err)
+			log.Printf("opportunity ingest: failed to upsert listing source=%s listing_id=%s: %v", listing.Source, listing.SourceListingID, err)
 			continue
 		}
 
-		if isNew {
-			newCount++
+		if result.IsNew {
+			stats.NewListings++
 		} else {
-			updatedCount++
+			stats.Updated++
+		}
+		if result.PriceDropped {
+			stats.PriceDrops++
+		}
+		if result.Relisted {
+			stats.Relisted++
+		}
+
+		switch {
+		case result.IsNew:
+			alertCandidates = append(alertCandidates, opportunityAlertCandidate{
+				ListingID: result.ListingID, Reason: opportunityAlertNew, PriceCents: listing.PriceCents,
+			})
+		case result.PriceDropped:
+			alertCandidates = append(alertCandidates, opportunityAlertCandidate{
+				ListingID: result.ListingID, Reason: opportunityAlertPriceDrop, PriceCents: listing.PriceCents,
+				PreviousPriceCents: result.PreviousPriceCents,
+			})
 		}
 	}
 
-	return newCount, updatedCount
+	// New and cheaper listings are matched against the saved searches
+	alerts, err := a.dispatchOpportunityAlerts(context.Background(), jobRunID, alertCandidates)
+	if err != nil {
+		log.Printf("opportunity alerts: failed to match saved searches job_run_id=%s: %v", jobRunID, err)
+	}
+	stats.Alerts = alerts
+
+	return stats
+}
+
+// completeScraperSources returns the portals that answered without error and
+// below the run limit, i.e. whose search results were not truncated. An empty
+// result is more likely a blocked page than a sold-out neighborhood.
+func completeScraperSources(stats []zapscraper.SourceRunStats, limit int) []string {
+	sources := make([]string, 0, len(stats))
+	for _, stat := range stats {
+		if stat.Error == "" && stat.Listings > 0 && stat.Listings < limit {
+			sources = append(sources, stat.Source)
+		}
+	}
+	return sources
 }
 
 func mapScraperOpportunityToIngestListing(listing zapscraper.Opportunity) IngestListing {
+	var parseQuality *int
+	if len(listing.FieldSources) > 0 {
+		quality := listing.ParseQuality
+		parseQuality = &quality
+	}
 	return IngestListing{
 		Source:          listing.Source,
 		SourceListingID: listing.SourceListingID,
@@ -537,6 +759,7 @@ func mapScraperOpportunityToIngestListing(listing zapscraper.Opportunity) Ingest
 		City:            listing.City,
 		State:           listing.State,
 		Images:          listing.Images,
+		ImageHashes:     listing.ImageHashes,
 		PublishedAt:     listing.PublishedAt,
 		Score:           listing.Score,
 		ScoreBreakdown: ScoreBreakdown{
@@ -547,15 +770,19 @@ func mapScraperOpportunityToIngestListing(listing zapscraper.Opportunity) Ingest
 			Keywords:  listing.ScoreBreakdown.Keywords,
 			Penalties: listing.ScoreBreakdown.Penalties,
 			Decay:     listing.ScoreBreakdown.Decay,
+			PriceCut:  listing.ScoreBreakdown.PriceCut,
 		},
 		PricePerM2:    listing.PricePerM2,
 		MedianPriceM2: listing.MedianPriceM2,
 		DiscountPct:   listing.DiscountPct,
+		ParseQuality:  parseQuality,
+		FieldSources:  listing.FieldSources,
 	}
 }
 
 func mapScraperOpportunityToRunListing(listing zapscraper.Opportunity) runOpportunityScraperListing {
 	return runOpportunityScraperListing{
+		Source:          listing.Source,
 		SourceListingID: listing.SourceListingID,
 		CanonicalURL:    listing.CanonicalURL,
 		Title:           listing.Title,
@@ -571,6 +798,7 @@ func mapScraperOpportunityToRunListing(listing zapscraper.Opportunity) runOpport
 		PricePerM2:      listing.PricePerM2,
 		MedianPriceM2:   listing.MedianPriceM2,
 		DiscountPct:     listing.DiscountPct,
+		ParseQuality:    listing.ParseQuality,
 	}
 }
 
@@ -580,16 +808,31 @@ func scanOpportunityScraperPlaceholder(scanner interface {
 	var placeholder opportunityScraperPlaceholderResponse
 	var lastRunAt sql.NullTime
 	var lastJobRunID sql.NullString
+	var sources []byte
+	var scheduleCron sql.NullString
+	var scheduleInterval sql.NullInt64
+	var scheduleLimit sql.NullInt64
+	var nextRunAt sql.NullTime
+	var lastError sql.NullString
 
 	err := scanner.Scan(
 		&placeholder.ID,
 		&placeholder.State,
 		&placeholder.City,
 		&placeholder.Neighborhood,
+		&sources,
 		&lastRunAt,
 		&lastJobRunID,
 		&placeholder.CreatedAt,
 		&placeholder.UpdatedAt,
+		&placeholder.Schedule.Enabled,
+		&scheduleCron,
+		&scheduleInterval,
+		&placeholder.Schedule.JitterSeconds,
+		&scheduleLimit,
+		&nextRunAt,
+		&placeholder.Schedule.ConsecutiveFailures,
+		&lastError,
 	)
 	if err != nil {
 		return opportunityScraperPlaceholderResponse{}, err
@@ -601,10 +844,43 @@ func scanOpportunityScraperPlaceholder(scanner interface {
 	if lastJobRunID.Valid {
 		placeholder.LastJobRunID = &lastJobRunID.String
 	}
+	if scheduleCron.Valid {
+		placeholder.Schedule.Cron = &scheduleCron.String
+	}
+	if scheduleInterval.Valid {
+		minutes := int(scheduleInterval.Int64)
+		placeholder.Schedule.IntervalMinutes = &minutes
+	}
+	if scheduleLimit.Valid {
+		limit := int(scheduleLimit.Int64)
+		placeholder.Schedule.Limit = &limit
+	}
+	if nextRunAt.Valid {
+		placeholder.Schedule.NextRunAt = &nextRunAt.Time
+	}
+	if lastError.Valid {
+		placeholder.Schedule.LastError = &lastError.String
+	}
+	if len(sources) == 0 || json.Unmarshal(sources, &placeholder.Sources) != nil || len(placeholder.Sources) == 0 {
+		placeholder.Sources = append([]string(nil), zapscraper.DefaultSources...)
+	}
 
 	return placeholder, nil
 }
 
+// normalizeScraperSources validates the portal names and returns their canonical form ("olx" -> "OLX")
+func normalizeScraperSources(names []string) ([]string, error) {
+	sources, err := zapscraper.ResolveSources(names)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make([]string, 0, len(sources))
+	for _, source := range sources {
+		normalized = append(normalized, source.Name())
+	}
+	return normalized, nil
+}
+
 func normalizeLocationLabel(value string) string {
 	return strings.Join(strings.Fields(strings.TrimSpace(value)), " ")
 }
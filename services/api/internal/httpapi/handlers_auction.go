@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/offerintelligence"
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
+
+// encodeAuctionTerms validates the acquisition mode and returns the auction_terms_json
+// value to store. The market mode always stores NULL; the auction mode without terms
+// stores an empty object so the defaults (5% commission, à vista) apply.
+func encodeAuctionTerms(mode string, terms *viability.AuctionTerms) ([]byte, error) {
+	switch mode {
+	case offerintelligence.AcquisitionModeMarket:
+		return nil, nil
+	case offerintelligence.AcquisitionModeAuction:
+	default:
+		return nil, errors.New("acquisition_mode must be one of: market, auction")
+	}
+
+	if terms == nil {
+		terms = &viability.AuctionTerms{}
+	}
+	if err := viability.ValidateAuctionTerms(*terms); err != nil {
+		return nil, errors.New("auction_terms: " + err.Error())
+	}
+	return json.Marshal(terms)
+}
+
+// decodeAuctionTerms returns the stored auction terms, nil for the market mode
+func decodeAuctionTerms(mode string, raw []byte) *viability.AuctionTerms {
+	if mode != offerintelligence.AcquisitionModeAuction {
+		return nil
+	}
+	terms := &viability.AuctionTerms{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, terms); err != nil {
+			return &viability.AuctionTerms{}
+		}
+	}
+	return terms
+}
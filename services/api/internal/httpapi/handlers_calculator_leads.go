@@ -178,6 +178,7 @@ func (a *api) handlePublicCalculatorLead(w http.ResponseWriter, r *http.Request)
 			NetProfit:       result.NetProfit,
 			ROI:             result.ROI,
 			IsPartial:       result.IsPartial,
+			TaxBreakdown:    result.TaxBreakdown,
 		},
 	})
 }
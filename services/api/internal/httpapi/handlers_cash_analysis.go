@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/offerintelligence"
 	"github.com/widia-projects/widia-flip/services/api/internal/viability"
 )
 
@@ -17,6 +18,13 @@ type cashInputs struct {
 	RenovationCost *float64 `json:"renovation_cost"`
 	OtherCosts     *float64 `json:"other_costs"`
 	SalePrice      *float64 `json:"sale_price"`
+	HoldMonths     *int     `json:"hold_months"`
+	CondoFee       *float64 `json:"condo_fee"`
+	IPTU           *float64 `json:"iptu"`
+
+	// Acquisition mode (market or auction); in the auction mode purchase_price is the bid
+	AcquisitionMode *string                 `json:"acquisition_mode"`
+	AuctionTerms    *viability.AuctionTerms `json:"auction_terms"`
 }
 
 type cashOutputs struct {
@@ -30,12 +38,19 @@ type cashOutputs struct {
 	NetProfit       float64 `json:"net_profit"`
 	ROI             float64 `json:"roi"`
 	IsPartial       bool    `json:"is_partial"`
+
+	TaxBreakdown *viability.TaxBreakdown `json:"tax_breakdown,omitempty"`
+
+	Auction *viability.AuctionBreakdown `json:"auction,omitempty"`
 }
 
 type cashAnalysisResponse struct {
 	Inputs         cashInputs     `json:"inputs"`
 	Outputs        cashOutputs    `json:"outputs"`
 	EffectiveRates effectiveRates `json:"effective_rates"`
+
+	// CashFlow is the monthly timeline with IRR/NPV (nil while the analysis is partial)
+	CashFlow *viability.CashFlow `json:"cash_flow,omitempty"`
 }
 
 type cashSnapshot struct {
@@ -43,6 +58,7 @@ type cashSnapshot struct {
 	Inputs         json.RawMessage `json:"inputs"`
 	Outputs        json.RawMessage `json:"outputs"`
 	EffectiveRates json.RawMessage `json:"effective_rates,omitempty"`
+	CashFlow       json.RawMessage `json:"cash_flow,omitempty"`
 	StatusPipeline *string         `json:"status_pipeline,omitempty"`
 	CreatedAt      time.Time       `json:"created_at"`
 }
@@ -70,6 +86,26 @@ func (a *api) handlePropertyCashAnalysis(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	// /api/v1/properties/:id/analysis/cash/solve
+	if len(subparts) == 1 && subparts[0] == "solve" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		a.handleSolveCashAnalysis(w, r, propertyID)
+		return
+	}
+
+	// /api/v1/properties/:id/analysis/cash/sensitivity
+	if len(subparts) == 1 && subparts[0] == "sensitivity" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		a.handlePropertyCashSensitivity(w, r, propertyID)
+		return
+	}
+
 	// /api/v1/properties/:id/analysis/cash/snapshot
 	if len(subparts) == 1 && subparts[0] == "snapshot" {
 		if r.Method != http.MethodPost {
@@ -138,14 +174,7 @@ func (a *api) handleGetCashAnalysis(w http.ResponseWriter, r *http.Request, prop
 	}
 
 	// Get current inputs
-	var inputs cashInputs
-	err = a.db.QueryRowContext(
-		r.Context(),
-		`SELECT purchase_price, renovation_cost, other_costs, sale_price
-		 FROM analysis_cash_inputs
-		 WHERE property_id = $1`,
-		propertyID,
-	).Scan(&inputs.PurchasePrice, &inputs.RenovationCost, &inputs.OtherCosts, &inputs.SalePrice)
+	inputs, err := a.getCashInputs(r.Context(), propertyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Return empty inputs with partial outputs
@@ -163,10 +192,17 @@ func (a *api) handleGetCashAnalysis(w http.ResponseWriter, r *http.Request, prop
 	// Calculate outputs
 	outputs := a.calculateCashOutputs(inputs, settings)
 
+	cashFlow, err := a.buildCashAnalysisCashFlow(r.Context(), propertyID, workspaceID, inputs, outputs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to build cash flow"})
+		return
+	}
+
 	writeJSON(w, http.StatusOK, cashAnalysisResponse{
 		Inputs:         inputs,
 		Outputs:        outputs,
 		EffectiveRates: settingsToRates(settings),
+		CashFlow:       cashFlow,
 	})
 }
 
@@ -202,6 +238,34 @@ func (a *api) handleUpdateCashAnalysis(w http.ResponseWriter, r *http.Request, p
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "sale_price must be >= 0"})
 		return
 	}
+	if req.HoldMonths != nil && *req.HoldMonths <= 0 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "hold_months must be > 0"})
+		return
+	}
+	if req.CondoFee != nil && *req.CondoFee < 0 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "condo_fee must be >= 0"})
+		return
+	}
+	if req.IPTU != nil && *req.IPTU < 0 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "iptu must be >= 0"})
+		return
+	}
+	// Auction terms without a mode switch the analysis to the auction mode
+	var acquisitionMode *string
+	var auctionTermsJSON []byte
+	if req.AcquisitionMode != nil || req.AuctionTerms != nil {
+		mode := offerintelligence.AcquisitionModeAuction
+		if req.AcquisitionMode != nil {
+			mode = *req.AcquisitionMode
+		}
+		encoded, err := encodeAuctionTerms(mode, req.AuctionTerms)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+			return
+		}
+		acquisitionMode = &mode
+		auctionTermsJSON = encoded
+	}
 
 	// Check access and get workspace_id
 	var workspaceID string
@@ -222,26 +286,37 @@ func (a *api) handleUpdateCashAnalysis(w http.ResponseWriter, r *http.Request, p
 		return
 	}
 
-	// Upsert inputs
+	// Upsert inputs (the auction terms are only replaced together with the mode)
 	var inputs cashInputs
+	var auctionTerms []byte
 	err = a.db.QueryRowContext(
 		r.Context(),
-		`INSERT INTO analysis_cash_inputs (property_id, workspace_id, purchase_price, renovation_cost, other_costs, sale_price)
-		 VALUES ($1, $2, $3, $4, $5, $6)
+		`INSERT INTO analysis_cash_inputs (property_id, workspace_id, purchase_price, renovation_cost, other_costs, sale_price,
+		                                   hold_months, condo_fee, iptu, acquisition_mode, auction_terms_json)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE($10, 'market'), $11)
 		 ON CONFLICT (property_id)
 		 DO UPDATE SET
 		   purchase_price = COALESCE($3, analysis_cash_inputs.purchase_price),
 		   renovation_cost = COALESCE($4, analysis_cash_inputs.renovation_cost),
 		   other_costs = COALESCE($5, analysis_cash_inputs.other_costs),
 		   sale_price = COALESCE($6, analysis_cash_inputs.sale_price),
+		   hold_months = COALESCE($7, analysis_cash_inputs.hold_months),
+		   condo_fee = COALESCE($8, analysis_cash_inputs.condo_fee),
+		   iptu = COALESCE($9, analysis_cash_inputs.iptu),
+		   acquisition_mode = COALESCE($10, analysis_cash_inputs.acquisition_mode),
+		   auction_terms_json = CASE WHEN $10::text IS NULL THEN analysis_cash_inputs.auction_terms_json ELSE $11 END,
 		   updated_at = now()
-		 RETURNING purchase_price, renovation_cost, other_costs, sale_price`,
+		 RETURNING purchase_price, renovation_cost, other_costs, sale_price, hold_months, condo_fee, iptu,
+		           acquisition_mode, auction_terms_json`,
 		propertyID, workspaceID, req.PurchasePrice, req.RenovationCost, req.OtherCosts, req.SalePrice,
-	).Scan(&inputs.PurchasePrice, &inputs.RenovationCost, &inputs.OtherCosts, &inputs.SalePrice)
+		req.HoldMonths, req.CondoFee, req.IPTU, acquisitionMode, auctionTermsJSON,
+	).Scan(&inputs.PurchasePrice, &inputs.RenovationCost, &inputs.OtherCosts, &inputs.SalePrice,
+		&inputs.HoldMonths, &inputs.CondoFee, &inputs.IPTU, &inputs.AcquisitionMode, &auctionTerms)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to save analysis", Details: []string{err.Error()}})
 		return
 	}
+	inputs.AuctionTerms = decodeAuctionTerms(*inputs.AcquisitionMode, auctionTerms)
 
 	// Get effective settings (property-level overrides + workspace fallback)
 	settings, err := a.getEffectivePropertySettings(r.Context(), propertyID, workspaceID)
@@ -253,10 +328,17 @@ func (a *api) handleUpdateCashAnalysis(w http.ResponseWriter, r *http.Request, p
 	// Calculate outputs
 	outputs := a.calculateCashOutputs(inputs, settings)
 
+	cashFlow, err := a.buildCashAnalysisCashFlow(r.Context(), propertyID, workspaceID, inputs, outputs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to build cash flow"})
+		return
+	}
+
 	writeJSON(w, http.StatusOK, cashAnalysisResponse{
 		Inputs:         inputs,
 		Outputs:        outputs,
 		EffectiveRates: settingsToRates(settings),
+		CashFlow:       cashFlow,
 	})
 }
 
@@ -293,14 +375,7 @@ func (a *api) handleCreateCashSnapshot(w http.ResponseWriter, r *http.Request, p
 	}
 
 	// Get current inputs
-	var inputs cashInputs
-	err = a.db.QueryRowContext(
-		r.Context(),
-		`SELECT purchase_price, renovation_cost, other_costs, sale_price
-		 FROM analysis_cash_inputs
-		 WHERE property_id = $1`,
-		propertyID,
-	).Scan(&inputs.PurchasePrice, &inputs.RenovationCost, &inputs.OtherCosts, &inputs.SalePrice)
+	inputs, err := a.getCashInputs(r.Context(), propertyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			writeError(w, http.StatusBadRequest, apiError{Code: "NO_ANALYSIS", Message: "no analysis to snapshot"})
@@ -320,19 +395,29 @@ func (a *api) handleCreateCashSnapshot(w http.ResponseWriter, r *http.Request, p
 	// Calculate outputs
 	outputs := a.calculateCashOutputs(inputs, settings)
 
+	cashFlow, err := a.buildCashAnalysisCashFlow(r.Context(), propertyID, workspaceID, inputs, outputs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to build cash flow"})
+		return
+	}
+
 	// Create snapshot
 	inputsJSON, _ := json.Marshal(inputs)
 	outputsJSON, _ := json.Marshal(outputs)
 	ratesJSON, _ := json.Marshal(settingsToRates(settings))
+	var cashFlowJSON []byte
+	if cashFlow != nil {
+		cashFlowJSON, _ = json.Marshal(cashFlow)
+	}
 
 	var snapshotID string
 	var createdAt time.Time
 	err = a.db.QueryRowContext(
 		r.Context(),
-		`INSERT INTO analysis_cash_snapshots (property_id, workspace_id, inputs, outputs, effective_rates, status_pipeline)
-		 VALUES ($1, $2, $3, $4, $5, $6)
+		`INSERT INTO analysis_cash_snapshots (property_id, workspace_id, inputs, outputs, effective_rates, status_pipeline, cash_flow_json)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
 		 RETURNING id, created_at`,
-		propertyID, workspaceID, inputsJSON, outputsJSON, ratesJSON, statusPipeline,
+		propertyID, workspaceID, inputsJSON, outputsJSON, ratesJSON, statusPipeline, cashFlowJSON,
 	).Scan(&snapshotID, &createdAt)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create snapshot"})
@@ -394,7 +479,7 @@ func (a *api) handleListCashSnapshots(w http.ResponseWriter, r *http.Request, pr
 
 	rows, err := a.db.QueryContext(
 		r.Context(),
-		`SELECT id, inputs, outputs, effective_rates, status_pipeline, created_at
+		`SELECT id, inputs, outputs, effective_rates, status_pipeline, cash_flow_json, created_at
 		 FROM analysis_cash_snapshots
 		 WHERE property_id = $1
 		 ORDER BY created_at DESC
@@ -410,8 +495,8 @@ func (a *api) handleListCashSnapshots(w http.ResponseWriter, r *http.Request, pr
 	items := make([]cashSnapshot, 0)
 	for rows.Next() {
 		var s cashSnapshot
-		var rates, status sql.NullString
-		err := rows.Scan(&s.ID, &s.Inputs, &s.Outputs, &rates, &status, &s.CreatedAt)
+		var rates, status, cashFlow sql.NullString
+		err := rows.Scan(&s.ID, &s.Inputs, &s.Outputs, &rates, &status, &cashFlow, &s.CreatedAt)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to scan snapshot"})
 			return
@@ -419,6 +504,9 @@ func (a *api) handleListCashSnapshots(w http.ResponseWriter, r *http.Request, pr
 		if rates.Valid {
 			s.EffectiveRates = json.RawMessage(rates.String)
 		}
+		if cashFlow.Valid {
+			s.CashFlow = json.RawMessage(cashFlow.String)
+		}
 		if status.Valid {
 			s.StatusPipeline = &status.String
 		}
@@ -477,25 +565,55 @@ func (a *api) handleDeleteCashSnapshot(w http.ResponseWriter, r *http.Request, p
 
 func (a *api) getWorkspaceCashSettings(ctx context.Context, workspaceID string) (viability.CashSettings, error) {
 	var s viability.CashSettings
+	var taxRegime string
+	var simplesRate, retRate, reinvestedPct sql.NullFloat64
 	err := a.db.QueryRowContext(
 		ctx,
-		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate
+		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate,
+		        tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct
 		 FROM workspace_settings
 		 WHERE workspace_id = $1`,
 		workspaceID,
-	).Scan(&s.ITBIRate, &s.RegistryRate, &s.BrokerRate, &s.PJTaxRate)
+	).Scan(&s.ITBIRate, &s.RegistryRate, &s.BrokerRate, &s.PJTaxRate,
+		&taxRegime, &simplesRate, &retRate, &reinvestedPct)
+	s.Tax = taxSettingsFromColumns(taxRegime, simplesRate, retRate, reinvestedPct)
 	return s, err
 }
 
-func (a *api) calculateCashOutputs(inputs cashInputs, settings viability.CashSettings) cashOutputs {
-	viabilityInputs := viability.CashInputs{
+// getCashInputs returns the saved cash analysis inputs (sql.ErrNoRows when none were saved)
+func (a *api) getCashInputs(ctx context.Context, propertyID string) (cashInputs, error) {
+	var inputs cashInputs
+	var mode sql.NullString
+	var auctionTerms []byte
+	err := a.db.QueryRowContext(
+		ctx,
+		`SELECT purchase_price, renovation_cost, other_costs, sale_price, hold_months, condo_fee, iptu,
+		        acquisition_mode, auction_terms_json
+		 FROM analysis_cash_inputs
+		 WHERE property_id = $1`,
+		propertyID,
+	).Scan(&inputs.PurchasePrice, &inputs.RenovationCost, &inputs.OtherCosts, &inputs.SalePrice,
+		&inputs.HoldMonths, &inputs.CondoFee, &inputs.IPTU, &mode, &auctionTerms)
+	if mode.Valid {
+		inputs.AcquisitionMode = &mode.String
+		inputs.AuctionTerms = decodeAuctionTerms(mode.String, auctionTerms)
+	}
+	return inputs, err
+}
+
+func (inputs cashInputs) toViability() viability.CashInputs {
+	return viability.CashInputs{
 		PurchasePrice:  inputs.PurchasePrice,
 		RenovationCost: inputs.RenovationCost,
 		OtherCosts:     inputs.OtherCosts,
 		SalePrice:      inputs.SalePrice,
+		HoldMonths:     inputs.HoldMonths,
+		Auction:        inputs.AuctionTerms,
 	}
+}
 
-	result := viability.CalculateCash(viabilityInputs, settings)
+func (a *api) calculateCashOutputs(inputs cashInputs, settings viability.CashSettings) cashOutputs {
+	result := viability.CalculateCash(inputs.toViability(), settings)
 
 	return cashOutputs{
 		ITBIValue:       result.ITBIValue,
@@ -508,5 +626,7 @@ func (a *api) calculateCashOutputs(inputs cashInputs, settings viability.CashSet
 		NetProfit:       result.NetProfit,
 		ROI:             result.ROI,
 		IsPartial:       result.IsPartial,
+		TaxBreakdown:    result.TaxBreakdown,
+		Auction:         result.Auction,
 	}
 }
@@ -0,0 +1,260 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
+
+// cashFlowContext holds the property data the cash-flow timeline needs beyond the analysis inputs
+type cashFlowContext struct {
+	DiscountRate       float64
+	RenovationDraws    []viability.CashFlowDraw
+	ProspectCondoFee   *float64
+	ProspectIPTU       *float64
+	ProspectHoldMonths *int
+}
+
+// loadCashFlowContext reads the workspace discount rate, the renovation draws derived from
+// cost/schedule items and the carry defaults inherited from the origin prospect
+func (a *api) loadCashFlowContext(ctx context.Context, propertyID, workspaceID string) (cashFlowContext, error) {
+	var c cashFlowContext
+
+	err := a.db.QueryRowContext(
+		ctx,
+		`SELECT discount_rate FROM workspace_settings WHERE workspace_id = $1`,
+		workspaceID,
+	).Scan(&c.DiscountRate)
+	if err != nil && err != sql.ErrNoRows {
+		return c, err
+	}
+
+	c.ProspectCondoFee, c.ProspectIPTU, c.ProspectHoldMonths, err = a.loadOriginProspectCarry(ctx, propertyID)
+	if err != nil {
+		return c, err
+	}
+
+	draws, err := a.loadRenovationDraws(ctx, propertyID)
+	if err != nil {
+		return c, err
+	}
+	c.RenovationDraws = draws
+
+	return c, nil
+}
+
+// loadOriginProspectCarry returns the condo fee, IPTU and hold period of the prospect the
+// property was converted from (all nil when the property has no origin prospect)
+func (a *api) loadOriginProspectCarry(ctx context.Context, propertyID string) (condoFee, iptu *float64, holdMonths *int, err error) {
+	err = a.db.QueryRowContext(
+		ctx,
+		`SELECT pp.condo_fee, pp.iptu, pp.hold_months
+		 FROM properties p
+		 JOIN prospecting_properties pp ON pp.id = p.origin_prospect_id
+		 WHERE p.id = $1`,
+		propertyID,
+	).Scan(&condoFee, &iptu, &holdMonths)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil, nil
+	}
+	return condoFee, iptu, holdMonths, err
+}
+
+// loadRenovationDraws spreads renovation cost items over the months they happen.
+// Items linked to a schedule item are spread evenly across its date range; the rest
+// fall on their due date. Month 0 is when the property was bought (first status change
+// to "bought"), falling back to the earliest dated item.
+func (a *api) loadRenovationDraws(ctx context.Context, propertyID string) ([]viability.CashFlowDraw, error) {
+	var boughtAt sql.NullTime
+	err := a.db.QueryRowContext(
+		ctx,
+		`SELECT MIN(created_at)
+		 FROM timeline_events
+		 WHERE property_id = $1 AND event_type = $2 AND payload->>'to_status' = $3`,
+		propertyID, EventTypeStatusChanged, PropertyStatusBought,
+	).Scan(&boughtAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	rows, err := a.db.QueryContext(
+		ctx,
+		`SELECT c.amount, COALESCE(s.start_date, c.due_date), COALESCE(s.end_date, c.due_date)
+		 FROM cost_items c
+		 LEFT JOIN schedule_items s ON s.id = c.schedule_item_id
+		 WHERE c.property_id = $1 AND c.cost_type = 'renovation'`,
+		propertyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type datedCost struct {
+		amount     float64
+		start, end sql.NullTime
+	}
+	var items []datedCost
+	var earliest time.Time
+	for rows.Next() {
+		var item datedCost
+		if err := rows.Scan(&item.amount, &item.start, &item.end); err != nil {
+			return nil, err
+		}
+		if item.start.Valid && (earliest.IsZero() || item.start.Time.Before(earliest)) {
+			earliest = item.start.Time
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reference := earliest
+	if boughtAt.Valid {
+		reference = boughtAt.Time
+	}
+	if reference.IsZero() {
+		reference = time.Now()
+	}
+
+	draws := make([]viability.CashFlowDraw, 0, len(items))
+	for _, item := range items {
+		if item.amount <= 0 {
+			continue
+		}
+		if !item.start.Valid {
+			// Undated renovation spend goes to the first month after purchase
+			draws = append(draws, viability.CashFlowDraw{MonthIndex: 1, Amount: item.amount})
+			continue
+		}
+
+		startMonth := monthsBetween(reference, item.start.Time)
+		endMonth := startMonth
+		if item.end.Valid {
+			endMonth = max(startMonth, monthsBetween(reference, item.end.Time))
+		}
+		span := endMonth - startMonth + 1
+		for month := startMonth; month <= endMonth; month++ {
+			draws = append(draws, viability.CashFlowDraw{MonthIndex: month, Amount: item.amount / float64(span)})
+		}
+	}
+
+	return draws, nil
+}
+
+// monthsBetween returns the number of calendar months from -> to (negative when to is earlier)
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// buildCashAnalysisCashFlow builds the cash-flow timeline for the cash analysis.
+// Carry inputs fall back to the origin prospect when not set on the analysis.
+func (a *api) buildCashAnalysisCashFlow(ctx context.Context, propertyID, workspaceID string, inputs cashInputs, outputs cashOutputs) (*viability.CashFlow, error) {
+	if outputs.IsPartial {
+		return nil, nil
+	}
+
+	c, err := a.loadCashFlowContext(ctx, propertyID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return viability.BuildCashFlowCash(
+		viability.CashInputs{
+			PurchasePrice:  inputs.PurchasePrice,
+			RenovationCost: inputs.RenovationCost,
+			OtherCosts:     inputs.OtherCosts,
+			SalePrice:      inputs.SalePrice,
+		},
+		viability.CashOutputs{
+			ITBIValue:     outputs.ITBIValue,
+			RegistryValue: outputs.RegistryValue,
+			BrokerFee:     outputs.BrokerFee,
+			PJTaxValue:    outputs.PJTaxValue,
+			TaxBreakdown:  outputs.TaxBreakdown,
+			ROI:           outputs.ROI,
+			IsPartial:     outputs.IsPartial,
+			Auction:       outputs.Auction,
+		},
+		cashAnalysisFlowParams(inputs, c),
+	), nil
+}
+
+// buildFinancingAnalysisCashFlow builds the cash-flow timeline for the financing analysis.
+// The sale happens at the plan's exit month (falling back to the prospect hold period).
+func (a *api) buildFinancingAnalysisCashFlow(ctx context.Context, propertyID, workspaceID string, inputs financingInputs, payments []financingPayment, outputs financingOutputs, schedule *viability.AmortizationSchedule) (*viability.CashFlow, error) {
+	if outputs.IsPartial {
+		return nil, nil
+	}
+
+	c, err := a.loadCashFlowContext(ctx, propertyID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return viability.BuildCashFlowFinancing(
+		viability.FinancingInputs{SalePrice: inputs.SalePrice},
+		viability.FinancingOutputs{
+			DownPaymentValue: outputs.DownPaymentValue,
+			BankFeesTotal:    outputs.BankFeesTotal,
+			AcquisitionFees:  outputs.AcquisitionFees,
+			BrokerFee:        outputs.BrokerFee,
+			PJTaxValue:       outputs.PJTaxValue,
+			TaxBreakdown:     outputs.TaxBreakdown,
+			ROI:              outputs.ROI,
+			RemainingDebt:    outputs.RemainingDebt,
+			ExitMonth:        outputs.ExitMonth,
+			IsPartial:        outputs.IsPartial,
+			Schedule:         schedule,
+		},
+		toViabilityPayments(payments),
+		financingAnalysisFlowParams(inputs, c),
+	), nil
+}
+
+// cashAnalysisFlowParams returns the timeline assumptions of the cash analysis.
+// Carry inputs fall back to the origin prospect when not set on the analysis.
+func cashAnalysisFlowParams(inputs cashInputs, c cashFlowContext) viability.CashFlowParams {
+	return viability.CashFlowParams{
+		HoldMonths:         intOrDefault(inputs.HoldMonths, c.ProspectHoldMonths),
+		CondoFee:           floatOrDefault(inputs.CondoFee, c.ProspectCondoFee),
+		IPTU:               floatOrDefault(inputs.IPTU, c.ProspectIPTU),
+		RenovationDraws:    c.RenovationDraws,
+		AnnualDiscountRate: c.DiscountRate,
+	}
+}
+
+// financingAnalysisFlowParams returns the timeline assumptions of the financing analysis.
+// The hold period only applies when the plan has no exit month.
+func financingAnalysisFlowParams(inputs financingInputs, c cashFlowContext) viability.CashFlowParams {
+	return viability.CashFlowParams{
+		HoldMonths:         intOrDefault(nil, c.ProspectHoldMonths),
+		CondoFee:           floatOrDefault(inputs.CondoFee, c.ProspectCondoFee),
+		IPTU:               floatOrDefault(inputs.IPTU, c.ProspectIPTU),
+		RenovationDraws:    c.RenovationDraws,
+		AnnualDiscountRate: c.DiscountRate,
+	}
+}
+
+func floatOrDefault(v, fallback *float64) float64 {
+	if v != nil {
+		return *v
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return 0
+}
+
+func intOrDefault(v, fallback *int) int {
+	if v != nil {
+		return *v
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return 0
+}
@@ -979,16 +979,25 @@ type resendEmailResponse struct {
 // Send marketing email using Resend and return the Resend email ID
 func sendMarketingEmail(toEmail, userName, subject, bodyHTML, unsubscribeToken string) (string, error) {
 	// Get base URL for unsubscribe link
+	unsubscribeURL := fmt.Sprintf("%s/unsubscribe/%s", appBaseURL(), unsubscribeToken)
+
+	// Build full email HTML with template
+	fullHTML := buildMarketingEmailHTML(userName, bodyHTML, unsubscribeURL)
+
+	return sendResendEmail(toEmail, subject, fullHTML)
+}
+
+// appBaseURL is the public web app URL used in email links
+func appBaseURL() string {
 	baseURL := os.Getenv("BETTER_AUTH_URL")
 	if baseURL == "" {
 		baseURL = "https://meuflip.com"
 	}
-	unsubscribeURL := fmt.Sprintf("%s/unsubscribe/%s", baseURL, unsubscribeToken)
-
-	// Build full email HTML with template
-	fullHTML := buildMarketingEmailHTML(userName, bodyHTML, unsubscribeURL)
+	return strings.TrimRight(baseURL, "/")
+}
 
-	// Use Resend API directly via HTTP
+// Send an email using the Resend API directly via HTTP and return the Resend email ID
+func sendResendEmail(toEmail, subject, html string) (string, error) {
 	apiKey := os.Getenv("RESEND_API_KEY")
 	if apiKey == "" {
 		return "", fmt.Errorf("RESEND_API_KEY not configured")
@@ -1003,7 +1012,7 @@ func sendMarketingEmail(toEmail, userName, subject, bodyHTML, unsubscribeToken s
 		"from":    fromEmail,
 		"to":      []string{toEmail},
 		"subject": subject,
-		"html":    fullHTML,
+		"html":    html,
 	}
 
 	payloadBytes, _ := json.Marshal(payload)
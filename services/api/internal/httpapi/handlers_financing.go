@@ -30,6 +30,12 @@ type financingInputs struct {
 	AppraisalFee       *float64 `json:"appraisal_fee"`
 	OtherFees          *float64 `json:"other_fees"`
 	RemainingDebt      *float64 `json:"remaining_debt"`
+	AmortizationSystem *string  `json:"amortization_system"`
+	CorrectionIndex    *string  `json:"correction_index"`
+	CorrectionRate     *float64 `json:"correction_rate"`
+	ExitMonth          *int     `json:"exit_month"`
+	CondoFee           *float64 `json:"condo_fee"`
+	IPTU               *float64 `json:"iptu"`
 }
 
 type financingOutputs struct {
@@ -48,7 +54,11 @@ type financingOutputs struct {
 	NetProfit            float64 `json:"net_profit"`
 	ROI                  float64 `json:"roi"`
 	InterestPaidEstimate float64 `json:"interest_paid_estimate"`
+	RemainingDebt        float64 `json:"remaining_debt"`
+	ExitMonth            int     `json:"exit_month"`
 	IsPartial            bool    `json:"is_partial"`
+
+	TaxBreakdown *viability.TaxBreakdown `json:"tax_breakdown,omitempty"`
 }
 
 type financingPayment struct {
@@ -64,6 +74,9 @@ type financingAnalysisResponse struct {
 	Payments       []financingPayment `json:"payments"`
 	Outputs        financingOutputs   `json:"outputs"`
 	EffectiveRates effectiveRates     `json:"effective_rates"`
+
+	// CashFlow is the monthly timeline with IRR/NPV (nil while the analysis is partial)
+	CashFlow *viability.CashFlow `json:"cash_flow,omitempty"`
 }
 
 type financingSnapshot struct {
@@ -72,10 +85,18 @@ type financingSnapshot struct {
 	PaymentsJSON   json.RawMessage `json:"payments"`
 	OutputsJSON    json.RawMessage `json:"outputs"`
 	EffectiveRates json.RawMessage `json:"effective_rates,omitempty"`
+	ScheduleJSON   json.RawMessage `json:"schedule,omitempty"`
+	CashFlowJSON   json.RawMessage `json:"cash_flow,omitempty"`
 	StatusPipeline *string         `json:"status_pipeline,omitempty"`
 	CreatedAt      time.Time       `json:"created_at"`
 }
 
+type financingScheduleResponse struct {
+	PlanID    string                          `json:"plan_id"`
+	ExitMonth int                             `json:"exit_month"`
+	Schedule  *viability.AmortizationSchedule `json:"schedule"`
+}
+
 type listFinancingSnapshotsResponse struct {
 	Items []financingSnapshot `json:"items"`
 }
@@ -152,6 +173,26 @@ func (a *api) handlePropertyFinancing(w http.ResponseWriter, r *http.Request, pr
 
 // handlePropertyFinancingAnalysis routes /api/v1/properties/:id/analysis/financing/...
 func (a *api) handlePropertyFinancingAnalysis(w http.ResponseWriter, r *http.Request, propertyID string, subparts []string) {
+	// /api/v1/properties/:id/analysis/financing/schedule
+	if len(subparts) == 1 && subparts[0] == "schedule" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		a.handleGetFinancingSchedule(w, r, propertyID)
+		return
+	}
+
+	// /api/v1/properties/:id/analysis/financing/solve
+	if len(subparts) == 1 && subparts[0] == "solve" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		a.handleSolveFinancingAnalysis(w, r, propertyID)
+		return
+	}
+
 	// /api/v1/properties/:id/analysis/financing/snapshot
 	if len(subparts) == 1 && subparts[0] == "snapshot" {
 		if r.Method != http.MethodPost {
@@ -225,14 +266,17 @@ func (a *api) handleGetFinancing(w http.ResponseWriter, r *http.Request, propert
 	err = a.db.QueryRowContext(
 		r.Context(),
 		`SELECT id, purchase_price, sale_price, down_payment_percent, down_payment_value, financed_value,
-		        term_months, cet, interest_rate, insurance, appraisal_fee, other_fees, remaining_debt
+		        term_months, cet, interest_rate, insurance, appraisal_fee, other_fees, remaining_debt,
+		        amortization_system, correction_index, correction_rate, exit_month, condo_fee, iptu
 		 FROM financing_plans
 		 WHERE property_id = $1`,
 		propertyID,
 	).Scan(&planID, &inputs.PurchasePrice, &inputs.SalePrice, &inputs.DownPaymentPercent,
 		&inputs.DownPaymentValue, &inputs.FinancedValue, &inputs.TermMonths,
 		&inputs.CET, &inputs.InterestRate, &inputs.Insurance, &inputs.AppraisalFee,
-		&inputs.OtherFees, &inputs.RemainingDebt)
+		&inputs.OtherFees, &inputs.RemainingDebt,
+		&inputs.AmortizationSystem, &inputs.CorrectionIndex, &inputs.CorrectionRate, &inputs.ExitMonth,
+		&inputs.CondoFee, &inputs.IPTU)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Return empty response with partial outputs
@@ -256,7 +300,13 @@ func (a *api) handleGetFinancing(w http.ResponseWriter, r *http.Request, propert
 	}
 
 	// Calculate outputs
-	outputs := a.calculateFinancingOutputs(inputs, payments, settings)
+	outputs, schedule := a.calculateFinancingOutputs(inputs, payments, settings)
+
+	cashFlow, err := a.buildFinancingAnalysisCashFlow(r.Context(), propertyID, workspaceID, inputs, payments, outputs, schedule)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to build cash flow"})
+		return
+	}
 
 	writeJSON(w, http.StatusOK, financingAnalysisResponse{
 		PlanID:         planID,
@@ -264,6 +314,7 @@ func (a *api) handleGetFinancing(w http.ResponseWriter, r *http.Request, propert
 		Payments:       payments,
 		Outputs:        outputs,
 		EffectiveRates: financingSettingsToRates(settings),
+		CashFlow:       cashFlow,
 	})
 }
 
@@ -323,6 +374,30 @@ func (a *api) handleUpdateFinancing(w http.ResponseWriter, r *http.Request, prop
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "remaining_debt must be >= 0"})
 		return
 	}
+	if req.AmortizationSystem != nil && !viability.IsValidAmortizationSystem(*req.AmortizationSystem) {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "amortization_system must be sac or price"})
+		return
+	}
+	if req.CorrectionIndex != nil && !viability.IsValidCorrectionIndex(*req.CorrectionIndex) {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "correction_index must be none, tr or ipca"})
+		return
+	}
+	if req.CorrectionRate != nil && (*req.CorrectionRate < 0 || *req.CorrectionRate > 1) {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "correction_rate must be between 0 and 1"})
+		return
+	}
+	if req.ExitMonth != nil && *req.ExitMonth < 0 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "exit_month must be >= 0"})
+		return
+	}
+	if req.CondoFee != nil && *req.CondoFee < 0 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "condo_fee must be >= 0"})
+		return
+	}
+	if req.IPTU != nil && *req.IPTU < 0 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "iptu must be >= 0"})
+		return
+	}
 
 	// Check access and get workspace_id
 	var workspaceID string
@@ -350,8 +425,10 @@ func (a *api) handleUpdateFinancing(w http.ResponseWriter, r *http.Request, prop
 		r.Context(),
 		`INSERT INTO financing_plans (property_id, workspace_id, purchase_price, sale_price, down_payment_percent,
 		                              down_payment_value, financed_value, term_months, cet, interest_rate,
-		                              insurance, appraisal_fee, other_fees, remaining_debt)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		                              insurance, appraisal_fee, other_fees, remaining_debt,
+		                              amortization_system, correction_index, correction_rate, exit_month,
+		                              condo_fee, iptu)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		 ON CONFLICT (property_id)
 		 DO UPDATE SET
 		   purchase_price = COALESCE($3, financing_plans.purchase_price),
@@ -366,16 +443,27 @@ func (a *api) handleUpdateFinancing(w http.ResponseWriter, r *http.Request, prop
 		   appraisal_fee = COALESCE($12, financing_plans.appraisal_fee),
 		   other_fees = COALESCE($13, financing_plans.other_fees),
 		   remaining_debt = COALESCE($14, financing_plans.remaining_debt),
+		   amortization_system = COALESCE($15, financing_plans.amortization_system),
+		   correction_index = COALESCE($16, financing_plans.correction_index),
+		   correction_rate = COALESCE($17, financing_plans.correction_rate),
+		   exit_month = COALESCE($18, financing_plans.exit_month),
+		   condo_fee = COALESCE($19, financing_plans.condo_fee),
+		   iptu = COALESCE($20, financing_plans.iptu),
 		   updated_at = now()
 		 RETURNING id, purchase_price, sale_price, down_payment_percent, down_payment_value, financed_value,
-		           term_months, cet, interest_rate, insurance, appraisal_fee, other_fees, remaining_debt`,
+		           term_months, cet, interest_rate, insurance, appraisal_fee, other_fees, remaining_debt,
+		           amortization_system, correction_index, correction_rate, exit_month, condo_fee, iptu`,
 		propertyID, workspaceID, req.PurchasePrice, req.SalePrice, req.DownPaymentPercent,
 		req.DownPaymentValue, req.FinancedValue, req.TermMonths, req.CET, req.InterestRate,
 		req.Insurance, req.AppraisalFee, req.OtherFees, req.RemainingDebt,
+		req.AmortizationSystem, req.CorrectionIndex, req.CorrectionRate, req.ExitMonth,
+		req.CondoFee, req.IPTU,
 	).Scan(&planID, &inputs.PurchasePrice, &inputs.SalePrice, &inputs.DownPaymentPercent,
 		&inputs.DownPaymentValue, &inputs.FinancedValue, &inputs.TermMonths,
 		&inputs.CET, &inputs.InterestRate, &inputs.Insurance, &inputs.AppraisalFee,
-		&inputs.OtherFees, &inputs.RemainingDebt)
+		&inputs.OtherFees, &inputs.RemainingDebt,
+		&inputs.AmortizationSystem, &inputs.CorrectionIndex, &inputs.CorrectionRate, &inputs.ExitMonth,
+		&inputs.CondoFee, &inputs.IPTU)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to save financing", Details: []string{err.Error()}})
 		return
@@ -396,7 +484,13 @@ func (a *api) handleUpdateFinancing(w http.ResponseWriter, r *http.Request, prop
 	}
 
 	// Calculate outputs
-	outputs := a.calculateFinancingOutputs(inputs, payments, settings)
+	outputs, schedule := a.calculateFinancingOutputs(inputs, payments, settings)
+
+	cashFlow, err := a.buildFinancingAnalysisCashFlow(r.Context(), propertyID, workspaceID, inputs, payments, outputs, schedule)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to build cash flow"})
+		return
+	}
 
 	writeJSON(w, http.StatusOK, financingAnalysisResponse{
 		PlanID:         planID,
@@ -404,6 +498,7 @@ func (a *api) handleUpdateFinancing(w http.ResponseWriter, r *http.Request, prop
 		Payments:       payments,
 		Outputs:        outputs,
 		EffectiveRates: financingSettingsToRates(settings),
+		CashFlow:       cashFlow,
 	})
 }
 
@@ -559,6 +654,86 @@ func (a *api) handleDeleteFinancingPayment(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (a *api) handleGetFinancingSchedule(w http.ResponseWriter, r *http.Request, propertyID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	// Check access and get workspace_id
+	var workspaceID string
+	err := a.db.QueryRowContext(
+		r.Context(),
+		`SELECT p.workspace_id
+		 FROM properties p
+		 JOIN workspace_memberships m ON m.workspace_id = p.workspace_id
+		 WHERE p.id = $1 AND m.user_id = $2`,
+		propertyID, userID,
+	).Scan(&workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "property not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check property"})
+		return
+	}
+
+	// Get financing plan
+	var planID string
+	var inputs financingInputs
+	err = a.db.QueryRowContext(
+		r.Context(),
+		`SELECT id, purchase_price, sale_price, down_payment_percent, down_payment_value, financed_value,
+		        term_months, cet, interest_rate, insurance, appraisal_fee, other_fees, remaining_debt,
+		        amortization_system, correction_index, correction_rate, exit_month, condo_fee, iptu
+		 FROM financing_plans
+		 WHERE property_id = $1`,
+		propertyID,
+	).Scan(&planID, &inputs.PurchasePrice, &inputs.SalePrice, &inputs.DownPaymentPercent,
+		&inputs.DownPaymentValue, &inputs.FinancedValue, &inputs.TermMonths,
+		&inputs.CET, &inputs.InterestRate, &inputs.Insurance, &inputs.AppraisalFee,
+		&inputs.OtherFees, &inputs.RemainingDebt,
+		&inputs.AmortizationSystem, &inputs.CorrectionIndex, &inputs.CorrectionRate, &inputs.ExitMonth,
+		&inputs.CondoFee, &inputs.IPTU)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "financing plan not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch financing"})
+		return
+	}
+
+	payments, err := a.getFinancingPayments(r.Context(), planID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch payments"})
+		return
+	}
+
+	settings, err := a.getEffectiveFinancingSettings(r.Context(), propertyID, workspaceID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch settings"})
+		return
+	}
+
+	outputs, schedule := a.calculateFinancingOutputs(inputs, payments, settings)
+	if schedule == nil {
+		writeError(w, http.StatusBadRequest, apiError{
+			Code:    "SCHEDULE_UNAVAILABLE",
+			Message: "amortization_system, term_months, interest_rate (or cet), purchase_price and sale_price are required",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, financingScheduleResponse{
+		PlanID:    planID,
+		ExitMonth: outputs.ExitMonth,
+		Schedule:  schedule,
+	})
+}
+
 func (a *api) handleCreateFinancingSnapshot(w http.ResponseWriter, r *http.Request, propertyID string) {
 	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
@@ -597,14 +772,17 @@ func (a *api) handleCreateFinancingSnapshot(w http.ResponseWriter, r *http.Reque
 	err = a.db.QueryRowContext(
 		r.Context(),
 		`SELECT id, purchase_price, sale_price, down_payment_percent, down_payment_value, financed_value,
-		        term_months, cet, interest_rate, insurance, appraisal_fee, other_fees, remaining_debt
+		        term_months, cet, interest_rate, insurance, appraisal_fee, other_fees, remaining_debt,
+		        amortization_system, correction_index, correction_rate, exit_month, condo_fee, iptu
 		 FROM financing_plans
 		 WHERE property_id = $1`,
 		propertyID,
 	).Scan(&planID, &inputs.PurchasePrice, &inputs.SalePrice, &inputs.DownPaymentPercent,
 		&inputs.DownPaymentValue, &inputs.FinancedValue, &inputs.TermMonths,
 		&inputs.CET, &inputs.InterestRate, &inputs.Insurance, &inputs.AppraisalFee,
-		&inputs.OtherFees, &inputs.RemainingDebt)
+		&inputs.OtherFees, &inputs.RemainingDebt,
+		&inputs.AmortizationSystem, &inputs.CorrectionIndex, &inputs.CorrectionRate, &inputs.ExitMonth,
+		&inputs.CondoFee, &inputs.IPTU)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			writeError(w, http.StatusBadRequest, apiError{Code: "NO_ANALYSIS", Message: "no financing to snapshot"})
@@ -629,22 +807,36 @@ func (a *api) handleCreateFinancingSnapshot(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Calculate outputs
-	outputs := a.calculateFinancingOutputs(inputs, payments, settings)
+	outputs, schedule := a.calculateFinancingOutputs(inputs, payments, settings)
+
+	cashFlow, err := a.buildFinancingAnalysisCashFlow(r.Context(), propertyID, workspaceID, inputs, payments, outputs, schedule)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to build cash flow"})
+		return
+	}
 
 	// Create snapshot
 	inputsJSON, _ := json.Marshal(inputs)
 	paymentsJSON, _ := json.Marshal(payments)
 	outputsJSON, _ := json.Marshal(outputs)
 	ratesJSON, _ := json.Marshal(financingSettingsToRates(settings))
+	var scheduleJSON []byte
+	if schedule != nil {
+		scheduleJSON, _ = json.Marshal(schedule)
+	}
+	var cashFlowJSON []byte
+	if cashFlow != nil {
+		cashFlowJSON, _ = json.Marshal(cashFlow)
+	}
 
 	var snapshotID string
 	var createdAt time.Time
 	err = a.db.QueryRowContext(
 		r.Context(),
-		`INSERT INTO analysis_financing_snapshots (property_id, workspace_id, inputs_json, payments_json, outputs_json, effective_rates, status_pipeline)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`INSERT INTO analysis_financing_snapshots (property_id, workspace_id, inputs_json, payments_json, outputs_json, effective_rates, status_pipeline, schedule_json, cash_flow_json)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		 RETURNING id, created_at`,
-		propertyID, workspaceID, inputsJSON, paymentsJSON, outputsJSON, ratesJSON, statusPipeline,
+		propertyID, workspaceID, inputsJSON, paymentsJSON, outputsJSON, ratesJSON, statusPipeline, scheduleJSON, cashFlowJSON,
 	).Scan(&snapshotID, &createdAt)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create snapshot"})
@@ -706,7 +898,7 @@ func (a *api) handleListFinancingSnapshots(w http.ResponseWriter, r *http.Reques
 
 	rows, err := a.db.QueryContext(
 		r.Context(),
-		`SELECT id, inputs_json, payments_json, outputs_json, effective_rates, status_pipeline, created_at
+		`SELECT id, inputs_json, payments_json, outputs_json, effective_rates, status_pipeline, schedule_json, cash_flow_json, created_at
 		 FROM analysis_financing_snapshots
 		 WHERE property_id = $1
 		 ORDER BY created_at DESC
@@ -722,8 +914,8 @@ func (a *api) handleListFinancingSnapshots(w http.ResponseWriter, r *http.Reques
 	items := make([]financingSnapshot, 0)
 	for rows.Next() {
 		var s financingSnapshot
-		var rates, status sql.NullString
-		err := rows.Scan(&s.ID, &s.InputsJSON, &s.PaymentsJSON, &s.OutputsJSON, &rates, &status, &s.CreatedAt)
+		var rates, status, schedule, cashFlow sql.NullString
+		err := rows.Scan(&s.ID, &s.InputsJSON, &s.PaymentsJSON, &s.OutputsJSON, &rates, &status, &schedule, &cashFlow, &s.CreatedAt)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to scan snapshot"})
 			return
@@ -734,6 +926,12 @@ func (a *api) handleListFinancingSnapshots(w http.ResponseWriter, r *http.Reques
 		if status.Valid {
 			s.StatusPipeline = &status.String
 		}
+		if schedule.Valid {
+			s.ScheduleJSON = json.RawMessage(schedule.String)
+		}
+		if cashFlow.Valid {
+			s.CashFlowJSON = json.RawMessage(cashFlow.String)
+		}
 		items = append(items, s)
 	}
 
@@ -814,20 +1012,54 @@ func (a *api) getFinancingPayments(ctx context.Context, planID string) ([]financ
 	return payments, nil
 }
 
+// getFinancingPlanInputs returns the financing plan ID and inputs of a property (sql.ErrNoRows when there is no plan)
+func (a *api) getFinancingPlanInputs(ctx context.Context, propertyID string) (string, financingInputs, error) {
+	var planID string
+	var inputs financingInputs
+	err := a.db.QueryRowContext(
+		ctx,
+		`SELECT id, purchase_price, sale_price, down_payment_percent, down_payment_value, financed_value,
+		        term_months, cet, interest_rate, insurance, appraisal_fee, other_fees, remaining_debt,
+		        amortization_system, correction_index, correction_rate, exit_month, condo_fee, iptu
+		 FROM financing_plans
+		 WHERE property_id = $1`,
+		propertyID,
+	).Scan(&planID, &inputs.PurchasePrice, &inputs.SalePrice, &inputs.DownPaymentPercent,
+		&inputs.DownPaymentValue, &inputs.FinancedValue, &inputs.TermMonths,
+		&inputs.CET, &inputs.InterestRate, &inputs.Insurance, &inputs.AppraisalFee,
+		&inputs.OtherFees, &inputs.RemainingDebt,
+		&inputs.AmortizationSystem, &inputs.CorrectionIndex, &inputs.CorrectionRate, &inputs.ExitMonth,
+		&inputs.CondoFee, &inputs.IPTU)
+	return planID, inputs, err
+}
+
 func (a *api) getWorkspaceFinancingSettings(ctx context.Context, workspaceID string) (viability.FinancingSettings, error) {
 	var s viability.FinancingSettings
+	var taxRegime string
+	var simplesRate, retRate, reinvestedPct sql.NullFloat64
 	err := a.db.QueryRowContext(
 		ctx,
-		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate
+		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate,
+		        tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct
 		 FROM workspace_settings
 		 WHERE workspace_id = $1`,
 		workspaceID,
-	).Scan(&s.ITBIRate, &s.RegistryRate, &s.BrokerRate, &s.PJTaxRate)
+	).Scan(&s.ITBIRate, &s.RegistryRate, &s.BrokerRate, &s.PJTaxRate,
+		&taxRegime, &simplesRate, &retRate, &reinvestedPct)
+	s.Tax = taxSettingsFromColumns(taxRegime, simplesRate, retRate, reinvestedPct)
 	return s, err
 }
 
-func (a *api) calculateFinancingOutputs(inputs financingInputs, payments []financingPayment, settings viability.FinancingSettings) financingOutputs {
-	viabilityInputs := viability.FinancingInputs{
+// calculateFinancingOutputs runs the viability engine and returns the API outputs
+// along with the derived amortization schedule (nil when the terms are incomplete)
+func (a *api) calculateFinancingOutputs(inputs financingInputs, payments []financingPayment, settings viability.FinancingSettings) (financingOutputs, *viability.AmortizationSchedule) {
+	result := viability.CalculateFinancing(inputs.toViability(), toViabilityPayments(payments), settings)
+
+	return toFinancingOutputs(result), result.Schedule
+}
+
+func (inputs financingInputs) toViability() viability.FinancingInputs {
+	return viability.FinancingInputs{
 		PurchasePrice:      inputs.PurchasePrice,
 		SalePrice:          inputs.SalePrice,
 		DownPaymentPercent: inputs.DownPaymentPercent,
@@ -840,8 +1072,14 @@ func (a *api) calculateFinancingOutputs(inputs financingInputs, payments []finan
 		AppraisalFee:       inputs.AppraisalFee,
 		OtherFees:          inputs.OtherFees,
 		RemainingDebt:      inputs.RemainingDebt,
+		AmortizationSystem: inputs.AmortizationSystem,
+		CorrectionIndex:    inputs.CorrectionIndex,
+		CorrectionRate:     inputs.CorrectionRate,
+		ExitMonth:          inputs.ExitMonth,
 	}
+}
 
+func toViabilityPayments(payments []financingPayment) []viability.FinancingPayment {
 	viabilityPayments := make([]viability.FinancingPayment, len(payments))
 	for i, p := range payments {
 		viabilityPayments[i] = viability.FinancingPayment{
@@ -849,9 +1087,10 @@ func (a *api) calculateFinancingOutputs(inputs financingInputs, payments []finan
 			Amount:     p.Amount,
 		}
 	}
+	return viabilityPayments
+}
 
-	result := viability.CalculateFinancing(viabilityInputs, viabilityPayments, settings)
-
+func toFinancingOutputs(result viability.FinancingOutputs) financingOutputs {
 	return financingOutputs{
 		DownPaymentValue:     result.DownPaymentValue,
 		FinancedValue:        result.FinancedValue,
@@ -868,6 +1107,9 @@ func (a *api) calculateFinancingOutputs(inputs financingInputs, payments []finan
 		NetProfit:            result.NetProfit,
 		ROI:                  result.ROI,
 		InterestPaidEstimate: result.InterestPaidEstimate,
+		RemainingDebt:        result.RemainingDebt,
+		ExitMonth:            result.ExitMonth,
 		IsPartial:            result.IsPartial,
+		TaxBreakdown:         result.TaxBreakdown,
 	}
 }
@@ -2,19 +2,30 @@ package httpapi
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/widia-projects/widia-flip/services/api/internal/auth"
 	"github.com/widia-projects/widia-flip/services/api/internal/flipscore"
+	"github.com/widia-projects/widia-flip/services/api/internal/marketingest"
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
 )
 
 const (
 	flipScoreRateLimitMinutes = 15
+
+	// Flip Score v2 market lookup: 12-month ITBI window, and the minimum transactions
+	// for the property-class aggregate before falling back to "geral"
+	flipScoreMarketPeriodMonths = 12
+	flipScoreMarketMinClassTx   = 15
 )
 
 type recomputeFlipScoreResponse struct {
@@ -40,7 +51,7 @@ func (a *api) handleFlipScoreRecompute(w http.ResponseWriter, r *http.Request, p
 
 	// Parse query params
 	force := r.URL.Query().Get("force") == "true"
-	forceVersion := r.URL.Query().Get("version") // "v0" to force v0 calculation
+	forceVersion := r.URL.Query().Get("version") // "v0" or "v2" to skip the v1 auto-detection
 
 	log.Printf("flip_score_recompute_start request_id=%s prospect_id=%s user_id=%s force=%v version=%s", reqID, prospectID, userID, force, forceVersion)
 
@@ -71,79 +82,25 @@ func (a *api) handleFlipScoreRecompute(w http.ResponseWriter, r *http.Request, p
 		}
 	}
 
-	// Get cohort stats for S_price calculation
-	cohort := a.getCohortStats(r.Context(), prospect)
-	log.Printf("flip_score_cohort request_id=%s prospect_id=%s cohort_scope=%s cohort_n=%d",
-		reqID, prospectID, cohort.Scope, cohort.N)
-
-	// Try LLM risk assessment (with fallback)
-	var riskAssessment *flipscore.FlipRiskAssessment
-	if a.llmClient != nil && prospect.ListingText != nil && *prospect.ListingText != "" {
-		startTime := time.Now()
-		log.Printf("llm_request_start request_id=%s prospect_id=%s input_len=%d",
-			reqID, prospectID, len(*prospect.ListingText))
-
-		assessment, err := a.llmClient.ExtractRiskAssessment(r.Context(), *prospect.ListingText)
-		latency := time.Since(startTime).Milliseconds()
-
-		if err != nil {
-			log.Printf("llm_request_error request_id=%s prospect_id=%s error=%v latency_ms=%d",
-				reqID, prospectID, err, latency)
-			// Continue without risk assessment (fallback)
-		} else {
-			log.Printf("llm_request_success request_id=%s prospect_id=%s latency_ms=%d llm_confidence=%.2f",
-				reqID, prospectID, latency, assessment.LLMConfidence)
-			riskAssessment = assessment
-		}
+	// Workspace scoring profile (weights and red-flag penalties)
+	profile, err := a.getWorkspaceScoringProfile(r.Context(), prospect.WorkspaceID)
+	if err != nil {
+		log.Printf("flip_score_profile_error request_id=%s prospect_id=%s error=%v", reqID, prospectID, err)
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch flip score profile"})
+		return
 	}
 
-	// Build v0 inputs
-	inputsV0 := flipscore.ProspectInputs{
-		AskingPrice:  prospect.AskingPrice,
-		AreaUsable:   prospect.AreaUsable,
-		CondoFee:     prospect.CondoFee,
-		IPTU:         prospect.IPTU,
-		Bedrooms:     prospect.Bedrooms,
-		Parking:      prospect.Parking,
-		Elevator:     prospect.Elevator,
-		Neighborhood: prospect.Neighborhood,
-	}
+	// Try LLM risk assessment (with fallback)
+	riskAssessment, _ := a.extractListingRisk(r.Context(), reqID, prospect)
 
-	// Build v1 inputs (extends v0)
-	inputsV1 := flipscore.ProspectInputsV1{
-		ProspectInputs:         inputsV0,
-		OfferPrice:             prospect.OfferPrice,
-		ExpectedSalePrice:      prospect.ExpectedSalePrice,
-		RenovationCostEstimate: prospect.RenovationCostEstimate,
-		HoldMonths:             prospect.HoldMonths,
-		OtherCostsEstimate:     prospect.OtherCostsEstimate,
-	}
+	// v1 when its inputs are available, unless the user explicitly requested v0 or v2
+	useV1 := forceVersion != flipscore.VersionV0 && forceVersion != flipscore.VersionV2 &&
+		flipscore.CanCalculateV1(flipScoreInputsV1(prospect))
 
 	// Check tier restriction for v1 (M12 enforcement)
-	// Skip if user explicitly requested v0
-	if forceVersion != "v0" && flipscore.CanCalculateV1(inputsV1) {
-		var ownerUserID string
-		err := a.db.QueryRowContext(r.Context(),
-			`SELECT created_by_user_id FROM workspaces WHERE id = $1`,
-			prospect.WorkspaceID,
-		).Scan(&ownerUserID)
+	if useV1 {
+		tier, err := a.workspaceOwnerTier(r.Context(), prospect.WorkspaceID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to get workspace owner"})
-			return
-		}
-
-		billing, err := a.getUserBilling(r.Context(), ownerUserID)
-		tier := "free"
-		if err == nil {
-			tier = billing.Tier
-		} else if err == sql.ErrNoRows {
-			billing, err = a.createDefaultBilling(r.Context(), ownerUserID)
-			if err != nil {
-				writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to initialize billing"})
-				return
-			}
-			tier = billing.Tier
-		} else {
 			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check billing"})
 			return
 		}
@@ -167,8 +124,63 @@ func (a *api) handleFlipScoreRecompute(w http.ResponseWriter, r *http.Request, p
 		}
 	}
 
-	// Auto-detect version: use v1 if inputs are sufficient, else v0
-	// Respect forceVersion param to allow users to explicitly request v0
+	run := flipScoreRun{
+		RequestID:    reqID,
+		ForceVersion: forceVersion,
+		UseV1:        useV1,
+		Profile:      profile,
+		Risk:         riskAssessment,
+	}
+	if useV1 {
+		// Get workspace settings for v1 calculation
+		run.Settings, err = a.getWorkspaceCashSettings(r.Context(), prospect.WorkspaceID)
+		if err != nil {
+			log.Printf("flip_score_settings_error request_id=%s prospect_id=%s error=%v", reqID, prospectID, err)
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch workspace settings"})
+			return
+		}
+	}
+
+	scored, err := a.scoreProspect(r.Context(), prospect, run)
+	if err != nil {
+		log.Printf("flip_score_persist_error request_id=%s prospect_id=%s error=%v", reqID, prospectID, err)
+		writeError(w, http.StatusInternalServerError, apiError{Code: "SCORE_CALC_FAILED", Message: "failed to persist score"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, recomputeFlipScoreResponse{Prospect: scored})
+}
+
+// flipScoreRun carries everything a score computation needs besides the prospect itself
+type flipScoreRun struct {
+	RequestID    string
+	ForceVersion string // "v0" or "v2" to skip the v1 auto-detection
+	UseV1        bool   // v1 inputs are available and the workspace tier allows it
+	Settings     viability.CashSettings
+	Profile      flipscore.ScoringProfile
+	Risk         *flipscore.FlipRiskAssessment
+}
+
+// scoreProspect calculates the prospect's Flip Score (v1, v2 or an explicitly requested v0)
+// and persists it together with the listing hash its risk assessment was extracted from
+func (a *api) scoreProspect(ctx context.Context, prospect *prospect, run flipScoreRun) (flipScoreProspect, error) {
+	reqID, prospectID := run.RequestID, prospect.ID
+
+	// Get cohort stats for S_price calculation
+	cohort := a.getCohortStats(ctx, prospect)
+	log.Printf("flip_score_cohort request_id=%s prospect_id=%s cohort_scope=%s cohort_n=%d",
+		reqID, prospectID, cohort.Scope, cohort.N)
+
+	// The stored assessment can be reused as long as listing_text does not change
+	var listingHash *string
+	if run.Risk != nil && prospect.ListingText != nil {
+		h := listingTextHash(*prospect.ListingText)
+		listingHash = &h
+	}
+
+	inputsV1 := flipScoreInputsV1(prospect)
+	inputsV0 := inputsV1.ProspectInputs
+
 	var (
 		finalScore      int
 		scoreVersion    string
@@ -177,23 +189,13 @@ func (a *api) handleFlipScoreRecompute(w http.ResponseWriter, r *http.Request, p
 		breakdownBytes  []byte
 	)
 
-	if forceVersion != "v0" && flipscore.CanCalculateV1(inputsV1) {
-		// Get workspace settings for v1 calculation
-		settings, err := a.getWorkspaceCashSettings(r.Context(), prospect.WorkspaceID)
-		if err != nil {
-			log.Printf("flip_score_settings_error request_id=%s prospect_id=%s error=%v", reqID, prospectID, err)
-			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch workspace settings"})
-			return
-		}
-
-		// Calculate v1 score
-		resultV1 := flipscore.CalculateV1(inputsV1, riskAssessment, cohort, settings)
+	// Auto-detect version: use v1 if inputs are sufficient, else v2 (market-based price)
+	// Respect ForceVersion to allow users to explicitly request v0
+	if run.UseV1 {
+		resultV1 := flipscore.CalculateV1(inputsV1, run.Risk, cohort, run.Settings, run.Profile)
 
-		// Persist v1 to DB
-		if err := a.persistFlipScoreV1(r.Context(), prospectID, resultV1); err != nil {
-			log.Printf("flip_score_persist_error request_id=%s prospect_id=%s error=%v", reqID, prospectID, err)
-			writeError(w, http.StatusInternalServerError, apiError{Code: "SCORE_CALC_FAILED", Message: "failed to persist score"})
-			return
+		if err := a.persistFlipScoreV1(ctx, prospectID, resultV1, listingHash); err != nil {
+			return flipScoreProspect{}, err
 		}
 
 		finalScore = resultV1.Score
@@ -205,15 +207,34 @@ func (a *api) handleFlipScoreRecompute(w http.ResponseWriter, r *http.Request, p
 		log.Printf("flip_score_recompute_done request_id=%s prospect_id=%s score=%d version=%s confidence=%.2f roi=%.2f",
 			reqID, prospectID, resultV1.Score, resultV1.Version, resultV1.Confidence,
 			resultV1.Breakdown.Economics.ROI)
+	} else if run.ForceVersion != flipscore.VersionV0 {
+		// Market aggregates for S_price; v2 falls back to the cohort when there is no market data
+		market := a.getMarketStats(ctx, prospect)
+		if market != nil {
+			log.Printf("flip_score_market request_id=%s prospect_id=%s region=%s class=%s tx_count=%d match_confidence=%.2f",
+				reqID, prospectID, market.Region, market.PropertyClass, market.TxCount, market.MatchConfidence)
+		}
+
+		resultV2 := flipscore.CalculateV2(inputsV0, run.Risk, market, cohort, run.Profile)
+
+		if err := a.persistFlipScoreV2(ctx, prospectID, resultV2, listingHash); err != nil {
+			return flipScoreProspect{}, err
+		}
+
+		finalScore = resultV2.Score
+		scoreVersion = resultV2.Version
+		scoreConfidence = resultV2.Confidence
+		computedAt = resultV2.ComputedAt
+		breakdownBytes, _ = json.Marshal(resultV2.Breakdown)
+
+		log.Printf("flip_score_recompute_done request_id=%s prospect_id=%s score=%d version=%s confidence=%.2f price_source=%s",
+			reqID, prospectID, resultV2.Score, resultV2.Version, resultV2.Confidence, resultV2.Breakdown.PriceSource)
 	} else {
-		// Calculate v0 score (fallback)
-		resultV0 := flipscore.Calculate(inputsV0, riskAssessment, cohort)
+		// Calculate v0 score (explicitly requested)
+		resultV0 := flipscore.Calculate(inputsV0, run.Risk, cohort, run.Profile)
 
-		// Persist v0 to DB
-		if err := a.persistFlipScore(r.Context(), prospectID, resultV0); err != nil {
-			log.Printf("flip_score_persist_error request_id=%s prospect_id=%s error=%v", reqID, prospectID, err)
-			writeError(w, http.StatusInternalServerError, apiError{Code: "SCORE_CALC_FAILED", Message: "failed to persist score"})
-			return
+		if err := a.persistFlipScore(ctx, prospectID, resultV0, listingHash); err != nil {
+			return flipScoreProspect{}, err
 		}
 
 		finalScore = resultV0.Score
@@ -226,54 +247,163 @@ func (a *api) handleFlipScoreRecompute(w http.ResponseWriter, r *http.Request, p
 			reqID, prospectID, resultV0.Score, resultV0.Version, resultV0.Confidence)
 	}
 
-	// Build response
 	breakdownRaw := json.RawMessage(breakdownBytes)
+	return flipScoreProspect{
+		ID:                  prospectID,
+		FlipScore:           &finalScore,
+		FlipScoreVersion:    &scoreVersion,
+		FlipScoreConfidence: &scoreConfidence,
+		FlipScoreBreakdown:  &breakdownRaw,
+		FlipScoreUpdatedAt:  &computedAt,
+	}, nil
+}
 
-	writeJSON(w, http.StatusOK, recomputeFlipScoreResponse{
-		Prospect: flipScoreProspect{
-			ID:                  prospectID,
-			FlipScore:           &finalScore,
-			FlipScoreVersion:    &scoreVersion,
-			FlipScoreConfidence: &scoreConfidence,
-			FlipScoreBreakdown:  &breakdownRaw,
-			FlipScoreUpdatedAt:  &computedAt,
+// flipScoreInputsV1 builds the v1 inputs (which embed the v0 inputs) from a prospect
+func flipScoreInputsV1(p *prospect) flipscore.ProspectInputsV1 {
+	return flipscore.ProspectInputsV1{
+		ProspectInputs: flipscore.ProspectInputs{
+			AskingPrice:  p.AskingPrice,
+			AreaUsable:   p.AreaUsable,
+			CondoFee:     p.CondoFee,
+			IPTU:         p.IPTU,
+			Bedrooms:     p.Bedrooms,
+			Parking:      p.Parking,
+			Elevator:     p.Elevator,
+			Neighborhood: p.Neighborhood,
 		},
-	})
+		OfferPrice:             p.OfferPrice,
+		ExpectedSalePrice:      p.ExpectedSalePrice,
+		RenovationCostEstimate: p.RenovationCostEstimate,
+		HoldMonths:             p.HoldMonths,
+		OtherCostsEstimate:     p.OtherCostsEstimate,
+		Auction:                p.AuctionTerms,
+	}
 }
 
-// getProspectWithFlipScore fetches a prospect including flip_score fields and v1 inputs with access check
-func (a *api) getProspectWithFlipScore(ctx context.Context, prospectID, userID string) (*prospect, error) {
-	var p prospect
-	var tags []byte
-	var flipScoreBreakdown []byte
+// extractListingRisk asks the LLM for the listing's risk assessment. Returns nil (no error)
+// when there is no LLM client or listing text; callers fall back to scoring without it.
+func (a *api) extractListingRisk(ctx context.Context, reqID string, p *prospect) (*flipscore.FlipRiskAssessment, error) {
+	if a.llmClient == nil || p.ListingText == nil || *p.ListingText == "" {
+		return nil, nil
+	}
 
-	err := a.db.QueryRowContext(
-		ctx,
-		`SELECT p.id, p.workspace_id, p.status, p.link, p.neighborhood, p.address,
+	startTime := time.Now()
+	log.Printf("llm_request_start request_id=%s prospect_id=%s input_len=%d",
+		reqID, p.ID, len(*p.ListingText))
+
+	assessment, err := a.llmClient.ExtractRiskAssessment(ctx, *p.ListingText)
+	latency := time.Since(startTime).Milliseconds()
+
+	if err != nil {
+		log.Printf("llm_request_error request_id=%s prospect_id=%s error=%v latency_ms=%d",
+			reqID, p.ID, err, latency)
+		return nil, err
+	}
+	log.Printf("llm_request_success request_id=%s prospect_id=%s latency_ms=%d llm_confidence=%.2f",
+		reqID, p.ID, latency, assessment.LLMConfidence)
+	return assessment, nil
+}
+
+// cachedRiskAssessment returns the risk assessment stored in the prospect's breakdown when it
+// was extracted from the current listing_text, or nil when it has to be extracted again
+func cachedRiskAssessment(p *prospect) *flipscore.FlipRiskAssessment {
+	if p.ListingText == nil || p.FlipScoreListingHash == nil || p.FlipScoreBreakdown == nil {
+		return nil
+	}
+	if *p.FlipScoreListingHash != listingTextHash(*p.ListingText) {
+		return nil
+	}
+
+	var breakdown struct {
+		RiskAssessment *flipscore.FlipRiskAssessment `json:"risk_assessment"`
+	}
+	if err := json.Unmarshal(*p.FlipScoreBreakdown, &breakdown); err != nil {
+		return nil
+	}
+	return breakdown.RiskAssessment
+}
+
+func listingTextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// workspaceOwnerTier returns the billing tier of the workspace owner (creating the default
+// billing row on first use)
+func (a *api) workspaceOwnerTier(ctx context.Context, workspaceID string) (string, error) {
+	var ownerUserID string
+	err := a.db.QueryRowContext(ctx,
+		`SELECT created_by_user_id FROM workspaces WHERE id = $1`,
+		workspaceID,
+	).Scan(&ownerUserID)
+	if err != nil {
+		return "", err
+	}
+
+	billing, err := a.getUserBilling(ctx, ownerUserID)
+	if err == sql.ErrNoRows {
+		billing, err = a.createDefaultBilling(ctx, ownerUserID)
+	}
+	if err != nil {
+		return "", err
+	}
+	return billing.Tier, nil
+}
+
+const prospectWithFlipScoreColumns = `p.id, p.workspace_id, p.status, p.link, p.neighborhood, p.address,
 		        p.area_usable, p.bedrooms, p.suites, p.bathrooms, p.gas, p.floor, p.elevator, p.face, p.parking,
 		        p.condo_fee, p.iptu, p.asking_price, p.agency, p.broker_name, p.broker_phone,
 		        p.comments, p.tags, p.created_at, p.updated_at,
 		        p.listing_text, p.flip_score, p.flip_score_version, p.flip_score_confidence,
-		        p.flip_score_breakdown, p.flip_score_updated_at,
-		        p.offer_price, p.expected_sale_price, p.renovation_cost_estimate, p.hold_months, p.other_costs_estimate
+		        p.flip_score_breakdown, p.flip_score_updated_at, p.flip_score_listing_hash,
+		        p.offer_price, p.expected_sale_price, p.renovation_cost_estimate, p.hold_months, p.other_costs_estimate,
+		        p.acquisition_mode, p.auction_terms_json`
+
+// getProspectWithFlipScore fetches a prospect including flip_score fields and v1 inputs with access check
+func (a *api) getProspectWithFlipScore(ctx context.Context, prospectID, userID string) (*prospect, error) {
+	return scanProspectWithFlipScore(a.db.QueryRowContext(
+		ctx,
+		`SELECT `+prospectWithFlipScoreColumns+`
 		 FROM prospecting_properties p
 		 JOIN workspace_memberships m ON m.workspace_id = p.workspace_id
 		 WHERE p.id = $1 AND m.user_id = $2 AND p.deleted_at IS NULL`,
 		prospectID, userID,
-	).Scan(
+	))
+}
+
+// getWorkspaceProspectWithFlipScore fetches a prospect of the workspace without a user access
+// check (background jobs already checked membership when they were created)
+func (a *api) getWorkspaceProspectWithFlipScore(ctx context.Context, workspaceID, prospectID string) (*prospect, error) {
+	return scanProspectWithFlipScore(a.db.QueryRowContext(
+		ctx,
+		`SELECT `+prospectWithFlipScoreColumns+`
+		 FROM prospecting_properties p
+		 WHERE p.id = $1 AND p.workspace_id = $2 AND p.deleted_at IS NULL`,
+		prospectID, workspaceID,
+	))
+}
+
+func scanProspectWithFlipScore(row *sql.Row) (*prospect, error) {
+	var p prospect
+	var tags, auctionTerms []byte
+	var flipScoreBreakdown []byte
+
+	err := row.Scan(
 		&p.ID, &p.WorkspaceID, &p.Status, &p.Link, &p.Neighborhood, &p.Address,
 		&p.AreaUsable, &p.Bedrooms, &p.Suites, &p.Bathrooms, &p.Gas, &p.Floor, &p.Elevator, &p.Face, &p.Parking,
 		&p.CondoFee, &p.IPTU, &p.AskingPrice, &p.Agency, &p.BrokerName, &p.BrokerPhone,
 		&p.Comments, &tags, &p.CreatedAt, &p.UpdatedAt,
 		&p.ListingText, &p.FlipScore, &p.FlipScoreVersion, &p.FlipScoreConfidence,
-		&flipScoreBreakdown, &p.FlipScoreUpdatedAt,
+		&flipScoreBreakdown, &p.FlipScoreUpdatedAt, &p.FlipScoreListingHash,
 		&p.OfferPrice, &p.ExpectedSalePrice, &p.RenovationCostEstimate, &p.HoldMonths, &p.OtherCostsEstimate,
+		&p.AcquisitionMode, &auctionTerms,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	p.Tags = parseTags(tags)
+	p.AuctionTerms = decodeAuctionTerms(p.AcquisitionMode, auctionTerms)
 	p.PricePerSqm = computePricePerSqm(p.AskingPrice, p.AreaUsable)
 
 	if len(flipScoreBreakdown) > 0 {
@@ -363,8 +493,158 @@ func (a *api) getCohortStats(ctx context.Context, p *prospect) flipscore.CohortS
 	return stats
 }
 
+// getMarketStats resolves the prospect's neighborhood to a market region and returns the latest
+// ITBI price/m² aggregates for its property class. Returns nil when the neighborhood cannot be
+// resolved or there is no market data for it.
+func (a *api) getMarketStats(ctx context.Context, p *prospect) *flipscore.MarketStats {
+	if p.Neighborhood == nil || strings.TrimSpace(*p.Neighborhood) == "" {
+		return nil
+	}
+	city := a.prospectMarketCity(ctx, p.WorkspaceID, p.Address)
+	_, stats, err := a.lookupMarketStats(ctx, city, *p.Neighborhood, prospectMarketClass(p))
+	if err != nil {
+		log.Printf("flip_score_market_query_error prospect_id=%s error=%v", p.ID, err)
+		return nil
+	}
+	return stats
+}
+
+// prospectMarketCity returns the city whose market data applies to a prospect: the city its
+// address ends in, else the workspace market city (São Paulo when it cannot be read)
+func (a *api) prospectMarketCity(ctx context.Context, workspaceID string, address *string) string {
+	if address != nil {
+		if city := marketingest.CityFromAddress(*address); city != "" {
+			return city
+		}
+	}
+
+	var city string
+	err := a.db.QueryRowContext(ctx,
+		`SELECT market_city FROM workspace_settings WHERE workspace_id = $1`,
+		workspaceID,
+	).Scan(&city)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("market_city_query_error workspace_id=%s error=%v", workspaceID, err)
+		}
+		return marketingest.DefaultCity
+	}
+	return city
+}
+
+// lookupMarketStats resolves a free-text neighborhood of the city through the approved
+// aliases and the golden dictionary and aggregates the latest ITBI price/m² for the property
+// class (falling back to "geral" when the class sample is thin). The match is returned even
+// when there is no market data; stats is nil then.
+func (a *api) lookupMarketStats(ctx context.Context, city, neighborhood, propertyClass string) (marketingest.NeighborhoodMatch, *flipscore.MarketStats, error) {
+	aliases, err := marketingest.LoadApprovedAliases(ctx, a.db, city)
+	if err != nil {
+		log.Printf("market_aliases_error neighborhood=%q error=%v", neighborhood, err)
+		aliases = nil
+	}
+	match := marketingest.MatchNeighborhood(city, neighborhood, aliases)
+	if match.Canonical == "" {
+		return match, nil, nil
+	}
+
+	candidates := marketRegionCandidates(canonicalizeMarketRegion(match.Canonical, neighborhood))
+	if len(candidates) == 0 {
+		return match, nil, nil
+	}
+
+	queryArgs := []any{city, flipScoreMarketPeriodMonths, propertyClass}
+	placeholders := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		queryArgs = append(queryArgs, candidate)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(queryArgs)))
+	}
+
+	rows, err := a.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			TO_CHAR(a.as_of_month, 'YYYY-MM') AS as_of_month,
+			a.property_class,
+			a.median_m2,
+			a.p25_m2,
+			a.p75_m2,
+			a.tx_count
+		FROM market_price_m2_aggregates a
+		JOIN market_regions r ON r.id = a.region_id
+		WHERE a.city = $1
+		  AND a.period_months = $2
+		  AND a.property_class IN ($3, 'geral')
+		  AND r.name_normalized IN (%s)
+		  AND a.as_of_month = (
+		    SELECT MAX(as_of_month) FROM market_price_m2_aggregates WHERE city = $1
+		  )
+	`, strings.Join(placeholders, ",")), queryArgs...)
+	if err != nil {
+		return match, nil, err
+	}
+	defer rows.Close()
+
+	// Consolidate per class (several sources or spellings may map to the same bairro)
+	byClass := make(map[string]*flipscore.MarketStats, 2)
+	for rows.Next() {
+		var asOfMonth, class string
+		var medianM2, p25M2, p75M2 float64
+		var txCount int
+		if err := rows.Scan(&asOfMonth, &class, &medianM2, &p25M2, &p75M2, &txCount); err != nil {
+			return match, nil, err
+		}
+		stats, ok := byClass[class]
+		if !ok {
+			stats = &flipscore.MarketStats{
+				Region:          humanizeRegionName(match.Canonical, neighborhood),
+				PropertyClass:   class,
+				AsOfMonth:       asOfMonth,
+				PeriodMonths:    flipScoreMarketPeriodMonths,
+				MatchConfidence: match.Confidence,
+				MatchMethod:     match.Method,
+			}
+			byClass[class] = stats
+		}
+		weight := float64(txCount)
+		stats.MedianM2 += medianM2 * weight
+		stats.P25M2 += p25M2 * weight
+		stats.P75M2 += p75M2 * weight
+		stats.TxCount += txCount
+	}
+	if err := rows.Err(); err != nil {
+		return match, nil, err
+	}
+
+	stats := byClass[propertyClass]
+	if stats == nil || stats.TxCount < flipScoreMarketMinClassTx {
+		if general := byClass["geral"]; general != nil {
+			stats = general
+		}
+	}
+	if stats == nil || stats.TxCount <= 0 {
+		return match, nil, nil
+	}
+
+	tx := float64(stats.TxCount)
+	stats.MedianM2 = round2(stats.MedianM2 / tx)
+	stats.P25M2 = round2(stats.P25M2 / tx)
+	stats.P75M2 = round2(stats.P75M2 / tx)
+	return match, stats, nil
+}
+
+// prospectMarketClass infers the ITBI property class from the prospect attributes.
+// Condo fee, elevator or floor indicate an apartment; otherwise the "geral" aggregate is used.
+func prospectMarketClass(p *prospect) string {
+	return marketPropertyClass(p.CondoFee, p.Elevator, p.Floor)
+}
+
+func marketPropertyClass(condoFee *float64, elevator *bool, floor *int) string {
+	if (condoFee != nil && *condoFee > 0) || (elevator != nil && *elevator) || floor != nil {
+		return "apartamento"
+	}
+	return "geral"
+}
+
 // persistFlipScore saves the flip score result to the database
-func (a *api) persistFlipScore(ctx context.Context, prospectID string, result flipscore.Result) error {
+func (a *api) persistFlipScore(ctx context.Context, prospectID string, result flipscore.Result, listingHash *string) error {
 	breakdownBytes, err := json.Marshal(result.Breakdown)
 	if err != nil {
 		return err
@@ -378,20 +658,51 @@ func (a *api) persistFlipScore(ctx context.Context, prospectID string, result fl
 		     flip_score_confidence = $3,
 		     flip_score_breakdown = $4,
 		     flip_score_updated_at = $5,
+		     flip_score_listing_hash = $6,
 		     updated_at = now()
-		 WHERE id = $6`,
+		 WHERE id = $7`,
 		result.Score,
 		result.Version,
 		result.Confidence,
 		breakdownBytes,
 		result.ComputedAt,
+		listingHash,
 		prospectID,
 	)
 	return err
 }
 
 // persistFlipScoreV1 saves the v1 flip score result to the database
-func (a *api) persistFlipScoreV1(ctx context.Context, prospectID string, result flipscore.ResultV1) error {
+func (a *api) persistFlipScoreV1(ctx context.Context, prospectID string, result flipscore.ResultV1, listingHash *string) error {
+	breakdownBytes, err := json.Marshal(result.Breakdown)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.db.ExecContext(
+		ctx,
+		`UPDATE prospecting_properties
+		 SET flip_score = $1,
+		     flip_score_version = $2,
+		     flip_score_confidence = $3,
+		     flip_score_breakdown = $4,
+		     flip_score_updated_at = $5,
+		     flip_score_listing_hash = $6,
+		     updated_at = now()
+		 WHERE id = $7`,
+		result.Score,
+		result.Version,
+		result.Confidence,
+		breakdownBytes,
+		result.ComputedAt,
+		listingHash,
+		prospectID,
+	)
+	return err
+}
+
+// persistFlipScoreV2 saves the v2 flip score result to the database
+func (a *api) persistFlipScoreV2(ctx context.Context, prospectID string, result flipscore.ResultV2, listingHash *string) error {
 	breakdownBytes, err := json.Marshal(result.Breakdown)
 	if err != nil {
 		return err
@@ -405,13 +716,15 @@ func (a *api) persistFlipScoreV1(ctx context.Context, prospectID string, result
 		     flip_score_confidence = $3,
 		     flip_score_breakdown = $4,
 		     flip_score_updated_at = $5,
+		     flip_score_listing_hash = $6,
 		     updated_at = now()
-		 WHERE id = $6`,
+		 WHERE id = $7`,
 		result.Score,
 		result.Version,
 		result.Confidence,
 		breakdownBytes,
 		result.ComputedAt,
+		listingHash,
 		prospectID,
 	)
 	return err
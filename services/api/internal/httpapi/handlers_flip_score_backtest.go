@@ -0,0 +1,345 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/flipscore"
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
+
+// Reasons a sold deal is left out of the backtest
+const (
+	backtestExcludedMissingInputs = "missing_inputs" // converted before the scoring inputs were stored
+	backtestExcludedUnscored      = "unscored"       // some version could not score it (see unscored)
+	backtestExcludedMissingROI    = "missing_roi"    // no net profit or cost basis to derive the ROI
+)
+
+// backtestVersions are the score versions compared on every sold deal
+var backtestVersions = []string{flipscore.VersionV0, flipscore.VersionV1, flipscore.VersionV2}
+
+type flipScoreBacktestResponse struct {
+	WorkspaceID *string                     `json:"workspace_id"`
+	GoodDealROI float64                     `json:"good_deal_roi"`
+	SoldDeals   int                         `json:"sold_deals"`
+	ScoredDeals int                         `json:"scored_deals"` // deals every version scored, the ones backtested
+	Excluded    map[string]int              `json:"excluded"`     // sold deals left out, by reason
+	Unscored    map[string][]string         `json:"unscored"`     // property ids of the sold deals each version could not score
+	Versions    []flipscore.VersionBacktest `json:"versions"`
+}
+
+// flipScoreInputsSnapshot holds everything the score versions need, stored with the outcome
+// when the prospect is converted so the backtest can score each sold deal with every version
+type flipScoreInputsSnapshot struct {
+	Inputs   flipscore.ProspectInputsV1    `json:"inputs"`
+	Risk     *flipscore.FlipRiskAssessment `json:"risk"`
+	Cohort   flipscore.CohortStats         `json:"cohort"`
+	Market   *flipscore.MarketStats        `json:"market"`
+	Settings viability.CashSettings        `json:"settings"`
+	Profile  flipscore.ScoringProfile      `json:"profile"`
+}
+
+// snapshotFlipScoreInputs captures the prospect's current scoring inputs as JSON
+func (a *api) snapshotFlipScoreInputs(ctx context.Context, workspaceID, prospectID string) ([]byte, error) {
+	p, err := a.getWorkspaceProspectWithFlipScore(ctx, workspaceID, prospectID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := a.getWorkspaceScoringProfile(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := a.getWorkspaceCashSettings(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(flipScoreInputsSnapshot{
+		Inputs:   flipScoreInputsV1(p),
+		Risk:     cachedRiskAssessment(p),
+		Cohort:   a.getCohortStats(ctx, p),
+		Market:   a.getMarketStats(ctx, p),
+		Settings: settings,
+		Profile:  profile,
+	})
+}
+
+// score scores the snapshot with a version; ok is false when the version cannot score it
+// (v1 needs an expected sale price and an offer or asking price)
+func (s flipScoreInputsSnapshot) score(version string) (int, bool) {
+	switch version {
+	case flipscore.VersionV0:
+		return flipscore.Calculate(s.Inputs.ProspectInputs, s.Risk, s.Cohort, s.Profile).Score, true
+	case flipscore.VersionV1:
+		if !flipscore.CanCalculateV1(s.Inputs) {
+			return 0, false
+		}
+		return flipscore.CalculateV1(s.Inputs, s.Risk, s.Cohort, s.Settings, s.Profile).Score, true
+	case flipscore.VersionV2:
+		return flipscore.CalculateV2(s.Inputs.ProspectInputs, s.Risk, s.Market, s.Cohort, s.Profile).Score, true
+	}
+	return 0, false
+}
+
+// recordFlipScoreOutcome stores the realized sale price, net profit and hold time of a
+// converted property that reached sold. Missing values come from the cash analysis; without
+// one the ROI is taken over the purchase price plus renovation (or the cost items).
+func (a *api) recordFlipScoreOutcome(ctx context.Context, p property, realizedSalePrice, realizedNetProfit *float64) error {
+	inputs, err := a.getCashInputs(ctx, p.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	settings, err := a.getEffectivePropertySettings(ctx, p.ID, p.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	salePrice := realizedSalePrice
+	if salePrice == nil {
+		salePrice = inputs.SalePrice
+	}
+
+	var netProfit, roi *float64
+	var source *string
+	investmentTotal := 0.0
+	if salePrice != nil && inputs.PurchasePrice != nil {
+		withSale := inputs
+		withSale.SalePrice = salePrice
+		outputs := a.calculateCashOutputs(withSale, settings)
+		investmentTotal = outputs.InvestmentTotal
+		value := outputs.NetProfit
+		netProfit = &value
+		s := "cash_analysis"
+		source = &s
+	}
+	if realizedNetProfit != nil {
+		netProfit = realizedNetProfit
+		s := "reported"
+		source = &s
+	}
+	if netProfit != nil && investmentTotal <= 0 {
+		investmentTotal, err = a.outcomeCostBasis(ctx, p.ID, inputs)
+		if err != nil {
+			return err
+		}
+	}
+	if netProfit != nil && investmentTotal > 0 {
+		value := math.Round(*netProfit/investmentTotal*10000) / 100
+		roi = &value
+	}
+
+	// Hold time runs from the first move to bought (or the conversion when never marked bought)
+	var acquiredAt time.Time
+	err = a.db.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(
+			(SELECT MIN(created_at) FROM timeline_events
+			 WHERE property_id = $1 AND event_type = $2 AND payload->>'to_status' = $3),
+			(SELECT created_at FROM properties WHERE id = $1)
+		 )`,
+		p.ID, EventTypeStatusChanged, PropertyStatusBought,
+	).Scan(&acquiredAt)
+	if err != nil {
+		return err
+	}
+	soldAt := time.Now().UTC()
+	holdDays := int(soldAt.Sub(acquiredAt).Hours() / 24)
+	if holdDays < 0 {
+		holdDays = 0
+	}
+
+	_, err = a.db.ExecContext(
+		ctx,
+		`INSERT INTO flip_score_outcomes (
+			workspace_id, prospect_id, property_id,
+			score, score_version, score_confidence, score_breakdown, scored_at,
+			predicted_purchase_price, predicted_sale_price, converted_at,
+			realized_sale_price, realized_net_profit, realized_roi, realized_hold_days, net_profit_source, sold_at
+		 )
+		 SELECT pr.workspace_id, pp.id, pr.id,
+		        pp.flip_score, pp.flip_score_version, pp.flip_score_confidence, pp.flip_score_breakdown, pp.flip_score_updated_at,
+		        COALESCE(pp.offer_price, pp.asking_price), pp.expected_sale_price, pr.created_at,
+		        $2, $3, $4, $5, $6, $7
+		 FROM properties pr
+		 JOIN prospecting_properties pp ON pp.id = pr.origin_prospect_id
+		 WHERE pr.id = $1
+		 ON CONFLICT (property_id) DO UPDATE SET
+			realized_sale_price = EXCLUDED.realized_sale_price,
+			realized_net_profit = EXCLUDED.realized_net_profit,
+			realized_roi = EXCLUDED.realized_roi,
+			realized_hold_days = EXCLUDED.realized_hold_days,
+			net_profit_source = EXCLUDED.net_profit_source,
+			sold_at = EXCLUDED.sold_at,
+			updated_at = now()`,
+		p.ID, salePrice, netProfit, roi, holdDays, source, soldAt,
+	)
+	return err
+}
+
+// outcomeCostBasis is the ROI denominator of a sale without a complete cash analysis: the
+// purchase price (falling back to the origin prospect's offer or asking price) plus the
+// renovation cost (falling back to the property's cost items)
+func (a *api) outcomeCostBasis(ctx context.Context, propertyID string, inputs cashInputs) (float64, error) {
+	var prospectPrice sql.NullFloat64
+	var costItems float64
+	err := a.db.QueryRowContext(
+		ctx,
+		`SELECT
+			(SELECT COALESCE(pp.offer_price, pp.asking_price)
+			 FROM properties pr
+			 JOIN prospecting_properties pp ON pp.id = pr.origin_prospect_id
+			 WHERE pr.id = $1),
+			(SELECT COALESCE(SUM(amount), 0) FROM cost_items WHERE property_id = $1)`,
+		propertyID,
+	).Scan(&prospectPrice, &costItems)
+	if err != nil {
+		return 0, err
+	}
+
+	purchase := prospectPrice.Float64
+	if inputs.PurchasePrice != nil {
+		purchase = *inputs.PurchasePrice
+	}
+	renovation := costItems
+	if inputs.RenovationCost != nil {
+		renovation = *inputs.RenovationCost
+	}
+	if purchase <= 0 {
+		return 0, nil
+	}
+	return purchase + renovation, nil
+}
+
+// handleWorkspaceFlipScoreBacktest handles GET /api/v1/workspaces/:id/flip-score-backtest
+func (a *api) handleWorkspaceFlipScoreBacktest(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, apiError{Code: "METHOD_NOT_ALLOWED", Message: "method not allowed"})
+		return
+	}
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	if ok, err := a.hasWorkspaceMembership(r.Context(), workspaceID, userID); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check membership"})
+		return
+	} else if !ok {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "workspace not found"})
+		return
+	}
+
+	a.writeFlipScoreBacktest(w, r, &workspaceID)
+}
+
+// handleAdminFlipScoreBacktest handles GET /api/v1/admin/flip-score/backtest (optionally ?workspace_id=)
+func (a *api) handleAdminFlipScoreBacktest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var workspaceID *string
+	if v := strings.TrimSpace(r.URL.Query().Get("workspace_id")); v != "" {
+		workspaceID = &v
+	}
+	a.writeFlipScoreBacktest(w, r, workspaceID)
+}
+
+func (a *api) writeFlipScoreBacktest(w http.ResponseWriter, r *http.Request, workspaceID *string) {
+	goodROI := flipscore.DefaultGoodDealROI
+	if raw := strings.TrimSpace(r.URL.Query().Get("good_roi")); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "good_roi must be a number"})
+			return
+		}
+		goodROI = v
+	}
+
+	rows, err := a.db.QueryContext(
+		r.Context(),
+		`SELECT property_id, score_inputs, realized_roi
+		 FROM flip_score_outcomes
+		 WHERE sold_at IS NOT NULL
+		   AND ($1::uuid IS NULL OR workspace_id = $1::uuid)
+		 ORDER BY sold_at, property_id`,
+		workspaceID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to query flip score outcomes"})
+		return
+	}
+	defer rows.Close()
+
+	// Every version is scored from the same snapshot and backtested on the deals all of them
+	// could score, so the versions are compared on the same sold deals
+	sold, scored := 0, 0
+	excluded := map[string]int{backtestExcludedMissingInputs: 0, backtestExcludedUnscored: 0, backtestExcludedMissingROI: 0}
+	unscored := make(map[string][]string, len(backtestVersions))
+	for _, version := range backtestVersions {
+		unscored[version] = []string{}
+	}
+	outcomes := make([]flipscore.BacktestOutcome, 0)
+	for rows.Next() {
+		var propertyID string
+		var inputs []byte
+		var roi sql.NullFloat64
+		if err := rows.Scan(&propertyID, &inputs, &roi); err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to scan flip score outcome"})
+			return
+		}
+		sold++
+		if !roi.Valid {
+			excluded[backtestExcludedMissingROI]++
+			continue
+		}
+
+		var snapshot flipScoreInputsSnapshot
+		if len(inputs) == 0 || json.Unmarshal(inputs, &snapshot) != nil {
+			excluded[backtestExcludedMissingInputs]++
+			for _, version := range backtestVersions {
+				unscored[version] = append(unscored[version], propertyID)
+			}
+			continue
+		}
+
+		deal := make([]flipscore.BacktestOutcome, 0, len(backtestVersions))
+		for _, version := range backtestVersions {
+			score, ok := snapshot.score(version)
+			if !ok {
+				unscored[version] = append(unscored[version], propertyID)
+				continue
+			}
+			deal = append(deal, flipscore.BacktestOutcome{Version: version, Score: score, RealizedROI: roi.Float64})
+		}
+		if len(deal) < len(backtestVersions) {
+			excluded[backtestExcludedUnscored]++
+			continue
+		}
+		scored++
+		outcomes = append(outcomes, deal...)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to read flip score outcomes"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, flipScoreBacktestResponse{
+		WorkspaceID: workspaceID,
+		GoodDealROI: goodROI,
+		SoldDeals:   sold,
+		ScoredDeals: scored,
+		Excluded:    excluded,
+		Unscored:    unscored,
+		Versions:    flipscore.Backtest(outcomes, goodROI),
+	})
+}
@@ -0,0 +1,104 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/flipscore"
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
+
+func backtestSnapshot(t *testing.T, expectedSale *float64) []byte {
+	t.Helper()
+	asking := 500000.0
+	area := 60.0
+	raw, err := json.Marshal(flipScoreInputsSnapshot{
+		Inputs: flipscore.ProspectInputsV1{
+			ProspectInputs:    flipscore.ProspectInputs{AskingPrice: &asking, AreaUsable: &area},
+			ExpectedSalePrice: expectedSale,
+		},
+		Cohort:   flipscore.CohortStats{Scope: "workspace", N: 10, PercentileRank: 0.5},
+		Settings: viability.CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06},
+		Profile:  flipscore.DefaultProfile(),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode snapshot: %v", err)
+	}
+	return raw
+}
+
+func TestWorkspaceFlipScoreBacktestScoresEveryVersionOnTheSameDeals(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+
+	rows := sqlmock.NewRows([]string{"property_id", "score_inputs", "realized_roi"})
+	// Higher expected sale prices realized higher ROI; only v1 looks at the sale price
+	for i, sale := range []float64{560000, 620000, 680000, 740000} {
+		sale := sale
+		rows.AddRow("property-"+string(rune('a'+i)), backtestSnapshot(t, &sale), float64(5+i*10))
+	}
+	// v1 needs an expected sale price
+	rows.AddRow("property-no-sale", backtestSnapshot(t, nil), 12.0)
+	// converted before the scoring inputs were stored
+	rows.AddRow("property-legacy", nil, 8.0)
+	// sold without a net profit or cost basis
+	rows.AddRow("property-no-roi", backtestSnapshot(t, nil), nil)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM flip_score_outcomes")).
+		WithArgs("workspace-1").
+		WillReturnRows(rows)
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodGet, "/api/v1/workspaces/workspace-1/flip-score-backtest?good_roi=20", "", "user-1")
+
+	a.handleWorkspaceFlipScoreBacktest(rr, req, "workspace-1")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp flipScoreBacktestResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SoldDeals != 7 || resp.ScoredDeals != 4 || len(resp.Versions) != 3 {
+		t.Fatalf("sold=%d scored=%d versions=%d", resp.SoldDeals, resp.ScoredDeals, len(resp.Versions))
+	}
+	if resp.Excluded[backtestExcludedMissingInputs] != 1 || resp.Excluded[backtestExcludedUnscored] != 1 ||
+		resp.Excluded[backtestExcludedMissingROI] != 1 {
+		t.Fatalf("excluded=%v", resp.Excluded)
+	}
+
+	if got := resp.Unscored[flipscore.VersionV0]; len(got) != 1 || got[0] != "property-legacy" {
+		t.Fatalf("unscored v0=%v", got)
+	}
+	if got := resp.Unscored[flipscore.VersionV1]; len(got) != 2 || got[0] != "property-no-sale" || got[1] != "property-legacy" {
+		t.Fatalf("unscored v1=%v", got)
+	}
+
+	for _, v := range resp.Versions {
+		if v.N != 4 {
+			t.Fatalf("version=%s n=%d want=4", v.Version, v.N)
+		}
+	}
+	v0, v1, v2 := resp.Versions[0], resp.Versions[1], resp.Versions[2]
+	if v0.Version != flipscore.VersionV0 || v1.Version != flipscore.VersionV1 || v2.Version != flipscore.VersionV2 {
+		t.Fatalf("versions=%s,%s,%s", v0.Version, v1.Version, v2.Version)
+	}
+	// The deals differ only in the sale price, so v0 and v2 cannot rank them
+	if v0.SpearmanRho != nil || v2.SpearmanRho != nil {
+		t.Fatalf("v0 rho=%v v2 rho=%v want=nil", v0.SpearmanRho, v2.SpearmanRho)
+	}
+	if v1.SpearmanRho == nil || *v1.SpearmanRho != 1 {
+		t.Fatalf("v1 rho=%v want=1", v1.SpearmanRho)
+	}
+	// 2 of the 4 deals reach 20% ROI
+	if v1.TopDecile.BaseRate == nil || *v1.TopDecile.BaseRate != 0.5 {
+		t.Fatalf("top_decile=%+v", v1.TopDecile)
+	}
+}
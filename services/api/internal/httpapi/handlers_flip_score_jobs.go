@@ -0,0 +1,813 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/flipscore"
+)
+
+const (
+	FlipScoreJobStatusQueued    = "queued"
+	FlipScoreJobStatusRunning   = "running"
+	FlipScoreJobStatusCompleted = "completed"
+	FlipScoreJobStatusFailed    = "failed"
+
+	flipScoreJobItemPending = "pending"
+	flipScoreJobItemDone    = "done"
+	flipScoreJobItemFailed  = "failed"
+
+	flipScoreRiskSourceLLM   = "llm"
+	flipScoreRiskSourceCache = "cache"
+	flipScoreRiskSourceNone  = "none"
+
+	// Minimum spacing between LLM calls across all recompute jobs of this API instance
+	flipScoreJobLLMInterval = 2 * time.Second
+	// Pause before re-checking a job whose pending items are held by another transaction
+	flipScoreJobIdleWait = time.Second
+)
+
+type flipScoreRecomputeJob struct {
+	ID             string     `json:"id"`
+	WorkspaceID    string     `json:"workspace_id"`
+	Status         string     `json:"status"`
+	ForceVersion   *string    `json:"force_version"`
+	TriggeredBy    *string    `json:"triggered_by"`
+	TotalItems     int        `json:"total_items"`
+	ProcessedItems int        `json:"processed_items"`
+	FailedItems    int        `json:"failed_items"`
+	LLMCalls       int        `json:"llm_calls"`
+	LLMCacheHits   int        `json:"llm_cache_hits"`
+	Progress       float64    `json:"progress"` // 0-1
+	ErrorMessage   *string    `json:"error_message"`
+	StartedAt      *time.Time `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type flipScoreRecomputeJobFailure struct {
+	ProspectID   string     `json:"prospect_id"`
+	ErrorMessage *string    `json:"error_message"`
+	ProcessedAt  *time.Time `json:"processed_at"`
+}
+
+type flipScoreRecomputeJobResponse struct {
+	Job      flipScoreRecomputeJob          `json:"job"`
+	Failures []flipScoreRecomputeJobFailure `json:"failures"`
+}
+
+type listFlipScoreRecomputeJobsResponse struct {
+	Items []flipScoreRecomputeJob `json:"items"`
+}
+
+type createFlipScoreRecomputeJobRequest struct {
+	ForceVersion *string `json:"force_version"` // "v0" or "v2" to skip the v1 auto-detection
+}
+
+// flipScoreJobRunner runs recompute jobs in background goroutines. It keeps a single worker
+// per job in this process (across replicas the DB advisory lock decides) and spaces LLM calls
+// so a bulk rescore does not hit the provider rate limits.
+type flipScoreJobRunner struct {
+	mu          sync.Mutex
+	running     map[string]bool
+	llmInterval time.Duration
+	nextLLMAt   time.Time
+}
+
+func newFlipScoreJobRunner() *flipScoreJobRunner {
+	return &flipScoreJobRunner{
+		running:     make(map[string]bool),
+		llmInterval: flipScoreJobLLMInterval,
+	}
+}
+
+// claim marks the job as running in this process; false when a worker already owns it
+func (r *flipScoreJobRunner) claim(jobID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running[jobID] {
+		return false
+	}
+	r.running[jobID] = true
+	return true
+}
+
+func (r *flipScoreJobRunner) release(jobID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, jobID)
+}
+
+// waitLLM blocks until the next LLM call slot
+func (r *flipScoreJobRunner) waitLLM(ctx context.Context) error {
+	r.mu.Lock()
+	now := time.Now()
+	at := r.nextLLMAt
+	if at.Before(now) {
+		at = now
+	}
+	r.nextLLMAt = at.Add(r.llmInterval)
+	r.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleWorkspaceFlipScoreRecomputeJobs routes /api/v1/workspaces/:id/flip-score-recompute-jobs[/:jobId]
+func (a *api) handleWorkspaceFlipScoreRecomputeJobs(w http.ResponseWriter, r *http.Request, workspaceID string, rest []string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	if ok, err := a.hasWorkspaceMembership(r.Context(), workspaceID, userID); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check membership"})
+		return
+	} else if !ok {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "workspace not found"})
+		return
+	}
+
+	switch {
+	case len(rest) == 0 || (len(rest) == 1 && rest[0] == ""):
+		switch r.Method {
+		case http.MethodGet:
+			a.handleListFlipScoreRecomputeJobs(w, r, workspaceID)
+		case http.MethodPost:
+			a.handleCreateFlipScoreRecomputeJob(w, r, workspaceID, userID)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, apiError{Code: "METHOD_NOT_ALLOWED", Message: "method not allowed"})
+		}
+	case len(rest) == 1:
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, apiError{Code: "METHOD_NOT_ALLOWED", Message: "method not allowed"})
+			return
+		}
+		a.handleGetFlipScoreRecomputeJob(w, r, workspaceID, strings.TrimSpace(rest[0]))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// handleCreateFlipScoreRecomputeJob queues a rescore of every prospect of the workspace.
+// Only one job can be active per workspace.
+func (a *api) handleCreateFlipScoreRecomputeJob(w http.ResponseWriter, r *http.Request, workspaceID, userID string) {
+	var req createFlipScoreRecomputeJobRequest
+	if err := decodeOptionalJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+	if req.ForceVersion != nil && *req.ForceVersion != flipscore.VersionV0 && *req.ForceVersion != flipscore.VersionV2 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "force_version must be v0 or v2"})
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to start transaction"})
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var activeID string
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT id FROM flip_score_recompute_jobs
+		 WHERE workspace_id = $1 AND status IN ($2, $3)
+		 LIMIT 1`,
+		workspaceID, FlipScoreJobStatusQueued, FlipScoreJobStatusRunning,
+	).Scan(&activeID)
+	if err == nil {
+		writeError(w, http.StatusConflict, apiError{
+			Code:    "JOB_ALREADY_RUNNING",
+			Message: "a flip score recompute job is already running for this workspace",
+			Details: []string{activeID},
+		})
+		return
+	}
+	if err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check active jobs"})
+		return
+	}
+
+	var jobID string
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO flip_score_recompute_jobs (workspace_id, status, force_version, triggered_by)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id`,
+		workspaceID, FlipScoreJobStatusQueued, req.ForceVersion, userID,
+	).Scan(&jobID)
+	if err != nil {
+		// A concurrent request created the active job after the check above
+		if isUniqueViolation(err) {
+			writeError(w, http.StatusConflict, apiError{
+				Code:    "JOB_ALREADY_RUNNING",
+				Message: "a flip score recompute job is already running for this workspace",
+			})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create job"})
+		return
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO flip_score_recompute_job_items (job_id, prospect_id)
+		 SELECT $1, id FROM prospecting_properties
+		 WHERE workspace_id = $2 AND deleted_at IS NULL`,
+		jobID, workspaceID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to queue prospects"})
+		return
+	}
+	total, _ := res.RowsAffected()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE flip_score_recompute_jobs SET total_items = $1 WHERE id = $2`,
+		total, jobID,
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create job"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to commit job"})
+		return
+	}
+
+	log.Printf("flip_score_job_created job_id=%s workspace_id=%s user_id=%s total=%d", jobID, workspaceID, userID, total)
+	a.startFlipScoreRecomputeJob(jobID)
+
+	job, err := a.getFlipScoreRecomputeJob(ctx, workspaceID, jobID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch job"})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, flipScoreRecomputeJobResponse{Job: job, Failures: []flipScoreRecomputeJobFailure{}})
+}
+
+func (a *api) handleListFlipScoreRecomputeJobs(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	rows, err := a.db.QueryContext(
+		r.Context(),
+		`SELECT `+flipScoreRecomputeJobColumns+`
+		 FROM flip_score_recompute_jobs
+		 WHERE workspace_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT 20`,
+		workspaceID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to list jobs"})
+		return
+	}
+	defer rows.Close()
+
+	items := make([]flipScoreRecomputeJob, 0)
+	for rows.Next() {
+		job, err := scanFlipScoreRecomputeJob(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to scan job"})
+			return
+		}
+		items = append(items, job)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to list jobs"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, listFlipScoreRecomputeJobsResponse{Items: items})
+}
+
+// handleGetFlipScoreRecomputeJob is the polling endpoint: job progress plus the failed items
+func (a *api) handleGetFlipScoreRecomputeJob(w http.ResponseWriter, r *http.Request, workspaceID, jobID string) {
+	job, err := a.getFlipScoreRecomputeJob(r.Context(), workspaceID, jobID)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "job not found"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch job"})
+		return
+	}
+
+	rows, err := a.db.QueryContext(
+		r.Context(),
+		`SELECT prospect_id, error_message, processed_at
+		 FROM flip_score_recompute_job_items
+		 WHERE job_id = $1 AND status = $2
+		 ORDER BY processed_at`,
+		jobID, flipScoreJobItemFailed,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch job failures"})
+		return
+	}
+	defer rows.Close()
+
+	failures := make([]flipScoreRecomputeJobFailure, 0)
+	for rows.Next() {
+		var f flipScoreRecomputeJobFailure
+		if err := rows.Scan(&f.ProspectID, &f.ErrorMessage, &f.ProcessedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to scan job failure"})
+			return
+		}
+		failures = append(failures, f)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch job failures"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, flipScoreRecomputeJobResponse{Job: job, Failures: failures})
+}
+
+const flipScoreRecomputeJobColumns = `id, workspace_id, status, force_version, triggered_by,
+		        total_items, processed_items, failed_items, llm_calls, llm_cache_hits,
+		        error_message, started_at, finished_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFlipScoreRecomputeJob(row rowScanner) (flipScoreRecomputeJob, error) {
+	var job flipScoreRecomputeJob
+	err := row.Scan(
+		&job.ID, &job.WorkspaceID, &job.Status, &job.ForceVersion, &job.TriggeredBy,
+		&job.TotalItems, &job.ProcessedItems, &job.FailedItems, &job.LLMCalls, &job.LLMCacheHits,
+		&job.ErrorMessage, &job.StartedAt, &job.FinishedAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return flipScoreRecomputeJob{}, err
+	}
+	if job.TotalItems > 0 {
+		job.Progress = float64(job.ProcessedItems) / float64(job.TotalItems)
+	} else if job.Status == FlipScoreJobStatusCompleted {
+		job.Progress = 1
+	}
+	return job, nil
+}
+
+func (a *api) getFlipScoreRecomputeJob(ctx context.Context, workspaceID, jobID string) (flipScoreRecomputeJob, error) {
+	return scanFlipScoreRecomputeJob(a.db.QueryRowContext(
+		ctx,
+		`SELECT `+flipScoreRecomputeJobColumns+`
+		 FROM flip_score_recompute_jobs
+		 WHERE id = $1 AND workspace_id = $2`,
+		jobID, workspaceID,
+	))
+}
+
+// startFlipScoreRecomputeJob processes the job in background (no-op when background jobs are disabled).
+// Every replica resumes active jobs at startup, so the job runs under a DB advisory lock and
+// replicas that do not get it leave the job to the one that did.
+func (a *api) startFlipScoreRecomputeJob(jobID string) {
+	if a.flipScoreJobs == nil || !a.flipScoreJobs.claim(jobID) {
+		return
+	}
+	go func() {
+		defer a.flipScoreJobs.release(jobID)
+		ctx := context.Background()
+
+		conn, err := a.db.Conn(ctx)
+		if err != nil {
+			log.Printf("flip_score_job_error job_id=%s error=%v", jobID, err)
+			return
+		}
+		locked, err := tryAcquireFlipScoreJobLock(ctx, conn, jobID)
+		if err != nil || !locked {
+			if err != nil {
+				log.Printf("flip_score_job_error job_id=%s error=%v", jobID, err)
+			}
+			_ = conn.Close()
+			return
+		}
+		defer releaseFlipScoreJobLockAndClose(conn, jobID)
+
+		if err := a.runFlipScoreRecomputeJob(ctx, jobID); err != nil {
+			log.Printf("flip_score_job_error job_id=%s error=%v", jobID, err)
+			a.finishFlipScoreRecomputeJob(ctx, jobID, FlipScoreJobStatusFailed, err)
+		}
+	}()
+}
+
+func tryAcquireFlipScoreJobLock(ctx context.Context, conn *sql.Conn, jobID string) (bool, error) {
+	var locked bool
+	err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext('flip_score_recompute'), hashtext($1))`, jobID).Scan(&locked)
+	return locked, err
+}
+
+func releaseFlipScoreJobLockAndClose(conn *sql.Conn, jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext('flip_score_recompute'), hashtext($1))`, jobID); err != nil {
+		log.Printf("flip_score_job_error job_id=%s unlock_error=%v", jobID, err)
+	}
+	if err := conn.Close(); err != nil {
+		log.Printf("flip_score_job_error job_id=%s close_error=%v", jobID, err)
+	}
+}
+
+// resumeFlipScoreRecomputeJobs restarts the jobs left queued or running by a previous API process.
+// Items already processed are kept, so a resumed job continues where it stopped.
+func (a *api) resumeFlipScoreRecomputeJobs(ctx context.Context) {
+	rows, err := a.db.QueryContext(
+		ctx,
+		`SELECT id FROM flip_score_recompute_jobs WHERE status IN ($1, $2) ORDER BY created_at`,
+		FlipScoreJobStatusQueued, FlipScoreJobStatusRunning,
+	)
+	if err != nil {
+		log.Printf("flip_score_job_resume_error error=%v", err)
+		return
+	}
+	defer rows.Close()
+
+	var jobIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("flip_score_job_resume_error error=%v", err)
+			return
+		}
+		jobIDs = append(jobIDs, id)
+	}
+
+	for _, id := range jobIDs {
+		log.Printf("flip_score_job_resume job_id=%s", id)
+		a.startFlipScoreRecomputeJob(id)
+	}
+}
+
+func (a *api) runFlipScoreRecomputeJob(ctx context.Context, jobID string) error {
+	var workspaceID string
+	var forceVersion sql.NullString
+	err := a.db.QueryRowContext(
+		ctx,
+		`UPDATE flip_score_recompute_jobs
+		 SET status = $2, started_at = COALESCE(started_at, now()), updated_at = now()
+		 WHERE id = $1
+		 RETURNING workspace_id, force_version`,
+		jobID, FlipScoreJobStatusRunning,
+	).Scan(&workspaceID, &forceVersion)
+	if err != nil {
+		return fmt.Errorf("start job: %w", err)
+	}
+
+	// Workspace-level inputs are loaded once per run
+	run := flipScoreRun{ForceVersion: forceVersion.String}
+	if run.Profile, err = a.getWorkspaceScoringProfile(ctx, workspaceID); err != nil {
+		return fmt.Errorf("load scoring profile: %w", err)
+	}
+	if run.Settings, err = a.getWorkspaceCashSettings(ctx, workspaceID); err != nil {
+		return fmt.Errorf("load workspace settings: %w", err)
+	}
+	tier, err := a.workspaceOwnerTier(ctx, workspaceID)
+	if err != nil {
+		return fmt.Errorf("check billing: %w", err)
+	}
+	allowV1 := canAccessFlipScoreV1(tier)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// The item stays locked until its outcome is recorded, so no other worker can take it
+		tx, err := a.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("next item: %w", err)
+		}
+		var prospectID string
+		err = tx.QueryRowContext(
+			ctx,
+			`SELECT prospect_id FROM flip_score_recompute_job_items
+			 WHERE job_id = $1 AND status = $2
+			 ORDER BY prospect_id
+			 LIMIT 1
+			 FOR UPDATE SKIP LOCKED`,
+			jobID, flipScoreJobItemPending,
+		).Scan(&prospectID)
+		if err == sql.ErrNoRows {
+			_ = tx.Rollback()
+			// Prospects appended while the job ran (converted opportunities) keep it open
+			done, err := a.completeFlipScoreRecomputeJob(ctx, jobID)
+			if errors.Is(err, errFlipScoreJobGone) {
+				// Deleted with its workspace; there is nothing left to finish
+				log.Printf("flip_score_job_gone job_id=%s workspace_id=%s", jobID, workspaceID)
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("complete job: %w", err)
+			}
+			if done {
+				break
+			}
+			// Pending items are still locked by another transaction; give it time to commit
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(flipScoreJobIdleWait):
+			}
+			continue
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("next item: %w", err)
+		}
+
+		item := a.processFlipScoreRecomputeItem(ctx, jobID, workspaceID, prospectID, run, allowV1)
+		if err := recordFlipScoreRecomputeItem(ctx, tx, jobID, prospectID, item); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("record item: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("record item: %w", err)
+		}
+	}
+
+	log.Printf("flip_score_job_done job_id=%s workspace_id=%s", jobID, workspaceID)
+	return nil
+}
+
+type flipScoreRecomputeItemResult struct {
+	Score      *int
+	Version    *string
+	RiskSource string
+	LLMCalled  bool
+	Err        error
+}
+
+// processFlipScoreRecomputeItem rescores one prospect. The risk assessment is reused when the
+// listing text did not change; otherwise the LLM is called within the runner's rate limit.
+func (a *api) processFlipScoreRecomputeItem(ctx context.Context, jobID, workspaceID, prospectID string, run flipScoreRun, allowV1 bool) flipScoreRecomputeItemResult {
+	var result flipScoreRecomputeItemResult
+	run.RequestID = "job:" + jobID
+
+	p, err := a.getWorkspaceProspectWithFlipScore(ctx, workspaceID, prospectID)
+	if err == sql.ErrNoRows {
+		result.Err = errors.New("prospect not found")
+		return result
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if cached := cachedRiskAssessment(p); cached != nil {
+		run.Risk = cached
+		result.RiskSource = flipScoreRiskSourceCache
+	} else if a.llmClient != nil && p.ListingText != nil && *p.ListingText != "" {
+		if err := a.flipScoreJobs.waitLLM(ctx); err != nil {
+			result.Err = err
+			return result
+		}
+		result.LLMCalled = true
+		assessment, err := a.extractListingRisk(ctx, run.RequestID, p)
+		if err != nil {
+			// Keep the previous score rather than overwriting it without the risk component
+			result.Err = fmt.Errorf("risk assessment: %w", err)
+			return result
+		}
+		run.Risk = assessment
+		result.RiskSource = flipScoreRiskSourceLLM
+	} else {
+		result.RiskSource = flipScoreRiskSourceNone
+	}
+
+	// Workspaces without v1 access are rescored with v2 instead of failing
+	run.UseV1 = allowV1 && run.ForceVersion == "" && flipscore.CanCalculateV1(flipScoreInputsV1(p))
+
+	scored, err := a.scoreProspect(ctx, p, run)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Score = scored.FlipScore
+	result.Version = scored.FlipScoreVersion
+	return result
+}
+
+// recordFlipScoreRecomputeItem stores the item outcome in tx and advances the job counters only
+// when the item was still pending, so an item processed twice is counted once
+func recordFlipScoreRecomputeItem(ctx context.Context, tx *sql.Tx, jobID, prospectID string, item flipScoreRecomputeItemResult) error {
+	status := flipScoreJobItemDone
+	var errMsg *string
+	failed := 0
+	if item.Err != nil {
+		status = flipScoreJobItemFailed
+		msg := item.Err.Error()
+		errMsg = &msg
+		failed = 1
+	}
+	var riskSource *string
+	if item.RiskSource != "" {
+		riskSource = &item.RiskSource
+	}
+	llmCalls, cacheHits := 0, 0
+	if item.LLMCalled {
+		llmCalls = 1
+	}
+	if item.RiskSource == flipScoreRiskSourceCache {
+		cacheHits = 1
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE flip_score_recompute_job_items
+		 SET status = $3, score = $4, score_version = $5, risk_source = $6, error_message = $7, processed_at = now()
+		 WHERE job_id = $1 AND prospect_id = $2 AND status = $8`,
+		jobID, prospectID, status, item.Score, item.Version, riskSource, errMsg, flipScoreJobItemPending,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected != 1 {
+		return nil
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE flip_score_recompute_jobs
+		 SET processed_items = processed_items + 1,
+		     failed_items = failed_items + $2,
+		     llm_calls = llm_calls + $3,
+		     llm_cache_hits = llm_cache_hits + $4,
+		     updated_at = now()
+		 WHERE id = $1`,
+		jobID, failed, llmCalls, cacheHits,
+	)
+	return err
+}
+
+var errFlipScoreJobGone = errors.New("flip score recompute job no longer exists")
+
+// completeFlipScoreRecomputeJob marks the job completed unless items were appended
+// after the last one processed. The job row is locked first, so the check is
+// serialized with queueProspectFlipScore, which appends items under the same lock.
+// Counters are re-synced from the item rows, since a prospect hard-delete cascades
+// its items away and would otherwise leave processed_items below total_items forever.
+func (a *api) completeFlipScoreRecomputeJob(ctx context.Context, jobID string) (bool, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var locked string
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT id FROM flip_score_recompute_jobs WHERE id = $1 FOR UPDATE`,
+		jobID,
+	).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return false, errFlipScoreJobGone
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var total, pending, failed int
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT count(*),
+		        count(*) FILTER (WHERE status = $2),
+		        count(*) FILTER (WHERE status = $3)
+		 FROM flip_score_recompute_job_items
+		 WHERE job_id = $1`,
+		jobID, flipScoreJobItemPending, flipScoreJobItemFailed,
+	).Scan(&total, &pending, &failed); err != nil {
+		return false, err
+	}
+	if pending > 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE flip_score_recompute_jobs
+		 SET status = $2, total_items = $3, processed_items = $3, failed_items = $4,
+		     finished_at = now(), updated_at = now()
+		 WHERE id = $1`,
+		jobID, FlipScoreJobStatusCompleted, total, failed,
+	); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// queueProspectFlipScore schedules a Flip Score computation for one prospect: it is
+// appended to the workspace's active recompute job or gets a single-item job.
+func (a *api) queueProspectFlipScore(ctx context.Context, workspaceID, prospectID, userID string) (string, error) {
+	jobID, err := a.appendProspectFlipScoreItem(ctx, workspaceID, prospectID, userID)
+	if isUniqueViolation(err) {
+		// Another request created the active job (uq_flip_score_recompute_jobs_active) after
+		// our lookup; it has committed by now, so the retry locks it and joins it
+		jobID, err = a.appendProspectFlipScoreItem(ctx, workspaceID, prospectID, userID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("flip_score_job_item_queued job_id=%s workspace_id=%s prospect_id=%s", jobID, workspaceID, prospectID)
+	a.startFlipScoreRecomputeJob(jobID)
+	return jobID, nil
+}
+
+// appendProspectFlipScoreItem adds the prospect to the workspace's active job, creating a
+// queued job when there is none, in its own transaction
+func (a *api) appendProspectFlipScoreItem(ctx context.Context, workspaceID, prospectID, userID string) (string, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var jobID string
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT id FROM flip_score_recompute_jobs
+		 WHERE workspace_id = $1 AND status IN ($2, $3)
+		 LIMIT 1
+		 FOR UPDATE`,
+		workspaceID, FlipScoreJobStatusQueued, FlipScoreJobStatusRunning,
+	).Scan(&jobID)
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.QueryRowContext(
+			ctx,
+			`INSERT INTO flip_score_recompute_jobs (workspace_id, status, triggered_by)
+			 VALUES ($1, $2, $3)
+			 RETURNING id`,
+			workspaceID, FlipScoreJobStatusQueued, userID,
+		).Scan(&jobID)
+		if err != nil {
+			return "", err
+		}
+	case err != nil:
+		return "", err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO flip_score_recompute_job_items (job_id, prospect_id)
+		 VALUES ($1, $2)
+		 ON CONFLICT (job_id, prospect_id) DO NOTHING`,
+		jobID, prospectID,
+	)
+	if err != nil {
+		return "", err
+	}
+	if added, _ := res.RowsAffected(); added > 0 {
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE flip_score_recompute_jobs SET total_items = total_items + 1, updated_at = now() WHERE id = $1`,
+			jobID,
+		); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return jobID, nil
+}
+
+func (a *api) finishFlipScoreRecomputeJob(ctx context.Context, jobID, status string, jobErr error) {
+	var errMsg *string
+	if jobErr != nil {
+		msg := jobErr.Error()
+		errMsg = &msg
+	}
+	if _, err := a.db.ExecContext(
+		ctx,
+		`UPDATE flip_score_recompute_jobs
+		 SET status = $2, error_message = $3, finished_at = now(), updated_at = now()
+		 WHERE id = $1`,
+		jobID, status, errMsg,
+	); err != nil {
+		log.Printf("flip_score_job_finish_error job_id=%s error=%v", jobID, err)
+	}
+}
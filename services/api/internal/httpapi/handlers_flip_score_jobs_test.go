@@ -0,0 +1,323 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func flipScoreJobRows(jobID, workspaceID, status string, total, processed, failed int) *sqlmock.Rows {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return sqlmock.NewRows([]string{
+		"id", "workspace_id", "status", "force_version", "triggered_by",
+		"total_items", "processed_items", "failed_items", "llm_calls", "llm_cache_hits",
+		"error_message", "started_at", "finished_at", "created_at", "updated_at",
+	}).AddRow(
+		jobID, workspaceID, status, nil, "user-1",
+		total, processed, failed, 1, 2,
+		nil, now, nil, now, now,
+	)
+}
+
+func TestCreateFlipScoreRecomputeJobRejectsWhenActive(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, FlipScoreJobStatusRunning).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-0"))
+	mock.ExpectRollback()
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/workspaces/workspace-1/flip-score-recompute-jobs", "", "user-1")
+
+	a.handleWorkspaceFlipScoreRecomputeJobs(rr, req, "workspace-1", nil)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusConflict, rr.Body.String())
+	}
+	if got := decodeAPIErrorCode(t, rr); got != "JOB_ALREADY_RUNNING" {
+		t.Fatalf("error.code=%s want=JOB_ALREADY_RUNNING", got)
+	}
+}
+
+func TestCreateFlipScoreRecomputeJobRejectsConcurrentlyCreatedJob(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	// The active job is created by another request between the check and the insert
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, FlipScoreJobStatusRunning).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, nil, "user-1").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_flip_score_recompute_jobs_active"})
+	mock.ExpectRollback()
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/workspaces/workspace-1/flip-score-recompute-jobs", "", "user-1")
+
+	a.handleWorkspaceFlipScoreRecomputeJobs(rr, req, "workspace-1", nil)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusConflict, rr.Body.String())
+	}
+	if got := decodeAPIErrorCode(t, rr); got != "JOB_ALREADY_RUNNING" {
+		t.Fatalf("error.code=%s want=JOB_ALREADY_RUNNING", got)
+	}
+}
+
+func TestCreateFlipScoreRecomputeJobQueuesWorkspaceProspects(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM flip_score_recompute_jobs")).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, "v2", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO flip_score_recompute_job_items")).
+		WithArgs("job-1", "workspace-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE flip_score_recompute_jobs SET total_items")).
+		WithArgs(int64(3), "job-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM flip_score_recompute_jobs")).
+		WithArgs("job-1", "workspace-1").
+		WillReturnRows(flipScoreJobRows("job-1", "workspace-1", FlipScoreJobStatusQueued, 3, 0, 0))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/workspaces/workspace-1/flip-score-recompute-jobs",
+		`{"force_version":"v2"}`, "user-1")
+
+	a.handleWorkspaceFlipScoreRecomputeJobs(rr, req, "workspace-1", nil)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusAccepted, rr.Body.String())
+	}
+	var resp flipScoreRecomputeJobResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Job.ID != "job-1" || resp.Job.TotalItems != 3 || resp.Job.Status != FlipScoreJobStatusQueued {
+		t.Fatalf("unexpected job: %+v", resp.Job)
+	}
+}
+
+func TestQueueProspectFlipScoreAppendsToActiveJob(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, FlipScoreJobStatusRunning).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-0"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO flip_score_recompute_job_items")).
+		WithArgs("job-0", "prospect-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE flip_score_recompute_jobs SET total_items = total_items + 1")).
+		WithArgs("job-0").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	jobID, err := a.queueProspectFlipScore(context.Background(), "workspace-1", "prospect-1", "user-1")
+	if err != nil {
+		t.Fatalf("queueProspectFlipScore: %v", err)
+	}
+	if jobID != "job-0" {
+		t.Fatalf("job_id=%s want=job-0", jobID)
+	}
+}
+
+func TestQueueProspectFlipScoreJoinsConcurrentlyCreatedJob(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	// Another request creates the active job between the lookup and the insert
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, FlipScoreJobStatusRunning).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, "user-1").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_flip_score_recompute_jobs_active"})
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, FlipScoreJobStatusRunning).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-2"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO flip_score_recompute_job_items")).
+		WithArgs("job-2", "prospect-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE flip_score_recompute_jobs SET total_items = total_items + 1")).
+		WithArgs("job-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	jobID, err := a.queueProspectFlipScore(context.Background(), "workspace-1", "prospect-1", "user-1")
+	if err != nil {
+		t.Fatalf("queueProspectFlipScore: %v", err)
+	}
+	if jobID != "job-2" {
+		t.Fatalf("job_id=%s want=job-2", jobID)
+	}
+}
+
+func TestCreateFlipScoreRecomputeJobRejectsUnknownVersion(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/workspaces/workspace-1/flip-score-recompute-jobs",
+		`{"force_version":"v1"}`, "user-1")
+
+	a.handleWorkspaceFlipScoreRecomputeJobs(rr, req, "workspace-1", nil)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+}
+
+func TestGetFlipScoreRecomputeJobReportsProgressAndFailures(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	processedAt := time.Date(2026, 3, 1, 12, 5, 0, 0, time.UTC)
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM flip_score_recompute_jobs")).
+		WithArgs("job-1", "workspace-1").
+		WillReturnRows(flipScoreJobRows("job-1", "workspace-1", FlipScoreJobStatusRunning, 4, 2, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM flip_score_recompute_job_items")).
+		WithArgs("job-1", flipScoreJobItemFailed).
+		WillReturnRows(sqlmock.NewRows([]string{"prospect_id", "error_message", "processed_at"}).
+			AddRow("prospect-9", "risk assessment: timeout", processedAt))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodGet, "/api/v1/workspaces/workspace-1/flip-score-recompute-jobs/job-1", "", "user-1")
+
+	a.handleWorkspaceFlipScoreRecomputeJobs(rr, req, "workspace-1", []string{"job-1"})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp flipScoreRecomputeJobResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Job.Progress != 0.5 {
+		t.Fatalf("progress=%v want=0.5", resp.Job.Progress)
+	}
+	if len(resp.Failures) != 1 || resp.Failures[0].ProspectID != "prospect-9" {
+		t.Fatalf("unexpected failures: %+v", resp.Failures)
+	}
+}
+
+func TestCompleteFlipScoreRecomputeJobResyncsDeletedItems(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	// Two of the five items were cascaded away with their prospects
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM flip_score_recompute_jobs WHERE id = $1 FOR UPDATE")).
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-1"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM flip_score_recompute_job_items")).
+		WithArgs("job-1", flipScoreJobItemPending, flipScoreJobItemFailed).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "pending", "failed"}).AddRow(3, 0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE flip_score_recompute_jobs")).
+		WithArgs("job-1", FlipScoreJobStatusCompleted, 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	done, err := a.completeFlipScoreRecomputeJob(context.Background(), "job-1")
+	if err != nil || !done {
+		t.Fatalf("done=%v err=%v", done, err)
+	}
+
+	// Deleted with its workspace
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM flip_score_recompute_jobs WHERE id = $1 FOR UPDATE")).
+		WithArgs("job-2").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	if _, err := a.completeFlipScoreRecomputeJob(context.Background(), "job-2"); !errors.Is(err, errFlipScoreJobGone) {
+		t.Fatalf("err=%v want=%v", err, errFlipScoreJobGone)
+	}
+}
+
+func TestRecordFlipScoreRecomputeItemCountsOnce(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	score := 72
+	item := flipScoreRecomputeItemResult{Score: &score, RiskSource: flipScoreRiskSourceLLM, LLMCalled: true}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE flip_score_recompute_job_items")).
+		WithArgs("job-1", "prospect-1", flipScoreJobItemDone, &score, nil, sqlmock.AnyArg(), nil, flipScoreJobItemPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET processed_items = processed_items + 1")).
+		WithArgs("job-1", 0, 1, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Already recorded by an earlier run: the job counters are left alone
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE flip_score_recompute_job_items")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := recordFlipScoreRecomputeItem(context.Background(), tx, "job-1", "prospect-1", item); err != nil {
+			t.Fatalf("recordFlipScoreRecomputeItem: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+func TestCachedRiskAssessmentRequiresUnchangedListingText(t *testing.T) {
+	text := "Apartamento reformado, documentação ok"
+	hash := listingTextHash(text)
+	breakdown := json.RawMessage(`{"risk_assessment":{"rehab_level":"light","llm_confidence":0.8,"red_flags":[],"missing_critical":[]}}`)
+
+	p := &prospect{ListingText: &text, FlipScoreListingHash: &hash, FlipScoreBreakdown: &breakdown}
+	cached := cachedRiskAssessment(p)
+	if cached == nil || cached.LLMConfidence != 0.8 {
+		t.Fatalf("expected cached assessment, got %+v", cached)
+	}
+
+	changed := text + " (atualizado)"
+	p.ListingText = &changed
+	if got := cachedRiskAssessment(p); got != nil {
+		t.Fatalf("expected no cache after listing text change, got %+v", got)
+	}
+
+	p.ListingText = &text
+	p.FlipScoreListingHash = nil
+	if got := cachedRiskAssessment(p); got != nil {
+		t.Fatalf("expected no cache without listing hash, got %+v", got)
+	}
+}
@@ -0,0 +1,227 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/flipscore"
+)
+
+type flipScoreProfileResponse struct {
+	WorkspaceID string                   `json:"workspace_id"`
+	Profile     flipscore.ScoringProfile `json:"profile"`
+	ProfileHash string                   `json:"profile_hash"`
+	IsDefault   bool                     `json:"is_default"`
+	UpdatedAt   *time.Time               `json:"updated_at"`
+}
+
+type updateFlipScoreProfileRequest struct {
+	Name            *string              `json:"name"`
+	WeightsV0       *flipscore.WeightsV0 `json:"weights_v0"`
+	WeightsV1       *flipscore.WeightsV1 `json:"weights_v1"`
+	CategoryWeights map[string]float64   `json:"category_weights"`
+	RehabPenalties  map[string]float64   `json:"rehab_penalties"`
+}
+
+// handleWorkspaceFlipScoreProfile routes /api/v1/workspaces/:id/flip-score-profile
+func (a *api) handleWorkspaceFlipScoreProfile(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	if ok, err := a.hasWorkspaceMembership(r.Context(), workspaceID, userID); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check membership"})
+		return
+	} else if !ok {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "workspace not found"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a.handleGetFlipScoreProfile(w, r, workspaceID)
+	case http.MethodPut:
+		a.handleUpdateFlipScoreProfile(w, r, workspaceID)
+	case http.MethodDelete:
+		a.handleResetFlipScoreProfile(w, r, workspaceID)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, apiError{Code: "METHOD_NOT_ALLOWED", Message: "method not allowed"})
+	}
+}
+
+func (a *api) handleGetFlipScoreProfile(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	profile, updatedAt, err := a.loadWorkspaceScoringProfile(r.Context(), workspaceID)
+	if err != nil && err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch flip score profile"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, flipScoreProfileResponse{
+		WorkspaceID: workspaceID,
+		Profile:     profile,
+		ProfileHash: profile.Hash(),
+		IsDefault:   err == sql.ErrNoRows,
+		UpdatedAt:   updatedAt,
+	})
+}
+
+// handleUpdateFlipScoreProfile merges the request over the current profile (or the default),
+// validates it and stores it. Category weights and rehab penalties can be partial.
+func (a *api) handleUpdateFlipScoreProfile(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	var req updateFlipScoreProfileRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+
+	profile, _, err := a.loadWorkspaceScoringProfile(r.Context(), workspaceID)
+	if err != nil && err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch flip score profile"})
+		return
+	}
+	if err == sql.ErrNoRows {
+		profile.Name = "custom"
+	}
+
+	if req.Name != nil {
+		profile.Name = *req.Name
+	}
+	if req.WeightsV0 != nil {
+		profile.WeightsV0 = *req.WeightsV0
+	}
+	if req.WeightsV1 != nil {
+		profile.WeightsV1 = *req.WeightsV1
+	}
+	for category, weight := range req.CategoryWeights {
+		profile.CategoryWeights[category] = weight
+	}
+	for level, penalty := range req.RehabPenalties {
+		profile.RehabPenalties[level] = penalty
+	}
+	if profile.Name == "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "name must not be empty"})
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+		return
+	}
+
+	categoryWeights, _ := json.Marshal(profile.CategoryWeights)
+	rehabPenalties, _ := json.Marshal(profile.RehabPenalties)
+	hash := profile.Hash()
+
+	var updatedAt time.Time
+	err = a.db.QueryRowContext(
+		r.Context(),
+		`INSERT INTO flip_score_profiles (
+			workspace_id, name,
+			weight_price, weight_carry, weight_liquidity, weight_risk,
+			weight_v1_econ, weight_v1_liquidity, weight_v1_risk,
+			category_weights, rehab_penalties, profile_hash
+		 ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 ON CONFLICT (workspace_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			weight_price = EXCLUDED.weight_price,
+			weight_carry = EXCLUDED.weight_carry,
+			weight_liquidity = EXCLUDED.weight_liquidity,
+			weight_risk = EXCLUDED.weight_risk,
+			weight_v1_econ = EXCLUDED.weight_v1_econ,
+			weight_v1_liquidity = EXCLUDED.weight_v1_liquidity,
+			weight_v1_risk = EXCLUDED.weight_v1_risk,
+			category_weights = EXCLUDED.category_weights,
+			rehab_penalties = EXCLUDED.rehab_penalties,
+			profile_hash = EXCLUDED.profile_hash,
+			updated_at = now()
+		 RETURNING updated_at`,
+		workspaceID, profile.Name,
+		profile.WeightsV0.Price, profile.WeightsV0.Carry, profile.WeightsV0.Liquidity, profile.WeightsV0.Risk,
+		profile.WeightsV1.Econ, profile.WeightsV1.Liquidity, profile.WeightsV1.Risk,
+		categoryWeights, rehabPenalties, hash,
+	).Scan(&updatedAt)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to save flip score profile"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, flipScoreProfileResponse{
+		WorkspaceID: workspaceID,
+		Profile:     profile,
+		ProfileHash: hash,
+		IsDefault:   false,
+		UpdatedAt:   &updatedAt,
+	})
+}
+
+// handleResetFlipScoreProfile deletes the custom profile so the workspace uses the default again
+func (a *api) handleResetFlipScoreProfile(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	if _, err := a.db.ExecContext(r.Context(), `DELETE FROM flip_score_profiles WHERE workspace_id = $1`, workspaceID); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to reset flip score profile"})
+		return
+	}
+
+	profile := flipscore.DefaultProfile()
+	writeJSON(w, http.StatusOK, flipScoreProfileResponse{
+		WorkspaceID: workspaceID,
+		Profile:     profile,
+		ProfileHash: profile.Hash(),
+		IsDefault:   true,
+	})
+}
+
+// getWorkspaceScoringProfile returns the workspace scoring profile, or the default profile
+// when the workspace has not customized it
+func (a *api) getWorkspaceScoringProfile(ctx context.Context, workspaceID string) (flipscore.ScoringProfile, error) {
+	profile, _, err := a.loadWorkspaceScoringProfile(ctx, workspaceID)
+	if err != nil && err != sql.ErrNoRows {
+		return flipscore.ScoringProfile{}, err
+	}
+	return profile, nil
+}
+
+// loadWorkspaceScoringProfile reads the stored profile merged over the defaults.
+// On sql.ErrNoRows it returns the default profile together with the error.
+func (a *api) loadWorkspaceScoringProfile(ctx context.Context, workspaceID string) (flipscore.ScoringProfile, *time.Time, error) {
+	var profile flipscore.ScoringProfile
+	var categoryWeights, rehabPenalties []byte
+	var updatedAt time.Time
+	err := a.db.QueryRowContext(
+		ctx,
+		`SELECT name,
+		        weight_price, weight_carry, weight_liquidity, weight_risk,
+		        weight_v1_econ, weight_v1_liquidity, weight_v1_risk,
+		        category_weights, rehab_penalties, updated_at
+		 FROM flip_score_profiles
+		 WHERE workspace_id = $1`,
+		workspaceID,
+	).Scan(
+		&profile.Name,
+		&profile.WeightsV0.Price, &profile.WeightsV0.Carry, &profile.WeightsV0.Liquidity, &profile.WeightsV0.Risk,
+		&profile.WeightsV1.Econ, &profile.WeightsV1.Liquidity, &profile.WeightsV1.Risk,
+		&categoryWeights, &rehabPenalties, &updatedAt,
+	)
+	if err != nil {
+		return flipscore.DefaultProfile(), nil, err
+	}
+
+	if len(categoryWeights) > 0 {
+		if err := json.Unmarshal(categoryWeights, &profile.CategoryWeights); err != nil {
+			return flipscore.ScoringProfile{}, nil, err
+		}
+	}
+	if len(rehabPenalties) > 0 {
+		if err := json.Unmarshal(rehabPenalties, &profile.RehabPenalties); err != nil {
+			return flipscore.ScoringProfile{}, nil, err
+		}
+	}
+
+	return profile.WithDefaults(), &updatedAt, nil
+}
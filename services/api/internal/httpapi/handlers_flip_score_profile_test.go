@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/flipscore"
+)
+
+func expectWorkspaceMembership(mock sqlmock.Sqlmock, workspaceID, userID string) {
+	mock.ExpectQuery(regexp.QuoteMeta("FROM workspace_memberships")).
+		WithArgs(workspaceID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+}
+
+func TestGetFlipScoreProfileDefaultsWhenNotCustomized(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM flip_score_profiles")).
+		WithArgs("workspace-1").
+		WillReturnError(sql.ErrNoRows)
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodGet, "/api/v1/workspaces/workspace-1/flip-score-profile", "", "user-1")
+
+	a.handleWorkspaceFlipScoreProfile(rr, req, "workspace-1")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp flipScoreProfileResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.IsDefault || resp.ProfileHash != flipscore.DefaultProfile().Hash() {
+		t.Fatalf("is_default=%v profile_hash=%s", resp.IsDefault, resp.ProfileHash)
+	}
+}
+
+func TestUpdateFlipScoreProfileRejectsWeightsNotSummingToOne(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM flip_score_profiles")).
+		WithArgs("workspace-1").
+		WillReturnError(sql.ErrNoRows)
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPut, "/api/v1/workspaces/workspace-1/flip-score-profile",
+		`{"weights_v1":{"econ":0.5,"liquidity":0.2,"risk":0.2}}`, "user-1")
+
+	a.handleWorkspaceFlipScoreProfile(rr, req, "workspace-1")
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+	if got := decodeAPIErrorCode(t, rr); got != "VALIDATION_ERROR" {
+		t.Fatalf("error.code=%s want=VALIDATION_ERROR", got)
+	}
+}
+
+func TestUpdateFlipScoreProfileMergesPartialPenalties(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM flip_score_profiles")).
+		WithArgs("workspace-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO flip_score_profiles")).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPut, "/api/v1/workspaces/workspace-1/flip-score-profile",
+		`{"name":"studios","weights_v1":{"econ":0.5,"liquidity":0.3,"risk":0.2},"rehab_penalties":{"heavy":25}}`, "user-1")
+
+	a.handleWorkspaceFlipScoreProfile(rr, req, "workspace-1")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp flipScoreProfileResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Profile.RehabPenalties["heavy"] != 25 || resp.Profile.RehabPenalties["medium"] != 8 {
+		t.Fatalf("rehab_penalties=%v want heavy=25 medium=8", resp.Profile.RehabPenalties)
+	}
+	if resp.ProfileHash == flipscore.DefaultProfile().Hash() {
+		t.Fatal("expected a profile hash different from the default")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package httpapi
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetMarketStatsFallsBackToGeralClass(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	neighborhood := "Vl. Mariana"
+	condoFee := 900.0
+	p := &prospect{ID: "prospect-1", WorkspaceID: "workspace-1", Neighborhood: &neighborhood, CondoFee: &condoFee}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT market_city FROM workspace_settings")).
+		WithArgs("workspace-1").
+		WillReturnRows(sqlmock.NewRows([]string{"market_city"}).AddRow("sp"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_region_aliases")).
+		WithArgs("sp").
+		WillReturnRows(sqlmock.NewRows([]string{"alias_normalized", "canonical_name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_price_m2_aggregates a")).
+		WithArgs("sp", flipScoreMarketPeriodMonths, "apartamento", "VILA MARIANA", "VL MARIANA").
+		WillReturnRows(sqlmock.NewRows([]string{"as_of_month", "property_class", "median_m2", "p25_m2", "p75_m2", "tx_count"}).
+			AddRow("2026-08", "apartamento", 14000.0, 12000.0, 16000.0, 8).
+			AddRow("2026-08", "geral", 12000.0, 10000.0, 14000.0, 30).
+			AddRow("2026-08", "geral", 15000.0, 13000.0, 17000.0, 10))
+
+	stats := a.getMarketStats(context.Background(), p)
+	if stats == nil {
+		t.Fatal("expected market stats")
+	}
+	if stats.PropertyClass != "geral" || stats.TxCount != 40 {
+		t.Fatalf("class=%s tx_count=%d want=geral/40", stats.PropertyClass, stats.TxCount)
+	}
+	if stats.MedianM2 != 12750 {
+		t.Fatalf("median_m2=%.2f want=12750 (tx-weighted)", stats.MedianM2)
+	}
+	if stats.MatchConfidence != 1 || stats.Region != "Vila Mariana" {
+		t.Fatalf("match_confidence=%.2f region=%s", stats.MatchConfidence, stats.Region)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetMarketStatsUnresolvedNeighborhood(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	neighborhood := "não informado"
+	p := &prospect{ID: "prospect-1", Neighborhood: &neighborhood}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT market_city FROM workspace_settings")).
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows([]string{"market_city"}).AddRow("sp"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_region_aliases")).
+		WithArgs("sp").
+		WillReturnRows(sqlmock.NewRows([]string{"alias_normalized", "canonical_name"}))
+
+	if stats := a.getMarketStats(context.Background(), p); stats != nil {
+		t.Fatalf("expected nil stats, got %+v", stats)
+	}
+}
+
+func TestOfferMarketEvidenceUsesResolvedBairroSample(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	neighborhood := "Vl. Mariana"
+	condoFee := 900.0
+	p := offerProspectRecord{ID: "prospect-1", WorkspaceID: "workspace-1", Neighborhood: &neighborhood, CondoFee: &condoFee}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT market_city FROM workspace_settings")).
+		WithArgs("workspace-1").
+		WillReturnRows(sqlmock.NewRows([]string{"market_city"}).AddRow("sp"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_region_aliases")).
+		WithArgs("sp").
+		WillReturnRows(sqlmock.NewRows([]string{"alias_normalized", "canonical_name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_price_m2_aggregates a")).
+		WithArgs("sp", flipScoreMarketPeriodMonths, "apartamento", "VILA MARIANA", "VL MARIANA").
+		WillReturnRows(sqlmock.NewRows([]string{"as_of_month", "property_class", "median_m2", "p25_m2", "p75_m2", "tx_count"}).
+			AddRow("2026-08", "apartamento", 14000.0, 12000.0, 16000.0, 25))
+
+	evidence := a.offerMarketEvidence(context.Background(), p)
+	if evidence == nil || !evidence.Resolved {
+		t.Fatalf("expected resolved market evidence, got %+v", evidence)
+	}
+	if evidence.TxCount != 25 || evidence.P75M2 != 16000 || evidence.Region != "Vila Mariana" {
+		t.Fatalf("unexpected evidence: %+v", evidence)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestOfferMarketEvidenceUnresolvedNeighborhood(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	neighborhood := "não informado"
+	p := offerProspectRecord{ID: "prospect-1", Neighborhood: &neighborhood}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT market_city FROM workspace_settings")).
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows([]string{"market_city"}).AddRow("sp"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_region_aliases")).
+		WithArgs("sp").
+		WillReturnRows(sqlmock.NewRows([]string{"alias_normalized", "canonical_name"}))
+
+	evidence := a.offerMarketEvidence(context.Background(), p)
+	if evidence == nil || evidence.Resolved || evidence.TxCount != 0 {
+		t.Fatalf("expected unresolved market evidence, got %+v", evidence)
+	}
+}
+
+func TestGetMarketStatsUsesProspectCity(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	neighborhood := "Centro"
+	address := "Rua XV de Novembro, 100 - Centro, Curitiba - PR"
+	p := &prospect{ID: "prospect-1", WorkspaceID: "workspace-1", Neighborhood: &neighborhood, Address: &address}
+
+	// The address names the city, so the workspace default is not read
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_region_aliases")).
+		WithArgs("curitiba").
+		WillReturnRows(sqlmock.NewRows([]string{"alias_normalized", "canonical_name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_price_m2_aggregates a")).
+		WithArgs("curitiba", flipScoreMarketPeriodMonths, "geral", "CENTRO").
+		WillReturnRows(sqlmock.NewRows([]string{"as_of_month", "property_class", "median_m2", "p25_m2", "p75_m2", "tx_count"}).
+			AddRow("2026-08", "geral", 8000.0, 7000.0, 9000.0, 40))
+
+	if stats := a.getMarketStats(context.Background(), p); stats == nil || stats.MedianM2 != 8000 {
+		t.Fatalf("stats=%+v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+
+	// Without a city in the address the workspace market city applies
+	p.Address = nil
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT market_city FROM workspace_settings")).
+		WithArgs("workspace-1").
+		WillReturnRows(sqlmock.NewRows([]string{"market_city"}).AddRow("curitiba"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_region_aliases")).
+		WithArgs("curitiba").
+		WillReturnRows(sqlmock.NewRows([]string{"alias_normalized", "canonical_name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM market_price_m2_aggregates a")).
+		WithArgs("curitiba", flipScoreMarketPeriodMonths, "geral", "CENTRO").
+		WillReturnRows(sqlmock.NewRows([]string{"as_of_month", "property_class", "median_m2", "p25_m2", "p75_m2", "tx_count"}))
+
+	if stats := a.getMarketStats(context.Background(), p); stats != nil {
+		t.Fatalf("expected no curitiba data, got %+v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -13,12 +13,15 @@ import (
 	"unicode"
 
 	"golang.org/x/text/unicode/norm"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/marketingest"
 )
 
 var (
 	validMarketPeriods     = map[int]struct{}{1: {}, 3: {}, 6: {}, 12: {}}
 	validMarketClass       = map[string]struct{}{"geral": {}, "apartamento": {}, "casa": {}, "outros": {}}
 	marketWhitespaceRE     = regexp.MustCompile(`\s+`)
+	marketCitySlugRE       = regexp.MustCompile(`^[a-z][a-z0-9-]{1,39}$`)
 	marketAliasToCanonical = map[string]string{
 		"JD":  "JARDIM",
 		"J":   "JARDIM",
@@ -42,13 +45,21 @@ var (
 	}
 )
 
+type marketCityOption struct {
+	City        string  `json:"city"`
+	Name        string  `json:"name"`
+	State       *string `json:"state"`
+	LatestMonth string  `json:"latest_month"`
+}
+
 type marketFiltersResponse struct {
-	City            string   `json:"city"`
-	Source          string   `json:"source"`
-	AvailableMonths []string `json:"available_months"`
-	PeriodOptions   []int    `json:"period_options"`
-	PropertyClasses []string `json:"property_classes"`
-	UpdatedAt       *string  `json:"updated_at"`
+	City            string             `json:"city"`
+	Source          string             `json:"source"`
+	AvailableCities []marketCityOption `json:"available_cities"`
+	AvailableMonths []string           `json:"available_months"`
+	PeriodOptions   []int              `json:"period_options"`
+	PropertyClasses []string           `json:"property_classes"`
+	UpdatedAt       *string            `json:"updated_at"`
 }
 
 type marketPriceItem struct {
@@ -135,12 +146,9 @@ func (a *api) handlePublicMarketSubroutes(w http.ResponseWriter, r *http.Request
 }
 
 func (a *api) handlePublicMarketFilters(w http.ResponseWriter, r *http.Request) {
-	city := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("city")))
-	if city == "" {
-		city = "sp"
-	}
-	if city != "sp" {
-		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "city must be sp"})
+	city, err := parseMarketCity(r.URL.Query().Get("city"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
 		return
 	}
 
@@ -167,6 +175,12 @@ func (a *api) handlePublicMarketFilters(w http.ResponseWriter, r *http.Request)
 		months = append(months, m)
 	}
 
+	cities, err := a.listMarketCities(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to query market cities"})
+		return
+	}
+
 	var source sql.NullString
 	var updatedAt sql.NullTime
 	_ = a.db.QueryRowContext(r.Context(), `
@@ -187,6 +201,7 @@ func (a *api) handlePublicMarketFilters(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, marketFiltersResponse{
 		City:            city,
 		Source:          source.String,
+		AvailableCities: cities,
 		AvailableMonths: months,
 		PeriodOptions:   []int{1, 3, 6, 12},
 		PropertyClasses: []string{"geral", "apartamento", "casa", "outros"},
@@ -197,12 +212,9 @@ func (a *api) handlePublicMarketFilters(w http.ResponseWriter, r *http.Request)
 func (a *api) handlePublicMarketPriceM2(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
-	city := strings.ToLower(strings.TrimSpace(q.Get("city")))
-	if city == "" {
-		city = "sp"
-	}
-	if city != "sp" {
-		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "city must be sp"})
+	city, err := parseMarketCity(q.Get("city"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
 		return
 	}
 
@@ -224,7 +236,7 @@ func (a *api) handlePublicMarketPriceM2(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	minTxCount, err := parsePositiveInt(q.Get("min_tx_count"), 15)
+	minTxCount, err := parsePositiveInt(q.Get("min_tx_count"), marketingest.DefaultMinTxCount)
 	if err != nil || minTxCount < 1 || minTxCount > 500 {
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "min_tx_count must be between 1 and 500"})
 		return
@@ -403,12 +415,9 @@ func (a *api) handlePublicMarketPriceM2(w http.ResponseWriter, r *http.Request)
 func (a *api) handlePublicMarketSeries(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
-	city := strings.ToLower(strings.TrimSpace(q.Get("city")))
-	if city == "" {
-		city = "sp"
-	}
-	if city != "sp" {
-		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "city must be sp"})
+	city, err := parseMarketCity(q.Get("city"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
 		return
 	}
 
@@ -591,6 +600,49 @@ func (a *api) handlePublicMarketSeries(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// parseMarketCity accepts any city slug; cities without aggregates simply return no data
+func parseMarketCity(raw string) (string, error) {
+	city := strings.ToLower(strings.TrimSpace(raw))
+	if city == "" {
+		return marketingest.DefaultCity, nil
+	}
+	if !marketCitySlugRE.MatchString(city) {
+		return "", fmt.Errorf("city must be a slug like sp or curitiba")
+	}
+	return city, nil
+}
+
+// listMarketCities returns the cities with published aggregates, named after their
+// ingestion profile when one is registered
+func (a *api) listMarketCities(r *http.Request) ([]marketCityOption, error) {
+	rows, err := a.db.QueryContext(r.Context(), `
+		SELECT city, TO_CHAR(MAX(as_of_month), 'YYYY-MM') AS latest_month
+		FROM market_price_m2_aggregates
+		GROUP BY city
+		ORDER BY city
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cities := make([]marketCityOption, 0, 4)
+	for rows.Next() {
+		var option marketCityOption
+		if err := rows.Scan(&option.City, &option.LatestMonth); err != nil {
+			return nil, err
+		}
+		option.Name = strings.ToUpper(option.City)
+		if profile, ok := marketingest.LookupCityProfile(option.City); ok {
+			option.Name = profile.Name
+			state := profile.State
+			option.State = &state
+		}
+		cities = append(cities, option)
+	}
+	return cities, rows.Err()
+}
+
 func parseMarketMonth(raw string) (time.Time, error) {
 	v := strings.TrimSpace(raw)
 	if v == "" {
@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -76,3 +77,58 @@ func TestHandlePublicMarketSeriesNotFound(t *testing.T) {
 		t.Fatalf("sql expectations: %v", err)
 	}
 }
+
+func TestHandlePublicMarketFiltersListsCitiesWithData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	updatedAt := time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("GROUP BY as_of_month").
+		WithArgs("curitiba").
+		WillReturnRows(sqlmock.NewRows([]string{"as_of_month"}).AddRow("2025-12").AddRow("2025-11"))
+	mock.ExpectQuery("GROUP BY city").
+		WillReturnRows(sqlmock.NewRows([]string{"city", "latest_month"}).
+			AddRow("curitiba", "2025-12").
+			AddRow("sp", "2026-01"))
+	mock.ExpectQuery("GROUP BY source").
+		WithArgs("curitiba").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "max"}).AddRow("itbi_curitiba", updatedAt))
+
+	a := &api{db: db}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/public/market/filters?city=Curitiba", nil)
+	rr := httptest.NewRecorder()
+
+	a.handlePublicMarketFilters(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp marketFiltersResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.City != "curitiba" || resp.Source != "itbi_curitiba" || len(resp.AvailableMonths) != 2 {
+		t.Fatalf("resp=%+v", resp)
+	}
+	if len(resp.AvailableCities) != 2 || resp.AvailableCities[0].Name != "Curitiba" || resp.AvailableCities[1].Name != "São Paulo" {
+		t.Fatalf("cities=%+v", resp.AvailableCities)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestHandlePublicMarketPriceM2RejectsInvalidCity(t *testing.T) {
+	a := &api{}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/public/market/price-m2?city=s%C3%A3o%20paulo&as_of_month=2025-12", nil)
+	rr := httptest.NewRecorder()
+
+	a.handlePublicMarketPriceM2(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+}
@@ -0,0 +1,193 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/offerintelligence"
+)
+
+type offerDecisionRulesResponse struct {
+	WorkspaceID  string                                    `json:"workspace_id"`
+	Rules        []offerintelligence.DecisionRule          `json:"rules"`
+	RulesHash    string                                    `json:"rules_hash"`
+	IsDefault    bool                                      `json:"is_default"`
+	Version      int                                       `json:"version"` // incremented on every change
+	UpdatedAt    *time.Time                                `json:"updated_at"`
+	DefaultRules []offerintelligence.DecisionRule          `json:"default_rules"`
+	Variables    map[string]offerintelligence.RuleVariable `json:"variables"`
+}
+
+type updateOfferDecisionRulesRequest struct {
+	Rules []offerintelligence.DecisionRule `json:"rules"`
+}
+
+// handleWorkspaceOfferDecisionRules routes /api/v1/workspaces/:id/offer-decision-rules
+func (a *api) handleWorkspaceOfferDecisionRules(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+	if !a.enforceOfferRollout(w, r, userID) {
+		return
+	}
+
+	if ok, err := a.hasWorkspaceMembership(r.Context(), workspaceID, userID); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check membership"})
+		return
+	} else if !ok {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "workspace not found"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a.handleGetOfferDecisionRules(w, r, workspaceID)
+	case http.MethodPut:
+		a.handleUpdateOfferDecisionRules(w, r, workspaceID)
+	case http.MethodDelete:
+		a.handleResetOfferDecisionRules(w, r, workspaceID)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, apiError{Code: "METHOD_NOT_ALLOWED", Message: "method not allowed"})
+	}
+}
+
+func (a *api) handleGetOfferDecisionRules(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	var (
+		rulesJSON []byte
+		version   int
+		updatedAt sql.NullTime
+	)
+	err := a.db.QueryRowContext(
+		r.Context(),
+		`SELECT offer_decision_rules_json, offer_decision_rules_version, offer_decision_rules_updated_at
+		 FROM workspace_settings
+		 WHERE workspace_id = $1`,
+		workspaceID,
+	).Scan(&rulesJSON, &version, &updatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch decision rules"})
+		return
+	}
+
+	var rules []offerintelligence.DecisionRule
+	if len(rulesJSON) > 0 {
+		if err := json.Unmarshal(rulesJSON, &rules); err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "INTERNAL_ERROR", Message: "failed to decode decision rules"})
+			return
+		}
+	}
+
+	var updated *time.Time
+	if updatedAt.Valid {
+		updated = &updatedAt.Time
+	}
+	writeJSON(w, http.StatusOK, newOfferDecisionRulesResponse(workspaceID, rules, version, updated))
+}
+
+// handleUpdateOfferDecisionRules replaces the workspace rule set. The full list is sent
+// each time; a set identical to the defaults is stored as the default (NULL).
+func (a *api) handleUpdateOfferDecisionRules(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	var req updateOfferDecisionRulesRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+
+	if err := offerintelligence.ValidateDecisionRules(req.Rules); err != nil {
+		var verr offerintelligence.DecisionRulesValidationError
+		if errors.As(err, &verr) {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid decision rules", Details: verr.Problems})
+			return
+		}
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+		return
+	}
+
+	var rulesJSON []byte
+	if !offerintelligence.IsDefaultDecisionRules(req.Rules) {
+		encoded, err := json.Marshal(req.Rules)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "INTERNAL_ERROR", Message: "failed to encode decision rules"})
+			return
+		}
+		rulesJSON = encoded
+	}
+
+	a.saveOfferDecisionRules(w, r, workspaceID, rulesJSON)
+}
+
+// handleResetOfferDecisionRules drops the custom rules so the workspace uses the defaults again
+func (a *api) handleResetOfferDecisionRules(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	a.saveOfferDecisionRules(w, r, workspaceID, nil)
+}
+
+func (a *api) saveOfferDecisionRules(w http.ResponseWriter, r *http.Request, workspaceID string, rulesJSON []byte) {
+	version, updatedAt, err := a.updateOfferDecisionRules(r.Context(), workspaceID, rulesJSON)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "workspace settings not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to save decision rules"})
+		return
+	}
+
+	var rules []offerintelligence.DecisionRule
+	if len(rulesJSON) > 0 {
+		_ = json.Unmarshal(rulesJSON, &rules)
+	}
+	writeJSON(w, http.StatusOK, newOfferDecisionRulesResponse(workspaceID, rules, version, &updatedAt))
+}
+
+func (a *api) updateOfferDecisionRules(ctx context.Context, workspaceID string, rulesJSON []byte) (int, time.Time, error) {
+	var rules any
+	if rulesJSON != nil {
+		rules = rulesJSON
+	}
+
+	var version int
+	var updatedAt time.Time
+	err := a.db.QueryRowContext(
+		ctx,
+		`UPDATE workspace_settings
+		 SET offer_decision_rules_json = $2,
+		     offer_decision_rules_version = offer_decision_rules_version + 1,
+		     offer_decision_rules_updated_at = NOW(),
+		     updated_at = NOW()
+		 WHERE workspace_id = $1
+		 RETURNING offer_decision_rules_version, offer_decision_rules_updated_at`,
+		workspaceID, rules,
+	).Scan(&version, &updatedAt)
+	return version, updatedAt, err
+}
+
+func newOfferDecisionRulesResponse(
+	workspaceID string,
+	rules []offerintelligence.DecisionRule,
+	version int,
+	updatedAt *time.Time,
+) offerDecisionRulesResponse {
+	isDefault := offerintelligence.IsDefaultDecisionRules(rules)
+	if isDefault {
+		rules = offerintelligence.DefaultDecisionRules()
+	}
+	return offerDecisionRulesResponse{
+		WorkspaceID:  workspaceID,
+		Rules:        rules,
+		RulesHash:    offerintelligence.DecisionRulesHash(rules),
+		IsDefault:    isDefault,
+		Version:      version,
+		UpdatedAt:    updatedAt,
+		DefaultRules: offerintelligence.DefaultDecisionRules(),
+		Variables:    offerintelligence.RuleVariables,
+	}
+}
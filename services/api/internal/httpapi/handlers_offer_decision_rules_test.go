@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/offerintelligence"
+)
+
+func TestGetOfferDecisionRulesReturnsDefaults(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT offer_decision_rules_json")).
+		WithArgs("workspace-1").
+		WillReturnRows(sqlmock.NewRows([]string{"offer_decision_rules_json", "offer_decision_rules_version", "offer_decision_rules_updated_at"}).
+			AddRow(nil, 0, nil))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodGet, "/api/v1/workspaces/workspace-1/offer-decision-rules", "", "user-1")
+
+	a.handleWorkspaceOfferDecisionRules(rr, req, "workspace-1")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp offerDecisionRulesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.IsDefault || len(resp.Rules) != len(offerintelligence.DefaultDecisionRules()) {
+		t.Fatalf("is_default=%v rules=%d", resp.IsDefault, len(resp.Rules))
+	}
+	if _, ok := resp.Variables["renovation_to_ask"]; !ok {
+		t.Fatalf("expected variables to list renovation_to_ask")
+	}
+}
+
+func TestUpdateOfferDecisionRulesRejectsInvalidRules(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPut, "/api/v1/workspaces/workspace-1/offer-decision-rules",
+		`{"rules":[{"id":"long_hold","when":"hold > 9","decision":"REVIEW"}]}`, "user-1")
+
+	a.handleWorkspaceOfferDecisionRules(rr, req, "workspace-1")
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+	if got := decodeAPIErrorCode(t, rr); got != "VALIDATION_ERROR" {
+		t.Fatalf("error.code=%s want=VALIDATION_ERROR", got)
+	}
+}
+
+func TestUpdateOfferDecisionRulesStoresCustomRules(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE workspace_settings")).
+		WithArgs("workspace-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"offer_decision_rules_version", "offer_decision_rules_updated_at"}).
+			AddRow(3, updatedAt))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPut, "/api/v1/workspaces/workspace-1/offer-decision-rules",
+		`{"rules":[{"id":"heavy_rehab_long_hold","when":"renovation_to_ask > 0.3 AND hold_months > 9","decision":"REVIEW","reason":"HEAVY_REHAB_LONG_HOLD","label":"Reforma pesada com prazo longo"}]}`, "user-1")
+
+	a.handleWorkspaceOfferDecisionRules(rr, req, "workspace-1")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp offerDecisionRulesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.IsDefault || resp.Version != 3 || len(resp.Rules) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
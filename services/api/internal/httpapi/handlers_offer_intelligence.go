@@ -18,6 +18,7 @@ import (
 	"github.com/widia-projects/widia-flip/services/api/internal/auth"
 	"github.com/widia-projects/widia-flip/services/api/internal/llm"
 	"github.com/widia-projects/widia-flip/services/api/internal/offerintelligence"
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
 )
 
 type offerIntelligenceGenerateRequest struct {
@@ -56,6 +57,10 @@ type offerIntelligencePreviewResponse struct {
 	Tier                string                                 `json:"tier"`
 	Limited             bool                                   `json:"limited"`
 	ConfidenceBreakdown offerintelligence.ConfidenceComponents `json:"confidence_breakdown"`
+	DecisionRules       []offerintelligence.RuleOutcome        `json:"decision_rules"`
+	Market              *offerintelligence.MarketCoverage      `json:"market,omitempty"`
+	AcquisitionMode     string                                 `json:"acquisition_mode,omitempty"`
+	Auction             *offerintelligence.AuctionSummary      `json:"auction,omitempty"`
 }
 
 type offerIntelligenceSaveResponse struct {
@@ -113,6 +118,8 @@ type offerProspectRecord struct {
 	BrokerName             *string
 	BrokerPhone            *string
 	FlipScore              *int
+	AcquisitionMode        string
+	AuctionTerms           *viability.AuctionTerms
 }
 
 type persistedOfferOutputs struct {
@@ -129,6 +136,10 @@ type persistedOfferOutputs struct {
 	InputHash           string                                 `json:"input_hash"`
 	SettingsHash        string                                 `json:"settings_hash"`
 	ConfidenceBreakdown offerintelligence.ConfidenceComponents `json:"confidence_breakdown"`
+	DecisionRules       []offerintelligence.RuleOutcome        `json:"decision_rules,omitempty"`
+	Market              *offerintelligence.MarketCoverage      `json:"market,omitempty"`
+	AcquisitionMode     string                                 `json:"acquisition_mode,omitempty"`
+	Auction             *offerintelligence.AuctionSummary      `json:"auction,omitempty"`
 }
 
 type persistedOfferInputs struct {
@@ -193,7 +204,7 @@ func (a *api) handleOfferIntelligenceGenerate(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	result, err := offerintelligence.Calculate(toOfferInputs(prospect), settings)
+	result, err := offerintelligence.Calculate(a.offerInputs(r.Context(), prospect), settings)
 	if err != nil {
 		if missing, ok := err.(offerintelligence.MissingCriticalInputsError); ok {
 			writeError(w, http.StatusBadRequest, apiError{
@@ -309,7 +320,7 @@ func (a *api) handleOfferIntelligenceSave(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	result, err := offerintelligence.Calculate(toOfferInputs(prospect), settings)
+	result, err := offerintelligence.Calculate(a.offerInputs(r.Context(), prospect), settings)
 	if err != nil {
 		if missing, ok := err.(offerintelligence.MissingCriticalInputsError); ok {
 			writeError(w, http.StatusBadRequest, apiError{
@@ -359,6 +370,10 @@ func (a *api) handleOfferIntelligenceSave(w http.ResponseWriter, r *http.Request
 		InputHash:           result.InputHash,
 		SettingsHash:        result.SettingsHash,
 		ConfidenceBreakdown: result.ConfidenceBreakdown,
+		DecisionRules:       result.DecisionRules,
+		Market:              result.Market,
+		AcquisitionMode:     result.AcquisitionMode,
+		Auction:             result.Auction,
 	})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "INTERNAL_ERROR", Message: "failed to encode outputs snapshot"})
@@ -507,7 +522,7 @@ func (a *api) handleOfferIntelligenceHistory(w http.ResponseWriter, r *http.Requ
 		cursorID = &id
 	}
 
-	currentInputHash, currentSettingsHash, err := computeCurrentOfferHashes(prospect, settings)
+	currentInputHash, currentSettingsHash, err := computeCurrentOfferHashes(prospect, settings, a.offerMarketEvidence(r.Context(), prospect))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "INTERNAL_ERROR", Message: "failed to compute current hashes"})
 		return
@@ -747,6 +762,7 @@ func (a *api) isUserAdmin(ctx context.Context, userID string) (bool, error) {
 
 func (a *api) getOfferProspect(ctx context.Context, prospectID string, userID string) (offerProspectRecord, error) {
 	row := offerProspectRecord{}
+	var auctionTerms []byte
 	err := a.db.QueryRowContext(ctx, `
 		SELECT
 			p.id,
@@ -765,7 +781,9 @@ func (a *api) getOfferProspect(ctx context.Context, prospectID string, userID st
 			p.agency,
 			p.broker_name,
 			p.broker_phone,
-			p.flip_score
+			p.flip_score,
+			p.acquisition_mode,
+			p.auction_terms_json
 		FROM prospecting_properties p
 		JOIN workspace_memberships m ON m.workspace_id = p.workspace_id
 		WHERE p.id = $1
@@ -789,15 +807,23 @@ func (a *api) getOfferProspect(ctx context.Context, prospectID string, userID st
 		&row.BrokerName,
 		&row.BrokerPhone,
 		&row.FlipScore,
+		&row.AcquisitionMode,
+		&auctionTerms,
 	)
+	row.AuctionTerms = decodeAuctionTerms(row.AcquisitionMode, auctionTerms)
 	return row, err
 }
 
 func (a *api) getOfferWorkspaceSettings(ctx context.Context, workspaceID string) (offerintelligence.WorkspaceSettings, bool, error) {
 	var (
 		weightsJSON           []byte
+		rulesJSON             []byte
 		consumedAt            sql.NullTime
 		consumedUserID        sql.NullString
+		taxRegime             string
+		taxSimplesRate        sql.NullFloat64
+		taxRETRate            sql.NullFloat64
+		taxPFReinvestedPct    sql.NullFloat64
 		settings              offerintelligence.WorkspaceSettings
 		defaultWeightsApplied bool
 	)
@@ -808,6 +834,10 @@ func (a *api) getOfferWorkspaceSettings(ctx context.Context, workspaceID string)
 			registry_rate,
 			broker_rate,
 			pj_tax_rate,
+			tax_regime,
+			tax_simples_rate,
+			tax_ret_rate,
+			tax_pf_reinvested_pct,
 			offer_min_margin_pct,
 			offer_min_net_profit_brl,
 			offer_min_confidence,
@@ -816,7 +846,8 @@ func (a *api) getOfferWorkspaceSettings(ctx context.Context, workspaceID string)
 			offer_max_sale_to_ask_ratio,
 			offer_generate_rate_limit_per_min,
 			offer_first_full_preview_consumed_at,
-			offer_first_full_preview_user_id
+			offer_first_full_preview_user_id,
+			offer_decision_rules_json
 		FROM workspace_settings
 		WHERE workspace_id = $1
 	`, workspaceID).Scan(
@@ -824,6 +855,10 @@ func (a *api) getOfferWorkspaceSettings(ctx context.Context, workspaceID string)
 		&settings.CashSettings.RegistryRate,
 		&settings.CashSettings.BrokerRate,
 		&settings.CashSettings.PJTaxRate,
+		&taxRegime,
+		&taxSimplesRate,
+		&taxRETRate,
+		&taxPFReinvestedPct,
 		&settings.MinMarginPct,
 		&settings.MinNetProfitBRL,
 		&settings.MinConfidence,
@@ -833,10 +868,12 @@ func (a *api) getOfferWorkspaceSettings(ctx context.Context, workspaceID string)
 		&settings.GenerateRateLimitPerMin,
 		&consumedAt,
 		&consumedUserID,
+		&rulesJSON,
 	)
 	if err != nil {
 		return offerintelligence.WorkspaceSettings{}, false, err
 	}
+	settings.CashSettings.Tax = taxSettingsFromColumns(taxRegime, taxSimplesRate, taxRETRate, taxPFReinvestedPct)
 
 	weights := offerintelligence.DefaultWeights()
 	if len(weightsJSON) > 0 {
@@ -854,6 +891,13 @@ func (a *api) getOfferWorkspaceSettings(ctx context.Context, workspaceID string)
 		defaultWeightsApplied = true
 	}
 	settings.ConfidenceWeights = weights
+	if len(rulesJSON) > 0 {
+		// Rules are validated on save; anything unreadable falls back to the defaults in Calculate
+		var rules []offerintelligence.DecisionRule
+		if unmarshalErr := json.Unmarshal(rulesJSON, &rules); unmarshalErr == nil {
+			settings.DecisionRules = rules
+		}
+	}
 	if settings.GenerateRateLimitPerMin <= 0 {
 		settings.GenerateRateLimitPerMin = 10
 	}
@@ -1012,6 +1056,10 @@ func buildOfferPreviewResponse(
 		Tier:                tier,
 		Limited:             limited,
 		ConfidenceBreakdown: result.ConfidenceBreakdown,
+		DecisionRules:       result.DecisionRules,
+		Market:              result.Market,
+		AcquisitionMode:     result.AcquisitionMode,
+		Auction:             result.Auction,
 	}
 }
 
@@ -1030,7 +1078,48 @@ func toOfferInputs(p offerProspectRecord) offerintelligence.ProspectInputs {
 		OfferPrice:             p.OfferPrice,
 		Neighborhood:           p.Neighborhood,
 		FlipScore:              p.FlipScore,
+		Auction:                p.AuctionTerms,
+	}
+}
+
+// offerInputs maps the prospect to engine inputs together with its bairro market evidence
+func (a *api) offerInputs(ctx context.Context, p offerProspectRecord) offerintelligence.ProspectInputs {
+	inputs := toOfferInputs(p)
+	inputs.Market = a.offerMarketEvidence(ctx, p)
+	return inputs
+}
+
+// offerMarketEvidence looks up the ITBI transactions for the prospect's bairro. It returns
+// nil when the market lookup fails, so the engine falls back to the legacy heuristic.
+func (a *api) offerMarketEvidence(ctx context.Context, p offerProspectRecord) *offerintelligence.MarketEvidence {
+	if p.Neighborhood == nil || strings.TrimSpace(*p.Neighborhood) == "" {
+		return &offerintelligence.MarketEvidence{}
+	}
+
+	city := a.prospectMarketCity(ctx, p.WorkspaceID, p.Address)
+	match, stats, err := a.lookupMarketStats(ctx, city, *p.Neighborhood, marketPropertyClass(p.CondoFee, nil, nil))
+	if err != nil {
+		log.Printf("offer_market_query_error prospect_id=%s error=%v", p.ID, err)
+		return nil
+	}
+	if match.Canonical == "" {
+		return &offerintelligence.MarketEvidence{}
+	}
+
+	evidence := &offerintelligence.MarketEvidence{
+		Resolved:        true,
+		Region:          humanizeRegionName(match.Canonical, *p.Neighborhood),
+		MatchMethod:     match.Method,
+		MatchConfidence: match.Confidence,
+	}
+	if stats != nil {
+		evidence.PropertyClass = stats.PropertyClass
+		evidence.AsOfMonth = stats.AsOfMonth
+		evidence.TxCount = stats.TxCount
+		evidence.MedianM2 = stats.MedianM2
+		evidence.P75M2 = stats.P75M2
 	}
+	return evidence
 }
 
 func decodeOptionalJSON[T any](r *http.Request, target *T) error {
@@ -1183,6 +1272,7 @@ func deterministicAgencyReference(agency *string) string {
 func computeCurrentOfferHashes(
 	prospect offerProspectRecord,
 	settings offerintelligence.WorkspaceSettings,
+	market *offerintelligence.MarketEvidence,
 ) (string, string, error) {
 	holdMonths := 6
 	if prospect.HoldMonths != nil && *prospect.HoldMonths > 0 {
@@ -1213,6 +1303,8 @@ func computeCurrentOfferHashes(
 		OfferPrice:             prospect.OfferPrice,
 		Neighborhood:           prospect.Neighborhood,
 		FlipScore:              prospect.FlipScore,
+		Market:                 market,
+		Auction:                prospect.AuctionTerms,
 	})
 	if err != nil {
 		return "", "", err
@@ -1231,6 +1323,8 @@ func computeCurrentOfferHashes(
 		MaxSaleToAskRatio:       settings.MaxSaleToAskRatio,
 		GenerateRateLimitPerMin: settings.GenerateRateLimitPerMin,
 		ConfidenceWeights:       settings.ConfidenceWeights.ToMap(),
+		Tax:                     offerintelligence.TaxSettingsSnapshot(settings.CashSettings.Tax),
+		DecisionRules:           offerintelligence.DecisionRulesSnapshot(settings.DecisionRules),
 	})
 	if err != nil {
 		return "", "", err
@@ -498,6 +498,8 @@ func prospectRows(prospectID, workspaceID string, expectedSale *float64) *sqlmoc
 		"broker_name",
 		"broker_phone",
 		"flip_score",
+		"acquisition_mode",
+		"auction_terms_json",
 	}).AddRow(
 		prospectID,
 		workspaceID,
@@ -516,6 +518,8 @@ func prospectRows(prospectID, workspaceID string, expectedSale *float64) *sqlmoc
 		"Corretor Teste",
 		"+55 41 99999-9999",
 		80,
+		"market",
+		nil,
 	)
 }
 
@@ -532,6 +536,10 @@ func workspaceSettingsRows(rateLimit int, consumedAt *time.Time) *sqlmock.Rows {
 		"registry_rate",
 		"broker_rate",
 		"pj_tax_rate",
+		"tax_regime",
+		"tax_simples_rate",
+		"tax_ret_rate",
+		"tax_pf_reinvested_pct",
 		"offer_min_margin_pct",
 		"offer_min_net_profit_brl",
 		"offer_min_confidence",
@@ -541,11 +549,16 @@ func workspaceSettingsRows(rateLimit int, consumedAt *time.Time) *sqlmock.Rows {
 		"offer_generate_rate_limit_per_min",
 		"offer_first_full_preview_consumed_at",
 		"offer_first_full_preview_user_id",
+		"offer_decision_rules_json",
 	}).AddRow(
 		0.03,
 		0.01,
 		0.06,
 		0.15,
+		"flat",
+		nil,
+		nil,
+		nil,
 		12.0,
 		30000.0,
 		0.55,
@@ -555,6 +568,7 @@ func workspaceSettingsRows(rateLimit int, consumedAt *time.Time) *sqlmock.Rows {
 		rateLimit,
 		consumedAtValue,
 		consumedUserID,
+		nil,
 	)
 }
 
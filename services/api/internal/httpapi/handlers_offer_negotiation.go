@@ -0,0 +1,501 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/offerintelligence"
+)
+
+// EventTypeNegotiationRound is the property timeline event for a negotiation round
+const EventTypeNegotiationRound = "negotiation_round"
+
+// Negotiation statuses
+const (
+	NegotiationStatusOpen      = "open"
+	NegotiationStatusAccepted  = "accepted"
+	NegotiationStatusRejected  = "rejected"
+	NegotiationStatusWithdrawn = "withdrawn"
+)
+
+// Negotiation round event types
+const (
+	NegotiationEventOfferSent      = "offer_sent"
+	NegotiationEventCounteroffer   = "counteroffer"
+	NegotiationEventSellerResponse = "seller_response"
+	NegotiationEventWithdrawn      = "withdrawn"
+)
+
+// Seller responses
+const (
+	SellerResponseAccepted  = "accepted"
+	SellerResponseRejected  = "rejected"
+	SellerResponseCountered = "countered"
+)
+
+type negotiationEventRequest struct {
+	EventType      string     `json:"event_type"`
+	Price          *float64   `json:"price"`
+	SellerResponse *string    `json:"seller_response"`
+	Notes          *string    `json:"notes"`
+	OccurredAt     *time.Time `json:"occurred_at"`
+}
+
+type offerNegotiationRound struct {
+	ID             string                               `json:"id"`
+	RoundNumber    int                                  `json:"round_number"`
+	EventType      string                               `json:"event_type"`
+	Price          *float64                             `json:"price"`
+	SellerResponse *string                              `json:"seller_response"`
+	Notes          *string                              `json:"notes"`
+	OccurredAt     time.Time                            `json:"occurred_at"`
+	Decision       *string                              `json:"decision"`
+	CeilingOffer   *float64                             `json:"ceiling_offer"`
+	RoomToCeiling  *float64                             `json:"room_to_ceiling"`
+	Evaluation     *offerintelligence.CounterEvaluation `json:"evaluation"`
+	CreatedBy      string                               `json:"created_by_user_id"`
+	CreatedAt      time.Time                            `json:"created_at"`
+}
+
+type offerNegotiation struct {
+	ID          string                  `json:"id"`
+	WorkspaceID string                  `json:"workspace_id"`
+	ProspectID  string                  `json:"prospect_id"`
+	PropertyID  *string                 `json:"property_id"`
+	Status      string                  `json:"status"`
+	AskingPrice *float64                `json:"asking_price"`
+	LastPrice   *float64                `json:"last_price"`
+	CreatedBy   string                  `json:"created_by_user_id"`
+	ClosedAt    *time.Time              `json:"closed_at"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	Rounds      []offerNegotiationRound `json:"rounds"`
+}
+
+type offerNegotiationResponse struct {
+	Negotiation offerNegotiation `json:"negotiation"`
+}
+
+// handleOfferNegotiation handles GET /api/v1/prospects/:id/negotiation (latest negotiation with its rounds)
+func (a *api) handleOfferNegotiation(w http.ResponseWriter, r *http.Request, prospectID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	if !a.enforceOfferRollout(w, r, userID) {
+		return
+	}
+
+	prospect, err := a.getOfferProspect(r.Context(), prospectID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "prospect not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch prospect"})
+		return
+	}
+
+	var negotiationID string
+	err = a.db.QueryRowContext(
+		r.Context(),
+		`SELECT id FROM offer_negotiations
+		 WHERE prospect_id = $1
+		 ORDER BY (status = 'open') DESC, created_at DESC
+		 LIMIT 1`,
+		prospect.ID,
+	).Scan(&negotiationID)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "negotiation not found"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch negotiation"})
+		return
+	}
+
+	negotiation, err := a.loadOfferNegotiation(r.Context(), negotiationID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch negotiation"})
+		return
+	}
+	writeJSON(w, http.StatusOK, offerNegotiationResponse{Negotiation: negotiation})
+}
+
+// handleOfferNegotiationEvent handles POST /api/v1/prospects/:id/negotiation/events.
+// An offer or counteroffer opens a negotiation when none is open. Priced rounds are
+// re-evaluated with offer intelligence at that price; seller acceptance/rejection and
+// withdrawal close the negotiation.
+func (a *api) handleOfferNegotiationEvent(w http.ResponseWriter, r *http.Request, prospectID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	if !a.enforceOfferRollout(w, r, userID) {
+		return
+	}
+
+	var req negotiationEventRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+	if details := validateNegotiationEvent(&req); len(details) > 0 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid negotiation event", Details: details})
+		return
+	}
+
+	prospect, err := a.getOfferProspect(r.Context(), prospectID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "prospect not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch prospect"})
+		return
+	}
+
+	// Re-evaluate the round at its price (kept without evaluation when critical inputs are missing)
+	var evaluation *offerintelligence.CounterEvaluation
+	if req.Price != nil {
+		settings, _, err := a.getOfferWorkspaceSettings(r.Context(), prospect.WorkspaceID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch workspace settings"})
+			return
+		}
+		eval, err := offerintelligence.EvaluateCounter(a.offerInputs(r.Context(), prospect), settings, *req.Price)
+		if err == nil {
+			evaluation = &eval
+		} else if _, missing := err.(offerintelligence.MissingCriticalInputsError); !missing {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "INTERNAL_ERROR", Message: "failed to evaluate negotiation round"})
+			return
+		}
+	}
+
+	occurredAt := time.Now().UTC()
+	if req.OccurredAt != nil {
+		occurredAt = req.OccurredAt.UTC()
+	}
+
+	negotiationID, propertyID, round, err := a.insertNegotiationRound(r.Context(), prospect, userID, req, occurredAt, evaluation)
+	if err == errNegotiationNotOpen {
+		writeError(w, http.StatusConflict, apiError{Code: "NEGOTIATION_NOT_OPEN", Message: "there is no open negotiation for this prospect"})
+		return
+	}
+	if err == errNegotiationRace {
+		writeError(w, http.StatusConflict, apiError{Code: "NEGOTIATION_CONFLICT", Message: "the negotiation changed concurrently, retry the event"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to record negotiation event"})
+		return
+	}
+
+	// Rounds after conversion go straight to the property timeline
+	if propertyID != nil {
+		a.createTimelineEvent(r.Context(), *propertyID, prospect.WorkspaceID, EventTypeNegotiationRound,
+			negotiationRoundPayload(negotiationID, round), userID)
+	}
+
+	negotiation, err := a.loadOfferNegotiation(r.Context(), negotiationID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch negotiation"})
+		return
+	}
+	writeJSON(w, http.StatusCreated, offerNegotiationResponse{Negotiation: negotiation})
+}
+
+func validateNegotiationEvent(req *negotiationEventRequest) []string {
+	details := make([]string, 0)
+	if req.Price != nil && (*req.Price <= 0 || math.IsNaN(*req.Price) || math.IsInf(*req.Price, 0)) {
+		details = append(details, "price must be positive")
+	}
+	if req.Notes != nil {
+		trimmed := strings.TrimSpace(*req.Notes)
+		req.Notes = &trimmed
+	}
+
+	switch req.EventType {
+	case NegotiationEventOfferSent, NegotiationEventCounteroffer:
+		if req.Price == nil {
+			details = append(details, "price is required for "+req.EventType)
+		}
+		if req.SellerResponse != nil {
+			details = append(details, "seller_response is only allowed for seller_response events")
+		}
+	case NegotiationEventSellerResponse:
+		if req.SellerResponse == nil {
+			details = append(details, "seller_response is required")
+			break
+		}
+		switch *req.SellerResponse {
+		case SellerResponseAccepted, SellerResponseRejected:
+		case SellerResponseCountered:
+			if req.Price == nil {
+				details = append(details, "price is required when the seller counters")
+			}
+		default:
+			details = append(details, "seller_response must be accepted, rejected or countered")
+		}
+	case NegotiationEventWithdrawn:
+		if req.Price != nil || req.SellerResponse != nil {
+			details = append(details, "withdrawn events take no price or seller_response")
+		}
+	default:
+		details = append(details, "event_type must be offer_sent, counteroffer, seller_response or withdrawn")
+	}
+	return details
+}
+
+var (
+	errNegotiationNotOpen = errors.New("no open negotiation")
+	errNegotiationRace    = errors.New("negotiation opened concurrently")
+)
+
+// nextNegotiationRound returns the round of a new event: an offer starts a new round once the
+// current round already has one; every other event belongs to the current round
+func nextNegotiationRound(lastRound int, lastRoundHasOffer bool, eventType string) int {
+	if lastRound == 0 {
+		return 1
+	}
+	if eventType == NegotiationEventOfferSent && lastRoundHasOffer {
+		return lastRound + 1
+	}
+	return lastRound
+}
+
+// negotiationStatusAfter returns the negotiation status after the event
+func negotiationStatusAfter(req negotiationEventRequest) string {
+	switch req.EventType {
+	case NegotiationEventWithdrawn:
+		return NegotiationStatusWithdrawn
+	case NegotiationEventSellerResponse:
+		switch *req.SellerResponse {
+		case SellerResponseAccepted:
+			return NegotiationStatusAccepted
+		case SellerResponseRejected:
+			return NegotiationStatusRejected
+		}
+	}
+	return NegotiationStatusOpen
+}
+
+// insertNegotiationRound records the event on the open negotiation of the prospect, opening
+// one for a first offer. When a concurrent request opens it first the event is appended to
+// that negotiation instead.
+func (a *api) insertNegotiationRound(
+	ctx context.Context,
+	prospect offerProspectRecord,
+	userID string,
+	req negotiationEventRequest,
+	occurredAt time.Time,
+	evaluation *offerintelligence.CounterEvaluation,
+) (string, *string, offerNegotiationRound, error) {
+	negotiationID, propertyID, round, err := a.tryInsertNegotiationRound(ctx, prospect, userID, req, occurredAt, evaluation)
+	if err == errNegotiationRace {
+		return a.tryInsertNegotiationRound(ctx, prospect, userID, req, occurredAt, evaluation)
+	}
+	return negotiationID, propertyID, round, err
+}
+
+func (a *api) tryInsertNegotiationRound(
+	ctx context.Context,
+	prospect offerProspectRecord,
+	userID string,
+	req negotiationEventRequest,
+	occurredAt time.Time,
+	evaluation *offerintelligence.CounterEvaluation,
+) (string, *string, offerNegotiationRound, error) {
+	round := offerNegotiationRound{
+		EventType:      req.EventType,
+		Price:          req.Price,
+		SellerResponse: req.SellerResponse,
+		Notes:          req.Notes,
+		OccurredAt:     occurredAt,
+		Evaluation:     evaluation,
+		CreatedBy:      userID,
+	}
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", nil, round, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var negotiationID string
+	var propertyID sql.NullString
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT id, property_id FROM offer_negotiations
+		 WHERE prospect_id = $1 AND status = $2
+		 FOR UPDATE`,
+		prospect.ID, NegotiationStatusOpen,
+	).Scan(&negotiationID, &propertyID)
+	if err == sql.ErrNoRows {
+		if req.EventType != NegotiationEventOfferSent && req.EventType != NegotiationEventCounteroffer {
+			return "", nil, round, errNegotiationNotOpen
+		}
+		err = tx.QueryRowContext(
+			ctx,
+			`INSERT INTO offer_negotiations (workspace_id, prospect_id, property_id, status, asking_price, created_by_user_id)
+			 SELECT $1, $2, (SELECT id FROM properties WHERE origin_prospect_id = $2 LIMIT 1), $3, $4, $5
+			 RETURNING id, property_id`,
+			prospect.WorkspaceID, prospect.ID, NegotiationStatusOpen, prospect.AskingPrice, userID,
+		).Scan(&negotiationID, &propertyID)
+		if isUniqueViolation(err) {
+			return "", nil, round, errNegotiationRace
+		}
+	}
+	if err != nil {
+		return "", nil, round, err
+	}
+
+	var lastRound int
+	var lastRoundHasOffer bool
+	err = tx.QueryRowContext(
+		ctx,
+		`WITH last AS (
+			SELECT COALESCE(MAX(round_number), 0) AS n FROM offer_negotiation_rounds WHERE negotiation_id = $1
+		 )
+		 SELECT last.n, EXISTS (
+			SELECT 1 FROM offer_negotiation_rounds r
+			WHERE r.negotiation_id = $1 AND r.round_number = last.n AND r.event_type = $2
+		 )
+		 FROM last`,
+		negotiationID, NegotiationEventOfferSent,
+	).Scan(&lastRound, &lastRoundHasOffer)
+	if err != nil {
+		return "", nil, round, err
+	}
+	round.RoundNumber = nextNegotiationRound(lastRound, lastRoundHasOffer, req.EventType)
+
+	var evaluationJSON []byte
+	if evaluation != nil {
+		evaluationJSON, _ = json.Marshal(evaluation)
+		decision := string(evaluation.Decision)
+		round.Decision = &decision
+		round.CeilingOffer = &evaluation.CeilingOffer
+		round.RoomToCeiling = &evaluation.RoomToCeiling
+	}
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO offer_negotiation_rounds (
+			negotiation_id, workspace_id, round_number, event_type, price, seller_response, notes, occurred_at,
+			decision, ceiling_offer, room_to_ceiling, evaluation_json, created_by_user_id
+		 ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 RETURNING id, created_at`,
+		negotiationID, prospect.WorkspaceID, round.RoundNumber, round.EventType, round.Price, round.SellerResponse, round.Notes, occurredAt,
+		round.Decision, round.CeilingOffer, round.RoomToCeiling, evaluationJSON, userID,
+	).Scan(&round.ID, &round.CreatedAt)
+	if err != nil {
+		return "", nil, round, err
+	}
+
+	status := negotiationStatusAfter(req)
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE offer_negotiations
+		 SET status = $2,
+		     closed_at = CASE WHEN $2 = 'open' THEN NULL ELSE now() END,
+		     updated_at = now()
+		 WHERE id = $1`,
+		negotiationID, status,
+	); err != nil {
+		return "", nil, round, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", nil, round, err
+	}
+
+	var property *string
+	if propertyID.Valid {
+		property = &propertyID.String
+	}
+	return negotiationID, property, round, nil
+}
+
+func (a *api) loadOfferNegotiation(ctx context.Context, negotiationID string) (offerNegotiation, error) {
+	var n offerNegotiation
+	err := a.db.QueryRowContext(
+		ctx,
+		`SELECT id, workspace_id, prospect_id, property_id, status, asking_price, created_by_user_id,
+		        closed_at, created_at, updated_at
+		 FROM offer_negotiations
+		 WHERE id = $1`,
+		negotiationID,
+	).Scan(
+		&n.ID, &n.WorkspaceID, &n.ProspectID, &n.PropertyID, &n.Status, &n.AskingPrice, &n.CreatedBy,
+		&n.ClosedAt, &n.CreatedAt, &n.UpdatedAt,
+	)
+	if err != nil {
+		return offerNegotiation{}, err
+	}
+
+	rows, err := a.db.QueryContext(
+		ctx,
+		`SELECT id, round_number, event_type, price, seller_response, notes, occurred_at,
+		        decision, ceiling_offer, room_to_ceiling, evaluation_json, created_by_user_id, created_at
+		 FROM offer_negotiation_rounds
+		 WHERE negotiation_id = $1
+		 ORDER BY occurred_at, created_at`,
+		negotiationID,
+	)
+	if err != nil {
+		return offerNegotiation{}, err
+	}
+	defer rows.Close()
+
+	n.Rounds = make([]offerNegotiationRound, 0)
+	for rows.Next() {
+		var round offerNegotiationRound
+		var evaluationJSON []byte
+		if err := rows.Scan(
+			&round.ID, &round.RoundNumber, &round.EventType, &round.Price, &round.SellerResponse, &round.Notes, &round.OccurredAt,
+			&round.Decision, &round.CeilingOffer, &round.RoomToCeiling, &evaluationJSON, &round.CreatedBy, &round.CreatedAt,
+		); err != nil {
+			return offerNegotiation{}, err
+		}
+		if len(evaluationJSON) > 0 {
+			var eval offerintelligence.CounterEvaluation
+			if err := json.Unmarshal(evaluationJSON, &eval); err == nil {
+				round.Evaluation = &eval
+			}
+		}
+		if round.Price != nil {
+			n.LastPrice = round.Price
+		}
+		n.Rounds = append(n.Rounds, round)
+	}
+	return n, rows.Err()
+}
+
+func negotiationRoundPayload(negotiationID string, round offerNegotiationRound) map[string]any {
+	return map[string]any{
+		"negotiation_id":    negotiationID,
+		"round_id":          round.ID,
+		"round_number":      round.RoundNumber,
+		"negotiation_event": round.EventType,
+		"price":             round.Price,
+		"seller_response":   round.SellerResponse,
+		"notes":             round.Notes,
+		"occurred_at":       round.OccurredAt,
+		"decision":          round.Decision,
+		"ceiling_offer":     round.CeilingOffer,
+		"room_to_ceiling":   round.RoomToCeiling,
+	}
+}
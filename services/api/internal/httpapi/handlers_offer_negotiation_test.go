@@ -0,0 +1,210 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestOfferNegotiationCounterofferOpensNegotiationAndIsEvaluated(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	const (
+		userID      = "user-1"
+		prospectID  = "prospect-1"
+		workspaceID = "workspace-1"
+	)
+	expectedSale := 460000.0
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM prospecting_properties p")).
+		WithArgs(prospectID, userID).
+		WillReturnRows(prospectRows(prospectID, workspaceID, &expectedSale))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM workspace_settings")).
+		WithArgs(workspaceID).
+		WillReturnRows(workspaceSettingsRows(10, nil))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, property_id FROM offer_negotiations")).
+		WithArgs(prospectID, NegotiationStatusOpen).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO offer_negotiations")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "property_id"}).AddRow("negotiation-1", nil))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM offer_negotiation_rounds WHERE negotiation_id = $1")).
+		WithArgs("negotiation-1", NegotiationEventOfferSent).
+		WillReturnRows(sqlmock.NewRows([]string{"n", "exists"}).AddRow(0, false))
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO offer_negotiation_rounds")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("round-1", now))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE offer_negotiations")).
+		WithArgs("negotiation-1", NegotiationStatusOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM offer_negotiations")).
+		WithArgs("negotiation-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "workspace_id", "prospect_id", "property_id", "status", "asking_price", "created_by_user_id",
+			"closed_at", "created_at", "updated_at",
+		}).AddRow("negotiation-1", workspaceID, prospectID, nil, NegotiationStatusOpen, 300000.0, userID, nil, now, now))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM offer_negotiation_rounds")).
+		WithArgs("negotiation-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "round_number", "event_type", "price", "seller_response", "notes", "occurred_at",
+			"decision", "ceiling_offer", "room_to_ceiling", "evaluation_json", "created_by_user_id", "created_at",
+		}).AddRow("round-1", 1, NegotiationEventCounteroffer, 290000.0, nil, nil, now,
+			"GO", 300000.0, 10000.0, []byte(`{"price":290000,"decision":"GO"}`), userID, now))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/prospects/"+prospectID+"/negotiation/events",
+		`{"event_type":"counteroffer","price":290000}`, userID)
+
+	a.handleOfferNegotiationEvent(rr, req, prospectID)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var resp offerNegotiationResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Negotiation.Rounds) != 1 || resp.Negotiation.Rounds[0].Evaluation == nil {
+		t.Fatalf("expected one evaluated round, got %+v", resp.Negotiation.Rounds)
+	}
+	if resp.Negotiation.LastPrice == nil || *resp.Negotiation.LastPrice != 290000 {
+		t.Fatalf("last_price=%v want=290000", resp.Negotiation.LastPrice)
+	}
+}
+
+func TestOfferNegotiationFirstOfferJoinsConcurrentlyOpenedNegotiation(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectedSale := 460000.0
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM prospecting_properties p")).
+		WithArgs("prospect-1", "user-1").
+		WillReturnRows(prospectRows("prospect-1", "workspace-1", &expectedSale))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM workspace_settings")).
+		WithArgs("workspace-1").
+		WillReturnRows(workspaceSettingsRows(10, nil))
+
+	// The first attempt loses the race on uq_offer_negotiations_open_prospect
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, property_id FROM offer_negotiations")).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO offer_negotiations")).
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	// The retry finds the winner's negotiation and appends the round
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, property_id FROM offer_negotiations")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "property_id"}).AddRow("negotiation-1", nil))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM offer_negotiation_rounds WHERE negotiation_id = $1")).
+		WithArgs("negotiation-1", NegotiationEventOfferSent).
+		WillReturnRows(sqlmock.NewRows([]string{"n", "exists"}).AddRow(1, true))
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO offer_negotiation_rounds")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("round-2", now))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE offer_negotiations")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM offer_negotiations")).
+		WithArgs("negotiation-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "workspace_id", "prospect_id", "property_id", "status", "asking_price", "created_by_user_id",
+			"closed_at", "created_at", "updated_at",
+		}).AddRow("negotiation-1", "workspace-1", "prospect-1", nil, NegotiationStatusOpen, 300000.0, "user-1", nil, now, now))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM offer_negotiation_rounds")).
+		WithArgs("negotiation-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "round_number", "event_type", "price", "seller_response", "notes", "occurred_at",
+			"decision", "ceiling_offer", "room_to_ceiling", "evaluation_json", "created_by_user_id", "created_at",
+		}).AddRow("round-2", 2, NegotiationEventOfferSent, 280000.0, nil, nil, now, nil, nil, nil, nil, "user-1", now))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/prospects/prospect-1/negotiation/events",
+		`{"event_type":"offer_sent","price":280000}`, "user-1")
+
+	a.handleOfferNegotiationEvent(rr, req, "prospect-1")
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestOfferNegotiationSellerResponseRequiresOpenNegotiation(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectedSale := 460000.0
+	mock.ExpectQuery(regexp.QuoteMeta("FROM prospecting_properties p")).
+		WithArgs("prospect-1", "user-1").
+		WillReturnRows(prospectRows("prospect-1", "workspace-1", &expectedSale))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, property_id FROM offer_negotiations")).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/prospects/prospect-1/negotiation/events",
+		`{"event_type":"seller_response","seller_response":"rejected"}`, "user-1")
+
+	a.handleOfferNegotiationEvent(rr, req, "prospect-1")
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusConflict, rr.Body.String())
+	}
+	if got := decodeAPIErrorCode(t, rr); got != "NEGOTIATION_NOT_OPEN" {
+		t.Fatalf("error.code=%s want=NEGOTIATION_NOT_OPEN", got)
+	}
+}
+
+func TestValidateNegotiationEvent(t *testing.T) {
+	price := 250000.0
+	countered := SellerResponseCountered
+
+	cases := []struct {
+		name  string
+		req   negotiationEventRequest
+		valid bool
+	}{
+		{"offer with price", negotiationEventRequest{EventType: NegotiationEventOfferSent, Price: &price}, true},
+		{"offer without price", negotiationEventRequest{EventType: NegotiationEventOfferSent}, false},
+		{"counter by seller without price", negotiationEventRequest{EventType: NegotiationEventSellerResponse, SellerResponse: &countered}, false},
+		{"withdrawn with price", negotiationEventRequest{EventType: NegotiationEventWithdrawn, Price: &price}, false},
+		{"unknown type", negotiationEventRequest{EventType: "call"}, false},
+	}
+	for _, tc := range cases {
+		details := validateNegotiationEvent(&tc.req)
+		if (len(details) == 0) != tc.valid {
+			t.Fatalf("%s: valid=%v details=%v", tc.name, tc.valid, details)
+		}
+	}
+}
+
+func TestNextNegotiationRound(t *testing.T) {
+	if got := nextNegotiationRound(0, false, NegotiationEventCounteroffer); got != 1 {
+		t.Fatalf("first event round=%d want=1", got)
+	}
+	if got := nextNegotiationRound(1, false, NegotiationEventOfferSent); got != 1 {
+		t.Fatalf("offer after a counter round=%d want=1", got)
+	}
+	if got := nextNegotiationRound(1, true, NegotiationEventOfferSent); got != 2 {
+		t.Fatalf("second offer round=%d want=2", got)
+	}
+	if got := nextNegotiationRound(2, true, NegotiationEventCounteroffer); got != 2 {
+		t.Fatalf("counter round=%d want=2", got)
+	}
+}
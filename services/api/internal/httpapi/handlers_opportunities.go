@@ -4,12 +4,16 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/zapscraper"
 )
 
 // Types for opportunities API
@@ -22,6 +26,7 @@ type ScoreBreakdown struct {
 	Keywords  int `json:"keywords"`
 	Penalties int `json:"penalties"`
 	Decay     int `json:"decay"`
+	PriceCut  int `json:"price_cut"`
 }
 
 type IngestListing struct {
@@ -42,12 +47,18 @@ type IngestListing struct {
 	City            string         `json:"city"`
 	State           string         `json:"state"`
 	Images          []string       `json:"images"`
+	ImageHashes     []string       `json:"image_hashes,omitempty"`
 	PublishedAt     *time.Time     `json:"published_at,omitempty"`
 	Score           int            `json:"score"`
 	ScoreBreakdown  ScoreBreakdown `json:"score_breakdown"`
 	PricePerM2      float64        `json:"price_per_m2"`
 	MedianPriceM2   float64        `json:"market_median_m2"`
 	DiscountPct     float64        `json:"discount_pct"`
+
+	// Parse quality (0-100) and per-field origin reported by the scraper;
+	// absent from older CLI payloads
+	ParseQuality *int              `json:"parse_quality,omitempty"`
+	FieldSources map[string]string `json:"field_sources,omitempty"`
 }
 
 type IngestRequest struct {
@@ -63,6 +74,9 @@ type IngestStats struct {
 	TotalReceived int `json:"total_received"`
 	NewListings   int `json:"new_listings"`
 	Updated       int `json:"updated"`
+	PriceDrops    int `json:"price_drops"`
+	Relisted      int `json:"relisted"`
+	Alerts        int `json:"alerts"`
 }
 
 type IngestResponse struct {
@@ -96,8 +110,41 @@ type OpportunityResponse struct {
 	MedianPriceM2   float64        `json:"market_median_m2"`
 	DiscountPct     float64        `json:"discount_pct"`
 	Status          string         `json:"status"`
-	FirstSeenAt     time.Time      `json:"first_seen_at"`
-	LastSeenAt      time.Time      `json:"last_seen_at"`
+
+	// How the listing was parsed: 0-100 quality and the origin of each field
+	ParseQuality    *int              `json:"parse_quality,omitempty"`
+	FieldProvenance map[string]string `json:"field_provenance,omitempty"`
+	FirstSeenAt     time.Time         `json:"first_seen_at"`
+	LastSeenAt      time.Time         `json:"last_seen_at"`
+
+	// Price history and market time
+	ListingStatus   string     `json:"listing_status"`
+	DelistedAt      *time.Time `json:"delisted_at,omitempty"`
+	PeakPriceCents  int64      `json:"peak_price_cents"`
+	PriceDropPct    float64    `json:"price_drop_pct"`
+	LastPriceDropAt *time.Time `json:"last_price_drop_at,omitempty"`
+	DaysOnMarket    int        `json:"days_on_market"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+
+	// Cross-portal cluster; the card shows the lowest priced listing
+	ClusterID         string                  `json:"cluster_id,omitempty"`
+	ListingCount      int                     `json:"listing_count"`
+	Sources           []OpportunitySourceLink `json:"sources"`
+	LowestPriceCents  int64                   `json:"lowest_price_cents"`
+	HighestPriceCents int64                   `json:"highest_price_cents"`
+	PriceSpreadCents  int64                   `json:"price_spread_cents"`
+	PriceSpreadPct    float64                 `json:"price_spread_pct"`
+
+	clusterKey string
+}
+
+// OpportunitySourceLink is one portal/agency listing of the same apartment
+type OpportunitySourceLink struct {
+	OpportunityID   string `json:"opportunity_id"`
+	Source          string `json:"source"`
+	SourceListingID string `json:"source_listing_id"`
+	CanonicalURL    string `json:"canonical_url"`
+	PriceCents      int64  `json:"price_cents"`
 }
 
 type OpportunityListResponse struct {
@@ -135,21 +182,26 @@ type OpportunityFacetNumberItemResponse struct {
 }
 
 type OpportunityFacetRangesResponse struct {
-	ScoreMin      int     `json:"score_min"`
-	ScoreMax      int     `json:"score_max"`
-	PriceMinCents int64   `json:"price_min_cents"`
-	PriceMaxCents int64   `json:"price_max_cents"`
-	AreaMin       float64 `json:"area_min"`
-	AreaMax       float64 `json:"area_max"`
+	ScoreMin        int     `json:"score_min"`
+	ScoreMax        int     `json:"score_max"`
+	PriceMinCents   int64   `json:"price_min_cents"`
+	PriceMaxCents   int64   `json:"price_max_cents"`
+	AreaMin         float64 `json:"area_min"`
+	AreaMax         float64 `json:"area_max"`
+	PriceDropMaxPct float64 `json:"price_drop_max_pct"`
+	DaysOnMarketMin int     `json:"days_on_market_min"`
+	DaysOnMarketMax int     `json:"days_on_market_max"`
 }
 
 type OpportunityFacetsResponse struct {
-	States        []OpportunityFacetItemResponse       `json:"states"`
-	Cities        []OpportunityFacetItemResponse       `json:"cities"`
-	Neighborhoods []OpportunityFacetItemResponse       `json:"neighborhoods"`
-	Statuses      []OpportunityFacetItemResponse       `json:"statuses"`
-	Bedrooms      []OpportunityFacetNumberItemResponse `json:"bedrooms"`
-	Ranges        OpportunityFacetRangesResponse       `json:"ranges"`
+	States          []OpportunityFacetItemResponse       `json:"states"`
+	Cities          []OpportunityFacetItemResponse       `json:"cities"`
+	Neighborhoods   []OpportunityFacetItemResponse       `json:"neighborhoods"`
+	Statuses        []OpportunityFacetItemResponse       `json:"statuses"`
+	ListingStatuses []OpportunityFacetItemResponse       `json:"listing_statuses"`
+	PriceDropCount  int                                  `json:"price_drop_count"`
+	Bedrooms        []OpportunityFacetNumberItemResponse `json:"bedrooms"`
+	Ranges          OpportunityFacetRangesResponse       `json:"ranges"`
 }
 
 type updateOpportunityStatusRequest struct {
@@ -163,19 +215,23 @@ type updateOpportunityStatusResponse struct {
 }
 
 type opportunityListFilters struct {
-	State        string
-	City         string
-	Neighborhood string
-	ScoreMin     int
-	PriceMin     int64
-	PriceMax     int64
-	AreaMin      float64
-	AreaMax      float64
-	Statuses     []string
-	Bedrooms     []int
-	SortBy       string
-	Limit        int
-	Offset       int
+	OpportunityID string
+	State         string
+	City          string
+	Neighborhood  string
+	ScoreMin      int
+	PriceMin      int64
+	PriceMax      int64
+	AreaMin       float64
+	AreaMax       float64
+	Statuses      []string
+	Bedrooms      []int
+	Listing       string
+	PriceDropMin  float64
+	SortBy        string
+	Dedupe        bool
+	Limit         int
+	Offset        int
 }
 
 var allowedOpportunityStatuses = map[string]struct{}{
@@ -202,6 +258,63 @@ func (a *api) handleOpportunitiesSubroutes(w http.ResponseWriter, r *http.Reques
 			return
 		}
 		w.WriteHeader(http.StatusMethodNotAllowed)
+	case path == "/saved-searches" || path == "/saved-searches/":
+		switch r.Method {
+		case http.MethodGet:
+			a.handleListOpportunitySavedSearches(w, r)
+		case http.MethodPost:
+			a.handleCreateOpportunitySavedSearch(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case strings.HasPrefix(path, "/saved-searches/"):
+		searchID := strings.Trim(strings.TrimPrefix(path, "/saved-searches/"), "/")
+		if searchID == "" || strings.Contains(searchID, "/") {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "endpoint not found"})
+			return
+		}
+		switch r.Method {
+		case http.MethodPatch:
+			a.handleUpdateOpportunitySavedSearch(w, r, searchID)
+		case http.MethodDelete:
+			a.handleDeleteOpportunitySavedSearch(w, r, searchID)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case path == "/alerts" || path == "/alerts/":
+		if r.Method == http.MethodGet {
+			a.handleListOpportunityAlerts(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	case path == "/alerts/read":
+		if r.Method == http.MethodPost {
+			a.handleMarkOpportunityAlertsRead(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	case strings.HasSuffix(path, "/history"):
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) != 2 || parts[1] != "history" || parts[0] == "" {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "endpoint not found"})
+			return
+		}
+		a.handleGetOpportunityHistory(w, r, parts[0])
+	case strings.HasSuffix(path, "/convert"):
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) != 2 || parts[1] != "convert" || parts[0] == "" {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "endpoint not found"})
+			return
+		}
+		a.handleConvertOpportunityToProspect(w, r, parts[0])
 	case strings.HasSuffix(path, "/status"):
 		if r.Method != http.MethodPatch {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -295,29 +408,13 @@ func (a *api) handleIngestOpportunities(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Process listings
-	var newCount, updatedCount int
-
-	for _, listing := range req.Listings {
-		isNew, err := a.upsertListing(listing)
-		if err != nil {
-			// Log error but continue
-			continue
-		}
-		if isNew {
-			newCount++
-		} else {
-			updatedCount++
-		}
-	}
+	// Process listings. The CLI does not report whether its search was complete,
+	// so its runs only refresh last seen and never count towards delisting.
+	stats := a.ingestOpportunityListings(req.Listings, jobRunID)
 
 	// Update job run
 	finishedAt := time.Now()
-	statsJSON, _ := json.Marshal(map[string]interface{}{
-		"total_received": len(req.Listings),
-		"new_listings":   newCount,
-		"updated":        updatedCount,
-	})
+	statsJSON, _ := json.Marshal(stats)
 
 	_, err = a.db.Exec(`
 		UPDATE opportunity_job_runs
@@ -330,95 +427,170 @@ func (a *api) handleIngestOpportunities(w http.ResponseWriter, r *http.Request)
 
 	writeJSON(w, http.StatusOK, IngestResponse{
 		JobRunID: jobRunID,
-		Stats: IngestStats{
-			TotalReceived: len(req.Listings),
-			NewListings:   newCount,
-			Updated:       updatedCount,
-		},
+		Stats:    stats,
 	})
 }
 
-func (a *api) upsertListing(listing IngestListing) (isNew bool, err error) {
+// upsertListing inserts or refreshes a listing and its opportunity. Every price
+// change is appended to the price history and recent cuts add to the score.
+func (a *api) upsertListing(listing IngestListing, jobRunID string) (listingUpsertResult, error) {
+	var result listingUpsertResult
 	imagesJSON, _ := json.Marshal(listing.Images)
-	scoreBreakdownJSON, _ := json.Marshal(listing.ScoreBreakdown)
+	// Without provenance in the payload the stored parse quality is kept
+	var provenanceJSON []byte
+	if len(listing.FieldSources) > 0 {
+		provenanceJSON, _ = json.Marshal(listing.FieldSources)
+	}
+	// Without hashes in the payload the stored ones are kept
+	var imageHashesJSON []byte
+	if len(listing.ImageHashes) > 0 {
+		imageHashesJSON, _ = json.Marshal(listing.ImageHashes)
+	}
 
 	// Check if exists
 	var existingID string
-	err = a.db.QueryRow(`
-		SELECT id FROM source_listings
+	var previousPrice, peakPrice sql.NullInt64
+	var lastDropAt, delistedAt sql.NullTime
+	err := a.db.QueryRow(`
+		SELECT id, price_cents, peak_price_cents, last_price_drop_at, delisted_at
+		FROM source_listings
 		WHERE source = $1 AND source_listing_id = $2
-	`, listing.Source, listing.SourceListingID).Scan(&existingID)
+	`, listing.Source, listing.SourceListingID).Scan(&existingID, &previousPrice, &peakPrice, &lastDropAt, &delistedAt)
 
 	if err == sql.ErrNoRows {
 		// Insert new
-		isNew = true
+		result.IsNew = true
 		listingID := uuid.New().String()
+		result.ListingID = listingID
 
 		_, err = a.db.Exec(`
 			INSERT INTO source_listings (
 				id, source, source_listing_id, canonical_url, title, description,
 				price_cents, area_m2, bedrooms, bathrooms, parking_spots,
 				condo_fee_cents, iptu_cents, address, neighborhood, city, state,
-				images, listing_published_at, first_seen_at, last_seen_at
+				images, listing_published_at, image_hashes, peak_price_cents,
+				last_seen_job_run_id, first_seen_at, last_seen_at
 			) VALUES (
-				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NOW(), NOW()
+				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+				COALESCE($20::jsonb, '[]'::jsonb), NULLIF($7::bigint, 0), $21, NOW(), NOW()
 			)
 		`, listingID, listing.Source, listing.SourceListingID, listing.CanonicalURL,
 			listing.Title, listing.Description, listing.PriceCents, listing.AreaM2,
 			listing.Bedrooms, listing.Bathrooms, listing.ParkingSpots,
 			listing.CondoFeeCents, listing.IPTUCents, listing.Address,
-			listing.Neighborhood, listing.City, listing.State, imagesJSON, listing.PublishedAt)
+			listing.Neighborhood, listing.City, listing.State, imagesJSON, listing.PublishedAt,
+			imageHashesJSON, nullableJobRunID(jobRunID))
 
 		if err != nil {
-			return false, err
+			return result, err
+		}
+
+		if err := a.recordListingPrice(listingID, jobRunID, listing.PriceCents, 0); err != nil {
+			return result, err
 		}
 
+		scoreBreakdownJSON, _ := json.Marshal(listing.ScoreBreakdown)
+
 		// Insert opportunity
 		_, err = a.db.Exec(`
 			INSERT INTO opportunities (
 				id, source_listing_id, score, score_breakdown,
-				price_per_m2, market_median_m2, discount_pct, status
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, 'new')
+				price_per_m2, market_median_m2, discount_pct, status,
+				parse_quality, field_provenance
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, 'new', $8, COALESCE($9::jsonb, '{}'::jsonb))
 		`, uuid.New().String(), listingID, listing.Score, scoreBreakdownJSON,
-			listing.PricePerM2, listing.MedianPriceM2, listing.DiscountPct)
+			listing.PricePerM2, listing.MedianPriceM2, listing.DiscountPct,
+			listing.ParseQuality, provenanceJSON)
+		if err != nil {
+			return result, err
+		}
 
-		return true, err
+		a.clusterListing(listingID, listing)
+		return result, nil
 	}
 
 	if err != nil {
-		return false, err
+		return result, err
+	}
+	result.ListingID = existingID
+	result.PreviousPriceCents = previousPrice.Int64
+
+	// Price history: append on change and keep the peak to measure cuts from
+	now := time.Now()
+	peak := peakPrice.Int64
+	if listing.PriceCents > peak {
+		peak = listing.PriceCents
+	}
+	if listing.PriceCents > 0 && previousPrice.Int64 != listing.PriceCents {
+		result.PriceChanged = true
+		if err := a.recordListingPrice(existingID, jobRunID, listing.PriceCents, previousPrice.Int64); err != nil {
+			return result, err
+		}
+		if previousPrice.Int64 > 0 && listing.PriceCents < previousPrice.Int64 {
+			result.PriceDropped = true
+			lastDropAt = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+
+	var droppedAt *time.Time
+	if lastDropAt.Valid {
+		droppedAt = &lastDropAt.Time
 	}
+	priceCut := zapscraper.PriceCutScore(listingPriceDropPct(peak, listing.PriceCents), droppedAt, now)
+	listing.ScoreBreakdown.PriceCut = priceCut
+	listing.Score = clampOpportunityScore(listing.Score + priceCut)
+	scoreBreakdownJSON, _ := json.Marshal(listing.ScoreBreakdown)
 
-	// Update existing
+	// Update existing; being seen again resets the delisting detection
 	_, err = a.db.Exec(`
 		UPDATE source_listings SET
 			canonical_url = $1, title = $2, description = $3,
 			price_cents = $4, area_m2 = $5, bedrooms = $6, bathrooms = $7,
 			parking_spots = $8, condo_fee_cents = $9, iptu_cents = $10,
 			address = $11, neighborhood = $12, city = $13, state = $14,
-			images = $15, last_seen_at = NOW(), updated_at = NOW()
-		WHERE id = $16
+			images = $15, image_hashes = COALESCE($16::jsonb, image_hashes),
+			peak_price_cents = NULLIF($17::bigint, 0), last_price_drop_at = $18,
+			last_seen_job_run_id = $19, missed_runs = 0, delisted_at = NULL,
+			last_seen_at = NOW(), updated_at = NOW()
+		WHERE id = $20
 	`, listing.CanonicalURL, listing.Title, listing.Description,
 		listing.PriceCents, listing.AreaM2, listing.Bedrooms, listing.Bathrooms,
 		listing.ParkingSpots, listing.CondoFeeCents, listing.IPTUCents,
 		listing.Address, listing.Neighborhood, listing.City, listing.State,
-		imagesJSON, existingID)
+		imagesJSON, imageHashesJSON, peak, lastDropAt, nullableJobRunID(jobRunID), existingID)
 
 	if err != nil {
-		return false, err
+		return result, err
 	}
 
 	// Update opportunity score
-	_, err = a.db.Exec(`
+	var opportunityID string
+	err = a.db.QueryRow(`
 		UPDATE opportunities SET
 			score = $1, score_breakdown = $2,
 			price_per_m2 = $3, market_median_m2 = $4, discount_pct = $5,
+			parse_quality = COALESCE($7, parse_quality),
+			field_provenance = COALESCE($8::jsonb, field_provenance),
 			updated_at = NOW()
 		WHERE source_listing_id = $6
+		RETURNING id
 	`, listing.Score, scoreBreakdownJSON, listing.PricePerM2,
-		listing.MedianPriceM2, listing.DiscountPct, existingID)
+		listing.MedianPriceM2, listing.DiscountPct, existingID,
+		listing.ParseQuality, provenanceJSON).Scan(&opportunityID)
+	if err != nil {
+		return result, err
+	}
+
+	if delistedAt.Valid {
+		result.Relisted = true
+		if err := a.recordOpportunityStatusChange(opportunityID, opportunityChangeListingStatus,
+			opportunityListingDelisted, opportunityListingActive, nil, jobRunID); err != nil {
+			return result, err
+		}
+	}
 
-	return false, err
+	a.clusterListing(existingID, listing)
+	return result, nil
 }
 
 func parseOpportunityListFilters(q map[string][]string) opportunityListFilters {
@@ -429,8 +601,23 @@ func parseOpportunityListFilters(q map[string][]string) opportunityListFilters {
 		SortBy:       strings.TrimSpace(firstNonEmptyQueryValue(q, "sort")),
 		Statuses:     parseOpportunityCSV(firstNonEmptyQueryValue(q, "status")),
 		Bedrooms:     parseOpportunityBedrooms(firstNonEmptyQueryValue(q, "bedrooms")),
+		Dedupe:       firstNonEmptyQueryValue(q, "dedupe") != "false",
+	}
+
+	// Deep links from alerts point at a single card
+	if id := firstNonEmptyQueryValue(q, "opportunity", "opportunity_id"); id != "" {
+		if _, err := uuid.Parse(id); err == nil {
+			filters.OpportunityID = id
+			filters.Dedupe = false
+		}
 	}
 
+	switch listing := strings.TrimSpace(strings.ToLower(firstNonEmptyQueryValue(q, "listing_status"))); listing {
+	case opportunityListingActive, opportunityListingDelisted:
+		filters.Listing = listing
+	}
+	filters.PriceDropMin, _ = strconv.ParseFloat(firstNonEmptyQueryValue(q, "min_price_drop", "price_drop_min"), 64)
+
 	filters.ScoreMin, _ = strconv.Atoi(firstNonEmptyQueryValue(q, "min_score", "score_min"))
 	filters.PriceMin, _ = strconv.ParseInt(firstNonEmptyQueryValue(q, "min_price", "price_min"), 10, 64)
 	filters.PriceMax, _ = strconv.ParseInt(firstNonEmptyQueryValue(q, "max_price", "price_max"), 10, 64)
@@ -516,6 +703,11 @@ func buildOpportunityFilterClause(filters opportunityListFilters) (string, []int
 	args := make([]interface{}, 0, 12)
 	argNum := 1
 
+	if filters.OpportunityID != "" {
+		where += fmt.Sprintf(" AND o.id = $%d", argNum)
+		args = append(args, filters.OpportunityID)
+		argNum++
+	}
 	if filters.State != "" {
 		where += fmt.Sprintf(" AND LOWER(sl.state) = LOWER($%d)", argNum)
 		args = append(args, filters.State)
@@ -574,16 +766,39 @@ func buildOpportunityFilterClause(filters opportunityListFilters) (string, []int
 		}
 		where += fmt.Sprintf(" AND sl.bedrooms IN (%s)", strings.Join(placeholders, ","))
 	}
+	switch filters.Listing {
+	case opportunityListingActive:
+		where += " AND sl.delisted_at IS NULL"
+	case opportunityListingDelisted:
+		where += " AND sl.delisted_at IS NOT NULL"
+	}
+	if filters.PriceDropMin > 0 {
+		where += fmt.Sprintf(" AND %s >= $%d", opportunityPriceDropSQL, argNum)
+		args = append(args, filters.PriceDropMin)
+		argNum++
+	}
 
 	return where, args, argNum
 }
 
+// Price cut from the peak price and days between publication (or first sighting)
+// and delisting (or now)
+const (
+	opportunityPriceDropSQL = `(CASE
+		WHEN sl.peak_price_cents > 0 AND sl.price_cents > 0 AND sl.price_cents < sl.peak_price_cents
+		THEN ROUND((sl.peak_price_cents - sl.price_cents)::numeric / sl.peak_price_cents, 4)
+		ELSE 0 END)`
+	opportunityDaysOnMarketSQL = `GREATEST(0, EXTRACT(DAY FROM
+		COALESCE(sl.delisted_at, NOW()) - COALESCE(sl.listing_published_at, sl.first_seen_at)))::int`
+)
+
 func scanOpportunityRows(rows *sql.Rows) []OpportunityResponse {
 	opportunities := make([]OpportunityResponse, 0)
 
 	for rows.Next() {
 		var opp OpportunityResponse
-		var imagesJSON, scoreBreakdownJSON []byte
+		var imagesJSON, scoreBreakdownJSON, provenanceJSON []byte
+		var peakPrice, parseQuality sql.NullInt64
 
 		err := rows.Scan(
 			&opp.ID, &opp.Source, &opp.SourceListingID, &opp.CanonicalURL,
@@ -593,7 +808,10 @@ func scanOpportunityRows(rows *sql.Rows) []OpportunityResponse {
 			&opp.Neighborhood, &opp.City, &opp.State, &imagesJSON,
 			&opp.PublishedAt, &opp.Score, &scoreBreakdownJSON,
 			&opp.PricePerM2, &opp.MedianPriceM2, &opp.DiscountPct,
+			&parseQuality, &provenanceJSON,
 			&opp.Status, &opp.FirstSeenAt, &opp.LastSeenAt,
+			&opp.DelistedAt, &peakPrice, &opp.PriceDropPct, &opp.LastPriceDropAt,
+			&opp.DaysOnMarket, &opp.StatusChangedAt, &opp.clusterKey,
 		)
 		if err != nil {
 			continue
@@ -601,6 +819,29 @@ func scanOpportunityRows(rows *sql.Rows) []OpportunityResponse {
 
 		json.Unmarshal(imagesJSON, &opp.Images)
 		json.Unmarshal(scoreBreakdownJSON, &opp.ScoreBreakdown)
+		if parseQuality.Valid {
+			quality := int(parseQuality.Int64)
+			opp.ParseQuality = &quality
+			json.Unmarshal(provenanceJSON, &opp.FieldProvenance)
+		}
+		opp.ListingStatus = opportunityListingActive
+		if opp.DelistedAt != nil {
+			opp.ListingStatus = opportunityListingDelisted
+		}
+		opp.PeakPriceCents = opp.PriceCents
+		if peakPrice.Valid {
+			opp.PeakPriceCents = peakPrice.Int64
+		}
+		opp.ListingCount = 1
+		opp.LowestPriceCents = opp.PriceCents
+		opp.HighestPriceCents = opp.PriceCents
+		opp.Sources = []OpportunitySourceLink{{
+			OpportunityID:   opp.ID,
+			Source:          opp.Source,
+			SourceListingID: opp.SourceListingID,
+			CanonicalURL:    opp.CanonicalURL,
+			PriceCents:      opp.PriceCents,
+		}}
 
 		opportunities = append(opportunities, opp)
 	}
@@ -610,6 +851,9 @@ func scanOpportunityRows(rows *sql.Rows) []OpportunityResponse {
 
 // GET /api/v1/internal/opportunities
 // GET /api/v1/opportunities
+//
+// Listings of the same apartment on different portals are collapsed into one
+// card per cluster (its lowest priced listing); dedupe=false lists every one.
 func (a *api) handleListOpportunities(w http.ResponseWriter, r *http.Request) {
 	filters := parseOpportunityListFilters(r.URL.Query())
 	where, args, argNum := buildOpportunityFilterClause(filters)
@@ -617,7 +861,23 @@ func (a *api) handleListOpportunities(w http.ResponseWriter, r *http.Request) {
 	baseFrom := `
 		FROM opportunities o
 		JOIN source_listings sl ON o.source_listing_id = sl.id
+	` + where
+	if filters.Dedupe {
+		baseFrom = `
+		FROM (
+			SELECT o.id AS opportunity_id,
+				ROW_NUMBER() OVER (
+					PARTITION BY COALESCE(sl.cluster_id, sl.id)
+					ORDER BY sl.price_cents ASC NULLS LAST, o.score DESC, o.created_at ASC
+				) AS cluster_rank
+			FROM opportunities o
+			JOIN source_listings sl ON o.source_listing_id = sl.id
+		` + where + `
+		) ranked
+		JOIN opportunities o ON o.id = ranked.opportunity_id AND ranked.cluster_rank = 1
+		JOIN source_listings sl ON o.source_listing_id = sl.id
 	`
+	}
 
 	query := `
 		SELECT
@@ -628,10 +888,15 @@ func (a *api) handleListOpportunities(w http.ResponseWriter, r *http.Request) {
 			sl.neighborhood, sl.city, sl.state, sl.images,
 			sl.listing_published_at, o.score, o.score_breakdown,
 			o.price_per_m2, o.market_median_m2, o.discount_pct,
-			o.status, sl.first_seen_at, sl.last_seen_at
-	` + baseFrom + where
+			o.parse_quality, o.field_provenance,
+			o.status, sl.first_seen_at, sl.last_seen_at,
+			sl.delisted_at, sl.peak_price_cents, ` + opportunityPriceDropSQL + `,
+			sl.last_price_drop_at, ` + opportunityDaysOnMarketSQL + `,
+			(SELECT MAX(c.created_at) FROM opportunity_status_changes c WHERE c.opportunity_id = o.id),
+			COALESCE(sl.cluster_id, sl.id)
+	` + baseFrom
 
-	countQuery := "SELECT COUNT(*) " + baseFrom + where
+	countQuery := "SELECT COUNT(*) " + baseFrom
 
 	switch filters.SortBy {
 	case "price_asc":
@@ -640,6 +905,10 @@ func (a *api) handleListOpportunities(w http.ResponseWriter, r *http.Request) {
 		query += " ORDER BY sl.price_cents DESC"
 	case "date_desc":
 		query += " ORDER BY sl.listing_published_at DESC NULLS LAST"
+	case "price_drop_desc":
+		query += " ORDER BY " + opportunityPriceDropSQL + " DESC, sl.last_price_drop_at DESC NULLS LAST"
+	case "days_on_market_desc":
+		query += " ORDER BY " + opportunityDaysOnMarketSQL + " DESC"
 	default:
 		query += " ORDER BY o.score DESC, o.created_at DESC"
 	}
@@ -661,6 +930,12 @@ func (a *api) handleListOpportunities(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	opportunities := scanOpportunityRows(rows)
+	if filters.Dedupe {
+		if err := a.attachOpportunityClusters(opportunities); err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: err.Error()})
+			return
+		}
+	}
 
 	writeJSON(w, http.StatusOK, OpportunityListResponse{
 		Data:   opportunities,
@@ -681,11 +956,12 @@ func (a *api) handleListOpportunityFacets(w http.ResponseWriter, r *http.Request
 	` + where
 
 	facets := OpportunityFacetsResponse{
-		States:        []OpportunityFacetItemResponse{},
-		Cities:        []OpportunityFacetItemResponse{},
-		Neighborhoods: []OpportunityFacetItemResponse{},
-		Statuses:      []OpportunityFacetItemResponse{},
-		Bedrooms:      []OpportunityFacetNumberItemResponse{},
+		States:          []OpportunityFacetItemResponse{},
+		Cities:          []OpportunityFacetItemResponse{},
+		Neighborhoods:   []OpportunityFacetItemResponse{},
+		Statuses:        []OpportunityFacetItemResponse{},
+		ListingStatuses: []OpportunityFacetItemResponse{},
+		Bedrooms:        []OpportunityFacetNumberItemResponse{},
 		Ranges: OpportunityFacetRangesResponse{
 			ScoreMin: 0,
 			ScoreMax: 100,
@@ -801,6 +1077,30 @@ func (a *api) handleListOpportunityFacets(w http.ResponseWriter, r *http.Request
 	}
 	statusRows.Close()
 
+	listingStatusRows, err := a.db.Query(`
+		SELECT CASE WHEN sl.delisted_at IS NULL THEN 'active' ELSE 'delisted' END AS listing_status, COUNT(*)
+	`+baseFrom+`
+		GROUP BY listing_status
+		ORDER BY listing_status ASC
+	`, args...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: err.Error()})
+		return
+	}
+	for listingStatusRows.Next() {
+		var value string
+		var count int
+		if scanErr := listingStatusRows.Scan(&value, &count); scanErr != nil {
+			continue
+		}
+		facets.ListingStatuses = append(facets.ListingStatuses, OpportunityFacetItemResponse{
+			Value: value,
+			Label: value,
+			Count: count,
+		})
+	}
+	listingStatusRows.Close()
+
 	bedroomRows, err := a.db.Query(`
 		SELECT sl.bedrooms, COUNT(*)
 	`+baseFrom+`
@@ -831,6 +1131,9 @@ func (a *api) handleListOpportunityFacets(w http.ResponseWriter, r *http.Request
 	var priceMax sql.NullInt64
 	var areaMin sql.NullFloat64
 	var areaMax sql.NullFloat64
+	var priceDropMax sql.NullFloat64
+	var daysOnMarketMin sql.NullInt64
+	var daysOnMarketMax sql.NullInt64
 
 	err = a.db.QueryRow(`
 		SELECT
@@ -839,9 +1142,14 @@ func (a *api) handleListOpportunityFacets(w http.ResponseWriter, r *http.Request
 			MIN(sl.price_cents),
 			MAX(sl.price_cents),
 			MIN(sl.area_m2),
-			MAX(sl.area_m2)
+			MAX(sl.area_m2),
+			MAX(`+opportunityPriceDropSQL+`),
+			COUNT(*) FILTER (WHERE `+opportunityPriceDropSQL+` > 0),
+			MIN(`+opportunityDaysOnMarketSQL+`),
+			MAX(`+opportunityDaysOnMarketSQL+`)
 	`+baseFrom+`
-	`, args...).Scan(&scoreMin, &scoreMax, &priceMin, &priceMax, &areaMin, &areaMax)
+	`, args...).Scan(&scoreMin, &scoreMax, &priceMin, &priceMax, &areaMin, &areaMax,
+		&priceDropMax, &facets.PriceDropCount, &daysOnMarketMin, &daysOnMarketMax)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: err.Error()})
 		return
@@ -865,6 +1173,15 @@ func (a *api) handleListOpportunityFacets(w http.ResponseWriter, r *http.Request
 	if areaMax.Valid {
 		facets.Ranges.AreaMax = areaMax.Float64
 	}
+	if priceDropMax.Valid {
+		facets.Ranges.PriceDropMaxPct = priceDropMax.Float64
+	}
+	if daysOnMarketMin.Valid {
+		facets.Ranges.DaysOnMarketMin = int(daysOnMarketMin.Int64)
+	}
+	if daysOnMarketMax.Valid {
+		facets.Ranges.DaysOnMarketMax = int(daysOnMarketMax.Int64)
+	}
 
 	writeJSON(w, http.StatusOK, facets)
 }
@@ -887,13 +1204,18 @@ func (a *api) handleUpdateOpportunityStatus(w http.ResponseWriter, r *http.Reque
 	}
 
 	var resp updateOpportunityStatusResponse
+	var previousStatus sql.NullString
 	err := a.db.QueryRow(`
-		UPDATE opportunities
+		WITH previous AS (
+			SELECT id, status FROM opportunities WHERE id = $2 FOR UPDATE
+		)
+		UPDATE opportunities o
 		SET status = $1,
 			updated_at = NOW()
-		WHERE id = $2
-		RETURNING id, status, updated_at
-	`, status, opportunityID).Scan(&resp.ID, &resp.Status, &resp.UpdatedAt)
+		FROM previous
+		WHERE o.id = previous.id
+		RETURNING o.id, o.status, o.updated_at, previous.status
+	`, status, opportunityID).Scan(&resp.ID, &resp.Status, &resp.UpdatedAt, &previousStatus)
 	if err == sql.ErrNoRows {
 		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "opportunity not found"})
 		return
@@ -903,6 +1225,17 @@ func (a *api) handleUpdateOpportunityStatus(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if previousStatus.String != resp.Status {
+		var changedBy *string
+		if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+			changedBy = &userID
+		}
+		if err := a.recordOpportunityStatusChange(resp.ID, opportunityChangeStatus,
+			previousStatus.String, resp.Status, changedBy, ""); err != nil {
+			log.Printf("opportunity history: failed to record status change of %s: %v", resp.ID, err)
+		}
+	}
+
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -0,0 +1,192 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math"
+
+	"github.com/lib/pq"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/listingcluster"
+)
+
+// clusterListing links a freshly upserted listing to the cluster of the first
+// listing from the same city that Match considers the same apartment, including
+// copies of the unit posted by other agencies on the same portal. When both
+// already belong to different clusters the clusters are merged. Failures are
+// logged and never fail the ingest.
+func (a *api) clusterListing(listingID string, listing IngestListing) {
+	if listing.AreaM2 <= 0 || listing.City == "" {
+		return
+	}
+
+	var currentCluster sql.NullString
+	var hashesJSON []byte
+	if err := a.db.QueryRow(`
+		SELECT cluster_id, image_hashes FROM source_listings WHERE id = $1
+	`, listingID).Scan(&currentCluster, &hashesJSON); err != nil {
+		log.Printf("opportunity clusters: failed to load listing %s: %v", listingID, err)
+		return
+	}
+
+	current := listingcluster.Listing{
+		ID:           listingID,
+		ClusterID:    currentCluster.String,
+		Source:       listing.Source,
+		Address:      listing.Address,
+		Neighborhood: listing.Neighborhood,
+		City:         listing.City,
+		AreaM2:       listing.AreaM2,
+		Bedrooms:     listing.Bedrooms,
+		PriceCents:   listing.PriceCents,
+		ImageHashes:  decodeImageHashes(hashesJSON),
+	}
+
+	// The SQL bands mirror Match so no real match is left out: same city, the area
+	// tolerance, compatible bedrooms and either the price tolerance or photos to
+	// compare. Neighborhood spelling variants are left to Match.
+	cfg := listingcluster.DefaultConfig()
+	minArea, maxArea := toleranceBand(listing.AreaM2, cfg.AreaTolerancePct)
+	minPrice, maxPrice := toleranceBand(float64(listing.PriceCents), cfg.PriceTolerancePct)
+	rows, err := a.db.Query(`
+		SELECT id, cluster_id, source, COALESCE(address, ''), COALESCE(neighborhood, ''),
+			COALESCE(city, ''), COALESCE(area_m2, 0), COALESCE(bedrooms, 0),
+			COALESCE(price_cents, 0), image_hashes
+		FROM source_listings
+		WHERE id <> $1
+			AND NOT (source = $2 AND source_listing_id = $3)
+			AND LOWER(city) = LOWER($4)
+			AND area_m2 BETWEEN $5 AND $6
+			AND ($7 = 0 OR COALESCE(bedrooms, 0) = 0 OR bedrooms = $7)
+			AND (
+				($8 > 0 AND price_cents BETWEEN $8 AND $9)
+				OR ($10::boolean AND jsonb_array_length(image_hashes) > 0)
+			)
+		ORDER BY first_seen_at ASC
+	`, listingID, listing.Source, listing.SourceListingID, listing.City,
+		minArea, maxArea, listing.Bedrooms, int64(math.Floor(minPrice)), int64(math.Ceil(maxPrice)), len(current.ImageHashes) > 0)
+	if err != nil {
+		log.Printf("opportunity clusters: failed to load candidates for %s: %v", listingID, err)
+		return
+	}
+
+	var match *listingcluster.Listing
+	for rows.Next() {
+		var candidate listingcluster.Listing
+		var clusterID sql.NullString
+		var candidateHashes []byte
+		if err := rows.Scan(
+			&candidate.ID, &clusterID, &candidate.Source, &candidate.Address,
+			&candidate.Neighborhood, &candidate.City, &candidate.AreaM2,
+			&candidate.Bedrooms, &candidate.PriceCents, &candidateHashes,
+		); err != nil {
+			continue
+		}
+		candidate.ClusterID = clusterID.String
+		candidate.ImageHashes = decodeImageHashes(candidateHashes)
+
+		if listingcluster.Match(current, candidate, cfg).Matched {
+			match = &candidate
+			break
+		}
+	}
+	rows.Close()
+
+	if match == nil {
+		return
+	}
+
+	target := match.ClusterID
+	if target == "" {
+		target = match.ID
+	}
+	if target == current.ClusterID {
+		return
+	}
+
+	if _, err := a.db.Exec(`
+		UPDATE source_listings SET cluster_id = $1, updated_at = NOW()
+		WHERE id IN ($2, $3)
+			OR cluster_id IN (
+				SELECT cluster_id FROM source_listings
+				WHERE id IN ($2, $3) AND cluster_id IS NOT NULL
+			)
+	`, target, listingID, match.ID); err != nil {
+		log.Printf("opportunity clusters: failed to merge %s into %s: %v", listingID, target, err)
+	}
+}
+
+// toleranceBand returns the values v' with relativeDiff(v, v') <= pct, the
+// inverse of the check listingcluster.Match applies
+func toleranceBand(value, pct float64) (float64, float64) {
+	if value <= 0 || pct <= 0 || pct >= 1 {
+		return value, value
+	}
+	return value * (1 - pct), value / (1 - pct)
+}
+
+// attachOpportunityClusters fills the source links and price spread of each
+// canonical card from every listing in its cluster
+func (a *api) attachOpportunityClusters(opportunities []OpportunityResponse) error {
+	if len(opportunities) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(opportunities))
+	for _, opp := range opportunities {
+		keys = append(keys, opp.clusterKey)
+	}
+
+	rows, err := a.db.Query(`
+		SELECT COALESCE(sl.cluster_id, sl.id), o.id, sl.source, sl.source_listing_id,
+			sl.canonical_url, COALESCE(sl.price_cents, 0)
+		FROM opportunities o
+		JOIN source_listings sl ON o.source_listing_id = sl.id
+		WHERE COALESCE(sl.cluster_id, sl.id) = ANY($1::uuid[])
+		ORDER BY sl.price_cents ASC NULLS LAST, sl.source ASC
+	`, pq.Array(keys))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	members := make(map[string][]OpportunitySourceLink, len(keys))
+	for rows.Next() {
+		var key string
+		var link OpportunitySourceLink
+		if err := rows.Scan(&key, &link.OpportunityID, &link.Source, &link.SourceListingID,
+			&link.CanonicalURL, &link.PriceCents); err != nil {
+			return err
+		}
+		members[key] = append(members[key], link)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range opportunities {
+		links := members[opportunities[i].clusterKey]
+		if len(links) < 2 {
+			continue
+		}
+		prices := make([]int64, 0, len(links))
+		for _, link := range links {
+			prices = append(prices, link.PriceCents)
+		}
+		opp := &opportunities[i]
+		opp.ClusterID = opp.clusterKey
+		opp.ListingCount = len(links)
+		opp.Sources = links
+		opp.LowestPriceCents, opp.HighestPriceCents, opp.PriceSpreadCents, opp.PriceSpreadPct = listingcluster.PriceSpread(prices)
+	}
+	return nil
+}
+
+func decodeImageHashes(raw []byte) []uint64 {
+	var values []string
+	if len(raw) == 0 || json.Unmarshal(raw, &values) != nil {
+		return nil
+	}
+	return listingcluster.ParseHashes(values)
+}
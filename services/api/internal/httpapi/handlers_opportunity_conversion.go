@@ -0,0 +1,219 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/offerintelligence"
+)
+
+// Set only by the conversion endpoint, never through PATCH /status
+const opportunityStatusConverted = "converted"
+
+type convertOpportunityRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+}
+
+type convertOpportunityResponse struct {
+	Prospect          prospect `json:"prospect"`
+	OpportunityID     string   `json:"opportunity_id"`
+	OpportunityStatus string   `json:"opportunity_status"`
+	FlipScoreJobID    *string  `json:"flip_score_job_id,omitempty"`
+}
+
+// POST /api/v1/opportunities/:id/convert
+// Creates a prospect from the scraped listing, marks the opportunity converted and
+// queues its Flip Score.
+func (a *api) handleConvertOpportunityToProspect(w http.ResponseWriter, r *http.Request, opportunityID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	var req convertOpportunityRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+
+	req.WorkspaceID = strings.TrimSpace(req.WorkspaceID)
+	if req.WorkspaceID == "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "workspace_id is required"})
+		return
+	}
+
+	if ok, err := a.hasWorkspaceMembership(r.Context(), req.WorkspaceID, userID); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check membership"})
+		return
+	} else if !ok {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "workspace not found"})
+		return
+	}
+
+	var (
+		previousStatus                       sql.NullString
+		title, description, canonicalURL     sql.NullString
+		address, neighborhood                sql.NullString
+		priceCents, condoFeeCents, iptuCents sql.NullInt64
+		areaM2                               sql.NullFloat64
+		bedrooms, bathrooms, parkingSpots    sql.NullInt64
+		source                               string
+	)
+	err := a.db.QueryRowContext(r.Context(), `
+		SELECT o.status, sl.source, sl.title, sl.description, sl.canonical_url, sl.address, sl.neighborhood,
+			sl.price_cents, sl.condo_fee_cents, sl.iptu_cents, sl.area_m2,
+			sl.bedrooms, sl.bathrooms, sl.parking_spots
+		FROM opportunities o
+		JOIN source_listings sl ON sl.id = o.source_listing_id
+		WHERE o.id = $1
+	`, opportunityID).Scan(&previousStatus, &source, &title, &description, &canonicalURL, &address, &neighborhood,
+		&priceCents, &condoFeeCents, &iptuCents, &areaM2, &bedrooms, &bathrooms, &parkingSpots)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "opportunity not found"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to load opportunity"})
+		return
+	}
+
+	var existingProspectID string
+	err = a.db.QueryRowContext(r.Context(), `
+		SELECT id FROM prospecting_properties
+		WHERE workspace_id = $1 AND source_opportunity_id = $2 AND deleted_at IS NULL
+	`, req.WorkspaceID, opportunityID).Scan(&existingProspectID)
+	if err == nil {
+		writeError(w, http.StatusConflict, apiError{
+			Code:    "ALREADY_CONVERTED",
+			Message: "opportunity already converted in this workspace",
+			Details: []string{existingProspectID},
+		})
+		return
+	}
+	if err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check conversion"})
+		return
+	}
+
+	requestID := r.Header.Get("X-Request-ID")
+	if !a.enforceProspectCreation(w, r, userID, req.WorkspaceID, requestID) {
+		return
+	}
+
+	// Portal values are in cents; prospects store reais
+	var askingPrice, condoFee, iptu, areaUsable *float64
+	if priceCents.Valid && priceCents.Int64 > 0 {
+		askingPrice = centsToReais(priceCents.Int64)
+	}
+	if condoFeeCents.Valid && condoFeeCents.Int64 > 0 {
+		condoFee = centsToReais(condoFeeCents.Int64)
+	}
+	if iptuCents.Valid && iptuCents.Int64 > 0 {
+		iptu = centsToReais(iptuCents.Int64)
+	}
+	if areaM2.Valid && areaM2.Float64 > 0 {
+		areaUsable = &areaM2.Float64
+	}
+	var comments *string
+	if title.Valid && strings.TrimSpace(title.String) != "" {
+		comment := source + ": " + strings.TrimSpace(title.String)
+		comments = &comment
+	}
+	tags := []string{"oportunidade"}
+
+	var p prospect
+	var tagsBytes, auctionTerms []byte
+	err = a.db.QueryRowContext(
+		r.Context(),
+		`INSERT INTO prospecting_properties
+			(workspace_id, link, neighborhood, address, area_usable, bedrooms, bathrooms, parking,
+			 condo_fee, iptu, asking_price, comments, tags, listing_text, acquisition_mode, source_opportunity_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		 RETURNING id, workspace_id, status, link, neighborhood, address,
+		           area_usable, bedrooms, suites, bathrooms, gas, floor, elevator, face, parking,
+		           condo_fee, iptu, asking_price, agency, broker_name, broker_phone,
+		           comments, tags, created_at, updated_at,
+		           offer_price, expected_sale_price, renovation_cost_estimate, hold_months, other_costs_estimate,
+		           acquisition_mode, auction_terms_json, listing_text`,
+		req.WorkspaceID, nullStringPtr(canonicalURL), nullStringPtr(neighborhood), nullStringPtr(address), areaUsable,
+		nullIntPtr(bedrooms), nullIntPtr(bathrooms), nullIntPtr(parkingSpots),
+		condoFee, iptu, askingPrice, comments, pq.Array(tags), nullStringPtr(description),
+		offerintelligence.AcquisitionModeMarket, opportunityID,
+	).Scan(
+		&p.ID, &p.WorkspaceID, &p.Status, &p.Link, &p.Neighborhood, &p.Address,
+		&p.AreaUsable, &p.Bedrooms, &p.Suites, &p.Bathrooms, &p.Gas, &p.Floor, &p.Elevator, &p.Face, &p.Parking,
+		&p.CondoFee, &p.IPTU, &p.AskingPrice, &p.Agency, &p.BrokerName, &p.BrokerPhone,
+		&p.Comments, &tagsBytes, &p.CreatedAt, &p.UpdatedAt,
+		&p.OfferPrice, &p.ExpectedSalePrice, &p.RenovationCostEstimate, &p.HoldMonths, &p.OtherCostsEstimate,
+		&p.AcquisitionMode, &auctionTerms, &p.ListingText,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeError(w, http.StatusConflict, apiError{Code: "ALREADY_CONVERTED", Message: "opportunity already converted in this workspace"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create prospect", Details: []string{err.Error()}})
+		return
+	}
+	p.Tags = parseTags(tagsBytes)
+	p.AuctionTerms = decodeAuctionTerms(p.AcquisitionMode, auctionTerms)
+	p.PricePerSqm = computePricePerSqm(p.AskingPrice, p.AreaUsable)
+
+	resp := convertOpportunityResponse{
+		Prospect:          p,
+		OpportunityID:     opportunityID,
+		OpportunityStatus: previousStatus.String,
+	}
+
+	if _, err := a.db.ExecContext(r.Context(), `
+		UPDATE opportunities SET status = $1, updated_at = NOW() WHERE id = $2
+	`, opportunityStatusConverted, opportunityID); err != nil {
+		log.Printf("opportunity conversion: failed to mark %s converted: %v", opportunityID, err)
+	} else {
+		resp.OpportunityStatus = opportunityStatusConverted
+		if previousStatus.String != opportunityStatusConverted {
+			if err := a.recordOpportunityStatusChange(opportunityID, opportunityChangeStatus,
+				previousStatus.String, opportunityStatusConverted, &userID, ""); err != nil {
+				log.Printf("opportunity history: failed to record conversion of %s: %v", opportunityID, err)
+			}
+		}
+	}
+
+	// The prospect exists either way; a failed queue only delays the score
+	if jobID, err := a.queueProspectFlipScore(r.Context(), req.WorkspaceID, p.ID, userID); err != nil {
+		log.Printf("opportunity conversion: failed to queue flip score prospect_id=%s: %v", p.ID, err)
+	} else {
+		resp.FlipScoreJobID = &jobID
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func centsToReais(cents int64) *float64 {
+	value := float64(cents) / 100
+	return &value
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid || strings.TrimSpace(value.String) == "" {
+		return nil
+	}
+	return &value.String
+}
+
+func nullIntPtr(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	v := int(value.Int64)
+	return &v
+}
@@ -0,0 +1,178 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+const convertOpportunityBody = `{"workspace_id":"workspace-1"}`
+
+func expectOpportunityListing(mock sqlmock.Sqlmock, opportunityID, status string) {
+	mock.ExpectQuery(regexp.QuoteMeta("JOIN source_listings sl ON sl.id = o.source_listing_id")).
+		WithArgs(opportunityID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"status", "source", "title", "description", "canonical_url", "address", "neighborhood",
+			"price_cents", "condo_fee_cents", "iptu_cents", "area_m2",
+			"bedrooms", "bathrooms", "parking_spots",
+		}).AddRow(
+			status, "zap", "Apartamento 2 quartos", "Apartamento reformado, 2 vagas", "https://zap.example/123",
+			"Rua Teste, 100", "Moema",
+			int64(65000000), int64(85050), int64(12000), 70.0,
+			int64(2), int64(1), int64(2),
+		))
+}
+
+func expectNoConvertedProspect(mock sqlmock.Sqlmock, workspaceID, opportunityID string) {
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE workspace_id = $1 AND source_opportunity_id = $2 AND deleted_at IS NULL")).
+		WithArgs(workspaceID, opportunityID).
+		WillReturnError(sql.ErrNoRows)
+}
+
+func expectProspectCreationBilling(mock sqlmock.Sqlmock, workspaceID, ownerUserID, status string) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT created_by_user_id FROM workspaces WHERE id = $1")).
+		WithArgs(workspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"created_by_user_id"}).AddRow(ownerUserID))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_billing")).
+		WithArgs(ownerUserID).
+		WillReturnRows(userBillingRows(ownerUserID, "pro", status))
+}
+
+func TestConvertOpportunityToProspect(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	expectOpportunityListing(mock, "opp-1", "new")
+	expectNoConvertedProspect(mock, "workspace-1", "opp-1")
+	expectProspectCreationBilling(mock, "workspace-1", "owner-1", "active")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT current_period_start, current_period_end, status")).
+		WithArgs("owner-1").
+		WillReturnRows(sqlmock.NewRows([]string{"current_period_start", "current_period_end", "status"}).AddRow(nil, nil, "active"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM prospecting_properties")).
+		WithArgs("workspace-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	// Cents become reais, the description becomes the listing text and parking_spots the parking
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO prospecting_properties")).
+		WithArgs(
+			"workspace-1", "https://zap.example/123", "Moema", "Rua Teste, 100", 70.0,
+			2, 1, 2,
+			850.5, 120.0, 650000.0, "zap: Apartamento 2 quartos", sqlmock.AnyArg(), "Apartamento reformado, 2 vagas",
+			"market", "opp-1",
+		).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "workspace_id", "status", "link", "neighborhood", "address",
+			"area_usable", "bedrooms", "suites", "bathrooms", "gas", "floor", "elevator", "face", "parking",
+			"condo_fee", "iptu", "asking_price", "agency", "broker_name", "broker_phone",
+			"comments", "tags", "created_at", "updated_at",
+			"offer_price", "expected_sale_price", "renovation_cost_estimate", "hold_months", "other_costs_estimate",
+			"acquisition_mode", "auction_terms_json", "listing_text",
+		}).AddRow(
+			"prospect-1", "workspace-1", "active", "https://zap.example/123", "Moema", "Rua Teste, 100",
+			70.0, 2, nil, 1, nil, nil, nil, nil, 2,
+			850.5, 120.0, 650000.0, nil, nil, nil,
+			"zap: Apartamento 2 quartos", []byte("{oportunidade}"), now, now,
+			nil, nil, nil, nil, nil,
+			"market", nil, "Apartamento reformado, 2 vagas",
+		))
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE opportunities SET status = $1")).
+		WithArgs("converted", "opp-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO opportunity_status_changes")).
+		WithArgs("opp-1", opportunityChangeStatus, "new", "converted", "user-1", nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// No active recompute job: the score gets a single-item job
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, FlipScoreJobStatusRunning).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO flip_score_recompute_jobs")).
+		WithArgs("workspace-1", FlipScoreJobStatusQueued, "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO flip_score_recompute_job_items")).
+		WithArgs("job-1", "prospect-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET total_items = total_items + 1")).
+		WithArgs("job-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/opportunities/opp-1/convert", convertOpportunityBody, "user-1")
+
+	a.handleConvertOpportunityToProspect(rr, req, "opp-1")
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var resp convertOpportunityResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.OpportunityStatus != opportunityStatusConverted || resp.FlipScoreJobID == nil || *resp.FlipScoreJobID != "job-1" {
+		t.Fatalf("opportunity_status=%s flip_score_job_id=%v", resp.OpportunityStatus, resp.FlipScoreJobID)
+	}
+	p := resp.Prospect
+	if p.ID != "prospect-1" || p.ListingText == nil || p.Parking == nil || *p.Parking != 2 {
+		t.Fatalf("prospect=%+v", p)
+	}
+	if len(p.Tags) != 1 || p.Tags[0] != "oportunidade" || p.PricePerSqm == nil {
+		t.Fatalf("tags=%v price_per_sqm=%v", p.Tags, p.PricePerSqm)
+	}
+}
+
+func TestConvertOpportunityToProspectAlreadyConverted(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	expectOpportunityListing(mock, "opp-1", "converted")
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE workspace_id = $1 AND source_opportunity_id = $2 AND deleted_at IS NULL")).
+		WithArgs("workspace-1", "opp-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("prospect-1"))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/opportunities/opp-1/convert", convertOpportunityBody, "user-1")
+
+	a.handleConvertOpportunityToProspect(rr, req, "opp-1")
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusConflict, rr.Body.String())
+	}
+	if got := decodeAPIErrorCode(t, rr); got != "ALREADY_CONVERTED" {
+		t.Fatalf("error.code=%s want=ALREADY_CONVERTED", got)
+	}
+}
+
+func TestConvertOpportunityToProspectEnforcesProspectCreation(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	expectWorkspaceMembership(mock, "workspace-1", "user-1")
+	expectOpportunityListing(mock, "opp-1", "new")
+	expectNoConvertedProspect(mock, "workspace-1", "opp-1")
+	// Blocked before any prospect is created or the opportunity changes
+	expectProspectCreationBilling(mock, "workspace-1", "owner-1", "past_due")
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/opportunities/opp-1/convert", convertOpportunityBody, "user-1")
+
+	a.handleConvertOpportunityToProspect(rr, req, "opp-1")
+
+	if rr.Code != StatusPaymentRequired {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, StatusPaymentRequired, rr.Body.String())
+	}
+	if got := decodeAPIErrorCode(t, rr); got != ErrCodePaywallRequired {
+		t.Fatalf("error.code=%s want=%s", got, ErrCodePaywallRequired)
+	}
+}
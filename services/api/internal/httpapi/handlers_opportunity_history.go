@@ -0,0 +1,291 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/zapscraper"
+)
+
+// Opportunity change types and listing statuses
+const (
+	opportunityChangeStatus        = "status"
+	opportunityChangeListingStatus = "listing_status"
+
+	opportunityListingActive   = "active"
+	opportunityListingDelisted = "delisted"
+)
+
+// A listing missing from this many consecutive complete runs, and unseen for the
+// grace period, is considered sold or withdrawn
+const (
+	delistAfterMissedRuns = 2
+	delistGracePeriod     = 72 * time.Hour
+)
+
+type listingUpsertResult struct {
+	ListingID          string
+	IsNew              bool
+	PriceChanged       bool
+	PriceDropped       bool
+	Relisted           bool
+	PreviousPriceCents int64
+}
+
+// listingRunScope is the search a scraper run covered. Sources only lists the
+// portals whose results were complete (below the run limit): a listing missing
+// from a truncated result may just be ranked lower.
+type listingRunScope struct {
+	JobRunID     string
+	State        string
+	City         string
+	Neighborhood string
+	Sources      []string
+}
+
+type OpportunityPriceHistoryEntry struct {
+	PriceCents         int64     `json:"price_cents"`
+	PreviousPriceCents *int64    `json:"previous_price_cents,omitempty"`
+	ChangePct          *float64  `json:"change_pct,omitempty"`
+	JobRunID           *string   `json:"job_run_id,omitempty"`
+	ObservedAt         time.Time `json:"observed_at"`
+}
+
+type OpportunityStatusChangeResponse struct {
+	ChangeType string    `json:"change_type"`
+	FromValue  *string   `json:"from_value,omitempty"`
+	ToValue    string    `json:"to_value"`
+	ChangedBy  *string   `json:"changed_by,omitempty"`
+	JobRunID   *string   `json:"job_run_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type OpportunityHistoryResponse struct {
+	OpportunityID string                            `json:"opportunity_id"`
+	PriceHistory  []OpportunityPriceHistoryEntry    `json:"price_history"`
+	StatusChanges []OpportunityStatusChangeResponse `json:"status_changes"`
+}
+
+func (a *api) recordListingPrice(listingID, jobRunID string, priceCents, previousPriceCents int64) error {
+	if priceCents <= 0 {
+		return nil
+	}
+	var previous interface{}
+	if previousPriceCents > 0 {
+		previous = previousPriceCents
+	}
+	_, err := a.db.Exec(`
+		INSERT INTO source_listing_price_history (source_listing_id, price_cents, previous_price_cents, job_run_id)
+		VALUES ($1, $2, $3, $4)
+	`, listingID, priceCents, previous, nullableJobRunID(jobRunID))
+	return err
+}
+
+func (a *api) recordOpportunityStatusChange(opportunityID, changeType, fromValue, toValue string, changedBy *string, jobRunID string) error {
+	var from interface{}
+	if fromValue != "" {
+		from = fromValue
+	}
+	_, err := a.db.Exec(`
+		INSERT INTO opportunity_status_changes (opportunity_id, change_type, from_value, to_value, changed_by, job_run_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, opportunityID, changeType, from, toValue, changedBy, nullableJobRunID(jobRunID))
+	return err
+}
+
+// detectDelistedListings counts a miss for every active listing of the scope not
+// seen by the run and flags the ones past the threshold as delisted
+func (a *api) detectDelistedListings(ctx context.Context, scope listingRunScope) (int, error) {
+	if len(scope.Sources) == 0 || scope.JobRunID == "" {
+		return 0, nil
+	}
+
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT sl.id, o.id, COALESCE(sl.city, ''), COALESCE(sl.neighborhood, '')
+		FROM source_listings sl
+		JOIN opportunities o ON o.source_listing_id = sl.id
+		WHERE sl.source = ANY($1)
+			AND sl.delisted_at IS NULL
+			AND sl.last_seen_job_run_id IS DISTINCT FROM $2
+			AND (COALESCE(sl.state, '') = '' OR LOWER(sl.state) = LOWER($3))
+	`, pq.Array(scope.Sources), scope.JobRunID, scope.State)
+	if err != nil {
+		return 0, err
+	}
+
+	opportunityByListing := make(map[string]string)
+	missed := make([]string, 0)
+	for rows.Next() {
+		var listingID, opportunityID, city, neighborhood string
+		if err := rows.Scan(&listingID, &opportunityID, &city, &neighborhood); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if !zapscraper.SameLocation(city, scope.City) || !zapscraper.SameLocation(neighborhood, scope.Neighborhood) {
+			continue
+		}
+		opportunityByListing[listingID] = opportunityID
+		missed = append(missed, listingID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(missed) == 0 {
+		return 0, nil
+	}
+
+	delistedRows, err := a.db.QueryContext(ctx, `
+		UPDATE source_listings SET
+			missed_runs = missed_runs + 1,
+			delisted_at = CASE
+				WHEN missed_runs + 1 >= $2 AND last_seen_at < $3 THEN NOW()
+				ELSE delisted_at
+			END,
+			updated_at = NOW()
+		WHERE id = ANY($1::uuid[])
+		RETURNING id, delisted_at IS NOT NULL
+	`, pq.Array(missed), delistAfterMissedRuns, time.Now().Add(-delistGracePeriod))
+	if err != nil {
+		return 0, err
+	}
+
+	delisted := make([]string, 0)
+	for delistedRows.Next() {
+		var listingID string
+		var isDelisted bool
+		if err := delistedRows.Scan(&listingID, &isDelisted); err != nil {
+			delistedRows.Close()
+			return 0, err
+		}
+		if isDelisted {
+			delisted = append(delisted, listingID)
+		}
+	}
+	delistedRows.Close()
+	if err := delistedRows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, listingID := range delisted {
+		if err := a.recordOpportunityStatusChange(opportunityByListing[listingID], opportunityChangeListingStatus,
+			opportunityListingActive, opportunityListingDelisted, nil, scope.JobRunID); err != nil {
+			log.Printf("opportunity history: failed to record delisting of %s: %v", listingID, err)
+		}
+	}
+
+	return len(delisted), nil
+}
+
+// GET /api/v1/opportunities/:id/history
+func (a *api) handleGetOpportunityHistory(w http.ResponseWriter, r *http.Request, opportunityID string) {
+	var listingID string
+	err := a.db.QueryRowContext(r.Context(), `
+		SELECT source_listing_id FROM opportunities WHERE id = $1
+	`, opportunityID).Scan(&listingID)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "opportunity not found"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: err.Error()})
+		return
+	}
+
+	resp := OpportunityHistoryResponse{
+		OpportunityID: opportunityID,
+		PriceHistory:  []OpportunityPriceHistoryEntry{},
+		StatusChanges: []OpportunityStatusChangeResponse{},
+	}
+
+	priceRows, err := a.db.QueryContext(r.Context(), `
+		SELECT price_cents, previous_price_cents, job_run_id, observed_at
+		FROM source_listing_price_history
+		WHERE source_listing_id = $1
+		ORDER BY observed_at ASC
+	`, listingID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: err.Error()})
+		return
+	}
+	for priceRows.Next() {
+		var entry OpportunityPriceHistoryEntry
+		var previous sql.NullInt64
+		var jobRunID sql.NullString
+		if scanErr := priceRows.Scan(&entry.PriceCents, &previous, &jobRunID, &entry.ObservedAt); scanErr != nil {
+			continue
+		}
+		if previous.Valid && previous.Int64 > 0 {
+			entry.PreviousPriceCents = &previous.Int64
+			changePct := math.Round(float64(entry.PriceCents-previous.Int64)/float64(previous.Int64)*10000) / 10000
+			entry.ChangePct = &changePct
+		}
+		if jobRunID.Valid {
+			entry.JobRunID = &jobRunID.String
+		}
+		resp.PriceHistory = append(resp.PriceHistory, entry)
+	}
+	priceRows.Close()
+
+	changeRows, err := a.db.QueryContext(r.Context(), `
+		SELECT change_type, from_value, to_value, changed_by, job_run_id, created_at
+		FROM opportunity_status_changes
+		WHERE opportunity_id = $1
+		ORDER BY created_at ASC
+	`, opportunityID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: err.Error()})
+		return
+	}
+	for changeRows.Next() {
+		var change OpportunityStatusChangeResponse
+		var fromValue, changedBy, jobRunID sql.NullString
+		if scanErr := changeRows.Scan(&change.ChangeType, &fromValue, &change.ToValue, &changedBy, &jobRunID, &change.CreatedAt); scanErr != nil {
+			continue
+		}
+		if fromValue.Valid {
+			change.FromValue = &fromValue.String
+		}
+		if changedBy.Valid {
+			change.ChangedBy = &changedBy.String
+		}
+		if jobRunID.Valid {
+			change.JobRunID = &jobRunID.String
+		}
+		resp.StatusChanges = append(resp.StatusChanges, change)
+	}
+	changeRows.Close()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// listingPriceDropPct is the cut from the highest price seen (0 when none)
+func listingPriceDropPct(peakPriceCents, priceCents int64) float64 {
+	if peakPriceCents <= 0 || priceCents <= 0 || priceCents >= peakPriceCents {
+		return 0
+	}
+	return math.Round(float64(peakPriceCents-priceCents)/float64(peakPriceCents)*10000) / 10000
+}
+
+func clampOpportunityScore(score int) int {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
+
+func nullableJobRunID(jobRunID string) interface{} {
+	if jobRunID == "" {
+		return nil
+	}
+	return jobRunID
+}
@@ -0,0 +1,112 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+// timeNear matches a time argument within a second of want
+type timeNear struct{ want time.Time }
+
+func (m timeNear) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	if !ok {
+		return false
+	}
+	diff := t.Sub(m.want)
+	return diff > -time.Second && diff < time.Second
+}
+
+func TestDetectDelistedListingsFlagsListingsPastTheThreshold(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("AND sl.last_seen_job_run_id IS DISTINCT FROM $2")).
+		WithArgs(`{"zap"}`, "run-2", "SP").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "opportunity_id", "city", "neighborhood"}).
+			AddRow("listing-1", "opp-1", "São Paulo", "Vila Mariana").
+			AddRow("listing-2", "opp-2", "sao paulo", "vila mariana").
+			// other neighborhood and other city: not covered by the run
+			AddRow("listing-3", "opp-3", "São Paulo", "Moema").
+			AddRow("listing-4", "opp-4", "Curitiba", "Vila Mariana"))
+
+	// Two missed runs and 72h unseen are both required; the database applies them per listing
+	mock.ExpectQuery(regexp.QuoteMeta("missed_runs = missed_runs + 1")).
+		WithArgs(`{"listing-1","listing-2"}`, delistAfterMissedRuns, timeNear{want: time.Now().Add(-delistGracePeriod)}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "delisted"}).
+			AddRow("listing-1", true).
+			AddRow("listing-2", false))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO opportunity_status_changes")).
+		WithArgs("opp-1", opportunityChangeListingStatus, opportunityListingActive, opportunityListingDelisted, nil, "run-2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	delisted, err := a.detectDelistedListings(context.Background(), listingRunScope{
+		JobRunID:     "run-2",
+		State:        "SP",
+		City:         "Sao Paulo",
+		Neighborhood: "Vila Mariana",
+		Sources:      []string{"zap"},
+	})
+	if err != nil {
+		t.Fatalf("detectDelistedListings: %v", err)
+	}
+	if delisted != 1 {
+		t.Fatalf("delisted=%d want=1", delisted)
+	}
+}
+
+func TestDetectDelistedListingsSkipsIncompleteRuns(t *testing.T) {
+	a, _, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	// Every source hit the run limit: a missing listing may just be ranked lower
+	delisted, err := a.detectDelistedListings(context.Background(), listingRunScope{
+		JobRunID: "run-2",
+		City:     "Sao Paulo",
+	})
+	if err != nil || delisted != 0 {
+		t.Fatalf("delisted=%d err=%v", delisted, err)
+	}
+}
+
+func TestUpsertListingRelistsDelistedListing(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	delistedAt := time.Now().Add(-24 * time.Hour)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE source = $1 AND source_listing_id = $2")).
+		WithArgs("zap", "123").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "price_cents", "peak_price_cents", "last_price_drop_at", "delisted_at"}).
+			AddRow("listing-1", int64(50000000), int64(50000000), nil, delistedAt))
+	// Seen again: the miss count and the delisting are reset
+	args := make([]driver.Value, 20)
+	for i := range args {
+		args[i] = sqlmock.AnyArg()
+	}
+	args[18], args[19] = "run-3", "listing-1"
+	mock.ExpectExec(regexp.QuoteMeta("last_seen_job_run_id = $19, missed_runs = 0, delisted_at = NULL")).
+		WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE opportunities SET")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("opp-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO opportunity_status_changes")).
+		WithArgs("opp-1", opportunityChangeListingStatus, opportunityListingDelisted, opportunityListingActive, nil, "run-3").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	result, err := a.upsertListing(IngestListing{
+		Source:          "zap",
+		SourceListingID: "123",
+		PriceCents:      50000000,
+	}, "run-3")
+	if err != nil {
+		t.Fatalf("upsertListing: %v", err)
+	}
+	if !result.Relisted || result.IsNew || result.PriceChanged {
+		t.Fatalf("result=%+v", result)
+	}
+}
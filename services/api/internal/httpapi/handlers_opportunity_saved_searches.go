@@ -0,0 +1,840 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+)
+
+// Alert reasons
+const (
+	opportunityAlertNew       = "new"
+	opportunityAlertPriceDrop = "price_drop"
+)
+
+const (
+	maxSavedSearchNameLength      = 120
+	defaultOpportunityAlertsLimit = 50
+	maxOpportunityAlertsLimit     = 200
+	// Alerts listed in one digest email; the email links to the rest
+	maxOpportunityDigestItems = 20
+)
+
+// opportunitySavedSearchFilters mirrors the GET /opportunities query parameters
+type opportunitySavedSearchFilters struct {
+	State         string   `json:"state,omitempty"`
+	City          string   `json:"city,omitempty"`
+	Neighborhood  string   `json:"neighborhood,omitempty"`
+	MinScore      int      `json:"min_score,omitempty"`
+	MinPrice      int64    `json:"min_price,omitempty"`
+	MaxPrice      int64    `json:"max_price,omitempty"`
+	MinArea       float64  `json:"min_area,omitempty"`
+	MaxArea       float64  `json:"max_area,omitempty"`
+	Bedrooms      []int    `json:"bedrooms,omitempty"`
+	Status        []string `json:"status,omitempty"`
+	ListingStatus string   `json:"listing_status,omitempty"`
+	MinPriceDrop  float64  `json:"min_price_drop,omitempty"`
+}
+
+type OpportunitySavedSearchResponse struct {
+	ID            string                        `json:"id"`
+	WorkspaceID   string                        `json:"workspace_id"`
+	Name          string                        `json:"name"`
+	Filters       opportunitySavedSearchFilters `json:"filters"`
+	AlertsEnabled bool                          `json:"alerts_enabled"`
+	EmailDigest   bool                          `json:"email_digest"`
+	UnreadAlerts  int                           `json:"unread_alerts"`
+	LastAlertedAt *time.Time                    `json:"last_alerted_at,omitempty"`
+	CreatedAt     time.Time                     `json:"created_at"`
+	UpdatedAt     time.Time                     `json:"updated_at"`
+}
+
+type listOpportunitySavedSearchesResponse struct {
+	Items []OpportunitySavedSearchResponse `json:"items"`
+}
+
+type createOpportunitySavedSearchRequest struct {
+	WorkspaceID   string                        `json:"workspace_id"`
+	Name          string                        `json:"name"`
+	Filters       opportunitySavedSearchFilters `json:"filters"`
+	AlertsEnabled *bool                         `json:"alerts_enabled"`
+	EmailDigest   bool                          `json:"email_digest"`
+}
+
+type updateOpportunitySavedSearchRequest struct {
+	Name          *string                        `json:"name"`
+	Filters       *opportunitySavedSearchFilters `json:"filters"`
+	AlertsEnabled *bool                          `json:"alerts_enabled"`
+	EmailDigest   *bool                          `json:"email_digest"`
+}
+
+type OpportunityAlertResponse struct {
+	ID                 string     `json:"id"`
+	SavedSearchID      string     `json:"saved_search_id"`
+	SavedSearchName    string     `json:"saved_search_name"`
+	OpportunityID      string     `json:"opportunity_id"`
+	Reason             string     `json:"reason"`
+	PriceCents         *int64     `json:"price_cents,omitempty"`
+	PreviousPriceCents *int64     `json:"previous_price_cents,omitempty"`
+	Title              string     `json:"title"`
+	Neighborhood       string     `json:"neighborhood"`
+	City               string     `json:"city"`
+	Score              int        `json:"score"`
+	DeepLink           string     `json:"deep_link"`
+	ReadAt             *time.Time `json:"read_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+type listOpportunityAlertsResponse struct {
+	Items       []OpportunityAlertResponse `json:"items"`
+	UnreadCount int                        `json:"unread_count"`
+}
+
+// Without ids every unread alert of the user in the workspace is marked read
+type markOpportunityAlertsReadRequest struct {
+	WorkspaceID string   `json:"workspace_id"`
+	IDs         []string `json:"ids"`
+}
+
+type markOpportunityAlertsReadResponse struct {
+	Updated int `json:"updated"`
+}
+
+// opportunityAlertCandidate is a listing from an ingest worth alerting on
+type opportunityAlertCandidate struct {
+	ListingID          string
+	Reason             string
+	PriceCents         int64
+	PreviousPriceCents int64
+}
+
+// listFilters parses the saved filters exactly like the list endpoint does
+func (f opportunitySavedSearchFilters) listFilters() opportunityListFilters {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("state", f.State)
+	set("city", f.City)
+	set("neighborhood", f.Neighborhood)
+	set("listing_status", f.ListingStatus)
+	if f.MinScore > 0 {
+		set("min_score", strconv.Itoa(f.MinScore))
+	}
+	if f.MinPrice > 0 {
+		set("min_price", strconv.FormatInt(f.MinPrice, 10))
+	}
+	if f.MaxPrice > 0 {
+		set("max_price", strconv.FormatInt(f.MaxPrice, 10))
+	}
+	if f.MinArea > 0 {
+		set("min_area", strconv.FormatFloat(f.MinArea, 'f', -1, 64))
+	}
+	if f.MaxArea > 0 {
+		set("max_area", strconv.FormatFloat(f.MaxArea, 'f', -1, 64))
+	}
+	if f.MinPriceDrop > 0 {
+		set("min_price_drop", strconv.FormatFloat(f.MinPriceDrop, 'f', -1, 64))
+	}
+	if len(f.Bedrooms) > 0 {
+		bedrooms := make([]string, 0, len(f.Bedrooms))
+		for _, bedroom := range f.Bedrooms {
+			bedrooms = append(bedrooms, strconv.Itoa(bedroom))
+		}
+		set("bedrooms", strings.Join(bedrooms, ","))
+	}
+	set("status", strings.Join(f.Status, ","))
+	return parseOpportunityListFilters(q)
+}
+
+func (f opportunitySavedSearchFilters) validate() error {
+	if f.MinScore < 0 || f.MinScore > 100 {
+		return fmt.Errorf("min_score must be between 0 and 100")
+	}
+	if f.MinPrice < 0 || f.MaxPrice < 0 || f.MinArea < 0 || f.MaxArea < 0 || f.MinPriceDrop < 0 {
+		return fmt.Errorf("filters cannot be negative")
+	}
+	if f.MaxPrice > 0 && f.MinPrice > f.MaxPrice {
+		return fmt.Errorf("min_price cannot exceed max_price")
+	}
+	if f.MaxArea > 0 && f.MinArea > f.MaxArea {
+		return fmt.Errorf("min_area cannot exceed max_area")
+	}
+	for _, status := range f.Status {
+		if _, ok := allowedOpportunityStatuses[status]; !ok && status != opportunityStatusConverted {
+			return fmt.Errorf("invalid status: %s", status)
+		}
+	}
+	switch f.ListingStatus {
+	case "", opportunityListingActive, opportunityListingDelisted:
+	default:
+		return fmt.Errorf("invalid listing_status: %s", f.ListingStatus)
+	}
+	return nil
+}
+
+func opportunityDeepLink(opportunityID string) string {
+	return "/app/opportunities?opportunity=" + url.QueryEscape(opportunityID)
+}
+
+// GET /api/v1/opportunities/saved-searches?workspace_id=
+func (a *api) handleListOpportunitySavedSearches(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	workspaceID := r.URL.Query().Get("workspace_id")
+	if workspaceID == "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "workspace_id required"})
+		return
+	}
+	if ok, err := a.hasWorkspaceMembership(r.Context(), workspaceID, userID); err != nil || !ok {
+		writeError(w, http.StatusForbidden, apiError{Code: "FORBIDDEN", Message: "access denied"})
+		return
+	}
+
+	rows, err := a.db.QueryContext(r.Context(), `
+		SELECT s.id, s.workspace_id, s.name, s.filters, s.alerts_enabled, s.email_digest,
+			(SELECT COUNT(*) FROM opportunity_alerts al WHERE al.saved_search_id = s.id AND al.read_at IS NULL),
+			s.last_alerted_at, s.created_at, s.updated_at
+		FROM opportunity_saved_searches s
+		WHERE s.workspace_id = $1 AND s.user_id = $2
+		ORDER BY s.name ASC
+	`, workspaceID, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to list saved searches"})
+		return
+	}
+	defer rows.Close()
+
+	items := make([]OpportunitySavedSearchResponse, 0)
+	for rows.Next() {
+		item, err := scanOpportunitySavedSearch(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to read saved search"})
+			return
+		}
+		items = append(items, item)
+	}
+
+	writeJSON(w, http.StatusOK, listOpportunitySavedSearchesResponse{Items: items})
+}
+
+// POST /api/v1/opportunities/saved-searches
+func (a *api) handleCreateOpportunitySavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	var req createOpportunitySavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "INVALID_BODY", Message: "invalid json body"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.WorkspaceID == "" || req.Name == "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "workspace_id and name required"})
+		return
+	}
+	if len(req.Name) > maxSavedSearchNameLength {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "name is too long"})
+		return
+	}
+	if err := req.Filters.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+		return
+	}
+	if ok, err := a.hasWorkspaceMembership(r.Context(), req.WorkspaceID, userID); err != nil || !ok {
+		writeError(w, http.StatusForbidden, apiError{Code: "FORBIDDEN", Message: "access denied"})
+		return
+	}
+
+	alertsEnabled := true
+	if req.AlertsEnabled != nil {
+		alertsEnabled = *req.AlertsEnabled
+	}
+	filtersJSON, _ := json.Marshal(req.Filters)
+
+	item, err := scanOpportunitySavedSearch(a.db.QueryRowContext(r.Context(), `
+		INSERT INTO opportunity_saved_searches (workspace_id, user_id, name, filters, alerts_enabled, email_digest)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, workspace_id, name, filters, alerts_enabled, email_digest, 0,
+			last_alerted_at, created_at, updated_at
+	`, req.WorkspaceID, userID, req.Name, filtersJSON, alertsEnabled, req.EmailDigest))
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeError(w, http.StatusConflict, apiError{Code: "CONFLICT", Message: "a saved search with this name already exists"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create saved search"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// PATCH /api/v1/opportunities/saved-searches/:id
+func (a *api) handleUpdateOpportunitySavedSearch(w http.ResponseWriter, r *http.Request, searchID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	var req updateOpportunitySavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "INVALID_BODY", Message: "invalid json body"})
+		return
+	}
+
+	var name interface{}
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" || len(trimmed) > maxSavedSearchNameLength {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid name"})
+			return
+		}
+		name = trimmed
+	}
+	var filtersJSON interface{}
+	if req.Filters != nil {
+		if err := req.Filters.validate(); err != nil {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+			return
+		}
+		encoded, _ := json.Marshal(req.Filters)
+		filtersJSON = encoded
+	}
+	var alertsEnabled, emailDigest interface{}
+	if req.AlertsEnabled != nil {
+		alertsEnabled = *req.AlertsEnabled
+	}
+	if req.EmailDigest != nil {
+		emailDigest = *req.EmailDigest
+	}
+
+	item, err := scanOpportunitySavedSearch(a.db.QueryRowContext(r.Context(), `
+		UPDATE opportunity_saved_searches s SET
+			name = COALESCE($1, s.name),
+			filters = COALESCE($2::jsonb, s.filters),
+			alerts_enabled = COALESCE($3, s.alerts_enabled),
+			email_digest = COALESCE($4, s.email_digest),
+			updated_at = NOW()
+		WHERE s.id = $5 AND s.user_id = $6
+			AND EXISTS (
+				SELECT 1 FROM workspace_memberships m
+				WHERE m.workspace_id = s.workspace_id AND m.user_id = s.user_id
+			)
+		RETURNING s.id, s.workspace_id, s.name, s.filters, s.alerts_enabled, s.email_digest,
+			(SELECT COUNT(*) FROM opportunity_alerts al WHERE al.saved_search_id = s.id AND al.read_at IS NULL),
+			s.last_alerted_at, s.created_at, s.updated_at
+	`, name, filtersJSON, alertsEnabled, emailDigest, searchID, userID))
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "saved search not found"})
+		return
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeError(w, http.StatusConflict, apiError{Code: "CONFLICT", Message: "a saved search with this name already exists"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to update saved search"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+// DELETE /api/v1/opportunities/saved-searches/:id
+func (a *api) handleDeleteOpportunitySavedSearch(w http.ResponseWriter, r *http.Request, searchID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	result, err := a.db.ExecContext(r.Context(), `
+		DELETE FROM opportunity_saved_searches WHERE id = $1 AND user_id = $2
+	`, searchID, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to delete saved search"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "saved search not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/v1/opportunities/alerts?workspace_id=&unread=true&limit=
+func (a *api) handleListOpportunityAlerts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	q := r.URL.Query()
+	workspaceID := q.Get("workspace_id")
+	if workspaceID == "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "workspace_id required"})
+		return
+	}
+	if ok, err := a.hasWorkspaceMembership(r.Context(), workspaceID, userID); err != nil || !ok {
+		writeError(w, http.StatusForbidden, apiError{Code: "FORBIDDEN", Message: "access denied"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = defaultOpportunityAlertsLimit
+	}
+	if limit > maxOpportunityAlertsLimit {
+		limit = maxOpportunityAlertsLimit
+	}
+	unreadOnly := q.Get("unread") == "true"
+
+	rows, err := a.db.QueryContext(r.Context(), `
+		SELECT al.id, al.saved_search_id, s.name, al.opportunity_id, al.reason,
+			al.price_cents, al.previous_price_cents,
+			COALESCE(sl.title, ''), COALESCE(sl.neighborhood, ''), COALESCE(sl.city, ''), o.score,
+			al.read_at, al.created_at
+		FROM opportunity_alerts al
+		JOIN opportunity_saved_searches s ON s.id = al.saved_search_id
+		JOIN opportunities o ON o.id = al.opportunity_id
+		JOIN source_listings sl ON sl.id = o.source_listing_id
+		WHERE al.user_id = $1 AND al.workspace_id = $2
+			AND ($3 = FALSE OR al.read_at IS NULL)
+		ORDER BY al.created_at DESC
+		LIMIT $4
+	`, userID, workspaceID, unreadOnly, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to list alerts"})
+		return
+	}
+	defer rows.Close()
+
+	resp := listOpportunityAlertsResponse{Items: make([]OpportunityAlertResponse, 0)}
+	for rows.Next() {
+		var alert OpportunityAlertResponse
+		var priceCents, previousPriceCents sql.NullInt64
+		var readAt sql.NullTime
+		if err := rows.Scan(&alert.ID, &alert.SavedSearchID, &alert.SavedSearchName, &alert.OpportunityID, &alert.Reason,
+			&priceCents, &previousPriceCents, &alert.Title, &alert.Neighborhood, &alert.City, &alert.Score,
+			&readAt, &alert.CreatedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to read alert"})
+			return
+		}
+		if priceCents.Valid {
+			alert.PriceCents = &priceCents.Int64
+		}
+		if previousPriceCents.Valid {
+			alert.PreviousPriceCents = &previousPriceCents.Int64
+		}
+		if readAt.Valid {
+			alert.ReadAt = &readAt.Time
+		}
+		alert.DeepLink = opportunityDeepLink(alert.OpportunityID)
+		resp.Items = append(resp.Items, alert)
+	}
+
+	if err := a.db.QueryRowContext(r.Context(), `
+		SELECT COUNT(*) FROM opportunity_alerts
+		WHERE user_id = $1 AND workspace_id = $2 AND read_at IS NULL
+	`, userID, workspaceID).Scan(&resp.UnreadCount); err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to count alerts"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /api/v1/opportunities/alerts/read
+func (a *api) handleMarkOpportunityAlertsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	var req markOpportunityAlertsReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "INVALID_BODY", Message: "invalid json body"})
+		return
+	}
+	if req.WorkspaceID == "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "workspace_id required"})
+		return
+	}
+
+	var result sql.Result
+	var err error
+	if len(req.IDs) > 0 {
+		result, err = a.db.ExecContext(r.Context(), `
+			UPDATE opportunity_alerts SET read_at = NOW()
+			WHERE user_id = $1 AND workspace_id = $2 AND read_at IS NULL AND id = ANY($3::uuid[])
+		`, userID, req.WorkspaceID, pq.Array(req.IDs))
+	} else {
+		result, err = a.db.ExecContext(r.Context(), `
+			UPDATE opportunity_alerts SET read_at = NOW()
+			WHERE user_id = $1 AND workspace_id = $2 AND read_at IS NULL
+		`, userID, req.WorkspaceID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to update alerts"})
+		return
+	}
+	updated, _ := result.RowsAffected()
+
+	writeJSON(w, http.StatusOK, markOpportunityAlertsReadResponse{Updated: int(updated)})
+}
+
+type opportunitySavedSearchScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOpportunitySavedSearch(row opportunitySavedSearchScanner) (OpportunitySavedSearchResponse, error) {
+	var item OpportunitySavedSearchResponse
+	var filtersJSON []byte
+	var lastAlertedAt sql.NullTime
+	if err := row.Scan(&item.ID, &item.WorkspaceID, &item.Name, &filtersJSON, &item.AlertsEnabled, &item.EmailDigest,
+		&item.UnreadAlerts, &lastAlertedAt, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		return item, err
+	}
+	if len(filtersJSON) > 0 {
+		_ = json.Unmarshal(filtersJSON, &item.Filters)
+	}
+	if lastAlertedAt.Valid {
+		item.LastAlertedAt = &lastAlertedAt.Time
+	}
+	return item, nil
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// dispatchOpportunityAlerts matches the listings of an ingest against every
+// saved search with alerts on and records one in-app alert per match. Users
+// with the email digest on get one email per ingest in the background.
+func (a *api) dispatchOpportunityAlerts(ctx context.Context, jobRunID string, candidates []opportunityAlertCandidate) (int, error) {
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	byListing := make(map[string]opportunityAlertCandidate, len(candidates))
+	listingIDs := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		byListing[candidate.ListingID] = candidate
+		listingIDs = append(listingIDs, candidate.ListingID)
+	}
+
+	type savedSearch struct {
+		ID          string
+		WorkspaceID string
+		UserID      string
+		Filters     opportunitySavedSearchFilters
+		EmailDigest bool
+	}
+
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT s.id, s.workspace_id, s.user_id, s.filters, s.email_digest
+		FROM opportunity_saved_searches s
+		JOIN workspace_memberships m ON m.workspace_id = s.workspace_id AND m.user_id = s.user_id
+		WHERE s.alerts_enabled
+	`)
+	if err != nil {
+		return 0, err
+	}
+	searches := make([]savedSearch, 0)
+	for rows.Next() {
+		var search savedSearch
+		var filtersJSON []byte
+		if err := rows.Scan(&search.ID, &search.WorkspaceID, &search.UserID, &filtersJSON, &search.EmailDigest); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if len(filtersJSON) > 0 {
+			if err := json.Unmarshal(filtersJSON, &search.Filters); err != nil {
+				log.Printf("opportunity alerts: invalid filters on saved search %s: %v", search.ID, err)
+				continue
+			}
+		}
+		searches = append(searches, search)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	created := 0
+	digestUsers := make(map[string]struct{})
+	for _, search := range searches {
+		where, args, argNum := buildOpportunityFilterClause(search.Filters.listFilters())
+		// A "new" listing that joined a cluster seen before is a cross-post, not news
+		query := `
+			SELECT o.id, sl.id, EXISTS (
+				SELECT 1 FROM source_listings other
+				WHERE sl.cluster_id IS NOT NULL AND other.cluster_id = sl.cluster_id
+					AND other.id <> sl.id AND other.first_seen_at < sl.first_seen_at
+			)
+			FROM opportunities o
+			JOIN source_listings sl ON o.source_listing_id = sl.id
+		` + where + fmt.Sprintf(" AND sl.id = ANY($%d::uuid[])", argNum)
+		args = append(args, pq.Array(listingIDs))
+
+		matchRows, err := a.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			log.Printf("opportunity alerts: failed to match saved search %s: %v", search.ID, err)
+			continue
+		}
+		type match struct {
+			OpportunityID string
+			Candidate     opportunityAlertCandidate
+		}
+		matches := make([]match, 0)
+		for matchRows.Next() {
+			var opportunityID, listingID string
+			var crossPosted bool
+			if err := matchRows.Scan(&opportunityID, &listingID, &crossPosted); err != nil {
+				continue
+			}
+			candidate := byListing[listingID]
+			if candidate.Reason == opportunityAlertNew && crossPosted {
+				continue
+			}
+			matches = append(matches, match{OpportunityID: opportunityID, Candidate: candidate})
+		}
+		matchRows.Close()
+
+		searchCreated := 0
+		for _, m := range matches {
+			var previousPrice interface{}
+			if m.Candidate.PreviousPriceCents > 0 {
+				previousPrice = m.Candidate.PreviousPriceCents
+			}
+			result, err := a.db.ExecContext(ctx, `
+				INSERT INTO opportunity_alerts (
+					saved_search_id, workspace_id, user_id, opportunity_id, reason,
+					price_cents, previous_price_cents, job_run_id
+				) VALUES ($1, $2, $3, $4, $5, NULLIF($6::bigint, 0), $7, $8)
+				ON CONFLICT (saved_search_id, opportunity_id, reason, job_run_id) DO NOTHING
+			`, search.ID, search.WorkspaceID, search.UserID, m.OpportunityID, m.Candidate.Reason,
+				m.Candidate.PriceCents, previousPrice, nullableJobRunID(jobRunID))
+			if err != nil {
+				log.Printf("opportunity alerts: failed to record alert search=%s opportunity=%s: %v", search.ID, m.OpportunityID, err)
+				continue
+			}
+			if affected, _ := result.RowsAffected(); affected > 0 {
+				searchCreated++
+			}
+		}
+
+		if searchCreated == 0 {
+			continue
+		}
+		created += searchCreated
+		if _, err := a.db.ExecContext(ctx, `
+			UPDATE opportunity_saved_searches SET last_alerted_at = NOW() WHERE id = $1
+		`, search.ID); err != nil {
+			log.Printf("opportunity alerts: failed to touch saved search %s: %v", search.ID, err)
+		}
+		if search.EmailDigest {
+			digestUsers[search.UserID] = struct{}{}
+		}
+	}
+
+	if len(digestUsers) > 0 {
+		userIDs := make([]string, 0, len(digestUsers))
+		for userID := range digestUsers {
+			userIDs = append(userIDs, userID)
+		}
+		go a.sendOpportunityAlertDigests(context.Background(), userIDs)
+	}
+
+	return created, nil
+}
+
+type opportunityDigestItem struct {
+	AlertID            string
+	SearchName         string
+	OpportunityID      string
+	Reason             string
+	Title              string
+	Neighborhood       string
+	City               string
+	PriceCents         int64
+	PreviousPriceCents int64
+}
+
+// sendOpportunityAlertDigests emails each user their not yet emailed alerts from
+// searches with the digest on. Alerts are marked only after Resend accepts the email.
+func (a *api) sendOpportunityAlertDigests(ctx context.Context, userIDs []string) {
+	for _, userID := range userIDs {
+		var email, name string
+		err := a.db.QueryRowContext(ctx, `SELECT email, COALESCE(name, '') FROM "user" WHERE id = $1`, userID).Scan(&email, &name)
+		if err != nil {
+			log.Printf("opportunity alerts digest: user %s lookup failed: %v", userID, err)
+			continue
+		}
+
+		rows, err := a.db.QueryContext(ctx, `
+			SELECT al.id, s.name, al.opportunity_id, al.reason,
+				COALESCE(sl.title, ''), COALESCE(sl.neighborhood, ''), COALESCE(sl.city, ''),
+				COALESCE(al.price_cents, 0), COALESCE(al.previous_price_cents, 0)
+			FROM opportunity_alerts al
+			JOIN opportunity_saved_searches s ON s.id = al.saved_search_id
+			JOIN opportunities o ON o.id = al.opportunity_id
+			JOIN source_listings sl ON sl.id = o.source_listing_id
+			WHERE al.user_id = $1 AND al.emailed_at IS NULL AND al.read_at IS NULL AND s.email_digest
+			ORDER BY al.created_at DESC
+		`, userID)
+		if err != nil {
+			log.Printf("opportunity alerts digest: user %s query failed: %v", userID, err)
+			continue
+		}
+		items := make([]opportunityDigestItem, 0)
+		for rows.Next() {
+			var item opportunityDigestItem
+			if err := rows.Scan(&item.AlertID, &item.SearchName, &item.OpportunityID, &item.Reason,
+				&item.Title, &item.Neighborhood, &item.City, &item.PriceCents, &item.PreviousPriceCents); err != nil {
+				continue
+			}
+			items = append(items, item)
+		}
+		rows.Close()
+		if len(items) == 0 {
+			continue
+		}
+
+		subject := fmt.Sprintf("%d novas oportunidades nas suas buscas salvas", len(items))
+		if len(items) == 1 {
+			subject = "1 nova oportunidade nas suas buscas salvas"
+		}
+		if _, err := sendResendEmail(email, subject, buildOpportunityAlertDigestHTML(name, items, appBaseURL())); err != nil {
+			log.Printf("opportunity alerts digest: send to user %s failed: %v", userID, err)
+			continue
+		}
+
+		alertIDs := make([]string, 0, len(items))
+		for _, item := range items {
+			alertIDs = append(alertIDs, item.AlertID)
+		}
+		if _, err := a.db.ExecContext(ctx, `
+			UPDATE opportunity_alerts SET emailed_at = NOW() WHERE id = ANY($1::uuid[])
+		`, pq.Array(alertIDs)); err != nil {
+			log.Printf("opportunity alerts digest: failed to mark alerts emailed for user %s: %v", userID, err)
+		}
+	}
+}
+
+// buildOpportunityAlertDigestHTML lists the alerts with a deep link to each card
+func buildOpportunityAlertDigestHTML(userName string, items []opportunityDigestItem, baseURL string) string {
+	var body strings.Builder
+	body.WriteString(`<p style="margin: 0 0 16px;">Encontramos imóveis que combinam com as suas buscas salvas:</p>`)
+	body.WriteString(`<table width="100%" cellpadding="0" cellspacing="0">`)
+
+	shown := items
+	if len(shown) > maxOpportunityDigestItems {
+		shown = shown[:maxOpportunityDigestItems]
+	}
+	for _, item := range shown {
+		label := "Novo anúncio"
+		if item.Reason == opportunityAlertPriceDrop {
+			label = "Preço reduzido"
+		}
+		price := formatBRLCents(item.PriceCents)
+		if item.Reason == opportunityAlertPriceDrop && item.PreviousPriceCents > 0 {
+			price = fmt.Sprintf("%s → %s", formatBRLCents(item.PreviousPriceCents), price)
+		}
+		location := strings.Trim(strings.Join([]string{item.Neighborhood, item.City}, ", "), ", ")
+
+		fmt.Fprintf(&body, `<tr><td style="padding: 12px 0; border-bottom: 1px solid #e4e4e7;">
+  <p style="margin: 0; font-size: 12px; color: #14B8A6; font-weight: 600;">%s · %s</p>
+  <p style="margin: 4px 0; font-size: 15px; font-weight: 600;"><a href="%s" style="color: #18181b; text-decoration: none;">%s</a></p>
+  <p style="margin: 0; font-size: 13px; color: #52525b;">%s · %s</p>
+</td></tr>`,
+			html.EscapeString(label), html.EscapeString(item.SearchName),
+			html.EscapeString(baseURL+opportunityDeepLink(item.OpportunityID)), html.EscapeString(item.Title),
+			html.EscapeString(location), html.EscapeString(price))
+	}
+	body.WriteString(`</table>`)
+
+	if remaining := len(items) - len(shown); remaining > 0 {
+		fmt.Fprintf(&body, `<p style="margin: 16px 0 0; font-size: 13px; color: #52525b;">E mais %d no app.</p>`, remaining)
+	}
+
+	greeting := "Olá"
+	if userName != "" {
+		greeting = "Olá " + html.EscapeString(userName)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; background-color: #f4f4f5; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif;">
+  <table width="100%%" cellpadding="0" cellspacing="0" style="background-color: #f4f4f5; padding: 40px 20px;">
+    <tr>
+      <td align="center">
+        <table width="100%%" cellpadding="0" cellspacing="0" style="max-width: 600px; background-color: white; border-radius: 12px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
+          <tr>
+            <td style="padding: 32px;">
+              <p style="margin: 0 0 16px; font-size: 15px; color: #52525b;">%s,</p>
+              <div style="font-size: 15px; line-height: 1.6; color: #18181b;">%s</div>
+            </td>
+          </tr>
+          <tr>
+            <td style="padding: 24px 32px; background-color: #fafafa; border-radius: 0 0 12px 12px; border-top: 1px solid #e4e4e7;">
+              <p style="margin: 0; font-size: 12px; line-height: 1.5; color: #a1a1aa; text-align: center;">
+                Você recebeu este email porque ativou o resumo por email em uma busca salva.<br>
+                <a href="%s/app/opportunities" style="color: #a1a1aa;">Gerenciar buscas salvas</a>
+              </p>
+            </td>
+          </tr>
+        </table>
+      </td>
+    </tr>
+  </table>
+</body>
+</html>`, greeting, body.String(), html.EscapeString(baseURL))
+}
+
+// formatBRLCents renders cents as "R$ 1.234.567"
+func formatBRLCents(cents int64) string {
+	if cents <= 0 {
+		return "Preço não informado"
+	}
+	digits := strconv.FormatInt(cents/100, 10)
+	var out strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteByte('.')
+		}
+		out.WriteRune(digit)
+	}
+	return "R$ " + out.String()
+}
@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSavedSearchFiltersMatchListFilters(t *testing.T) {
+	saved := opportunitySavedSearchFilters{
+		State:         "SP",
+		City:          "São Paulo",
+		Neighborhood:  "Mooca",
+		MinScore:      70,
+		MaxPrice:      600000,
+		MinArea:       45.5,
+		Bedrooms:      []int{2, 3},
+		Status:        []string{"new", "viewed"},
+		ListingStatus: "active",
+	}
+
+	filters := saved.listFilters()
+	if filters.State != "sp" || filters.City != "São Paulo" || filters.Neighborhood != "Mooca" {
+		t.Fatalf("location filters=%+v", filters)
+	}
+	if filters.ScoreMin != 70 || filters.PriceMax != 600000 || filters.AreaMin != 45.5 {
+		t.Fatalf("numeric filters=%+v", filters)
+	}
+	if len(filters.Bedrooms) != 2 || len(filters.Statuses) != 2 || filters.Listing != "active" {
+		t.Fatalf("list filters=%+v", filters)
+	}
+}
+
+func TestSavedSearchFiltersValidate(t *testing.T) {
+	if err := (opportunitySavedSearchFilters{City: "Santos", MinPrice: 100, MaxPrice: 200}).validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	invalid := []opportunitySavedSearchFilters{
+		{MinScore: 120},
+		{MinPrice: 300, MaxPrice: 200},
+		{MinArea: -1},
+		{Status: []string{"archived"}},
+		{ListingStatus: "sold"},
+	}
+	for _, filters := range invalid {
+		if err := filters.validate(); err == nil {
+			t.Fatalf("expected error for %+v", filters)
+		}
+	}
+}
+
+func TestDispatchOpportunityAlertsRecordsMatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM opportunity_saved_searches s").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "user_id", "filters", "email_digest"}).
+			AddRow("search-1", "ws-1", "user-1", []byte(`{"city":"Santos","max_price":500000}`), false))
+	mock.ExpectQuery("AND sl.id = ANY").
+		WithArgs("%Santos%", int64(50000000), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"opportunity_id", "listing_id", "cross_posted"}).
+			AddRow("opp-1", "listing-1", false).
+			AddRow("opp-2", "listing-2", true))
+	mock.ExpectExec("INSERT INTO opportunity_alerts").
+		WithArgs("search-1", "ws-1", "user-1", "opp-1", opportunityAlertNew, int64(45000000), nil, "run-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE opportunity_saved_searches SET last_alerted_at").
+		WithArgs("search-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a := &api{db: db}
+	created, err := a.dispatchOpportunityAlerts(context.Background(), "run-1", []opportunityAlertCandidate{
+		{ListingID: "listing-1", Reason: opportunityAlertNew, PriceCents: 45000000},
+		// Cross-posted copy of a listing already known: no alert
+		{ListingID: "listing-2", Reason: opportunityAlertNew, PriceCents: 44000000},
+	})
+	if err != nil {
+		t.Fatalf("dispatchOpportunityAlerts: %v", err)
+	}
+	if created != 1 {
+		t.Fatalf("created=%d want=1", created)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestBuildOpportunityAlertDigestHTMLLinksAndEscapes(t *testing.T) {
+	body := buildOpportunityAlertDigestHTML("Ana", []opportunityDigestItem{{
+		SearchName:         "Mooca <2 dorms>",
+		OpportunityID:      "opp-1",
+		Reason:             opportunityAlertPriceDrop,
+		Title:              "Apartamento reformado",
+		Neighborhood:       "Mooca",
+		City:               "São Paulo",
+		PriceCents:         45000000,
+		PreviousPriceCents: 50000000,
+	}}, "https://meuflip.com")
+
+	if !strings.Contains(body, "https://meuflip.com/app/opportunities?opportunity=opp-1") {
+		t.Fatalf("digest missing deep link: %s", body)
+	}
+	if strings.Contains(body, "<2 dorms>") || !strings.Contains(body, "Mooca &lt;2 dorms&gt;") {
+		t.Fatalf("search name not escaped")
+	}
+	if !strings.Contains(body, "R$ 500.000 → R$ 450.000") {
+		t.Fatalf("digest missing price drop")
+	}
+}
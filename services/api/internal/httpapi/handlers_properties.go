@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -51,6 +52,11 @@ type updatePropertyRequest struct {
 
 type updateStatusRequest struct {
 	StatusPipeline string `json:"status_pipeline"`
+
+	// Realized outcome, only used when moving a converted property to sold.
+	// Defaults to the cash analysis sale price and the net profit it implies.
+	RealizedSalePrice *float64 `json:"realized_sale_price"`
+	RealizedNetProfit *float64 `json:"realized_net_profit"`
 }
 
 type timelineEvent struct {
@@ -442,6 +448,14 @@ func (a *api) handleUpdatePropertyStatus(w http.ResponseWriter, r *http.Request,
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid status_pipeline value"})
 		return
 	}
+	if req.RealizedSalePrice != nil && *req.RealizedSalePrice <= 0 {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "realized_sale_price must be positive"})
+		return
+	}
+	if (req.RealizedSalePrice != nil || req.RealizedNetProfit != nil) && req.StatusPipeline != PropertyStatusSold {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "realized values are only accepted with status_pipeline sold"})
+		return
+	}
 
 	// Get current property and check access
 	var p property
@@ -482,6 +496,13 @@ func (a *api) handleUpdatePropertyStatus(w http.ResponseWriter, r *http.Request,
 		"to_status":   req.StatusPipeline,
 	}, userID)
 
+	// Record the realized outcome of converted prospects for Flip Score backtesting
+	if req.StatusPipeline == PropertyStatusSold && p.OriginProspectID != nil {
+		if err := a.recordFlipScoreOutcome(r.Context(), p, req.RealizedSalePrice, req.RealizedNetProfit); err != nil {
+			log.Printf("flip_score_outcome_error property_id=%s error=%v", p.ID, err)
+		}
+	}
+
 	writeJSON(w, http.StatusOK, p)
 }
 
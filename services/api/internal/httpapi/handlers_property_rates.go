@@ -12,17 +12,22 @@ import (
 )
 
 type propertyRates struct {
-	ITBIRate     *float64 `json:"itbi_rate"`
-	RegistryRate *float64 `json:"registry_rate"`
-	BrokerRate   *float64 `json:"broker_rate"`
-	PJTaxRate    *float64 `json:"pj_tax_rate"`
+	ITBIRate           *float64 `json:"itbi_rate"`
+	RegistryRate       *float64 `json:"registry_rate"`
+	BrokerRate         *float64 `json:"broker_rate"`
+	PJTaxRate          *float64 `json:"pj_tax_rate"`
+	TaxRegime          *string  `json:"tax_regime"`
+	TaxSimplesRate     *float64 `json:"tax_simples_rate"`
+	TaxRETRate         *float64 `json:"tax_ret_rate"`
+	TaxPFReinvestedPct *float64 `json:"tax_pf_reinvested_pct"`
 }
 
 type effectiveRates struct {
-	ITBIRate     float64 `json:"itbi_rate"`
-	RegistryRate float64 `json:"registry_rate"`
-	BrokerRate   float64 `json:"broker_rate"`
-	PJTaxRate    float64 `json:"pj_tax_rate"`
+	ITBIRate     float64               `json:"itbi_rate"`
+	RegistryRate float64               `json:"registry_rate"`
+	BrokerRate   float64               `json:"broker_rate"`
+	PJTaxRate    float64               `json:"pj_tax_rate"`
+	Tax          viability.TaxSettings `json:"tax"`
 }
 
 type propertyRatesResponse struct {
@@ -81,11 +86,13 @@ func (a *api) handleGetPropertyRates(w http.ResponseWriter, r *http.Request, pro
 	var updatedAt *time.Time
 	err = a.db.QueryRowContext(
 		r.Context(),
-		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate, updated_at
+		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate,
+		        tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct, updated_at
 		 FROM property_tax_rates
 		 WHERE property_id = $1`,
 		propertyID,
-	).Scan(&custom.ITBIRate, &custom.RegistryRate, &custom.BrokerRate, &custom.PJTaxRate, &updatedAt)
+	).Scan(&custom.ITBIRate, &custom.RegistryRate, &custom.BrokerRate, &custom.PJTaxRate,
+		&custom.TaxRegime, &custom.TaxSimplesRate, &custom.TaxRETRate, &custom.TaxPFReinvestedPct, &updatedAt)
 	if err != nil && err != sql.ErrNoRows {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch property rates"})
 		return
@@ -97,6 +104,7 @@ func (a *api) handleGetPropertyRates(w http.ResponseWriter, r *http.Request, pro
 		RegistryRate: wsRates.RegistryRate,
 		BrokerRate:   wsRates.BrokerRate,
 		PJTaxRate:    wsRates.PJTaxRate,
+		Tax:          applyTaxOverrides(wsRates.Tax, custom),
 	}
 	if custom.ITBIRate != nil {
 		effective.ITBIRate = *custom.ITBIRate
@@ -119,6 +127,7 @@ func (a *api) handleGetPropertyRates(w http.ResponseWriter, r *http.Request, pro
 			RegistryRate: wsRates.RegistryRate,
 			BrokerRate:   wsRates.BrokerRate,
 			PJTaxRate:    wsRates.PJTaxRate,
+			Tax:          wsRates.Tax,
 		},
 		UpdatedAt: updatedAt,
 	})
@@ -156,6 +165,10 @@ func (a *api) handleUpdatePropertyRates(w http.ResponseWriter, r *http.Request,
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "pj_tax_rate must be between 0 and 1"})
 		return
 	}
+	if msg := validateTaxSettingsInput(req.TaxRegime, req.TaxSimplesRate, req.TaxRETRate, req.TaxPFReinvestedPct); msg != "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: msg})
+		return
+	}
 
 	// Check access and get workspace_id
 	var workspaceID string
@@ -181,18 +194,26 @@ func (a *api) handleUpdatePropertyRates(w http.ResponseWriter, r *http.Request,
 	var updatedAt time.Time
 	err = a.db.QueryRowContext(
 		r.Context(),
-		`INSERT INTO property_tax_rates (property_id, workspace_id, itbi_rate, registry_rate, broker_rate, pj_tax_rate)
-		 VALUES ($1, $2, $3, $4, $5, $6)
+		`INSERT INTO property_tax_rates (property_id, workspace_id, itbi_rate, registry_rate, broker_rate, pj_tax_rate,
+		                                 tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		 ON CONFLICT (property_id)
 		 DO UPDATE SET
 		   itbi_rate = $3,
 		   registry_rate = $4,
 		   broker_rate = $5,
 		   pj_tax_rate = $6,
+		   tax_regime = $7,
+		   tax_simples_rate = $8,
+		   tax_ret_rate = $9,
+		   tax_pf_reinvested_pct = $10,
 		   updated_at = now()
-		 RETURNING itbi_rate, registry_rate, broker_rate, pj_tax_rate, updated_at`,
+		 RETURNING itbi_rate, registry_rate, broker_rate, pj_tax_rate,
+		           tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct, updated_at`,
 		propertyID, workspaceID, req.ITBIRate, req.RegistryRate, req.BrokerRate, req.PJTaxRate,
-	).Scan(&custom.ITBIRate, &custom.RegistryRate, &custom.BrokerRate, &custom.PJTaxRate, &updatedAt)
+		req.TaxRegime, req.TaxSimplesRate, req.TaxRETRate, req.TaxPFReinvestedPct,
+	).Scan(&custom.ITBIRate, &custom.RegistryRate, &custom.BrokerRate, &custom.PJTaxRate,
+		&custom.TaxRegime, &custom.TaxSimplesRate, &custom.TaxRETRate, &custom.TaxPFReinvestedPct, &updatedAt)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to save property rates", Details: []string{err.Error()}})
 		return
@@ -211,6 +232,7 @@ func (a *api) handleUpdatePropertyRates(w http.ResponseWriter, r *http.Request,
 		RegistryRate: wsRates.RegistryRate,
 		BrokerRate:   wsRates.BrokerRate,
 		PJTaxRate:    wsRates.PJTaxRate,
+		Tax:          applyTaxOverrides(wsRates.Tax, custom),
 	}
 	if custom.ITBIRate != nil {
 		effective.ITBIRate = *custom.ITBIRate
@@ -233,6 +255,7 @@ func (a *api) handleUpdatePropertyRates(w http.ResponseWriter, r *http.Request,
 			RegistryRate: wsRates.RegistryRate,
 			BrokerRate:   wsRates.BrokerRate,
 			PJTaxRate:    wsRates.PJTaxRate,
+			Tax:          wsRates.Tax,
 		},
 		UpdatedAt: &updatedAt,
 	})
@@ -245,6 +268,7 @@ func settingsToRates(s viability.CashSettings) effectiveRates {
 		RegistryRate: s.RegistryRate,
 		BrokerRate:   s.BrokerRate,
 		PJTaxRate:    s.PJTaxRate,
+		Tax:          s.Tax,
 	}
 }
 
@@ -255,6 +279,7 @@ func financingSettingsToRates(s viability.FinancingSettings) effectiveRates {
 		RegistryRate: s.RegistryRate,
 		BrokerRate:   s.BrokerRate,
 		PJTaxRate:    s.PJTaxRate,
+		Tax:          s.Tax,
 	}
 }
 
@@ -270,11 +295,13 @@ func (a *api) getEffectivePropertySettings(ctx context.Context, propertyID, work
 	var custom propertyRates
 	err = a.db.QueryRowContext(
 		ctx,
-		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate
+		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate,
+		        tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct
 		 FROM property_tax_rates
 		 WHERE property_id = $1`,
 		propertyID,
-	).Scan(&custom.ITBIRate, &custom.RegistryRate, &custom.BrokerRate, &custom.PJTaxRate)
+	).Scan(&custom.ITBIRate, &custom.RegistryRate, &custom.BrokerRate, &custom.PJTaxRate,
+		&custom.TaxRegime, &custom.TaxSimplesRate, &custom.TaxRETRate, &custom.TaxPFReinvestedPct)
 	if err == sql.ErrNoRows {
 		return ws, nil // No custom rates, use workspace
 	}
@@ -295,6 +322,7 @@ func (a *api) getEffectivePropertySettings(ctx context.Context, propertyID, work
 	if custom.PJTaxRate != nil {
 		ws.PJTaxRate = *custom.PJTaxRate
 	}
+	ws.Tax = applyTaxOverrides(ws.Tax, custom)
 
 	return ws, nil
 }
@@ -311,11 +339,13 @@ func (a *api) getEffectiveFinancingSettings(ctx context.Context, propertyID, wor
 	var custom propertyRates
 	err = a.db.QueryRowContext(
 		ctx,
-		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate
+		`SELECT itbi_rate, registry_rate, broker_rate, pj_tax_rate,
+		        tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct
 		 FROM property_tax_rates
 		 WHERE property_id = $1`,
 		propertyID,
-	).Scan(&custom.ITBIRate, &custom.RegistryRate, &custom.BrokerRate, &custom.PJTaxRate)
+	).Scan(&custom.ITBIRate, &custom.RegistryRate, &custom.BrokerRate, &custom.PJTaxRate,
+		&custom.TaxRegime, &custom.TaxSimplesRate, &custom.TaxRETRate, &custom.TaxPFReinvestedPct)
 	if err == sql.ErrNoRows {
 		return ws, nil // No custom rates, use workspace
 	}
@@ -336,6 +366,51 @@ func (a *api) getEffectiveFinancingSettings(ctx context.Context, propertyID, wor
 	if custom.PJTaxRate != nil {
 		ws.PJTaxRate = *custom.PJTaxRate
 	}
+	ws.Tax = applyTaxOverrides(ws.Tax, custom)
 
 	return ws, nil
 }
+
+// taxSettingsFromColumns builds the tax settings from the workspace_settings tax_* columns
+func taxSettingsFromColumns(regime string, simplesRate, retRate, reinvestedPct sql.NullFloat64) viability.TaxSettings {
+	return viability.TaxSettings{
+		Regime:          regime,
+		SimplesRate:     simplesRate.Float64,
+		RETRate:         retRate.Float64,
+		PFReinvestedPct: reinvestedPct.Float64,
+	}
+}
+
+// applyTaxOverrides merges property-level tax overrides onto the workspace tax settings
+func applyTaxOverrides(ws viability.TaxSettings, custom propertyRates) viability.TaxSettings {
+	if custom.TaxRegime != nil {
+		ws.Regime = *custom.TaxRegime
+	}
+	if custom.TaxSimplesRate != nil {
+		ws.SimplesRate = *custom.TaxSimplesRate
+	}
+	if custom.TaxRETRate != nil {
+		ws.RETRate = *custom.TaxRETRate
+	}
+	if custom.TaxPFReinvestedPct != nil {
+		ws.PFReinvestedPct = *custom.TaxPFReinvestedPct
+	}
+	return ws
+}
+
+// validateTaxSettingsInput returns a validation message for invalid tax regime inputs ("" when valid)
+func validateTaxSettingsInput(regime *string, simplesRate, retRate, reinvestedPct *float64) string {
+	if regime != nil && !viability.IsValidTaxRegime(*regime) {
+		return "tax_regime must be flat, pf, lucro_presumido, ret or simples"
+	}
+	if simplesRate != nil && (*simplesRate < 0 || *simplesRate > 1) {
+		return "tax_simples_rate must be between 0 and 1"
+	}
+	if retRate != nil && (*retRate < 0 || *retRate > 1) {
+		return "tax_ret_rate must be between 0 and 1"
+	}
+	if reinvestedPct != nil && (*reinvestedPct < 0 || *reinvestedPct > 1) {
+		return "tax_pf_reinvested_pct must be between 0 and 1"
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/lib/pq"
 	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/offerintelligence"
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
 )
 
 // Prospect statuses
@@ -63,14 +66,19 @@ type prospect struct {
 	FlipScoreConfidence *float64         `json:"flip_score_confidence,omitempty"`
 	FlipScoreBreakdown  *json.RawMessage `json:"flip_score_breakdown,omitempty"`
 	FlipScoreUpdatedAt  *time.Time       `json:"flip_score_updated_at,omitempty"`
+	// Hash of the listing_text the stored risk assessment came from (internal)
+	FlipScoreListingHash *string `json:"-"`
 	// M9 - Flip Score v1 inputs
-	OfferPrice             *float64  `json:"offer_price,omitempty"`
-	ExpectedSalePrice      *float64  `json:"expected_sale_price,omitempty"`
-	RenovationCostEstimate *float64  `json:"renovation_cost_estimate,omitempty"`
-	HoldMonths             *int      `json:"hold_months,omitempty"`
-	OtherCostsEstimate     *float64  `json:"other_costs_estimate,omitempty"`
-	CreatedAt              time.Time `json:"created_at"`
-	UpdatedAt              time.Time `json:"updated_at"`
+	OfferPrice             *float64 `json:"offer_price,omitempty"`
+	ExpectedSalePrice      *float64 `json:"expected_sale_price,omitempty"`
+	RenovationCostEstimate *float64 `json:"renovation_cost_estimate,omitempty"`
+	HoldMonths             *int     `json:"hold_months,omitempty"`
+	OtherCostsEstimate     *float64 `json:"other_costs_estimate,omitempty"`
+	// Acquisition mode (market or auction) and the auction notice terms
+	AcquisitionMode string                  `json:"acquisition_mode"`
+	AuctionTerms    *viability.AuctionTerms `json:"auction_terms,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
 }
 
 type listProspectsResponse struct {
@@ -106,6 +114,9 @@ type createProspectRequest struct {
 	RenovationCostEstimate *float64 `json:"renovation_cost_estimate"`
 	HoldMonths             *int     `json:"hold_months"`
 	OtherCostsEstimate     *float64 `json:"other_costs_estimate"`
+	// Acquisition mode (market or auction) and the auction notice terms
+	AcquisitionMode *string                 `json:"acquisition_mode"`
+	AuctionTerms    *viability.AuctionTerms `json:"auction_terms"`
 	// URL import tracking
 	ImportedViaURL *bool `json:"imported_via_url"`
 }
@@ -138,6 +149,9 @@ type updateProspectRequest struct {
 	RenovationCostEstimate *float64 `json:"renovation_cost_estimate"`
 	HoldMonths             *int     `json:"hold_months"`
 	OtherCostsEstimate     *float64 `json:"other_costs_estimate"`
+	// Acquisition mode (market or auction) and the auction notice terms
+	AcquisitionMode *string                 `json:"acquisition_mode"`
+	AuctionTerms    *viability.AuctionTerms `json:"auction_terms"`
 }
 
 type convertProspectResponse struct {
@@ -195,6 +209,16 @@ func (a *api) handleProspectsSubroutes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// /api/v1/prospects/:id/sensitivity
+	if len(parts) == 2 && parts[1] == "sensitivity" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, apiError{Code: "METHOD_NOT_ALLOWED", Message: "method not allowed"})
+			return
+		}
+		a.handleProspectSensitivity(w, r, prospectID)
+		return
+	}
+
 	// /api/v1/prospects/:id/offer-intelligence/generate
 	if len(parts) == 3 && parts[1] == "offer-intelligence" && parts[2] == "generate" {
 		if r.Method != http.MethodPost {
@@ -225,6 +249,26 @@ func (a *api) handleProspectsSubroutes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// /api/v1/prospects/:id/negotiation
+	if len(parts) == 2 && parts[1] == "negotiation" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, apiError{Code: "METHOD_NOT_ALLOWED", Message: "method not allowed"})
+			return
+		}
+		a.handleOfferNegotiation(w, r, prospectID)
+		return
+	}
+
+	// /api/v1/prospects/:id/negotiation/events
+	if len(parts) == 3 && parts[1] == "negotiation" && parts[2] == "events" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, apiError{Code: "METHOD_NOT_ALLOWED", Message: "method not allowed"})
+			return
+		}
+		a.handleOfferNegotiationEvent(w, r, prospectID)
+		return
+	}
+
 	// /api/v1/prospects/:id/offer-intelligence/:recommendation_id
 	if len(parts) == 3 && parts[1] == "offer-intelligence" {
 		if parts[2] == "generate" || parts[2] == "save" || parts[2] == "history" {
@@ -299,7 +343,8 @@ func (a *api) handleListProspects(w http.ResponseWriter, r *http.Request) {
 		       condo_fee, iptu, asking_price, agency, broker_name, broker_phone,
 		       comments, tags, created_at, updated_at,
 		       flip_score, flip_score_version,
-		       offer_price, expected_sale_price, renovation_cost_estimate, hold_months, other_costs_estimate
+		       offer_price, expected_sale_price, renovation_cost_estimate, hold_months, other_costs_estimate,
+		       acquisition_mode, auction_terms_json
 		FROM prospecting_properties
 		WHERE workspace_id = $1 AND deleted_at IS NULL
 	`
@@ -337,7 +382,7 @@ func (a *api) handleListProspects(w http.ResponseWriter, r *http.Request) {
 	items := make([]prospect, 0)
 	for rows.Next() {
 		var p prospect
-		var tags []byte
+		var tags, auctionTerms []byte
 		err := rows.Scan(
 			&p.ID, &p.WorkspaceID, &p.Status, &p.Link, &p.Neighborhood, &p.Address,
 			&p.AreaUsable, &p.Bedrooms, &p.Suites, &p.Bathrooms, &p.Gas, &p.Floor, &p.Elevator, &p.Face, &p.Parking,
@@ -345,12 +390,14 @@ func (a *api) handleListProspects(w http.ResponseWriter, r *http.Request) {
 			&p.Comments, &tags, &p.CreatedAt, &p.UpdatedAt,
 			&p.FlipScore, &p.FlipScoreVersion,
 			&p.OfferPrice, &p.ExpectedSalePrice, &p.RenovationCostEstimate, &p.HoldMonths, &p.OtherCostsEstimate,
+			&p.AcquisitionMode, &auctionTerms,
 		)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to scan prospect"})
 			return
 		}
 		p.Tags = parseTags(tags)
+		p.AuctionTerms = decodeAuctionTerms(p.AcquisitionMode, auctionTerms)
 		p.PricePerSqm = computePricePerSqm(p.AskingPrice, p.AreaUsable)
 		items = append(items, p)
 	}
@@ -409,40 +456,51 @@ func (a *api) handleCreateProspect(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "asking_price must be >= 0"})
 		return
 	}
+	acquisitionMode := offerintelligence.AcquisitionModeMarket
+	if req.AcquisitionMode != nil {
+		acquisitionMode = *req.AcquisitionMode
+	}
+	auctionTermsJSON, err := encodeAuctionTerms(acquisitionMode, req.AuctionTerms)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+		return
+	}
 
 	if req.Tags == nil {
 		req.Tags = []string{}
 	}
 
 	var p prospect
-	var tagsBytes []byte
-	err := a.db.QueryRowContext(
+	var tagsBytes, auctionTerms []byte
+	err = a.db.QueryRowContext(
 		r.Context(),
 		`INSERT INTO prospecting_properties
 			(workspace_id, link, neighborhood, address, area_usable, bedrooms, suites, bathrooms,
 			 gas, floor, elevator, face, parking, condo_fee, iptu, asking_price, agency, broker_name, broker_phone,
 			 comments, tags,
 			 offer_price, expected_sale_price, renovation_cost_estimate, hold_months, other_costs_estimate,
-			 imported_via_url)
+			 imported_via_url, acquisition_mode, auction_terms_json)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
-		         $22, $23, $24, $25, $26, $27)
+		         $22, $23, $24, $25, $26, $27, $28, $29)
 		 RETURNING id, workspace_id, status, link, neighborhood, address,
 		           area_usable, bedrooms, suites, bathrooms, gas, floor, elevator, face, parking,
 		           condo_fee, iptu, asking_price, agency, broker_name, broker_phone,
 		           comments, tags, created_at, updated_at,
-		           offer_price, expected_sale_price, renovation_cost_estimate, hold_months, other_costs_estimate`,
+		           offer_price, expected_sale_price, renovation_cost_estimate, hold_months, other_costs_estimate,
+		           acquisition_mode, auction_terms_json`,
 		req.WorkspaceID, req.Link, req.Neighborhood, req.Address, req.AreaUsable,
 		req.Bedrooms, req.Suites, req.Bathrooms, req.Gas, req.Floor, req.Elevator, req.Face, req.Parking,
 		req.CondoFee, req.IPTU, req.AskingPrice, req.Agency, req.BrokerName, req.BrokerPhone,
 		req.Comments, pq.Array(req.Tags),
 		req.OfferPrice, req.ExpectedSalePrice, req.RenovationCostEstimate, req.HoldMonths, req.OtherCostsEstimate,
-		req.ImportedViaURL,
+		req.ImportedViaURL, acquisitionMode, auctionTermsJSON,
 	).Scan(
 		&p.ID, &p.WorkspaceID, &p.Status, &p.Link, &p.Neighborhood, &p.Address,
 		&p.AreaUsable, &p.Bedrooms, &p.Suites, &p.Bathrooms, &p.Gas, &p.Floor, &p.Elevator, &p.Face, &p.Parking,
 		&p.CondoFee, &p.IPTU, &p.AskingPrice, &p.Agency, &p.BrokerName, &p.BrokerPhone,
 		&p.Comments, &tagsBytes, &p.CreatedAt, &p.UpdatedAt,
 		&p.OfferPrice, &p.ExpectedSalePrice, &p.RenovationCostEstimate, &p.HoldMonths, &p.OtherCostsEstimate,
+		&p.AcquisitionMode, &auctionTerms,
 	)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to create prospect", Details: []string{err.Error()}})
@@ -450,6 +508,7 @@ func (a *api) handleCreateProspect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	p.Tags = parseTags(tagsBytes)
+	p.AuctionTerms = decodeAuctionTerms(p.AcquisitionMode, auctionTerms)
 	p.PricePerSqm = computePricePerSqm(p.AskingPrice, p.AreaUsable)
 	writeJSON(w, http.StatusCreated, p)
 }
@@ -462,7 +521,7 @@ func (a *api) handleGetProspect(w http.ResponseWriter, r *http.Request, prospect
 	}
 
 	var p prospect
-	var tags []byte
+	var tags, auctionTerms []byte
 	err := a.db.QueryRowContext(
 		r.Context(),
 		`SELECT p.id, p.workspace_id, p.status, p.link, p.neighborhood, p.address,
@@ -470,7 +529,8 @@ func (a *api) handleGetProspect(w http.ResponseWriter, r *http.Request, prospect
 		        p.condo_fee, p.iptu, p.asking_price, p.agency, p.broker_name, p.broker_phone,
 		        p.comments, p.tags, p.created_at, p.updated_at,
 		        p.flip_score, p.flip_score_version, p.flip_score_confidence, p.flip_score_breakdown, p.flip_score_updated_at,
-		        p.offer_price, p.expected_sale_price, p.renovation_cost_estimate, p.hold_months, p.other_costs_estimate
+		        p.offer_price, p.expected_sale_price, p.renovation_cost_estimate, p.hold_months, p.other_costs_estimate,
+		        p.acquisition_mode, p.auction_terms_json
 		 FROM prospecting_properties p
 		 JOIN workspace_memberships m ON m.workspace_id = p.workspace_id
 		 WHERE p.id = $1 AND m.user_id = $2 AND p.deleted_at IS NULL`,
@@ -482,6 +542,7 @@ func (a *api) handleGetProspect(w http.ResponseWriter, r *http.Request, prospect
 		&p.Comments, &tags, &p.CreatedAt, &p.UpdatedAt,
 		&p.FlipScore, &p.FlipScoreVersion, &p.FlipScoreConfidence, &p.FlipScoreBreakdown, &p.FlipScoreUpdatedAt,
 		&p.OfferPrice, &p.ExpectedSalePrice, &p.RenovationCostEstimate, &p.HoldMonths, &p.OtherCostsEstimate,
+		&p.AcquisitionMode, &auctionTerms,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -493,6 +554,7 @@ func (a *api) handleGetProspect(w http.ResponseWriter, r *http.Request, prospect
 	}
 
 	p.Tags = parseTags(tags)
+	p.AuctionTerms = decodeAuctionTerms(p.AcquisitionMode, auctionTerms)
 	p.PricePerSqm = computePricePerSqm(p.AskingPrice, p.AreaUsable)
 	writeJSON(w, http.StatusOK, p)
 }
@@ -528,6 +590,21 @@ func (a *api) handleUpdateProspect(w http.ResponseWriter, r *http.Request, prosp
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "asking_price must be >= 0"})
 		return
 	}
+	// Auction terms without a mode switch the prospect to the auction mode
+	var acquisitionMode string
+	var auctionTermsJSON []byte
+	if req.AcquisitionMode != nil || req.AuctionTerms != nil {
+		acquisitionMode = offerintelligence.AcquisitionModeAuction
+		if req.AcquisitionMode != nil {
+			acquisitionMode = *req.AcquisitionMode
+		}
+		encoded, err := encodeAuctionTerms(acquisitionMode, req.AuctionTerms)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+			return
+		}
+		auctionTermsJSON = encoded
+	}
 
 	// Check access (only non-deleted prospects)
 	var workspaceID string
@@ -684,6 +761,11 @@ func (a *api) handleUpdateProspect(w http.ResponseWriter, r *http.Request, prosp
 		args = append(args, *req.OtherCostsEstimate)
 		argIdx++
 	}
+	if acquisitionMode != "" {
+		sets = append(sets, "acquisition_mode = $"+strconv.Itoa(argIdx), "auction_terms_json = $"+strconv.Itoa(argIdx+1))
+		args = append(args, acquisitionMode, auctionTermsJSON)
+		argIdx += 2
+	}
 
 	args = append(args, prospectID)
 	query := `UPDATE prospecting_properties SET ` + strings.Join(sets, ", ") + ` WHERE id = $` + strconv.Itoa(argIdx) + `
@@ -692,10 +774,11 @@ func (a *api) handleUpdateProspect(w http.ResponseWriter, r *http.Request, prosp
 		           condo_fee, iptu, asking_price, agency, broker_name, broker_phone,
 		           comments, tags, created_at, updated_at,
 		           flip_score, flip_score_version,
-		           offer_price, expected_sale_price, renovation_cost_estimate, hold_months, other_costs_estimate`
+		           offer_price, expected_sale_price, renovation_cost_estimate, hold_months, other_costs_estimate,
+		           acquisition_mode, auction_terms_json`
 
 	var p prospect
-	var tags []byte
+	var tags, auctionTerms []byte
 	err = a.db.QueryRowContext(r.Context(), query, args...).Scan(
 		&p.ID, &p.WorkspaceID, &p.Status, &p.Link, &p.Neighborhood, &p.Address,
 		&p.AreaUsable, &p.Bedrooms, &p.Suites, &p.Bathrooms, &p.Gas, &p.Floor, &p.Elevator, &p.Face, &p.Parking,
@@ -703,6 +786,7 @@ func (a *api) handleUpdateProspect(w http.ResponseWriter, r *http.Request, prosp
 		&p.Comments, &tags, &p.CreatedAt, &p.UpdatedAt,
 		&p.FlipScore, &p.FlipScoreVersion,
 		&p.OfferPrice, &p.ExpectedSalePrice, &p.RenovationCostEstimate, &p.HoldMonths, &p.OtherCostsEstimate,
+		&p.AcquisitionMode, &auctionTerms,
 	)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to update prospect"})
@@ -710,6 +794,7 @@ func (a *api) handleUpdateProspect(w http.ResponseWriter, r *http.Request, prosp
 	}
 
 	p.Tags = parseTags(tags)
+	p.AuctionTerms = decodeAuctionTerms(p.AcquisitionMode, auctionTerms)
 	p.PricePerSqm = computePricePerSqm(p.AskingPrice, p.AreaUsable)
 	writeJSON(w, http.StatusOK, p)
 }
@@ -827,8 +912,14 @@ func (a *api) handleConvertProspect(w http.ResponseWriter, r *http.Request, pros
 		return
 	}
 
+	// Scoring inputs for the backtest; a conversion is never blocked by a failed snapshot
+	scoreInputs, err := a.snapshotFlipScoreInputs(r.Context(), p.WorkspaceID, p.ID)
+	if err != nil {
+		log.Printf("flip_score_inputs_snapshot_error prospect_id=%s error=%v", p.ID, err)
+	}
+
 	// Convert in transaction
-	propertyID, err := a.convertProspectTx(r.Context(), &p)
+	propertyID, err := a.convertProspectTx(r.Context(), &p, scoreInputs)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to convert prospect"})
 		return
@@ -837,7 +928,15 @@ func (a *api) handleConvertProspect(w http.ResponseWriter, r *http.Request, pros
 	writeJSON(w, http.StatusCreated, convertProspectResponse{PropertyID: propertyID})
 }
 
-func (a *api) convertProspectTx(ctx context.Context, p *prospect) (string, error) {
+// nullableJSON passes an empty JSON document as NULL
+func nullableJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func (a *api) convertProspectTx(ctx context.Context, p *prospect, scoreInputs []byte) (string, error) {
 	tx, err := a.db.BeginTx(ctx, nil)
 	if err != nil {
 		return "", err
@@ -867,6 +966,62 @@ func (a *api) convertProspectTx(ctx context.Context, p *prospect) (string, error
 		return "", err
 	}
 
+	// Snapshot the score at conversion for backtesting against the realized outcome
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO flip_score_outcomes (
+			workspace_id, prospect_id, property_id,
+			score, score_version, score_confidence, score_breakdown, scored_at, score_inputs,
+			predicted_purchase_price, predicted_sale_price
+		 )
+		 SELECT workspace_id, id, $2,
+		        flip_score, flip_score_version, flip_score_confidence, flip_score_breakdown, flip_score_updated_at, $3::jsonb,
+		        COALESCE(offer_price, asking_price), expected_sale_price
+		 FROM prospecting_properties
+		 WHERE id = $1
+		 ON CONFLICT (property_id) DO NOTHING`,
+		p.ID, propertyID, nullableJSON(scoreInputs),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	// Carry the negotiation history into the property timeline
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE offer_negotiations SET property_id = $2, updated_at = now() WHERE prospect_id = $1`,
+		p.ID, propertyID,
+	)
+	if err != nil {
+		return "", err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO timeline_events (property_id, workspace_id, event_type, payload, actor_user_id, created_at)
+		 SELECT $2, r.workspace_id, $3,
+		        jsonb_build_object(
+		          'negotiation_id', r.negotiation_id,
+		          'round_id', r.id,
+		          'round_number', r.round_number,
+		          'negotiation_event', r.event_type,
+		          'price', r.price,
+		          'seller_response', r.seller_response,
+		          'notes', r.notes,
+		          'occurred_at', r.occurred_at,
+		          'decision', r.decision,
+		          'ceiling_offer', r.ceiling_offer,
+		          'room_to_ceiling', r.room_to_ceiling
+		        ),
+		        r.created_by_user_id, r.occurred_at
+		 FROM offer_negotiation_rounds r
+		 JOIN offer_negotiations n ON n.id = r.negotiation_id
+		 WHERE n.prospect_id = $1`,
+		p.ID, propertyID, EventTypeNegotiationRound,
+	)
+	if err != nil {
+		return "", err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return "", err
 	}
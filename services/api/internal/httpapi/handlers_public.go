@@ -17,10 +17,14 @@ type publicCashCalcRequest struct {
 }
 
 type publicCashSettings struct {
-	ITBIRate     *float64 `json:"itbi_rate"`
-	RegistryRate *float64 `json:"registry_rate"`
-	BrokerRate   *float64 `json:"broker_rate"`
-	PJTaxRate    *float64 `json:"pj_tax_rate"`
+	ITBIRate        *float64 `json:"itbi_rate"`
+	RegistryRate    *float64 `json:"registry_rate"`
+	BrokerRate      *float64 `json:"broker_rate"`
+	PJTaxRate       *float64 `json:"pj_tax_rate"`
+	TaxRegime       *string  `json:"tax_regime"`
+	SimplesRate     *float64 `json:"tax_simples_rate"`
+	RETRate         *float64 `json:"tax_ret_rate"`
+	PFReinvestedPct *float64 `json:"tax_pf_reinvested_pct"`
 }
 
 type publicCashCalcResponse struct {
@@ -51,6 +55,8 @@ type publicCashDetailedOutputs struct {
 	NetProfit       float64 `json:"net_profit"`
 	ROI             float64 `json:"roi"`
 	IsPartial       bool    `json:"is_partial"`
+
+	TaxBreakdown *viability.TaxBreakdown `json:"tax_breakdown,omitempty"`
 }
 
 // Default settings for public calculator (BR defaults)
@@ -116,6 +122,10 @@ func (a *api) handlePublicCashCalc(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "pj_tax_rate must be between 0 and 1"})
 			return
 		}
+		if msg := validateTaxSettingsInput(req.Settings.TaxRegime, req.Settings.SimplesRate, req.Settings.RETRate, req.Settings.PFReinvestedPct); msg != "" {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: msg})
+			return
+		}
 	}
 
 	// Build settings from request or use defaults
@@ -133,6 +143,12 @@ func (a *api) handlePublicCashCalc(w http.ResponseWriter, r *http.Request) {
 		if req.Settings.PJTaxRate != nil {
 			settings.PJTaxRate = *req.Settings.PJTaxRate
 		}
+		settings.Tax = applyTaxOverrides(settings.Tax, propertyRates{
+			TaxRegime:          req.Settings.TaxRegime,
+			TaxSimplesRate:     req.Settings.SimplesRate,
+			TaxRETRate:         req.Settings.RETRate,
+			TaxPFReinvestedPct: req.Settings.PFReinvestedPct,
+		})
 	}
 
 	// Build viability inputs
@@ -165,6 +181,7 @@ func (a *api) handlePublicCashCalc(w http.ResponseWriter, r *http.Request) {
 			NetProfit:       result.NetProfit,
 			ROI:             result.ROI,
 			IsPartial:       result.IsPartial,
+			TaxBreakdown:    result.TaxBreakdown,
 		},
 	}
 
@@ -0,0 +1,264 @@
+package httpapi
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
+
+type sensitivityRequest struct {
+	Ranges     *viability.SensitivityRanges `json:"ranges"`
+	TwoWay     *sensitivityTwoWayRequest    `json:"two_way"`
+	MonteCarlo *viability.MonteCarloParams  `json:"monte_carlo"`
+}
+
+type sensitivityTwoWayRequest struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+type sensitivityResponse struct {
+	Base           viability.SensitivityPoint  `json:"base"`
+	Ranges         viability.SensitivityRanges `json:"ranges"`
+	Tornado        []viability.TornadoBar      `json:"tornado"`
+	TwoWay         viability.TwoWayTable       `json:"two_way"`
+	MonteCarlo     *viability.MonteCarloResult `json:"monte_carlo,omitempty"`
+	EffectiveRates effectiveRates              `json:"effective_rates"`
+}
+
+func (a *api) handlePropertyCashSensitivity(w http.ResponseWriter, r *http.Request, propertyID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	var req sensitivityRequest
+	if err := decodeOptionalJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+	if msg := validateSensitivityRequest(req); msg != "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: msg})
+		return
+	}
+
+	// Check access and get workspace_id
+	var workspaceID string
+	err := a.db.QueryRowContext(
+		r.Context(),
+		`SELECT p.workspace_id
+		 FROM properties p
+		 JOIN workspace_memberships m ON m.workspace_id = p.workspace_id
+		 WHERE p.id = $1 AND m.user_id = $2`,
+		propertyID, userID,
+	).Scan(&workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "property not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check property"})
+		return
+	}
+
+	settings, err := a.getEffectivePropertySettings(r.Context(), propertyID, workspaceID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch settings"})
+		return
+	}
+
+	inputs, err := a.getCashInputs(r.Context(), propertyID)
+	if err != nil && err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch analysis"})
+		return
+	}
+
+	// Carry inputs fall back to the origin prospect, like the cash-flow timeline
+	condoFee, iptu, holdMonths, err := a.loadOriginProspectCarry(r.Context(), propertyID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch origin prospect"})
+		return
+	}
+	if inputs.HoldMonths == nil {
+		inputs.HoldMonths = holdMonths
+	}
+
+	model := viability.SensitivityModel{
+		Inputs:       inputs.toViability(),
+		Settings:     settings,
+		MonthlyCarry: monthlyCarry(floatOrDefault(inputs.CondoFee, condoFee), floatOrDefault(inputs.IPTU, iptu)),
+	}
+	if model.IsPartial() {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "purchase_price and sale_price are required for sensitivity analysis"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, runSensitivity(model, req, settingsToRates(settings)))
+}
+
+func (a *api) handleProspectSensitivity(w http.ResponseWriter, r *http.Request, prospectID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	var req sensitivityRequest
+	if err := decodeOptionalJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+	if msg := validateSensitivityRequest(req); msg != "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: msg})
+		return
+	}
+
+	p, err := a.getProspectWithFlipScore(r.Context(), prospectID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "prospect not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch prospect"})
+		return
+	}
+
+	settings, err := a.getWorkspaceCashSettings(r.Context(), p.WorkspaceID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch workspace settings"})
+		return
+	}
+
+	// Same purchase price rule as Flip Score v1: offer price, falling back to asking price
+	purchasePrice := p.OfferPrice
+	if purchasePrice == nil {
+		purchasePrice = p.AskingPrice
+	}
+
+	model := viability.SensitivityModel{
+		Inputs: viability.CashInputs{
+			PurchasePrice:  purchasePrice,
+			RenovationCost: p.RenovationCostEstimate,
+			OtherCosts:     p.OtherCostsEstimate,
+			SalePrice:      p.ExpectedSalePrice,
+			HoldMonths:     p.HoldMonths,
+			Auction:        p.AuctionTerms,
+		},
+		Settings:     settings,
+		MonthlyCarry: monthlyCarry(floatOrDefault(p.CondoFee, nil), floatOrDefault(p.IPTU, nil)),
+	}
+	if model.IsPartial() {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "offer_price or asking_price and expected_sale_price are required for sensitivity analysis"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, runSensitivity(model, req, settingsToRates(settings)))
+}
+
+// runSensitivity builds the tornado, the two-way table (sale price x renovation cost by default)
+// and, when requested, the Monte Carlo simulation
+func runSensitivity(model viability.SensitivityModel, req sensitivityRequest, rates effectiveRates) sensitivityResponse {
+	var ranges viability.SensitivityRanges
+	if req.Ranges != nil {
+		ranges = *req.Ranges
+	}
+	ranges = ranges.WithDefaults()
+
+	x, y := viability.SensitivitySalePrice, viability.SensitivityRenovationCost
+	if req.TwoWay != nil {
+		x, y = req.TwoWay.X, req.TwoWay.Y
+	}
+
+	resp := sensitivityResponse{
+		Base:           model.Base(),
+		Ranges:         ranges,
+		Tornado:        model.Tornado(ranges),
+		TwoWay:         model.TwoWay(ranges, x, y),
+		EffectiveRates: rates,
+	}
+	if req.MonteCarlo != nil {
+		result := model.MonteCarlo(*req.MonteCarlo)
+		resp.MonteCarlo = &result
+	}
+	return resp
+}
+
+// validateSensitivityRequest returns a validation message for invalid ranges or distributions ("" when valid)
+func validateSensitivityRequest(req sensitivityRequest) string {
+	if req.Ranges != nil {
+		ranges := map[string]*viability.SensitivityRange{
+			viability.SensitivitySalePrice:      req.Ranges.SalePrice,
+			viability.SensitivityRenovationCost: req.Ranges.RenovationCost,
+			viability.SensitivityHoldMonths:     req.Ranges.HoldMonths,
+		}
+		for _, variable := range []string{viability.SensitivitySalePrice, viability.SensitivityRenovationCost, viability.SensitivityHoldMonths} {
+			rng := ranges[variable]
+			if rng == nil {
+				continue
+			}
+			if rng.Low > rng.High {
+				return fmt.Sprintf("ranges.%s.low must be less than or equal to high", variable)
+			}
+			if variable != viability.SensitivityHoldMonths && rng.Low < -1 {
+				return fmt.Sprintf("ranges.%s.low must be greater than or equal to -1", variable)
+			}
+			if rng.Steps < 0 || rng.Steps > viability.MaxSensitivitySteps {
+				return fmt.Sprintf("ranges.%s.steps must be between 0 and %d", variable, viability.MaxSensitivitySteps)
+			}
+		}
+	}
+
+	if req.TwoWay != nil {
+		if !viability.IsValidSensitivityVariable(req.TwoWay.X) || !viability.IsValidSensitivityVariable(req.TwoWay.Y) {
+			return "two_way.x and two_way.y must be sale_price, renovation_cost or hold_months"
+		}
+		if req.TwoWay.X == req.TwoWay.Y {
+			return "two_way.x and two_way.y must be different"
+		}
+	}
+
+	if mc := req.MonteCarlo; mc != nil {
+		if mc.Iterations < 0 || mc.Iterations > viability.MaxMonteCarloRuns {
+			return fmt.Sprintf("monte_carlo.iterations must be between 0 and %d", viability.MaxMonteCarloRuns)
+		}
+		distributions := map[string]*viability.Distribution{
+			viability.SensitivitySalePrice:      mc.SalePrice,
+			viability.SensitivityRenovationCost: mc.RenovationCost,
+			viability.SensitivityHoldMonths:     mc.HoldMonths,
+		}
+		for _, variable := range []string{viability.SensitivitySalePrice, viability.SensitivityRenovationCost, viability.SensitivityHoldMonths} {
+			d := distributions[variable]
+			if d == nil {
+				continue
+			}
+			if !viability.IsValidDistributionType(d.Type) {
+				return fmt.Sprintf("monte_carlo.%s.type must be triangular, uniform or normal", variable)
+			}
+			switch d.Type {
+			case viability.DistributionTriangular:
+				if d.Low > d.Mode || d.Mode > d.High {
+					return fmt.Sprintf("monte_carlo.%s must satisfy low <= mode <= high", variable)
+				}
+			case viability.DistributionUniform:
+				if d.Low > d.High {
+					return fmt.Sprintf("monte_carlo.%s.low must be less than or equal to high", variable)
+				}
+			case viability.DistributionNormal:
+				if d.StdDev < 0 {
+					return fmt.Sprintf("monte_carlo.%s.std_dev must be non-negative", variable)
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// monthlyCarry returns the monthly holding cost (condo fee + IPTU/12)
+func monthlyCarry(condoFee, iptu float64) float64 {
+	return condoFee + iptu/12
+}
@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"testing"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
+
+func TestValidateSensitivityRequest(t *testing.T) {
+	cases := []struct {
+		name    string
+		req     sensitivityRequest
+		wantErr bool
+	}{
+		{name: "empty uses defaults", req: sensitivityRequest{}},
+		{
+			name:    "inverted range",
+			req:     sensitivityRequest{Ranges: &viability.SensitivityRanges{SalePrice: &viability.SensitivityRange{Low: 0.1, High: -0.1}}},
+			wantErr: true,
+		},
+		{
+			name:    "same two-way axes",
+			req:     sensitivityRequest{TwoWay: &sensitivityTwoWayRequest{X: "sale_price", Y: "sale_price"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown distribution",
+			req:     sensitivityRequest{MonteCarlo: &viability.MonteCarloParams{SalePrice: &viability.Distribution{Type: "beta"}}},
+			wantErr: true,
+		},
+		{
+			name: "valid monte carlo",
+			req: sensitivityRequest{MonteCarlo: &viability.MonteCarloParams{
+				Iterations: 500,
+				Seed:       42,
+				HoldMonths: &viability.Distribution{Type: "triangular", Low: -1, Mode: 0, High: 3},
+			}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := validateSensitivityRequest(tc.req)
+			if (msg != "") != tc.wantErr {
+				t.Fatalf("validateSensitivityRequest()=%q wantErr=%v", msg, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunSensitivityMonteCarloIsOptIn(t *testing.T) {
+	purchase := 300000.0
+	sale := 420000.0
+	model := viability.SensitivityModel{
+		Inputs:   viability.CashInputs{PurchasePrice: &purchase, SalePrice: &sale},
+		Settings: viability.CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06},
+	}
+
+	resp := runSensitivity(model, sensitivityRequest{}, effectiveRates{})
+	if resp.MonteCarlo != nil {
+		t.Fatal("monte_carlo should be nil unless requested")
+	}
+	if len(resp.Tornado) != 3 || resp.TwoWay.XVariable != viability.SensitivitySalePrice {
+		t.Fatalf("unexpected defaults: tornado=%d two_way.x=%s", len(resp.Tornado), resp.TwoWay.XVariable)
+	}
+
+	resp = runSensitivity(model, sensitivityRequest{MonteCarlo: &viability.MonteCarloParams{Seed: 3}}, effectiveRates{})
+	if resp.MonteCarlo == nil || resp.MonteCarlo.Iterations != viability.DefaultMonteCarloRuns {
+		t.Fatalf("monte_carlo=%+v want default iterations", resp.MonteCarlo)
+	}
+}
@@ -0,0 +1,231 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
+
+type cashSolveResponse struct {
+	Result         viability.SolveResult `json:"result"`
+	Inputs         cashInputs            `json:"inputs"`
+	Outputs        cashOutputs           `json:"outputs"`
+	EffectiveRates effectiveRates        `json:"effective_rates"`
+}
+
+type financingSolveResponse struct {
+	Result         viability.SolveResult `json:"result"`
+	Inputs         financingInputs       `json:"inputs"`
+	Outputs        financingOutputs      `json:"outputs"`
+	EffectiveRates effectiveRates        `json:"effective_rates"`
+}
+
+// handleSolveCashAnalysis finds the value of one cash input that reaches a target
+// (e.g. the maximum purchase price for 25% ROI) using the property's effective rates.
+// The saved analysis is not changed; the response shows the inputs with the solved value.
+func (a *api) handleSolveCashAnalysis(w http.ResponseWriter, r *http.Request, propertyID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	var req viability.SolveRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+	if !viability.IsValidSolveTarget(req.Target) {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "target must be roi, net_profit, margin or irr"})
+		return
+	}
+	if !viability.IsValidCashSolveVariable(req.Variable) {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "variable must be purchase_price, renovation_cost, other_costs or sale_price"})
+		return
+	}
+
+	// Check access and get workspace_id
+	var workspaceID string
+	err := a.db.QueryRowContext(
+		r.Context(),
+		`SELECT p.workspace_id
+		 FROM properties p
+		 JOIN workspace_memberships m ON m.workspace_id = p.workspace_id
+		 WHERE p.id = $1 AND m.user_id = $2`,
+		propertyID, userID,
+	).Scan(&workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "property not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check property"})
+		return
+	}
+
+	settings, err := a.getEffectivePropertySettings(r.Context(), propertyID, workspaceID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch settings"})
+		return
+	}
+
+	inputs, err := a.getCashInputs(r.Context(), propertyID)
+	if err != nil && err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch analysis"})
+		return
+	}
+
+	// IRR is read from the cash-flow timeline, which needs the carry and draw assumptions
+	var flow viability.CashFlowParams
+	if req.Target == viability.SolveTargetIRR {
+		c, err := a.loadCashFlowContext(r.Context(), propertyID, workspaceID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to build cash flow"})
+			return
+		}
+		flow = cashAnalysisFlowParams(inputs, c)
+	}
+
+	result, err := viability.SolveCash(inputs.toViability(), settings, flow, req)
+	if err != nil {
+		writeSolveError(w, err)
+		return
+	}
+
+	solved := inputs
+	value := result.Value
+	switch req.Variable {
+	case viability.SolveVariablePurchasePrice:
+		solved.PurchasePrice = &value
+	case viability.SolveVariableRenovationCost:
+		solved.RenovationCost = &value
+	case viability.SolveVariableOtherCosts:
+		solved.OtherCosts = &value
+	case viability.SolveVariableSalePrice:
+		solved.SalePrice = &value
+	}
+
+	writeJSON(w, http.StatusOK, cashSolveResponse{
+		Result:         result,
+		Inputs:         solved,
+		Outputs:        a.calculateCashOutputs(solved, settings),
+		EffectiveRates: settingsToRates(settings),
+	})
+}
+
+// handleSolveFinancingAnalysis finds the purchase or sale price that reaches a target under
+// the financing plan, its recorded payments and the property's effective rates
+func (a *api) handleSolveFinancingAnalysis(w http.ResponseWriter, r *http.Request, propertyID string) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, apiError{Code: "UNAUTHORIZED", Message: "missing auth"})
+		return
+	}
+
+	var req viability.SolveRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
+		return
+	}
+	if !viability.IsValidSolveTarget(req.Target) {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "target must be roi, net_profit, margin or irr"})
+		return
+	}
+	if !viability.IsValidFinancingSolveVariable(req.Variable) {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "variable must be purchase_price or sale_price"})
+		return
+	}
+
+	// Check access and get workspace_id
+	var workspaceID string
+	err := a.db.QueryRowContext(
+		r.Context(),
+		`SELECT p.workspace_id
+		 FROM properties p
+		 JOIN workspace_memberships m ON m.workspace_id = p.workspace_id
+		 WHERE p.id = $1 AND m.user_id = $2`,
+		propertyID, userID,
+	).Scan(&workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "property not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to check property"})
+		return
+	}
+
+	settings, err := a.getEffectiveFinancingSettings(r.Context(), propertyID, workspaceID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch settings"})
+		return
+	}
+
+	planID, inputs, err := a.getFinancingPlanInputs(r.Context(), propertyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "financing plan not found"})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch financing"})
+		return
+	}
+
+	payments, err := a.getFinancingPayments(r.Context(), planID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to fetch payments"})
+		return
+	}
+
+	var flow viability.CashFlowParams
+	if req.Target == viability.SolveTargetIRR {
+		c, err := a.loadCashFlowContext(r.Context(), propertyID, workspaceID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to build cash flow"})
+			return
+		}
+		flow = financingAnalysisFlowParams(inputs, c)
+	}
+
+	result, err := viability.SolveFinancing(inputs.toViability(), toViabilityPayments(payments), settings, flow, req)
+	if err != nil {
+		writeSolveError(w, err)
+		return
+	}
+
+	solved := inputs
+	value := result.Value
+	switch req.Variable {
+	case viability.SolveVariablePurchasePrice:
+		solved.PurchasePrice = &value
+	case viability.SolveVariableSalePrice:
+		solved.SalePrice = &value
+	}
+	outputs, _ := a.calculateFinancingOutputs(solved, payments, settings)
+
+	writeJSON(w, http.StatusOK, financingSolveResponse{
+		Result:         result,
+		Inputs:         solved,
+		Outputs:        outputs,
+		EffectiveRates: financingSettingsToRates(settings),
+	})
+}
+
+func writeSolveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, viability.ErrSolvePartialInputs):
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "purchase_price and sale_price are required unless solved for"})
+	case errors.Is(err, viability.ErrInvalidSolveTarget), errors.Is(err, viability.ErrInvalidSolveVariable):
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: err.Error()})
+	default:
+		writeError(w, http.StatusInternalServerError, apiError{Code: "SOLVE_FAILED", Message: "failed to solve"})
+	}
+}
@@ -0,0 +1,85 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHandleSolveCashAnalysisMaxPurchasePrice(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	const (
+		userID      = "user-1"
+		propertyID  = "property-1"
+		workspaceID = "workspace-1"
+	)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM properties p")).
+		WithArgs(propertyID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"workspace_id"}).AddRow(workspaceID))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM workspace_settings")).
+		WithArgs(workspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"itbi_rate", "registry_rate", "broker_rate", "pj_tax_rate",
+			"tax_regime", "tax_simples_rate", "tax_ret_rate", "tax_pf_reinvested_pct",
+		}).AddRow(0.03, 0.01, 0.06, 0.15, "flat", nil, nil, nil))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM property_tax_rates")).
+		WithArgs(propertyID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM analysis_cash_inputs")).
+		WithArgs(propertyID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"purchase_price", "renovation_cost", "other_costs", "sale_price", "hold_months", "condo_fee", "iptu",
+			"acquisition_mode", "auction_terms_json",
+		}).AddRow(350000.0, 40000.0, 5000.0, 450000.0, nil, nil, nil, "market", nil))
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/properties/"+propertyID+"/analysis/cash/solve",
+		`{"variable":"purchase_price","target":"roi","target_value":25}`, userID)
+
+	a.handleSolveCashAnalysis(rr, req, propertyID)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var resp cashSolveResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v body=%s", err, rr.Body.String())
+	}
+	if !resp.Result.Feasible {
+		t.Fatalf("result.feasible=false body=%s", rr.Body.String())
+	}
+	if resp.Inputs.PurchasePrice == nil || *resp.Inputs.PurchasePrice != resp.Result.Value {
+		t.Fatalf("inputs.purchase_price=%v want=%.2f", resp.Inputs.PurchasePrice, resp.Result.Value)
+	}
+	if math.Abs(resp.Outputs.ROI-25) > 0.01 {
+		t.Fatalf("outputs.roi=%.2f want=25", resp.Outputs.ROI)
+	}
+}
+
+func TestHandleSolveCashAnalysisRejectsUnknownTarget(t *testing.T) {
+	a, _, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	rr := httptest.NewRecorder()
+	req := authedJSONRequest(http.MethodPost, "/api/v1/properties/property-1/analysis/cash/solve",
+		`{"variable":"purchase_price","target":"payback","target_value":12}`, "user-1")
+
+	a.handleSolveCashAnalysis(rr, req, "property-1")
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d want=%d body=%s", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+	if got := decodeAPIErrorCode(t, rr); got != "VALIDATION_ERROR" {
+		t.Fatalf("error.code=%s want=VALIDATION_ERROR", got)
+	}
+}
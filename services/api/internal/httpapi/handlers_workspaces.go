@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/widia-projects/widia-flip/services/api/internal/auth"
+	"github.com/widia-projects/widia-flip/services/api/internal/marketingest"
 )
 
 type workspaceMembership struct {
@@ -85,6 +86,30 @@ func (a *api) handleWorkspacesSubroutes(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// Flip Score scoring profile (weights and red-flag penalties)
+	if len(parts) == 2 && parts[1] == "flip-score-profile" {
+		a.handleWorkspaceFlipScoreProfile(w, r, workspaceID)
+		return
+	}
+
+	// Flip Score backtest against realized outcomes
+	if len(parts) == 2 && parts[1] == "flip-score-backtest" {
+		a.handleWorkspaceFlipScoreBacktest(w, r, workspaceID)
+		return
+	}
+
+	// Bulk Flip Score recompute jobs
+	if len(parts) >= 2 && parts[1] == "flip-score-recompute-jobs" {
+		a.handleWorkspaceFlipScoreRecomputeJobs(w, r, workspaceID, parts[2:])
+		return
+	}
+
+	// Offer decision rules (GO/REVIEW/NO_GO conditions)
+	if len(parts) == 2 && parts[1] == "offer-decision-rules" {
+		a.handleWorkspaceOfferDecisionRules(w, r, workspaceID)
+		return
+	}
+
 	// M11 - Usage tracking
 	if len(parts) == 2 && parts[1] == "usage" {
 		a.handleGetWorkspaceUsage(w, r, workspaceID)
@@ -385,9 +410,18 @@ func (a *api) isWorkspaceOwner(ctx context.Context, workspaceID string, userID s
 }
 
 type workspaceSettings struct {
-	WorkspaceID string    `json:"workspace_id"`
-	PJTaxRate   float64   `json:"pj_tax_rate"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	WorkspaceID  string    `json:"workspace_id"`
+	PJTaxRate    float64   `json:"pj_tax_rate"`
+	DiscountRate float64   `json:"discount_rate"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	TaxRegime          string   `json:"tax_regime"`
+	TaxSimplesRate     *float64 `json:"tax_simples_rate"`
+	TaxRETRate         *float64 `json:"tax_ret_rate"`
+	TaxPFReinvestedPct *float64 `json:"tax_pf_reinvested_pct"`
+
+	// MarketCity is the city slug used for ITBI market data when a prospect address has none
+	MarketCity string `json:"market_city"`
 }
 
 func (a *api) handleGetWorkspaceSettings(w http.ResponseWriter, r *http.Request, workspaceID string) {
@@ -409,9 +443,12 @@ func (a *api) handleGetWorkspaceSettings(w http.ResponseWriter, r *http.Request,
 	s.WorkspaceID = workspaceID
 	err := a.db.QueryRowContext(
 		r.Context(),
-		`SELECT pj_tax_rate, updated_at FROM workspace_settings WHERE workspace_id = $1`,
+		`SELECT pj_tax_rate, discount_rate, updated_at,
+		        tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct, market_city
+		 FROM workspace_settings WHERE workspace_id = $1`,
 		workspaceID,
-	).Scan(&s.PJTaxRate, &s.UpdatedAt)
+	).Scan(&s.PJTaxRate, &s.DiscountRate, &s.UpdatedAt,
+		&s.TaxRegime, &s.TaxSimplesRate, &s.TaxRETRate, &s.TaxPFReinvestedPct, &s.MarketCity)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			writeError(w, http.StatusNotFound, apiError{Code: "NOT_FOUND", Message: "settings not found"})
@@ -425,7 +462,15 @@ func (a *api) handleGetWorkspaceSettings(w http.ResponseWriter, r *http.Request,
 }
 
 type updateWorkspaceSettingsRequest struct {
-	PJTaxRate float64 `json:"pj_tax_rate"`
+	PJTaxRate    *float64 `json:"pj_tax_rate"`
+	DiscountRate *float64 `json:"discount_rate"`
+
+	TaxRegime          *string  `json:"tax_regime"`
+	TaxSimplesRate     *float64 `json:"tax_simples_rate"`
+	TaxRETRate         *float64 `json:"tax_ret_rate"`
+	TaxPFReinvestedPct *float64 `json:"tax_pf_reinvested_pct"`
+
+	MarketCity *string `json:"market_city"`
 }
 
 func (a *api) handleUpdateWorkspaceSettings(w http.ResponseWriter, r *http.Request, workspaceID string) {
@@ -450,19 +495,56 @@ func (a *api) handleUpdateWorkspaceSettings(w http.ResponseWriter, r *http.Reque
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "invalid json body", Details: []string{err.Error()}})
 		return
 	}
-	if req.PJTaxRate < 0 || req.PJTaxRate > 1 {
+	if req.PJTaxRate != nil && (*req.PJTaxRate < 0 || *req.PJTaxRate > 1) {
 		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "pj_tax_rate must be between 0 and 1"})
 		return
 	}
+	if req.DiscountRate != nil && (*req.DiscountRate < 0 || *req.DiscountRate > 1) {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: "discount_rate must be between 0 and 1"})
+		return
+	}
+	if msg := validateTaxSettingsInput(req.TaxRegime, req.TaxSimplesRate, req.TaxRETRate, req.TaxPFReinvestedPct); msg != "" {
+		writeError(w, http.StatusBadRequest, apiError{Code: "VALIDATION_ERROR", Message: msg})
+		return
+	}
+	if req.MarketCity != nil {
+		profile, ok := marketingest.LookupCityProfile(*req.MarketCity)
+		if !ok {
+			writeError(w, http.StatusBadRequest, apiError{
+				Code:    "VALIDATION_ERROR",
+				Message: "market_city must be one of: " + strings.Join(marketingest.SupportedCities(), ", "),
+			})
+			return
+		}
+		req.MarketCity = &profile.City
+	}
 
 	var s workspaceSettings
 	s.WorkspaceID = workspaceID
 	err := a.db.QueryRowContext(
 		r.Context(),
-		`UPDATE workspace_settings SET pj_tax_rate = $1, updated_at = now() WHERE workspace_id = $2 RETURNING pj_tax_rate, updated_at`,
+		`UPDATE workspace_settings
+		 SET pj_tax_rate = COALESCE($1, pj_tax_rate),
+		     discount_rate = COALESCE($2, discount_rate),
+		     tax_regime = COALESCE($3, tax_regime),
+		     tax_simples_rate = COALESCE($4, tax_simples_rate),
+		     tax_ret_rate = COALESCE($5, tax_ret_rate),
+		     tax_pf_reinvested_pct = COALESCE($6, tax_pf_reinvested_pct),
+		     market_city = COALESCE($8, market_city),
+		     updated_at = now()
+		 WHERE workspace_id = $7
+		 RETURNING pj_tax_rate, discount_rate, updated_at,
+		           tax_regime, tax_simples_rate, tax_ret_rate, tax_pf_reinvested_pct, market_city`,
 		req.PJTaxRate,
+		req.DiscountRate,
+		req.TaxRegime,
+		req.TaxSimplesRate,
+		req.TaxRETRate,
+		req.TaxPFReinvestedPct,
 		workspaceID,
-	).Scan(&s.PJTaxRate, &s.UpdatedAt)
+		req.MarketCity,
+	).Scan(&s.PJTaxRate, &s.DiscountRate, &s.UpdatedAt,
+		&s.TaxRegime, &s.TaxSimplesRate, &s.TaxRETRate, &s.TaxPFReinvestedPct, &s.MarketCity)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, apiError{Code: "DB_ERROR", Message: "failed to update settings"})
 		return
@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 
@@ -16,6 +17,8 @@ type Deps struct {
 	LLMClient                *llm.Client
 	StorageProvider          string // "minio" or "supabase"
 	OfferIntelligenceRollout string
+	ScraperScheduler         ScraperSchedulerConfig
+	MarketGeoDir             string // bairro polygons and street indexes per city for market ingestion
 }
 
 func NewHandler(deps Deps) http.Handler {
@@ -26,7 +29,20 @@ func NewHandler(deps Deps) http.Handler {
 		llmClient:                deps.LLMClient,
 		storageProvider:          deps.StorageProvider,
 		offerIntelligenceRollout: deps.OfferIntelligenceRollout,
+		marketGeoDir:             deps.MarketGeoDir,
 		offerLimiter:             newOfferRateLimiter(),
+		flipScoreJobs:            newFlipScoreJobRunner(),
+		scraperRunner:            newScraperRunner(deps.ScraperScheduler.MaxConcurrency),
+	}
+
+	// Resume Flip Score recompute jobs interrupted by a restart
+	if deps.DB != nil {
+		go api.resumeFlipScoreRecomputeJobs(context.Background())
+	}
+
+	// Scheduled scraping from the opportunity scraper placeholders
+	if deps.DB != nil && deps.ScraperScheduler.Enabled {
+		go api.runOpportunityScraperScheduler(context.Background(), deps.ScraperScheduler.PollInterval)
 	}
 
 	// Public routes (no auth required)
@@ -124,6 +140,7 @@ func NewHandler(deps Deps) http.Handler {
 	adminMux.HandleFunc("/api/v1/admin/market/aliases/", api.handleAdminMarketAliasesSubroutes)
 	adminMux.HandleFunc("/api/v1/admin/blog/posts", api.handleAdminBlogPostsCollection)
 	adminMux.HandleFunc("/api/v1/admin/blog/posts/", api.handleAdminBlogPostsSubroutes)
+	adminMux.HandleFunc("/api/v1/admin/flip-score/backtest", api.handleAdminFlipScoreBacktest)
 	var adminHandler http.Handler = adminMux
 	adminHandler = adminAuthMiddleware(api.tokenVerifier, api.db, adminHandler)
 
@@ -159,5 +176,8 @@ type api struct {
 	llmClient                *llm.Client
 	storageProvider          string
 	offerIntelligenceRollout string
+	marketGeoDir             string
 	offerLimiter             *offerRateLimiter
+	flipScoreJobs            *flipScoreJobRunner
+	scraperRunner            *scraperRunner
 }
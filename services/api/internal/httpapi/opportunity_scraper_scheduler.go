@@ -0,0 +1,316 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/schedule"
+)
+
+const (
+	defaultScraperSchedulerPollInterval = time.Minute
+	scraperScheduleBatchSize            = 10
+	// A scheduled run that takes longer than this is cancelled and counted as a failure
+	scheduledScraperRunTimeout = 20 * time.Minute
+)
+
+// ScraperSchedulerConfig controls the in-process scraper scheduler
+type ScraperSchedulerConfig struct {
+	Enabled        bool
+	MaxConcurrency int           // Chrome instances shared by manual and scheduled runs
+	PollInterval   time.Duration // how often due placeholders are looked up
+}
+
+// scraperRunner caps the concurrent scraper runs (each one drives a Chrome
+// instance) and tracks the scheduled placeholders running in this process
+type scraperRunner struct {
+	slots   chan struct{}
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+func newScraperRunner(maxConcurrency int) *scraperRunner {
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+	return &scraperRunner{
+		slots:   make(chan struct{}, maxConcurrency),
+		running: make(map[string]bool),
+	}
+}
+
+func (r *scraperRunner) claim(placeholderID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running[placeholderID] {
+		return false
+	}
+	r.running[placeholderID] = true
+	return true
+}
+
+func (r *scraperRunner) release(placeholderID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, placeholderID)
+}
+
+// acquireScraperSlot blocks until a Chrome slot is free; the returned func frees it
+func (a *api) acquireScraperSlot(ctx context.Context) (func(), error) {
+	if a.scraperRunner == nil {
+		return func() {}, nil
+	}
+	select {
+	case a.scraperRunner.slots <- struct{}{}:
+		return func() { <-a.scraperRunner.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// validateOpportunityScraperSchedule returns the spec to compute runs from, or
+// nil when the schedule is being cleared
+func validateOpportunityScraperSchedule(req opportunityScraperScheduleRequest) (*schedule.Spec, error) {
+	if req.Limit < 0 || req.Limit > maxOpportunityScraperLimit {
+		return nil, errors.New("schedule limit must be between 1 and 200")
+	}
+	spec := schedule.Spec{
+		Cron:     strings.TrimSpace(req.Cron),
+		Interval: time.Duration(req.IntervalMinutes) * time.Minute,
+		Jitter:   time.Duration(req.JitterSeconds) * time.Second,
+	}
+	if !req.Enabled && spec.Cron == "" && spec.Interval == 0 {
+		return nil, nil
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// saveOpportunityScraperSchedule stores the schedule and computes the first run.
+// Saving resets the failure backoff.
+func (a *api) saveOpportunityScraperSchedule(ctx context.Context, placeholderID string, req opportunityScraperScheduleRequest, spec *schedule.Spec) error {
+	var cron, interval, limit, nextRunAt interface{}
+	jitterSeconds := 0
+	if spec != nil {
+		if spec.Cron != "" {
+			cron = spec.Cron
+		}
+		if req.IntervalMinutes > 0 {
+			interval = req.IntervalMinutes
+		}
+		jitterSeconds = req.JitterSeconds
+		if req.Enabled {
+			next, err := spec.Next(time.Now(), nil)
+			if err != nil {
+				return err
+			}
+			nextRunAt = next
+		}
+	}
+	if req.Limit > 0 {
+		limit = req.Limit
+	}
+
+	_, err := a.db.ExecContext(ctx, `
+		UPDATE opportunity_scraper_placeholders
+		SET schedule_enabled = $1,
+			schedule_cron = $2,
+			schedule_interval_minutes = $3,
+			schedule_jitter_seconds = $4,
+			schedule_limit = $5,
+			next_run_at = $6,
+			consecutive_failures = 0,
+			last_error = NULL,
+			updated_at = NOW()
+		WHERE id = $7
+	`, req.Enabled && spec != nil, cron, interval, jitterSeconds, limit, nextRunAt, placeholderID)
+	return err
+}
+
+// runOpportunityScraperScheduler polls for due placeholders until ctx is done
+func (a *api) runOpportunityScraperScheduler(ctx context.Context, pollInterval time.Duration) {
+	if pollInterval <= 0 {
+		pollInterval = defaultScraperSchedulerPollInterval
+	}
+	log.Printf("scraper_scheduler_started poll_interval=%s slots=%d", pollInterval, cap(a.scraperRunner.slots))
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		a.dispatchDueScraperSchedules(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *api) dispatchDueScraperSchedules(ctx context.Context) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT id FROM opportunity_scraper_placeholders
+		WHERE schedule_enabled AND next_run_at IS NOT NULL AND next_run_at <= NOW()
+		ORDER BY next_run_at ASC
+		LIMIT $1
+	`, scraperScheduleBatchSize)
+	if err != nil {
+		log.Printf("scraper_scheduler_error error=%v", err)
+		return
+	}
+	var due []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			log.Printf("scraper_scheduler_error error=%v", err)
+			return
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+
+	for _, id := range due {
+		if !a.scraperRunner.claim(id) {
+			continue
+		}
+		go func(placeholderID string) {
+			defer a.scraperRunner.release(placeholderID)
+			a.runScheduledOpportunityScraper(ctx, placeholderID)
+		}(id)
+	}
+}
+
+// runScheduledOpportunityScraper runs one placeholder under a DB advisory lock so
+// only one API replica picks it up, then advances next_run_at (with exponential
+// backoff after failures)
+func (a *api) runScheduledOpportunityScraper(ctx context.Context, placeholderID string) {
+	conn, err := a.db.Conn(ctx)
+	if err != nil {
+		log.Printf("scraper_schedule_error placeholder_id=%s error=%v", placeholderID, err)
+		return
+	}
+	locked, err := tryAcquireOpportunityScraperLock(ctx, conn, placeholderID)
+	if err != nil || !locked {
+		if err != nil {
+			log.Printf("scraper_schedule_error placeholder_id=%s error=%v", placeholderID, err)
+		}
+		_ = conn.Close()
+		return
+	}
+	defer releaseOpportunityScraperLockAndClose(conn, placeholderID)
+
+	// Another replica may have run it between the lookup and the lock
+	placeholder, err := scanOpportunityScraperPlaceholder(a.db.QueryRowContext(ctx, `
+		SELECT `+opportunityScraperPlaceholderColumns+`
+		FROM opportunity_scraper_placeholders
+		WHERE id = $1 AND schedule_enabled AND next_run_at <= NOW()
+	`, placeholderID))
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("scraper_schedule_error placeholder_id=%s error=%v", placeholderID, err)
+		return
+	}
+
+	limit := defaultOpportunityScraperLimit
+	if placeholder.Schedule.Limit != nil {
+		limit = *placeholder.Schedule.Limit
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, scheduledScraperRunTimeout)
+	resp, runErr := a.executeOpportunityScraperRun(runCtx, opportunityScraperRunParams{
+		State:         placeholder.State,
+		City:          placeholder.City,
+		Neighborhood:  placeholder.Neighborhood,
+		Sources:       placeholder.Sources,
+		Limit:         limit,
+		TriggerType:   "schedule",
+		PlaceholderID: placeholder.ID,
+	})
+	cancel()
+
+	a.advanceOpportunityScraperSchedule(ctx, placeholder, time.Now(), resp, runErr)
+}
+
+// advanceOpportunityScraperSchedule stores the next run after a scheduled run finished at
+// now: the regular next run on success, pushed back by the failure backoff otherwise
+func (a *api) advanceOpportunityScraperSchedule(ctx context.Context, placeholder opportunityScraperPlaceholderResponse, now time.Time, resp runOpportunityScraperResponse, runErr error) {
+	placeholderID := placeholder.ID
+	spec := schedule.Spec{Jitter: time.Duration(placeholder.Schedule.JitterSeconds) * time.Second}
+	if placeholder.Schedule.Cron != nil {
+		spec.Cron = *placeholder.Schedule.Cron
+	}
+	if placeholder.Schedule.IntervalMinutes != nil {
+		spec.Interval = time.Duration(*placeholder.Schedule.IntervalMinutes) * time.Minute
+	}
+
+	next, specErr := spec.Next(now, nil)
+	if specErr != nil {
+		// Invalid stored schedule: disable it instead of retrying every poll
+		log.Printf("scraper_schedule_error placeholder_id=%s error=%v", placeholderID, specErr)
+		a.updateOpportunityScraperScheduleState(ctx, placeholderID, nil, placeholder.Schedule.ConsecutiveFailures, specErr)
+		return
+	}
+
+	if runErr != nil {
+		failures := placeholder.Schedule.ConsecutiveFailures + 1
+		// Failures push runs further apart, never closer than the regular schedule
+		if retryAt := now.Add(schedule.Backoff(failures, schedule.DefaultBackoff, schedule.DefaultMaxBackoff)); retryAt.After(next) {
+			next = retryAt
+		}
+		log.Printf("scraper_schedule_failed placeholder_id=%s job_run_id=%s failures=%d next_run_at=%s error=%v",
+			placeholderID, resp.JobRunID, failures, next.Format(time.RFC3339), runErr)
+		a.updateOpportunityScraperScheduleState(ctx, placeholderID, &next, failures, runErr)
+		return
+	}
+
+	log.Printf("scraper_schedule_completed placeholder_id=%s job_run_id=%s listings=%d next_run_at=%s",
+		placeholderID, resp.JobRunID, resp.Stats.TotalReceived, next.Format(time.RFC3339))
+	a.updateOpportunityScraperScheduleState(ctx, placeholderID, &next, 0, nil)
+}
+
+// updateOpportunityScraperScheduleState advances the schedule; a nil next run disables it
+func (a *api) updateOpportunityScraperScheduleState(ctx context.Context, placeholderID string, nextRunAt *time.Time, failures int, runErr error) {
+	var lastError *string
+	if runErr != nil {
+		message := runErr.Error()
+		lastError = &message
+	}
+	_, err := a.db.ExecContext(ctx, `
+		UPDATE opportunity_scraper_placeholders
+		SET next_run_at = $1,
+			schedule_enabled = schedule_enabled AND $1::timestamptz IS NOT NULL,
+			consecutive_failures = $2,
+			last_error = $3,
+			updated_at = NOW()
+		WHERE id = $4
+	`, nextRunAt, failures, lastError, placeholderID)
+	if err != nil {
+		log.Printf("scraper_schedule_error placeholder_id=%s error=%v", placeholderID, err)
+	}
+}
+
+func tryAcquireOpportunityScraperLock(ctx context.Context, conn *sql.Conn, placeholderID string) (bool, error) {
+	var locked bool
+	err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext('opportunity_scraper'), hashtext($1))`, placeholderID).Scan(&locked)
+	return locked, err
+}
+
+func releaseOpportunityScraperLockAndClose(conn *sql.Conn, placeholderID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext('opportunity_scraper'), hashtext($1))`, placeholderID); err != nil {
+		log.Printf("scraper_schedule_error placeholder_id=%s unlock_error=%v", placeholderID, err)
+	}
+	if err := conn.Close(); err != nil {
+		log.Printf("scraper_schedule_error placeholder_id=%s close_error=%v", placeholderID, err)
+	}
+}
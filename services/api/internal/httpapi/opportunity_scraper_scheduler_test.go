@@ -0,0 +1,106 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func scheduledPlaceholder(intervalMinutes, failures int) opportunityScraperPlaceholderResponse {
+	return opportunityScraperPlaceholderResponse{
+		ID:   "placeholder-1",
+		City: "sao-paulo",
+		Schedule: opportunityScraperScheduleResponse{
+			Enabled:             true,
+			IntervalMinutes:     &intervalMinutes,
+			ConsecutiveFailures: failures,
+		},
+	}
+}
+
+func expectScraperScheduleState(mock sqlmock.Sqlmock, nextRunAt interface{}, failures int, lastError interface{}) {
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE opportunity_scraper_placeholders")).
+		WithArgs(nextRunAt, failures, lastError, "placeholder-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestAdvanceOpportunityScraperScheduleResetsFailuresOnSuccess(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	expectScraperScheduleState(mock, now.Add(time.Hour), 0, nil)
+
+	a.advanceOpportunityScraperSchedule(context.Background(), scheduledPlaceholder(60, 3), now, runOpportunityScraperResponse{}, nil)
+}
+
+func TestAdvanceOpportunityScraperScheduleBacksOffAfterFailures(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	runErr := errors.New("chrome crashed")
+	tests := []struct {
+		name            string
+		intervalMinutes int
+		failures        int
+		wantNext        time.Time
+	}{
+		// 3rd failure backs off 40 minutes, past the 15-minute interval
+		{name: "backoff past the next run", intervalMinutes: 15, failures: 2, wantNext: now.Add(40 * time.Minute)},
+		// 1st failure backs off 10 minutes, but the daily run is never moved earlier
+		{name: "backoff before the next run", intervalMinutes: 1440, failures: 0, wantNext: now.Add(24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+			defer cleanup()
+
+			expectScraperScheduleState(mock, tt.wantNext, tt.failures+1, runErr.Error())
+
+			a.advanceOpportunityScraperSchedule(context.Background(), scheduledPlaceholder(tt.intervalMinutes, tt.failures), now, runOpportunityScraperResponse{}, runErr)
+		})
+	}
+}
+
+func TestAdvanceOpportunityScraperScheduleDisablesInvalidSchedule(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	// A 5-minute interval stored before the 15-minute minimum: no next run, failures kept
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	expectScraperScheduleState(mock, nil, 2, "schedule interval must be at least 15 minutes")
+
+	a.advanceOpportunityScraperSchedule(context.Background(), scheduledPlaceholder(5, 2), now, runOpportunityScraperResponse{}, nil)
+}
+
+func TestRunScheduledOpportunityScraperSkipsPlaceholderAlreadyRun(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	// Another replica ran it and advanced next_run_at between the lookup and the lock
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_lock(hashtext('opportunity_scraper'), hashtext($1))")).
+		WithArgs("placeholder-1").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id = $1 AND schedule_enabled AND next_run_at <= NOW()")).
+		WithArgs("placeholder-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock(hashtext('opportunity_scraper'), hashtext($1))")).
+		WithArgs("placeholder-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.runScheduledOpportunityScraper(context.Background(), "placeholder-1")
+}
+
+func TestRunScheduledOpportunityScraperSkipsWhenLocked(t *testing.T) {
+	a, mock, cleanup := newOfferIntelligenceTestAPI(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_lock(hashtext('opportunity_scraper'), hashtext($1))")).
+		WithArgs("placeholder-1").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	a.runScheduledOpportunityScraper(context.Background(), "placeholder-1")
+}
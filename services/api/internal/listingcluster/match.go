@@ -0,0 +1,313 @@
+// Package listingcluster groups listings of the same apartment published by
+// different portals and agencies. Two listings match when their address, area,
+// bedrooms and price agree within tolerance, or when they share a listing photo
+// (perceptual hash within a small Hamming distance).
+package listingcluster
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Match methods
+const (
+	MethodAttributes = "attributes"
+	MethodImage      = "image"
+)
+
+// Listing is the subset of a source listing used for matching
+type Listing struct {
+	ID           string
+	ClusterID    string
+	Source       string
+	Address      string
+	Neighborhood string
+	City         string
+	AreaM2       float64
+	Bedrooms     int
+	PriceCents   int64
+	ImageHashes  []uint64
+}
+
+// Config holds the match tolerances
+type Config struct {
+	AreaTolerancePct     float64 // relative area difference, both methods
+	PriceTolerancePct    float64 // relative price difference, attribute matches only
+	MinAddressSimilarity float64 // Dice coefficient over the street tokens
+	MaxImageDistance     int     // Hamming distance between 64-bit dHashes
+}
+
+// DefaultConfig returns the tolerances used by the ingest
+func DefaultConfig() Config {
+	return Config{
+		AreaTolerancePct:     0.05,
+		PriceTolerancePct:    0.15,
+		MinAddressSimilarity: 0.8,
+		MaxImageDistance:     6,
+	}
+}
+
+// MatchResult explains why two listings were (or were not) grouped
+type MatchResult struct {
+	Matched           bool    `json:"matched"`
+	Method            string  `json:"method,omitempty"`
+	AddressSimilarity float64 `json:"address_similarity"`
+	ImageDistance     int     `json:"image_distance"` // -1 when either listing has no usable hash
+}
+
+// Match reports whether a and b are the same apartment. City, bedrooms and area
+// must agree for both methods; the price tolerance only applies to attribute
+// matches, since agencies often ask different prices for the same unit.
+func Match(a, b Listing, cfg Config) MatchResult {
+	result := MatchResult{ImageDistance: -1}
+
+	if !sameLabel(a.City, b.City) || !sameLabel(a.Neighborhood, b.Neighborhood) {
+		return result
+	}
+	if a.Bedrooms > 0 && b.Bedrooms > 0 && a.Bedrooms != b.Bedrooms {
+		return result
+	}
+	if a.AreaM2 <= 0 || b.AreaM2 <= 0 || relativeDiff(a.AreaM2, b.AreaM2) > cfg.AreaTolerancePct {
+		return result
+	}
+
+	if distance, ok := minImageDistance(a.ImageHashes, b.ImageHashes); ok {
+		result.ImageDistance = distance
+		if distance <= cfg.MaxImageDistance {
+			result.Matched = true
+			result.Method = MethodImage
+		}
+	}
+
+	addrA := ParseAddress(a.Address)
+	addrB := ParseAddress(b.Address)
+	result.AddressSimilarity = addrA.Similarity(addrB)
+	if result.Matched {
+		return result
+	}
+
+	if result.AddressSimilarity < cfg.MinAddressSimilarity {
+		return result
+	}
+	if addrA.Number != "" && addrB.Number != "" && addrA.Number != addrB.Number {
+		return result
+	}
+	if a.PriceCents <= 0 || b.PriceCents <= 0 || relativeDiff(float64(a.PriceCents), float64(b.PriceCents)) > cfg.PriceTolerancePct {
+		return result
+	}
+
+	result.Matched = true
+	result.Method = MethodAttributes
+	return result
+}
+
+// Address is a normalized street address
+type Address struct {
+	Tokens []string // street type and name, abbreviations expanded
+	Number string   // first number after the street name
+}
+
+var addressAbbreviations = map[string]string{
+	"r":    "rua",
+	"av":   "avenida",
+	"al":   "alameda",
+	"tv":   "travessa",
+	"trav": "travessa",
+	"pc":   "praca",
+	"pca":  "praca",
+	"rod":  "rodovia",
+	"estr": "estrada",
+	"dr":   "doutor",
+	"prof": "professor",
+	"pres": "presidente",
+	"cel":  "coronel",
+	"gen":  "general",
+	"eng":  "engenheiro",
+	"des":  "desembargador",
+	"sto":  "santo",
+	"sta":  "santa",
+	"mal":  "marechal",
+	"cap":  "capitao",
+	"cmte": "comandante",
+}
+
+var addressStopwords = map[string]struct{}{
+	"de": {}, "da": {}, "do": {}, "dos": {}, "das": {}, "e": {},
+	"n": {}, "nº": {}, "no": {}, "nro": {}, "numero": {}, "o": {},
+}
+
+// ParseAddress normalizes accents, abbreviations and punctuation
+func ParseAddress(raw string) Address {
+	var addr Address
+	for _, token := range strings.Fields(normalizeText(raw)) {
+		if isDigits(token) {
+			if addr.Number == "" && len(addr.Tokens) > 0 {
+				addr.Number = strings.TrimLeft(token, "0")
+			}
+			continue
+		}
+		// Unit and complement details (apto 12, bloco B) are not part of the street
+		if token == "apto" || token == "ap" || token == "apartamento" || token == "bloco" || token == "bl" {
+			break
+		}
+		if expanded, ok := addressAbbreviations[token]; ok {
+			token = expanded
+		}
+		if _, stop := addressStopwords[token]; stop {
+			continue
+		}
+		if addr.Number == "" {
+			addr.Tokens = append(addr.Tokens, token)
+		}
+	}
+	return addr
+}
+
+// Similarity returns the Dice coefficient of the street tokens (0 when unknown)
+func (a Address) Similarity(b Address) float64 {
+	if len(a.Tokens) == 0 || len(b.Tokens) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(a.Tokens))
+	for _, token := range a.Tokens {
+		counts[token]++
+	}
+	shared := 0
+	for _, token := range b.Tokens {
+		if counts[token] > 0 {
+			counts[token]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a.Tokens)+len(b.Tokens))
+}
+
+// Groups clusters listings transitively (union-find) and returns the member
+// indexes of each group with more than one listing
+func Groups(listings []Listing, cfg Config) [][]int {
+	parent := make([]int, len(listings))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range listings {
+		for j := i + 1; j < len(listings); j++ {
+			if find(i) == find(j) {
+				continue
+			}
+			if Match(listings[i], listings[j], cfg).Matched {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	order := make([]int, 0)
+	for i := range listings {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			order = append(order, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	groups := make([][]int, 0)
+	for _, root := range order {
+		if len(members[root]) > 1 {
+			groups = append(groups, members[root])
+		}
+	}
+	return groups
+}
+
+// PriceSpread summarizes the asking prices of a cluster. SpreadPct is relative to
+// the lowest price.
+func PriceSpread(prices []int64) (lowest, highest, spread int64, spreadPct float64) {
+	for _, price := range prices {
+		if price <= 0 {
+			continue
+		}
+		if lowest == 0 || price < lowest {
+			lowest = price
+		}
+		if price > highest {
+			highest = price
+		}
+	}
+	if lowest == 0 {
+		return 0, 0, 0, 0
+	}
+	spread = highest - lowest
+	spreadPct = math.Round(float64(spread)/float64(lowest)*10000) / 10000
+	return lowest, highest, spread, spreadPct
+}
+
+var labelAbbreviations = map[string]string{
+	"vl":  "vila",
+	"jd":  "jardim",
+	"jdm": "jardim",
+	"pq":  "parque",
+	"res": "residencial",
+	"sta": "santa",
+	"sto": "santo",
+}
+
+// sameLabel compares city/neighborhood labels; an empty label matches anything
+func sameLabel(a, b string) bool {
+	a = normalizeLabel(a)
+	b = normalizeLabel(b)
+	return a == "" || b == "" || a == b
+}
+
+func normalizeLabel(value string) string {
+	tokens := strings.Fields(normalizeText(value))
+	for i, token := range tokens {
+		if expanded, ok := labelAbbreviations[token]; ok {
+			tokens[i] = expanded
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+func relativeDiff(a, b float64) float64 {
+	return math.Abs(a-b) / math.Max(a, b)
+}
+
+func normalizeText(value string) string {
+	var builder strings.Builder
+	lastSpace := true
+	for _, r := range norm.NFD.String(strings.ToLower(value)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(r)
+			lastSpace = false
+		default:
+			if !lastSpace {
+				builder.WriteRune(' ')
+				lastSpace = true
+			}
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
@@ -0,0 +1,145 @@
+package listingcluster
+
+import "testing"
+
+func baseListing() Listing {
+	return Listing{
+		ID:           "zap-1",
+		Source:       "ZAP",
+		Address:      "Rua Brasílio Itiberê, 1200",
+		Neighborhood: "Vila Izabel",
+		City:         "Curitiba",
+		AreaM2:       82,
+		Bedrooms:     3,
+		PriceCents:   52000000,
+	}
+}
+
+func TestMatchByFuzzyAddressAndAttributes(t *testing.T) {
+	a := baseListing()
+	b := Listing{
+		ID:           "vivareal-1",
+		Source:       "VIVAREAL",
+		Address:      "R. Brasilio Itibere, nº 1200 - apto 71",
+		Neighborhood: "Vl. Izabel",
+		City:         "curitiba",
+		AreaM2:       80,
+		Bedrooms:     3,
+		PriceCents:   49500000,
+	}
+
+	result := Match(a, b, DefaultConfig())
+	if !result.Matched || result.Method != MethodAttributes {
+		t.Fatalf("expected attribute match, got %+v", result)
+	}
+	if result.AddressSimilarity != 1 {
+		t.Fatalf("address_similarity=%.2f want=1", result.AddressSimilarity)
+	}
+}
+
+func TestMatchRejectsDifferentUnits(t *testing.T) {
+	cfg := DefaultConfig()
+	a := baseListing()
+
+	otherNumber := baseListing()
+	otherNumber.Address = "Rua Brasílio Itiberê, 1500"
+	if Match(a, otherNumber, cfg).Matched {
+		t.Fatal("different street numbers must not match")
+	}
+
+	otherBedrooms := baseListing()
+	otherBedrooms.Bedrooms = 2
+	if Match(a, otherBedrooms, cfg).Matched {
+		t.Fatal("different bedrooms must not match")
+	}
+
+	otherArea := baseListing()
+	otherArea.AreaM2 = 95
+	if Match(a, otherArea, cfg).Matched {
+		t.Fatal("area beyond tolerance must not match")
+	}
+
+	otherPrice := baseListing()
+	otherPrice.PriceCents = 70000000
+	if Match(a, otherPrice, cfg).Matched {
+		t.Fatal("price beyond tolerance must not match without a shared photo")
+	}
+
+	otherCity := baseListing()
+	otherCity.City = "São José dos Pinhais"
+	if Match(a, otherCity, cfg).Matched {
+		t.Fatal("different cities must not match")
+	}
+}
+
+func TestMatchBySharedPhotoIgnoresPriceAndAddress(t *testing.T) {
+	a := baseListing()
+	a.ImageHashes = []uint64{0xf0f0f0f0f0f0f0f0}
+
+	b := baseListing()
+	b.Address = ""
+	b.PriceCents = 62000000 // another agency asks 19% more
+	b.ImageHashes = []uint64{0x00000000000000ff, 0xf0f0f0f0f0f0f0f3}
+
+	result := Match(a, b, DefaultConfig())
+	if !result.Matched || result.Method != MethodImage || result.ImageDistance != 2 {
+		t.Fatalf("expected image match at distance 2, got %+v", result)
+	}
+
+	// Blank placeholders never match
+	a.ImageHashes = []uint64{0}
+	b.ImageHashes = []uint64{0}
+	if result := Match(a, b, DefaultConfig()); result.Matched || result.ImageDistance != -1 {
+		t.Fatalf("expected blank hashes to be ignored, got %+v", result)
+	}
+}
+
+func TestGroupsIsTransitive(t *testing.T) {
+	a := baseListing()
+	a.ImageHashes = []uint64{0xf0f0f0f0f0f0f0f0}
+	b := baseListing()
+	b.ID = "olx-1"
+	b.Address = ""
+	b.PriceCents = 62000000
+	b.ImageHashes = []uint64{0xf0f0f0f0f0f0f0f1}
+	c := baseListing()
+	c.ID = "zap-2"
+	c.PriceCents = 51000000
+	d := baseListing()
+	d.ID = "zap-3"
+	d.Address = "Avenida República Argentina, 2100"
+
+	groups := Groups([]Listing{a, b, c, d}, DefaultConfig())
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Fatalf("expected one group of 3, got %v", groups)
+	}
+	if groups[0][0] != 0 || groups[0][1] != 1 || groups[0][2] != 2 {
+		t.Fatalf("unexpected members: %v", groups[0])
+	}
+}
+
+func TestParseAddressExpandsAbbreviations(t *testing.T) {
+	addr := ParseAddress("Av. Pres. Getúlio Vargas, 0350, Bloco B")
+	if addr.Number != "350" {
+		t.Fatalf("number=%q want=350", addr.Number)
+	}
+	want := []string{"avenida", "presidente", "getulio", "vargas"}
+	if len(addr.Tokens) != len(want) {
+		t.Fatalf("tokens=%v want=%v", addr.Tokens, want)
+	}
+	for i := range want {
+		if addr.Tokens[i] != want[i] {
+			t.Fatalf("tokens=%v want=%v", addr.Tokens, want)
+		}
+	}
+}
+
+func TestPriceSpread(t *testing.T) {
+	lowest, highest, spread, pct := PriceSpread([]int64{52000000, 0, 49500000, 55000000})
+	if lowest != 49500000 || highest != 55000000 || spread != 5500000 {
+		t.Fatalf("unexpected spread: %d %d %d", lowest, highest, spread)
+	}
+	if pct != 0.1111 {
+		t.Fatalf("spread_pct=%.4f want=0.1111", pct)
+	}
+}
@@ -0,0 +1,133 @@
+package listingcluster
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // register decoders for listing photos
+	_ "image/jpeg" // register decoders for listing photos
+	_ "image/png"  // register decoders for listing photos
+	"io"
+	"math/bits"
+	"strconv"
+)
+
+const (
+	hashWidth  = 9 // 9 columns give 8 horizontal gradients per row
+	hashHeight = 8
+	// maxSamplesPerCell bounds the work on large photos
+	maxSamplesPerCell = 16
+)
+
+// DifferenceHash computes the 64-bit dHash of an image: the image is reduced to a
+// 9x8 grayscale grid and each bit records whether a cell is brighter than its
+// right neighbour. Resized or recompressed copies of a photo differ by a few bits.
+func DifferenceHash(img image.Image) uint64 {
+	bounds := img.Bounds()
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+		return 0
+	}
+
+	var grid [hashHeight][hashWidth]float64
+	for gy := 0; gy < hashHeight; gy++ {
+		y0, y1 := cellRange(bounds.Min.Y, bounds.Dy(), gy, hashHeight)
+		for gx := 0; gx < hashWidth; gx++ {
+			x0, x1 := cellRange(bounds.Min.X, bounds.Dx(), gx, hashWidth)
+			grid[gy][gx] = averageLuminance(img, x0, x1, y0, y1)
+		}
+	}
+
+	var hash uint64
+	for gy := 0; gy < hashHeight; gy++ {
+		for gx := 0; gx < hashWidth-1; gx++ {
+			hash <<= 1
+			if grid[gy][gx] > grid[gy][gx+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HashImage decodes a JPEG, PNG or GIF and returns its dHash
+func HashImage(r io.Reader) (uint64, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return 0, fmt.Errorf("decode image: %w", err)
+	}
+	return DifferenceHash(img), nil
+}
+
+// HammingDistance counts the differing bits between two hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// FormatHash encodes a hash as 16 hex characters (the stored format)
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParseHashes decodes stored hashes, skipping invalid entries
+func ParseHashes(values []string) []uint64 {
+	hashes := make([]uint64, 0, len(values))
+	for _, value := range values {
+		hash, err := strconv.ParseUint(value, 16, 64)
+		if err != nil {
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// minImageDistance returns the smallest distance between any pair of usable hashes.
+// Flat images (placeholders, "sem foto" banners) hash to all zeros and are ignored.
+func minImageDistance(a, b []uint64) (int, bool) {
+	best := -1
+	for _, ha := range a {
+		if !usableHash(ha) {
+			continue
+		}
+		for _, hb := range b {
+			if !usableHash(hb) {
+				continue
+			}
+			if d := HammingDistance(ha, hb); best < 0 || d < best {
+				best = d
+			}
+		}
+	}
+	return best, best >= 0
+}
+
+func usableHash(hash uint64) bool {
+	return hash != 0 && hash != ^uint64(0)
+}
+
+func cellRange(origin, size, index, cells int) (int, int) {
+	start := origin + index*size/cells
+	end := origin + (index+1)*size/cells
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+func averageLuminance(img image.Image, x0, x1, y0, y1 int) float64 {
+	stepX := max(1, (x1-x0)/maxSamplesPerCell)
+	stepY := max(1, (y1-y0)/maxSamplesPerCell)
+
+	sum := 0.0
+	count := 0
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
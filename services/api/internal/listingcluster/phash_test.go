@@ -0,0 +1,77 @@
+package listingcluster
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// scene draws a deterministic "photo": a bright window on a darker wall
+func scene(width, height int, mirrored bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			fx := float64(x) / float64(width)
+			if mirrored {
+				fx = 1 - fx
+			}
+			fy := float64(y) / float64(height)
+			v := 60 + 120*fx*fy
+			if fx > 0.55 && fx < 0.85 && fy > 0.2 && fy < 0.6 {
+				v = 235
+			}
+			img.Set(x, y, color.RGBA{R: uint8(v), G: uint8(v * 0.9), B: uint8(v * 0.8), A: 255})
+		}
+	}
+	return img
+}
+
+func TestDifferenceHashSurvivesResizeAndRecompression(t *testing.T) {
+	original := DifferenceHash(scene(640, 480, false))
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scene(320, 240, false), &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	resized, err := HashImage(&buf)
+	if err != nil {
+		t.Fatalf("HashImage returned error: %v", err)
+	}
+
+	if d := HammingDistance(original, resized); d > DefaultConfig().MaxImageDistance {
+		t.Fatalf("distance=%d between resized copies", d)
+	}
+
+	other := DifferenceHash(scene(640, 480, true))
+	if d := HammingDistance(original, other); d <= DefaultConfig().MaxImageDistance {
+		t.Fatalf("distance=%d between different photos", d)
+	}
+}
+
+func TestDifferenceHashOfFlatImageIsUnusable(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	hash, err := HashImage(&buf)
+	if err != nil {
+		t.Fatalf("HashImage returned error: %v", err)
+	}
+	if usableHash(hash) {
+		t.Fatalf("flat image hash %016x should be ignored", hash)
+	}
+}
+
+func TestFormatAndParseHashes(t *testing.T) {
+	hashes := ParseHashes([]string{FormatHash(0xf0f0f0f0f0f0f0f0), "not-hex", FormatHash(42)})
+	if len(hashes) != 2 || hashes[0] != 0xf0f0f0f0f0f0f0f0 || hashes[1] != 42 {
+		t.Fatalf("unexpected hashes: %v", hashes)
+	}
+	if FormatHash(42) != "000000000000002a" {
+		t.Fatalf("unexpected format: %s", FormatHash(42))
+	}
+}
@@ -0,0 +1,334 @@
+package marketingest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Input formats; FormatAuto picks one from the file extension and the city profile
+const (
+	FormatAuto        = ""
+	FormatXLSXMonthly = "xlsx_monthly" // one sheet per month named MMM-YYYY
+	FormatXLSX        = "xlsx"         // single sheet, month from the transaction date
+	FormatCSV         = "csv"          // month from the transaction date
+)
+
+// Text encodings detected for CSV input
+const (
+	EncodingUTF8   = "utf-8"
+	EncodingLatin1 = "latin-1"
+)
+
+var supportedFormats = []string{FormatXLSXMonthly, FormatXLSX, FormatCSV}
+
+func SupportedFormats() []string {
+	return append([]string(nil), supportedFormats...)
+}
+
+// NormalizeFormat validates a format name; empty and "auto" mean FormatAuto
+func NormalizeFormat(value string) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(value))
+	if format == "" || format == "auto" {
+		return FormatAuto, nil
+	}
+	for _, supported := range supportedFormats {
+		if format == supported {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q (supported: auto, %s)", value, strings.Join(supportedFormats, ", "))
+}
+
+// ColumnMapping maps logical columns (ColumnDate, ColumnValue, ...) to the headers that may
+// hold them. A mapping passed at run time replaces the profile headers of the columns it names.
+type ColumnMapping map[string][]string
+
+var knownColumns = map[string]struct{}{
+	ColumnRegistration:   {},
+	ColumnNeighborhood:   {},
+	ColumnAddress:        {},
+	ColumnAddressNumber:  {},
+	ColumnCEP:            {},
+	ColumnValue:          {},
+	ColumnDate:           {},
+	ColumnArea:           {},
+	ColumnUse:            {},
+	ColumnUseDescription: {},
+}
+
+// ParseColumnMapping reads the CLI form "date=DATA DA VENDA|DATA,value=VALOR DECLARADO"
+func ParseColumnMapping(spec string) (ColumnMapping, error) {
+	mapping := ColumnMapping{}
+	if strings.TrimSpace(spec) == "" {
+		return mapping, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		column, headers, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid column mapping %q (want column=HEADER|HEADER)", strings.TrimSpace(entry))
+		}
+		column = strings.ToLower(strings.TrimSpace(column))
+		mapping[column] = append(mapping[column], strings.Split(headers, "|")...)
+	}
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (m ColumnMapping) Validate() error {
+	for column, headers := range m {
+		if _, ok := knownColumns[column]; !ok {
+			names := make([]string, 0, len(knownColumns))
+			for name := range knownColumns {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown column %q (known: %s)", column, strings.Join(names, ", "))
+		}
+		valid := 0
+		for _, header := range headers {
+			if normalizeHeader(header) != "" {
+				valid++
+			}
+		}
+		if valid == 0 {
+			return fmt.Errorf("column %q has no header", column)
+		}
+	}
+	return nil
+}
+
+// mergeColumns overlays the run mapping on the profile columns, normalizing headers the
+// same way the header row is normalized
+func mergeColumns(base map[string][]string, override ColumnMapping) map[string][]string {
+	out := make(map[string][]string, len(base)+len(override))
+	for column, headers := range base {
+		out[column] = headers
+	}
+	for column, headers := range override {
+		normalized := make([]string, 0, len(headers))
+		for _, header := range headers {
+			if value := normalizeHeader(header); value != "" {
+				normalized = append(normalized, value)
+			}
+		}
+		if len(normalized) > 0 {
+			out[column] = normalized
+		}
+	}
+	return out
+}
+
+// rowReader iterates the rows of one table; the first row is the header
+type rowReader interface {
+	Next() bool
+	Columns() ([]string, error)
+	Close() error
+}
+
+// sourceTable is a sheet or file to parse. Tables without Month are bucketed by
+// transaction date.
+type sourceTable struct {
+	Name  string
+	Month time.Time
+}
+
+// tabularAdapter turns one input file into tables of rows for parseTable
+type tabularAdapter interface {
+	Format() string
+	Encoding() string
+	Tables(asOfMonth time.Time) ([]sourceTable, error)
+	Rows(table sourceTable) (rowReader, error)
+	Close() error
+}
+
+func openAdapter(path, format string, profile *CityProfile) (tabularAdapter, error) {
+	format, err := NormalizeFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatAuto {
+		format = detectFormat(path, profile)
+	}
+	switch format {
+	case FormatCSV:
+		return openCSVAdapter(path)
+	default:
+		return openXLSXAdapter(path, format, profile)
+	}
+}
+
+func detectFormat(path string, profile *CityProfile) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".txt":
+		return FormatCSV
+	}
+	if profile.MonthlySheets {
+		return FormatXLSXMonthly
+	}
+	return FormatXLSX
+}
+
+type xlsxAdapter struct {
+	file   *excelize.File
+	format string
+	sheet  string
+}
+
+func openXLSXAdapter(path, format string, profile *CityProfile) (*xlsxAdapter, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxAdapter{file: f, format: format, sheet: profile.Sheet}, nil
+}
+
+func (a *xlsxAdapter) Format() string   { return a.format }
+func (a *xlsxAdapter) Encoding() string { return "" }
+func (a *xlsxAdapter) Close() error     { return a.file.Close() }
+
+func (a *xlsxAdapter) Tables(asOfMonth time.Time) ([]sourceTable, error) {
+	sheetNames := a.file.GetSheetList()
+	if a.format == FormatXLSXMonthly {
+		return monthlySheets(sheetNames, asOfMonth)
+	}
+	if len(sheetNames) == 0 {
+		return nil, errors.New("workbook has no sheets")
+	}
+	if a.sheet == "" {
+		return []sourceTable{{Name: sheetNames[0]}}, nil
+	}
+	for _, sheet := range sheetNames {
+		if strings.EqualFold(strings.TrimSpace(sheet), a.sheet) {
+			return []sourceTable{{Name: sheet}}, nil
+		}
+	}
+	return nil, fmt.Errorf("sheet %q not found", a.sheet)
+}
+
+func (a *xlsxAdapter) Rows(table sourceTable) (rowReader, error) {
+	rows, err := a.file.Rows(table.Name)
+	if err != nil {
+		return nil, err
+	}
+	return xlsxRows{rows: rows}, nil
+}
+
+type xlsxRows struct {
+	rows *excelize.Rows
+}
+
+func (r xlsxRows) Next() bool                 { return r.rows.Next() }
+func (r xlsxRows) Columns() ([]string, error) { return r.rows.Columns() }
+func (r xlsxRows) Close() error               { return r.rows.Close() }
+
+type csvAdapter struct {
+	name      string
+	data      []byte
+	encoding  string
+	delimiter rune
+}
+
+func openCSVAdapter(path string) (*csvAdapter, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, encoding, err := decodeCSVText(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &csvAdapter{
+		name:      filepath.Base(path),
+		data:      data,
+		encoding:  encoding,
+		delimiter: detectCSVDelimiter(data),
+	}, nil
+}
+
+func (a *csvAdapter) Format() string   { return FormatCSV }
+func (a *csvAdapter) Encoding() string { return a.encoding }
+func (a *csvAdapter) Close() error     { return nil }
+
+func (a *csvAdapter) Tables(time.Time) ([]sourceTable, error) {
+	return []sourceTable{{Name: a.name}}, nil
+}
+
+func (a *csvAdapter) Rows(sourceTable) (rowReader, error) {
+	reader := csv.NewReader(bytes.NewReader(a.data))
+	reader.Comma = a.delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return &csvRows{reader: reader}, nil
+}
+
+type csvRows struct {
+	reader *csv.Reader
+	record []string
+	err    error
+}
+
+func (r *csvRows) Next() bool {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return false
+	}
+	r.record, r.err = record, err
+	return true
+}
+
+func (r *csvRows) Columns() ([]string, error) { return r.record, r.err }
+func (r *csvRows) Close() error               { return nil }
+
+// decodeCSVText returns UTF-8 text. Files that are not valid UTF-8 are read as Latin-1
+// (Windows-1252), the usual export encoding of municipal and cartório systems.
+func decodeCSVText(raw []byte) ([]byte, string, error) {
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(raw) {
+		return raw, EncodingUTF8, nil
+	}
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(raw)
+	if err != nil {
+		return nil, "", fmt.Errorf("decode latin-1: %w", err)
+	}
+	return decoded, EncodingLatin1, nil
+}
+
+// detectCSVDelimiter picks the most frequent separator of the header line, ignoring
+// quoted text. Brazilian exports usually use ";" since "," is the decimal separator.
+func detectCSVDelimiter(data []byte) rune {
+	line := data
+	if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+		line = data[:idx]
+	}
+	counts := map[rune]int{}
+	quoted := false
+	for _, r := range string(line) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ';' || r == ',' || r == '\t' || r == '|'):
+			counts[r]++
+		}
+	}
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{';', ',', '\t', '|'} {
+		if counts[candidate] > bestCount {
+			best, bestCount = candidate, counts[candidate]
+		}
+	}
+	return best
+}
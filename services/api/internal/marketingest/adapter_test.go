@@ -0,0 +1,101 @@
+package marketingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestParseColumnMapping(t *testing.T) {
+	mapping, err := ParseColumnMapping("date=Data da Venda|DATA, value=VALOR DECLARADO")
+	if err != nil {
+		t.Fatalf("ParseColumnMapping: %v", err)
+	}
+	if len(mapping[ColumnDate]) != 2 || mapping[ColumnValue][0] != "VALOR DECLARADO" {
+		t.Fatalf("mapping=%v", mapping)
+	}
+
+	invalid := []string{"date", "price=VALOR", "date=  "}
+	for _, spec := range invalid {
+		if _, err := ParseColumnMapping(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestNormalizeFormat(t *testing.T) {
+	for input, want := range map[string]string{"": FormatAuto, "auto": FormatAuto, " CSV ": FormatCSV, "xlsx_monthly": FormatXLSXMonthly} {
+		got, err := NormalizeFormat(input)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeFormat(%q)=%q err=%v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := NormalizeFormat("xls"); err == nil {
+		t.Fatalf("expected error for xls")
+	}
+}
+
+func TestDetectCSVDelimiter(t *testing.T) {
+	tests := map[string]rune{
+		"BAIRRO;VALOR;DATA\n":            ';',
+		"BAIRRO,VALOR,DATA\n":            ',',
+		"\"BAIRRO;X\",VALOR,DATA\n":      ',',
+		"BAIRRO\tVALOR\tDATA\nA\tB\tC\n": '\t',
+	}
+	for input, want := range tests {
+		if got := detectCSVDelimiter([]byte(input)); got != want {
+			t.Fatalf("detectCSVDelimiter(%q)=%q want %q", input, got, want)
+		}
+	}
+}
+
+func TestParseFileReadsLatin1CSVWithColumnMapping(t *testing.T) {
+	content := "Data da Venda;Bairro;Endereço;Valor Declarado;Área Construída;Tipo do Imóvel\r\n" +
+		"15/01/2025;Água Verde;Rua Bento Viana, 100;450.000,00;75,5;Apartamento\r\n" +
+		"20/02/2025;Portão;Rua João Bettega, 20;380000;90;Casa\r\n" +
+		"20/02/2025;;Rua sem bairro, 1;380000;90;Casa\r\n"
+	encoded, err := charmap.ISO8859_1.NewEncoder().String(content)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "cartorio.csv")
+	if err := os.WriteFile(path, []byte(encoded), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	columns, err := ParseColumnMapping("date=Data da Venda,value=Valor Declarado")
+	if err != nil {
+		t.Fatalf("ParseColumnMapping: %v", err)
+	}
+	res, err := ParseFile(context.Background(), ParseConfig{FilePath: path, City: "curitiba", Columns: columns}, time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if res.Format != FormatCSV || res.Encoding != EncodingLatin1 {
+		t.Fatalf("format=%q encoding=%q", res.Format, res.Encoding)
+	}
+	if res.InputRows != 3 || res.ValidRows != 2 {
+		t.Fatalf("input=%d valid=%d", res.InputRows, res.ValidRows)
+	}
+	first := res.Records[0]
+	if first.RegionNormalized != "AGUA VERDE" || first.Address != "Rua Bento Viana, 100" || first.TransactionValue != 450000 || first.AreaM2 != 75.5 {
+		t.Fatalf("first record=%+v", first)
+	}
+	if res.Records[1].RegionNormalized != "PORTAO" || res.Records[1].PropertyClass != "casa" {
+		t.Fatalf("second record=%+v", res.Records[1])
+	}
+}
+
+func TestParseFileRejectsFormatMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "itbi.csv")
+	if err := os.WriteFile(path, []byte("BAIRRO;VALOR\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := ParseFile(context.Background(), ParseConfig{FilePath: path, City: "curitiba", Format: FormatXLSX}, time.Now()); err == nil {
+		t.Fatalf("expected error reading a CSV as xlsx")
+	}
+}
@@ -38,7 +38,7 @@ func LoadApprovedAliases(ctx context.Context, db *sql.DB, city string) (map[stri
 		}
 
 		aliasKey := NormalizeNeighborhoodKey(aliasNormalized)
-		canonical := CanonicalNeighborhoodFromGolden(canonicalName)
+		canonical := CanonicalNeighborhoodFromGolden(city, canonicalName)
 		if canonical == "" {
 			canonical = NormalizeNeighborhoodKey(canonicalName)
 		}
@@ -83,7 +83,7 @@ func PersistAliasCandidates(ctx context.Context, db *sql.DB, city string, candid
 			continue
 		}
 
-		canonical := CanonicalNeighborhoodFromGolden(candidate.SuggestedCanonical)
+		canonical := CanonicalNeighborhoodFromGolden(city, candidate.SuggestedCanonical)
 		if canonical == "" {
 			canonical = NormalizeNeighborhoodKey(candidate.SuggestedCanonical)
 		}
@@ -0,0 +1,333 @@
+package marketingest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultBoundaryNameFields are the attribute names that usually hold the bairro/district
+// name in municipal GeoJSON and Shapefile exports (GeoSampa, IPPUC, IBGE)
+var defaultBoundaryNameFields = []string{"NOME", "NM_BAIRRO", "NOME_BAIRR", "NOME_BAIRRO", "BAIRRO", "DS_NOME", "NOME_DIST", "NM_DIST", "NAME"}
+
+type geoPoint struct {
+	Lon float64
+	Lat float64
+}
+
+type geoBBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+func (b geoBBox) contains(p geoPoint) bool {
+	return p.Lon >= b.MinLon && p.Lon <= b.MaxLon && p.Lat >= b.MinLat && p.Lat <= b.MaxLat
+}
+
+// boundary is one bairro; rings from every polygon part are tested together with the
+// even-odd rule, so holes and multipolygons need no special handling
+type boundary struct {
+	Name  string
+	Rings [][]geoPoint
+	BBox  geoBBox
+}
+
+func newBoundary(name string, rings [][]geoPoint) boundary {
+	b := boundary{Name: name, Rings: rings, BBox: geoBBox{MinLon: math.Inf(1), MinLat: math.Inf(1), MaxLon: math.Inf(-1), MaxLat: math.Inf(-1)}}
+	for _, ring := range rings {
+		for _, p := range ring {
+			b.BBox.MinLon = math.Min(b.BBox.MinLon, p.Lon)
+			b.BBox.MinLat = math.Min(b.BBox.MinLat, p.Lat)
+			b.BBox.MaxLon = math.Max(b.BBox.MaxLon, p.Lon)
+			b.BBox.MaxLat = math.Max(b.BBox.MaxLat, p.Lat)
+		}
+	}
+	return b
+}
+
+func (b boundary) contains(p geoPoint) bool {
+	if !b.BBox.contains(p) {
+		return false
+	}
+	inside := false
+	for _, ring := range b.Rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, c := ring[i], ring[j]
+			if (a.Lat > p.Lat) != (c.Lat > p.Lat) && p.Lon < (c.Lon-a.Lon)*(p.Lat-a.Lat)/(c.Lat-a.Lat)+a.Lon {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// loadBoundaries reads bairro polygons from a GeoJSON (.geojson/.json) or Shapefile (.shp
+// with its .dbf). Coordinates must be geographic (WGS84/SIRGAS 2000 longitude, latitude).
+func loadBoundaries(path, nameField string) ([]boundary, error) {
+	fields := defaultBoundaryNameFields
+	if strings.TrimSpace(nameField) != "" {
+		fields = []string{nameField}
+	}
+
+	var boundaries []boundary
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		boundaries, err = loadGeoJSONBoundaries(path, fields)
+	case ".shp":
+		boundaries, err = loadShapefileBoundaries(path, fields)
+	default:
+		return nil, fmt.Errorf("unsupported boundary file %q (want .geojson or .shp)", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(boundaries) == 0 {
+		return nil, fmt.Errorf("no named polygons in %s", filepath.Base(path))
+	}
+	for _, b := range boundaries {
+		if b.BBox.MinLon < -180 || b.BBox.MaxLon > 180 || b.BBox.MinLat < -90 || b.BBox.MaxLat > 90 {
+			return nil, fmt.Errorf("boundary %q is not in geographic coordinates; reproject %s to EPSG:4674 or EPSG:4326", b.Name, filepath.Base(path))
+		}
+	}
+	return boundaries, nil
+}
+
+type geoJSONFeatureCollection struct {
+	Features []struct {
+		Properties map[string]any `json:"properties"`
+		Geometry   *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+func loadGeoJSONBoundaries(path string, nameFields []string) ([]boundary, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var collection geoJSONFeatureCollection
+	if err := json.Unmarshal(raw, &collection); err != nil {
+		return nil, fmt.Errorf("decode geojson: %w", err)
+	}
+
+	out := make([]boundary, 0, len(collection.Features))
+	for _, feature := range collection.Features {
+		if feature.Geometry == nil {
+			continue
+		}
+		name := boundaryName(func(field string) string {
+			for key, value := range feature.Properties {
+				if strings.EqualFold(key, field) {
+					if text, ok := value.(string); ok {
+						return text
+					}
+				}
+			}
+			return ""
+		}, nameFields)
+		if name == "" {
+			continue
+		}
+
+		var rings [][]geoPoint
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var coords [][][]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil {
+				return nil, fmt.Errorf("decode polygon %q: %w", name, err)
+			}
+			rings = appendGeoJSONRings(rings, coords)
+		case "MultiPolygon":
+			var coords [][][][]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil {
+				return nil, fmt.Errorf("decode multipolygon %q: %w", name, err)
+			}
+			for _, polygon := range coords {
+				rings = appendGeoJSONRings(rings, polygon)
+			}
+		default:
+			continue
+		}
+		if len(rings) > 0 {
+			out = append(out, newBoundary(name, rings))
+		}
+	}
+	return out, nil
+}
+
+func appendGeoJSONRings(rings [][]geoPoint, polygon [][][]float64) [][]geoPoint {
+	for _, coords := range polygon {
+		ring := make([]geoPoint, 0, len(coords))
+		for _, position := range coords {
+			if len(position) < 2 {
+				continue
+			}
+			ring = append(ring, geoPoint{Lon: position[0], Lat: position[1]})
+		}
+		if len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+	return rings
+}
+
+const (
+	shapePolygon  = 5
+	shapePolygonZ = 15
+	shapePolygonM = 25
+)
+
+// loadShapefileBoundaries reads polygon records from the .shp and their names from the
+// .dbf next to it. Only the subset of the format used by polygon layers is supported.
+func loadShapefileBoundaries(path string, nameFields []string) ([]boundary, error) {
+	shp, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dbfPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".dbf"
+	if _, statErr := os.Stat(dbfPath); statErr != nil {
+		dbfPath = strings.TrimSuffix(path, filepath.Ext(path)) + ".DBF"
+	}
+	dbf, err := os.ReadFile(dbfPath)
+	if err != nil {
+		return nil, fmt.Errorf("read shapefile attributes: %w", err)
+	}
+	records, err := readDBF(dbf)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shp) < 100 || binary.BigEndian.Uint32(shp[0:4]) != 9994 {
+		return nil, errors.New("invalid shapefile header")
+	}
+
+	out := make([]boundary, 0, len(records))
+	offset := 100
+	for index := 0; offset+8 <= len(shp); index++ {
+		contentLength := int(binary.BigEndian.Uint32(shp[offset+4:offset+8])) * 2
+		content := shp[offset+8:]
+		if contentLength > len(content) {
+			return nil, fmt.Errorf("truncated shapefile record %d", index+1)
+		}
+		content = content[:contentLength]
+		offset += 8 + contentLength
+
+		if index >= len(records) || len(content) < 44 {
+			continue
+		}
+		shapeType := binary.LittleEndian.Uint32(content[0:4])
+		if shapeType != shapePolygon && shapeType != shapePolygonZ && shapeType != shapePolygonM {
+			continue
+		}
+		record := records[index]
+		name := boundaryName(func(field string) string {
+			for key, value := range record {
+				if strings.EqualFold(key, field) {
+					return value
+				}
+			}
+			return ""
+		}, nameFields)
+		if name == "" {
+			continue
+		}
+
+		numParts := int(binary.LittleEndian.Uint32(content[36:40]))
+		numPoints := int(binary.LittleEndian.Uint32(content[40:44]))
+		pointsStart := 44 + 4*numParts
+		if numParts <= 0 || numPoints <= 0 || pointsStart+16*numPoints > len(content) {
+			return nil, fmt.Errorf("invalid polygon in shapefile record %d", index+1)
+		}
+		parts := make([]int, numParts+1)
+		for i := 0; i < numParts; i++ {
+			parts[i] = int(binary.LittleEndian.Uint32(content[44+4*i : 48+4*i]))
+		}
+		parts[numParts] = numPoints
+
+		rings := make([][]geoPoint, 0, numParts)
+		for i := 0; i < numParts; i++ {
+			if parts[i] < 0 || parts[i] >= parts[i+1] || parts[i+1] > numPoints {
+				return nil, fmt.Errorf("invalid polygon part in shapefile record %d", index+1)
+			}
+			ring := make([]geoPoint, 0, parts[i+1]-parts[i])
+			for p := parts[i]; p < parts[i+1]; p++ {
+				base := pointsStart + 16*p
+				ring = append(ring, geoPoint{
+					Lon: math.Float64frombits(binary.LittleEndian.Uint64(content[base : base+8])),
+					Lat: math.Float64frombits(binary.LittleEndian.Uint64(content[base+8 : base+16])),
+				})
+			}
+			if len(ring) >= 3 {
+				rings = append(rings, ring)
+			}
+		}
+		if len(rings) > 0 {
+			out = append(out, newBoundary(name, rings))
+		}
+	}
+	return out, nil
+}
+
+// readDBF returns the dBase records as field name -> trimmed text. Municipal exports are
+// often Latin-1, so text that is not valid UTF-8 is decoded like CSV input.
+func readDBF(data []byte) ([]map[string]string, error) {
+	if len(data) < 32 {
+		return nil, errors.New("invalid dbf header")
+	}
+	numRecords := int(binary.LittleEndian.Uint32(data[4:8]))
+	headerSize := int(binary.LittleEndian.Uint16(data[8:10]))
+	recordSize := int(binary.LittleEndian.Uint16(data[10:12]))
+	if headerSize > len(data) || recordSize <= 0 {
+		return nil, errors.New("invalid dbf header")
+	}
+
+	type dbfField struct {
+		name   string
+		length int
+	}
+	var fields []dbfField
+	for pos := 32; pos+32 <= headerSize && data[pos] != 0x0D; pos += 32 {
+		name := string(bytes.TrimRight(data[pos:pos+11], "\x00 "))
+		fields = append(fields, dbfField{name: name, length: int(data[pos+16])})
+	}
+
+	records := make([]map[string]string, 0, numRecords)
+	for i := 0; i < numRecords; i++ {
+		start := headerSize + i*recordSize
+		if start+recordSize > len(data) {
+			break
+		}
+		record := make(map[string]string, len(fields))
+		pos := start + 1 // deletion flag
+		for _, field := range fields {
+			if pos+field.length > start+recordSize {
+				break
+			}
+			text, _, err := decodeCSVText(data[pos : pos+field.length])
+			if err != nil {
+				return nil, err
+			}
+			record[field.name] = strings.TrimSpace(string(text))
+			pos += field.length
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func boundaryName(lookup func(field string) string, fields []string) string {
+	for _, field := range fields {
+		if value := strings.TrimSpace(lookup(field)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,250 @@
+package marketingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testBoundariesGeoJSON = `{
+  "type": "FeatureCollection",
+  "features": [
+    {"type": "Feature", "properties": {"NOME": "Batel"}, "geometry": {"type": "Polygon", "coordinates": [
+      [[-49.30, -25.45], [-49.28, -25.45], [-49.28, -25.43], [-49.30, -25.43], [-49.30, -25.45]],
+      [[-49.295, -25.445], [-49.290, -25.445], [-49.290, -25.440], [-49.295, -25.440], [-49.295, -25.445]]
+    ]}},
+    {"type": "Feature", "properties": {"NOME": "CENTRO"}, "geometry": {"type": "MultiPolygon", "coordinates": [
+      [[[-49.28, -25.45], [-49.26, -25.45], [-49.26, -25.43], [-49.28, -25.43], [-49.28, -25.45]]],
+      [[[-49.20, -25.40], [-49.19, -25.40], [-49.19, -25.39], [-49.20, -25.39], [-49.20, -25.40]]]
+    ]}}
+  ]
+}`
+
+func TestLoadBoundariesGeoJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bairros.geojson")
+	if err := os.WriteFile(path, []byte(testBoundariesGeoJSON), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	boundaries, err := loadBoundaries(path, "")
+	if err != nil {
+		t.Fatalf("loadBoundaries: %v", err)
+	}
+	if len(boundaries) != 2 {
+		t.Fatalf("boundaries=%d", len(boundaries))
+	}
+
+	tests := []struct {
+		point geoPoint
+		want  string
+	}{
+		{point: geoPoint{Lon: -49.285, Lat: -25.435}, want: "Batel"},
+		{point: geoPoint{Lon: -49.2925, Lat: -25.4425}, want: ""}, // hole
+		{point: geoPoint{Lon: -49.27, Lat: -25.44}, want: "CENTRO"},
+		{point: geoPoint{Lon: -49.195, Lat: -25.395}, want: "CENTRO"}, // second polygon
+		{point: geoPoint{Lon: -49.10, Lat: -25.44}, want: ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		for _, b := range boundaries {
+			if b.contains(tt.point) {
+				got = b.Name
+			}
+		}
+		if got != tt.want {
+			t.Fatalf("point %+v: got %q, want %q", tt.point, got, tt.want)
+		}
+	}
+}
+
+func TestLoadBoundariesShapefile(t *testing.T) {
+	dir := t.TempDir()
+	ring := []geoPoint{{-49.29, -25.43}, {-49.27, -25.43}, {-49.27, -25.41}, {-49.29, -25.41}, {-49.29, -25.43}}
+	writeTestShapefile(t, filepath.Join(dir, "bairros"), "NM_BAIRRO", "MERC\xcaS", ring)
+
+	boundaries, err := loadBoundaries(filepath.Join(dir, "bairros.shp"), "")
+	if err != nil {
+		t.Fatalf("loadBoundaries: %v", err)
+	}
+	if len(boundaries) != 1 || boundaries[0].Name != "MERCÊS" {
+		t.Fatalf("boundaries=%+v", boundaries)
+	}
+	if !boundaries[0].contains(geoPoint{Lon: -49.28, Lat: -25.42}) || boundaries[0].contains(geoPoint{Lon: -49.26, Lat: -25.42}) {
+		t.Fatal("point in polygon mismatch")
+	}
+
+	if _, err := loadBoundaries(filepath.Join(dir, "bairros.shp"), "NOME"); err == nil {
+		t.Fatal("expected error for missing name field")
+	}
+}
+
+func TestLoadBoundariesRejectsProjectedCoordinates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bairros.geojson")
+	content := `{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"NOME":"Batel"},"geometry":{"type":"Polygon","coordinates":[[[670000,7183000],[671000,7183000],[671000,7184000],[670000,7183000]]]}}]}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := loadBoundaries(path, ""); err == nil {
+		t.Fatal("expected error for projected coordinates")
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address    string
+		wantStreet string
+		wantNumber int
+		wantCEP    string
+	}{
+		{address: "R. Bento Viana, 100 - ap 12, 80240-110", wantStreet: "RUA BENTO VIANA", wantNumber: 100, wantCEP: "80240110"},
+		{address: "Av Visconde de Guarapuava 2500", wantStreet: "AVENIDA VISCONDE DE GUARAPUAVA", wantNumber: 2500},
+		{address: "Al. Dr. Carlos de Carvalho, nº 555", wantStreet: "ALAMEDA DOUTOR CARLOS DE CARVALHO", wantNumber: 555},
+		{address: "Rua XV de Novembro", wantStreet: "RUA XV DE NOVEMBRO"},
+	}
+	for _, tt := range tests {
+		street, number, cep := parseAddress(tt.address)
+		if street != tt.wantStreet || number != tt.wantNumber || cep != tt.wantCEP {
+			t.Fatalf("%q: got (%q, %d, %q)", tt.address, street, number, cep)
+		}
+	}
+	if got := normalizeCEP("1310100"); got != "01310100" {
+		t.Fatalf("normalizeCEP=%q", got)
+	}
+}
+
+func TestParseFileResolvesBairroByAddress(t *testing.T) {
+	dir := t.TempDir()
+	boundariesPath := filepath.Join(dir, "bairros.geojson")
+	if err := os.WriteFile(boundariesPath, []byte(testBoundariesGeoJSON), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	streetsPath := filepath.Join(dir, "logradouros.csv")
+	streets := "CEP;LOGRADOURO;NUMERO_INICIO;NUMERO_FIM;LATITUDE;LONGITUDE\n" +
+		"80060-000;Rua XV de Novembro;1;999;-25,435;-49,270\n" +
+		";Avenida Visconde de Guarapuava;1;2999;-25,436;-49,285\n" +
+		";Avenida Visconde de Guarapuava;3000;4999;-25,437;-49,270\n" +
+		";Rua Comendador Araújo;;;-25,434;-49,285\n" +
+		";Rua Comendador Araújo;;;-25,438;-49,286\n"
+	if err := os.WriteFile(streetsPath, []byte(streets), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	content := "Data;Bairro;Endereço;Valor;Área;Tipo\n" +
+		"10/01/2025;Batel;Rua XV de Novembro, 10 - 80060-000;500000;80;Apartamento\n" + // CEP wins over the text
+		"10/01/2025;;Av. Visconde de Guarapuava, 3500;510000;80;Apartamento\n" +
+		"10/01/2025;;R. Comendador Araujo, 80;520000;80;Apartamento\n" + // every segment in Batel
+		"10/01/2025;Batel;Rua Desconhecida, 1;530000;80;Apartamento\n" +
+		"10/01/2025;;Rua Desconhecida, 2;540000;80;Apartamento\n"
+	path := filepath.Join(dir, "itbi.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	columns, _ := ParseColumnMapping("date=Data,value=Valor,area=Área,use=Tipo")
+	res, err := ParseFile(context.Background(), ParseConfig{
+		FilePath: path,
+		City:     "curitiba",
+		Columns:  columns,
+		Geo:      GeoConfig{BoundariesPath: boundariesPath, StreetIndexPath: streetsPath},
+	}, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	want := []struct{ region, method string }{
+		{"CENTRO", MatchMethodGeoCEP},
+		{"CENTRO", MatchMethodGeoStreet},
+		{"BATEL", MatchMethodGeoStreet},
+		{"BATEL", MatchMethodDictionary},
+	}
+	if len(res.Records) != len(want) {
+		t.Fatalf("records=%+v", res.Records)
+	}
+	for i, rec := range res.Records {
+		if rec.RegionNormalized != want[i].region || rec.RegionMethod != want[i].method {
+			t.Fatalf("record %d: got (%q, %q), want %+v", i, rec.RegionNormalized, rec.RegionMethod, want[i])
+		}
+	}
+	if res.Records[1].RegionRaw != "CENTRO" {
+		t.Fatalf("region raw=%q", res.Records[1].RegionRaw)
+	}
+	if res.RegionMethods[MatchMethodGeoStreet] != 2 || res.RegionMethods[MatchMethodGeoCEP] != 1 || res.RegionMethods[MatchMethodDictionary] != 1 {
+		t.Fatalf("region methods=%v", res.RegionMethods)
+	}
+	if res.Quality.DroppedRows[DropMissingNeighborhood] != 1 {
+		t.Fatalf("dropped=%v", res.Quality.DroppedRows)
+	}
+
+	if _, err := ParseFile(context.Background(), ParseConfig{FilePath: path, City: "curitiba", Columns: columns, Geo: GeoConfig{BoundariesPath: boundariesPath}}, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Fatal("expected error without street index")
+	}
+
+	// Without an address column the geo files are ignored and the bairro text is matched
+	textOnly := filepath.Join(dir, "itbi_sem_endereco.csv")
+	if err := os.WriteFile(textOnly, []byte("Data;Bairro;Valor;Área;Tipo\n10/01/2025;Batel;500000;80;Apartamento\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	res, err = ParseFile(context.Background(), ParseConfig{
+		FilePath: textOnly,
+		City:     "curitiba",
+		Columns:  columns,
+		Geo:      GeoConfig{BoundariesPath: boundariesPath, StreetIndexPath: streetsPath},
+	}, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ParseFile without address: %v", err)
+	}
+	if len(res.Records) != 1 || res.Records[0].RegionMethod != MatchMethodDictionary {
+		t.Fatalf("records=%+v", res.Records)
+	}
+}
+
+// writeTestShapefile writes a single-polygon .shp/.dbf pair with one text attribute
+func writeTestShapefile(t *testing.T, base, field, name string, ring []geoPoint) {
+	t.Helper()
+
+	var content bytes.Buffer
+	le := func(v any) { _ = binary.Write(&content, binary.LittleEndian, v) }
+	le(int32(shapePolygon))
+	le([4]float64{-180, -90, 180, 90})
+	le(int32(1))
+	le(int32(len(ring)))
+	le(int32(0))
+	for _, p := range ring {
+		le(p.Lon)
+		le(p.Lat)
+	}
+
+	shp := make([]byte, 100, 100+8+content.Len())
+	binary.BigEndian.PutUint32(shp[0:4], 9994)
+	binary.BigEndian.PutUint32(shp[24:28], uint32((100+8+content.Len())/2))
+	binary.LittleEndian.PutUint32(shp[28:32], 1000)
+	binary.LittleEndian.PutUint32(shp[32:36], shapePolygon)
+	record := make([]byte, 8)
+	binary.BigEndian.PutUint32(record[0:4], 1)
+	binary.BigEndian.PutUint32(record[4:8], uint32(content.Len()/2))
+	shp = append(append(shp, record...), content.Bytes()...)
+	if err := os.WriteFile(base+".shp", shp, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	const fieldLength = 40
+	headerSize := 32 + 32 + 1
+	dbf := make([]byte, headerSize)
+	dbf[0] = 0x03
+	binary.LittleEndian.PutUint32(dbf[4:8], 1)
+	binary.LittleEndian.PutUint16(dbf[8:10], uint16(headerSize))
+	binary.LittleEndian.PutUint16(dbf[10:12], uint16(1+fieldLength))
+	copy(dbf[32:43], field)
+	dbf[43] = 'C'
+	dbf[48] = fieldLength
+	dbf[64] = 0x0D
+	value := bytes.Repeat([]byte(" "), fieldLength)
+	copy(value, name)
+	dbf = append(append(append(dbf, ' '), value...), 0x1A)
+	if err := os.WriteFile(base+".dbf", dbf, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
@@ -0,0 +1,330 @@
+package marketingest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reCEP           = regexp.MustCompile(`\b(\d{5})-?(\d{3})\b`)
+	reAddressNumber = regexp.MustCompile(`^\s*(?:N[º°O]?\.?\s*)?(\d{1,6})\b`)
+
+	streetAbbreviations = map[string]string{
+		"R":     "RUA",
+		"AV":    "AVENIDA",
+		"AVN":   "AVENIDA",
+		"AL":    "ALAMEDA",
+		"TV":    "TRAVESSA",
+		"TRAV":  "TRAVESSA",
+		"PC":    "PRACA",
+		"PCA":   "PRACA",
+		"PRC":   "PRACA",
+		"EST":   "ESTRADA",
+		"ROD":   "RODOVIA",
+		"LG":    "LARGO",
+		"VD":    "VIADUTO",
+		"DR":    "DOUTOR",
+		"PROF":  "PROFESSOR",
+		"ENG":   "ENGENHEIRO",
+		"CEL":   "CORONEL",
+		"GAL":   "GENERAL",
+		"GEN":   "GENERAL",
+		"MAL":   "MARECHAL",
+		"PRES":  "PRESIDENTE",
+		"BRIG":  "BRIGADEIRO",
+		"CAP":   "CAPITAO",
+		"VISC":  "VISCONDE",
+		"DEP":   "DEPUTADO",
+		"SEN":   "SENADOR",
+		"STA":   "SANTA",
+		"STO":   "SANTO",
+		"NSA":   "NOSSA",
+		"SRA":   "SENHORA",
+		"CONS":  "CONSELHEIRO",
+		"DES":   "DESEMBARGADOR",
+		"MONS":  "MONSENHOR",
+		"COMEN": "COMENDADOR",
+	}
+
+	streetIndexColumns = map[string][]string{
+		"cep":         {"CEP"},
+		"street":      {"LOGRADOURO", "ENDERECO", "NOME DO LOGRADOURO", "RUA"},
+		"number_from": {"NUMERO INICIO", "NUMERO INICIAL", "NUM INICIO", "INICIO"},
+		"number_to":   {"NUMERO FIM", "NUMERO FINAL", "NUM FIM", "FIM"},
+		"lat":         {"LATITUDE", "LAT"},
+		"lon":         {"LONGITUDE", "LON", "LNG"},
+	}
+)
+
+// GeoConfig points to the local files used to place transactions in bairro polygons
+type GeoConfig struct {
+	BoundariesPath  string // .geojson or .shp (with .dbf) in longitude/latitude
+	NameField       string // polygon attribute with the bairro name; common names are tried when empty
+	StreetIndexPath string // CSV with CEP, LOGRADOURO, NUMERO_INICIO, NUMERO_FIM, LATITUDE, LONGITUDE
+}
+
+func (c GeoConfig) Enabled() bool {
+	return strings.TrimSpace(c.BoundariesPath) != "" || strings.TrimSpace(c.StreetIndexPath) != ""
+}
+
+// GeoConfigFromDir looks for <dir>/<city>/bairros.geojson (or bairros.shp) and
+// <dir>/<city>/logradouros.csv; it is empty unless both exist
+func GeoConfigFromDir(dir, city string) GeoConfig {
+	if strings.TrimSpace(dir) == "" || strings.TrimSpace(city) == "" {
+		return GeoConfig{}
+	}
+	base := filepath.Join(dir, city)
+	streets := filepath.Join(base, "logradouros.csv")
+	if !fileExists(streets) {
+		return GeoConfig{}
+	}
+	for _, name := range []string{"bairros.geojson", "bairros.json", "bairros.shp"} {
+		if path := filepath.Join(base, name); fileExists(path) {
+			return GeoConfig{BoundariesPath: path, StreetIndexPath: streets}
+		}
+	}
+	return GeoConfig{}
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+type streetSegment struct {
+	From, To int // 0 when the index has no number range
+	Point    geoPoint
+}
+
+type streetIndex struct {
+	byCEP    map[string]geoPoint
+	byStreet map[string][]streetSegment
+}
+
+func loadStreetIndex(path string) (*streetIndex, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := decodeCSVText(raw)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectCSVDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read street index header: %w", err)
+	}
+	index, err := columnIndex(streetIndexColumns, []string{"lat", "lon"}, header)
+	if err != nil {
+		return nil, fmt.Errorf("street index: %w", err)
+	}
+	_, hasCEP := index["cep"]
+	_, hasStreet := index["street"]
+	if !hasCEP && !hasStreet {
+		return nil, errors.New("street index: needs a CEP or LOGRADOURO column")
+	}
+
+	out := &streetIndex{byCEP: map[string]geoPoint{}, byStreet: map[string][]streetSegment{}}
+	for {
+		cols, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("read street index: %w", readErr)
+		}
+		lat, latOK := parseDecimal(getCol(cols, index, "lat"))
+		lon, lonOK := parseDecimal(getCol(cols, index, "lon"))
+		if !latOK || !lonOK || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			continue
+		}
+		point := geoPoint{Lon: lon, Lat: lat}
+
+		if cep := normalizeCEP(getCol(cols, index, "cep")); cep != "" {
+			if _, exists := out.byCEP[cep]; !exists {
+				out.byCEP[cep] = point
+			}
+		}
+		if street := normalizeStreet(getCol(cols, index, "street")); street != "" {
+			from, _ := strconv.Atoi(strings.TrimSpace(getCol(cols, index, "number_from")))
+			to, _ := strconv.Atoi(strings.TrimSpace(getCol(cols, index, "number_to")))
+			out.byStreet[street] = append(out.byStreet[street], streetSegment{From: from, To: to, Point: point})
+		}
+	}
+	if len(out.byCEP) == 0 && len(out.byStreet) == 0 {
+		return nil, errors.New("street index has no geocoded rows")
+	}
+	return out, nil
+}
+
+// locate geocodes an address: a known CEP wins, then the street segment covering the number.
+// Without either it returns every point of the street and the caller decides.
+func (idx *streetIndex) locate(address string) ([]geoPoint, string) {
+	street, number, cep := parseAddress(address)
+	if cep != "" {
+		if point, ok := idx.byCEP[cep]; ok {
+			return []geoPoint{point}, MatchMethodGeoCEP
+		}
+	}
+	segments := idx.byStreet[street]
+	if len(segments) == 0 {
+		return nil, ""
+	}
+	if number > 0 {
+		for _, segment := range segments {
+			if segment.From > 0 && segment.To >= segment.From && number >= segment.From && number <= segment.To {
+				return []geoPoint{segment.Point}, MatchMethodGeoStreet
+			}
+		}
+	}
+	points := make([]geoPoint, 0, len(segments))
+	for _, segment := range segments {
+		points = append(points, segment.Point)
+	}
+	return points, MatchMethodGeoStreet
+}
+
+// parseAddress splits "R. Bento Viana, 100 - ap 12, 80240-110" into the normalized street,
+// the door number and the CEP
+func parseAddress(address string) (street string, number int, cep string) {
+	raw := address
+	if match := reCEP.FindStringSubmatch(raw); match != nil {
+		cep = match[1] + match[2]
+		raw = strings.Replace(raw, match[0], " ", 1)
+	}
+
+	streetPart, rest, hasComma := strings.Cut(raw, ",")
+	if hasComma {
+		if match := reAddressNumber.FindStringSubmatch(strings.ToUpper(rest)); match != nil {
+			number, _ = strconv.Atoi(match[1])
+		}
+		return normalizeStreet(streetPart), number, cep
+	}
+
+	tokens := strings.Fields(normalizeText(streetPart))
+	if n := len(tokens); n > 1 {
+		if value, err := strconv.Atoi(tokens[n-1]); err == nil {
+			number = value
+			tokens = tokens[:n-1]
+		}
+	}
+	return normalizeStreet(strings.Join(tokens, " ")), number, cep
+}
+
+// geocodingAddress rebuilds "street, number cep" for sources that keep the door number and
+// CEP in their own columns
+func geocodingAddress(address, number, cep string) string {
+	address = strings.TrimSpace(address)
+	if number = strings.TrimSpace(number); number != "" {
+		address += ", " + number
+	}
+	if cep = normalizeCEP(cep); cep != "" {
+		address += " " + cep[:5] + "-" + cep[5:]
+	}
+	return address
+}
+
+func normalizeStreet(value string) string {
+	tokens := strings.Fields(normalizeText(value))
+	for i, token := range tokens {
+		if expanded, ok := streetAbbreviations[token]; ok {
+			tokens[i] = expanded
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+func normalizeCEP(value string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+	if len(digits) == 7 {
+		digits = "0" + digits // spreadsheets drop the leading zero of São Paulo CEPs
+	}
+	if len(digits) != 8 {
+		return ""
+	}
+	return digits
+}
+
+// geoResolver assigns addresses to the bairro polygon that contains them
+type geoResolver struct {
+	boundaries []boundary
+	canonical  []string
+	streets    *streetIndex
+	cache      map[string]regionMatch
+}
+
+func newGeoResolver(cfg GeoConfig, profile *CityProfile) (*geoResolver, error) {
+	if strings.TrimSpace(cfg.BoundariesPath) == "" || strings.TrimSpace(cfg.StreetIndexPath) == "" {
+		return nil, errors.New("geo resolution needs both a boundaries file and a street index")
+	}
+	boundaries, err := loadBoundaries(cfg.BoundariesPath, cfg.NameField)
+	if err != nil {
+		return nil, fmt.Errorf("load boundaries: %w", err)
+	}
+	streets, err := loadStreetIndex(cfg.StreetIndexPath)
+	if err != nil {
+		return nil, fmt.Errorf("load street index: %w", err)
+	}
+
+	// Polygon names go through the golden dictionary so geo and text matches share keys
+	canonical := make([]string, len(boundaries))
+	for i, b := range boundaries {
+		canonical[i] = profile.CanonicalNeighborhood(b.Name)
+		if canonical[i] == "" {
+			canonical[i] = dictionaryKey(b.Name)
+		}
+	}
+	return &geoResolver{boundaries: boundaries, canonical: canonical, streets: streets, cache: make(map[string]regionMatch, 4096)}, nil
+}
+
+// Resolve returns the bairro of address and the geo method used, or "" when the address is
+// not in the index, falls outside every polygon or its street crosses several bairros
+func (g *geoResolver) Resolve(address string) (string, string) {
+	key := normalizeText(address)
+	if key == "" {
+		return "", ""
+	}
+	if cached, ok := g.cache[key]; ok {
+		return cached.canonical, cached.method
+	}
+
+	var match regionMatch
+	points, method := g.streets.locate(address)
+	for i, point := range points {
+		canonical := g.bairroAt(point)
+		if canonical == "" || (i > 0 && canonical != match.canonical) {
+			match = regionMatch{}
+			break
+		}
+		match = regionMatch{canonical: canonical, method: method}
+	}
+	g.cache[key] = match
+	return match.canonical, match.method
+}
+
+func (g *geoResolver) bairroAt(point geoPoint) string {
+	for i, b := range g.boundaries {
+		if b.contains(point) {
+			return g.canonical[i]
+		}
+	}
+	return ""
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 func IngestParsed(ctx context.Context, db *sql.DB, runID string, cfg ParseConfig, asOfMonth time.Time, parsed ParseResult) (int, error) {
@@ -25,11 +27,18 @@ func IngestParsed(ctx context.Context, db *sql.DB, runID string, cfg ParseConfig
 		return 0, err
 	}
 
+	// Replaced transactions are archived so RollbackRun can restore them
 	for _, month := range parsed.TouchedMonths {
 		if _, err := tx.ExecContext(ctx, `
-			DELETE FROM market_transactions
-			WHERE city = $1 AND source = $2 AND month = $3
-		`, cfg.City, cfg.Source, month); err != nil {
+			WITH removed AS (
+				DELETE FROM market_transactions
+				WHERE city = $1 AND source = $2 AND month = $3
+				RETURNING *
+			)
+			INSERT INTO market_transactions_archive (`+archiveTransactionColumns+`, archived_by_run_id, reason)
+			SELECT `+archiveTransactionColumns+`, $4, 'replaced'
+			FROM removed
+		`, cfg.City, cfg.Source, month, runID); err != nil {
 			return 0, err
 		}
 	}
@@ -54,6 +63,7 @@ func IngestParsed(ctx context.Context, db *sql.DB, runID string, cfg ParseConfig
 				region_id,
 				region_name_raw,
 				region_name_normalized,
+				address,
 				property_class,
 				sql_registration,
 				transaction_date,
@@ -62,9 +72,11 @@ func IngestParsed(ctx context.Context, db *sql.DB, runID string, cfg ParseConfig
 				price_m2,
 				iptu_use,
 				iptu_use_description,
-				row_hash
+				row_hash,
+				quality_flags,
+				region_method
 			) VALUES (
-				$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16
+				$1,$2,$3,$4,$5,$6,$7,NULLIF($8, ''),$9,$10,$11,$12,$13,$14,$15,$16,$17,COALESCE($18::text[], '{}'),NULLIF($19, '')
 			)
 			ON CONFLICT (city, source, row_hash) DO NOTHING
 		`,
@@ -75,6 +87,7 @@ func IngestParsed(ctx context.Context, db *sql.DB, runID string, cfg ParseConfig
 			regionID,
 			rec.RegionRaw,
 			rec.RegionNormalized,
+			rec.Address,
 			rec.PropertyClass,
 			rec.SQLRegistration,
 			rec.TransactionDate,
@@ -84,12 +97,36 @@ func IngestParsed(ctx context.Context, db *sql.DB, runID string, cfg ParseConfig
 			rec.IPTUUse,
 			rec.IPTUUseDescription,
 			rec.RowHash,
+			pq.Array(rec.QualityFlags),
+			rec.RegionMethod,
 		)
 		if err != nil {
 			return 0, err
 		}
 	}
 
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO market_ingestion_run_aggregate_changes (
+			run_id,
+			city,
+			source,
+			region_id,
+			region_type,
+			as_of_month,
+			period_months,
+			property_class,
+			previous_median_m2,
+			previous_p25_m2,
+			previous_p75_m2,
+			previous_tx_count
+		)
+		SELECT $4, city, source, region_id, region_type, as_of_month, period_months, property_class, median_m2, p25_m2, p75_m2, tx_count
+		FROM market_price_m2_aggregates
+		WHERE city = $1 AND source = $2 AND as_of_month = $3
+	`, cfg.City, cfg.Source, asOfMonth, runID); err != nil {
+		return 0, err
+	}
+
 	if _, err := tx.ExecContext(ctx, `
 		DELETE FROM market_price_m2_aggregates
 		WHERE city = $1 AND source = $2 AND as_of_month = $3
@@ -151,6 +188,10 @@ func IngestParsed(ctx context.Context, db *sql.DB, runID string, cfg ParseConfig
 		totalGroups += int(affected)
 	}
 
+	if err := recordAggregateChanges(ctx, tx, runID, cfg, asOfMonth); err != nil {
+		return 0, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
@@ -158,6 +199,48 @@ func IngestParsed(ctx context.Context, db *sql.DB, runID string, cfg ParseConfig
 	return totalGroups, nil
 }
 
+// recordAggregateChanges completes the previous values snapshotted before the recompute with
+// the new ones and drops the aggregates the run left unchanged
+func recordAggregateChanges(ctx context.Context, tx *sql.Tx, runID string, cfg ParseConfig, asOfMonth time.Time) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO market_ingestion_run_aggregate_changes (
+			run_id,
+			city,
+			source,
+			region_id,
+			region_type,
+			as_of_month,
+			period_months,
+			property_class,
+			new_median_m2,
+			new_p25_m2,
+			new_p75_m2,
+			new_tx_count
+		)
+		SELECT $4, city, source, region_id, region_type, as_of_month, period_months, property_class, median_m2, p25_m2, p75_m2, tx_count
+		FROM market_price_m2_aggregates
+		WHERE city = $1 AND source = $2 AND as_of_month = $3
+		ON CONFLICT (run_id, region_id, period_months, property_class)
+		DO UPDATE SET
+			new_median_m2 = EXCLUDED.new_median_m2,
+			new_p25_m2 = EXCLUDED.new_p25_m2,
+			new_p75_m2 = EXCLUDED.new_p75_m2,
+			new_tx_count = EXCLUDED.new_tx_count
+	`, cfg.City, cfg.Source, asOfMonth, runID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM market_ingestion_run_aggregate_changes
+		WHERE run_id = $1
+		  AND previous_median_m2 = new_median_m2
+		  AND previous_p25_m2 = new_p25_m2
+		  AND previous_p75_m2 = new_p75_m2
+		  AND previous_tx_count = new_tx_count
+	`, runID)
+	return err
+}
+
 func upsertRegion(ctx context.Context, tx *sql.Tx, cfg ParseConfig, regionRaw, regionNormalized string) (string, error) {
 	var regionID string
 	err := tx.QueryRowContext(ctx, `
@@ -19,6 +19,11 @@ type AliasCandidate struct {
 	Occurrences         int
 }
 
+type regionMatch struct {
+	canonical string
+	method    string
+}
+
 type neighborhoodResolver struct {
 	dictionary    map[string]string
 	candidates    []string
@@ -28,14 +33,17 @@ type neighborhoodResolver struct {
 	llmResolved   int
 	llmErrors     int
 	llmDisabled   bool
-	cache         map[string]string
+	cache         map[string]regionMatch
 	missCache     map[string]struct{}
 	pending       map[string]*AliasCandidate
 	minConfidence float64
 }
 
-func newNeighborhoodResolver(normalizer NeighborhoodNormalizer, maxLLMCalls int, approvedAliases map[string]string) *neighborhoodResolver {
-	dictionary := buildGoldenNeighborhoodDictionary()
+func newNeighborhoodResolver(golden map[string]string, normalizer NeighborhoodNormalizer, maxLLMCalls int, approvedAliases map[string]string) *neighborhoodResolver {
+	dictionary := make(map[string]string, len(golden)+len(approvedAliases))
+	for key, canonical := range golden {
+		dictionary[key] = canonical
+	}
 	for alias, canonical := range approvedAliases {
 		aliasKey := dictionaryKey(alias)
 		canonicalKey := dictionaryKey(canonical)
@@ -65,41 +73,43 @@ func newNeighborhoodResolver(normalizer NeighborhoodNormalizer, maxLLMCalls int,
 		candidates:    uniqueStrings(candidates),
 		normalizer:    normalizer,
 		maxLLMCalls:   maxLLMCalls,
-		cache:         make(map[string]string, 4096),
+		cache:         make(map[string]regionMatch, 4096),
 		missCache:     make(map[string]struct{}, 4096),
 		pending:       make(map[string]*AliasCandidate, 4096),
 		minConfidence: 0.72,
 	}
 }
 
-func (r *neighborhoodResolver) Resolve(ctx context.Context, raw string, heuristic string) string {
+// Resolve returns the canonical bairro for a raw label and the method that matched it
+// (dictionary, normalized or llm), or "" when the label stays unresolved
+func (r *neighborhoodResolver) Resolve(ctx context.Context, raw string, heuristic string) (string, string) {
 	rawKey := dictionaryKey(raw)
 	if rawKey == "" {
-		return ""
+		return "", ""
 	}
 
 	if cached, ok := r.cache[rawKey]; ok {
-		return cached
+		return cached.canonical, cached.method
 	}
 	if _, miss := r.missCache[rawKey]; miss {
-		return ""
+		return "", ""
 	}
 
 	if canonical := r.match(heuristic); canonical != "" {
-		r.cache[rawKey] = canonical
-		return canonical
+		r.cache[rawKey] = regionMatch{canonical: canonical, method: MatchMethodDictionary}
+		return canonical, MatchMethodDictionary
 	}
 
 	normalizedHeuristic := dictionaryKey(heuristic)
 	if normalizedHeuristic != "" && !looksSuspiciousNeighborhood(normalizedHeuristic) {
-		r.cache[rawKey] = normalizedHeuristic
-		return normalizedHeuristic
+		r.cache[rawKey] = regionMatch{canonical: normalizedHeuristic, method: MatchMethodNormalized}
+		return normalizedHeuristic, MatchMethodNormalized
 	}
 
 	if r.normalizer == nil || r.maxLLMCalls == 0 || r.llmCalls >= r.maxLLMCalls || r.llmDisabled {
 		r.recordPending(raw, rawKey, "", 0)
 		r.missCache[rawKey] = struct{}{}
-		return ""
+		return "", ""
 	}
 
 	r.llmCalls++
@@ -121,25 +131,25 @@ func (r *neighborhoodResolver) Resolve(ctx context.Context, raw string, heuristi
 		}
 		r.recordPending(raw, rawKey, "", 0)
 		r.missCache[rawKey] = struct{}{}
-		return ""
+		return "", ""
 	}
 	r.llmErrors = 0
 
 	if confidence < r.minConfidence {
 		r.recordPending(raw, rawKey, canonical, confidence)
 		r.missCache[rawKey] = struct{}{}
-		return ""
+		return "", ""
 	}
 
 	if matched := r.match(canonical); matched != "" {
 		r.llmResolved++
-		r.cache[rawKey] = matched
-		return matched
+		r.cache[rawKey] = regionMatch{canonical: matched, method: MatchMethodLLM}
+		return matched, MatchMethodLLM
 	}
 
 	r.recordPending(raw, rawKey, canonical, confidence)
 	r.missCache[rawKey] = struct{}{}
-	return ""
+	return "", ""
 }
 
 func (r *neighborhoodResolver) Stats() (calls int, resolved int) {
@@ -252,65 +262,72 @@ func dictionaryKey(value string) string {
 	return strings.Join(parts, " ")
 }
 
+// Neighborhood match methods reported by MatchNeighborhood and stored per ingested row
+const (
+	MatchMethodDictionary = "dictionary"
+	MatchMethodNormalized = "normalized"
+	MatchMethodLLM        = "llm"
+	MatchMethodGeoCEP     = "geo_cep"    // CEP geocoded and placed in a bairro polygon
+	MatchMethodGeoStreet  = "geo_street" // street and number geocoded and placed in a bairro polygon
+)
+
+// NeighborhoodMatch is the canonical market region for a free-text bairro
+type NeighborhoodMatch struct {
+	Canonical  string
+	Confidence float64 // 0-1
+	Method     string  // dictionary, normalized or "" when unresolved
+}
+
+// MatchNeighborhood resolves a free-text bairro (e.g. typed on a prospect) to a market region
+// of city without LLM calls. Golden dictionary and approved alias hits have confidence 1; a clean
+// normalized label outside the dictionary is kept with lower confidence, since it only matches
+// market data when the ITBI source spells the bairro the same way.
+func MatchNeighborhood(city, raw string, approvedAliases map[string]string) NeighborhoodMatch {
+	r := newNeighborhoodResolver(goldenDictionaryFor(city), nil, 0, approvedAliases)
+	heuristic := normalizeNeighborhoodLabel(raw)
+
+	if canonical := r.match(heuristic); canonical != "" {
+		return NeighborhoodMatch{Canonical: canonical, Confidence: 1, Method: MatchMethodDictionary}
+	}
+	if canonical := r.match(raw); canonical != "" {
+		return NeighborhoodMatch{Canonical: canonical, Confidence: 1, Method: MatchMethodDictionary}
+	}
+
+	normalized := dictionaryKey(heuristic)
+	if normalized == "" || looksSuspiciousNeighborhood(normalized) || isUnknownNeighborhoodLabel(normalized) {
+		return NeighborhoodMatch{}
+	}
+	return NeighborhoodMatch{Canonical: normalized, Confidence: 0.6, Method: MatchMethodNormalized}
+}
+
 func NormalizeNeighborhoodKey(value string) string {
 	return dictionaryKey(value)
 }
 
-func CanonicalNeighborhoodFromGolden(value string) string {
-	key := dictionaryKey(value)
-	if key == "" {
+// CanonicalNeighborhoodFromGolden returns the golden bairro of city matching value, or ""
+func CanonicalNeighborhoodFromGolden(city, value string) string {
+	profile, ok := LookupCityProfile(city)
+	if !ok {
 		return ""
 	}
-	dict := buildGoldenNeighborhoodDictionary()
-	if canonical, ok := dict[key]; ok {
-		return canonical
-	}
-	return ""
+	return profile.CanonicalNeighborhood(value)
 }
 
-func GoldenNeighborhoodCanonicalList() []string {
-	dict := buildGoldenNeighborhoodDictionary()
-	out := make([]string, 0, len(dict))
-	seen := make(map[string]struct{}, len(dict))
-	for _, canonical := range dict {
-		if _, ok := seen[canonical]; ok {
-			continue
-		}
-		seen[canonical] = struct{}{}
-		out = append(out, canonical)
+func GoldenNeighborhoodCanonicalList(city string) []string {
+	profile, ok := LookupCityProfile(city)
+	if !ok {
+		return []string{}
 	}
-	sort.Strings(out)
-	return out
+	return profile.CanonicalNeighborhoods()
 }
 
-func buildGoldenNeighborhoodDictionary() map[string]string {
-	canonical := []string{
-		"ACLIMACAO", "AGUA BRANCA", "AGUA FRIA", "AGUA RASA", "ALTO DA BOA VISTA", "ALTO DA LAPA", "ALTO DA MOOCA", "ALTO DE PINHEIROS",
-		"ANHANGUERA", "ARICANDUVA", "ARTUR ALVIM", "BARRA FUNDA", "BELA VISTA", "BELEM", "BELENZINHO", "BOM RETIRO", "BRAS", "BRASILANDIA",
-		"BROOKLIN", "BROOKLIN PAULISTA", "BUTANTA", "CACHOEIRINHA", "CAMBUCI", "CAMPO BELO", "CAMPO GRANDE", "CAMPO LIMPO", "CANGAIBA",
-		"CAPAO REDONDO", "CARRAO", "CASA VERDE", "CERQUEIRA CESAR", "CHACARA KLABIN", "CHACARA SANTO ANTONIO", "CIDADE ADEMAR", "CIDADE DUTRA",
-		"CIDADE LIDER", "CIDADE MONCOES", "CIDADE TIRADENTES", "CONSOLACAO", "CURSINO", "ERMELINO MATARAZZO", "FREGUESIA DO O", "GRAJAU",
-		"GUAIANASES", "HIGIENOPOLIS", "IGUATEMI", "INDIANOPOLIS", "INTERLAGOS", "IPIRANGA", "ITAIM", "ITAIM BIBI", "ITAIM PAULISTA", "ITAQUERA",
-		"JABAQUARA", "JACANA", "JAGUARA", "JAGUARE", "JARAGUA", "JARDIM AMERICA", "JARDIM ANGELA", "JARDIM AURELIA", "JARDIM BONFIGLIOLI",
-		"JARDIM DAS BANDEIRAS", "JARDIM EUROPA", "JARDIM HELENA", "JARDIM LEONOR", "JARDIM LUSITANIA", "JARDIM PAULISTA", "JARDIM PRUDENCIA",
-		"JARDIM SAO LUIS", "JOSE BONIFACIO", "JURUBATUBA", "LAJEADO", "LAPA", "LIBERDADE", "LIMAO", "MANDAQUI", "MARSILAC", "MIRANDOPOLIS",
-		"MOEMA", "MOOCA", "MORUMBI", "PACAEMBU", "PARAISO", "PARELHEIROS", "PARI", "PARQUE DO CARMO", "PEDREIRA", "PENHA", "PERDIZES", "PERUS",
-		"PINHEIROS", "PIRITUBA", "PONTE RASA", "RAPOSO TAVARES", "REPUBLICA", "RIO PEQUENO", "SACOMA", "SANTA CECILIA", "SANTA EFIGENIA",
-		"SANTANA", "SANTO AMARO", "SAO DOMINGOS", "SAO LUCAS", "SAO MATEUS", "SAO MIGUEL", "SAO RAFAEL", "SAPOPEMBA", "SAUDE", "SE", "SOCORRO",
-		"TATUAPE", "TREMEMBE", "TUCURUVI", "VILA ALPINA", "VILA ANDRADE", "VILA BUARQUE", "VILA CLEMENTINO", "VILA CURUCA", "VILA FORMOSA",
-		"VILA GUILHERME", "VILA GUMERCINDO", "VILA JACUI", "VILA LEOPOLDINA", "VILA MADALENA", "VILA MARIA", "VILA MARIANA", "VILA MASCOTE",
-		"VILA MATILDE", "VILA MEDEIROS", "VILA OLIMPIA", "VILA PRUDENTE", "VILA ROMANA", "VILA SONIA", "VILA VERMELHA",
-	}
-
-	dict := make(map[string]string, len(canonical)*2)
-	for _, item := range canonical {
-		key := dictionaryKey(item)
-		if key == "" {
-			continue
-		}
-		dict[key] = item
+// goldenDictionaryFor is empty for cities without a profile, so only normalized labels match
+func goldenDictionaryFor(city string) map[string]string {
+	profile, ok := LookupCityProfile(city)
+	if !ok {
+		return nil
 	}
-	return dict
+	return profile.goldenDictionary()
 }
 
 func uniqueStrings(values []string) []string {
@@ -0,0 +1,30 @@
+package marketingest
+
+import "testing"
+
+func TestMatchNeighborhood(t *testing.T) {
+	tests := []struct {
+		name           string
+		raw            string
+		aliases        map[string]string
+		wantCanonical  string
+		wantConfidence float64
+		wantMethod     string
+	}{
+		{name: "golden", raw: "Vila Mariana", wantCanonical: "VILA MARIANA", wantConfidence: 1, wantMethod: MatchMethodDictionary},
+		{name: "abbreviation", raw: "Jd. Paulista", wantCanonical: "JARDIM PAULISTA", wantConfidence: 1, wantMethod: MatchMethodDictionary},
+		{name: "approved alias", raw: "Vl Mariana Sul", aliases: map[string]string{"VILA MARIANA SUL": "VILA MARIANA"}, wantCanonical: "VILA MARIANA", wantConfidence: 1, wantMethod: MatchMethodDictionary},
+		{name: "normalized", raw: "Jardim das Flores", wantCanonical: "JARDIM DAS FLORES", wantConfidence: 0.6, wantMethod: MatchMethodNormalized},
+		{name: "unknown", raw: "não informado"},
+		{name: "empty", raw: "  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchNeighborhood(DefaultCity, tt.raw, tt.aliases)
+			if got.Canonical != tt.wantCanonical || got.Confidence != tt.wantConfidence || got.Method != tt.wantMethod {
+				t.Fatalf("got %+v, want canonical=%q confidence=%v method=%q", got, tt.wantCanonical, tt.wantConfidence, tt.wantMethod)
+			}
+		})
+	}
+}
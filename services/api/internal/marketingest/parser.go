@@ -129,9 +129,13 @@ type ParseConfig struct {
 	FilePath               string
 	City                   string
 	Source                 string
+	Format                 string        // FormatAuto, FormatXLSXMonthly, FormatXLSX or FormatCSV
+	Columns                ColumnMapping // overrides the city profile headers
 	NeighborhoodNormalizer NeighborhoodNormalizer
 	MaxLLMCalls            int
 	ApprovedAliases        map[string]string
+	MinTxCount             int       // low-sample threshold of the quality report, DefaultMinTxCount when 0
+	Geo                    GeoConfig // bairro polygons and street index; text matching is the fallback
 }
 
 type TxRecord struct {
@@ -140,6 +144,7 @@ type TxRecord struct {
 	Month              time.Time
 	RegionRaw          string
 	RegionNormalized   string
+	Address            string
 	PropertyClass      string
 	SQLRegistration    string
 	TransactionDate    sql.NullTime
@@ -149,9 +154,13 @@ type TxRecord struct {
 	IPTUUse            string
 	IPTUUseDescription string
 	RowHash            string
+	QualityFlags       []string
+	RegionMethod       string // MatchMethod* that resolved the bairro
 }
 
 type ParseResult struct {
+	Format          string
+	Encoding        string // CSV only
 	Records         []TxRecord
 	InputRows       int
 	ValidRows       int
@@ -159,11 +168,8 @@ type ParseResult struct {
 	LLMCalls        int
 	LLMResolved     int
 	AliasCandidates []AliasCandidate
-}
-
-type monthSheet struct {
-	Name  string
-	Month time.Time
+	Quality         QualityReport
+	RegionMethods   map[string]int // kept rows per bairro resolution method
 }
 
 func ParseAsOfMonth(value string) (time.Time, error) {
@@ -174,35 +180,81 @@ func ParseAsOfMonth(value string) (time.Time, error) {
 	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
 }
 
-func ParseWorkbook(ctx context.Context, cfg ParseConfig, asOfMonth time.Time) (ParseResult, error) {
-	f, err := excelize.OpenFile(cfg.FilePath)
+// ParseFile reads a transactions file through the adapter of its format and returns the
+// valid rows up to asOfMonth
+func ParseFile(ctx context.Context, cfg ParseConfig, asOfMonth time.Time) (ParseResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	profile, err := CityProfileFor(cfg.City)
 	if err != nil {
 		return ParseResult{}, err
 	}
-	defer f.Close()
+	cfg.City = profile.City
+	if strings.TrimSpace(cfg.Source) == "" {
+		cfg.Source = profile.DefaultSource
+	}
+	if err := cfg.Columns.Validate(); err != nil {
+		return ParseResult{}, err
+	}
+	columns := mergeColumns(profile.Columns, cfg.Columns)
 
-	if ctx == nil {
-		ctx = context.Background()
+	adapter, err := openAdapter(cfg.FilePath, cfg.Format, profile)
+	if err != nil {
+		return ParseResult{}, err
 	}
+	defer adapter.Close()
 
-	sheets, err := monthlySheets(f.GetSheetList(), asOfMonth)
+	tables, err := adapter.Tables(asOfMonth)
 	if err != nil {
 		return ParseResult{}, err
 	}
 
-	res := ParseResult{Records: make([]TxRecord, 0, 8192)}
+	res := ParseResult{Format: adapter.Format(), Encoding: adapter.Encoding(), Records: make([]TxRecord, 0, 8192), Quality: newQualityReport()}
 	touched := make(map[string]time.Time)
-	resolver := newNeighborhoodResolver(cfg.NeighborhoodNormalizer, cfg.MaxLLMCalls, cfg.ApprovedAliases)
+	resolver := newNeighborhoodResolver(profile.goldenDictionary(), cfg.NeighborhoodNormalizer, cfg.MaxLLMCalls, cfg.ApprovedAliases)
+	var geo *geoResolver
+	if cfg.Geo.Enabled() {
+		geo, err = newGeoResolver(cfg.Geo, profile)
+		if err != nil {
+			return ParseResult{}, err
+		}
+	}
 
-	for _, sheet := range sheets {
-		sheetRes, parseErr := parseMonthlySheet(ctx, f, cfg, sheet, resolver)
+	for _, table := range tables {
+		rows, rowsErr := adapter.Rows(table)
+		if rowsErr != nil {
+			return ParseResult{}, fmt.Errorf("sheet %s: %w", table.Name, rowsErr)
+		}
+		tableRes, parseErr := parseTable(ctx, rows, cfg, profile, columns, table, asOfMonth, resolver, geo)
+		rows.Close()
 		if parseErr != nil {
-			return ParseResult{}, fmt.Errorf("sheet %s: %w", sheet.Name, parseErr)
+			return ParseResult{}, fmt.Errorf("sheet %s: %w", table.Name, parseErr)
+		}
+		res.InputRows += tableRes.InputRows
+		res.Records = append(res.Records, tableRes.Records...)
+		res.Quality.merge(tableRes.Quality)
+		if !table.Month.IsZero() {
+			touched[table.Month.Format("2006-01-02")] = table.Month
+		}
+		for _, month := range tableRes.TouchedMonths {
+			touched[month.Format("2006-01-02")] = month
 		}
-		res.InputRows += sheetRes.InputRows
-		res.ValidRows += sheetRes.ValidRows
-		res.Records = append(res.Records, sheetRes.Records...)
-		touched[sheet.Month.Format("2006-01-02")] = sheet.Month
+	}
+	if len(touched) == 0 {
+		return ParseResult{}, errors.New("no transactions found up to as-of-month")
+	}
+
+	minTxCount := cfg.MinTxCount
+	if minTxCount <= 0 {
+		minTxCount = DefaultMinTxCount
+	}
+	res.Records = applyQualityStage(res.Records, &res.Quality, asOfMonth, minTxCount)
+	res.ValidRows = len(res.Records)
+	res.RegionMethods = make(map[string]int)
+	for _, rec := range res.Records {
+		res.RegionMethods[rec.RegionMethod]++
 	}
 
 	res.TouchedMonths = make([]time.Time, 0, len(touched))
@@ -218,8 +270,8 @@ func ParseWorkbook(ctx context.Context, cfg ParseConfig, asOfMonth time.Time) (P
 	return res, nil
 }
 
-func monthlySheets(sheetNames []string, asOfMonth time.Time) ([]monthSheet, error) {
-	out := make([]monthSheet, 0, len(sheetNames))
+func monthlySheets(sheetNames []string, asOfMonth time.Time) ([]sourceTable, error) {
+	out := make([]sourceTable, 0, len(sheetNames))
 	for _, sheet := range sheetNames {
 		if !reMonthSheet.MatchString(sheet) {
 			continue
@@ -231,7 +283,7 @@ func monthlySheets(sheetNames []string, asOfMonth time.Time) ([]monthSheet, erro
 		if month.After(asOfMonth) {
 			continue
 		}
-		out = append(out, monthSheet{Name: sheet, Month: month})
+		out = append(out, sourceTable{Name: sheet, Month: month})
 	}
 
 	if len(out) == 0 {
@@ -276,14 +328,8 @@ func parseSheetMonth(sheet string) (time.Time, error) {
 	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), nil
 }
 
-func parseMonthlySheet(ctx context.Context, f *excelize.File, cfg ParseConfig, sheet monthSheet, resolver *neighborhoodResolver) (ParseResult, error) {
-	rows, err := f.Rows(sheet.Name)
-	if err != nil {
-		return ParseResult{}, err
-	}
-	defer rows.Close()
-
-	result := ParseResult{Records: make([]TxRecord, 0, 2048)}
+func parseTable(ctx context.Context, rows rowReader, cfg ParseConfig, profile *CityProfile, columns map[string][]string, sheet sourceTable, asOfMonth time.Time, resolver *neighborhoodResolver, geo *geoResolver) (ParseResult, error) {
+	result := ParseResult{Records: make([]TxRecord, 0, 2048), Quality: newQualityReport()}
 
 	if !rows.Next() {
 		return result, nil
@@ -293,21 +339,15 @@ func parseMonthlySheet(ctx context.Context, f *excelize.File, cfg ParseConfig, s
 		return ParseResult{}, err
 	}
 
-	headerIndex := buildHeaderIndex(headerRow)
-	required := []string{
-		"N DO CADASTRO SQL",
-		"BAIRRO",
-		"VALOR DE TRANSACAO DECLARADO PELO CONTRIBUINTE",
-		"DATA DE TRANSACAO",
-		"AREA CONSTRUIDA M2",
-		"USO IPTU",
-		"DESCRICAO DO USO IPTU",
+	headerIndex, err := columnIndex(columns, profile.Required, headerRow)
+	if err != nil {
+		return ParseResult{}, err
 	}
-	for _, key := range required {
-		if _, ok := headerIndex[key]; !ok {
-			return ParseResult{}, fmt.Errorf("missing required column %q in sheet %s", key, sheet.Name)
-		}
+	// Files without an address column fall back to the bairro text
+	if _, ok := headerIndex[ColumnAddress]; !ok {
+		geo = nil
 	}
+	touched := make(map[string]time.Time)
 
 	for rows.Next() {
 		cols, columnsErr := rows.Columns()
@@ -316,48 +356,81 @@ func parseMonthlySheet(ctx context.Context, f *excelize.File, cfg ParseConfig, s
 		}
 		result.InputRows++
 
-		regionRaw := strings.TrimSpace(getCol(cols, headerIndex, "BAIRRO"))
-		if regionRaw == "" {
+		regionRaw := strings.TrimSpace(getCol(cols, headerIndex, ColumnNeighborhood))
+		address := strings.TrimSpace(getCol(cols, headerIndex, ColumnAddress))
+		if regionRaw == "" && (geo == nil || address == "") {
+			result.Quality.drop(DropMissingNeighborhood)
 			continue
 		}
 
-		transactionValue, ok := parseMoney(getCol(cols, headerIndex, "VALOR DE TRANSACAO DECLARADO PELO CONTRIBUINTE"))
+		transactionValue, ok := parseMoney(getCol(cols, headerIndex, ColumnValue))
 		if !ok || transactionValue <= 0 {
+			result.Quality.drop(DropInvalidValue)
 			continue
 		}
 
-		areaM2, ok := parseDecimal(getCol(cols, headerIndex, "AREA CONSTRUIDA M2"))
-		if !ok || areaM2 <= 10 {
+		areaM2, ok := parseDecimal(getCol(cols, headerIndex, ColumnArea))
+		if !ok || areaM2 <= minAreaM2 {
+			result.Quality.drop(DropInvalidArea)
 			continue
 		}
 
 		priceM2 := transactionValue / areaM2
-		if priceM2 <= 5 || priceM2 >= 200000 || math.IsNaN(priceM2) || math.IsInf(priceM2, 0) {
+		if priceM2 <= minPriceM2 || priceM2 >= maxPriceM2 || math.IsNaN(priceM2) || math.IsInf(priceM2, 0) {
+			result.Quality.drop(DropImpossiblePriceM2)
 			continue
 		}
 
-		transactionDate := parseDate(getCol(cols, headerIndex, "DATA DE TRANSACAO"))
-		regionNormalized := normalizeNeighborhoodLabel(regionRaw)
-		if resolver != nil {
-			regionNormalized = resolver.Resolve(ctx, regionRaw, regionNormalized)
+		transactionDate := parseDate(getCol(cols, headerIndex, ColumnDate))
+		month := sheet.Month
+		if month.IsZero() {
+			if !transactionDate.Valid {
+				result.Quality.drop(DropMissingDate)
+				continue
+			}
+			month = time.Date(transactionDate.Time.Year(), transactionDate.Time.Month(), 1, 0, 0, 0, 0, time.UTC)
+			if month.After(asOfMonth) {
+				result.Quality.drop(DropAfterAsOfMonth)
+				continue
+			}
+		}
+
+		var regionNormalized, regionMethod string
+		if geo != nil {
+			regionNormalized, regionMethod = geo.Resolve(geocodingAddress(address, getCol(cols, headerIndex, ColumnAddressNumber), getCol(cols, headerIndex, ColumnCEP)))
+		}
+		if regionNormalized == "" {
+			if regionRaw == "" {
+				result.Quality.drop(DropMissingNeighborhood)
+				continue
+			}
+			regionNormalized, regionMethod = normalizeNeighborhoodLabel(regionRaw), MatchMethodNormalized
+			if resolver != nil {
+				regionNormalized, regionMethod = resolver.Resolve(ctx, regionRaw, regionNormalized)
+			}
 		}
 		if regionNormalized == "" || isUnknownNeighborhoodLabel(regionNormalized) {
+			result.Quality.drop(DropUnknownNeighborhood)
 			continue
 		}
-		iptuUse := strings.TrimSpace(getCol(cols, headerIndex, "USO IPTU"))
-		iptuDescription := strings.TrimSpace(getCol(cols, headerIndex, "DESCRICAO DO USO IPTU"))
-		propertyClass := classifyPropertyClass(iptuUse, iptuDescription)
-		sqlRegistration := strings.TrimSpace(getCol(cols, headerIndex, "N DO CADASTRO SQL"))
+		if regionRaw == "" {
+			regionRaw = regionNormalized
+		}
+		iptuUse := strings.TrimSpace(getCol(cols, headerIndex, ColumnUse))
+		iptuDescription := strings.TrimSpace(getCol(cols, headerIndex, ColumnUseDescription))
+		propertyClass := profile.ClassifyPropertyClass(iptuUse, iptuDescription)
+		sqlRegistration := strings.TrimSpace(getCol(cols, headerIndex, ColumnRegistration))
 
-		hashInput := fmt.Sprintf("%s|%s|%s|%.2f|%.2f|%s|%s|%s", cfg.City, cfg.Source, sheet.Month.Format("2006-01-02"), transactionValue, areaM2, regionNormalized, sqlRegistration, iptuDescription)
+		hashInput := fmt.Sprintf("%s|%s|%s|%.2f|%.2f|%s|%s|%s", cfg.City, cfg.Source, month.Format("2006-01-02"), transactionValue, areaM2, regionNormalized, sqlRegistration, iptuDescription)
 		rowHash := hashValue(hashInput)
 
 		rec := TxRecord{
 			City:               cfg.City,
 			Source:             cfg.Source,
-			Month:              sheet.Month,
+			Month:              month,
 			RegionRaw:          regionRaw,
 			RegionNormalized:   regionNormalized,
+			Address:            address,
 			PropertyClass:      propertyClass,
 			SQLRegistration:    sqlRegistration,
 			TransactionDate:    transactionDate,
@@ -367,15 +440,45 @@ func parseMonthlySheet(ctx context.Context, f *excelize.File, cfg ParseConfig, s
 			IPTUUse:            iptuUse,
 			IPTUUseDescription: iptuDescription,
 			RowHash:            rowHash,
+			RegionMethod:       regionMethod,
 		}
 
 		result.Records = append(result.Records, rec)
 		result.ValidRows++
+		touched[month.Format("2006-01-02")] = month
 	}
 
+	for _, month := range touched {
+		result.TouchedMonths = append(result.TouchedMonths, month)
+	}
 	return result, nil
 }
 
+// columnIndex maps logical columns to positions in the header row
+func columnIndex(columns map[string][]string, required []string, headerRow []string) (map[string]int, error) {
+	headerIndex := buildHeaderIndex(headerRow)
+	out := make(map[string]int, len(columns))
+	for column, headers := range columns {
+		for _, header := range headers {
+			if idx, ok := headerIndex[header]; ok {
+				out[column] = idx
+				break
+			}
+		}
+	}
+	for _, column := range required {
+		if _, ok := out[column]; ok {
+			continue
+		}
+		name := column
+		if headers := columns[column]; len(headers) > 0 {
+			name = headers[0]
+		}
+		return nil, fmt.Errorf("missing required column %q", name)
+	}
+	return out, nil
+}
+
 func buildHeaderIndex(headers []string) map[string]int {
 	idx := make(map[string]int, len(headers))
 	for i, h := range headers {
@@ -446,6 +549,14 @@ func parseDate(raw string) sql.NullTime {
 		return sql.NullTime{}
 	}
 
+	// Text dates first: parseDecimal would read 15/01/2025 as the serial 15012025
+	layouts := []string{"02/01/2006", "2006-01-02", "02-01-2006"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return sql.NullTime{Time: t.UTC(), Valid: true}
+		}
+	}
+
 	if serial, ok := parseDecimal(value); ok {
 		if serial > 0 {
 			t, err := excelize.ExcelDateToTime(serial, false)
@@ -455,26 +566,11 @@ func parseDate(raw string) sql.NullTime {
 		}
 	}
 
-	layouts := []string{"02/01/2006", "2006-01-02", "02-01-2006"}
-	for _, layout := range layouts {
-		if t, err := time.Parse(layout, value); err == nil {
-			return sql.NullTime{Time: t.UTC(), Valid: true}
-		}
-	}
-
 	return sql.NullTime{}
 }
 
 func classifyPropertyClass(iptuUse, desc string) string {
-	value := strings.ToUpper(normalizeText(iptuUse + " " + desc))
-	switch {
-	case strings.Contains(value, "APART"), strings.Contains(value, "DUPLEX"), strings.Contains(value, "COBERTURA"):
-		return "apartamento"
-	case strings.Contains(value, "CASA"), strings.Contains(value, "RESIDENC"), strings.Contains(value, "SOBRADO"), strings.Contains(value, "TERREA"):
-		return "casa"
-	default:
-		return "outros"
-	}
+	return classifyByRules(defaultPropertyClassRules, iptuUse, desc)
 }
 
 func normalizeNeighborhoodLabel(value string) string {
@@ -0,0 +1,214 @@
+package marketingest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Logical columns; each city profile maps them to its own headers
+const (
+	ColumnRegistration   = "registration"
+	ColumnNeighborhood   = "neighborhood"
+	ColumnAddress        = "address"
+	ColumnAddressNumber  = "address_number"
+	ColumnCEP            = "cep"
+	ColumnValue          = "value"
+	ColumnDate           = "date"
+	ColumnArea           = "area"
+	ColumnUse            = "use"
+	ColumnUseDescription = "use_description"
+)
+
+// PropertyClassRule assigns Class when the use/description text contains any keyword.
+// Rules are checked in order; rows matching none are "outros".
+type PropertyClassRule struct {
+	Class    string
+	Keywords []string
+}
+
+// defaultPropertyClassRules follow the São Paulo IPTU use descriptions
+var defaultPropertyClassRules = []PropertyClassRule{
+	{Class: "apartamento", Keywords: []string{"APART", "DUPLEX", "COBERTURA"}},
+	{Class: "casa", Keywords: []string{"CASA", "RESIDENC", "SOBRADO", "TERREA"}},
+}
+
+// CityProfile describes how one city's transaction workbook is laid out and which
+// bairros are canonical there.
+type CityProfile struct {
+	City          string // slug stored in market tables (e.g. sp, curitiba)
+	Name          string
+	State         string
+	DefaultSource string
+
+	// MonthlySheets means one sheet per month named MMM-YYYY (e.g. JAN-2025). Otherwise
+	// rows are read from Sheet (the first sheet when empty) and bucketed by transaction date.
+	MonthlySheets bool
+	Sheet         string
+
+	// Columns maps each logical column to the accepted headers, already normalized
+	// (upper case, no accents). The first header present in the sheet wins.
+	Columns  map[string][]string
+	Required []string
+
+	// PropertyClassRules defaults to the São Paulo IPTU rules when empty
+	PropertyClassRules []PropertyClassRule
+
+	GoldenNeighborhoods []string
+
+	goldenOnce sync.Once
+	golden     map[string]string
+}
+
+var (
+	cityProfilesMu sync.RWMutex
+	cityProfiles   = map[string]*CityProfile{}
+)
+
+// RegisterCityProfile makes a city available to ingestion and the market endpoints.
+// Registering the same slug again replaces the previous profile.
+func RegisterCityProfile(profile *CityProfile) {
+	if profile == nil {
+		return
+	}
+	city := strings.ToLower(strings.TrimSpace(profile.City))
+	if city == "" {
+		panic("marketingest: city profile without slug")
+	}
+	profile.City = city
+
+	cityProfilesMu.Lock()
+	defer cityProfilesMu.Unlock()
+	cityProfiles[city] = profile
+}
+
+// LookupCityProfile returns the registered profile for a city slug
+func LookupCityProfile(city string) (*CityProfile, bool) {
+	city = strings.ToLower(strings.TrimSpace(city))
+	cityProfilesMu.RLock()
+	defer cityProfilesMu.RUnlock()
+	profile, ok := cityProfiles[city]
+	return profile, ok
+}
+
+// CityProfileFor resolves a city slug, defaulting to DefaultCity when empty
+func CityProfileFor(city string) (*CityProfile, error) {
+	if strings.TrimSpace(city) == "" {
+		city = DefaultCity
+	}
+	profile, ok := LookupCityProfile(city)
+	if !ok {
+		return nil, fmt.Errorf("unsupported city %q (supported: %s)", city, strings.Join(SupportedCities(), ", "))
+	}
+	return profile, nil
+}
+
+// CityFromAddress returns the slug of the registered city an address ends in, as in
+// "Rua X, 10 - Batel, Curitiba - PR" or "..., São Paulo/SP". A city name inside the street
+// ("Rua São Paulo, 100") does not count. Returns "" when no city is recognized.
+func CityFromAddress(address string) string {
+	text := " " + normalizeText(address) + " "
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	best, bestAt := "", -1
+	for _, profile := range CityProfiles() {
+		name := normalizeText(profile.Name)
+		if name == "" {
+			continue
+		}
+		at := -1
+		if state := normalizeText(profile.State); state != "" {
+			at = strings.LastIndex(text, " "+name+" "+state+" ")
+		}
+		if at < 0 && strings.HasSuffix(text, " "+name+" ") {
+			at = len(text) - len(name) - 2
+		}
+		if at > bestAt {
+			best, bestAt = profile.City, at
+		}
+	}
+	return best
+}
+
+// CityProfiles lists the registered profiles ordered by slug
+func CityProfiles() []*CityProfile {
+	cityProfilesMu.RLock()
+	defer cityProfilesMu.RUnlock()
+	out := make([]*CityProfile, 0, len(cityProfiles))
+	for _, profile := range cityProfiles {
+		out = append(out, profile)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].City < out[j].City })
+	return out
+}
+
+func SupportedCities() []string {
+	profiles := CityProfiles()
+	out := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		out = append(out, profile.City)
+	}
+	return out
+}
+
+// ClassifyPropertyClass maps the use columns of a row to apartamento, casa or outros
+func (p *CityProfile) ClassifyPropertyClass(use, description string) string {
+	if len(p.PropertyClassRules) == 0 {
+		return classifyPropertyClass(use, description)
+	}
+	return classifyByRules(p.PropertyClassRules, use, description)
+}
+
+// CanonicalNeighborhood returns the golden bairro name for value, or "" when unknown
+func (p *CityProfile) CanonicalNeighborhood(value string) string {
+	key := dictionaryKey(value)
+	if key == "" {
+		return ""
+	}
+	return p.goldenDictionary()[key]
+}
+
+// CanonicalNeighborhoods lists the golden bairros in alphabetical order
+func (p *CityProfile) CanonicalNeighborhoods() []string {
+	dict := p.goldenDictionary()
+	out := make([]string, 0, len(dict))
+	seen := make(map[string]struct{}, len(dict))
+	for _, canonical := range dict {
+		if _, ok := seen[canonical]; ok {
+			continue
+		}
+		seen[canonical] = struct{}{}
+		out = append(out, canonical)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// goldenDictionary is shared and must not be mutated; copy it before adding aliases
+func (p *CityProfile) goldenDictionary() map[string]string {
+	p.goldenOnce.Do(func() {
+		p.golden = make(map[string]string, len(p.GoldenNeighborhoods))
+		for _, item := range p.GoldenNeighborhoods {
+			key := dictionaryKey(item)
+			if key == "" {
+				continue
+			}
+			p.golden[key] = item
+		}
+	})
+	return p.golden
+}
+
+func classifyByRules(rules []PropertyClassRule, use, description string) string {
+	value := strings.ToUpper(normalizeText(use + " " + description))
+	for _, rule := range rules {
+		for _, keyword := range rule.Keywords {
+			if strings.Contains(value, keyword) {
+				return rule.Class
+			}
+		}
+	}
+	return "outros"
+}
@@ -0,0 +1,46 @@
+package marketingest
+
+// Curitiba: ITBI transactions exported from the Prefeitura open data portal as a single
+// sheet; the month comes from the transaction date.
+func init() {
+	RegisterCityProfile(&CityProfile{
+		City:          "curitiba",
+		Name:          "Curitiba",
+		State:         "PR",
+		DefaultSource: "itbi_curitiba",
+		Columns: map[string][]string{
+			ColumnRegistration:   {"INDICACAO FISCAL", "INSCRICAO IMOBILIARIA"},
+			ColumnNeighborhood:   {"BAIRRO", "NOME DO BAIRRO"},
+			ColumnAddress:        {"ENDERECO", "LOGRADOURO"},
+			ColumnAddressNumber:  {"NUMERO", "NUMERO PREDIAL"},
+			ColumnCEP:            {"CEP"},
+			ColumnValue:          {"VALOR DA TRANSACAO", "VALOR DE TRANSACAO", "VALOR DECLARADO", "VALOR TRANSACIONADO"},
+			ColumnDate:           {"DATA DA TRANSACAO", "DATA DE TRANSACAO", "DATA DO PAGAMENTO"},
+			ColumnArea:           {"AREA CONSTRUIDA", "AREA CONSTRUIDA M2", "AREA PRIVATIVA M2"},
+			ColumnUse:            {"TIPO DO IMOVEL", "TIPO DE IMOVEL", "TIPO"},
+			ColumnUseDescription: {"UTILIZACAO", "DESCRICAO DO IMOVEL"},
+		},
+		Required: []string{
+			ColumnNeighborhood,
+			ColumnValue,
+			ColumnDate,
+			ColumnArea,
+			ColumnUse,
+		},
+		PropertyClassRules: []PropertyClassRule{
+			{Class: "apartamento", Keywords: []string{"APART", "COBERTURA", "DUPLEX", "KITINETE", "KITNET", "STUDIO"}},
+			{Class: "casa", Keywords: []string{"CASA", "SOBRADO", "RESIDENCIA"}},
+		},
+		GoldenNeighborhoods: []string{
+			"ABRANCHES", "AGUA VERDE", "AHU", "ALTO BOQUEIRAO", "ALTO DA GLORIA", "ALTO DA RUA XV", "ATUBA", "AUGUSTA", "BACACHERI",
+			"BAIRRO ALTO", "BARREIRINHA", "BATEL", "BIGORRILHO", "BOA VISTA", "BOM RETIRO", "BOQUEIRAO", "BUTIATUVINHA", "CABRAL",
+			"CACHOEIRA", "CAJURU", "CAMPINA DO SIQUEIRA", "CAMPO COMPRIDO", "CAMPO DE SANTANA", "CAPAO DA IMBUIA", "CAPAO RASO",
+			"CASCATINHA", "CAXIMBA", "CENTRO", "CENTRO CIVICO", "CIDADE INDUSTRIAL", "CRISTO REI", "FANNY", "FAZENDINHA", "GANCHINHO",
+			"GUABIROTUBA", "GUAIRA", "HAUER", "HUGO LANGE", "JARDIM BOTANICO", "JARDIM DAS AMERICAS", "JARDIM SOCIAL", "JUVEVE",
+			"LAMENHA PEQUENA", "LINDOIA", "MERCES", "MOSSUNGUE", "NOVO MUNDO", "ORLEANS", "PAROLIN", "PILARZINHO", "PINHEIRINHO",
+			"PORTAO", "PRADO VELHO", "REBOUCAS", "RIVIERA", "SANTA CANDIDA", "SANTA FELICIDADE", "SANTA QUITERIA", "SANTO INACIO",
+			"SAO BRAZ", "SAO FRANCISCO", "SAO JOAO", "SAO LOURENCO", "SAO MIGUEL", "SEMINARIO", "SITIO CERCADO", "TABOAO", "TARUMA",
+			"TATUQUARA", "TINGUI", "UBERABA", "UMBARA", "VILA IZABEL", "VISTA ALEGRE", "XAXIM",
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package marketingest
+
+// São Paulo: ITBI "guias pagas" workbook published by the Prefeitura, one sheet per month
+func init() {
+	RegisterCityProfile(&CityProfile{
+		City:          DefaultCity,
+		Name:          "São Paulo",
+		State:         "SP",
+		DefaultSource: DefaultSource,
+		MonthlySheets: true,
+		Columns: map[string][]string{
+			ColumnRegistration:   {"N DO CADASTRO SQL"},
+			ColumnNeighborhood:   {"BAIRRO"},
+			ColumnAddress:        {"NOME DO LOGRADOURO", "LOGRADOURO"},
+			ColumnAddressNumber:  {"NUMERO"},
+			ColumnCEP:            {"CEP"},
+			ColumnValue:          {"VALOR DE TRANSACAO DECLARADO PELO CONTRIBUINTE"},
+			ColumnDate:           {"DATA DE TRANSACAO"},
+			ColumnArea:           {"AREA CONSTRUIDA M2"},
+			ColumnUse:            {"USO IPTU"},
+			ColumnUseDescription: {"DESCRICAO DO USO IPTU"},
+		},
+		Required: []string{
+			ColumnRegistration,
+			ColumnNeighborhood,
+			ColumnValue,
+			ColumnDate,
+			ColumnArea,
+			ColumnUse,
+			ColumnUseDescription,
+		},
+		GoldenNeighborhoods: []string{
+			"ACLIMACAO", "AGUA BRANCA", "AGUA FRIA", "AGUA RASA", "ALTO DA BOA VISTA", "ALTO DA LAPA", "ALTO DA MOOCA", "ALTO DE PINHEIROS",
+			"ANHANGUERA", "ARICANDUVA", "ARTUR ALVIM", "BARRA FUNDA", "BELA VISTA", "BELEM", "BELENZINHO", "BOM RETIRO", "BRAS", "BRASILANDIA",
+			"BROOKLIN", "BROOKLIN PAULISTA", "BUTANTA", "CACHOEIRINHA", "CAMBUCI", "CAMPO BELO", "CAMPO GRANDE", "CAMPO LIMPO", "CANGAIBA",
+			"CAPAO REDONDO", "CARRAO", "CASA VERDE", "CERQUEIRA CESAR", "CHACARA KLABIN", "CHACARA SANTO ANTONIO", "CIDADE ADEMAR", "CIDADE DUTRA",
+			"CIDADE LIDER", "CIDADE MONCOES", "CIDADE TIRADENTES", "CONSOLACAO", "CURSINO", "ERMELINO MATARAZZO", "FREGUESIA DO O", "GRAJAU",
+			"GUAIANASES", "HIGIENOPOLIS", "IGUATEMI", "INDIANOPOLIS", "INTERLAGOS", "IPIRANGA", "ITAIM", "ITAIM BIBI", "ITAIM PAULISTA", "ITAQUERA",
+			"JABAQUARA", "JACANA", "JAGUARA", "JAGUARE", "JARAGUA", "JARDIM AMERICA", "JARDIM ANGELA", "JARDIM AURELIA", "JARDIM BONFIGLIOLI",
+			"JARDIM DAS BANDEIRAS", "JARDIM EUROPA", "JARDIM HELENA", "JARDIM LEONOR", "JARDIM LUSITANIA", "JARDIM PAULISTA", "JARDIM PRUDENCIA",
+			"JARDIM SAO LUIS", "JOSE BONIFACIO", "JURUBATUBA", "LAJEADO", "LAPA", "LIBERDADE", "LIMAO", "MANDAQUI", "MARSILAC", "MIRANDOPOLIS",
+			"MOEMA", "MOOCA", "MORUMBI", "PACAEMBU", "PARAISO", "PARELHEIROS", "PARI", "PARQUE DO CARMO", "PEDREIRA", "PENHA", "PERDIZES", "PERUS",
+			"PINHEIROS", "PIRITUBA", "PONTE RASA", "RAPOSO TAVARES", "REPUBLICA", "RIO PEQUENO", "SACOMA", "SANTA CECILIA", "SANTA EFIGENIA",
+			"SANTANA", "SANTO AMARO", "SAO DOMINGOS", "SAO LUCAS", "SAO MATEUS", "SAO MIGUEL", "SAO RAFAEL", "SAPOPEMBA", "SAUDE", "SE", "SOCORRO",
+			"TATUAPE", "TREMEMBE", "TUCURUVI", "VILA ALPINA", "VILA ANDRADE", "VILA BUARQUE", "VILA CLEMENTINO", "VILA CURUCA", "VILA FORMOSA",
+			"VILA GUILHERME", "VILA GUMERCINDO", "VILA JACUI", "VILA LEOPOLDINA", "VILA MADALENA", "VILA MARIA", "VILA MARIANA", "VILA MASCOTE",
+			"VILA MATILDE", "VILA MEDEIROS", "VILA OLIMPIA", "VILA PRUDENTE", "VILA ROMANA", "VILA SONIA", "VILA VERMELHA",
+		},
+	})
+}
@@ -0,0 +1,123 @@
+package marketingest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestCityProfileFor(t *testing.T) {
+	profile, err := CityProfileFor("")
+	if err != nil || profile.City != DefaultCity || profile.DefaultSource != DefaultSource {
+		t.Fatalf("default profile=%+v err=%v", profile, err)
+	}
+	profile, err = CityProfileFor(" Curitiba ")
+	if err != nil || profile.State != "PR" {
+		t.Fatalf("curitiba profile=%+v err=%v", profile, err)
+	}
+	if _, err := CityProfileFor("recife"); err == nil {
+		t.Fatalf("expected error for unsupported city")
+	}
+}
+
+func TestCityFromAddress(t *testing.T) {
+	tests := map[string]string{
+		"Rua Bento Viana, 100 - Batel, Curitiba - PR":   "curitiba",
+		"Av. Paulista, 1000 - Bela Vista, São Paulo/SP": DefaultCity,
+		"Rua São Paulo, 100 - Centro, Curitiba/PR":      "curitiba",
+		"Rua Augusta, 500, Sao Paulo":                   DefaultCity,
+		"Rua São Paulo, 100":                            "",
+		"":                                              "",
+	}
+	for address, want := range tests {
+		if got := CityFromAddress(address); got != want {
+			t.Fatalf("%q: got %q, want %q", address, got, want)
+		}
+	}
+}
+
+func TestCityProfileGoldenNeighborhoodsAreScoped(t *testing.T) {
+	if got := CanonicalNeighborhoodFromGolden("curitiba", "Água Verde"); got != "AGUA VERDE" {
+		t.Fatalf("curitiba canonical=%q", got)
+	}
+	if got := CanonicalNeighborhoodFromGolden(DefaultCity, "Água Verde"); got != "" {
+		t.Fatalf("sp should not know curitiba bairros, got %q", got)
+	}
+	if got := MatchNeighborhood("curitiba", "Vl. Izabel", nil); got.Canonical != "VILA IZABEL" || got.Method != MatchMethodDictionary {
+		t.Fatalf("curitiba match=%+v", got)
+	}
+}
+
+func TestCuritibaPropertyClassRules(t *testing.T) {
+	profile, _ := LookupCityProfile("curitiba")
+	tests := map[string]string{
+		"Apartamento": "apartamento",
+		"Kitinete":    "apartamento",
+		"Sobrado":     "casa",
+		"Terreno":     "outros",
+	}
+	for use, want := range tests {
+		if got := profile.ClassifyPropertyClass(use, ""); got != want {
+			t.Fatalf("%s: got %s, want %s", use, got, want)
+		}
+	}
+}
+
+func TestParseFileBucketsSingleSheetByTransactionDate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "itbi_curitiba.xlsx")
+	f := excelize.NewFile()
+	rows := [][]any{
+		{"Indicação Fiscal", "Bairro", "Valor da Transação", "Data da Transação", "Área Construída", "Tipo do Imóvel"},
+		{"12.345.678", "Água Verde", "500000", "15/01/2025", "80", "Apartamento"},
+		{"12.345.679", "Batel", "1.200.000,00", "03/02/2025", "120", "Sobrado"},
+		{"12.345.680", "Batel", "900000", "10/04/2025", "100", "Apartamento"}, // after as-of-month
+		{"12.345.681", "Batel", "900000", "", "100", "Apartamento"},           // no date to bucket by
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatalf("SetSheetRow: %v", err)
+		}
+	}
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs: %v", err)
+	}
+
+	asOf := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	res, err := ParseFile(context.Background(), ParseConfig{FilePath: path, City: "curitiba"}, asOf)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if res.InputRows != 4 || res.ValidRows != 2 {
+		t.Fatalf("input=%d valid=%d", res.InputRows, res.ValidRows)
+	}
+	if len(res.TouchedMonths) != 2 || res.TouchedMonths[0].Month() != time.January || res.TouchedMonths[1].Month() != time.February {
+		t.Fatalf("touched months=%v", res.TouchedMonths)
+	}
+	first := res.Records[0]
+	if first.City != "curitiba" || first.Source != "itbi_curitiba" || first.RegionNormalized != "AGUA VERDE" || first.PropertyClass != "apartamento" {
+		t.Fatalf("first record=%+v", first)
+	}
+	if res.Records[1].PropertyClass != "casa" || res.Records[1].Month.Month() != time.February {
+		t.Fatalf("second record=%+v", res.Records[1])
+	}
+}
+
+func TestParseFileReportsMissingProfileColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "itbi_curitiba.xlsx")
+	f := excelize.NewFile()
+	if err := f.SetSheetRow("Sheet1", "A1", &[]any{"Bairro", "Valor da Transação", "Área Construída"}); err != nil {
+		t.Fatalf("SetSheetRow: %v", err)
+	}
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs: %v", err)
+	}
+
+	_, err := ParseFile(context.Background(), ParseConfig{FilePath: path, City: "curitiba"}, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC))
+	if err == nil || err.Error() != `sheet Sheet1: missing required column "DATA DA TRANSACAO"` {
+		t.Fatalf("err=%v", err)
+	}
+}
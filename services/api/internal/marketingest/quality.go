@@ -0,0 +1,255 @@
+package marketingest
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMinTxCount is the sample size below which the market endpoints hide a bairro
+const DefaultMinTxCount = 15
+
+// Reasons a row is dropped before aggregation
+const (
+	DropMissingNeighborhood = "missing_neighborhood"
+	DropUnknownNeighborhood = "unknown_neighborhood"
+	DropInvalidValue        = "invalid_value"
+	DropInvalidArea         = "invalid_area"
+	DropImpossiblePriceM2   = "impossible_price_m2"
+	DropMissingDate         = "missing_date"
+	DropAfterAsOfMonth      = "after_as_of_month"
+	DropDuplicateRow        = "duplicate_row"
+	DropIQROutlier          = "iqr_outlier"
+)
+
+// Flags kept on rows that are still aggregated but deserve review
+const (
+	FlagMADOutlier            = "mad_outlier"
+	FlagDuplicateRegistration = "duplicate_registration"
+)
+
+const (
+	minAreaM2           = 10
+	minPriceM2          = 5
+	maxPriceM2          = 200000
+	outlierMinGroupSize = 8   // smaller bairro/class groups are not tested
+	iqrFenceMultiplier  = 3.0 // Tukey "far out" fences
+	madZScoreThreshold  = 3.5 // Iglewicz and Hoaglin modified z-score
+	lowSamplePeriod     = 6   // months, the default window of the market page
+	qualitySampleLimit  = 20
+	lowSampleListLimit  = 200
+)
+
+// QualityReport summarizes what the quality stage dropped or flagged in a run
+type QualityReport struct {
+	DroppedRows      map[string]int  `json:"dropped_rows"`
+	FlaggedRows      map[string]int  `json:"flagged_rows"`
+	Samples          []QualitySample `json:"samples,omitempty"`
+	MinTxCount       int             `json:"min_tx_count"`
+	PeriodMonths     int             `json:"period_months"`
+	LowSampleTotal   int             `json:"low_sample_total"`
+	LowSampleBairros []BairroTxCount `json:"low_sample_bairros,omitempty"`
+}
+
+// QualitySample is a dropped outlier or flagged row shown for review
+type QualitySample struct {
+	Month            string  `json:"month"`
+	RegionName       string  `json:"region_name"`
+	PropertyClass    string  `json:"property_class"`
+	SQLRegistration  string  `json:"sql_registration,omitempty"`
+	TransactionValue float64 `json:"transaction_value"`
+	AreaM2           float64 `json:"area_m2"`
+	PriceM2          float64 `json:"price_m2"`
+	Reason           string  `json:"reason"`
+	Excluded         bool    `json:"excluded"`
+}
+
+type BairroTxCount struct {
+	RegionName string `json:"region_name"`
+	TxCount    int    `json:"tx_count"`
+}
+
+func newQualityReport() QualityReport {
+	return QualityReport{DroppedRows: map[string]int{}, FlaggedRows: map[string]int{}}
+}
+
+func (q *QualityReport) drop(reason string) {
+	if q.DroppedRows == nil {
+		q.DroppedRows = map[string]int{}
+	}
+	q.DroppedRows[reason]++
+}
+
+func (q *QualityReport) merge(other QualityReport) {
+	for reason, count := range other.DroppedRows {
+		if q.DroppedRows == nil {
+			q.DroppedRows = map[string]int{}
+		}
+		q.DroppedRows[reason] += count
+	}
+}
+
+func (q *QualityReport) sample(rec TxRecord, reason string, excluded bool) {
+	if len(q.Samples) >= qualitySampleLimit {
+		return
+	}
+	q.Samples = append(q.Samples, QualitySample{
+		Month:            rec.Month.Format("2006-01"),
+		RegionName:       rec.RegionNormalized,
+		PropertyClass:    rec.PropertyClass,
+		SQLRegistration:  rec.SQLRegistration,
+		TransactionValue: rec.TransactionValue,
+		AreaM2:           rec.AreaM2,
+		PriceM2:          rec.PriceM2,
+		Reason:           reason,
+		Excluded:         excluded,
+	})
+}
+
+// applyQualityStage drops repeated rows and IQR outliers per bairro and class, flags MAD
+// outliers and repeated SQL registrations, and fills the report. The kept rows are returned.
+func applyQualityStage(records []TxRecord, report *QualityReport, asOfMonth time.Time, minTxCount int) []TxRecord {
+	if report.FlaggedRows == nil {
+		report.FlaggedRows = map[string]int{}
+	}
+
+	seen := make(map[string]struct{}, len(records))
+	deduped := make([]TxRecord, 0, len(records))
+	for _, rec := range records {
+		if _, ok := seen[rec.RowHash]; ok {
+			report.drop(DropDuplicateRow)
+			continue
+		}
+		seen[rec.RowHash] = struct{}{}
+		deduped = append(deduped, rec)
+	}
+
+	type groupKey struct{ region, class string }
+	groups := make(map[groupKey][]int)
+	for i, rec := range deduped {
+		key := groupKey{rec.RegionNormalized, rec.PropertyClass}
+		groups[key] = append(groups[key], i)
+	}
+
+	excluded := make([]bool, len(deduped))
+	flagged := make([]bool, len(deduped))
+	for _, idxs := range groups {
+		if len(idxs) < outlierMinGroupSize {
+			continue
+		}
+		prices := make([]float64, len(idxs))
+		for i, idx := range idxs {
+			prices[i] = deduped[idx].PriceM2
+		}
+		sort.Float64s(prices)
+
+		q1, q3 := percentile(prices, 0.25), percentile(prices, 0.75)
+		iqr := q3 - q1
+		low, high := q1-iqrFenceMultiplier*iqr, q3+iqrFenceMultiplier*iqr
+
+		median := percentile(prices, 0.5)
+		deviations := make([]float64, len(prices))
+		for i, price := range prices {
+			deviations[i] = math.Abs(price - median)
+		}
+		sort.Float64s(deviations)
+		mad := percentile(deviations, 0.5)
+
+		for _, idx := range idxs {
+			price := deduped[idx].PriceM2
+			if iqr > 0 && (price < low || price > high) {
+				excluded[idx] = true
+				continue
+			}
+			if mad > 0 && 0.6745*math.Abs(price-median)/mad > madZScoreThreshold {
+				flagged[idx] = true
+			}
+		}
+	}
+
+	registrations := make(map[string][]int)
+	for i, rec := range deduped {
+		if excluded[i] {
+			continue
+		}
+		registration := strings.TrimSpace(rec.SQLRegistration)
+		if registration == "" {
+			continue
+		}
+		key := rec.Month.Format("2006-01") + "|" + registration
+		registrations[key] = append(registrations[key], i)
+	}
+
+	kept := make([]TxRecord, 0, len(deduped))
+	for i, rec := range deduped {
+		if excluded[i] {
+			report.drop(DropIQROutlier)
+			report.sample(rec, DropIQROutlier, true)
+			continue
+		}
+		if flagged[i] {
+			rec.QualityFlags = append(rec.QualityFlags, FlagMADOutlier)
+		}
+		if len(registrations[rec.Month.Format("2006-01")+"|"+strings.TrimSpace(rec.SQLRegistration)]) > 1 {
+			rec.QualityFlags = append(rec.QualityFlags, FlagDuplicateRegistration)
+		}
+		for _, flag := range rec.QualityFlags {
+			report.FlaggedRows[flag]++
+			report.sample(rec, flag, false)
+		}
+		kept = append(kept, rec)
+	}
+
+	report.MinTxCount = minTxCount
+	report.PeriodMonths = lowSamplePeriod
+	report.LowSampleTotal, report.LowSampleBairros = lowSampleBairros(kept, asOfMonth, minTxCount)
+	return kept
+}
+
+// lowSampleBairros counts the rows of every bairro in the file over the lowSamplePeriod
+// months up to asOfMonth and lists those below minTxCount, smallest first
+func lowSampleBairros(records []TxRecord, asOfMonth time.Time, minTxCount int) (int, []BairroTxCount) {
+	start := asOfMonth.AddDate(0, -(lowSamplePeriod - 1), 0)
+	counts := make(map[string]int)
+	for _, rec := range records {
+		if _, ok := counts[rec.RegionNormalized]; !ok {
+			counts[rec.RegionNormalized] = 0
+		}
+		if !rec.Month.Before(start) && !rec.Month.After(asOfMonth) {
+			counts[rec.RegionNormalized]++
+		}
+	}
+
+	out := make([]BairroTxCount, 0)
+	for region, count := range counts {
+		if count < minTxCount {
+			out = append(out, BairroTxCount{RegionName: region, TxCount: count})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TxCount != out[j].TxCount {
+			return out[i].TxCount < out[j].TxCount
+		}
+		return out[i].RegionName < out[j].RegionName
+	})
+	total := len(out)
+	if len(out) > lowSampleListLimit {
+		out = out[:lowSampleListLimit]
+	}
+	return total, out
+}
+
+// percentile interpolates linearly like Postgres percentile_cont; sorted must be ascending
+func percentile(sorted []float64, fraction float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := fraction * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
@@ -0,0 +1,91 @@
+package marketingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApplyQualityStage(t *testing.T) {
+	jan := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	records := make([]TxRecord, 0, 16)
+	for i, price := range []float64{10000, 10100, 10200, 10300, 10400, 10500, 10600, 10700, 10800, 12150, 90000} {
+		records = append(records, TxRecord{
+			Month:            jan,
+			RegionNormalized: "MOEMA",
+			PropertyClass:    "apartamento",
+			SQLRegistration:  fmt.Sprintf("001.002.%04d-1", i),
+			PriceM2:          price,
+			RowHash:          fmt.Sprintf("moema-%d", i),
+		})
+	}
+	records = append(records, records[0]) // repeated row
+	records = append(records,
+		TxRecord{Month: jan, RegionNormalized: "SE", PropertyClass: "casa", SQLRegistration: "009.009.0009-9", PriceM2: 8000, RowHash: "se-1"},
+		TxRecord{Month: jan, RegionNormalized: "SE", PropertyClass: "casa", SQLRegistration: "009.009.0009-9", PriceM2: 8200, RowHash: "se-2"},
+	)
+
+	report := newQualityReport()
+	kept := applyQualityStage(records, &report, jan, 5)
+
+	if report.DroppedRows[DropDuplicateRow] != 1 || report.DroppedRows[DropIQROutlier] != 1 {
+		t.Fatalf("dropped=%v", report.DroppedRows)
+	}
+	if len(kept) != 12 {
+		t.Fatalf("kept=%d", len(kept))
+	}
+	for _, rec := range kept {
+		if rec.PriceM2 == 90000 {
+			t.Fatalf("IQR outlier kept: %+v", rec)
+		}
+	}
+	if report.FlaggedRows[FlagMADOutlier] != 1 || report.FlaggedRows[FlagDuplicateRegistration] != 2 {
+		t.Fatalf("flagged=%v", report.FlaggedRows)
+	}
+	if report.LowSampleTotal != 1 || report.LowSampleBairros[0] != (BairroTxCount{RegionName: "SE", TxCount: 2}) {
+		t.Fatalf("low sample=%d %+v", report.LowSampleTotal, report.LowSampleBairros)
+	}
+	if len(report.Samples) != 4 || report.Samples[0].Reason != FlagMADOutlier || !report.Samples[1].Excluded {
+		t.Fatalf("samples=%+v", report.Samples)
+	}
+}
+
+func TestParseFileReportsDropReasons(t *testing.T) {
+	content := "Data;Bairro;Valor;Área;Tipo\n" +
+		"10/01/2025;Batel;500000;80;Apartamento\n" +
+		"10/01/2025;;500000;80;Apartamento\n" +
+		"10/01/2025;Batel;abc;80;Apartamento\n" +
+		"10/01/2025;Batel;500000;5;Apartamento\n" +
+		"10/01/2025;Batel;100;80;Apartamento\n" +
+		";Batel;500000;80;Apartamento\n" +
+		"10/05/2025;Batel;500000;80;Apartamento\n"
+	path := filepath.Join(t.TempDir(), "itbi.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	columns, _ := ParseColumnMapping("date=Data,value=Valor,area=Área,use=Tipo")
+	res, err := ParseFile(context.Background(), ParseConfig{FilePath: path, City: "curitiba", Columns: columns}, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	want := map[string]int{
+		DropMissingNeighborhood: 1,
+		DropInvalidValue:        1,
+		DropInvalidArea:         1,
+		DropImpossiblePriceM2:   1,
+		DropMissingDate:         1,
+		DropAfterAsOfMonth:      1,
+	}
+	for reason, count := range want {
+		if res.Quality.DroppedRows[reason] != count {
+			t.Fatalf("dropped=%v", res.Quality.DroppedRows)
+		}
+	}
+	if res.ValidRows != 1 || res.Quality.MinTxCount != DefaultMinTxCount {
+		t.Fatalf("valid=%d quality=%+v", res.ValidRows, res.Quality)
+	}
+}
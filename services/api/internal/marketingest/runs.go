@@ -0,0 +1,409 @@
+package marketingest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	ErrRunNotFound        = errors.New("ingestion run not found")
+	ErrRunNotRollbackable = errors.New("ingestion run cannot be rolled back")
+	ErrRunSuperseded      = errors.New("a later ingestion run must be rolled back first")
+	ErrRunsNotComparable  = errors.New("ingestion runs cannot be compared")
+)
+
+// Columns shared by market_transactions and market_transactions_archive
+const archiveTransactionColumns = `id, run_id, city, source, month, region_id, region_name_raw, region_name_normalized, address, property_class, sql_registration, transaction_date, transaction_value, area_m2, price_m2, iptu_use, iptu_use_description, row_hash, quality_flags, region_method, created_at`
+
+// Change kinds of a RunDiffRow
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+type RollbackResult struct {
+	RunID                string `json:"run_id"`
+	AggregateChanges     int    `json:"aggregate_changes"`
+	TransactionsRemoved  int    `json:"transactions_removed"`
+	TransactionsRestored int    `json:"transactions_restored"`
+}
+
+// RollbackRun restores the aggregates and transactions a run replaced, in one transaction.
+// Only the latest live run of a city/source can be rolled back.
+func RollbackRun(ctx context.Context, db *sql.DB, runID, rolledBackBy string) (RollbackResult, error) {
+	if db == nil {
+		return RollbackResult{}, fmt.Errorf("db is required")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return RollbackResult{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SET LOCAL search_path TO flip, public`); err != nil {
+		return RollbackResult{}, err
+	}
+
+	var city, source, status string
+	var dryRun bool
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT city, source, status, dry_run, created_at
+		FROM market_ingestion_runs
+		WHERE id = $1
+		FOR UPDATE
+	`, runID).Scan(&city, &source, &status, &dryRun, &createdAt)
+	if err == sql.ErrNoRows {
+		return RollbackResult{}, ErrRunNotFound
+	}
+	if err != nil {
+		return RollbackResult{}, err
+	}
+	if dryRun {
+		return RollbackResult{}, fmt.Errorf("%w: dry runs write no data", ErrRunNotRollbackable)
+	}
+	if status != "success" {
+		return RollbackResult{}, fmt.Errorf("%w: status is %s", ErrRunNotRollbackable, status)
+	}
+
+	var superseded bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM market_ingestion_runs
+			WHERE city = $1
+			  AND source = $2
+			  AND dry_run = false
+			  AND status IN ('running', 'success')
+			  AND created_at > $3
+			  AND id <> $4
+		)
+	`, city, source, createdAt, runID).Scan(&superseded); err != nil {
+		return RollbackResult{}, err
+	}
+	if superseded {
+		return RollbackResult{}, ErrRunSuperseded
+	}
+
+	result := RollbackResult{RunID: runID}
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM market_ingestion_run_aggregate_changes WHERE run_id = $1
+	`, runID).Scan(&result.AggregateChanges); err != nil {
+		return RollbackResult{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM market_price_m2_aggregates a
+		USING market_ingestion_run_aggregate_changes c
+		WHERE c.run_id = $1
+		  AND a.city = c.city
+		  AND a.source = c.source
+		  AND a.region_id = c.region_id
+		  AND a.as_of_month = c.as_of_month
+		  AND a.period_months = c.period_months
+		  AND a.property_class = c.property_class
+	`, runID); err != nil {
+		return RollbackResult{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO market_price_m2_aggregates (
+			city,
+			source,
+			region_id,
+			region_type,
+			as_of_month,
+			period_months,
+			property_class,
+			median_m2,
+			p25_m2,
+			p75_m2,
+			tx_count,
+			updated_at
+		)
+		SELECT city, source, region_id, region_type, as_of_month, period_months, property_class,
+			previous_median_m2, previous_p25_m2, previous_p75_m2, previous_tx_count, NOW()
+		FROM market_ingestion_run_aggregate_changes
+		WHERE run_id = $1 AND previous_tx_count IS NOT NULL
+	`, runID); err != nil {
+		return RollbackResult{}, err
+	}
+
+	removed, err := tx.ExecContext(ctx, `
+		WITH removed AS (
+			DELETE FROM market_transactions
+			WHERE run_id = $1
+			RETURNING *
+		)
+		INSERT INTO market_transactions_archive (`+archiveTransactionColumns+`, archived_by_run_id, reason)
+		SELECT `+archiveTransactionColumns+`, $1, 'rolled_back'
+		FROM removed
+	`, runID)
+	if err != nil {
+		return RollbackResult{}, err
+	}
+	affected, _ := removed.RowsAffected()
+	result.TransactionsRemoved = int(affected)
+
+	restored, err := tx.ExecContext(ctx, `
+		WITH restored AS (
+			INSERT INTO market_transactions (`+archiveTransactionColumns+`)
+			SELECT `+archiveTransactionColumns+`
+			FROM market_transactions_archive
+			WHERE archived_by_run_id = $1 AND reason = 'replaced'
+			ON CONFLICT DO NOTHING
+			RETURNING id
+		)
+		DELETE FROM market_transactions_archive
+		WHERE id IN (SELECT id FROM restored)
+	`, runID)
+	if err != nil {
+		return RollbackResult{}, err
+	}
+	affected, _ = restored.RowsAffected()
+	result.TransactionsRestored = int(affected)
+
+	var rolledBackByValue any
+	if stringsTrim(rolledBackBy) != "" {
+		rolledBackByValue = stringsTrim(rolledBackBy)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE market_ingestion_runs
+		SET status = 'rolled_back',
+			rolled_back_at = NOW(),
+			rolled_back_by = $2
+		WHERE id = $1
+	`, runID, rolledBackByValue); err != nil {
+		return RollbackResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return RollbackResult{}, err
+	}
+	return result, nil
+}
+
+// RunMonthStat summarizes the transactions a run wrote for one bairro and month
+type RunMonthStat struct {
+	RegionName string
+	Month      time.Time
+	MedianM2   float64
+	TxCount    int
+}
+
+type RunDiffRow struct {
+	RegionName      string   `json:"region_name"`
+	Month           string   `json:"month"`
+	Change          string   `json:"change"`
+	BaseMedianM2    *float64 `json:"base_median_m2"`
+	MedianM2        *float64 `json:"median_m2"`
+	MedianChange    *float64 `json:"median_change"`
+	MedianChangePct *float64 `json:"median_change_pct"`
+	BaseTxCount     int      `json:"base_tx_count"`
+	TxCount         int      `json:"tx_count"`
+	TxCountChange   int      `json:"tx_count_change"`
+}
+
+type RunDiffSummary struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// RunDiff lists the bairro/month pairs that differ between two runs; unchanged pairs are
+// only counted in Summary
+type RunDiff struct {
+	BaseRunID string         `json:"base_run_id"`
+	RunID     string         `json:"run_id"`
+	Rows      []RunDiffRow   `json:"rows"`
+	Summary   RunDiffSummary `json:"summary"`
+}
+
+type runIdentity struct {
+	City      string
+	Source    string
+	DryRun    bool
+	CreatedAt time.Time
+}
+
+// PreviousRunID returns the latest earlier run of the same city and source that wrote data
+func PreviousRunID(ctx context.Context, db *sql.DB, runID string) (string, error) {
+	run, err := loadRunIdentity(ctx, db, runID)
+	if err != nil {
+		return "", err
+	}
+	var previous string
+	err = db.QueryRowContext(ctx, `
+		SELECT id
+		FROM market_ingestion_runs
+		WHERE city = $1
+		  AND source = $2
+		  AND dry_run = false
+		  AND status IN ('success', 'rolled_back')
+		  AND created_at < $3
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, run.City, run.Source, run.CreatedAt).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: no earlier run for %s/%s", ErrRunNotFound, run.City, run.Source)
+	}
+	return previous, err
+}
+
+// DiffRuns compares the transactions written by two runs of the same city and source,
+// including transactions later replaced or rolled back
+func DiffRuns(ctx context.Context, db *sql.DB, baseRunID, runID string) (RunDiff, error) {
+	if db == nil {
+		return RunDiff{}, fmt.Errorf("db is required")
+	}
+	base, err := loadRunIdentity(ctx, db, baseRunID)
+	if err != nil {
+		return RunDiff{}, err
+	}
+	target, err := loadRunIdentity(ctx, db, runID)
+	if err != nil {
+		return RunDiff{}, err
+	}
+	if base.City != target.City || base.Source != target.Source {
+		return RunDiff{}, fmt.Errorf("%w: runs are for %s/%s and %s/%s", ErrRunsNotComparable, base.City, base.Source, target.City, target.Source)
+	}
+	if base.DryRun || target.DryRun {
+		return RunDiff{}, fmt.Errorf("%w: dry runs write no data", ErrRunsNotComparable)
+	}
+
+	baseStats, err := loadRunMonthStats(ctx, db, baseRunID)
+	if err != nil {
+		return RunDiff{}, err
+	}
+	targetStats, err := loadRunMonthStats(ctx, db, runID)
+	if err != nil {
+		return RunDiff{}, err
+	}
+
+	diff := diffRunStats(baseStats, targetStats)
+	diff.BaseRunID = baseRunID
+	diff.RunID = runID
+	return diff, nil
+}
+
+func loadRunIdentity(ctx context.Context, db *sql.DB, runID string) (runIdentity, error) {
+	var run runIdentity
+	err := db.QueryRowContext(ctx, `
+		SELECT city, source, dry_run, created_at
+		FROM market_ingestion_runs
+		WHERE id = $1
+	`, runID).Scan(&run.City, &run.Source, &run.DryRun, &run.CreatedAt)
+	if err == sql.ErrNoRows {
+		return runIdentity{}, ErrRunNotFound
+	}
+	return run, err
+}
+
+func loadRunMonthStats(ctx context.Context, db *sql.DB, runID string) ([]RunMonthStat, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			region_name_normalized,
+			month,
+			ROUND((percentile_cont(0.5) WITHIN GROUP (ORDER BY price_m2))::numeric, 2)::float8,
+			COUNT(*)::int
+		FROM (
+			SELECT region_name_normalized, month, price_m2 FROM market_transactions WHERE run_id = $1
+			UNION ALL
+			SELECT region_name_normalized, month, price_m2 FROM market_transactions_archive WHERE run_id = $1
+		) t
+		GROUP BY region_name_normalized, month
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RunMonthStat
+	for rows.Next() {
+		var stat RunMonthStat
+		if err := rows.Scan(&stat.RegionName, &stat.Month, &stat.MedianM2, &stat.TxCount); err != nil {
+			return nil, err
+		}
+		out = append(out, stat)
+	}
+	return out, rows.Err()
+}
+
+func diffRunStats(base, target []RunMonthStat) RunDiff {
+	type key struct {
+		region string
+		month  string
+	}
+	type pair struct {
+		base   *RunMonthStat
+		target *RunMonthStat
+	}
+	pairs := map[key]*pair{}
+	for i := range base {
+		k := key{base[i].RegionName, base[i].Month.Format("2006-01")}
+		pairs[k] = &pair{base: &base[i]}
+	}
+	for i := range target {
+		k := key{target[i].RegionName, target[i].Month.Format("2006-01")}
+		if p, ok := pairs[k]; ok {
+			p.target = &target[i]
+			continue
+		}
+		pairs[k] = &pair{target: &target[i]}
+	}
+
+	diff := RunDiff{Rows: []RunDiffRow{}}
+	for k, p := range pairs {
+		row := RunDiffRow{RegionName: k.region, Month: k.month}
+		if p.base != nil {
+			median := round2(p.base.MedianM2)
+			row.BaseMedianM2 = &median
+			row.BaseTxCount = p.base.TxCount
+		}
+		if p.target != nil {
+			median := round2(p.target.MedianM2)
+			row.MedianM2 = &median
+			row.TxCount = p.target.TxCount
+		}
+		row.TxCountChange = row.TxCount - row.BaseTxCount
+
+		switch {
+		case p.base == nil:
+			row.Change = DiffAdded
+			diff.Summary.Added++
+		case p.target == nil:
+			row.Change = DiffRemoved
+			diff.Summary.Removed++
+		default:
+			change := round2(*row.MedianM2 - *row.BaseMedianM2)
+			row.MedianChange = &change
+			if *row.BaseMedianM2 != 0 {
+				pct := round2(change / *row.BaseMedianM2 * 100)
+				row.MedianChangePct = &pct
+			}
+			if math.Abs(change) < 0.01 && row.TxCountChange == 0 {
+				diff.Summary.Unchanged++
+				continue
+			}
+			row.Change = DiffChanged
+			diff.Summary.Changed++
+		}
+		diff.Rows = append(diff.Rows, row)
+	}
+
+	sort.Slice(diff.Rows, func(i, j int) bool {
+		if diff.Rows[i].RegionName != diff.Rows[j].RegionName {
+			return diff.Rows[i].RegionName < diff.Rows[j].RegionName
+		}
+		return diff.Rows[i].Month < diff.Rows[j].Month
+	})
+	return diff
+}
@@ -0,0 +1,40 @@
+package marketingest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffRunStats(t *testing.T) {
+	jan := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)
+	base := []RunMonthStat{
+		{RegionName: "MOEMA", Month: jan, MedianM2: 10000, TxCount: 10},
+		{RegionName: "MOEMA", Month: feb, MedianM2: 11000, TxCount: 8},
+		{RegionName: "PINHEIROS", Month: jan, MedianM2: 12000, TxCount: 5},
+	}
+	target := []RunMonthStat{
+		{RegionName: "MOEMA", Month: jan, MedianM2: 10000, TxCount: 10},
+		{RegionName: "MOEMA", Month: feb, MedianM2: 12100, TxCount: 9},
+		{RegionName: "ACLIMACAO", Month: feb, MedianM2: 9000, TxCount: 3},
+	}
+
+	diff := diffRunStats(base, target)
+	if diff.Summary != (RunDiffSummary{Added: 1, Removed: 1, Changed: 1, Unchanged: 1}) {
+		t.Fatalf("summary=%+v", diff.Summary)
+	}
+	if len(diff.Rows) != 3 || diff.Rows[0].RegionName != "ACLIMACAO" || diff.Rows[2].RegionName != "PINHEIROS" {
+		t.Fatalf("rows=%+v", diff.Rows)
+	}
+
+	changed := diff.Rows[1]
+	if changed.Change != DiffChanged || changed.Month != "2025-02" || *changed.MedianChange != 1100 || *changed.MedianChangePct != 10 || changed.TxCountChange != 1 {
+		t.Fatalf("changed=%+v", changed)
+	}
+	if added := diff.Rows[0]; added.Change != DiffAdded || added.BaseMedianM2 != nil || added.TxCountChange != 3 {
+		t.Fatalf("added=%+v", added)
+	}
+	if removed := diff.Rows[2]; removed.Change != DiffRemoved || removed.MedianM2 != nil || removed.TxCountChange != -5 {
+		t.Fatalf("removed=%+v", removed)
+	}
+}
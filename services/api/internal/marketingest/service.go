@@ -13,16 +13,22 @@ type RunConfig struct {
 	FilePath               string
 	City                   string
 	Source                 string
+	Format                 string
+	Columns                ColumnMapping
 	AsOfMonth              time.Time
 	DryRun                 bool
 	RunID                  string
 	NeighborhoodNormalizer NeighborhoodNormalizer
 	MaxLLMCalls            int
 	ApprovedAliases        map[string]string
+	MinTxCount             int
+	Geo                    GeoConfig
 }
 
 type RunResult struct {
 	RunID           string
+	Format          string
+	Encoding        string
 	InputRows       int
 	ValidRows       int
 	OutputGroups    int
@@ -31,17 +37,22 @@ type RunResult struct {
 	LLMCalls        int
 	LLMResolved     int
 	AliasCandidates int
+	Quality         QualityReport
+	RegionMethods   map[string]int
 }
 
 type PreviewRow struct {
-	Month            string  `json:"month"`
-	RegionName       string  `json:"region_name"`
-	PropertyClass    string  `json:"property_class"`
-	TransactionDate  *string `json:"transaction_date,omitempty"`
-	TransactionValue float64 `json:"transaction_value"`
-	AreaM2           float64 `json:"area_m2"`
-	PriceM2          float64 `json:"price_m2"`
-	SQLRegistration  string  `json:"sql_registration,omitempty"`
+	Month            string   `json:"month"`
+	RegionName       string   `json:"region_name"`
+	Address          string   `json:"address,omitempty"`
+	PropertyClass    string   `json:"property_class"`
+	TransactionDate  *string  `json:"transaction_date,omitempty"`
+	TransactionValue float64  `json:"transaction_value"`
+	AreaM2           float64  `json:"area_m2"`
+	PriceM2          float64  `json:"price_m2"`
+	SQLRegistration  string   `json:"sql_registration,omitempty"`
+	QualityFlags     []string `json:"quality_flags,omitempty"`
+	RegionMethod     string   `json:"region_method,omitempty"`
 }
 
 type RunMetadata struct {
@@ -56,10 +67,19 @@ type RunMetadata struct {
 }
 
 func RunFromFile(ctx context.Context, db *sql.DB, cfg RunConfig) (RunResult, error) {
+	profile, err := CityProfileFor(cfg.City)
+	if err != nil {
+		return RunResult{}, err
+	}
+	cfg.City = profile.City
+	if stringsTrim(cfg.Source) == "" {
+		cfg.Source = profile.DefaultSource
+	}
+
 	approvedAliases := make(map[string]string, len(cfg.ApprovedAliases))
 	for alias, canonical := range cfg.ApprovedAliases {
 		aliasKey := NormalizeNeighborhoodKey(alias)
-		canonicalName := CanonicalNeighborhoodFromGolden(canonical)
+		canonicalName := profile.CanonicalNeighborhood(canonical)
 		if canonicalName == "" {
 			canonicalName = NormalizeNeighborhoodKey(canonical)
 		}
@@ -79,13 +99,17 @@ func RunFromFile(ctx context.Context, db *sql.DB, cfg RunConfig) (RunResult, err
 		}
 	}
 
-	parsed, err := ParseWorkbook(ctx, ParseConfig{
+	parsed, err := ParseFile(ctx, ParseConfig{
 		FilePath:               cfg.FilePath,
 		City:                   cfg.City,
 		Source:                 cfg.Source,
+		Format:                 cfg.Format,
+		Columns:                cfg.Columns,
 		NeighborhoodNormalizer: cfg.NeighborhoodNormalizer,
 		MaxLLMCalls:            cfg.MaxLLMCalls,
 		ApprovedAliases:        approvedAliases,
+		MinTxCount:             cfg.MinTxCount,
+		Geo:                    cfg.Geo,
 	}, cfg.AsOfMonth)
 	if err != nil {
 		return RunResult{}, err
@@ -93,12 +117,16 @@ func RunFromFile(ctx context.Context, db *sql.DB, cfg RunConfig) (RunResult, err
 
 	result := RunResult{
 		RunID:           cfg.RunID,
+		Format:          parsed.Format,
+		Encoding:        parsed.Encoding,
 		InputRows:       parsed.InputRows,
 		ValidRows:       parsed.ValidRows,
 		TouchedMonths:   make([]string, 0, len(parsed.TouchedMonths)),
 		LLMCalls:        parsed.LLMCalls,
 		LLMResolved:     parsed.LLMResolved,
 		AliasCandidates: len(parsed.AliasCandidates),
+		Quality:         parsed.Quality,
+		RegionMethods:   parsed.RegionMethods,
 	}
 	for _, month := range parsed.TouchedMonths {
 		result.TouchedMonths = append(result.TouchedMonths, month.Format("2006-01"))
@@ -142,6 +170,10 @@ func RunFromFile(ctx context.Context, db *sql.DB, cfg RunConfig) (RunResult, err
 				"llm_calls":        result.LLMCalls,
 				"llm_resolved":     result.LLMResolved,
 				"alias_candidates": result.AliasCandidates,
+				"format":           result.Format,
+				"encoding":         result.Encoding,
+				"quality":          result.Quality,
+				"region_methods":   result.RegionMethods,
 			}, time.Now().UTC())
 		}
 		return result, ingestErr
@@ -157,6 +189,10 @@ func RunFromFile(ctx context.Context, db *sql.DB, cfg RunConfig) (RunResult, err
 			"llm_calls":        result.LLMCalls,
 			"llm_resolved":     result.LLMResolved,
 			"alias_candidates": result.AliasCandidates,
+			"format":           result.Format,
+			"encoding":         result.Encoding,
+			"quality":          result.Quality,
+			"region_methods":   result.RegionMethods,
 		}, time.Now().UTC())
 	}
 
@@ -306,12 +342,15 @@ func buildPreviewRows(records []TxRecord, limit int) []PreviewRow {
 		out = append(out, PreviewRow{
 			Month:            rec.Month.Format("2006-01"),
 			RegionName:       rec.RegionNormalized,
+			Address:          rec.Address,
 			PropertyClass:    rec.PropertyClass,
 			TransactionDate:  transactionDate,
 			TransactionValue: round2(rec.TransactionValue),
 			AreaM2:           round2(rec.AreaM2),
 			PriceM2:          round2(rec.PriceM2),
 			SQLRegistration:  rec.SQLRegistration,
+			QualityFlags:     rec.QualityFlags,
+			RegionMethod:     rec.RegionMethod,
 		})
 	}
 
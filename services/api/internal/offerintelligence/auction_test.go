@@ -0,0 +1,74 @@
+package offerintelligence
+
+import (
+	"testing"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
+)
+
+func auctionInputs(minimumBid float64) ProspectInputs {
+	inputs := negotiationInputs()
+	inputs.AskingPrice = &minimumBid
+	inputs.Auction = &viability.AuctionTerms{
+		IPTUArrears:         6000,
+		CondoArrears:        9000,
+		EvictionCost:        15000,
+		EvictionDelayMonths: 3,
+	}
+	return inputs
+}
+
+func TestCalculateAuctionBidsFromMinimumToMaxBid(t *testing.T) {
+	result, err := Calculate(auctionInputs(250000), testSettings())
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+
+	if result.AcquisitionMode != AcquisitionModeAuction || result.Auction == nil {
+		t.Fatalf("expected auction mode, got %q %+v", result.AcquisitionMode, result.Auction)
+	}
+	aggressive := findScenario(result.Scenarios, ScenarioAggressive)
+	ceiling := findScenario(result.Scenarios, ScenarioCeiling)
+	if aggressive.OfferPrice != 250000 {
+		t.Fatalf("aggressive bid=%.2f want the minimum bid", aggressive.OfferPrice)
+	}
+	if ceiling.OfferPrice <= 250000 || ceiling.OfferPrice != result.Auction.MaxBid {
+		t.Fatalf("max bid=%.2f summary=%.2f: want above the minimum bid", ceiling.OfferPrice, result.Auction.MaxBid)
+	}
+	if ceiling.Margin < testSettings().MinMarginPct-0.01 || ceiling.NetProfit < testSettings().MinNetProfitBRL-0.01 {
+		t.Fatalf("max bid misses the targets: %+v", ceiling)
+	}
+	if result.Auction.MaxBidForMargin < result.Auction.MaxBid {
+		t.Fatalf("max bid for margin=%.2f below max bid=%.2f", result.Auction.MaxBidForMargin, result.Auction.MaxBid)
+	}
+	if ceiling.Auction == nil || ceiling.Auction.Commission != round2(ceiling.OfferPrice*viability.DefaultAuctionCommissionRate) {
+		t.Fatalf("unexpected auction breakdown: %+v", ceiling.Auction)
+	}
+	if result.Auction.EffectiveHoldMonths != 9 {
+		t.Fatalf("effective_hold_months=%d want=9", result.Auction.EffectiveHoldMonths)
+	}
+
+	market := auctionInputs(250000)
+	market.Auction = nil
+	marketResult, err := Calculate(market, testSettings())
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+	if marketResult.InputHash == result.InputHash || marketResult.AcquisitionMode != AcquisitionModeMarket {
+		t.Fatalf("auction terms must change the input hash")
+	}
+}
+
+func TestCalculateAuctionMaxBidBelowMinimumBidIsNoGo(t *testing.T) {
+	result, err := Calculate(auctionInputs(400000), testSettings())
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+
+	if result.Decision != DecisionNoGo || !hasReason(result.ReasonCodes, ReasonMaxBidBelowMinimumBid) {
+		t.Fatalf("decision=%s reasons=%v want NO_GO with MAX_BID_BELOW_MINIMUM_BID", result.Decision, result.ReasonCodes)
+	}
+	if result.Auction.BidRoom >= 0 {
+		t.Fatalf("bid_room=%.2f want negative", result.Auction.BidRoom)
+	}
+}
@@ -52,6 +52,8 @@ type ConfidenceInput struct {
 	RiskScore            float64
 	HasNeighborhood      bool
 	HasArea              bool
+	Market               *MarketEvidence // drives market coverage when set
+	SaleAboveMarketP75   bool
 }
 
 func CalculateConfidence(input ConfidenceInput, weights ConfidenceWeights) (float64, ConfidenceComponents) {
@@ -68,12 +70,17 @@ func CalculateConfidence(input ConfidenceInput, weights ConfidenceWeights) (floa
 	case input.RenovationToAskRatio > 0.25:
 		economicConsistency -= 0.15
 	}
+	if input.SaleAboveMarketP75 {
+		economicConsistency -= 0.25
+	}
 	economicConsistency = clamp01(economicConsistency)
 
 	riskSignals := 1.0 - clamp01(input.RiskScore/100.0)
 
 	marketCoverage := 0.60
 	switch {
+	case input.Market != nil:
+		marketCoverage = marketCoverageScore(*input.Market, input.HasArea)
 	case input.HasNeighborhood && input.HasArea:
 		marketCoverage = 0.80
 	case input.HasNeighborhood || input.HasArea:
@@ -19,66 +19,46 @@ func Calculate(inputs ProspectInputs, settings WorkspaceSettings) (CalculationRe
 		weights = DefaultWeights()
 	}
 
-	defaultsUsed := make([]string, 0)
-	assumptions := make([]string, 0)
-
-	holdMonths := 6
-	if inputs.HoldMonths != nil && *inputs.HoldMonths > 0 {
-		holdMonths = *inputs.HoldMonths
-	} else {
-		defaultsUsed = append(defaultsUsed, "Prazo da operação (meses)")
-		assumptions = append(assumptions, "Prazo da operação assumido em 6 meses (padrão)")
-	}
-
-	otherCostsEstimate := float64(0)
-	if inputs.OtherCostsEstimate != nil {
-		otherCostsEstimate = *inputs.OtherCostsEstimate
-	} else {
-		defaultsUsed = append(defaultsUsed, "Outros custos estimados")
-		assumptions = append(assumptions, "Outros custos assumidos em R$ 0,00 (padrão)")
-	}
-
-	condoFee := float64(0)
-	if inputs.CondoFee != nil {
-		condoFee = *inputs.CondoFee
-	} else {
-		defaultsUsed = append(defaultsUsed, "Condomínio mensal")
-	}
-
-	iptu := float64(0)
-	if inputs.IPTU != nil {
-		iptu = *inputs.IPTU
-	} else {
-		defaultsUsed = append(defaultsUsed, "IPTU anual")
-	}
+	basis := resolveCostBasis(inputs)
+	defaultsUsed := basis.DefaultsUsed
+	assumptions := basis.Assumptions
+	holdMonths := basis.HoldMonths
+	otherCostsEstimate := basis.OtherCosts
+	condoFee := basis.CondoFee
+	iptu := basis.IPTU
+	combinedOtherCosts := basis.CombinedOtherCosts
 
 	asking := *inputs.AskingPrice
 	expectedSale := *inputs.ExpectedSalePrice
 	renovation := *inputs.RenovationCostEstimate
 
-	carryCost := (condoFee + (iptu / 12.0)) * float64(holdMonths)
-	combinedOtherCosts := otherCostsEstimate + carryCost
-	if carryCost > 0 {
-		assumptions = append(assumptions, "Custos de carregamento (condomínio + IPTU proporcional) incluídos na análise")
-	}
-
-	ceilingOffer := calculateCeilingOffer(asking, expectedSale, renovation, combinedOtherCosts, settings)
+	auction := inputs.Auction
+	ceilingOffer := calculateCeilingOffer(asking, expectedSale, renovation, combinedOtherCosts, holdMonths, auction, settings)
 	aggressiveOffer, recommendedOffer := calculateOffers(asking, ceilingOffer)
+	if auction != nil {
+		aggressiveOffer, recommendedOffer = calculateAuctionBids(asking, ceilingOffer)
+	}
 
-	scenarioAggressive := calculateScenario(ScenarioAggressive, aggressiveOffer, expectedSale, renovation, combinedOtherCosts, settings.CashSettings)
-	scenarioRecommended := calculateScenario(ScenarioRecommended, recommendedOffer, expectedSale, renovation, combinedOtherCosts, settings.CashSettings)
-	scenarioCeiling := calculateScenario(ScenarioCeiling, ceilingOffer, expectedSale, renovation, combinedOtherCosts, settings.CashSettings)
+	scenarioAggressive := calculateScenario(ScenarioAggressive, aggressiveOffer, expectedSale, renovation, combinedOtherCosts, holdMonths, auction, settings.CashSettings)
+	scenarioRecommended := calculateScenario(ScenarioRecommended, recommendedOffer, expectedSale, renovation, combinedOtherCosts, holdMonths, auction, settings.CashSettings)
+	scenarioCeiling := calculateScenario(ScenarioCeiling, ceilingOffer, expectedSale, renovation, combinedOtherCosts, holdMonths, auction, settings.CashSettings)
 
 	renovationToAskRatio := 0.0
 	if asking > 0 {
 		renovationToAskRatio = renovation / asking
 	}
-	riskScore := calculateRiskScore(inputs, renovationToAskRatio, holdMonths)
+	riskScore := calculateRiskScore(inputs, renovationToAskRatio, holdMonths+basis.EvictionDelayMonths)
 
-	optimisticSale := settings.MaxSaleToAskRatio > 0 && expectedSale > (asking*settings.MaxSaleToAskRatio)
+	optimisticSale := isOptimisticSale(asking, expectedSale, settings)
 	if optimisticSale {
 		assumptions = append(assumptions, "Preço de venda esperado acima do limite configurado para validação")
 	}
+	hasNeighborhood := inputs.Neighborhood != nil && strings.TrimSpace(*inputs.Neighborhood) != ""
+	market := buildMarketCoverage(inputs.Market, expectedSale, inputs.AreaUsable)
+	saleAboveMarketP75 := market != nil && market.SaleAboveMarketP75
+	if saleAboveMarketP75 {
+		assumptions = append(assumptions, "Preço de venda esperado acima do P75 de R$/m² das transações do bairro")
+	}
 
 	confidence, confidenceBreakdown := CalculateConfidence(ConfidenceInput{
 		CriticalPresent:      countCriticalPresent(inputs),
@@ -88,58 +68,43 @@ func Calculate(inputs ProspectInputs, settings WorkspaceSettings) (CalculationRe
 		OptimisticSalePrice:  optimisticSale,
 		RenovationToAskRatio: renovationToAskRatio,
 		RiskScore:            riskScore,
-		HasNeighborhood:      inputs.Neighborhood != nil && strings.TrimSpace(*inputs.Neighborhood) != "",
+		HasNeighborhood:      hasNeighborhood,
 		HasArea:              inputs.AreaUsable != nil && *inputs.AreaUsable > 0,
+		Market:               inputs.Market,
+		SaleAboveMarketP75:   saleAboveMarketP75,
 	}, weights)
 
 	confidenceBucket := BucketFromConfidence(confidence)
-	if optimisticSale && confidenceBucket == ConfidenceBucketHigh {
+	if (optimisticSale || saleAboveMarketP75) && confidenceBucket == ConfidenceBucketHigh {
 		confidenceBucket = ConfidenceBucketMedium
 	}
 
-	reasonSet := make(map[ReasonCode]struct{})
-	if optimisticSale {
-		reasonSet[ReasonOptimisticSalePriceEstimate] = struct{}{}
-	}
-	if scenarioRecommended.Margin < settings.MinMarginPct {
-		reasonSet[ReasonLowMargin] = struct{}{}
-	}
-	if scenarioRecommended.NetProfit < settings.MinNetProfitBRL {
-		reasonSet[ReasonLowNetProfit] = struct{}{}
-	}
-	if confidence < settings.MinConfidence {
-		reasonSet[ReasonLowDataConfidence] = struct{}{}
-	}
-	if riskScore > settings.MaxRiskScore {
-		reasonSet[ReasonHighRenovationRisk] = struct{}{}
-	}
-	if scenarioRecommended.BreakEvenSalePrice >= expectedSale {
-		reasonSet[ReasonUnfavorableBreakEven] = struct{}{}
-	}
-	if inputs.Neighborhood == nil || strings.TrimSpace(*inputs.Neighborhood) == "" {
-		reasonSet[ReasonMarketSampleTooLow] = struct{}{}
-	}
+	rules, invalidRules := effectiveDecisionRules(settings.DecisionRules)
+	if invalidRules {
+		assumptions = append(assumptions, "Regras de decisão inválidas; utilizadas as regras padrão")
+	}
+	scenarios := []Scenario{scenarioAggressive, scenarioRecommended, scenarioCeiling}
+	evaluation := evaluateDecisionRules(rules, decisionFacts{
+		Inputs:         inputs,
+		Basis:          basis,
+		Scenarios:      scenarios,
+		Confidence:     confidence,
+		RiskScore:      riskScore,
+		DefaultsCount:  countDefaultsUsed(inputs),
+		OptimisticSale: optimisticSale,
+		Market:         market,
+		MarketLow:      marketSampleTooLow(inputs.Market, hasNeighborhood),
+		Settings:       settings,
+	})
 
-	decision := DecisionReview
-	if scenarioCeiling.Margin < settings.MinMarginPct || scenarioCeiling.NetProfit < settings.MinNetProfitBRL || riskScore > settings.MaxRiskScore {
-		decision = DecisionNoGo
-	} else if scenarioRecommended.Margin >= settings.MinMarginPct &&
-		scenarioRecommended.NetProfit >= settings.MinNetProfitBRL &&
-		confidence >= settings.MinConfidence &&
-		riskScore <= settings.MaxRiskScore {
-		decision = DecisionGO
-	}
+	decision := evaluation.Decision
+	reasonSet := evaluation.Reasons
 	if decision == DecisionReview && len(reasonSet) == 0 {
 		reasonSet[ReasonLowDataConfidence] = struct{}{}
 	}
 
 	reasonCodes := orderedReasonCodes(reasonSet)
-	reasonLabels := make([]string, 0, len(reasonCodes))
-	for _, code := range reasonCodes {
-		if label, ok := ReasonLabelByCode[code]; ok {
-			reasonLabels = append(reasonLabels, label)
-		}
-	}
+	reasonLabels := evaluation.reasonLabels(reasonCodes)
 
 	inputHash, err := HashInputSnapshot(InputSnapshot{
 		AskingPrice:            inputs.AskingPrice,
@@ -153,6 +118,8 @@ func Calculate(inputs ProspectInputs, settings WorkspaceSettings) (CalculationRe
 		OfferPrice:             inputs.OfferPrice,
 		Neighborhood:           inputs.Neighborhood,
 		FlipScore:              inputs.FlipScore,
+		Market:                 inputs.Market,
+		Auction:                inputs.Auction,
 	})
 	if err != nil {
 		return CalculationResult{}, err
@@ -164,6 +131,7 @@ func Calculate(inputs ProspectInputs, settings WorkspaceSettings) (CalculationRe
 		RegistryRate:            settings.CashSettings.RegistryRate,
 		BrokerRate:              settings.CashSettings.BrokerRate,
 		PJTaxRate:               settings.CashSettings.PJTaxRate,
+		Tax:                     TaxSettingsSnapshot(settings.CashSettings.Tax),
 		MinMarginPct:            settings.MinMarginPct,
 		MinNetProfitBRL:         settings.MinNetProfitBRL,
 		MinConfidence:           settings.MinConfidence,
@@ -171,6 +139,7 @@ func Calculate(inputs ProspectInputs, settings WorkspaceSettings) (CalculationRe
 		MaxSaleToAskRatio:       settings.MaxSaleToAskRatio,
 		GenerateRateLimitPerMin: settings.GenerateRateLimitPerMin,
 		ConfidenceWeights:       weights.ToMap(),
+		DecisionRules:           DecisionRulesSnapshot(settings.DecisionRules),
 	})
 	if err != nil {
 		return CalculationResult{}, err
@@ -186,15 +155,101 @@ func Calculate(inputs ProspectInputs, settings WorkspaceSettings) (CalculationRe
 		RiskScore:           riskScore,
 		Assumptions:         dedupeStrings(assumptions),
 		DefaultsUsed:        dedupeStrings(defaultsUsed),
-		Scenarios:           []Scenario{scenarioAggressive, scenarioRecommended, scenarioCeiling},
+		Scenarios:           scenarios,
 		InputHash:           inputHash,
 		SettingsHash:        settingsHash,
 		ConfidenceBreakdown: confidenceBreakdown,
+		DecisionRules:       evaluation.Outcomes,
+		Market:              market,
+		AcquisitionMode:     AcquisitionModeMarket,
+	}
+	if auction != nil {
+		result.AcquisitionMode = AcquisitionModeAuction
+		result.Auction = buildAuctionSummary(inputs, basis, ceilingOffer, settings)
 	}
 	result.MessageTemplates = BuildMessageTemplates(result)
 	return result, nil
 }
 
+// costBasis holds the hold period and non-acquisition costs shared by every scenario,
+// after defaults are applied
+type costBasis struct {
+	HoldMonths          int
+	OtherCosts          float64
+	CondoFee            float64
+	IPTU                float64
+	CombinedOtherCosts  float64 // other costs + carry (condo + prorated IPTU over the hold)
+	EvictionDelayMonths int     // auction only: months until possession, carried on top of the hold
+	DefaultsUsed        []string
+	Assumptions         []string
+}
+
+func resolveCostBasis(inputs ProspectInputs) costBasis {
+	defaultsUsed := make([]string, 0)
+	assumptions := make([]string, 0)
+
+	holdMonths := 6
+	if inputs.HoldMonths != nil && *inputs.HoldMonths > 0 {
+		holdMonths = *inputs.HoldMonths
+	} else {
+		defaultsUsed = append(defaultsUsed, "Prazo da operação (meses)")
+		assumptions = append(assumptions, "Prazo da operação assumido em 6 meses (padrão)")
+	}
+
+	otherCostsEstimate := float64(0)
+	if inputs.OtherCostsEstimate != nil {
+		otherCostsEstimate = *inputs.OtherCostsEstimate
+	} else {
+		defaultsUsed = append(defaultsUsed, "Outros custos estimados")
+		assumptions = append(assumptions, "Outros custos assumidos em R$ 0,00 (padrão)")
+	}
+
+	condoFee := float64(0)
+	if inputs.CondoFee != nil {
+		condoFee = *inputs.CondoFee
+	} else {
+		defaultsUsed = append(defaultsUsed, "Condomínio mensal")
+	}
+
+	iptu := float64(0)
+	if inputs.IPTU != nil {
+		iptu = *inputs.IPTU
+	} else {
+		defaultsUsed = append(defaultsUsed, "IPTU anual")
+	}
+
+	evictionDelay := 0
+	if inputs.Auction != nil {
+		evictionDelay = inputs.Auction.EvictionDelayMonths
+		assumptions = append(assumptions, "Aquisição em leilão: comissão do leiloeiro, débitos assumidos e desocupação incluídos nos custos")
+		if evictionDelay > 0 {
+			assumptions = append(assumptions, "Prazo de desocupação somado ao prazo da operação")
+		}
+	}
+
+	carryCost := (condoFee + (iptu / 12.0)) * float64(holdMonths+evictionDelay)
+	combinedOtherCosts := otherCostsEstimate + carryCost
+	if carryCost > 0 {
+		assumptions = append(assumptions, "Custos de carregamento (condomínio + IPTU proporcional) incluídos na análise")
+	}
+
+	return costBasis{
+		HoldMonths:          holdMonths,
+		OtherCosts:          otherCostsEstimate,
+		CondoFee:            condoFee,
+		IPTU:                iptu,
+		CombinedOtherCosts:  combinedOtherCosts,
+		EvictionDelayMonths: evictionDelay,
+		DefaultsUsed:        defaultsUsed,
+		Assumptions:         assumptions,
+	}
+}
+
+// isOptimisticSale reports whether the expected sale is above the configured sale/ask ratio
+func isOptimisticSale(asking, expectedSale float64, settings WorkspaceSettings) bool {
+	return settings.MaxSaleToAskRatio > 0 && expectedSale > (asking*settings.MaxSaleToAskRatio)
+}
+
 func missingCriticalInputs(inputs ProspectInputs) []string {
 	missing := make([]string, 0, 4)
 	if inputs.AskingPrice == nil || *inputs.AskingPrice <= 0 {
@@ -212,11 +267,21 @@ func missingCriticalInputs(inputs ProspectInputs) []string {
 	return missing
 }
 
-func calculateCeilingOffer(asking, salePrice, renovation, otherCosts float64, settings WorkspaceSettings) float64 {
+// calculateCeilingOffer returns the highest offer that still meets the minimum net profit
+// and margin, capped at the asking price. At an auction the asking price is only the
+// minimum bid, so the ceiling is the maximum bid, capped at the expected sale price.
+func calculateCeilingOffer(asking, salePrice, renovation, otherCosts float64, holdMonths int, auction *viability.AuctionTerms, settings WorkspaceSettings) float64 {
 	if asking <= 0 {
 		return 0
 	}
 
+	if auction != nil {
+		return solveCeilingOffer(salePrice, salePrice, renovation, otherCosts, holdMonths, auction, settings)
+	}
+	if !settings.CashSettings.Tax.IsFlat() {
+		return solveCeilingOffer(asking, salePrice, renovation, otherCosts, holdMonths, nil, settings)
+	}
+
 	acquisitionFactor := 1.0 + settings.CashSettings.ITBIRate + settings.CashSettings.RegistryRate
 	if acquisitionFactor <= 0 {
 		return round2(asking)
@@ -241,6 +306,46 @@ func calculateCeilingOffer(asking, salePrice, renovation, otherCosts float64, se
 	return round2(ceiling)
 }
 
+// solveCeilingOffer finds the highest offer (up to limit) that still meets the minimum
+// net profit and margin. Used for tax regimes whose tax is not a flat share of the profit
+// and for auctions, where the closed form above does not apply. Net profit falls as the
+// offer rises.
+func solveCeilingOffer(limit, salePrice, renovation, otherCosts float64, holdMonths int, auction *viability.AuctionTerms, settings WorkspaceSettings) float64 {
+	meetsTargets := func(offer float64) bool {
+		outputs := viability.CalculateCash(viability.CashInputs{
+			PurchasePrice:  &offer,
+			RenovationCost: &renovation,
+			OtherCosts:     &otherCosts,
+			SalePrice:      &salePrice,
+			HoldMonths:     &holdMonths,
+			Auction:        auction,
+		}, settings.CashSettings)
+		margin := float64(0)
+		if salePrice > 0 {
+			margin = (outputs.NetProfit / salePrice) * 100.0
+		}
+		return outputs.NetProfit >= settings.MinNetProfitBRL && margin >= settings.MinMarginPct
+	}
+
+	if meetsTargets(limit) {
+		return round2(limit)
+	}
+	if !meetsTargets(0) {
+		return 0
+	}
+
+	low, high := 0.0, limit
+	for high-low > 0.01 {
+		mid := (low + high) / 2
+		if meetsTargets(mid) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return round2(low)
+}
+
 func calculateOffers(asking, ceiling float64) (float64, float64) {
 	if ceiling <= 0 {
 		return 0, 0
@@ -269,18 +374,66 @@ func calculateOffers(asking, ceiling float64) (float64, float64) {
 	return round2(aggressive), round2(recommended)
 }
 
-func calculateScenario(key ScenarioKey, offerPrice, salePrice, renovation, otherCosts float64, cashSettings viability.CashSettings) Scenario {
+// calculateAuctionBids places the bids of an auction prospect: the aggressive bid is the
+// minimum bid and the recommended one sits halfway to the maximum bid. When the maximum
+// bid is below the minimum bid every scenario stays at the maximum bid.
+func calculateAuctionBids(minimumBid, maxBid float64) (float64, float64) {
+	if maxBid <= 0 {
+		return 0, 0
+	}
+	if maxBid <= minimumBid {
+		return round2(maxBid), round2(maxBid)
+	}
+	return round2(minimumBid), round2(minimumBid + (maxBid-minimumBid)*0.5)
+}
+
+// buildAuctionSummary reports the bidding range of an auction prospect, including the
+// maximum bid for the minimum margin alone
+func buildAuctionSummary(inputs ProspectInputs, basis costBasis, maxBid float64, settings WorkspaceSettings) *AuctionSummary {
+	renovation := *inputs.RenovationCostEstimate
+	otherCosts := basis.CombinedOtherCosts
+	hold := basis.HoldMonths
+	marginBid, _ := viability.MaxAuctionBid(viability.CashInputs{
+		RenovationCost: &renovation,
+		OtherCosts:     &otherCosts,
+		SalePrice:      inputs.ExpectedSalePrice,
+		HoldMonths:     &hold,
+		Auction:        inputs.Auction,
+	}, settings.CashSettings, settings.MinMarginPct)
+
+	paymentTerms := viability.AuctionPaymentCash
+	if inputs.Auction.IsInstallment() {
+		paymentTerms = viability.AuctionPaymentInstallments
+	}
+	return &AuctionSummary{
+		MinimumBid:          round2(*inputs.AskingPrice),
+		MaxBid:              maxBid,
+		MaxBidForMargin:     marginBid.Value,
+		MaxBidFeasible:      marginBid.Feasible,
+		TargetMarginPct:     settings.MinMarginPct,
+		BidRoom:             round2(maxBid - *inputs.AskingPrice),
+		PaymentTerms:        paymentTerms,
+		EvictionDelayMonths: basis.EvictionDelayMonths,
+		EffectiveHoldMonths: hold + basis.EvictionDelayMonths,
+	}
+}
+
+func calculateScenario(key ScenarioKey, offerPrice, salePrice, renovation, otherCosts float64, holdMonths int, auction *viability.AuctionTerms, cashSettings viability.CashSettings) Scenario {
 	purchase := offerPrice
 	renovationCost := renovation
 	otherCostsValue := otherCosts
 	sale := salePrice
+	hold := holdMonths
 
-	outputs := viability.CalculateCash(viability.CashInputs{
+	cashInputs := viability.CashInputs{
 		PurchasePrice:  &purchase,
 		RenovationCost: &renovationCost,
 		OtherCosts:     &otherCostsValue,
 		SalePrice:      &sale,
-	}, cashSettings)
+		HoldMonths:     &hold,
+		Auction:        auction,
+	}
+	outputs := viability.CalculateCash(cashInputs, cashSettings)
 
 	margin := float64(0)
 	if salePrice > 0 {
@@ -293,19 +446,11 @@ func calculateScenario(key ScenarioKey, offerPrice, salePrice, renovation, other
 		NetProfit:          round2(outputs.NetProfit),
 		ROI:                round2(outputs.ROI),
 		Margin:             round2(margin),
-		BreakEvenSalePrice: calculateBreakEven(outputs.InvestmentTotal, cashSettings.BrokerRate, cashSettings.PJTaxRate),
-	}
-}
-
-func calculateBreakEven(investmentTotal, brokerRate, taxRate float64) float64 {
-	if investmentTotal <= 0 {
-		return 0
-	}
-	denominator := 1.0 - brokerRate - taxRate
-	if denominator <= 0 {
-		return 0
+		BreakEvenSalePrice: viability.BreakEvenSalePrice(cashInputs, cashSettings),
+		TaxValue:           outputs.PJTaxValue,
+		TaxBreakdown:       outputs.TaxBreakdown,
+		Auction:            outputs.Auction,
 	}
-	return round2(investmentTotal / denominator)
 }
 
 func calculateRiskScore(inputs ProspectInputs, renovationToAskRatio float64, holdMonths int) float64 {
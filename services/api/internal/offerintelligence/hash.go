@@ -4,6 +4,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/viability"
 )
 
 func HashInputSnapshot(snapshot InputSnapshot) (string, error) {
@@ -26,3 +28,13 @@ func hashBytes(data []byte) string {
 	sum := sha256.Sum256(data)
 	return hex.EncodeToString(sum[:])
 }
+
+// TaxSettingsSnapshot returns the tax settings to include in the settings snapshot.
+// The legacy flat regime is already captured by PJTaxRate and is omitted so existing
+// settings hashes stay stable.
+func TaxSettingsSnapshot(tax viability.TaxSettings) *viability.TaxSettings {
+	if tax.IsFlat() {
+		return nil
+	}
+	return &tax
+}
@@ -0,0 +1,99 @@
+package offerintelligence
+
+// MinMarketSampleTx is the transaction count below which the bairro sample is too thin
+// to back the expected sale price
+const MinMarketSampleTx = 5
+
+// MarketEvidence is the ITBI market data for the prospect's bairro, resolved through the
+// market ingestion alias dictionary. A nil *MarketEvidence means the lookup was not
+// available and the legacy neighborhood/area heuristic is used instead.
+type MarketEvidence struct {
+	Resolved        bool    `json:"resolved"`         // neighborhood matched a market region
+	Region          string  `json:"region"`           // canonical market region
+	MatchMethod     string  `json:"match_method"`     // dictionary or normalized
+	MatchConfidence float64 `json:"match_confidence"` // 0-1
+	PropertyClass   string  `json:"property_class"`   // apartamento or geral
+	AsOfMonth       string  `json:"as_of_month"`      // YYYY-MM
+	TxCount         int     `json:"tx_count"`         // transactions behind the aggregate
+	MedianM2        float64 `json:"median_m2"`        // median transaction price/m²
+	P75M2           float64 `json:"p75_m2"`
+}
+
+// MarketCoverage explains the market evidence used by a calculation
+type MarketCoverage struct {
+	Resolved           bool    `json:"resolved"`
+	Region             string  `json:"region,omitempty"`
+	MatchMethod        string  `json:"match_method,omitempty"`
+	MatchConfidence    float64 `json:"match_confidence"`
+	PropertyClass      string  `json:"property_class,omitempty"`
+	AsOfMonth          string  `json:"as_of_month,omitempty"`
+	TxCount            int     `json:"tx_count"`
+	MedianM2           float64 `json:"median_m2"`
+	P75M2              float64 `json:"p75_m2"`
+	SalePriceM2        float64 `json:"sale_price_m2"`         // expected sale / area
+	SaleAboveMarketP75 bool    `json:"sale_above_market_p75"` // expected sale price/m² above the bairro P75
+}
+
+// marketCoverageScore turns the market evidence into the market_coverage confidence
+// component: the sample size sets the base, scaled down for fuzzy neighborhood matches
+// and when there is no area to compare price/m² with.
+func marketCoverageScore(market MarketEvidence, hasArea bool) float64 {
+	if !market.Resolved {
+		return 0.30
+	}
+	if market.TxCount <= 0 {
+		return 0.40
+	}
+
+	score := 0.55
+	switch {
+	case market.TxCount >= 30:
+		score = 1.0
+	case market.TxCount >= 15:
+		score = 0.85
+	case market.TxCount >= MinMarketSampleTx:
+		score = 0.70
+	}
+	score *= 0.5 + 0.5*clamp01(market.MatchConfidence)
+	if !hasArea {
+		score *= 0.85
+	}
+	return clamp01(score)
+}
+
+// buildMarketCoverage compares the expected sale price/m² with the bairro distribution
+func buildMarketCoverage(market *MarketEvidence, expectedSale float64, area *float64) *MarketCoverage {
+	if market == nil {
+		return nil
+	}
+
+	coverage := &MarketCoverage{
+		Resolved:        market.Resolved,
+		Region:          market.Region,
+		MatchMethod:     market.MatchMethod,
+		MatchConfidence: market.MatchConfidence,
+		PropertyClass:   market.PropertyClass,
+		AsOfMonth:       market.AsOfMonth,
+		TxCount:         market.TxCount,
+		MedianM2:        round2(market.MedianM2),
+		P75M2:           round2(market.P75M2),
+	}
+	if area != nil && *area > 0 {
+		coverage.SalePriceM2 = round2(expectedSale / *area)
+	}
+	coverage.SaleAboveMarketP75 = market.TxCount >= MinMarketSampleTx &&
+		market.P75M2 > 0 && coverage.SalePriceM2 > market.P75M2
+	return coverage
+}
+
+// marketSampleTooLow reports whether there is too little market evidence for the bairro.
+// Without a market lookup only a blank neighborhood counts as too low.
+func marketSampleTooLow(market *MarketEvidence, hasNeighborhood bool) bool {
+	if !hasNeighborhood {
+		return true
+	}
+	if market == nil {
+		return false
+	}
+	return !market.Resolved || market.TxCount < MinMarketSampleTx
+}
@@ -0,0 +1,79 @@
+package offerintelligence
+
+import "testing"
+
+func TestCalculateMarketCoverageFromTransactionSample(t *testing.T) {
+	inputs := negotiationInputs()
+	inputs.Market = &MarketEvidence{
+		Resolved:        true,
+		Region:          "MOEMA",
+		MatchMethod:     "dictionary",
+		MatchConfidence: 1,
+		TxCount:         42,
+		MedianM2:        5200,
+		P75M2:           6100,
+	}
+
+	result, err := Calculate(inputs, testSettings())
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+	if result.ConfidenceBreakdown.MarketCoverage != 1 {
+		t.Fatalf("market_coverage=%v want=1", result.ConfidenceBreakdown.MarketCoverage)
+	}
+	if result.Market == nil || result.Market.SalePriceM2 != 5750 || result.Market.SaleAboveMarketP75 {
+		t.Fatalf("unexpected market coverage: %+v", result.Market)
+	}
+	if hasReason(result.ReasonCodes, ReasonMarketSampleTooLow) || hasReason(result.ReasonCodes, ReasonSaleAboveMarketP75) {
+		t.Fatalf("unexpected reasons: %v", result.ReasonCodes)
+	}
+}
+
+func TestCalculateFlagsThinMarketSample(t *testing.T) {
+	inputs := negotiationInputs()
+	inputs.Market = &MarketEvidence{Resolved: true, Region: "MOEMA", MatchConfidence: 0.6, TxCount: 3, MedianM2: 5200, P75M2: 5400}
+
+	result, err := Calculate(inputs, testSettings())
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+	if !hasReason(result.ReasonCodes, ReasonMarketSampleTooLow) {
+		t.Fatalf("reason_codes=%v want %s", result.ReasonCodes, ReasonMarketSampleTooLow)
+	}
+	// Too few transactions to judge the sale price against the P75
+	if hasReason(result.ReasonCodes, ReasonSaleAboveMarketP75) {
+		t.Fatalf("reason_codes=%v must not include %s", result.ReasonCodes, ReasonSaleAboveMarketP75)
+	}
+	if result.ConfidenceBreakdown.MarketCoverage != 0.44 {
+		t.Fatalf("market_coverage=%v want=0.44", result.ConfidenceBreakdown.MarketCoverage)
+	}
+}
+
+func TestCalculateFlagsSalePriceAboveMarketP75(t *testing.T) {
+	inputs := negotiationInputs()
+	inputs.Market = &MarketEvidence{Resolved: true, Region: "MOEMA", MatchConfidence: 1, TxCount: 20, MedianM2: 4800, P75M2: 5500}
+
+	withMarket, err := Calculate(inputs, testSettings())
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+	if !hasReason(withMarket.ReasonCodes, ReasonSaleAboveMarketP75) {
+		t.Fatalf("reason_codes=%v want %s", withMarket.ReasonCodes, ReasonSaleAboveMarketP75)
+	}
+	if withMarket.ConfidenceBucket == ConfidenceBucketHigh {
+		t.Fatalf("confidence_bucket=%s want not high", withMarket.ConfidenceBucket)
+	}
+
+	inputs.Market = nil
+	withoutMarket, err := Calculate(inputs, testSettings())
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+	if withMarket.InputHash == withoutMarket.InputHash {
+		t.Fatalf("market evidence must be part of the input hash")
+	}
+	if withMarket.ConfidenceBreakdown.EconomicConsistency >= withoutMarket.ConfidenceBreakdown.EconomicConsistency {
+		t.Fatalf("economic_consistency=%v want below %v",
+			withMarket.ConfidenceBreakdown.EconomicConsistency, withoutMarket.ConfidenceBreakdown.EconomicConsistency)
+	}
+}
@@ -0,0 +1,105 @@
+package offerintelligence
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// CounterEvaluation is a negotiation round re-evaluated at the round price
+type CounterEvaluation struct {
+	Price            float64       `json:"price"`
+	Decision         Decision      `json:"decision"`
+	Confidence       float64       `json:"confidence"`
+	RiskScore        float64       `json:"risk_score"`
+	ReasonCodes      []ReasonCode  `json:"reason_codes"`
+	ReasonLabels     []string      `json:"reason_labels"`
+	CeilingOffer     float64       `json:"ceiling_offer"`
+	RoomToCeiling    float64       `json:"room_to_ceiling"`     // ceiling - price; negative when the price is above the ceiling
+	RoomToCeilingPct float64       `json:"room_to_ceiling_pct"` // % of the price
+	Scenario         Scenario      `json:"scenario"`            // economics of closing at the price
+	InputHash        string        `json:"input_hash"`
+	SettingsHash     string        `json:"settings_hash"`
+	DecisionRules    []RuleOutcome `json:"decision_rules"`
+}
+
+// EvaluateCounter runs Calculate with the round price as the offer and reports whether
+// closing at that price is still GO/REVIEW/NO_GO and how much room remains to the ceiling.
+// The ceiling stays capped at the asking price unless the counter is above it; at an
+// auction the asking price is the minimum bid and the ceiling is the maximum bid.
+func EvaluateCounter(inputs ProspectInputs, settings WorkspaceSettings, price float64) (CounterEvaluation, error) {
+	if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return CounterEvaluation{}, errors.New("price must be positive")
+	}
+
+	evalInputs := inputs
+	evalInputs.OfferPrice = &price
+	if inputs.Auction == nil && (inputs.AskingPrice == nil || *inputs.AskingPrice < price) {
+		evalInputs.AskingPrice = &price
+	}
+
+	result, err := Calculate(evalInputs, settings)
+	if err != nil {
+		return CounterEvaluation{}, err
+	}
+
+	basis := resolveCostBasis(evalInputs)
+	scenario := calculateScenario(ScenarioCounter, price, *evalInputs.ExpectedSalePrice, *evalInputs.RenovationCostEstimate,
+		basis.CombinedOtherCosts, basis.HoldMonths, evalInputs.Auction, settings.CashSettings)
+
+	ceiling := valueOrZero(findScenario(result.Scenarios, ScenarioCeiling))
+	room := round2(ceiling - price)
+
+	// The decision rules run again with the counter scenario standing in for the
+	// recommended offer, so margin, profit and break-even refer to the round price
+	scenarios := make([]Scenario, 0, len(result.Scenarios))
+	for _, item := range result.Scenarios {
+		if item.Key == ScenarioRecommended {
+			item = scenario
+			item.Key = ScenarioRecommended
+		}
+		scenarios = append(scenarios, item)
+	}
+	rules, _ := effectiveDecisionRules(settings.DecisionRules)
+	evaluation := evaluateDecisionRules(rules, decisionFacts{
+		Inputs:         evalInputs,
+		Basis:          basis,
+		Scenarios:      scenarios,
+		Confidence:     result.Confidence,
+		RiskScore:      result.RiskScore,
+		DefaultsCount:  countDefaultsUsed(evalInputs),
+		OptimisticSale: isOptimisticSale(*evalInputs.AskingPrice, *evalInputs.ExpectedSalePrice, settings),
+		Market:         result.Market,
+		MarketLow:      marketSampleTooLow(evalInputs.Market, evalInputs.Neighborhood != nil && strings.TrimSpace(*evalInputs.Neighborhood) != ""),
+		Settings:       settings,
+	})
+
+	decision := evaluation.Decision
+	reasonSet := evaluation.Reasons
+	if room < 0 {
+		reasonSet[ReasonAboveCeiling] = struct{}{}
+		decision = DecisionNoGo
+	}
+	if decision == DecisionReview && len(reasonSet) == 0 {
+		reasonSet[ReasonLowDataConfidence] = struct{}{}
+	}
+
+	reasonCodes := orderedReasonCodes(reasonSet)
+	reasonLabels := evaluation.reasonLabels(reasonCodes)
+
+	return CounterEvaluation{
+		Price:            round2(price),
+		Decision:         decision,
+		Confidence:       result.Confidence,
+		RiskScore:        result.RiskScore,
+		ReasonCodes:      reasonCodes,
+		ReasonLabels:     reasonLabels,
+		CeilingOffer:     ceiling,
+		RoomToCeiling:    room,
+		RoomToCeilingPct: round2(room / price * 100),
+		Scenario:         scenario,
+		InputHash:        result.InputHash,
+		SettingsHash:     result.SettingsHash,
+		DecisionRules:    evaluation.Outcomes,
+	}, nil
+}
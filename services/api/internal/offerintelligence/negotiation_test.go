@@ -0,0 +1,66 @@
+package offerintelligence
+
+import "testing"
+
+func negotiationInputs() ProspectInputs {
+	asking := 300000.0
+	area := 80.0
+	expectedSale := 460000.0
+	renovation := 40000.0
+	holdMonths := 6
+	neighborhood := "Moema"
+	flipScore := 78
+
+	return ProspectInputs{
+		ID:                     "p1",
+		WorkspaceID:            "w1",
+		AskingPrice:            &asking,
+		AreaUsable:             &area,
+		ExpectedSalePrice:      &expectedSale,
+		RenovationCostEstimate: &renovation,
+		HoldMonths:             &holdMonths,
+		Neighborhood:           &neighborhood,
+		FlipScore:              &flipScore,
+	}
+}
+
+func TestEvaluateCounterBelowCeilingKeepsRoom(t *testing.T) {
+	eval, err := EvaluateCounter(negotiationInputs(), testSettings(), 280000)
+	if err != nil {
+		t.Fatalf("EvaluateCounter returned error: %v", err)
+	}
+
+	if eval.Decision != DecisionGO {
+		t.Fatalf("decision=%s want=%s reasons=%v", eval.Decision, DecisionGO, eval.ReasonCodes)
+	}
+	// Ceiling is capped at the asking price
+	if eval.CeilingOffer != 300000 || eval.RoomToCeiling != 20000 {
+		t.Fatalf("ceiling=%.2f room=%.2f want 300000/20000", eval.CeilingOffer, eval.RoomToCeiling)
+	}
+	if eval.Scenario.Key != ScenarioCounter || eval.Scenario.OfferPrice != 280000 {
+		t.Fatalf("unexpected scenario: %+v", eval.Scenario)
+	}
+}
+
+func TestEvaluateCounterAboveCeilingIsNoGo(t *testing.T) {
+	eval, err := EvaluateCounter(negotiationInputs(), testSettings(), 340000)
+	if err != nil {
+		t.Fatalf("EvaluateCounter returned error: %v", err)
+	}
+
+	if eval.Decision != DecisionNoGo {
+		t.Fatalf("decision=%s want=%s", eval.Decision, DecisionNoGo)
+	}
+	if eval.RoomToCeiling >= 0 {
+		t.Fatalf("room_to_ceiling=%.2f want negative", eval.RoomToCeiling)
+	}
+	if !hasReason(eval.ReasonCodes, ReasonAboveCeiling) {
+		t.Fatalf("expected ABOVE_CEILING in %v", eval.ReasonCodes)
+	}
+}
+
+func TestEvaluateCounterRejectsNonPositivePrice(t *testing.T) {
+	if _, err := EvaluateCounter(negotiationInputs(), testSettings(), 0); err == nil {
+		t.Fatalf("expected error for zero price")
+	}
+}
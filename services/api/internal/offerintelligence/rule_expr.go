@@ -0,0 +1,410 @@
+package offerintelligence
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rule conditions are small boolean expressions over the variables in RuleVariables:
+//
+//	renovation_to_ask > 0.3 AND hold_months > 9
+//	recommended.margin < min_margin_pct OR NOT has_neighborhood
+//
+// Supported: numbers, variables, + - * /, comparisons (< <= > >= == !=), AND, OR, NOT
+// and parentheses. Keywords are case-insensitive. Expressions are type-checked at
+// compile time; a division by zero yields NaN, which makes every comparison false.
+
+type exprKind int
+
+const (
+	exprNumber exprKind = iota
+	exprBool
+)
+
+func (k exprKind) String() string {
+	if k == exprBool {
+		return "boolean"
+	}
+	return "number"
+}
+
+// ruleExpr is a compiled condition. Booleans are carried as 1/0.
+type ruleExpr struct {
+	kind exprKind
+	eval func(env map[string]float64) float64
+}
+
+type exprTokenKind int
+
+const (
+	tokEOF exprTokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokTrue
+	tokFalse
+)
+
+type exprToken struct {
+	kind  exprTokenKind
+	text  string
+	value float64
+	pos   int
+}
+
+func tokenizeRuleExpr(src string) ([]exprToken, error) {
+	tokens := make([]exprToken, 0, 16)
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, exprToken{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, exprToken{kind: tokRParen, text: ")", pos: i})
+			i++
+		case strings.ContainsRune("+-*/", c):
+			tokens = append(tokens, exprToken{kind: tokOp, text: string(c), pos: i})
+			i++
+		case strings.ContainsRune("<>=!", c):
+			op := string(c)
+			if i+1 < len(src) && src[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("unexpected %q at position %d", op, i)
+			}
+			tokens = append(tokens, exprToken{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		case c >= '0' && c <= '9' || c == '.':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			value, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", src[start:i], start)
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, text: src[start:i], value: value, pos: start})
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' ||
+				src[i] >= 'a' && src[i] <= 'z' || src[i] >= 'A' && src[i] <= 'Z' || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			word := src[start:i]
+			tok := exprToken{kind: tokIdent, text: word, pos: start}
+			switch strings.ToUpper(word) {
+			case "AND":
+				tok.kind = tokAnd
+			case "OR":
+				tok.kind = tokOr
+			case "NOT":
+				tok.kind = tokNot
+			case "TRUE":
+				tok.kind = tokTrue
+			case "FALSE":
+				tok.kind = tokFalse
+			}
+			tokens = append(tokens, tok)
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF, pos: len(src)}), nil
+}
+
+type ruleExprParser struct {
+	tokens []exprToken
+	pos    int
+	vars   map[string]struct{} // variables referenced by the expression
+}
+
+// compileRuleExpr parses and type-checks a condition. It returns the variables the
+// condition references so evaluations can report their values.
+func compileRuleExpr(src string) (ruleExpr, []string, error) {
+	if strings.TrimSpace(src) == "" {
+		return ruleExpr{}, nil, fmt.Errorf("condition is empty")
+	}
+	tokens, err := tokenizeRuleExpr(src)
+	if err != nil {
+		return ruleExpr{}, nil, err
+	}
+
+	p := &ruleExprParser{tokens: tokens, vars: make(map[string]struct{})}
+	expr, err := p.parseOr()
+	if err != nil {
+		return ruleExpr{}, nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return ruleExpr{}, nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	if expr.kind != exprBool {
+		return ruleExpr{}, nil, fmt.Errorf("condition must be a comparison or boolean, got a number")
+	}
+
+	vars := make([]string, 0, len(p.vars))
+	for _, v := range RuleVariableNames() {
+		if _, ok := p.vars[v]; ok {
+			vars = append(vars, v)
+		}
+	}
+	return expr, vars, nil
+}
+
+func (p *ruleExprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *ruleExprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *ruleExprParser) parseOr() (ruleExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return ruleExpr{}, err
+	}
+	for p.peek().kind == tokOr {
+		tok := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return ruleExpr{}, err
+		}
+		if left.kind != exprBool || right.kind != exprBool {
+			return ruleExpr{}, fmt.Errorf("OR at position %d needs boolean operands", tok.pos)
+		}
+		l, r := left.eval, right.eval
+		left = ruleExpr{kind: exprBool, eval: func(env map[string]float64) float64 {
+			return boolValue(l(env) != 0 || r(env) != 0)
+		}}
+	}
+	return left, nil
+}
+
+func (p *ruleExprParser) parseAnd() (ruleExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return ruleExpr{}, err
+	}
+	for p.peek().kind == tokAnd {
+		tok := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return ruleExpr{}, err
+		}
+		if left.kind != exprBool || right.kind != exprBool {
+			return ruleExpr{}, fmt.Errorf("AND at position %d needs boolean operands", tok.pos)
+		}
+		l, r := left.eval, right.eval
+		left = ruleExpr{kind: exprBool, eval: func(env map[string]float64) float64 {
+			return boolValue(l(env) != 0 && r(env) != 0)
+		}}
+	}
+	return left, nil
+}
+
+func (p *ruleExprParser) parseNot() (ruleExpr, error) {
+	if p.peek().kind != tokNot {
+		return p.parseComparison()
+	}
+	tok := p.next()
+	operand, err := p.parseNot()
+	if err != nil {
+		return ruleExpr{}, err
+	}
+	if operand.kind != exprBool {
+		return ruleExpr{}, fmt.Errorf("NOT at position %d needs a boolean operand", tok.pos)
+	}
+	inner := operand.eval
+	return ruleExpr{kind: exprBool, eval: func(env map[string]float64) float64 {
+		return boolValue(inner(env) == 0)
+	}}, nil
+}
+
+func (p *ruleExprParser) parseComparison() (ruleExpr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return ruleExpr{}, err
+	}
+	tok := p.peek()
+	if tok.kind != tokOp || !isComparisonOp(tok.text) {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseAdditive()
+	if err != nil {
+		return ruleExpr{}, err
+	}
+	if left.kind != right.kind {
+		return ruleExpr{}, fmt.Errorf("cannot compare %s with %s at position %d", left.kind, right.kind, tok.pos)
+	}
+	if left.kind == exprBool && tok.text != "==" && tok.text != "!=" {
+		return ruleExpr{}, fmt.Errorf("%s at position %d needs numeric operands", tok.text, tok.pos)
+	}
+	if next := p.peek(); next.kind == tokOp && isComparisonOp(next.text) {
+		return ruleExpr{}, fmt.Errorf("chained comparison at position %d; use AND", next.pos)
+	}
+
+	l, r := left.eval, right.eval
+	var cmp func(a, b float64) bool
+	switch tok.text {
+	case "<":
+		cmp = func(a, b float64) bool { return a < b }
+	case "<=":
+		cmp = func(a, b float64) bool { return a <= b }
+	case ">":
+		cmp = func(a, b float64) bool { return a > b }
+	case ">=":
+		cmp = func(a, b float64) bool { return a >= b }
+	case "==":
+		cmp = func(a, b float64) bool { return a == b }
+	default:
+		cmp = func(a, b float64) bool { return a != b && !math.IsNaN(a) && !math.IsNaN(b) }
+	}
+	return ruleExpr{kind: exprBool, eval: func(env map[string]float64) float64 {
+		return boolValue(cmp(l(env), r(env)))
+	}}, nil
+}
+
+func (p *ruleExprParser) parseAdditive() (ruleExpr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return ruleExpr{}, err
+	}
+	for tok := p.peek(); tok.kind == tokOp && (tok.text == "+" || tok.text == "-"); tok = p.peek() {
+		p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return ruleExpr{}, err
+		}
+		if left, err = arithmetic(tok, left, right); err != nil {
+			return ruleExpr{}, err
+		}
+	}
+	return left, nil
+}
+
+func (p *ruleExprParser) parseMultiplicative() (ruleExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return ruleExpr{}, err
+	}
+	for tok := p.peek(); tok.kind == tokOp && (tok.text == "*" || tok.text == "/"); tok = p.peek() {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return ruleExpr{}, err
+		}
+		if left, err = arithmetic(tok, left, right); err != nil {
+			return ruleExpr{}, err
+		}
+	}
+	return left, nil
+}
+
+func (p *ruleExprParser) parseUnary() (ruleExpr, error) {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return ruleExpr{}, err
+		}
+		if operand.kind != exprNumber {
+			return ruleExpr{}, fmt.Errorf("unary minus at position %d needs a number", tok.pos)
+		}
+		inner := operand.eval
+		return ruleExpr{kind: exprNumber, eval: func(env map[string]float64) float64 { return -inner(env) }}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *ruleExprParser) parsePrimary() (ruleExpr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		value := tok.value
+		return ruleExpr{kind: exprNumber, eval: func(map[string]float64) float64 { return value }}, nil
+	case tokTrue, tokFalse:
+		value := boolValue(tok.kind == tokTrue)
+		return ruleExpr{kind: exprBool, eval: func(map[string]float64) float64 { return value }}, nil
+	case tokIdent:
+		variable, ok := RuleVariables[tok.text]
+		if !ok {
+			return ruleExpr{}, fmt.Errorf("unknown variable %q at position %d", tok.text, tok.pos)
+		}
+		p.vars[tok.text] = struct{}{}
+		name := tok.text
+		kind := exprNumber
+		if variable.Boolean {
+			kind = exprBool
+		}
+		return ruleExpr{kind: kind, eval: func(env map[string]float64) float64 { return env[name] }}, nil
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return ruleExpr{}, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return ruleExpr{}, fmt.Errorf("missing ) at position %d", closing.pos)
+		}
+		return inner, nil
+	case tokEOF:
+		return ruleExpr{}, fmt.Errorf("unexpected end of condition")
+	default:
+		return ruleExpr{}, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+func arithmetic(op exprToken, left, right ruleExpr) (ruleExpr, error) {
+	if left.kind != exprNumber || right.kind != exprNumber {
+		return ruleExpr{}, fmt.Errorf("%s at position %d needs numeric operands", op.text, op.pos)
+	}
+	l, r := left.eval, right.eval
+	var fn func(a, b float64) float64
+	switch op.text {
+	case "+":
+		fn = func(a, b float64) float64 { return a + b }
+	case "-":
+		fn = func(a, b float64) float64 { return a - b }
+	case "*":
+		fn = func(a, b float64) float64 { return a * b }
+	default:
+		fn = func(a, b float64) float64 {
+			if b == 0 {
+				return math.NaN()
+			}
+			return a / b
+		}
+	}
+	return ruleExpr{kind: exprNumber, eval: func(env map[string]float64) float64 { return fn(l(env), r(env)) }}, nil
+}
+
+func isComparisonOp(op string) bool {
+	switch op {
+	case "<", "<=", ">", ">=", "==", "!=":
+		return true
+	}
+	return false
+}
+
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,413 @@
+package offerintelligence
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DecisionRule is one declarative condition of the GO/REVIEW/NO_GO decision.
+// When the condition holds, the rule raises the decision to at least Decision
+// (REVIEW or NO_GO; empty only adds the reason) and adds Reason to the result.
+type DecisionRule struct {
+	ID       string     `json:"id"`
+	When     string     `json:"when"`
+	Decision Decision   `json:"decision,omitempty"`
+	Reason   ReasonCode `json:"reason,omitempty"`
+	Label    string     `json:"label,omitempty"` // required for custom reason codes
+}
+
+// RuleOutcome explains how a rule was evaluated for a calculation
+type RuleOutcome struct {
+	ID       string             `json:"id"`
+	When     string             `json:"when"`
+	Matched  bool               `json:"matched"`
+	Decision Decision           `json:"decision,omitempty"`
+	Reason   ReasonCode         `json:"reason,omitempty"`
+	Values   map[string]float64 `json:"values"` // referenced variables (booleans as 1/0)
+}
+
+// RuleVariable describes a variable available to rule conditions
+type RuleVariable struct {
+	Description string `json:"description"`
+	Boolean     bool   `json:"boolean"`
+}
+
+const (
+	MaxDecisionRules      = 50
+	maxRuleConditionLen   = 500
+	maxRuleReasonLabelLen = 200
+)
+
+var (
+	ruleIDPattern     = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+	reasonCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{2,63}$`)
+)
+
+// RuleVariables lists the variables rule conditions can reference. Scenario variables
+// exist for the aggressive, recommended and ceiling offers.
+var RuleVariables = func() map[string]RuleVariable {
+	vars := map[string]RuleVariable{
+		"asking_price":          {Description: "Preço pedido"},
+		"expected_sale_price":   {Description: "Preço de venda esperado"},
+		"renovation_cost":       {Description: "Custo de reforma estimado"},
+		"hold_months":           {Description: "Prazo da operação em meses (padrão aplicado)"},
+		"other_costs":           {Description: "Outros custos estimados"},
+		"condo_fee":             {Description: "Condomínio mensal"},
+		"iptu":                  {Description: "IPTU anual"},
+		"area_usable":           {Description: "Área útil (m²)"},
+		"offer_price":           {Description: "Oferta informada no prospect (0 se ausente)"},
+		"flip_score":            {Description: "Flip Score (0 se ausente)"},
+		"renovation_to_ask":     {Description: "Reforma / preço pedido"},
+		"sale_to_ask":           {Description: "Venda esperada / preço pedido"},
+		"confidence":            {Description: "Confiança calculada (0-1)"},
+		"risk_score":            {Description: "Score de risco (0-100)"},
+		"defaults_count":        {Description: "Quantidade de campos com valor padrão"},
+		"min_margin_pct":        {Description: "Margem mínima configurada (%)"},
+		"min_net_profit_brl":    {Description: "Lucro líquido mínimo configurado"},
+		"min_confidence":        {Description: "Confiança mínima configurada"},
+		"max_risk_score":        {Description: "Risco máximo configurado"},
+		"max_sale_to_ask_ratio": {Description: "Razão venda/pedido máxima configurada"},
+		"optimistic_sale":       {Description: "Venda esperada acima da razão máxima configurada", Boolean: true},
+		"has_neighborhood":      {Description: "Bairro informado", Boolean: true},
+		"has_flip_score":        {Description: "Flip Score disponível", Boolean: true},
+		"has_offer_price":       {Description: "Oferta informada no prospect", Boolean: true},
+		"has_market_data":       {Description: "Bairro com transações ITBI na base de mercado", Boolean: true},
+		"market_tx_count":       {Description: "Transações ITBI do bairro no período (0 sem dados)"},
+		"market_median_m2":      {Description: "Mediana de R$/m² do bairro (0 sem dados)"},
+		"market_p75_m2":         {Description: "P75 de R$/m² do bairro (0 sem dados)"},
+		"sale_price_m2":         {Description: "Venda esperada / área útil"},
+		"sale_above_market_p75": {Description: "R$/m² de venda esperado acima do P75 do bairro", Boolean: true},
+		"market_sample_low":     {Description: "Amostra de mercado insuficiente para o bairro", Boolean: true},
+		"is_auction":            {Description: "Aquisição em leilão (preço pedido = lance mínimo)", Boolean: true},
+		"eviction_delay_months": {Description: "Prazo de desocupação do leilão em meses (0 fora de leilão)"},
+		"auction_arrears":       {Description: "Débitos de IPTU e condomínio assumidos no leilão"},
+	}
+	for _, key := range []ScenarioKey{ScenarioAggressive, ScenarioRecommended, ScenarioCeiling} {
+		prefix := string(key) + "."
+		vars[prefix+"offer_price"] = RuleVariable{Description: "Oferta do cenário " + string(key)}
+		vars[prefix+"net_profit"] = RuleVariable{Description: "Lucro líquido do cenário " + string(key)}
+		vars[prefix+"roi"] = RuleVariable{Description: "ROI (%) do cenário " + string(key)}
+		vars[prefix+"margin"] = RuleVariable{Description: "Margem (%) do cenário " + string(key)}
+		vars[prefix+"break_even_sale_price"] = RuleVariable{Description: "Preço de venda de break-even do cenário " + string(key)}
+	}
+	return vars
+}()
+
+// RuleVariableNames returns the variable names in a stable order
+func RuleVariableNames() []string {
+	names := make([]string, 0, len(RuleVariables))
+	for name := range RuleVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultDecisionRules reproduces the built-in decision: NO_GO when even the ceiling
+// misses the targets, the auction maximum bid is below the minimum bid or the risk is
+// too high, REVIEW when the recommended offer misses
+// a target or confidence is low, GO otherwise.
+func DefaultDecisionRules() []DecisionRule {
+	return []DecisionRule{
+		{ID: "ceiling_below_targets", When: "ceiling.margin < min_margin_pct OR ceiling.net_profit < min_net_profit_brl", Decision: DecisionNoGo},
+		{ID: "max_bid_below_minimum_bid", When: "is_auction AND ceiling.offer_price < asking_price", Decision: DecisionNoGo, Reason: ReasonMaxBidBelowMinimumBid},
+		{ID: "high_risk", When: "risk_score > max_risk_score", Decision: DecisionNoGo, Reason: ReasonHighRenovationRisk},
+		{ID: "optimistic_sale", When: "optimistic_sale", Reason: ReasonOptimisticSalePriceEstimate},
+		{ID: "sale_above_market_p75", When: "sale_above_market_p75", Reason: ReasonSaleAboveMarketP75},
+		{ID: "low_margin", When: "recommended.margin < min_margin_pct", Decision: DecisionReview, Reason: ReasonLowMargin},
+		{ID: "low_net_profit", When: "recommended.net_profit < min_net_profit_brl", Decision: DecisionReview, Reason: ReasonLowNetProfit},
+		{ID: "low_confidence", When: "confidence < min_confidence", Decision: DecisionReview, Reason: ReasonLowDataConfidence},
+		{ID: "unfavorable_break_even", When: "recommended.break_even_sale_price >= expected_sale_price", Reason: ReasonUnfavorableBreakEven},
+		{ID: "market_sample_too_low", When: "market_sample_low", Reason: ReasonMarketSampleTooLow},
+	}
+}
+
+// DecisionRulesValidationError lists every problem found in a rule set
+type DecisionRulesValidationError struct {
+	Problems []string
+}
+
+func (e DecisionRulesValidationError) Error() string {
+	return "invalid decision rules: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateDecisionRules checks ids, conditions, decisions and reasons of a rule set
+func ValidateDecisionRules(rules []DecisionRule) error {
+	_, err := compileDecisionRules(rules)
+	return err
+}
+
+// DecisionRulesHash returns a stable hash of a rule set
+func DecisionRulesHash(rules []DecisionRule) string {
+	payload, _ := json.Marshal(rules)
+	return hashBytes(payload)
+}
+
+// IsDefaultDecisionRules reports whether the rules are empty or identical to the defaults
+func IsDefaultDecisionRules(rules []DecisionRule) bool {
+	return len(rules) == 0 || DecisionRulesHash(rules) == DecisionRulesHash(DefaultDecisionRules())
+}
+
+// DecisionRulesSnapshot returns the rules to include in the settings snapshot. The
+// default rule set (and an invalid one, which falls back to it) is omitted so existing
+// settings hashes stay stable.
+func DecisionRulesSnapshot(rules []DecisionRule) []DecisionRule {
+	if IsDefaultDecisionRules(rules) || ValidateDecisionRules(rules) != nil {
+		return nil
+	}
+	return rules
+}
+
+type compiledRule struct {
+	rule DecisionRule
+	expr ruleExpr
+	vars []string
+}
+
+func compileDecisionRules(rules []DecisionRule) ([]compiledRule, error) {
+	problems := make([]string, 0)
+	if len(rules) == 0 {
+		problems = append(problems, "at least one rule is required")
+	}
+	if len(rules) > MaxDecisionRules {
+		problems = append(problems, fmt.Sprintf("at most %d rules are allowed", MaxDecisionRules))
+	}
+
+	compiled := make([]compiledRule, 0, len(rules))
+	seen := make(map[string]struct{}, len(rules))
+	for i, rule := range rules {
+		name := fmt.Sprintf("rules[%d]", i)
+		if rule.ID != "" {
+			name = fmt.Sprintf("rules[%d] (%s)", i, rule.ID)
+		}
+
+		if !ruleIDPattern.MatchString(rule.ID) {
+			problems = append(problems, name+": id must be 1-64 lowercase letters, digits, _ or -")
+		} else if _, dup := seen[rule.ID]; dup {
+			problems = append(problems, name+": duplicate id")
+		}
+		seen[rule.ID] = struct{}{}
+
+		switch rule.Decision {
+		case "", DecisionReview, DecisionNoGo:
+		default:
+			problems = append(problems, name+": decision must be REVIEW, NO_GO or empty")
+		}
+		if rule.Decision == "" && rule.Reason == "" {
+			problems = append(problems, name+": a decision or a reason is required")
+		}
+		if rule.Reason != "" {
+			_, builtIn := ReasonLabelByCode[rule.Reason]
+			switch {
+			case !reasonCodePattern.MatchString(string(rule.Reason)):
+				problems = append(problems, name+": reason must be UPPER_SNAKE_CASE (3-64 chars)")
+			case rule.Reason == ReasonMissingCriticalInput:
+				problems = append(problems, name+": reason MISSING_CRITICAL_INPUT is reserved")
+			case !builtIn && strings.TrimSpace(rule.Label) == "":
+				problems = append(problems, name+": label is required for custom reason "+string(rule.Reason))
+			}
+		}
+		if len(rule.Label) > maxRuleReasonLabelLen {
+			problems = append(problems, fmt.Sprintf("%s: label must be at most %d characters", name, maxRuleReasonLabelLen))
+		}
+
+		if len(rule.When) > maxRuleConditionLen {
+			problems = append(problems, fmt.Sprintf("%s: condition must be at most %d characters", name, maxRuleConditionLen))
+			continue
+		}
+		expr, vars, err := compileRuleExpr(rule.When)
+		if err != nil {
+			problems = append(problems, name+": "+err.Error())
+			continue
+		}
+		compiled = append(compiled, compiledRule{rule: rule, expr: expr, vars: vars})
+	}
+
+	if len(problems) > 0 {
+		return nil, DecisionRulesValidationError{Problems: problems}
+	}
+	return compiled, nil
+}
+
+// decisionFacts are the values a rule set is evaluated against
+type decisionFacts struct {
+	Inputs         ProspectInputs
+	Basis          costBasis
+	Scenarios      []Scenario
+	Confidence     float64
+	RiskScore      float64
+	DefaultsCount  int
+	OptimisticSale bool
+	Market         *MarketCoverage
+	MarketLow      bool
+	Settings       WorkspaceSettings
+}
+
+func (f decisionFacts) env() map[string]float64 {
+	asking := floatOrZero(f.Inputs.AskingPrice)
+	sale := floatOrZero(f.Inputs.ExpectedSalePrice)
+	renovation := floatOrZero(f.Inputs.RenovationCostEstimate)
+	flipScore := 0.0
+	if f.Inputs.FlipScore != nil {
+		flipScore = float64(*f.Inputs.FlipScore)
+	}
+
+	env := map[string]float64{
+		"asking_price":          asking,
+		"expected_sale_price":   sale,
+		"renovation_cost":       renovation,
+		"hold_months":           float64(f.Basis.HoldMonths),
+		"other_costs":           f.Basis.OtherCosts,
+		"condo_fee":             f.Basis.CondoFee,
+		"iptu":                  f.Basis.IPTU,
+		"area_usable":           floatOrZero(f.Inputs.AreaUsable),
+		"offer_price":           floatOrZero(f.Inputs.OfferPrice),
+		"flip_score":            flipScore,
+		"renovation_to_ask":     safeDivide(renovation, asking),
+		"sale_to_ask":           safeDivide(sale, asking),
+		"confidence":            f.Confidence,
+		"risk_score":            f.RiskScore,
+		"defaults_count":        float64(f.DefaultsCount),
+		"min_margin_pct":        f.Settings.MinMarginPct,
+		"min_net_profit_brl":    f.Settings.MinNetProfitBRL,
+		"min_confidence":        f.Settings.MinConfidence,
+		"max_risk_score":        f.Settings.MaxRiskScore,
+		"max_sale_to_ask_ratio": f.Settings.MaxSaleToAskRatio,
+		"optimistic_sale":       boolValue(f.OptimisticSale),
+		"has_neighborhood":      boolValue(f.Inputs.Neighborhood != nil && strings.TrimSpace(*f.Inputs.Neighborhood) != ""),
+		"has_flip_score":        boolValue(f.Inputs.FlipScore != nil),
+		"has_offer_price":       boolValue(f.Inputs.OfferPrice != nil),
+		"market_sample_low":     boolValue(f.MarketLow),
+		"sale_price_m2":         safeDivide(sale, floatOrZero(f.Inputs.AreaUsable)),
+		"is_auction":            boolValue(f.Inputs.Auction != nil),
+	}
+	if f.Inputs.Auction != nil {
+		env["eviction_delay_months"] = float64(f.Inputs.Auction.EvictionDelayMonths)
+		env["auction_arrears"] = f.Inputs.Auction.IPTUArrears + f.Inputs.Auction.CondoArrears
+	}
+	if f.Market != nil {
+		env["has_market_data"] = boolValue(f.Market.Resolved && f.Market.TxCount > 0)
+		env["market_tx_count"] = float64(f.Market.TxCount)
+		env["market_median_m2"] = f.Market.MedianM2
+		env["market_p75_m2"] = f.Market.P75M2
+		env["sale_above_market_p75"] = boolValue(f.Market.SaleAboveMarketP75)
+	}
+	for _, scenario := range f.Scenarios {
+		prefix := string(scenario.Key) + "."
+		env[prefix+"offer_price"] = scenario.OfferPrice
+		env[prefix+"net_profit"] = scenario.NetProfit
+		env[prefix+"roi"] = scenario.ROI
+		env[prefix+"margin"] = scenario.Margin
+		env[prefix+"break_even_sale_price"] = scenario.BreakEvenSalePrice
+	}
+	return env
+}
+
+// ruleDecision is the outcome of evaluating a rule set
+type ruleDecision struct {
+	Decision Decision
+	Reasons  map[ReasonCode]struct{}
+	Labels   map[ReasonCode]string // labels of custom reasons
+	Outcomes []RuleOutcome
+}
+
+// evaluateDecisionRules runs every rule against the facts. The decision is the most
+// severe one among the matched rules, GO when none matches.
+func evaluateDecisionRules(rules []compiledRule, facts decisionFacts) ruleDecision {
+	env := facts.env()
+	out := ruleDecision{
+		Decision: DecisionGO,
+		Reasons:  make(map[ReasonCode]struct{}),
+		Labels:   make(map[ReasonCode]string),
+		Outcomes: make([]RuleOutcome, 0, len(rules)),
+	}
+
+	for _, compiled := range rules {
+		matched := compiled.expr.eval(env) != 0
+		values := make(map[string]float64, len(compiled.vars))
+		for _, name := range compiled.vars {
+			if v := env[name]; !math.IsNaN(v) && !math.IsInf(v, 0) {
+				values[name] = round2(v)
+			}
+		}
+		out.Outcomes = append(out.Outcomes, RuleOutcome{
+			ID:       compiled.rule.ID,
+			When:     compiled.rule.When,
+			Matched:  matched,
+			Decision: compiled.rule.Decision,
+			Reason:   compiled.rule.Reason,
+			Values:   values,
+		})
+		if !matched {
+			continue
+		}
+
+		if decisionSeverity(compiled.rule.Decision) > decisionSeverity(out.Decision) {
+			out.Decision = compiled.rule.Decision
+		}
+		if compiled.rule.Reason != "" {
+			out.Reasons[compiled.rule.Reason] = struct{}{}
+			if label := strings.TrimSpace(compiled.rule.Label); label != "" {
+				out.Labels[compiled.rule.Reason] = label
+			}
+		}
+	}
+	return out
+}
+
+// reasonLabels returns the labels for the ordered reason codes, preferring rule labels
+func (d ruleDecision) reasonLabels(codes []ReasonCode) []string {
+	labels := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if label, ok := d.Labels[code]; ok {
+			labels = append(labels, label)
+		} else if label, ok := ReasonLabelByCode[code]; ok {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// effectiveDecisionRules compiles the workspace rules, falling back to the defaults when
+// the workspace has none or they no longer validate (reported via the bool)
+func effectiveDecisionRules(rules []DecisionRule) ([]compiledRule, bool) {
+	if len(rules) > 0 {
+		if compiled, err := compileDecisionRules(rules); err == nil {
+			return compiled, false
+		}
+	}
+	compiled, err := compileDecisionRules(DefaultDecisionRules())
+	if err != nil {
+		panic("offerintelligence: default decision rules are invalid: " + err.Error())
+	}
+	return compiled, len(rules) > 0
+}
+
+func decisionSeverity(d Decision) int {
+	switch d {
+	case DecisionNoGo:
+		return 2
+	case DecisionReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func safeDivide(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func floatOrZero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
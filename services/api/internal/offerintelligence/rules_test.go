@@ -0,0 +1,153 @@
+package offerintelligence
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultDecisionRulesAreValid(t *testing.T) {
+	if err := ValidateDecisionRules(DefaultDecisionRules()); err != nil {
+		t.Fatalf("default rules invalid: %v", err)
+	}
+}
+
+func TestValidateDecisionRulesReportsEveryProblem(t *testing.T) {
+	err := ValidateDecisionRules([]DecisionRule{
+		{ID: "ok", When: "renovation_to_ask / asking_price > 0.3", Decision: DecisionReview},
+		{ID: "ok", When: "hold_months > 9", Decision: DecisionReview},
+		{ID: "unknown_var", When: "renovation_ratio > 0.3", Decision: DecisionReview},
+		{ID: "numeric", When: "hold_months + 1", Decision: DecisionReview},
+		{ID: "go_decision", When: "hold_months > 9", Decision: DecisionGO},
+		{ID: "custom_no_label", When: "hold_months > 9", Reason: "LONG_HOLD"},
+		{ID: "bad_syntax", When: "(hold_months > 9", Decision: DecisionReview},
+	})
+	verr, ok := err.(DecisionRulesValidationError)
+	if !ok {
+		t.Fatalf("err=%v want DecisionRulesValidationError", err)
+	}
+	wants := []string{"duplicate id", "unknown variable", "got a number", "decision must be", "label is required", "missing )"}
+	if len(verr.Problems) != len(wants) {
+		t.Fatalf("problems=%v want %d", verr.Problems, len(wants))
+	}
+	for i, want := range wants {
+		if !strings.Contains(verr.Problems[i], want) {
+			t.Fatalf("problems[%d]=%q want containing %q", i, verr.Problems[i], want)
+		}
+	}
+}
+
+func TestCalculateDefaultRulesKeepSettingsHash(t *testing.T) {
+	withoutRules, err := Calculate(negotiationInputs(), testSettings())
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+
+	settings := testSettings()
+	settings.DecisionRules = DefaultDecisionRules()
+	withDefaults, err := Calculate(negotiationInputs(), settings)
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+
+	if withoutRules.SettingsHash != withDefaults.SettingsHash {
+		t.Fatalf("explicit default rules changed the settings hash")
+	}
+	if len(withoutRules.DecisionRules) != len(DefaultDecisionRules()) {
+		t.Fatalf("decision_rules=%d want=%d", len(withoutRules.DecisionRules), len(DefaultDecisionRules()))
+	}
+	for _, outcome := range withoutRules.DecisionRules {
+		if outcome.Matched && outcome.Decision != "" {
+			t.Fatalf("rule %s raised the decision of a GO prospect", outcome.ID)
+		}
+	}
+}
+
+func TestCalculateCustomRuleAddsReviewWithCustomReason(t *testing.T) {
+	inputs := negotiationInputs()
+	renovation := 100000.0
+	holdMonths := 10
+	inputs.RenovationCostEstimate = &renovation
+	inputs.HoldMonths = &holdMonths
+
+	settings := testSettings()
+	settings.MaxRiskScore = 100
+	settings.DecisionRules = append(DefaultDecisionRules(), DecisionRule{
+		ID:       "heavy_rehab_long_hold",
+		When:     "renovation_to_ask > 0.3 AND hold_months > 9",
+		Decision: DecisionReview,
+		Reason:   "HEAVY_REHAB_LONG_HOLD",
+		Label:    "Reforma pesada com prazo longo",
+	})
+
+	result, err := Calculate(inputs, settings)
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+
+	if result.Decision != DecisionReview {
+		t.Fatalf("decision=%s want=%s reasons=%v", result.Decision, DecisionReview, result.ReasonCodes)
+	}
+	if !hasReason(result.ReasonCodes, "HEAVY_REHAB_LONG_HOLD") {
+		t.Fatalf("reason_codes=%v want HEAVY_REHAB_LONG_HOLD", result.ReasonCodes)
+	}
+	found := false
+	for _, label := range result.ReasonLabels {
+		found = found || label == "Reforma pesada com prazo longo"
+	}
+	if !found {
+		t.Fatalf("reason_labels=%v want custom label", result.ReasonLabels)
+	}
+
+	last := result.DecisionRules[len(result.DecisionRules)-1]
+	if last.ID != "heavy_rehab_long_hold" || !last.Matched {
+		t.Fatalf("unexpected outcome: %+v", last)
+	}
+	if last.Values["renovation_to_ask"] != 0.33 || last.Values["hold_months"] != 10 {
+		t.Fatalf("values=%v", last.Values)
+	}
+
+	baseline := settings
+	baseline.DecisionRules = nil
+	baseResult, err := Calculate(inputs, baseline)
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+	if baseResult.SettingsHash == result.SettingsHash {
+		t.Fatalf("custom rules must change the settings hash")
+	}
+}
+
+func TestCalculateInvalidRulesFallBackToDefaults(t *testing.T) {
+	settings := testSettings()
+	settings.DecisionRules = []DecisionRule{{ID: "broken", When: "nope > 1", Decision: DecisionNoGo}}
+
+	result, err := Calculate(negotiationInputs(), settings)
+	if err != nil {
+		t.Fatalf("Calculate returned error: %v", err)
+	}
+	if result.Decision != DecisionGO {
+		t.Fatalf("decision=%s want=%s", result.Decision, DecisionGO)
+	}
+	if len(result.DecisionRules) != len(DefaultDecisionRules()) {
+		t.Fatalf("expected default rules to be evaluated, got %+v", result.DecisionRules)
+	}
+}
+
+func TestCompileRuleExprPrecedenceAndDivision(t *testing.T) {
+	expr, vars, err := compileRuleExpr("NOT has_neighborhood OR asking_price / renovation_cost > 2 AND hold_months >= 6")
+	if err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+	if strings.Join(vars, ",") != "asking_price,has_neighborhood,hold_months,renovation_cost" {
+		t.Fatalf("vars=%v", vars)
+	}
+
+	env := map[string]float64{"has_neighborhood": 1, "asking_price": 300000, "renovation_cost": 0, "hold_months": 6}
+	if expr.eval(env) != 0 {
+		t.Fatalf("division by zero must make the comparison false")
+	}
+	env["renovation_cost"] = 100000
+	if expr.eval(env) != 1 {
+		t.Fatalf("expected AND to bind tighter than OR")
+	}
+}
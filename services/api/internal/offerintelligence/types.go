@@ -35,6 +35,9 @@ const (
 	ReasonMarketSampleTooLow          ReasonCode = "MARKET_SAMPLE_TOO_LOW"
 	ReasonUnfavorableBreakEven        ReasonCode = "UNFAVORABLE_BREAK_EVEN"
 	ReasonOptimisticSalePriceEstimate ReasonCode = "OPTIMISTIC_SALE_PRICE_ESTIMATE"
+	ReasonAboveCeiling                ReasonCode = "ABOVE_CEILING"
+	ReasonSaleAboveMarketP75          ReasonCode = "SALE_PRICE_ABOVE_MARKET_P75"
+	ReasonMaxBidBelowMinimumBid       ReasonCode = "MAX_BID_BELOW_MINIMUM_BID"
 )
 
 var ReasonLabelByCode = map[ReasonCode]string{
@@ -46,11 +49,17 @@ var ReasonLabelByCode = map[ReasonCode]string{
 	ReasonMarketSampleTooLow:          "Cobertura de mercado limitada para este prospect",
 	ReasonUnfavorableBreakEven:        "Break-even desfavorável para o preço de venda informado",
 	ReasonOptimisticSalePriceEstimate: "Preço de venda esperado parece otimista para o ticket",
+	ReasonAboveCeiling:                "Preço da rodada acima do teto de oferta",
+	ReasonSaleAboveMarketP75:          "Preço de venda esperado acima do P75 de R$/m² do bairro",
+	ReasonMaxBidBelowMinimumBid:       "Lance máximo abaixo do lance mínimo do leilão",
 }
 
 var ReasonCodeOrder = []ReasonCode{
 	ReasonMissingCriticalInput,
+	ReasonAboveCeiling,
+	ReasonMaxBidBelowMinimumBid,
 	ReasonOptimisticSalePriceEstimate,
+	ReasonSaleAboveMarketP75,
 	ReasonLowMargin,
 	ReasonLowNetProfit,
 	ReasonLowDataConfidence,
@@ -59,12 +68,19 @@ var ReasonCodeOrder = []ReasonCode{
 	ReasonUnfavorableBreakEven,
 }
 
+// Acquisition modes of a prospect
+const (
+	AcquisitionModeMarket  = "market"
+	AcquisitionModeAuction = "auction"
+)
+
 type ScenarioKey string
 
 const (
 	ScenarioAggressive  ScenarioKey = "aggressive"
 	ScenarioRecommended ScenarioKey = "recommended"
 	ScenarioCeiling     ScenarioKey = "ceiling"
+	ScenarioCounter     ScenarioKey = "counter" // closing at a negotiation round price
 )
 
 type MessageLevel string
@@ -123,6 +139,7 @@ type WorkspaceSettings struct {
 	MaxSaleToAskRatio        float64
 	GenerateRateLimitPerMin  int
 	ConfidenceWeights        ConfidenceWeights
+	DecisionRules            []DecisionRule // empty uses DefaultDecisionRules
 	FirstFullPreviewConsumed *time.Time
 	FirstFullPreviewUserID   *string
 }
@@ -141,6 +158,11 @@ type ProspectInputs struct {
 	OfferPrice             *float64
 	Neighborhood           *string
 	FlipScore              *int
+	Market                 *MarketEvidence // nil when the market lookup is unavailable
+
+	// Auction selects the auction acquisition mode; AskingPrice is then the minimum bid.
+	// nil is a market purchase.
+	Auction *viability.AuctionTerms
 }
 
 type Scenario struct {
@@ -150,6 +172,26 @@ type Scenario struct {
 	ROI                float64     `json:"roi"`
 	Margin             float64     `json:"margin"`
 	BreakEvenSalePrice float64     `json:"break_even_sale_price"`
+
+	// Tax under the workspace tax regime (TaxValue is the breakdown total)
+	TaxValue     float64                 `json:"tax_value"`
+	TaxBreakdown *viability.TaxBreakdown `json:"tax_breakdown,omitempty"`
+
+	// Auction itemizes the auction costs at the scenario bid (auction mode only)
+	Auction *viability.AuctionBreakdown `json:"auction,omitempty"`
+}
+
+// AuctionSummary explains the bidding range of an auction prospect
+type AuctionSummary struct {
+	MinimumBid          float64 `json:"minimum_bid"`           // asking price of the prospect
+	MaxBid              float64 `json:"max_bid"`               // ceiling: meets both the margin and net profit targets
+	MaxBidForMargin     float64 `json:"max_bid_for_margin"`    // highest bid for the minimum margin alone
+	MaxBidFeasible      bool    `json:"max_bid_feasible"`      // false when even a near-zero bid misses the margin
+	TargetMarginPct     float64 `json:"target_margin_pct"`     // workspace minimum margin
+	BidRoom             float64 `json:"bid_room"`              // max bid - minimum bid; negative when the auction is out of reach
+	PaymentTerms        string  `json:"payment_terms"`         // a_vista or parcelado
+	EvictionDelayMonths int     `json:"eviction_delay_months"` // added to the hold
+	EffectiveHoldMonths int     `json:"effective_hold_months"` // hold + eviction delay
 }
 
 type MessageTemplates struct {
@@ -180,35 +222,43 @@ type CalculationResult struct {
 	InputHash           string               `json:"input_hash"`
 	SettingsHash        string               `json:"settings_hash"`
 	ConfidenceBreakdown ConfidenceComponents `json:"confidence_breakdown"`
+	DecisionRules       []RuleOutcome        `json:"decision_rules"` // how each decision rule evaluated
+	Market              *MarketCoverage      `json:"market,omitempty"`
+	AcquisitionMode     string               `json:"acquisition_mode"` // market or auction
+	Auction             *AuctionSummary      `json:"auction,omitempty"`
 }
 
 type InputSnapshot struct {
-	AskingPrice            *float64 `json:"asking_price"`
-	AreaUsable             *float64 `json:"area_usable"`
-	ExpectedSalePrice      *float64 `json:"expected_sale_price"`
-	RenovationCostEstimate *float64 `json:"renovation_cost_estimate"`
-	HoldMonths             int      `json:"hold_months"`
-	OtherCostsEstimate     float64  `json:"other_costs_estimate"`
-	CondoFee               float64  `json:"condo_fee"`
-	IPTU                   float64  `json:"iptu"`
-	OfferPrice             *float64 `json:"offer_price"`
-	Neighborhood           *string  `json:"neighborhood"`
-	FlipScore              *int     `json:"flip_score"`
+	AskingPrice            *float64                `json:"asking_price"`
+	AreaUsable             *float64                `json:"area_usable"`
+	ExpectedSalePrice      *float64                `json:"expected_sale_price"`
+	RenovationCostEstimate *float64                `json:"renovation_cost_estimate"`
+	HoldMonths             int                     `json:"hold_months"`
+	OtherCostsEstimate     float64                 `json:"other_costs_estimate"`
+	CondoFee               float64                 `json:"condo_fee"`
+	IPTU                   float64                 `json:"iptu"`
+	OfferPrice             *float64                `json:"offer_price"`
+	Neighborhood           *string                 `json:"neighborhood"`
+	FlipScore              *int                    `json:"flip_score"`
+	Market                 *MarketEvidence         `json:"market,omitempty"`
+	Auction                *viability.AuctionTerms `json:"auction,omitempty"`
 }
 
 type SettingsSnapshot struct {
-	FormulaVersion          string             `json:"formula_version"`
-	ITBIRate                float64            `json:"itbi_rate"`
-	RegistryRate            float64            `json:"registry_rate"`
-	BrokerRate              float64            `json:"broker_rate"`
-	PJTaxRate               float64            `json:"pj_tax_rate"`
-	MinMarginPct            float64            `json:"offer_min_margin_pct"`
-	MinNetProfitBRL         float64            `json:"offer_min_net_profit_brl"`
-	MinConfidence           float64            `json:"offer_min_confidence"`
-	MaxRiskScore            float64            `json:"offer_max_risk_score"`
-	MaxSaleToAskRatio       float64            `json:"offer_max_sale_to_ask_ratio"`
-	GenerateRateLimitPerMin int                `json:"offer_generate_rate_limit_per_min"`
-	ConfidenceWeights       map[string]float64 `json:"offer_confidence_weights_json"`
+	FormulaVersion          string                 `json:"formula_version"`
+	ITBIRate                float64                `json:"itbi_rate"`
+	RegistryRate            float64                `json:"registry_rate"`
+	BrokerRate              float64                `json:"broker_rate"`
+	PJTaxRate               float64                `json:"pj_tax_rate"`
+	Tax                     *viability.TaxSettings `json:"tax,omitempty"`
+	MinMarginPct            float64                `json:"offer_min_margin_pct"`
+	MinNetProfitBRL         float64                `json:"offer_min_net_profit_brl"`
+	MinConfidence           float64                `json:"offer_min_confidence"`
+	MaxRiskScore            float64                `json:"offer_max_risk_score"`
+	MaxSaleToAskRatio       float64                `json:"offer_max_sale_to_ask_ratio"`
+	GenerateRateLimitPerMin int                    `json:"offer_generate_rate_limit_per_min"`
+	ConfidenceWeights       map[string]float64     `json:"offer_confidence_weights_json"`
+	DecisionRules           []DecisionRule         `json:"offer_decision_rules,omitempty"`
 }
 
 type MissingCriticalInputsError struct {
@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Bounds for interval schedules and backoff
+const (
+	MinInterval       = 15 * time.Minute
+	MaxJitter         = time.Hour
+	DefaultBackoff    = 10 * time.Minute
+	DefaultMaxBackoff = 24 * time.Hour
+)
+
+// Spec is a recurring schedule: either a cron expression or a fixed interval,
+// plus up to Jitter of random delay so replicas and jobs do not fire together
+type Spec struct {
+	Cron     string
+	Interval time.Duration
+	Jitter   time.Duration
+}
+
+// Validate checks that exactly one of Cron and Interval is set
+func (s Spec) Validate() error {
+	hasCron := strings.TrimSpace(s.Cron) != ""
+	switch {
+	case hasCron && s.Interval > 0:
+		return errors.New("schedule accepts either a cron expression or an interval, not both")
+	case !hasCron && s.Interval <= 0:
+		return errors.New("schedule requires a cron expression or an interval")
+	case s.Interval > 0 && s.Interval < MinInterval:
+		return errors.New("schedule interval must be at least 15 minutes")
+	case s.Jitter < 0 || s.Jitter > MaxJitter:
+		return errors.New("schedule jitter must be between 0 and 1 hour")
+	}
+	if hasCron {
+		if _, err := ParseCron(s.Cron); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Next returns the next run after the given time, jitter included
+func (s Spec) Next(after time.Time, rng *rand.Rand) (time.Time, error) {
+	if err := s.Validate(); err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	if s.Interval > 0 {
+		next = after.Add(s.Interval)
+	} else {
+		cron, _ := ParseCron(s.Cron)
+		next = cron.Next(after)
+		if next.IsZero() {
+			return time.Time{}, errors.New("cron expression never matches")
+		}
+	}
+	return next.Add(jitter(s.Jitter, rng)), nil
+}
+
+// Backoff returns the delay before retrying after the given number of
+// consecutive failures: base, 2*base, 4*base... capped at max
+func Backoff(failures int, base, max time.Duration) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	delay := base
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+func jitter(max time.Duration, rng *rand.Rand) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	if rng == nil {
+		return time.Duration(rand.Int63n(int64(max) + 1))
+	}
+	return time.Duration(rng.Int63n(int64(max) + 1))
+}
@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2025, time.March, 14, 10, 17, 30, 0, time.UTC) // Friday
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/30 * * * *", time.Date(2025, time.March, 14, 10, 30, 0, 0, time.UTC)},
+		{"0 6 * * *", time.Date(2025, time.March, 15, 6, 0, 0, 0, time.UTC)},
+		{"0 6 * * 1-5", time.Date(2025, time.March, 17, 6, 0, 0, 0, time.UTC)},
+		{"15 10,22 * * *", time.Date(2025, time.March, 14, 22, 15, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2025, time.March, 16, 9, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 20th or a Monday)
+		{"0 0 20 * 1", time.Date(2025, time.March, 17, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		cron, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tc.expr, err)
+		}
+		if got := cron.Next(base); !got.Equal(tc.want) {
+			t.Fatalf("Next(%q)=%s want=%s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Fatalf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	if err := (Spec{Interval: time.Hour, Jitter: 5 * time.Minute}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	invalid := []Spec{
+		{},
+		{Cron: "@daily", Interval: time.Hour},
+		{Interval: 5 * time.Minute},
+		{Cron: "@daily", Jitter: 2 * time.Hour},
+		{Cron: "bad"},
+	}
+	for _, spec := range invalid {
+		if err := spec.Validate(); err == nil {
+			t.Fatalf("expected error for %+v", spec)
+		}
+	}
+}
+
+func TestSpecNextAddsJitterWithinBounds(t *testing.T) {
+	base := time.Date(2025, time.March, 14, 10, 0, 0, 0, time.UTC)
+	spec := Spec{Interval: 6 * time.Hour, Jitter: 10 * time.Minute}
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		next, err := spec.Next(base, rng)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if next.Before(base.Add(6*time.Hour)) || next.After(base.Add(6*time.Hour+10*time.Minute)) {
+			t.Fatalf("next run %s outside jitter window", next)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		0:  0,
+		1:  10 * time.Minute,
+		2:  20 * time.Minute,
+		4:  80 * time.Minute,
+		20: DefaultMaxBackoff,
+	}
+	for failures, want := range cases {
+		if got := Backoff(failures, DefaultBackoff, DefaultMaxBackoff); got != want {
+			t.Fatalf("Backoff(%d)=%s want=%s", failures, got, want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package viability
+
+import "math"
+
+// Amortization systems supported by the schedule engine
+const (
+	AmortizationSAC   = "sac"
+	AmortizationPrice = "price"
+)
+
+// Monetary correction indexes applied to the outstanding balance
+const (
+	CorrectionNone = "none"
+	CorrectionTR   = "tr"
+	CorrectionIPCA = "ipca"
+)
+
+// AmortizationParams holds the contract terms used to build a schedule.
+// Rates are annual decimals (0.12 = 12% a.a.).
+type AmortizationParams struct {
+	System           string
+	Principal        float64
+	TermMonths       int
+	AnnualRate       float64 // nominal annual rate (taxa nominal a.a.), divided by 12
+	EffectiveRate    bool    // when true AnnualRate is effective (e.g. CET) and converted by compounding
+	MonthlyInsurance float64
+	CorrectionIndex  string
+	CorrectionRate   float64 // annual index assumption, compounded monthly
+}
+
+// AmortizationRow is a single month of the schedule
+type AmortizationRow struct {
+	MonthIndex     int     `json:"month_index"`
+	OpeningBalance float64 `json:"opening_balance"`
+	Correction     float64 `json:"correction"`
+	Interest       float64 `json:"interest"`
+	Amortization   float64 `json:"amortization"`
+	Insurance      float64 `json:"insurance"`
+	Installment    float64 `json:"installment"`
+	ClosingBalance float64 `json:"closing_balance"`
+}
+
+// AmortizationSchedule is the full month-by-month schedule for a financing contract
+type AmortizationSchedule struct {
+	System                string            `json:"system"`
+	Principal             float64           `json:"principal"`
+	TermMonths            int               `json:"term_months"`
+	MonthlyInterestRate   float64           `json:"monthly_interest_rate"`
+	CorrectionIndex       string            `json:"correction_index"`
+	MonthlyCorrectionRate float64           `json:"monthly_correction_rate"`
+	FirstInstallment      float64           `json:"first_installment"`
+	TotalInterest         float64           `json:"total_interest"`
+	TotalCorrection       float64           `json:"total_correction"`
+	TotalInsurance        float64           `json:"total_insurance"`
+	TotalPaid             float64           `json:"total_paid"`
+	Rows                  []AmortizationRow `json:"rows"`
+}
+
+// IsValidAmortizationSystem reports whether s is a supported amortization system
+func IsValidAmortizationSystem(s string) bool {
+	return s == AmortizationSAC || s == AmortizationPrice
+}
+
+// IsValidCorrectionIndex reports whether s is a supported monetary correction index
+func IsValidCorrectionIndex(s string) bool {
+	return s == CorrectionNone || s == CorrectionTR || s == CorrectionIPCA
+}
+
+// BuildAmortizationSchedule builds a SAC or Tabela Price schedule month by month.
+// Monetary correction (TR/IPCA) is applied to the balance before interest accrues,
+// and the amortization (SAC) or installment (Price) is recomputed over the remaining
+// term, as Brazilian SFH contracts do. Returns nil when the terms are incomplete.
+func BuildAmortizationSchedule(params AmortizationParams) *AmortizationSchedule {
+	if !IsValidAmortizationSystem(params.System) || params.Principal <= 0 || params.TermMonths <= 0 {
+		return nil
+	}
+
+	monthlyRate := params.AnnualRate / 12
+	if params.EffectiveRate {
+		monthlyRate = annualToMonthly(params.AnnualRate)
+	}
+
+	correctionIndex := params.CorrectionIndex
+	if correctionIndex == "" {
+		correctionIndex = CorrectionNone
+	}
+	monthlyCorrection := float64(0)
+	if correctionIndex != CorrectionNone {
+		monthlyCorrection = annualToMonthly(params.CorrectionRate)
+	}
+
+	schedule := &AmortizationSchedule{
+		System:                params.System,
+		Principal:             round2(params.Principal),
+		TermMonths:            params.TermMonths,
+		MonthlyInterestRate:   round6(monthlyRate),
+		CorrectionIndex:       correctionIndex,
+		MonthlyCorrectionRate: round6(monthlyCorrection),
+		Rows:                  make([]AmortizationRow, 0, params.TermMonths),
+	}
+
+	balance := params.Principal
+	for month := 1; month <= params.TermMonths; month++ {
+		opening := balance
+		correction := balance * monthlyCorrection
+		balance += correction
+
+		interest := balance * monthlyRate
+		remaining := params.TermMonths - month + 1
+
+		var amortization float64
+		switch params.System {
+		case AmortizationSAC:
+			amortization = balance / float64(remaining)
+		case AmortizationPrice:
+			amortization = pricePayment(balance, monthlyRate, remaining) - interest
+		}
+		if month == params.TermMonths || amortization > balance {
+			amortization = balance
+		}
+
+		installment := amortization + interest + params.MonthlyInsurance
+		balance -= amortization
+
+		schedule.Rows = append(schedule.Rows, AmortizationRow{
+			MonthIndex:     month,
+			OpeningBalance: round2(opening),
+			Correction:     round2(correction),
+			Interest:       round2(interest),
+			Amortization:   round2(amortization),
+			Insurance:      round2(params.MonthlyInsurance),
+			Installment:    round2(installment),
+			ClosingBalance: round2(balance),
+		})
+
+		schedule.TotalInterest += interest
+		schedule.TotalCorrection += correction
+		schedule.TotalInsurance += params.MonthlyInsurance
+		schedule.TotalPaid += installment
+	}
+
+	schedule.FirstInstallment = schedule.Rows[0].Installment
+	schedule.TotalInterest = round2(schedule.TotalInterest)
+	schedule.TotalCorrection = round2(schedule.TotalCorrection)
+	schedule.TotalInsurance = round2(schedule.TotalInsurance)
+	schedule.TotalPaid = round2(schedule.TotalPaid)
+
+	return schedule
+}
+
+// BalanceAt returns the outstanding balance right after the installment of the given month.
+// Month 0 (or earlier) returns the principal; months past the term return 0.
+func (s *AmortizationSchedule) BalanceAt(month int) float64 {
+	if s == nil {
+		return 0
+	}
+	if month <= 0 {
+		return s.Principal
+	}
+	if month > len(s.Rows) {
+		return 0
+	}
+	return s.Rows[month-1].ClosingBalance
+}
+
+// InterestThrough returns the interest accrued from month 1 up to and including the given month
+func (s *AmortizationSchedule) InterestThrough(month int) float64 {
+	if s == nil {
+		return 0
+	}
+	var total float64
+	for _, row := range s.Rows {
+		if row.MonthIndex > month {
+			break
+		}
+		total += row.Interest
+	}
+	return round2(total)
+}
+
+// pricePayment returns the constant installment (Tabela Price) for the balance over n months
+func pricePayment(balance, monthlyRate float64, n int) float64 {
+	if n <= 0 {
+		return balance
+	}
+	if monthlyRate == 0 {
+		return balance / float64(n)
+	}
+	return balance * monthlyRate / (1 - math.Pow(1+monthlyRate, -float64(n)))
+}
+
+// annualToMonthly converts an effective annual rate to its equivalent monthly rate
+func annualToMonthly(annual float64) float64 {
+	if annual <= -1 {
+		return 0
+	}
+	return math.Pow(1+annual, 1.0/12) - 1
+}
+
+// round6 rounds a float64 to 6 decimal places (used for rates)
+func round6(val float64) float64 {
+	return math.Round(val*1e6) / 1e6
+}
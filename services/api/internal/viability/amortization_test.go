@@ -0,0 +1,141 @@
+package viability
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildAmortizationScheduleSAC(t *testing.T) {
+	schedule := BuildAmortizationSchedule(AmortizationParams{
+		System:     AmortizationSAC,
+		Principal:  120000,
+		TermMonths: 120,
+		AnnualRate: 0.12,
+	})
+	if schedule == nil {
+		t.Fatal("expected schedule")
+	}
+	if len(schedule.Rows) != 120 {
+		t.Fatalf("rows=%d want=120", len(schedule.Rows))
+	}
+
+	first := schedule.Rows[0]
+	if first.Amortization != 1000 {
+		t.Fatalf("amortization=%.2f want=1000", first.Amortization)
+	}
+	if first.Interest != 1200 {
+		t.Fatalf("interest=%.2f want=1200", first.Interest)
+	}
+	if got := schedule.BalanceAt(12); got != 108000 {
+		t.Fatalf("balance_at_12=%.2f want=108000", got)
+	}
+	if got := schedule.BalanceAt(120); got != 0 {
+		t.Fatalf("balance_at_120=%.2f want=0", got)
+	}
+}
+
+func TestBuildAmortizationSchedulePriceConstantInstallment(t *testing.T) {
+	schedule := BuildAmortizationSchedule(AmortizationParams{
+		System:     AmortizationPrice,
+		Principal:  100000,
+		TermMonths: 12,
+		AnnualRate: 0.12,
+	})
+	if schedule == nil {
+		t.Fatal("expected schedule")
+	}
+
+	// PMT(1%, 12, 100000) = 8884.88
+	for _, row := range schedule.Rows {
+		if math.Abs(row.Installment-8884.88) > 0.02 {
+			t.Fatalf("month=%d installment=%.2f want=8884.88", row.MonthIndex, row.Installment)
+		}
+	}
+	if got := schedule.BalanceAt(12); got != 0 {
+		t.Fatalf("final balance=%.2f want=0", got)
+	}
+}
+
+func TestBuildAmortizationScheduleMonetaryCorrection(t *testing.T) {
+	base := BuildAmortizationSchedule(AmortizationParams{
+		System:     AmortizationSAC,
+		Principal:  200000,
+		TermMonths: 240,
+		AnnualRate: 0.10,
+	})
+	corrected := BuildAmortizationSchedule(AmortizationParams{
+		System:          AmortizationSAC,
+		Principal:       200000,
+		TermMonths:      240,
+		AnnualRate:      0.10,
+		CorrectionIndex: CorrectionIPCA,
+		CorrectionRate:  0.045,
+	})
+
+	if corrected.TotalCorrection <= 0 {
+		t.Fatalf("total_correction=%.2f want > 0", corrected.TotalCorrection)
+	}
+	if corrected.BalanceAt(24) <= base.BalanceAt(24) {
+		t.Fatalf("corrected balance %.2f should exceed base %.2f", corrected.BalanceAt(24), base.BalanceAt(24))
+	}
+	if got := corrected.BalanceAt(240); got != 0 {
+		t.Fatalf("final balance=%.2f want=0", got)
+	}
+}
+
+func TestCalculateFinancingDerivesRemainingDebt(t *testing.T) {
+	purchase := 500000.0
+	sale := 650000.0
+	downPct := 0.20
+	term := 360
+	rate := 0.11
+	system := AmortizationSAC
+	exit := 6
+	typedDebt := 1.0
+
+	outputs := CalculateFinancing(FinancingInputs{
+		PurchasePrice:      &purchase,
+		SalePrice:          &sale,
+		DownPaymentPercent: &downPct,
+		TermMonths:         &term,
+		InterestRate:       &rate,
+		AmortizationSystem: &system,
+		ExitMonth:          &exit,
+		RemainingDebt:      &typedDebt,
+	}, nil, FinancingSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, PJTaxRate: 0.15})
+
+	if outputs.Schedule == nil {
+		t.Fatal("expected schedule")
+	}
+	// SAC amortization of 400000 over 360 months = 1111.11 per month
+	if math.Abs(outputs.RemainingDebt-393333.33) > 0.05 {
+		t.Fatalf("remaining_debt=%.2f want≈393333.33", outputs.RemainingDebt)
+	}
+	if outputs.InterestPaidEstimate != outputs.Schedule.InterestThrough(exit) {
+		t.Fatalf("interest_paid_estimate=%.2f want=%.2f", outputs.InterestPaidEstimate, outputs.Schedule.InterestThrough(exit))
+	}
+	if outputs.PaymentsTotal <= 0 {
+		t.Fatalf("payments_total=%.2f want > 0", outputs.PaymentsTotal)
+	}
+}
+
+func TestCalculateFinancingIgnoresPaymentsAfterExit(t *testing.T) {
+	purchase := 500000.0
+	sale := 650000.0
+	downPct := 0.20
+	exit := 2
+	debt := 390000.0
+	payments := []FinancingPayment{{MonthIndex: 1, Amount: 4000}, {MonthIndex: 2, Amount: 4000}, {MonthIndex: 3, Amount: 4000}}
+
+	outputs := CalculateFinancing(FinancingInputs{
+		PurchasePrice:      &purchase,
+		SalePrice:          &sale,
+		DownPaymentPercent: &downPct,
+		ExitMonth:          &exit,
+		RemainingDebt:      &debt,
+	}, payments, FinancingSettings{})
+
+	if outputs.PaymentsTotal != 8000 {
+		t.Fatalf("payments_total=%.2f want=8000", outputs.PaymentsTotal)
+	}
+}
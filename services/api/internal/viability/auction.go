@@ -0,0 +1,154 @@
+package viability
+
+import (
+	"errors"
+	"math"
+)
+
+// Payment terms of an auction notice (edital)
+const (
+	AuctionPaymentCash         = "a_vista"
+	AuctionPaymentInstallments = "parcelado"
+)
+
+// DefaultAuctionCommissionRate is the usual auctioneer commission (5% of the bid)
+const DefaultAuctionCommissionRate = 0.05
+
+var ErrInvalidAuctionTerms = errors.New("invalid auction terms")
+
+// AuctionTerms are the costs and payment terms of buying at an auction (leilão).
+// PurchasePrice is the winning bid; the buyer also pays the auctioneer commission, assumes
+// the IPTU/condo arrears stated in the notice and bears the eviction cost and delay.
+type AuctionTerms struct {
+	CommissionRate      *float64 `json:"commission_rate,omitempty"` // over the bid; defaults to DefaultAuctionCommissionRate
+	IPTUArrears         float64  `json:"iptu_arrears"`              // IPTU debt assumed by the buyer
+	CondoArrears        float64  `json:"condo_arrears"`             // condo debt assumed by the buyer
+	EvictionCost        float64  `json:"eviction_cost"`             // legal fees and agreements with occupants
+	EvictionDelayMonths int      `json:"eviction_delay_months"`     // months until possession, added to the hold
+	PaymentTerms        string   `json:"payment_terms"`             // a_vista or parcelado; empty means a_vista
+
+	// Installment terms (parcelado only): DownPaymentPct is paid on the auction date and the
+	// balance in monthly installments corrected by InstallmentMonthlyRate. The remaining
+	// balance is paid off at the sale.
+	DownPaymentPct         float64 `json:"down_payment_pct,omitempty"` // 0.25 = 25%
+	Installments           int     `json:"installments,omitempty"`
+	InstallmentMonthlyRate float64 `json:"installment_monthly_rate,omitempty"` // 0.01 = 1% a.m.
+}
+
+// AuctionBreakdown itemizes the auction costs included in the cash outputs
+type AuctionBreakdown struct {
+	Bid                    float64 `json:"bid"`
+	CommissionRate         float64 `json:"commission_rate"`
+	Commission             float64 `json:"commission"`
+	IPTUArrears            float64 `json:"iptu_arrears"`
+	CondoArrears           float64 `json:"condo_arrears"`
+	EvictionCost           float64 `json:"eviction_cost"`
+	EvictionDelayMonths    int     `json:"eviction_delay_months"`
+	PaymentTerms           string  `json:"payment_terms"`
+	DownPayment            float64 `json:"down_payment"`
+	Installments           int     `json:"installments"`
+	InstallmentMonthlyRate float64 `json:"installment_monthly_rate"`
+	InstallmentValue       float64 `json:"installment_value"` // principal per installment
+	InstallmentsPaid       int     `json:"installments_paid"` // installments due before the sale
+	BalanceAtSale          float64 `json:"balance_at_sale"`   // paid off with the sale proceeds
+	CorrectionCost         float64 `json:"correction_cost"`   // correction on the balance until the sale
+	ExtraCosts             float64 `json:"extra_costs"`       // commission + arrears + eviction + correction
+	UpfrontCash            float64 `json:"upfront_cash"`      // due on the auction date, including ITBI and registry
+	EffectiveHoldMonths    int     `json:"effective_hold_months"`
+}
+
+// ValidateAuctionTerms checks the notice terms before they are stored
+func ValidateAuctionTerms(t AuctionTerms) error {
+	if t.CommissionRate != nil && (*t.CommissionRate < 0 || *t.CommissionRate >= 1) {
+		return errors.New("commission_rate must be between 0 and 1")
+	}
+	if t.IPTUArrears < 0 || t.CondoArrears < 0 || t.EvictionCost < 0 {
+		return errors.New("arrears and eviction_cost must be >= 0")
+	}
+	if t.EvictionDelayMonths < 0 || t.EvictionDelayMonths > 60 {
+		return errors.New("eviction_delay_months must be between 0 and 60")
+	}
+	switch t.PaymentTerms {
+	case "", AuctionPaymentCash:
+	case AuctionPaymentInstallments:
+		if t.DownPaymentPct <= 0 || t.DownPaymentPct > 1 {
+			return errors.New("down_payment_pct must be > 0 and <= 1")
+		}
+		if t.Installments < 1 || t.Installments > 120 {
+			return errors.New("installments must be between 1 and 120")
+		}
+		if t.InstallmentMonthlyRate < 0 || t.InstallmentMonthlyRate > 0.1 {
+			return errors.New("installment_monthly_rate must be between 0 and 0.1")
+		}
+	default:
+		return errors.New("payment_terms must be a_vista or parcelado")
+	}
+	return nil
+}
+
+// EffectiveCommissionRate returns the commission rate, applying the default
+func (t AuctionTerms) EffectiveCommissionRate() float64 {
+	if t.CommissionRate != nil {
+		return *t.CommissionRate
+	}
+	return DefaultAuctionCommissionRate
+}
+
+// IsInstallment reports whether the notice allows paying the bid in installments
+func (t AuctionTerms) IsInstallment() bool {
+	return t.PaymentTerms == AuctionPaymentInstallments && t.Installments > 0 && t.DownPaymentPct < 1
+}
+
+// calculateAuction computes the auction costs for a bid. holdMonths is the flip hold after
+// possession; installments due within the effective hold (eviction delay + hold) are paid
+// with correction on the outstanding balance, the rest is paid off at the sale.
+func calculateAuction(t AuctionTerms, bid float64, holdMonths int) AuctionBreakdown {
+	b := AuctionBreakdown{
+		Bid:                 round2(bid),
+		CommissionRate:      t.EffectiveCommissionRate(),
+		IPTUArrears:         round2(t.IPTUArrears),
+		CondoArrears:        round2(t.CondoArrears),
+		EvictionCost:        round2(t.EvictionCost),
+		EvictionDelayMonths: t.EvictionDelayMonths,
+		PaymentTerms:        AuctionPaymentCash,
+		DownPayment:         round2(bid),
+		EffectiveHoldMonths: holdMonths + t.EvictionDelayMonths,
+	}
+	b.Commission = round2(bid * b.CommissionRate)
+
+	if t.IsInstallment() {
+		b.PaymentTerms = AuctionPaymentInstallments
+		b.DownPayment = round2(bid * t.DownPaymentPct)
+		b.Installments = t.Installments
+		b.InstallmentMonthlyRate = t.InstallmentMonthlyRate
+
+		balance := bid - b.DownPayment
+		principal := balance / float64(t.Installments)
+		b.InstallmentValue = round2(principal)
+		b.InstallmentsPaid = int(math.Min(float64(t.Installments), float64(b.EffectiveHoldMonths)))
+
+		correction := 0.0
+		for i := 0; i < b.InstallmentsPaid; i++ {
+			correction += balance * t.InstallmentMonthlyRate
+			balance -= principal
+		}
+		b.CorrectionCost = round2(correction)
+		b.BalanceAtSale = round2(math.Max(balance, 0))
+	}
+
+	b.ExtraCosts = round2(b.Commission + b.IPTUArrears + b.CondoArrears + b.EvictionCost + b.CorrectionCost)
+	return b
+}
+
+// MaxAuctionBid returns the highest bid that still yields the target margin (% of the sale
+// price), with every auction cost recalculated at each candidate bid
+func MaxAuctionBid(inputs CashInputs, settings CashSettings, targetMarginPct float64) (SolveResult, error) {
+	if inputs.Auction == nil {
+		return SolveResult{}, ErrInvalidAuctionTerms
+	}
+	return SolveCash(inputs, settings, CashFlowParams{}, SolveRequest{
+		Variable:    SolveVariablePurchasePrice,
+		Target:      SolveTargetMargin,
+		TargetValue: targetMarginPct,
+	})
+}
@@ -0,0 +1,130 @@
+package viability
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateCashAuctionAddsCommissionArrearsAndEviction(t *testing.T) {
+	bid := 200000.0
+	renovation := 30000.0
+	sale := 400000.0
+	hold := 6
+	inputs := CashInputs{
+		PurchasePrice:  &bid,
+		RenovationCost: &renovation,
+		SalePrice:      &sale,
+		HoldMonths:     &hold,
+		Auction: &AuctionTerms{
+			IPTUArrears:         8000,
+			CondoArrears:        12000,
+			EvictionCost:        15000,
+			EvictionDelayMonths: 4,
+		},
+	}
+	settings := CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, PJTaxRate: 0.15}
+
+	outputs := CalculateCash(inputs, settings)
+	if outputs.Auction == nil {
+		t.Fatal("expected auction breakdown")
+	}
+	if outputs.Auction.Commission != 10000 {
+		t.Fatalf("commission=%.2f want=10000 (default 5%%)", outputs.Auction.Commission)
+	}
+	// 200000 + 6000 ITBI + 2000 registry + 10000 commission + 20000 arrears
+	if outputs.AcquisitionCost != 238000 {
+		t.Fatalf("acquisition_cost=%.2f want=238000", outputs.AcquisitionCost)
+	}
+	if outputs.InvestmentTotal != 283000 {
+		t.Fatalf("investment_total=%.2f want=283000", outputs.InvestmentTotal)
+	}
+	if outputs.Auction.EffectiveHoldMonths != 10 || outputs.Auction.UpfrontCash != 238000 {
+		t.Fatalf("unexpected breakdown: %+v", *outputs.Auction)
+	}
+
+	market := inputs
+	market.Auction = nil
+	if base := CalculateCash(market, settings); base.NetProfit <= outputs.NetProfit {
+		t.Fatalf("auction net_profit=%.2f should be below market net_profit=%.2f", outputs.NetProfit, base.NetProfit)
+	}
+}
+
+func TestCalculateCashAuctionInstallments(t *testing.T) {
+	bid := 200000.0
+	sale := 350000.0
+	hold := 6
+	inputs := CashInputs{
+		PurchasePrice: &bid,
+		SalePrice:     &sale,
+		HoldMonths:    &hold,
+		Auction: &AuctionTerms{
+			PaymentTerms:           AuctionPaymentInstallments,
+			DownPaymentPct:         0.25,
+			Installments:           30,
+			InstallmentMonthlyRate: 0.01,
+		},
+	}
+
+	outputs := CalculateCash(inputs, CashSettings{})
+	auction := outputs.Auction
+	if auction.DownPayment != 50000 || auction.InstallmentValue != 5000 || auction.InstallmentsPaid != 6 {
+		t.Fatalf("unexpected installments: %+v", *auction)
+	}
+	if auction.BalanceAtSale != 120000 {
+		t.Fatalf("balance_at_sale=%.2f want=120000", auction.BalanceAtSale)
+	}
+	// 1% over 150000, 145000, ..., 125000
+	if auction.CorrectionCost != 8250 {
+		t.Fatalf("correction_cost=%.2f want=8250", auction.CorrectionCost)
+	}
+
+	flow := BuildCashFlowCash(inputs, outputs, CashFlowParams{HoldMonths: hold})
+	if flow.Months[0].Acquisition != 50000 || flow.Months[1].Acquisition != 5000 || flow.Months[6].DebtPayoff != 120000 {
+		t.Fatalf("unexpected timeline: %+v", flow.Months)
+	}
+	if math.Abs(flow.Metrics.NetProfit-outputs.NetProfit) > 0.05 {
+		t.Fatalf("timeline net_profit=%.2f want=%.2f", flow.Metrics.NetProfit, outputs.NetProfit)
+	}
+}
+
+func TestMaxAuctionBidForTargetMargin(t *testing.T) {
+	renovation := 30000.0
+	sale := 400000.0
+	inputs := CashInputs{
+		RenovationCost: &renovation,
+		SalePrice:      &sale,
+		Auction:        &AuctionTerms{CondoArrears: 10000, EvictionCost: 10000, EvictionDelayMonths: 3},
+	}
+	settings := CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, PJTaxRate: 0.15}
+
+	result, err := MaxAuctionBid(inputs, settings, 15)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Feasible {
+		t.Fatal("expected feasible result")
+	}
+
+	bid := result.Value
+	inputs.PurchasePrice = &bid
+	outputs := CalculateCash(inputs, settings)
+	if margin := outputs.NetProfit / sale * 100; math.Abs(margin-15) > 0.01 {
+		t.Fatalf("margin at max bid=%.2f want=15", margin)
+	}
+
+	if _, err := MaxAuctionBid(CashInputs{SalePrice: &sale}, settings, 15); err != ErrInvalidAuctionTerms {
+		t.Fatalf("err=%v want ErrInvalidAuctionTerms", err)
+	}
+}
+
+func TestValidateAuctionTerms(t *testing.T) {
+	if err := ValidateAuctionTerms(AuctionTerms{PaymentTerms: AuctionPaymentCash}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ValidateAuctionTerms(AuctionTerms{PaymentTerms: AuctionPaymentInstallments, DownPaymentPct: 0.25}); err == nil {
+		t.Fatal("expected installments to be required")
+	}
+	if err := ValidateAuctionTerms(AuctionTerms{PaymentTerms: "boleto"}); err == nil {
+		t.Fatal("expected invalid payment terms")
+	}
+}
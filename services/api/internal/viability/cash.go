@@ -8,6 +8,10 @@ type CashInputs struct {
 	RenovationCost *float64 `json:"renovation_cost"`
 	OtherCosts     *float64 `json:"other_costs"`
 	SalePrice      *float64 `json:"sale_price"`
+	HoldMonths     *int     `json:"hold_months,omitempty"` // used by time-dependent tax rules; defaults to DefaultHoldMonths
+
+	// Auction switches to the auction acquisition mode (PurchasePrice is the winning bid); nil is a market purchase
+	Auction *AuctionTerms `json:"auction,omitempty"`
 }
 
 // CashSettings represents workspace-level settings used in calculations
@@ -16,6 +20,9 @@ type CashSettings struct {
 	RegistryRate float64 `json:"registry_rate"`
 	BrokerRate   float64 `json:"broker_rate"`
 	PJTaxRate    float64 `json:"pj_tax_rate"`
+
+	// Tax selects the tax regime; the flat PJTaxRate applies when no regime is set
+	Tax TaxSettings `json:"tax"`
 }
 
 // CashOutputs represents the calculated outputs for cash viability analysis
@@ -30,6 +37,12 @@ type CashOutputs struct {
 	NetProfit       float64 `json:"net_profit"`
 	ROI             float64 `json:"roi"`
 	IsPartial       bool    `json:"is_partial"`
+
+	// TaxBreakdown itemizes PJTaxValue under the selected tax regime
+	TaxBreakdown *TaxBreakdown `json:"tax_breakdown,omitempty"`
+
+	// Auction itemizes the auction costs (nil for a market purchase)
+	Auction *AuctionBreakdown `json:"auction,omitempty"`
 }
 
 // CalculateCash computes the cash viability outputs from inputs and settings.
@@ -57,11 +70,28 @@ func CalculateCash(inputs CashInputs, settings CashSettings) CashOutputs {
 		otherCosts = *inputs.OtherCosts
 	}
 
+	holdMonths := DefaultHoldMonths
+	if inputs.HoldMonths != nil && *inputs.HoldMonths > 0 {
+		holdMonths = *inputs.HoldMonths
+	}
+
 	// Calculate acquisition costs
 	outputs.ITBIValue = round2(purchasePrice * settings.ITBIRate)
 	outputs.RegistryValue = round2(purchasePrice * settings.RegistryRate)
 	outputs.AcquisitionCost = round2(purchasePrice + outputs.ITBIValue + outputs.RegistryValue)
 
+	// Auction: commission and assumed arrears are part of the acquisition; eviction and
+	// installment correction are extra costs, and the eviction delay extends the hold
+	if inputs.Auction != nil {
+		auction := calculateAuction(*inputs.Auction, purchasePrice, holdMonths)
+		outputs.AcquisitionCost = round2(outputs.AcquisitionCost + auction.Commission + auction.IPTUArrears + auction.CondoArrears)
+		otherCosts += auction.EvictionCost + auction.CorrectionCost
+		auction.UpfrontCash = round2(auction.DownPayment + outputs.ITBIValue + outputs.RegistryValue +
+			auction.Commission + auction.IPTUArrears + auction.CondoArrears)
+		holdMonths = auction.EffectiveHoldMonths
+		outputs.Auction = &auction
+	}
+
 	// Calculate total investment
 	outputs.InvestmentTotal = round2(outputs.AcquisitionCost + renovationCost + otherCosts)
 
@@ -69,8 +99,17 @@ func CalculateCash(inputs CashInputs, settings CashSettings) CashOutputs {
 	outputs.BrokerFee = round2(salePrice * settings.BrokerRate)
 	outputs.GrossProfit = round2(salePrice - outputs.InvestmentTotal - outputs.BrokerFee)
 
-	// Calculate taxes (PJ tax rate on gross profit, not sale price)
-	outputs.PJTaxValue = round2(outputs.GrossProfit * settings.PJTaxRate)
+	// Calculate taxes under the selected regime (flat PJ rate on gross profit by default)
+	breakdown := CalculateTax(settings.Tax, settings.PJTaxRate, TaxBase{
+		SalePrice:       salePrice,
+		SaleCosts:       outputs.BrokerFee,
+		AcquisitionCost: outputs.AcquisitionCost,
+		Improvements:    renovationCost,
+		GrossProfit:     outputs.GrossProfit,
+		HoldMonths:      holdMonths,
+	})
+	outputs.TaxBreakdown = &breakdown
+	outputs.PJTaxValue = breakdown.Total
 	outputs.NetProfit = round2(outputs.GrossProfit - outputs.PJTaxValue)
 
 	// Calculate ROI
@@ -82,6 +121,54 @@ func CalculateCash(inputs CashInputs, settings CashSettings) CashOutputs {
 	return outputs
 }
 
+// BreakEvenSalePrice returns the sale price at which the net profit is zero.
+// The flat regime keeps the closed form investment / (1 - broker - pj_tax); other regimes
+// are solved numerically since their tax is not proportional to the profit.
+// Returns 0 when the inputs are partial or no break-even exists.
+func BreakEvenSalePrice(inputs CashInputs, settings CashSettings) float64 {
+	if inputs.PurchasePrice == nil {
+		return 0
+	}
+
+	probe := inputs
+	zero := float64(0)
+	probe.SalePrice = &zero
+	investment := CalculateCash(probe, settings).InvestmentTotal
+	if investment <= 0 {
+		return 0
+	}
+
+	if settings.Tax.IsFlat() {
+		denominator := 1 - settings.BrokerRate - settings.PJTaxRate
+		if denominator <= 0 {
+			return 0
+		}
+		return round2(investment / denominator)
+	}
+
+	netProfitAt := func(sale float64) float64 {
+		probe.SalePrice = &sale
+		return CalculateCash(probe, settings).NetProfit
+	}
+
+	low, high := 0.0, investment
+	for netProfitAt(high) < 0 {
+		high *= 2
+		if high > investment*1000 {
+			return 0
+		}
+	}
+	for high-low > 0.01 {
+		mid := (low + high) / 2
+		if netProfitAt(mid) < 0 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return round2(high)
+}
+
 // round2 rounds a float64 to 2 decimal places
 func round2(val float64) float64 {
 	return math.Round(val*100) / 100
@@ -0,0 +1,369 @@
+package viability
+
+import "math"
+
+// DefaultHoldMonths is the hold period assumed when none is provided
+const DefaultHoldMonths = 6
+
+// CashFlowDraw is a dated outflow (e.g. a renovation draw) relative to the acquisition month (0)
+type CashFlowDraw struct {
+	MonthIndex int     `json:"month_index"`
+	Amount     float64 `json:"amount"`
+}
+
+// CashFlowParams holds the timing and carry assumptions used to build a cash-flow timeline
+type CashFlowParams struct {
+	HoldMonths         int            // month in which the sale closes
+	CondoFee           float64        // monthly condo fee
+	IPTU               float64        // annual IPTU, paid monthly (1/12)
+	RenovationDraws    []CashFlowDraw // timing of renovation spend; defaults to month 1 when empty
+	AnnualDiscountRate float64        // workspace discount rate for NPV (0.12 = 12% a.a.)
+}
+
+// CashFlowMonth is one month of the cash-flow timeline.
+// Components are positive amounts; NetFlow is signed (outflows negative).
+type CashFlowMonth struct {
+	MonthIndex      int     `json:"month_index"`
+	Acquisition     float64 `json:"acquisition"`
+	AcquisitionFees float64 `json:"acquisition_fees"`
+	Renovation      float64 `json:"renovation"`
+	OtherCosts      float64 `json:"other_costs"`
+	Carry           float64 `json:"carry"`
+	Financing       float64 `json:"financing"`
+	SalePrice       float64 `json:"sale_price"`
+	SaleCosts       float64 `json:"sale_costs"`
+	DebtPayoff      float64 `json:"debt_payoff"`
+	Tax             float64 `json:"tax"`
+	NetFlow         float64 `json:"net_flow"`
+	Cumulative      float64 `json:"cumulative"`
+}
+
+// CashFlowMetrics summarizes the timeline with time-aware return metrics.
+// IRR and annualized ROI are percentages; DiscountRate is a decimal like the other rates.
+type CashFlowMetrics struct {
+	HoldMonths       int      `json:"hold_months"`
+	TotalOutflow     float64  `json:"total_outflow"`
+	TotalInflow      float64  `json:"total_inflow"`
+	NetProfit        float64  `json:"net_profit"`
+	IRRMonthly       *float64 `json:"irr_monthly"`
+	IRRAnnual        *float64 `json:"irr_annual"`
+	AnnualizedROI    *float64 `json:"annualized_roi"`
+	DiscountRate     float64  `json:"discount_rate"`
+	NPV              float64  `json:"npv"`
+	PeakCapital      float64  `json:"peak_capital"`
+	PeakCapitalMonth int      `json:"peak_capital_month"`
+}
+
+// CashFlow is the month-by-month model of a flip plus its summary metrics
+type CashFlow struct {
+	Months  []CashFlowMonth `json:"months"`
+	Metrics CashFlowMetrics `json:"metrics"`
+}
+
+// BuildCashFlowCash builds the cash-flow timeline of an all-cash flip.
+// Purchase, ITBI/registry and other costs happen at month 0, renovation follows the
+// draws (scaled to the renovation budget), carry runs monthly and the sale closes at HoldMonths.
+// Returns nil when the cash outputs are partial.
+func BuildCashFlowCash(inputs CashInputs, outputs CashOutputs, params CashFlowParams) *CashFlow {
+	if outputs.IsPartial || inputs.PurchasePrice == nil || inputs.SalePrice == nil {
+		return nil
+	}
+
+	hold := normalizeHoldMonths(params.HoldMonths)
+	delay := 0
+	if outputs.Auction != nil {
+		delay = outputs.Auction.EvictionDelayMonths
+	}
+	exit := hold + delay
+	months := newCashFlowMonths(exit)
+
+	months[0].Acquisition = *inputs.PurchasePrice
+	months[0].AcquisitionFees = outputs.ITBIValue + outputs.RegistryValue
+	months[0].OtherCosts = getFloatOrZero(inputs.OtherCosts)
+	if outputs.Auction != nil {
+		applyAuction(months, *outputs.Auction)
+	}
+
+	// Renovation only starts after possession, so auction draws shift by the eviction delay
+	renovation := getFloatOrZero(inputs.RenovationCost)
+	for _, draw := range scaleDraws(params.RenovationDraws, renovation, hold) {
+		months[draw.MonthIndex+delay].Renovation += draw.Amount
+	}
+
+	applyCarry(months, params, exit)
+
+	months[exit].SalePrice = *inputs.SalePrice
+	months[exit].SaleCosts = outputs.BrokerFee
+	months[exit].Tax = timelineTax(outputs.TaxBreakdown, outputs.PJTaxValue, months)
+
+	return finalizeCashFlow(months, params.AnnualDiscountRate)
+}
+
+// BuildCashFlowFinancing builds the cash-flow timeline of a financed flip.
+// The down payment, acquisition fees and upfront bank fees happen at month 0, installments
+// follow the recorded payments (or the amortization schedule when available), and the sale
+// at the exit month pays off the remaining debt. Renovation draws are taken at face value.
+func BuildCashFlowFinancing(inputs FinancingInputs, outputs FinancingOutputs, payments []FinancingPayment, params CashFlowParams) *CashFlow {
+	if outputs.IsPartial || inputs.SalePrice == nil {
+		return nil
+	}
+
+	hold := params.HoldMonths
+	if outputs.ExitMonth > 0 {
+		hold = outputs.ExitMonth
+	}
+	hold = normalizeHoldMonths(hold)
+	months := newCashFlowMonths(hold)
+
+	months[0].Acquisition = outputs.DownPaymentValue
+	months[0].AcquisitionFees = outputs.AcquisitionFees + outputs.BankFeesTotal
+
+	recorded := make(map[int]float64, len(payments))
+	for _, p := range payments {
+		recorded[p.MonthIndex] = p.Amount
+	}
+	for month := 1; month <= hold; month++ {
+		if amount, ok := recorded[month]; ok {
+			months[month].Financing = amount
+			continue
+		}
+		if outputs.Schedule != nil && month <= len(outputs.Schedule.Rows) {
+			months[month].Financing = outputs.Schedule.Rows[month-1].Installment
+		}
+	}
+
+	for _, draw := range clampDraws(params.RenovationDraws, hold) {
+		months[draw.MonthIndex].Renovation += draw.Amount
+	}
+
+	applyCarry(months, params, hold)
+
+	months[hold].SalePrice = *inputs.SalePrice
+	months[hold].SaleCosts = outputs.BrokerFee
+	months[hold].DebtPayoff = outputs.RemainingDebt
+	if outputs.Schedule != nil {
+		// The timeline may run past the analysis exit month (none set defaults to the hold
+		// period), so the payoff is the balance left after the installments it charged
+		months[hold].DebtPayoff = outputs.Schedule.BalanceAt(hold)
+	}
+	months[hold].Tax = timelineTax(outputs.TaxBreakdown, outputs.PJTaxValue, months)
+
+	return finalizeCashFlow(months, params.AnnualDiscountRate)
+}
+
+// IRR returns the periodic internal rate of return of the flows (index = period).
+// The second return value is false when the flows have no sign change or no root was found.
+func IRR(flows []float64) (float64, bool) {
+	hasNegative, hasPositive := false, false
+	for _, f := range flows {
+		if f < 0 {
+			hasNegative = true
+		}
+		if f > 0 {
+			hasPositive = true
+		}
+	}
+	if !hasNegative || !hasPositive {
+		return 0, false
+	}
+
+	low, high := -0.99, 1.0
+	npvLow, npvHigh := NPV(flows, low), NPV(flows, high)
+	for npvLow*npvHigh > 0 && high < 1000 {
+		high *= 2
+		npvHigh = NPV(flows, high)
+	}
+	if npvLow*npvHigh > 0 {
+		return 0, false
+	}
+
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		npvMid := NPV(flows, mid)
+		if math.Abs(npvMid) < 1e-7 || (high-low)/2 < 1e-10 {
+			return mid, true
+		}
+		if npvMid*npvLow < 0 {
+			high = mid
+		} else {
+			low, npvLow = mid, npvMid
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// NPV discounts the flows at the given periodic rate (flow at index 0 is not discounted)
+func NPV(flows []float64, rate float64) float64 {
+	var total float64
+	for t, f := range flows {
+		total += f / math.Pow(1+rate, float64(t))
+	}
+	return total
+}
+
+// finalizeCashFlow rounds the months and derives the metrics from the timeline's own
+// totals, so net profit, IRR and annualized ROI describe the same deal
+func finalizeCashFlow(months []CashFlowMonth, annualDiscountRate float64) *CashFlow {
+	flows := make([]float64, len(months))
+	metrics := CashFlowMetrics{
+		HoldMonths:   len(months) - 1,
+		DiscountRate: annualDiscountRate,
+	}
+
+	var cumulative, lowest, invested float64
+	for i := range months {
+		m := &months[i]
+		outflow := m.Acquisition + m.AcquisitionFees + m.Renovation + m.OtherCosts + m.Carry + m.Financing +
+			m.SaleCosts + m.DebtPayoff + m.Tax
+		inflow := m.SalePrice
+		invested += outflow - m.SaleCosts - m.DebtPayoff - m.Tax
+
+		m.Acquisition = round2(m.Acquisition)
+		m.AcquisitionFees = round2(m.AcquisitionFees)
+		m.Renovation = round2(m.Renovation)
+		m.OtherCosts = round2(m.OtherCosts)
+		m.Carry = round2(m.Carry)
+		m.Financing = round2(m.Financing)
+		m.SalePrice = round2(m.SalePrice)
+		m.SaleCosts = round2(m.SaleCosts)
+		m.DebtPayoff = round2(m.DebtPayoff)
+		m.Tax = round2(m.Tax)
+
+		flows[i] = inflow - outflow
+		cumulative += flows[i]
+		m.NetFlow = round2(flows[i])
+		m.Cumulative = round2(cumulative)
+
+		metrics.TotalOutflow += outflow
+		metrics.TotalInflow += inflow
+		if cumulative < lowest {
+			lowest = cumulative
+			metrics.PeakCapitalMonth = m.MonthIndex
+		}
+	}
+
+	metrics.TotalOutflow = round2(metrics.TotalOutflow)
+	metrics.TotalInflow = round2(metrics.TotalInflow)
+	metrics.NetProfit = round2(cumulative)
+	metrics.PeakCapital = round2(-lowest)
+	metrics.NPV = round2(NPV(flows, annualToMonthly(annualDiscountRate)))
+
+	if irr, ok := IRR(flows); ok {
+		monthly := round2(irr * 100)
+		annual := round2((math.Pow(1+irr, 12) - 1) * 100)
+		metrics.IRRMonthly = &monthly
+		metrics.IRRAnnual = &annual
+	}
+
+	// ROI over the capital the investor put in (purchase, fees, renovation, carry and
+	// installments); what the sale itself pays out is not invested capital
+	if invested > 0 && metrics.HoldMonths > 0 && cumulative > -invested {
+		roi := cumulative / invested
+		annualized := round2((math.Pow(1+roi, 12/float64(metrics.HoldMonths)) - 1) * 100)
+		metrics.AnnualizedROI = &annualized
+	}
+
+	return &CashFlow{Months: months, Metrics: metrics}
+}
+
+// timelineTax assesses the tax on the profit the timeline actually makes. Only the flat
+// regime taxes the profit itself: the revenue based regimes and the capital gains base
+// do not deduct carry or interest, so their static tax stands. Without a breakdown the
+// static tax is kept.
+func timelineTax(breakdown *TaxBreakdown, staticTax float64, months []CashFlowMonth) float64 {
+	if breakdown == nil || breakdown.Regime != TaxRegimeFlat || len(breakdown.Items) == 0 {
+		return staticTax
+	}
+
+	var preTaxProfit float64
+	for _, m := range months {
+		preTaxProfit += m.SalePrice - (m.Acquisition + m.AcquisitionFees + m.Renovation + m.OtherCosts +
+			m.Carry + m.Financing + m.SaleCosts + m.DebtPayoff)
+	}
+	return round2(preTaxProfit * breakdown.Items[0].Rate)
+}
+
+// applyAuction replaces the month-0 purchase with the auction down payment, adds the
+// commission, arrears and eviction cost, and spreads the installments (principal plus
+// correction) until the sale, which pays off the remaining balance
+func applyAuction(months []CashFlowMonth, auction AuctionBreakdown) {
+	exit := len(months) - 1
+	months[0].Acquisition = auction.DownPayment
+	months[0].AcquisitionFees += auction.Commission + auction.IPTUArrears + auction.CondoArrears
+	months[0].OtherCosts += auction.EvictionCost
+
+	balance := auction.Bid - auction.DownPayment
+	for month := 1; month <= auction.InstallmentsPaid && month <= exit; month++ {
+		months[month].Acquisition += auction.InstallmentValue
+		months[month].Financing += balance * auction.InstallmentMonthlyRate
+		balance -= auction.InstallmentValue
+	}
+	months[exit].DebtPayoff += auction.BalanceAtSale
+}
+
+func newCashFlowMonths(hold int) []CashFlowMonth {
+	months := make([]CashFlowMonth, hold+1)
+	for i := range months {
+		months[i].MonthIndex = i
+	}
+	return months
+}
+
+func applyCarry(months []CashFlowMonth, params CashFlowParams, hold int) {
+	monthlyCarry := params.CondoFee + params.IPTU/12
+	if monthlyCarry <= 0 {
+		return
+	}
+	for month := 1; month <= hold; month++ {
+		months[month].Carry += monthlyCarry
+	}
+}
+
+// scaleDraws keeps the timing of the draws but scales their total to the budget.
+// Without draws the whole budget is spent in month 1.
+func scaleDraws(draws []CashFlowDraw, budget float64, hold int) []CashFlowDraw {
+	if budget <= 0 {
+		return nil
+	}
+
+	clamped := clampDraws(draws, hold)
+	var total float64
+	for _, d := range clamped {
+		total += d.Amount
+	}
+	if total <= 0 {
+		return []CashFlowDraw{{MonthIndex: min(1, hold), Amount: budget}}
+	}
+
+	factor := budget / total
+	for i := range clamped {
+		clamped[i].Amount *= factor
+	}
+	return clamped
+}
+
+// clampDraws moves draws outside the hold window to its edges and drops non-positive ones
+func clampDraws(draws []CashFlowDraw, hold int) []CashFlowDraw {
+	out := make([]CashFlowDraw, 0, len(draws))
+	for _, d := range draws {
+		if d.Amount <= 0 {
+			continue
+		}
+		month := d.MonthIndex
+		if month < 0 {
+			month = 0
+		}
+		if month > hold {
+			month = hold
+		}
+		out = append(out, CashFlowDraw{MonthIndex: month, Amount: d.Amount})
+	}
+	return out
+}
+
+func normalizeHoldMonths(hold int) int {
+	if hold <= 0 {
+		return DefaultHoldMonths
+	}
+	return hold
+}
@@ -0,0 +1,142 @@
+package viability
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIRRMatchesKnownRate(t *testing.T) {
+	// -1000 today, +1210 two periods later => 10% per period
+	irr, ok := IRR([]float64{-1000, 0, 1210})
+	if !ok {
+		t.Fatal("expected irr")
+	}
+	if math.Abs(irr-0.10) > 1e-6 {
+		t.Fatalf("irr=%.6f want=0.10", irr)
+	}
+
+	if _, ok := IRR([]float64{-1000, -10}); ok {
+		t.Fatal("expected no irr without a sign change")
+	}
+}
+
+func TestBuildCashFlowCashMatchesNetProfit(t *testing.T) {
+	purchase := 300000.0
+	renovation := 40000.0
+	other := 5000.0
+	sale := 450000.0
+	inputs := CashInputs{PurchasePrice: &purchase, RenovationCost: &renovation, OtherCosts: &other, SalePrice: &sale}
+	outputs := CalculateCash(inputs, CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, PJTaxRate: 0.15})
+
+	flow := BuildCashFlowCash(inputs, outputs, CashFlowParams{
+		HoldMonths: 8,
+		RenovationDraws: []CashFlowDraw{
+			{MonthIndex: 1, Amount: 10000},
+			{MonthIndex: 2, Amount: 10000},
+		},
+		AnnualDiscountRate: 0.12,
+	})
+	if flow == nil {
+		t.Fatal("expected cash flow")
+	}
+	if len(flow.Months) != 9 {
+		t.Fatalf("months=%d want=9", len(flow.Months))
+	}
+
+	// Without carry the timeline nets to the static analysis
+	if math.Abs(flow.Metrics.NetProfit-outputs.NetProfit) > 0.02 {
+		t.Fatalf("net_profit=%.2f want=%.2f", flow.Metrics.NetProfit, outputs.NetProfit)
+	}
+	// Draws are scaled to the renovation budget
+	if flow.Months[1].Renovation != 20000 || flow.Months[2].Renovation != 20000 {
+		t.Fatalf("renovation draws=%.2f/%.2f want=20000/20000", flow.Months[1].Renovation, flow.Months[2].Renovation)
+	}
+	if flow.Metrics.PeakCapitalMonth != 2 {
+		t.Fatalf("peak_capital_month=%d want=2", flow.Metrics.PeakCapitalMonth)
+	}
+	if flow.Metrics.IRRAnnual == nil || *flow.Metrics.IRRAnnual <= 0 {
+		t.Fatal("expected positive irr")
+	}
+	if flow.Metrics.NPV >= flow.Metrics.NetProfit {
+		t.Fatalf("npv=%.2f should be below undiscounted profit %.2f", flow.Metrics.NPV, flow.Metrics.NetProfit)
+	}
+}
+
+func TestBuildCashFlowCashCarryReducesProfit(t *testing.T) {
+	purchase := 300000.0
+	sale := 400000.0
+	inputs := CashInputs{PurchasePrice: &purchase, SalePrice: &sale}
+	outputs := CalculateCash(inputs, CashSettings{})
+
+	flow := BuildCashFlowCash(inputs, outputs, CashFlowParams{HoldMonths: 6, CondoFee: 800, IPTU: 2400})
+	if flow == nil {
+		t.Fatal("expected cash flow")
+	}
+	// (800 + 2400/12) * 6 = 6000
+	if got := outputs.NetProfit - flow.Metrics.NetProfit; math.Abs(got-6000) > 0.02 {
+		t.Fatalf("carry=%.2f want=6000", got)
+	}
+}
+
+func TestBuildCashFlowCashTaxesTimelineProfit(t *testing.T) {
+	purchase := 300000.0
+	sale := 400000.0
+	inputs := CashInputs{PurchasePrice: &purchase, SalePrice: &sale}
+	outputs := CalculateCash(inputs, CashSettings{PJTaxRate: 0.15})
+
+	flow := BuildCashFlowCash(inputs, outputs, CashFlowParams{HoldMonths: 6, CondoFee: 800, IPTU: 2400})
+	if flow == nil {
+		t.Fatal("expected cash flow")
+	}
+	// Carry of 6000 lowers the flat tax base: (100000 - 6000) * 0.15
+	if got := flow.Months[6].Tax; got != 14100 {
+		t.Fatalf("tax=%.2f want=14100", got)
+	}
+	if flow.Metrics.NetProfit != 79900 {
+		t.Fatalf("net_profit=%.2f want=79900", flow.Metrics.NetProfit)
+	}
+	// ROI on the capital put in, purchase plus carry: 79900 / 306000 over 6 months
+	want := round2((math.Pow(1+79900.0/306000, 2) - 1) * 100)
+	if flow.Metrics.AnnualizedROI == nil || *flow.Metrics.AnnualizedROI != want {
+		t.Fatalf("annualized_roi=%v want=%.2f", flow.Metrics.AnnualizedROI, want)
+	}
+}
+
+func TestBuildCashFlowFinancingScheduleWithoutPaymentsPaysPrincipalOnce(t *testing.T) {
+	purchase := 500000.0
+	sale := 650000.0
+	downPct := 0.20
+	term := 360
+	rate := 0.11
+	system := AmortizationSAC
+	inputs := FinancingInputs{
+		PurchasePrice:      &purchase,
+		SalePrice:          &sale,
+		DownPaymentPercent: &downPct,
+		TermMonths:         &term,
+		InterestRate:       &rate,
+		AmortizationSystem: &system,
+	}
+	outputs := CalculateFinancing(inputs, nil, FinancingSettings{BrokerRate: 0.06})
+	if outputs.Schedule == nil || outputs.ExitMonth != 0 {
+		t.Fatalf("schedule=%v exit_month=%d", outputs.Schedule != nil, outputs.ExitMonth)
+	}
+
+	flow := BuildCashFlowFinancing(inputs, outputs, nil, CashFlowParams{HoldMonths: 6})
+	if flow == nil {
+		t.Fatal("expected cash flow")
+	}
+	var installments float64
+	for _, m := range flow.Months {
+		installments += m.Financing
+	}
+	// Amortization inside the installments plus the payoff repay the 400000 principal once
+	principalPaid := installments - outputs.Schedule.InterestThrough(6) + flow.Months[6].DebtPayoff
+	if math.Abs(principalPaid-400000) > 0.05 {
+		t.Fatalf("principal paid=%.2f want=400000 (payoff=%.2f)", principalPaid, flow.Months[6].DebtPayoff)
+	}
+	want := sale - 100000 - installments - flow.Months[6].DebtPayoff - outputs.BrokerFee
+	if math.Abs(flow.Metrics.NetProfit-want) > 0.05 {
+		t.Fatalf("net_profit=%.2f want=%.2f", flow.Metrics.NetProfit, want)
+	}
+}
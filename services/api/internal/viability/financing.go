@@ -14,6 +14,10 @@ type FinancingInputs struct {
 	AppraisalFee       *float64 `json:"appraisal_fee"`
 	OtherFees          *float64 `json:"other_fees"`
 	RemainingDebt      *float64 `json:"remaining_debt"`
+	AmortizationSystem *string  `json:"amortization_system"`
+	CorrectionIndex    *string  `json:"correction_index"`
+	CorrectionRate     *float64 `json:"correction_rate"`
+	ExitMonth          *int     `json:"exit_month"`
 }
 
 // FinancingPayment represents a single payment made
@@ -28,6 +32,9 @@ type FinancingSettings struct {
 	RegistryRate float64 `json:"registry_rate"`
 	BrokerRate   float64 `json:"broker_rate"`
 	PJTaxRate    float64 `json:"pj_tax_rate"`
+
+	// Tax selects the tax regime; the flat PJTaxRate applies when no regime is set
+	Tax TaxSettings `json:"tax"`
 }
 
 // FinancingOutputs represents the calculated outputs for financing viability analysis
@@ -47,7 +54,15 @@ type FinancingOutputs struct {
 	NetProfit            float64 `json:"net_profit"`
 	ROI                  float64 `json:"roi"`
 	InterestPaidEstimate float64 `json:"interest_paid_estimate"`
+	RemainingDebt        float64 `json:"remaining_debt"`
+	ExitMonth            int     `json:"exit_month"`
 	IsPartial            bool    `json:"is_partial"`
+
+	// TaxBreakdown itemizes PJTaxValue under the selected tax regime
+	TaxBreakdown *TaxBreakdown `json:"tax_breakdown,omitempty"`
+
+	// Schedule is the amortization schedule derived from the contract terms (nil when not derivable)
+	Schedule *AmortizationSchedule `json:"schedule,omitempty"`
 }
 
 // CalculateFinancing computes the financing viability outputs from inputs, payments and settings.
@@ -77,19 +92,72 @@ func CalculateFinancing(inputs FinancingInputs, payments []FinancingPayment, set
 	financedValue := round2(purchasePrice - downPaymentValue)
 	outputs.FinancedValue = financedValue
 
-	// Calculate payments total
-	var paymentsTotal float64
+	// Build the amortization schedule when the contract terms allow it
+	schedule := buildFinancingSchedule(inputs, financedValue)
+	outputs.Schedule = schedule
+
+	// Exit month defaults to the last recorded payment
+	exitMonth := 0
 	for _, p := range payments {
-		paymentsTotal += p.Amount
+		if p.MonthIndex > exitMonth {
+			exitMonth = p.MonthIndex
+		}
+	}
+	if inputs.ExitMonth != nil && *inputs.ExitMonth >= 0 {
+		exitMonth = *inputs.ExitMonth
+	}
+	outputs.ExitMonth = exitMonth
+
+	// Payments after the exit month never happen: the sale pays off the balance instead
+	paid := make([]FinancingPayment, 0, len(payments))
+	for _, p := range payments {
+		if p.MonthIndex <= exitMonth {
+			paid = append(paid, p)
+		}
 	}
-	outputs.PaymentsTotal = round2(paymentsTotal)
 
-	// Calculate bank fees total
 	insurance := getFloatOrZero(inputs.Insurance)
 	appraisalFee := getFloatOrZero(inputs.AppraisalFee)
 	otherFees := getFloatOrZero(inputs.OtherFees)
-	bankFeesTotal := round2(insurance + appraisalFee + otherFees)
-	outputs.BankFeesTotal = bankFeesTotal
+
+	var paymentsTotal, bankFeesTotal, remainingDebt float64
+	if schedule != nil {
+		// Recorded payments take precedence; months without a record use the projected installment
+		recorded := make(map[int]float64, len(paid))
+		for _, p := range paid {
+			recorded[p.MonthIndex] = p.Amount
+			paymentsTotal += p.Amount
+		}
+		for _, row := range schedule.Rows {
+			if row.MonthIndex > exitMonth {
+				break
+			}
+			if _, ok := recorded[row.MonthIndex]; !ok {
+				paymentsTotal += row.Installment
+			}
+		}
+
+		// Insurance is paid monthly inside the installments, so only upfront fees count here
+		bankFeesTotal = appraisalFee + otherFees
+		remainingDebt = schedule.BalanceAt(exitMonth)
+		outputs.InterestPaidEstimate = schedule.InterestThrough(exitMonth)
+	} else {
+		for _, p := range paid {
+			paymentsTotal += p.Amount
+		}
+		bankFeesTotal = insurance + appraisalFee + otherFees
+		remainingDebt = getFloatOrZero(inputs.RemainingDebt)
+
+		// Calculate interest paid estimate (if CET and term_months provided)
+		if inputs.CET != nil && inputs.TermMonths != nil && *inputs.TermMonths > 0 {
+			cet := *inputs.CET
+			termMonths := float64(*inputs.TermMonths)
+			outputs.InterestPaidEstimate = round2(financedValue * cet * (termMonths / 12.0))
+		}
+	}
+	outputs.PaymentsTotal = round2(paymentsTotal)
+	outputs.BankFeesTotal = round2(bankFeesTotal)
+	outputs.RemainingDebt = round2(remainingDebt)
 
 	// Calculate acquisition fees (ITBI + Registry)
 	outputs.ITBIValue = round2(purchasePrice * settings.ITBIRate)
@@ -97,30 +165,33 @@ func CalculateFinancing(inputs FinancingInputs, payments []FinancingPayment, set
 	outputs.AcquisitionFees = round2(outputs.ITBIValue + outputs.RegistryValue)
 
 	// Calculate total paid
-	outputs.TotalPaid = round2(downPaymentValue + paymentsTotal + bankFeesTotal + outputs.AcquisitionFees)
+	outputs.TotalPaid = round2(downPaymentValue + outputs.PaymentsTotal + outputs.BankFeesTotal + outputs.AcquisitionFees)
 
 	// Calculate investment total (what the investor actually put in)
-	outputs.InvestmentTotal = round2(downPaymentValue + paymentsTotal + bankFeesTotal)
-
-	// Calculate interest paid estimate (if CET and term_months provided)
-	if inputs.CET != nil && inputs.TermMonths != nil && *inputs.TermMonths > 0 {
-		cet := *inputs.CET
-		termMonths := float64(*inputs.TermMonths)
-		outputs.InterestPaidEstimate = round2(financedValue * cet * (termMonths / 12.0))
-	}
+	outputs.InvestmentTotal = round2(downPaymentValue + outputs.PaymentsTotal + outputs.BankFeesTotal)
 
 	// Calculate broker fee
 	outputs.BrokerFee = round2(salePrice * settings.BrokerRate)
 
-	// Calculate remaining debt
-	remainingDebt := getFloatOrZero(inputs.RemainingDebt)
-
 	// Calculate gross profit
 	// sale_price - total_paid - remaining_debt - broker_fee
 	outputs.GrossProfit = round2(salePrice - outputs.TotalPaid - remainingDebt - outputs.BrokerFee)
 
-	// Calculate PJ tax on gross profit, not sale price
-	outputs.PJTaxValue = round2(outputs.GrossProfit * settings.PJTaxRate)
+	// Calculate taxes under the selected regime (flat PJ rate on gross profit by default).
+	// Financing costs are not part of the capital gains cost basis.
+	holdMonths := exitMonth
+	if holdMonths <= 0 {
+		holdMonths = DefaultHoldMonths
+	}
+	breakdown := CalculateTax(settings.Tax, settings.PJTaxRate, TaxBase{
+		SalePrice:       salePrice,
+		SaleCosts:       outputs.BrokerFee,
+		AcquisitionCost: purchasePrice + outputs.AcquisitionFees,
+		GrossProfit:     outputs.GrossProfit,
+		HoldMonths:      holdMonths,
+	})
+	outputs.TaxBreakdown = &breakdown
+	outputs.PJTaxValue = breakdown.Total
 
 	// Calculate net profit
 	outputs.NetProfit = round2(outputs.GrossProfit - outputs.PJTaxValue)
@@ -134,6 +205,40 @@ func CalculateFinancing(inputs FinancingInputs, payments []FinancingPayment, set
 	return outputs
 }
 
+// buildFinancingSchedule derives the amortization schedule from the plan inputs.
+// The nominal interest rate is preferred; CET is used as an effective annual rate fallback.
+// The insurance input is the contract total and is spread evenly across the term.
+func buildFinancingSchedule(inputs FinancingInputs, financedValue float64) *AmortizationSchedule {
+	if inputs.AmortizationSystem == nil || inputs.TermMonths == nil || *inputs.TermMonths <= 0 {
+		return nil
+	}
+
+	params := AmortizationParams{
+		System:     *inputs.AmortizationSystem,
+		Principal:  financedValue,
+		TermMonths: *inputs.TermMonths,
+	}
+	switch {
+	case inputs.InterestRate != nil:
+		params.AnnualRate = *inputs.InterestRate
+	case inputs.CET != nil:
+		params.AnnualRate = *inputs.CET
+		params.EffectiveRate = true
+	default:
+		return nil
+	}
+
+	if inputs.Insurance != nil {
+		params.MonthlyInsurance = *inputs.Insurance / float64(*inputs.TermMonths)
+	}
+	if inputs.CorrectionIndex != nil {
+		params.CorrectionIndex = *inputs.CorrectionIndex
+		params.CorrectionRate = getFloatOrZero(inputs.CorrectionRate)
+	}
+
+	return BuildAmortizationSchedule(params)
+}
+
 // getFloatOrZero returns the value or 0 if nil
 func getFloatOrZero(v *float64) float64 {
 	if v == nil {
@@ -0,0 +1,450 @@
+package viability
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Variables that can be stressed by the sensitivity and Monte Carlo analyses
+const (
+	SensitivitySalePrice      = "sale_price"
+	SensitivityRenovationCost = "renovation_cost"
+	SensitivityHoldMonths     = "hold_months"
+)
+
+// Distribution types supported by the Monte Carlo sampler
+const (
+	DistributionTriangular = "triangular"
+	DistributionUniform    = "uniform"
+	DistributionNormal     = "normal"
+)
+
+const (
+	DefaultSensitivitySteps = 5
+	MaxSensitivitySteps     = 21
+	DefaultMonteCarloRuns   = 1000
+	MaxMonteCarloRuns       = 20000
+	DefaultMonteCarloSeed   = int64(1)
+
+	minStressedHoldMonths = 1
+)
+
+// SensitivityRange is the variation applied to a variable.
+// Sale price and renovation cost use relative changes (-0.10 = -10%);
+// hold months uses absolute month deltas (-2 = two months shorter).
+type SensitivityRange struct {
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Steps int     `json:"steps,omitempty"` // points in the two-way table axis (default 5)
+}
+
+// SensitivityRanges holds the range of each variable (nil uses the default range)
+type SensitivityRanges struct {
+	SalePrice      *SensitivityRange `json:"sale_price,omitempty"`
+	RenovationCost *SensitivityRange `json:"renovation_cost,omitempty"`
+	HoldMonths     *SensitivityRange `json:"hold_months,omitempty"`
+}
+
+// Distribution describes how a variable is sampled in the Monte Carlo mode, using the
+// same units as SensitivityRange. Triangular uses Low/Mode/High, uniform uses Low/High
+// and normal uses Mode as the mean and StdDev.
+type Distribution struct {
+	Type   string  `json:"type"`
+	Low    float64 `json:"low"`
+	Mode   float64 `json:"mode"`
+	High   float64 `json:"high"`
+	StdDev float64 `json:"std_dev,omitempty"`
+}
+
+// MonteCarloParams configures the seeded simulation (nil distributions use the defaults)
+type MonteCarloParams struct {
+	Iterations     int           `json:"iterations"`
+	Seed           int64         `json:"seed"`
+	SalePrice      *Distribution `json:"sale_price,omitempty"`
+	RenovationCost *Distribution `json:"renovation_cost,omitempty"`
+	HoldMonths     *Distribution `json:"hold_months,omitempty"`
+}
+
+// SensitivityModel evaluates a deal under stressed sale price, renovation cost and hold period.
+// Carry (condo fee + IPTU/12) is added to other costs for every month held, like Flip Score v1.
+type SensitivityModel struct {
+	Inputs       CashInputs
+	Settings     CashSettings
+	MonthlyCarry float64
+}
+
+// SensitivityPoint is the outcome of one evaluation
+type SensitivityPoint struct {
+	SalePrice      float64 `json:"sale_price"`
+	RenovationCost float64 `json:"renovation_cost"`
+	HoldMonths     int     `json:"hold_months"`
+	NetProfit      float64 `json:"net_profit"`
+	ROI            float64 `json:"roi"`
+}
+
+// TornadoBar is the swing in net profit when one variable moves across its range
+type TornadoBar struct {
+	Variable      string  `json:"variable"`
+	LowValue      float64 `json:"low_value"`
+	HighValue     float64 `json:"high_value"`
+	LowNetProfit  float64 `json:"low_net_profit"`
+	HighNetProfit float64 `json:"high_net_profit"`
+	LowROI        float64 `json:"low_roi"`
+	HighROI       float64 `json:"high_roi"`
+	Swing         float64 `json:"swing"`
+}
+
+// TwoWayTable holds net profit and ROI over the grid of two variables (rows = Y, columns = X)
+type TwoWayTable struct {
+	XVariable string      `json:"x_variable"`
+	YVariable string      `json:"y_variable"`
+	XValues   []float64   `json:"x_values"`
+	YValues   []float64   `json:"y_values"`
+	NetProfit [][]float64 `json:"net_profit"`
+	ROI       [][]float64 `json:"roi"`
+}
+
+// MonteCarloResult summarizes the simulated net profit distribution
+type MonteCarloResult struct {
+	Iterations        int     `json:"iterations"`
+	Seed              int64   `json:"seed"`
+	ProbabilityOfLoss float64 `json:"probability_of_loss"` // share of runs with net profit < 0 (0..1)
+	MeanNetProfit     float64 `json:"mean_net_profit"`
+	NetProfitP10      float64 `json:"net_profit_p10"`
+	NetProfitP50      float64 `json:"net_profit_p50"`
+	NetProfitP90      float64 `json:"net_profit_p90"`
+	ROIP10            float64 `json:"roi_p10"`
+	ROIP50            float64 `json:"roi_p50"`
+	ROIP90            float64 `json:"roi_p90"`
+	MinNetProfit      float64 `json:"min_net_profit"`
+	MaxNetProfit      float64 `json:"max_net_profit"`
+}
+
+// DefaultSensitivityRanges returns the ranges used when none are provided:
+// sale price -10%..+10%, renovation -10%..+30% and hold -2..+6 months
+func DefaultSensitivityRanges() SensitivityRanges {
+	return SensitivityRanges{
+		SalePrice:      &SensitivityRange{Low: -0.10, High: 0.10, Steps: DefaultSensitivitySteps},
+		RenovationCost: &SensitivityRange{Low: -0.10, High: 0.30, Steps: DefaultSensitivitySteps},
+		HoldMonths:     &SensitivityRange{Low: -2, High: 6, Steps: DefaultSensitivitySteps},
+	}
+}
+
+// DefaultMonteCarloParams returns the default distributions: sale price skewed to the
+// downside, renovation and hold period skewed to overruns
+func DefaultMonteCarloParams() MonteCarloParams {
+	return MonteCarloParams{
+		Iterations:     DefaultMonteCarloRuns,
+		Seed:           DefaultMonteCarloSeed,
+		SalePrice:      &Distribution{Type: DistributionTriangular, Low: -0.15, Mode: 0, High: 0.05},
+		RenovationCost: &Distribution{Type: DistributionTriangular, Low: -0.05, Mode: 0, High: 0.30},
+		HoldMonths:     &Distribution{Type: DistributionTriangular, Low: -1, Mode: 0, High: 4},
+	}
+}
+
+// IsValidSensitivityVariable reports whether v is a supported variable
+func IsValidSensitivityVariable(v string) bool {
+	switch v {
+	case SensitivitySalePrice, SensitivityRenovationCost, SensitivityHoldMonths:
+		return true
+	}
+	return false
+}
+
+// IsValidDistributionType reports whether t is a supported distribution type
+func IsValidDistributionType(t string) bool {
+	switch t {
+	case DistributionTriangular, DistributionUniform, DistributionNormal:
+		return true
+	}
+	return false
+}
+
+// WithDefaults fills missing ranges and steps with the defaults
+func (r SensitivityRanges) WithDefaults() SensitivityRanges {
+	defaults := DefaultSensitivityRanges()
+	if r.SalePrice == nil {
+		r.SalePrice = defaults.SalePrice
+	}
+	if r.RenovationCost == nil {
+		r.RenovationCost = defaults.RenovationCost
+	}
+	if r.HoldMonths == nil {
+		r.HoldMonths = defaults.HoldMonths
+	}
+	return r
+}
+
+// WithDefaults fills missing distributions, iterations and seed with the defaults
+func (p MonteCarloParams) WithDefaults() MonteCarloParams {
+	defaults := DefaultMonteCarloParams()
+	if p.Iterations <= 0 {
+		p.Iterations = defaults.Iterations
+	}
+	if p.Iterations > MaxMonteCarloRuns {
+		p.Iterations = MaxMonteCarloRuns
+	}
+	if p.Seed == 0 {
+		p.Seed = defaults.Seed
+	}
+	if p.SalePrice == nil {
+		p.SalePrice = defaults.SalePrice
+	}
+	if p.RenovationCost == nil {
+		p.RenovationCost = defaults.RenovationCost
+	}
+	if p.HoldMonths == nil {
+		p.HoldMonths = defaults.HoldMonths
+	}
+	return p
+}
+
+// Range returns the range of a variable (the default range when not set)
+func (r SensitivityRanges) Range(variable string) SensitivityRange {
+	r = r.WithDefaults()
+	switch variable {
+	case SensitivitySalePrice:
+		return *r.SalePrice
+	case SensitivityRenovationCost:
+		return *r.RenovationCost
+	case SensitivityHoldMonths:
+		return *r.HoldMonths
+	}
+	return SensitivityRange{}
+}
+
+// IsPartial reports whether the base inputs are missing purchase or sale price
+func (m SensitivityModel) IsPartial() bool {
+	return m.Inputs.PurchasePrice == nil || m.Inputs.SalePrice == nil
+}
+
+// BaseHoldMonths returns the hold period of the base case (default 6)
+func (m SensitivityModel) BaseHoldMonths() int {
+	if m.Inputs.HoldMonths != nil && *m.Inputs.HoldMonths > 0 {
+		return *m.Inputs.HoldMonths
+	}
+	return DefaultHoldMonths
+}
+
+// Base evaluates the unstressed deal
+func (m SensitivityModel) Base() SensitivityPoint {
+	return m.Evaluate(getFloatOrZero(m.Inputs.SalePrice), getFloatOrZero(m.Inputs.RenovationCost), m.BaseHoldMonths())
+}
+
+// Evaluate runs CalculateCash with the given sale price, renovation cost and hold period
+func (m SensitivityModel) Evaluate(salePrice, renovationCost float64, holdMonths int) SensitivityPoint {
+	holdMonths = max(holdMonths, minStressedHoldMonths)
+	otherCosts := getFloatOrZero(m.Inputs.OtherCosts) + m.MonthlyCarry*float64(holdMonths)
+
+	inputs := m.Inputs
+	inputs.SalePrice = &salePrice
+	inputs.RenovationCost = &renovationCost
+	inputs.OtherCosts = &otherCosts
+	inputs.HoldMonths = &holdMonths
+
+	outputs := CalculateCash(inputs, m.Settings)
+	return SensitivityPoint{
+		SalePrice:      round2(salePrice),
+		RenovationCost: round2(renovationCost),
+		HoldMonths:     holdMonths,
+		NetProfit:      outputs.NetProfit,
+		ROI:            outputs.ROI,
+	}
+}
+
+// evaluateVariation applies a variation of one variable to the base case and returns
+// the stressed value of that variable with the outcome
+func (m SensitivityModel) evaluateVariation(variable string, delta float64) (float64, SensitivityPoint) {
+	sale, renovation, hold := m.applyDelta(variable, delta, getFloatOrZero(m.Inputs.SalePrice), getFloatOrZero(m.Inputs.RenovationCost), m.BaseHoldMonths())
+
+	var value float64
+	switch variable {
+	case SensitivitySalePrice:
+		value = sale
+	case SensitivityRenovationCost:
+		value = renovation
+	case SensitivityHoldMonths:
+		value = float64(hold)
+	}
+	return round2(value), m.Evaluate(sale, renovation, hold)
+}
+
+// Tornado moves each variable to the ends of its range and returns the bars sorted by swing
+func (m SensitivityModel) Tornado(ranges SensitivityRanges) []TornadoBar {
+	variables := []string{SensitivitySalePrice, SensitivityRenovationCost, SensitivityHoldMonths}
+	bars := make([]TornadoBar, 0, len(variables))
+	for _, variable := range variables {
+		rng := ranges.Range(variable)
+		lowValue, low := m.evaluateVariation(variable, rng.Low)
+		highValue, high := m.evaluateVariation(variable, rng.High)
+		bars = append(bars, TornadoBar{
+			Variable:      variable,
+			LowValue:      lowValue,
+			HighValue:     highValue,
+			LowNetProfit:  low.NetProfit,
+			HighNetProfit: high.NetProfit,
+			LowROI:        low.ROI,
+			HighROI:       high.ROI,
+			Swing:         round2(math.Abs(high.NetProfit - low.NetProfit)),
+		})
+	}
+
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Swing > bars[j].Swing })
+	return bars
+}
+
+// TwoWay evaluates the grid of two variables over their ranges
+func (m SensitivityModel) TwoWay(ranges SensitivityRanges, xVariable, yVariable string) TwoWayTable {
+	xDeltas := rangeSteps(ranges.Range(xVariable))
+	yDeltas := rangeSteps(ranges.Range(yVariable))
+
+	table := TwoWayTable{
+		XVariable: xVariable,
+		YVariable: yVariable,
+		XValues:   make([]float64, len(xDeltas)),
+		YValues:   make([]float64, len(yDeltas)),
+		NetProfit: make([][]float64, len(yDeltas)),
+		ROI:       make([][]float64, len(yDeltas)),
+	}
+	for i, d := range xDeltas {
+		table.XValues[i], _ = m.evaluateVariation(xVariable, d)
+	}
+
+	baseSale, baseRenovation, baseHold := getFloatOrZero(m.Inputs.SalePrice), getFloatOrZero(m.Inputs.RenovationCost), m.BaseHoldMonths()
+	for row, yDelta := range yDeltas {
+		table.NetProfit[row] = make([]float64, len(xDeltas))
+		table.ROI[row] = make([]float64, len(xDeltas))
+		for col, xDelta := range xDeltas {
+			sale, renovation, hold := m.applyDelta(xVariable, xDelta, baseSale, baseRenovation, baseHold)
+			sale, renovation, hold = m.applyDelta(yVariable, yDelta, sale, renovation, hold)
+			point := m.Evaluate(sale, renovation, hold)
+			table.NetProfit[row][col] = point.NetProfit
+			table.ROI[row][col] = point.ROI
+		}
+		table.YValues[row], _ = m.evaluateVariation(yVariable, yDelta)
+	}
+
+	return table
+}
+
+// MonteCarlo samples sale price, renovation cost and hold period from their distributions
+// and evaluates each draw with CalculateCash. The same seed always yields the same result.
+func (m SensitivityModel) MonteCarlo(params MonteCarloParams) MonteCarloResult {
+	params = params.WithDefaults()
+	rng := rand.New(rand.NewSource(params.Seed))
+
+	baseSale := getFloatOrZero(m.Inputs.SalePrice)
+	baseRenovation := getFloatOrZero(m.Inputs.RenovationCost)
+	baseHold := m.BaseHoldMonths()
+
+	profits := make([]float64, params.Iterations)
+	rois := make([]float64, params.Iterations)
+	var losses int
+	var total float64
+	for i := 0; i < params.Iterations; i++ {
+		// Draw in a fixed order so results are reproducible for a seed
+		sale := baseSale * (1 + params.SalePrice.sample(rng))
+		renovation := math.Max(baseRenovation*(1+params.RenovationCost.sample(rng)), 0)
+		hold := max(baseHold+int(math.Round(params.HoldMonths.sample(rng))), minStressedHoldMonths)
+
+		point := m.Evaluate(sale, renovation, hold)
+		profits[i] = point.NetProfit
+		rois[i] = point.ROI
+		total += point.NetProfit
+		if point.NetProfit < 0 {
+			losses++
+		}
+	}
+
+	sort.Float64s(profits)
+	sort.Float64s(rois)
+
+	return MonteCarloResult{
+		Iterations:        params.Iterations,
+		Seed:              params.Seed,
+		ProbabilityOfLoss: math.Round(float64(losses)/float64(params.Iterations)*10000) / 10000,
+		MeanNetProfit:     round2(total / float64(params.Iterations)),
+		NetProfitP10:      round2(Percentile(profits, 0.10)),
+		NetProfitP50:      round2(Percentile(profits, 0.50)),
+		NetProfitP90:      round2(Percentile(profits, 0.90)),
+		ROIP10:            round2(Percentile(rois, 0.10)),
+		ROIP50:            round2(Percentile(rois, 0.50)),
+		ROIP90:            round2(Percentile(rois, 0.90)),
+		MinNetProfit:      profits[0],
+		MaxNetProfit:      profits[len(profits)-1],
+	}
+}
+
+// Percentile returns the p-th percentile (0..1) of sorted values using linear interpolation
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := clampUnit(p) * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*frac
+}
+
+// applyDelta replaces one variable of (sale, renovation, hold) with its base value stressed by delta
+func (m SensitivityModel) applyDelta(variable string, delta, sale, renovation float64, hold int) (float64, float64, int) {
+	switch variable {
+	case SensitivitySalePrice:
+		sale = getFloatOrZero(m.Inputs.SalePrice) * (1 + delta)
+	case SensitivityRenovationCost:
+		renovation = getFloatOrZero(m.Inputs.RenovationCost) * (1 + delta)
+	case SensitivityHoldMonths:
+		hold = max(m.BaseHoldMonths()+int(math.Round(delta)), minStressedHoldMonths)
+	}
+	sale = math.Max(sale, 0)
+	renovation = math.Max(renovation, 0)
+	return sale, renovation, hold
+}
+
+// rangeSteps returns evenly spaced deltas from Low to High (inclusive)
+func rangeSteps(rng SensitivityRange) []float64 {
+	steps := rng.Steps
+	if steps <= 0 {
+		steps = DefaultSensitivitySteps
+	}
+	steps = min(steps, MaxSensitivitySteps)
+	if steps == 1 || rng.High == rng.Low {
+		return []float64{rng.Low}
+	}
+
+	deltas := make([]float64, steps)
+	step := (rng.High - rng.Low) / float64(steps-1)
+	for i := range deltas {
+		deltas[i] = round6(rng.Low + step*float64(i))
+	}
+	return deltas
+}
+
+func (d *Distribution) sample(rng *rand.Rand) float64 {
+	switch d.Type {
+	case DistributionUniform:
+		return d.Low + rng.Float64()*(d.High-d.Low)
+	case DistributionNormal:
+		return d.Mode + rng.NormFloat64()*d.StdDev
+	default:
+		return sampleTriangular(rng.Float64(), d.Low, d.Mode, d.High)
+	}
+}
+
+// sampleTriangular maps a uniform draw u to a triangular(low, mode, high) distribution
+func sampleTriangular(u, low, mode, high float64) float64 {
+	if high <= low {
+		return low
+	}
+	mode = math.Max(low, math.Min(high, mode))
+	cut := (mode - low) / (high - low)
+	if u < cut {
+		return low + math.Sqrt(u*(high-low)*(mode-low))
+	}
+	return high - math.Sqrt((1-u)*(high-low)*(high-mode))
+}
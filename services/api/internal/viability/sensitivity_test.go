@@ -0,0 +1,104 @@
+package viability
+
+import (
+	"math"
+	"testing"
+)
+
+func sensitivityTestModel() SensitivityModel {
+	purchase := 300000.0
+	renovation := 40000.0
+	other := 5000.0
+	sale := 450000.0
+	return SensitivityModel{
+		Inputs:       CashInputs{PurchasePrice: &purchase, RenovationCost: &renovation, OtherCosts: &other, SalePrice: &sale},
+		Settings:     CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, PJTaxRate: 0.15},
+		MonthlyCarry: 1000,
+	}
+}
+
+func TestSensitivityBaseMatchesCalculateCash(t *testing.T) {
+	model := sensitivityTestModel()
+
+	other := 5000.0 + 1000*DefaultHoldMonths
+	inputs := model.Inputs
+	inputs.OtherCosts = &other
+	want := CalculateCash(inputs, model.Settings)
+
+	base := model.Base()
+	if base.NetProfit != want.NetProfit || base.HoldMonths != DefaultHoldMonths {
+		t.Fatalf("base net_profit=%.2f hold=%d want=%.2f/%d", base.NetProfit, base.HoldMonths, want.NetProfit, DefaultHoldMonths)
+	}
+}
+
+func TestSensitivityTornadoSortedBySwing(t *testing.T) {
+	bars := sensitivityTestModel().Tornado(DefaultSensitivityRanges())
+	if len(bars) != 3 {
+		t.Fatalf("bars=%d want=3", len(bars))
+	}
+	if bars[0].Variable != SensitivitySalePrice {
+		t.Fatalf("first bar=%s want=%s", bars[0].Variable, SensitivitySalePrice)
+	}
+	for i := 1; i < len(bars); i++ {
+		if bars[i].Swing > bars[i-1].Swing {
+			t.Fatalf("bars not sorted by swing: %.2f > %.2f", bars[i].Swing, bars[i-1].Swing)
+		}
+	}
+}
+
+func TestSensitivityTwoWayGrid(t *testing.T) {
+	model := sensitivityTestModel()
+	ranges := SensitivityRanges{RenovationCost: &SensitivityRange{Low: -0.2, High: 0.2, Steps: 5}}
+	table := model.TwoWay(ranges, SensitivitySalePrice, SensitivityRenovationCost)
+	if len(table.XValues) != 5 || len(table.YValues) != 5 || len(table.NetProfit) != 5 || len(table.NetProfit[0]) != 5 {
+		t.Fatalf("unexpected grid %dx%d", len(table.YValues), len(table.XValues))
+	}
+	// The center cell is the base case
+	if table.NetProfit[2][2] != model.Base().NetProfit {
+		t.Fatalf("center=%.2f want=%.2f", table.NetProfit[2][2], model.Base().NetProfit)
+	}
+	// Profit rises with sale price and falls with renovation cost
+	if table.NetProfit[2][4] <= table.NetProfit[2][0] || table.NetProfit[4][2] >= table.NetProfit[0][2] {
+		t.Fatal("unexpected profit ordering in two-way table")
+	}
+}
+
+func TestMonteCarloIsDeterministicForSeed(t *testing.T) {
+	model := sensitivityTestModel()
+	params := MonteCarloParams{Iterations: 500, Seed: 42}
+
+	first := model.MonteCarlo(params)
+	second := model.MonteCarlo(params)
+	if first != second {
+		t.Fatalf("same seed produced different results: %+v vs %+v", first, second)
+	}
+	if !(first.NetProfitP10 <= first.NetProfitP50 && first.NetProfitP50 <= first.NetProfitP90) {
+		t.Fatalf("percentiles out of order: %.2f %.2f %.2f", first.NetProfitP10, first.NetProfitP50, first.NetProfitP90)
+	}
+	if first.ProbabilityOfLoss < 0 || first.ProbabilityOfLoss > 1 {
+		t.Fatalf("probability_of_loss=%.4f", first.ProbabilityOfLoss)
+	}
+}
+
+func TestMonteCarloProbabilityOfLoss(t *testing.T) {
+	model := sensitivityTestModel()
+	// Sale price drawn uniformly between -40% and -30% always loses money
+	result := model.MonteCarlo(MonteCarloParams{
+		Iterations: 200,
+		Seed:       7,
+		SalePrice:  &Distribution{Type: DistributionUniform, Low: -0.40, High: -0.30},
+	})
+	if result.ProbabilityOfLoss != 1 {
+		t.Fatalf("probability_of_loss=%.4f want=1", result.ProbabilityOfLoss)
+	}
+}
+
+func TestPercentileInterpolates(t *testing.T) {
+	values := []float64{0, 10, 20, 30, 40}
+	if got := Percentile(values, 0.5); got != 20 {
+		t.Fatalf("p50=%.2f want=20", got)
+	}
+	if got := Percentile(values, 0.1); math.Abs(got-4) > 1e-9 {
+		t.Fatalf("p10=%.2f want=4", got)
+	}
+}
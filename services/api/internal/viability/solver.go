@@ -0,0 +1,254 @@
+package viability
+
+import (
+	"errors"
+	"math"
+)
+
+// Targets supported by the goal-seek solver. ROI, margin and IRR (annual) are percentages;
+// net profit is in BRL.
+const (
+	SolveTargetROI       = "roi"
+	SolveTargetNetProfit = "net_profit"
+	SolveTargetMargin    = "margin"
+	SolveTargetIRR       = "irr"
+)
+
+// Inputs the goal-seek solver can vary
+const (
+	SolveVariablePurchasePrice  = "purchase_price"
+	SolveVariableRenovationCost = "renovation_cost"
+	SolveVariableOtherCosts     = "other_costs"
+	SolveVariableSalePrice      = "sale_price"
+)
+
+const (
+	solveMaxIterations = 200
+	solveMaxExpansions = 40
+	solveTolerance     = 0.005 // half a cent
+	solveIRRCeiling    = 1e6   // annual IRR (%) assumed when the timeline is too profitable to bracket
+)
+
+var (
+	ErrInvalidSolveTarget   = errors.New("invalid solve target")
+	ErrInvalidSolveVariable = errors.New("invalid solve variable")
+	ErrSolvePartialInputs   = errors.New("purchase_price and sale_price are required unless solved for")
+)
+
+// SolveRequest asks for the value of Variable that makes Target reach TargetValue
+type SolveRequest struct {
+	Variable    string  `json:"variable"`
+	Target      string  `json:"target"`
+	TargetValue float64 `json:"target_value"`
+}
+
+// SolveResult is the outcome of a goal-seek run.
+// Value is the limit of the variable that still meets the target (e.g. the maximum purchase
+// price for the target ROI, or the minimum sale price); Achieved is the target metric at Value.
+// When the target cannot be reached within the search bounds Feasible is false and Value is
+// the bound closest to the target.
+type SolveResult struct {
+	Variable    string  `json:"variable"`
+	Target      string  `json:"target"`
+	TargetValue float64 `json:"target_value"`
+	Value       float64 `json:"value"`
+	Achieved    float64 `json:"achieved"`
+	Feasible    bool    `json:"feasible"`
+	Iterations  int     `json:"iterations"`
+}
+
+// IsValidSolveTarget reports whether t is a supported solve target
+func IsValidSolveTarget(t string) bool {
+	switch t {
+	case SolveTargetROI, SolveTargetNetProfit, SolveTargetMargin, SolveTargetIRR:
+		return true
+	}
+	return false
+}
+
+// IsValidCashSolveVariable reports whether v can be solved for in the cash analysis
+func IsValidCashSolveVariable(v string) bool {
+	switch v {
+	case SolveVariablePurchasePrice, SolveVariableRenovationCost, SolveVariableOtherCosts, SolveVariableSalePrice:
+		return true
+	}
+	return false
+}
+
+// IsValidFinancingSolveVariable reports whether v can be solved for in the financing analysis
+func IsValidFinancingSolveVariable(v string) bool {
+	switch v {
+	case SolveVariablePurchasePrice, SolveVariableSalePrice:
+		return true
+	}
+	return false
+}
+
+// SolveCash inverts CalculateCash: it finds the value of one input that makes the target metric
+// reach the requested value, keeping the other inputs and the settings (including the tax regime)
+// fixed. Flow is only used for the IRR target, which is read from the cash-flow timeline.
+func SolveCash(inputs CashInputs, settings CashSettings, flow CashFlowParams, req SolveRequest) (SolveResult, error) {
+	if !IsValidSolveTarget(req.Target) {
+		return SolveResult{}, ErrInvalidSolveTarget
+	}
+	if !IsValidCashSolveVariable(req.Variable) {
+		return SolveResult{}, ErrInvalidSolveVariable
+	}
+	if (inputs.PurchasePrice == nil && req.Variable != SolveVariablePurchasePrice) ||
+		(inputs.SalePrice == nil && req.Variable != SolveVariableSalePrice) {
+		return SolveResult{}, ErrSolvePartialInputs
+	}
+
+	evaluate := func(x float64) float64 {
+		probe := WithCashInput(inputs, req.Variable, x)
+		outputs := CalculateCash(probe, settings)
+		if req.Target == SolveTargetIRR {
+			return cashFlowIRR(BuildCashFlowCash(probe, outputs, flow))
+		}
+		return solveMetric(req.Target, outputs.NetProfit, outputs.ROI, *probe.SalePrice)
+	}
+
+	high := solveUpperBound(getFloatOrZero(inputs.PurchasePrice), getFloatOrZero(inputs.SalePrice))
+	return solveMonotone(req, evaluate, solveLowerBound(req.Variable), high), nil
+}
+
+// SolveFinancing inverts CalculateFinancing for the purchase or sale price, keeping the
+// contract terms, payments and settings fixed. Flow is only used for the IRR target.
+func SolveFinancing(inputs FinancingInputs, payments []FinancingPayment, settings FinancingSettings, flow CashFlowParams, req SolveRequest) (SolveResult, error) {
+	if !IsValidSolveTarget(req.Target) {
+		return SolveResult{}, ErrInvalidSolveTarget
+	}
+	if !IsValidFinancingSolveVariable(req.Variable) {
+		return SolveResult{}, ErrInvalidSolveVariable
+	}
+	if (inputs.PurchasePrice == nil && req.Variable != SolveVariablePurchasePrice) ||
+		(inputs.SalePrice == nil && req.Variable != SolveVariableSalePrice) {
+		return SolveResult{}, ErrSolvePartialInputs
+	}
+
+	evaluate := func(x float64) float64 {
+		probe := WithFinancingInput(inputs, req.Variable, x)
+		outputs := CalculateFinancing(probe, payments, settings)
+		if req.Target == SolveTargetIRR {
+			return cashFlowIRR(BuildCashFlowFinancing(probe, outputs, payments, flow))
+		}
+		return solveMetric(req.Target, outputs.NetProfit, outputs.ROI, *probe.SalePrice)
+	}
+
+	high := solveUpperBound(getFloatOrZero(inputs.PurchasePrice), getFloatOrZero(inputs.SalePrice))
+	return solveMonotone(req, evaluate, solveLowerBound(req.Variable), high), nil
+}
+
+// WithCashInput returns a copy of the inputs with one variable replaced
+func WithCashInput(inputs CashInputs, variable string, value float64) CashInputs {
+	switch variable {
+	case SolveVariablePurchasePrice:
+		inputs.PurchasePrice = &value
+	case SolveVariableRenovationCost:
+		inputs.RenovationCost = &value
+	case SolveVariableOtherCosts:
+		inputs.OtherCosts = &value
+	case SolveVariableSalePrice:
+		inputs.SalePrice = &value
+	}
+	return inputs
+}
+
+// WithFinancingInput returns a copy of the inputs with one variable replaced
+func WithFinancingInput(inputs FinancingInputs, variable string, value float64) FinancingInputs {
+	switch variable {
+	case SolveVariablePurchasePrice:
+		inputs.PurchasePrice = &value
+	case SolveVariableSalePrice:
+		inputs.SalePrice = &value
+	}
+	return inputs
+}
+
+// solveMonotone bisects f(x) = target over [low, high], doubling high until the target is
+// bracketed. All targets are "higher is better", so the returned value is the end of the
+// final bracket that still meets the target.
+func solveMonotone(req SolveRequest, f func(float64) float64, low, high float64) SolveResult {
+	result := SolveResult{Variable: req.Variable, Target: req.Target, TargetValue: req.TargetValue}
+
+	fLow, fHigh := f(low)-req.TargetValue, f(high)-req.TargetValue
+	for i := 0; fLow*fHigh > 0 && i < solveMaxExpansions; i++ {
+		high *= 2
+		fHigh = f(high) - req.TargetValue
+		result.Iterations++
+	}
+	if fLow*fHigh > 0 {
+		// Not reachable: report the bound closest to the target
+		result.Value = low
+		if math.Abs(fHigh) < math.Abs(fLow) {
+			result.Value = high
+		}
+		result.Value = round2(result.Value)
+		result.Achieved = round2(f(result.Value))
+		return result
+	}
+
+	for ; result.Iterations < solveMaxIterations && high-low > solveTolerance; result.Iterations++ {
+		mid := (low + high) / 2
+		fMid := f(mid) - req.TargetValue
+		if fMid == 0 {
+			low, high, fLow, fHigh = mid, mid, 0, 0
+			break
+		}
+		if fMid*fLow < 0 {
+			high, fHigh = mid, fMid
+		} else {
+			low, fLow = mid, fMid
+		}
+	}
+
+	value := low
+	if fHigh >= 0 && fLow < 0 {
+		value = high
+	}
+	result.Value = round2(value)
+	result.Achieved = round2(f(result.Value))
+	result.Feasible = true
+	return result
+}
+
+func solveMetric(target string, netProfit, roi, salePrice float64) float64 {
+	switch target {
+	case SolveTargetNetProfit:
+		return netProfit
+	case SolveTargetMargin:
+		if salePrice <= 0 {
+			return 0
+		}
+		return netProfit / salePrice * 100
+	default:
+		return roi
+	}
+}
+
+// cashFlowIRR returns the annual IRR (%) of the timeline. When no IRR is found (extreme
+// inputs) it falls back to a total loss or to solveIRRCeiling depending on the profit sign.
+func cashFlowIRR(flow *CashFlow) float64 {
+	if flow == nil {
+		return -100
+	}
+	if flow.Metrics.IRRAnnual == nil {
+		if flow.Metrics.NetProfit > 0 {
+			return solveIRRCeiling
+		}
+		return -100
+	}
+	return *flow.Metrics.IRRAnnual
+}
+
+// solveLowerBound keeps prices strictly positive so ROI and margin stay defined
+func solveLowerBound(variable string) float64 {
+	if variable == SolveVariablePurchasePrice || variable == SolveVariableSalePrice {
+		return 0.01
+	}
+	return 0
+}
+
+func solveUpperBound(purchasePrice, salePrice float64) float64 {
+	return math.Max(math.Max(purchasePrice, salePrice), 1) * 2
+}
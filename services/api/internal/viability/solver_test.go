@@ -0,0 +1,107 @@
+package viability
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSolveCashMaxPurchasePriceForROI(t *testing.T) {
+	renovation := 40000.0
+	sale := 450000.0
+	inputs := CashInputs{RenovationCost: &renovation, SalePrice: &sale}
+	settings := CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, PJTaxRate: 0.15}
+
+	result, err := SolveCash(inputs, settings, CashFlowParams{}, SolveRequest{
+		Variable: SolveVariablePurchasePrice, Target: SolveTargetROI, TargetValue: 25,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Feasible {
+		t.Fatal("expected feasible result")
+	}
+
+	purchase := result.Value
+	inputs.PurchasePrice = &purchase
+	if roi := CalculateCash(inputs, settings).ROI; math.Abs(roi-25) > 0.01 {
+		t.Fatalf("roi at solved purchase price=%.2f want=25", roi)
+	}
+}
+
+func TestSolveCashBreakEvenSalePrice(t *testing.T) {
+	purchase := 300000.0
+	renovation := 40000.0
+	inputs := CashInputs{PurchasePrice: &purchase, RenovationCost: &renovation}
+	settings := CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, PJTaxRate: 0.15}
+
+	result, err := SolveCash(inputs, settings, CashFlowParams{}, SolveRequest{
+		Variable: SolveVariableSalePrice, Target: SolveTargetNetProfit, TargetValue: 0,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sale := result.Value
+	inputs.SalePrice = &sale
+	if profit := CalculateCash(inputs, settings).NetProfit; !result.Feasible || math.Abs(profit) > 0.05 {
+		t.Fatalf("net_profit at solved sale price=%.2f want=0", profit)
+	}
+}
+
+func TestSolveCashInfeasibleTarget(t *testing.T) {
+	purchase := 300000.0
+	sale := 320000.0
+	inputs := CashInputs{PurchasePrice: &purchase, SalePrice: &sale}
+
+	// No renovation budget can push ROI above the no-renovation ROI
+	result, err := SolveCash(inputs, CashSettings{}, CashFlowParams{}, SolveRequest{
+		Variable: SolveVariableRenovationCost, Target: SolveTargetROI, TargetValue: 50,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Feasible {
+		t.Fatalf("expected infeasible result, got value=%.2f", result.Value)
+	}
+	if result.Value != 0 {
+		t.Fatalf("value=%.2f want=0 (closest bound)", result.Value)
+	}
+}
+
+func TestSolveCashRejectsInvalidRequests(t *testing.T) {
+	purchase := 300000.0
+	inputs := CashInputs{PurchasePrice: &purchase}
+
+	if _, err := SolveCash(inputs, CashSettings{}, CashFlowParams{}, SolveRequest{Variable: "hold_months", Target: SolveTargetROI}); err != ErrInvalidSolveVariable {
+		t.Fatalf("err=%v want=%v", err, ErrInvalidSolveVariable)
+	}
+	if _, err := SolveCash(inputs, CashSettings{}, CashFlowParams{}, SolveRequest{Variable: SolveVariablePurchasePrice, Target: "payback"}); err != ErrInvalidSolveTarget {
+		t.Fatalf("err=%v want=%v", err, ErrInvalidSolveTarget)
+	}
+	if _, err := SolveCash(inputs, CashSettings{}, CashFlowParams{}, SolveRequest{Variable: SolveVariableRenovationCost, Target: SolveTargetROI}); err != ErrSolvePartialInputs {
+		t.Fatalf("err=%v want=%v", err, ErrSolvePartialInputs)
+	}
+}
+
+func TestSolveFinancingMaxPurchasePriceForIRR(t *testing.T) {
+	sale := 500000.0
+	downPct := 0.2
+	term := 360
+	rate := 0.12
+	system := AmortizationSAC
+	exit := 8
+	inputs := FinancingInputs{
+		SalePrice: &sale, DownPaymentPercent: &downPct, TermMonths: &term,
+		InterestRate: &rate, AmortizationSystem: &system, ExitMonth: &exit,
+	}
+	settings := FinancingSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, PJTaxRate: 0.15}
+
+	result, err := SolveFinancing(inputs, nil, settings, CashFlowParams{}, SolveRequest{
+		Variable: SolveVariablePurchasePrice, Target: SolveTargetIRR, TargetValue: 30,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Feasible || math.Abs(result.Achieved-30) > 0.1 {
+		t.Fatalf("feasible=%v achieved=%.2f want=30", result.Feasible, result.Achieved)
+	}
+}
@@ -0,0 +1,288 @@
+package viability
+
+import "math"
+
+// Tax regimes supported by the tax engine
+const (
+	TaxRegimeFlat           = "flat"            // legacy: PJTaxRate applied to gross profit
+	TaxRegimePF             = "pf"              // individual: progressive capital gains (ganho de capital)
+	TaxRegimeLucroPresumido = "lucro_presumido" // company: presumed profit on revenue
+	TaxRegimeRET            = "ret"             // company: Regime Especial de Tributação (patrimônio de afetação)
+	TaxRegimeSimples        = "simples"         // company: Simples Nacional effective rate on revenue
+)
+
+// Defaults used when a regime parameter is not configured
+const (
+	DefaultRETRate     = 0.04
+	DefaultSimplesRate = 0.06
+)
+
+// Lucro Presumido parameters for real estate sales (atividade imobiliária)
+const (
+	lpIRPJPresumption     = 0.08
+	lpCSLLPresumption     = 0.12
+	lpIRPJRate            = 0.15
+	lpIRPJSurchargeRate   = 0.10
+	lpIRPJSurchargeLimit  = 60000 // presumed profit per quarter exempt from the surcharge
+	lpCSLLRate            = 0.09
+	lpPISRate             = 0.0065
+	lpCOFINSRate          = 0.03
+	pfFR2MonthlyReduction = 1.0035
+)
+
+// retSplit is how the unified RET rate is split between the federal taxes (4% = 1.26/0.66/0.37/1.71)
+var retSplit = []struct {
+	code, label string
+	share       float64
+}{
+	{"irpj", "IRPJ", 1.26 / 4},
+	{"csll", "CSLL", 0.66 / 4},
+	{"pis", "PIS", 0.37 / 4},
+	{"cofins", "COFINS", 1.71 / 4},
+}
+
+// pfBrackets are the progressive capital gains brackets for individuals (Lei 13.259/2016)
+var pfBrackets = []struct {
+	upTo float64
+	rate float64
+}{
+	{5_000_000, 0.15},
+	{10_000_000, 0.175},
+	{30_000_000, 0.20},
+	{math.Inf(1), 0.225},
+}
+
+// TaxSettings selects the tax regime and its parameters.
+// An empty Regime means the legacy flat PJTaxRate on gross profit.
+type TaxSettings struct {
+	Regime          string  `json:"regime"`
+	SimplesRate     float64 `json:"simples_rate,omitempty"`      // Simples Nacional effective rate on revenue
+	RETRate         float64 `json:"ret_rate,omitempty"`          // RET unified rate on revenue
+	PFReinvestedPct float64 `json:"pf_reinvested_pct,omitempty"` // share of proceeds reinvested in residential property within 180 days (0..1)
+}
+
+// TaxBase holds the figures of a sale the regimes compute their base from
+type TaxBase struct {
+	SalePrice       float64 // gross sale revenue
+	SaleCosts       float64 // costs paid by the seller on the sale (broker fee)
+	AcquisitionCost float64 // purchase price + ITBI + registry
+	Improvements    float64 // renovation spend added to the cost basis
+	GrossProfit     float64 // calculator gross profit (used by the flat regime)
+	HoldMonths      int     // months between acquisition and sale (FR2)
+}
+
+// TaxItem is one line of the tax breakdown
+type TaxItem struct {
+	Code   string  `json:"code"`
+	Label  string  `json:"label"`
+	Base   float64 `json:"base"`
+	Rate   float64 `json:"rate"`
+	Amount float64 `json:"amount"`
+}
+
+// TaxBreakdown is the itemized result of a tax regime
+type TaxBreakdown struct {
+	Regime        string    `json:"regime"`
+	TaxableBase   float64   `json:"taxable_base"`
+	Items         []TaxItem `json:"items"`
+	Total         float64   `json:"total"`
+	EffectiveRate float64   `json:"effective_rate"` // total / gross profit (0 when there is no profit)
+	Notes         []string  `json:"notes,omitempty"`
+}
+
+// TaxRegime computes the taxes due on a sale
+type TaxRegime interface {
+	Code() string
+	Calculate(base TaxBase) TaxBreakdown
+}
+
+// IsValidTaxRegime reports whether s is a supported tax regime
+func IsValidTaxRegime(s string) bool {
+	switch s {
+	case TaxRegimeFlat, TaxRegimePF, TaxRegimeLucroPresumido, TaxRegimeRET, TaxRegimeSimples:
+		return true
+	}
+	return false
+}
+
+// IsFlat reports whether the settings use the legacy flat rate
+func (s TaxSettings) IsFlat() bool {
+	return s.Regime == "" || s.Regime == TaxRegimeFlat
+}
+
+// NewTaxRegime returns the regime selected by the settings.
+// flatRate is the legacy PJTaxRate, used when the regime is flat or unknown.
+func NewTaxRegime(settings TaxSettings, flatRate float64) TaxRegime {
+	switch settings.Regime {
+	case TaxRegimePF:
+		return pfRegime{reinvestedPct: clampUnit(settings.PFReinvestedPct)}
+	case TaxRegimeLucroPresumido:
+		return lucroPresumidoRegime{}
+	case TaxRegimeRET:
+		rate := settings.RETRate
+		if rate <= 0 {
+			rate = DefaultRETRate
+		}
+		return retRegime{rate: rate}
+	case TaxRegimeSimples:
+		rate := settings.SimplesRate
+		if rate <= 0 {
+			rate = DefaultSimplesRate
+		}
+		return simplesRegime{rate: rate}
+	default:
+		return flatRegime{rate: flatRate}
+	}
+}
+
+// CalculateTax runs the selected regime and fills the effective rate
+func CalculateTax(settings TaxSettings, flatRate float64, base TaxBase) TaxBreakdown {
+	breakdown := NewTaxRegime(settings, flatRate).Calculate(base)
+	breakdown.Total = round2(breakdown.Total)
+	breakdown.TaxableBase = round2(breakdown.TaxableBase)
+	if base.GrossProfit > 0 {
+		breakdown.EffectiveRate = round6(breakdown.Total / base.GrossProfit)
+	}
+	return breakdown
+}
+
+type flatRegime struct{ rate float64 }
+
+func (flatRegime) Code() string { return TaxRegimeFlat }
+
+func (r flatRegime) Calculate(base TaxBase) TaxBreakdown {
+	amount := round2(base.GrossProfit * r.rate)
+	return TaxBreakdown{
+		Regime:      TaxRegimeFlat,
+		TaxableBase: base.GrossProfit,
+		Items: []TaxItem{
+			{Code: "pj_tax", Label: "Imposto PJ (alíquota única)", Base: round2(base.GrossProfit), Rate: r.rate, Amount: amount},
+		},
+		Total: amount,
+	}
+}
+
+type pfRegime struct{ reinvestedPct float64 }
+
+func (pfRegime) Code() string { return TaxRegimePF }
+
+// Calculate applies the capital gains rules for individuals: the gain is the sale price
+// net of broker fees minus the cost basis (acquisition + improvements), reduced by the
+// FR2 factor (Lei 11.196/2005 art. 40) and by the share reinvested within 180 days
+// (art. 39), then taxed by the progressive brackets.
+func (r pfRegime) Calculate(base TaxBase) TaxBreakdown {
+	breakdown := TaxBreakdown{Regime: TaxRegimePF, Items: []TaxItem{}}
+
+	gain := base.SalePrice - base.SaleCosts - base.AcquisitionCost - base.Improvements
+	if gain <= 0 {
+		breakdown.Notes = append(breakdown.Notes, "Sem ganho de capital tributável")
+		return breakdown
+	}
+
+	fr2 := ReductionFactor(base)
+	taxable := gain * fr2
+	if fr2 < 1 {
+		breakdown.Notes = append(breakdown.Notes, "Fator de redução FR2 aplicado ao ganho")
+	}
+
+	if r.reinvestedPct > 0 {
+		taxable *= 1 - r.reinvestedPct
+		breakdown.Notes = append(breakdown.Notes, "Isenção proporcional por reinvestimento em imóvel residencial em até 180 dias")
+	}
+	breakdown.TaxableBase = taxable
+
+	lower := 0.0
+	for _, bracket := range pfBrackets {
+		if taxable <= lower {
+			break
+		}
+		slice := math.Min(taxable, bracket.upTo) - lower
+		amount := round2(slice * bracket.rate)
+		breakdown.Items = append(breakdown.Items, TaxItem{
+			Code:   "irpf_ganho_capital",
+			Label:  "IRPF ganho de capital",
+			Base:   round2(slice),
+			Rate:   bracket.rate,
+			Amount: amount,
+		})
+		breakdown.Total += amount
+		lower = bracket.upTo
+	}
+
+	return breakdown
+}
+
+// ReductionFactor returns the FR2 factor of Lei 11.196/2005 art. 40 for the hold period.
+// FR1 is left out: it only applies to acquisitions before December 2005.
+func ReductionFactor(base TaxBase) float64 {
+	return 1 / math.Pow(pfFR2MonthlyReduction, float64(max(base.HoldMonths, 0)))
+}
+
+type lucroPresumidoRegime struct{}
+
+func (lucroPresumidoRegime) Code() string { return TaxRegimeLucroPresumido }
+
+// Calculate applies Lucro Presumido for real estate sales: IRPJ and CSLL on the presumed
+// profit (8% / 12% of revenue) plus cumulative PIS/COFINS on revenue. The sale is assumed
+// to close in a single quarter for the IRPJ surcharge.
+func (lucroPresumidoRegime) Calculate(base TaxBase) TaxBreakdown {
+	revenue := math.Max(base.SalePrice, 0)
+	irpjBase := revenue * lpIRPJPresumption
+	csllBase := revenue * lpCSLLPresumption
+
+	items := []TaxItem{
+		{Code: "irpj", Label: "IRPJ", Base: round2(irpjBase), Rate: lpIRPJRate, Amount: round2(irpjBase * lpIRPJRate)},
+	}
+	if surchargeBase := irpjBase - lpIRPJSurchargeLimit; surchargeBase > 0 {
+		items = append(items, TaxItem{
+			Code: "irpj_adicional", Label: "Adicional de IRPJ", Base: round2(surchargeBase), Rate: lpIRPJSurchargeRate,
+			Amount: round2(surchargeBase * lpIRPJSurchargeRate),
+		})
+	}
+	items = append(items,
+		TaxItem{Code: "csll", Label: "CSLL", Base: round2(csllBase), Rate: lpCSLLRate, Amount: round2(csllBase * lpCSLLRate)},
+		TaxItem{Code: "pis", Label: "PIS", Base: round2(revenue), Rate: lpPISRate, Amount: round2(revenue * lpPISRate)},
+		TaxItem{Code: "cofins", Label: "COFINS", Base: round2(revenue), Rate: lpCOFINSRate, Amount: round2(revenue * lpCOFINSRate)},
+	)
+
+	return revenueBreakdown(TaxRegimeLucroPresumido, revenue, items)
+}
+
+type retRegime struct{ rate float64 }
+
+func (retRegime) Code() string { return TaxRegimeRET }
+
+// Calculate applies the RET unified rate on revenue, itemized by federal tax
+func (r retRegime) Calculate(base TaxBase) TaxBreakdown {
+	revenue := math.Max(base.SalePrice, 0)
+	items := make([]TaxItem, 0, len(retSplit))
+	for _, part := range retSplit {
+		rate := r.rate * part.share
+		items = append(items, TaxItem{Code: part.code, Label: part.label, Base: round2(revenue), Rate: round6(rate), Amount: round2(revenue * rate)})
+	}
+	return revenueBreakdown(TaxRegimeRET, revenue, items)
+}
+
+type simplesRegime struct{ rate float64 }
+
+func (simplesRegime) Code() string { return TaxRegimeSimples }
+
+// Calculate applies the Simples Nacional effective rate (DAS) on revenue
+func (r simplesRegime) Calculate(base TaxBase) TaxBreakdown {
+	revenue := math.Max(base.SalePrice, 0)
+	return revenueBreakdown(TaxRegimeSimples, revenue, []TaxItem{
+		{Code: "das", Label: "Simples Nacional (DAS)", Base: round2(revenue), Rate: r.rate, Amount: round2(revenue * r.rate)},
+	})
+}
+
+func revenueBreakdown(regime string, revenue float64, items []TaxItem) TaxBreakdown {
+	breakdown := TaxBreakdown{Regime: regime, TaxableBase: revenue, Items: items}
+	for _, item := range items {
+		breakdown.Total += item.Amount
+	}
+	return breakdown
+}
+
+func clampUnit(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,82 @@
+package viability
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateTaxFlatMatchesLegacyRate(t *testing.T) {
+	breakdown := CalculateTax(TaxSettings{}, 0.15, TaxBase{SalePrice: 450000, GrossProfit: 100000})
+	if breakdown.Regime != TaxRegimeFlat {
+		t.Fatalf("regime=%s want=%s", breakdown.Regime, TaxRegimeFlat)
+	}
+	if breakdown.Total != 15000 {
+		t.Fatalf("total=%.2f want=15000", breakdown.Total)
+	}
+}
+
+func TestCalculateTaxPFAppliesFR2AndReinvestment(t *testing.T) {
+	base := TaxBase{SalePrice: 500000, SaleCosts: 30000, AcquisitionCost: 300000, Improvements: 50000, HoldMonths: 6}
+
+	full := CalculateTax(TaxSettings{Regime: TaxRegimePF}, 0, base)
+	// gain 120000 reduced by FR2 = 1/1.0035^6, all in the 15% bracket
+	want := 120000 / math.Pow(1.0035, 6) * 0.15
+	if math.Abs(full.Total-want) > 0.02 {
+		t.Fatalf("total=%.2f want=%.2f", full.Total, want)
+	}
+
+	half := CalculateTax(TaxSettings{Regime: TaxRegimePF, PFReinvestedPct: 0.5}, 0, base)
+	if math.Abs(half.Total-full.Total/2) > 0.02 {
+		t.Fatalf("reinvested total=%.2f want=%.2f", half.Total, full.Total/2)
+	}
+
+	loss := CalculateTax(TaxSettings{Regime: TaxRegimePF}, 0, TaxBase{SalePrice: 300000, AcquisitionCost: 320000})
+	if loss.Total != 0 {
+		t.Fatalf("loss total=%.2f want=0", loss.Total)
+	}
+}
+
+func TestCalculateTaxPFProgressiveBrackets(t *testing.T) {
+	breakdown := CalculateTax(TaxSettings{Regime: TaxRegimePF}, 0, TaxBase{SalePrice: 6000000})
+	// 5M at 15% + 1M at 17.5%
+	if len(breakdown.Items) != 2 {
+		t.Fatalf("items=%d want=2", len(breakdown.Items))
+	}
+	if math.Abs(breakdown.Total-925000) > 0.02 {
+		t.Fatalf("total=%.2f want=925000", breakdown.Total)
+	}
+}
+
+func TestCalculateTaxRevenueRegimes(t *testing.T) {
+	base := TaxBase{SalePrice: 500000, GrossProfit: 80000}
+
+	lp := CalculateTax(TaxSettings{Regime: TaxRegimeLucroPresumido}, 0, base)
+	// IRPJ 1.2% + CSLL 1.08% + PIS 0.65% + COFINS 3% = 5.93% of revenue (no surcharge below 60k presumed profit)
+	if math.Abs(lp.Total-29650) > 0.02 {
+		t.Fatalf("lucro presumido total=%.2f want=29650", lp.Total)
+	}
+
+	ret := CalculateTax(TaxSettings{Regime: TaxRegimeRET}, 0, base)
+	if math.Abs(ret.Total-20000) > 0.05 || len(ret.Items) != 4 {
+		t.Fatalf("ret total=%.2f items=%d want=20000/4", ret.Total, len(ret.Items))
+	}
+
+	simples := CalculateTax(TaxSettings{Regime: TaxRegimeSimples, SimplesRate: 0.08}, 0, base)
+	if simples.Total != 40000 {
+		t.Fatalf("simples total=%.2f want=40000", simples.Total)
+	}
+}
+
+func TestBreakEvenSalePriceUnderRevenueTax(t *testing.T) {
+	purchase := 300000.0
+	renovation := 40000.0
+	inputs := CashInputs{PurchasePrice: &purchase, RenovationCost: &renovation}
+	settings := CashSettings{ITBIRate: 0.03, RegistryRate: 0.01, BrokerRate: 0.06, Tax: TaxSettings{Regime: TaxRegimeRET}}
+
+	breakEven := BreakEvenSalePrice(inputs, settings)
+	inputs.SalePrice = &breakEven
+	outputs := CalculateCash(inputs, settings)
+	if math.Abs(outputs.NetProfit) > 1 {
+		t.Fatalf("net_profit at break-even=%.2f want=0", outputs.NetProfit)
+	}
+}
@@ -20,29 +20,15 @@ func NewCollector(verbose bool) *Collector {
 	return &Collector{verbose: verbose}
 }
 
-// CollectListings coleta a lista de anúncios da página de busca
-func (c *Collector) CollectListings(ctx context.Context, params CollectParams) ([]ListingSummary, error) {
-	candidates := buildSearchURLCandidates(params)
-	state := strings.TrimSpace(strings.ToLower(params.State))
-	city := normalizeLocationSlug(params.City)
-	neighborhood := normalizeLocationSlug(params.Neighborhood)
-	if state == "" {
-		state = defaultState
-	}
+// CollectListings coleta a lista de anúncios da página de busca do portal
+func (c *Collector) CollectListings(ctx context.Context, source Source, params CollectParams) ([]ListingSummary, error) {
+	candidates := source.SearchURLs(params)
 	if c.verbose {
-		log.Printf("[collector] URLs candidatas: %d", len(candidates))
+		log.Printf("[collector:%s] URLs candidatas: %d", source.Name(), len(candidates))
 	}
 
 	// Criar contexto do Chrome
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-	)
-
-	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, opts...)
+	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, chromeAllocatorOptions()...)
 	defer allocCancel()
 
 	var lastErr error
@@ -65,23 +51,18 @@ func (c *Collector) CollectListings(ctx context.Context, params CollectParams) (
 		if err != nil {
 			lastErr = err
 			if c.verbose {
-				log.Printf("[collector] tentativa %d/%d falhou: %s (%v)", idx+1, len(candidates), url, err)
+				log.Printf("[collector:%s] tentativa %d/%d falhou: %s (%v)", source.Name(), idx+1, len(candidates), url, err)
 			}
 			continue
 		}
 
 		successfulAttempts++
-		summaries := parseListingsHTML(html)
-		matchedSummaries := filterSummariesByLocation(
-			summaries,
-			city,
-			buildNeighborhoodSlugVariants(neighborhood),
-			state,
-		)
+		summaries := source.ParseListings(html)
+		matchedSummaries := source.MatchLocation(summaries, params)
 
 		if c.verbose {
-			log.Printf("[collector] tentativa %d/%d: %s | title=%q | html=%d bytes | listings=%d | location_match=%d",
-				idx+1, len(candidates), url, title, len(html), len(summaries), len(matchedSummaries))
+			log.Printf("[collector:%s] tentativa %d/%d: %s | title=%q | html=%d bytes | listings=%d | location_match=%d",
+				source.Name(), idx+1, len(candidates), url, title, len(html), len(summaries), len(matchedSummaries))
 		}
 
 		if len(matchedSummaries) == 0 {
@@ -104,17 +85,9 @@ func (c *Collector) CollectListings(ctx context.Context, params CollectParams) (
 }
 
 // EnrichListings coleta detalhes de cada anúncio
-func (c *Collector) EnrichListings(ctx context.Context, summaries []ListingSummary) ([]ListingDetails, error) {
+func (c *Collector) EnrichListings(ctx context.Context, source Source, summaries []ListingSummary) ([]ListingDetails, error) {
 	// Criar contexto do Chrome reutilizável
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-	)
-
-	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, opts...)
+	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, chromeAllocatorOptions()...)
 	defer allocCancel()
 
 	// Processar em paralelo com limite de concorrência
@@ -136,13 +109,13 @@ func (c *Collector) EnrichListings(ctx context.Context, summaries []ListingSumma
 			sleepMs := 1000 + rand.Intn(2000)
 			time.Sleep(time.Duration(sleepMs) * time.Millisecond)
 
-			detail, err := c.enrichOne(allocCtx, s)
+			detail, err := c.enrichOne(allocCtx, source, s)
 			mu.Lock()
 			defer mu.Unlock()
 
 			if err != nil {
 				if c.verbose {
-					log.Printf("[collector] ⚠️ Erro no anúncio %d: %v", idx+1, err)
+					log.Printf("[collector:%s] ⚠️ Erro no anúncio %d: %v", source.Name(), idx+1, err)
 				}
 				errors = append(errors, err)
 				// Usar dados do summary como fallback
@@ -164,7 +137,7 @@ func (c *Collector) EnrichListings(ctx context.Context, summaries []ListingSumma
 			} else {
 				details = append(details, *detail)
 				if c.verbose {
-					log.Printf("[collector] ✓ Anúncio %d/%d coletado", idx+1, len(summaries))
+					log.Printf("[collector:%s] ✓ Anúncio %d/%d coletado", source.Name(), idx+1, len(summaries))
 				}
 			}
 		}(i, summary)
@@ -179,7 +152,7 @@ func (c *Collector) EnrichListings(ctx context.Context, summaries []ListingSumma
 	return details, nil
 }
 
-func (c *Collector) enrichOne(allocCtx context.Context, summary ListingSummary) (*ListingDetails, error) {
+func (c *Collector) enrichOne(allocCtx context.Context, source Source, summary ListingSummary) (*ListingDetails, error) {
 	chromeCtx, cancel := chromedp.NewContext(allocCtx)
 	defer cancel()
 
@@ -198,10 +171,21 @@ func (c *Collector) enrichOne(allocCtx context.Context, summary ListingSummary)
 	}
 
 	// Parse HTML do detalhe
-	detail := parseDetailHTML(html, summary)
+	detail := source.ParseDetail(html, summary)
 	return detail, nil
 }
 
+func chromeAllocatorOptions() []chromedp.ExecAllocatorOption {
+	return append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+	)
+}
+
+// buildSearchURLCandidates monta as URLs de busca do ZAP
 func buildSearchURLCandidates(params CollectParams) []string {
 	state := strings.TrimSpace(strings.ToLower(params.State))
 	city := normalizeLocationSlug(params.City)
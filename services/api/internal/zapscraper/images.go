@@ -0,0 +1,71 @@
+package zapscraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/widia-projects/widia-flip/services/api/internal/listingcluster"
+)
+
+const (
+	maxHashedImagesPerListing = 3
+	maxImageBytes             = 5 << 20
+	imageFetchTimeout         = 15 * time.Second
+	imageFetchConcurrency     = 4
+)
+
+var imageHTTPClient = &http.Client{Timeout: imageFetchTimeout}
+
+// hashListingImages calcula o hash perceptual das primeiras fotos de cada anúncio.
+// Fotos que falham no download são ignoradas; o anúncio ainda pode ser agrupado
+// por endereço e atributos.
+func hashListingImages(ctx context.Context, listings []SourceListing) {
+	sem := make(chan struct{}, imageFetchConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range listings {
+		wg.Add(1)
+		go func(listing *SourceListing) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, imageURL := range listing.Images {
+				if len(listing.ImageHashes) >= maxHashedImagesPerListing {
+					break
+				}
+				if imageURL == "" {
+					continue
+				}
+				hash, err := fetchImageHash(ctx, imageURL)
+				if err != nil {
+					continue
+				}
+				listing.ImageHashes = append(listing.ImageHashes, listingcluster.FormatHash(hash))
+			}
+		}(&listings[i])
+	}
+
+	wg.Wait()
+}
+
+func fetchImageHash(ctx context.Context, imageURL string) (uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := imageHTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return listingcluster.HashImage(io.LimitReader(resp.Body, maxImageBytes))
+}
@@ -1,12 +1,15 @@
 package zapscraper
 
-// normalize converte ListingDetails para SourceListing
-func normalize(details []ListingDetails) []SourceListing {
+// normalize converte ListingDetails do portal para SourceListing
+func normalize(source Source, details []ListingDetails) []SourceListing {
 	var listings []SourceListing
 
 	for _, d := range details {
+		if d.SourceListingID == "" {
+			d.SourceListingID = source.CanonicalID(d.URL)
+		}
 		listing := SourceListing{
-			Source:          "ZAP",
+			Source:          source.Name(),
 			SourceListingID: d.SourceListingID,
 			CanonicalURL:    d.URL,
 			Title:           d.Title,
@@ -24,6 +27,8 @@ func normalize(details []ListingDetails) []SourceListing {
 			State:           d.State,
 			Images:          d.Images,
 			PublishedAt:     d.PublishedAt,
+			FieldSources:    d.FieldSources,
+			ParseQuality:    d.ParseQuality,
 		}
 
 		// Validação básica - precisa ter preço e área
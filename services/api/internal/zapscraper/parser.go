@@ -7,31 +7,47 @@ import (
 	"time"
 )
 
+// portalPatterns agrupa as expressões específicas de cada portal usadas pelo parser de HTML
+type portalPatterns struct {
+	listingLink *regexp.Regexp // grupo 1: URL do anúncio, grupo 2: ID
+	image       *regexp.Regexp // grupo 1: URL da imagem
+	title       *regexp.Regexp // opcional, grupo 1: título do card
+	location    *regexp.Regexp // opcional, grupo 1: bairro
+}
+
+// maxCardContext limita o trecho de HTML analisado para cada card
+const maxCardContext = 5000
+
 // parseListingsHTML extrai os anúncios do HTML da página de listagem
-func parseListingsHTML(html string) []ListingSummary {
+func parseListingsHTML(html string, p portalPatterns) []ListingSummary {
 	var summaries []ListingSummary
 
-	// Regex para encontrar links de anúncios
-	// Formato: /imovel/venda-apartamento-...-id-NNNNNNNNNN/
-	linkRe := regexp.MustCompile(`href="(https://www\.zapimoveis\.com\.br/imovel/[^"]+id-(\d+)[^"]*)"`)
-	matches := linkRe.FindAllStringSubmatch(html, -1)
+	matches := p.listingLink.FindAllStringSubmatchIndex(html, -1)
 
 	seen := make(map[string]bool)
-	for _, match := range matches {
-		if len(match) < 3 {
+	for i, match := range matches {
+		if len(match) < 6 {
 			continue
 		}
-		url := match[1]
-		id := match[2]
+		url := html[match[2]:match[3]]
+		id := html[match[4]:match[5]]
 
-		// Evitar duplicatas
+		// Evitar duplicatas (foto e título costumam apontar para o mesmo anúncio)
 		if seen[id] {
 			continue
 		}
 		seen[id] = true
 
-		// Extrair dados do contexto próximo ao link
-		summary := extractSummaryFromContext(html, url, id)
+		// O card vai do link até o link do próximo anúncio
+		end := min(len(html), match[0]+maxCardContext)
+		for _, next := range matches[i+1:] {
+			if html[next[4]:next[5]] != id {
+				end = min(end, next[0])
+				break
+			}
+		}
+
+		summary := extractSummaryFromContext(html[match[0]:end], url, id, p)
 		if summary != nil {
 			summaries = append(summaries, *summary)
 		}
@@ -40,23 +56,18 @@ func parseListingsHTML(html string) []ListingSummary {
 	return summaries
 }
 
-func extractSummaryFromContext(html, url, id string) *ListingSummary {
-	// Encontrar o bloco que contém este anúncio
-	idx := strings.Index(html, url)
-	if idx == -1 {
-		return nil
-	}
-
-	// Pegar contexto ao redor (5KB antes e depois)
-	start := max(0, idx-5000)
-	end := min(len(html), idx+5000)
-	context := html[start:end]
-
+func extractSummaryFromContext(context, url, id string, p portalPatterns) *ListingSummary {
 	summary := &ListingSummary{
 		SourceListingID: id,
 		URL:             url,
 	}
 
+	if p.title != nil {
+		if m := p.title.FindStringSubmatch(context); len(m) > 1 {
+			summary.Title = cleanText(m[1])
+		}
+	}
+
 	// Título - procurar por padrões comuns
 	titlePatterns := []string{
 		`Apartamento[^<]*à venda[^<]*`,
@@ -64,6 +75,9 @@ func extractSummaryFromContext(html, url, id string) *ListingSummary {
 		`Apartamento com \d+ [Qq]uartos[^<]*`,
 	}
 	for _, pattern := range titlePatterns {
+		if summary.Title != "" {
+			break
+		}
 		re := regexp.MustCompile(pattern)
 		if m := re.FindString(context); m != "" {
 			summary.Title = cleanText(m)
@@ -90,9 +104,12 @@ func extractSummaryFromContext(html, url, id string) *ListingSummary {
 		}
 	}
 
+	// Contagens sem as tags (ex.: "<span>2</span> Banheiros")
+	text := cleanText(context)
+
 	// Área - NNN m² ou NNNm²
 	areaRe := regexp.MustCompile(`(\d+)\s*m²`)
-	if m := areaRe.FindStringSubmatch(context); len(m) > 1 {
+	if m := areaRe.FindStringSubmatch(text); len(m) > 1 {
 		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
 			summary.AreaM2 = v
 		}
@@ -100,7 +117,7 @@ func extractSummaryFromContext(html, url, id string) *ListingSummary {
 
 	// Quartos - com validação (1-10)
 	quartoRe := regexp.MustCompile(`(\d+)\s*[Qq]uartos?`)
-	if m := quartoRe.FindStringSubmatch(context); len(m) > 1 {
+	if m := quartoRe.FindStringSubmatch(text); len(m) > 1 {
 		if v, err := strconv.Atoi(m[1]); err == nil && v >= 1 && v <= 10 {
 			summary.Bedrooms = v
 		}
@@ -108,7 +125,7 @@ func extractSummaryFromContext(html, url, id string) *ListingSummary {
 
 	// Banheiros - com validação (1-10)
 	banheiroRe := regexp.MustCompile(`(\d+)\s*[Bb]anheiros?`)
-	if m := banheiroRe.FindStringSubmatch(context); len(m) > 1 {
+	if m := banheiroRe.FindStringSubmatch(text); len(m) > 1 {
 		if v, err := strconv.Atoi(m[1]); err == nil && v >= 1 && v <= 10 {
 			summary.Bathrooms = v
 		}
@@ -116,7 +133,7 @@ func extractSummaryFromContext(html, url, id string) *ListingSummary {
 
 	// Vagas - com validação (0-10)
 	vagasRe := regexp.MustCompile(`(\d+)\s*[Vv]agas?`)
-	if m := vagasRe.FindStringSubmatch(context); len(m) > 1 {
+	if m := vagasRe.FindStringSubmatch(text); len(m) > 1 {
 		if v, err := strconv.Atoi(m[1]); err == nil && v >= 0 && v <= 10 {
 			summary.ParkingSpots = v
 		}
@@ -127,6 +144,11 @@ func extractSummaryFromContext(html, url, id string) *ListingSummary {
 	if m := neighborhoodRe.FindString(context); m != "" {
 		summary.Neighborhood = "Vila Izabel"
 	}
+	if p.location != nil {
+		if m := p.location.FindStringSubmatch(context); len(m) > 1 {
+			summary.Neighborhood = cleanText(m[1])
+		}
+	}
 
 	// Endereço
 	addressRe := regexp.MustCompile(`(Rua|Avenida|Av\.|R\.)[^,<]+`)
@@ -135,8 +157,7 @@ func extractSummaryFromContext(html, url, id string) *ListingSummary {
 	}
 
 	// Imagem
-	imgRe := regexp.MustCompile(`src="(https://resizedimgs\.zapimoveis\.com\.br[^"]+)"`)
-	if m := imgRe.FindStringSubmatch(context); len(m) > 1 {
+	if m := p.image.FindStringSubmatch(context); len(m) > 1 {
 		summary.ImageURL = m[1]
 	}
 
@@ -144,7 +165,7 @@ func extractSummaryFromContext(html, url, id string) *ListingSummary {
 }
 
 // parseDetailHTML extrai detalhes completos da página do anúncio
-func parseDetailHTML(html string, summary ListingSummary) *ListingDetails {
+func parseDetailHTML(html string, summary ListingSummary, p portalPatterns) *ListingDetails {
 	detail := &ListingDetails{
 		SourceListingID: summary.SourceListingID,
 		URL:             summary.URL,
@@ -277,7 +298,7 @@ func parseDetailHTML(html string, summary ListingSummary) *ListingDetails {
 	}
 
 	// Data de publicação
-	dateRe := regexp.MustCompile(`criado em (\d+) de (\w+) de (\d+)`)
+	dateRe := regexp.MustCompile(`criado em (\d+) de (\p{L}+) de (\d+)`)
 	if m := dateRe.FindStringSubmatch(html); len(m) > 3 {
 		if t := parseDate(m[1], m[2], m[3]); t != nil {
 			detail.PublishedAt = t
@@ -285,8 +306,7 @@ func parseDetailHTML(html string, summary ListingSummary) *ListingDetails {
 	}
 
 	// Imagens
-	imgRe := regexp.MustCompile(`src="(https://resizedimgs\.zapimoveis\.com\.br[^"]+)"`)
-	matches := imgRe.FindAllStringSubmatch(html, 20)
+	matches := p.image.FindAllStringSubmatch(html, 20)
 	seen := make(map[string]bool)
 	for _, m := range matches {
 		if len(m) > 1 && !seen[m[1]] {
@@ -295,7 +315,8 @@ func parseDetailHTML(html string, summary ListingSummary) *ListingDetails {
 		}
 	}
 
-	return detail
+	// Dados estruturados, quando a página traz, têm precedência sobre as regexes acima
+	return applyStructuredData(html, detail)
 }
 
 // Helpers
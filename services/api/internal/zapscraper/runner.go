@@ -2,6 +2,7 @@ package zapscraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -18,13 +19,24 @@ type RunParams struct {
 	State        string
 	MaxListings  int
 	Verbose      bool
+	Sources      []string // nomes dos portais; vazio usa DefaultSources
+}
+
+// SourceRunStats resume a coleta de um portal
+type SourceRunStats struct {
+	Source   string `json:"source"`
+	Listings int    `json:"listings"`
+	Error    string `json:"error,omitempty"`
 }
 
 type RunResult struct {
 	Listings      []Opportunity
 	MedianPriceM2 float64
+	Sources       []SourceRunStats
 }
 
+// Run coleta os portais em sequência e pontua o conjunto com uma mediana única de
+// preço/m². Falha de um portal não derruba os demais; só retorna erro se todos falharem.
 func Run(ctx context.Context, params RunParams) (RunResult, error) {
 	city := normalizeLocationSlug(params.City)
 	neighborhood := normalizeLocationSlug(params.Neighborhood)
@@ -35,6 +47,11 @@ func Run(ctx context.Context, params RunParams) (RunResult, error) {
 		return RunResult{}, fmt.Errorf("neighborhood is required")
 	}
 
+	sources, err := ResolveSources(params.Sources)
+	if err != nil {
+		return RunResult{}, err
+	}
+
 	state := strings.TrimSpace(strings.ToLower(params.State))
 	if state == "" {
 		state = defaultState
@@ -46,34 +63,79 @@ func Run(ctx context.Context, params RunParams) (RunResult, error) {
 	}
 
 	collector := NewCollector(params.Verbose)
-	summaries, err := collector.CollectListings(ctx, CollectParams{
+	collectParams := CollectParams{
 		City:         city,
 		Neighborhood: neighborhood,
 		State:        state,
 		MaxListings:  maxListings,
-	})
-	if err != nil {
-		return RunResult{}, fmt.Errorf("collect listings: %w", err)
 	}
-	if len(summaries) == 0 {
-		return RunResult{Listings: []Opportunity{}, MedianPriceM2: 0}, nil
+
+	var listings []SourceListing
+	var errs []error
+	stats := make([]SourceRunStats, 0, len(sources))
+	for _, source := range sources {
+		sourceListings, sourceErr := collectSource(ctx, collector, source, collectParams)
+		stat := SourceRunStats{Source: source.Name(), Listings: len(sourceListings)}
+		if sourceErr != nil {
+			stat.Error = sourceErr.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), sourceErr))
+		}
+		stats = append(stats, stat)
+		listings = append(listings, sourceListings...)
 	}
 
-	details, err := collector.EnrichListings(ctx, summaries)
-	if err != nil && len(details) == 0 {
-		return RunResult{}, fmt.Errorf("enrich listings: %w", err)
+	if len(errs) == len(sources) {
+		return RunResult{}, errors.Join(errs...)
+	}
+	if len(listings) == 0 {
+		return RunResult{Listings: []Opportunity{}, MedianPriceM2: 0, Sources: stats}, nil
 	}
 
-	listings := normalize(details)
 	medianM2 := calculateMedianPricePerM2(listings)
 	opportunities := scoreAll(listings, medianM2)
 
 	return RunResult{
 		Listings:      opportunities,
 		MedianPriceM2: medianM2,
+		Sources:       stats,
 	}, nil
 }
 
+func collectSource(ctx context.Context, collector *Collector, source Source, params CollectParams) ([]SourceListing, error) {
+	summaries, err := collector.CollectListings(ctx, source, params)
+	if err != nil {
+		return nil, fmt.Errorf("collect listings: %w", err)
+	}
+	if len(summaries) == 0 {
+		return nil, nil
+	}
+
+	details, err := collector.EnrichListings(ctx, source, summaries)
+	if err != nil && len(details) == 0 {
+		return nil, fmt.Errorf("enrich listings: %w", err)
+	}
+
+	listings := normalize(source, details)
+	hashListingImages(ctx, listings)
+	return listings, nil
+}
+
+// SameLocation compara nomes de cidade/bairro pelo slug, aceitando as abreviações
+// usadas pelos portais (ex.: "Vila Izabel" e "vl-izabel")
+func SameLocation(a, b string) bool {
+	slugA := normalizeLocationSlug(a)
+	slugB := normalizeLocationSlug(b)
+	if slugA == "" || slugB == "" {
+		return false
+	}
+	for _, variant := range buildNeighborhoodSlugVariants(slugA) {
+		if variant == slugB {
+			return true
+		}
+	}
+	return false
+}
+
 func normalizeLocationSlug(value string) string {
 	value = strings.TrimSpace(strings.ToLower(value))
 	if value == "" {
@@ -173,6 +173,27 @@ func score(listing SourceListing, medianM2 float64) Opportunity {
 	return opp
 }
 
+// priceCutWindow é a janela em que uma redução de preço ainda pontua
+const priceCutWindow = 30 * 24 * time.Hour
+
+// PriceCutScore pontua reduções de preço recentes (max +10). dropPct é a queda em
+// relação ao maior preço já visto do anúncio e droppedAt a data da última redução.
+func PriceCutScore(dropPct float64, droppedAt *time.Time, now time.Time) int {
+	if droppedAt == nil || dropPct <= 0 || now.Sub(*droppedAt) > priceCutWindow {
+		return 0
+	}
+
+	switch {
+	case dropPct >= 0.10:
+		return 10
+	case dropPct >= 0.05:
+		return 6
+	case dropPct >= 0.02:
+		return 3
+	}
+	return 0
+}
+
 // scoreDescription analisa texto para keywords (exportado para testes)
 func scoreDescription(text string) (reform int, penalty bool) {
 	text = strings.ToLower(text)
@@ -0,0 +1,32 @@
+package zapscraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriceCutScore(t *testing.T) {
+	now := time.Date(2025, time.October, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-5 * 24 * time.Hour)
+	old := now.Add(-45 * 24 * time.Hour)
+
+	cases := []struct {
+		name      string
+		dropPct   float64
+		droppedAt *time.Time
+		want      int
+	}{
+		{"no drop", 0, &recent, 0},
+		{"never dropped", 0.15, nil, 0},
+		{"small recent drop", 0.01, &recent, 0},
+		{"recent 3%", 0.03, &recent, 3},
+		{"recent 7%", 0.07, &recent, 6},
+		{"recent 12%", 0.12, &recent, 10},
+		{"old 12%", 0.12, &old, 0},
+	}
+	for _, tc := range cases {
+		if got := PriceCutScore(tc.dropPct, tc.droppedAt, now); got != tc.want {
+			t.Fatalf("%s: PriceCutScore=%d want=%d", tc.name, got, tc.want)
+		}
+	}
+}
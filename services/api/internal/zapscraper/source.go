@@ -0,0 +1,97 @@
+package zapscraper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Portais suportados (valor gravado em source_listings.source)
+const (
+	SourceZAP      = "ZAP"
+	SourceVivaReal = "VIVAREAL"
+	SourceOLX      = "OLX"
+)
+
+// DefaultSources é usada quando nenhuma fonte é informada
+var DefaultSources = []string{SourceZAP}
+
+// Source encapsula tudo que é específico de um portal: URLs de busca, parsers de
+// listagem/detalhe e identificação do anúncio. O collector só navega e entrega o HTML.
+type Source interface {
+	// Name retorna o identificador do portal (ex.: "ZAP")
+	Name() string
+	// SearchURLs retorna as URLs de busca candidatas, na ordem de tentativa
+	SearchURLs(params CollectParams) []string
+	// ParseListings extrai os cards da página de listagem
+	ParseListings(html string) []ListingSummary
+	// ParseDetail completa o card com os dados da página do anúncio
+	ParseDetail(html string, summary ListingSummary) *ListingDetails
+	// CanonicalID extrai o ID do anúncio a partir da URL
+	CanonicalID(url string) string
+	// MatchLocation descarta cards de outras praças que o portal mistura na busca
+	MatchLocation(summaries []ListingSummary, params CollectParams) []ListingSummary
+}
+
+var registeredSources = []Source{
+	zapSource{},
+	vivaRealSource{},
+	olxSource{},
+}
+
+// SourceNames lista os portais suportados
+func SourceNames() []string {
+	names := make([]string, 0, len(registeredSources))
+	for _, source := range registeredSources {
+		names = append(names, source.Name())
+	}
+	return names
+}
+
+// LookupSource busca um portal pelo nome, sem diferenciar maiúsculas
+func LookupSource(name string) (Source, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for _, source := range registeredSources {
+		if source.Name() == name {
+			return source, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveSources converte a lista de nomes em portais, sem duplicatas.
+// Lista vazia usa DefaultSources.
+func ResolveSources(names []string) ([]Source, error) {
+	if len(names) == 0 {
+		names = DefaultSources
+	}
+
+	sources := make([]Source, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		source, ok := LookupSource(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown source %q (supported: %s)", name, strings.Join(SourceNames(), ", "))
+		}
+		if seen[source.Name()] {
+			continue
+		}
+		seen[source.Name()] = true
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// matchLocationByURL filtra pelos slugs de cidade/bairro/UF presentes na URL do
+// anúncio (ZAP e VivaReal codificam a localização no path)
+func matchLocationByURL(summaries []ListingSummary, params CollectParams) []ListingSummary {
+	state := strings.TrimSpace(strings.ToLower(params.State))
+	if state == "" {
+		state = defaultState
+	}
+	return filterSummariesByLocation(
+		summaries,
+		normalizeLocationSlug(params.City),
+		buildNeighborhoodSlugVariants(normalizeLocationSlug(params.Neighborhood)),
+		state,
+	)
+}
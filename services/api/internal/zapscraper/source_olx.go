@@ -0,0 +1,181 @@
+package zapscraper
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// olxSource implementa a OLX. Diferente do ZAP/VivaReal, a URL do anúncio não traz
+// o bairro e a página de detalhe lista as características em pares <dt>/<dd>.
+type olxSource struct{}
+
+var olxPatterns = portalPatterns{
+	// Formato: https://pr.olx.com.br/regiao-de-curitiba-e-paranagua/imoveis/apartamento-...-NNNNNNNNNN
+	listingLink: regexp.MustCompile(`href="(https://[a-z]{2}\.olx\.com\.br/[^"]*?-(\d{8,})(?:\?[^"]*)?)"`),
+	image:       regexp.MustCompile(`src="(https://img\.olx\.com\.br[^"]+)"`),
+	title:       regexp.MustCompile(`<h2[^>]*class="[^"]*olx-adcard__title[^"]*"[^>]*>([^<]+)</h2>`),
+	location:    regexp.MustCompile(`class="[^"]*olx-adcard__location[^"]*"[^>]*>[^,<]+,\s*([^<]+)<`),
+}
+
+var (
+	olxIDRe        = regexp.MustCompile(`-(\d{8,})(?:[/?#]|$)`)
+	olxStateRe     = regexp.MustCompile(`https://([a-z]{2})\.olx\.com\.br/`)
+	olxFieldRe     = regexp.MustCompile(`(?s)<dt[^>]*>(.*?)</dt>\s*<dd[^>]*>(.*?)</dd>`)
+	olxTitleRe     = regexp.MustCompile(`<h1[^>]*>([^<]+)</h1>`)
+	olxPriceRe     = regexp.MustCompile(`class="[^"]*ad__price[^"]*"[^>]*>\s*R\$\s*([\d.,]+)`)
+	olxDescRe      = regexp.MustCompile(`(?s)<div[^>]*data-section="description"[^>]*>(.+?)</div>`)
+	olxPublishedRe = regexp.MustCompile(`Publicado em (\d{2})/(\d{2})/(\d{4})`)
+	firstNumberRe  = regexp.MustCompile(`\d+`)
+)
+
+func (olxSource) Name() string {
+	return SourceOLX
+}
+
+func (olxSource) SearchURLs(params CollectParams) []string {
+	state := strings.TrimSpace(strings.ToLower(params.State))
+	if state == "" {
+		state = defaultState
+	}
+	city := normalizeLocationSlug(params.City)
+
+	// A busca da OLX é por UF + texto livre; o filtro de bairro é feito em MatchLocation
+	candidates := make([]string, 0, 4)
+	for _, neighborhood := range buildNeighborhoodSlugVariants(normalizeLocationSlug(params.Neighborhood)) {
+		query := strings.ReplaceAll(neighborhood+"-"+city, "-", " ")
+		candidates = append(candidates, fmt.Sprintf(
+			"https://www.olx.com.br/imoveis/venda/apartamentos/estado-%s?q=%s",
+			state, url.QueryEscape(query)))
+	}
+	return dedupeURLCandidates(candidates)
+}
+
+func (olxSource) ParseListings(html string) []ListingSummary {
+	return parseListingsHTML(html, olxPatterns)
+}
+
+func (olxSource) ParseDetail(html string, summary ListingSummary) *ListingDetails {
+	detail := &ListingDetails{
+		SourceListingID: summary.SourceListingID,
+		URL:             summary.URL,
+		Title:           summary.Title,
+		PriceCents:      summary.PriceCents,
+		AreaM2:          summary.AreaM2,
+		Bedrooms:        summary.Bedrooms,
+		Bathrooms:       summary.Bathrooms,
+		ParkingSpots:    summary.ParkingSpots,
+		CondoFeeCents:   summary.CondoFeeCents,
+		IPTUCents:       summary.IPTUCents,
+		Neighborhood:    summary.Neighborhood,
+		Address:         summary.Address,
+		Images:          []string{summary.ImageURL},
+	}
+
+	if m := olxTitleRe.FindStringSubmatch(html); len(m) > 1 {
+		detail.Title = cleanText(m[1])
+	}
+	if m := olxPriceRe.FindStringSubmatch(html); len(m) > 1 {
+		detail.PriceCents = parsePriceToCents(m[1])
+	}
+	if m := olxDescRe.FindStringSubmatch(html); len(m) > 1 {
+		detail.Description = cleanText(m[1])
+	}
+
+	fields := make(map[string]string)
+	for _, m := range olxFieldRe.FindAllStringSubmatch(html, -1) {
+		fields[strings.ToLower(cleanText(m[1]))] = cleanText(m[2])
+	}
+	if v, ok := fields["área útil"]; ok {
+		if n := parseFirstInt(v); n > 0 {
+			detail.AreaM2 = float64(n)
+		}
+	}
+	if n := parseFirstInt(fields["quartos"]); n >= 1 && n <= 10 {
+		detail.Bedrooms = n
+	}
+	if n := parseFirstInt(fields["banheiros"]); n >= 1 && n <= 10 {
+		detail.Bathrooms = n
+	}
+	if v, ok := fields["vagas na garagem"]; ok {
+		if n := parseFirstInt(v); n >= 0 && n <= 10 {
+			detail.ParkingSpots = n
+		}
+	}
+	if v, ok := fields["condomínio"]; ok {
+		detail.CondoFeeCents = parsePriceToCents(strings.TrimPrefix(v, "R$ "))
+	}
+	if v, ok := fields["iptu"]; ok {
+		detail.IPTUCents = parsePriceToCents(strings.TrimPrefix(v, "R$ "))
+	}
+	if v := fields["logradouro"]; v != "" {
+		detail.Address = v
+	}
+	if v := fields["bairro"]; v != "" {
+		detail.Neighborhood = v
+	}
+	if v := fields["município"]; v != "" {
+		detail.City = v
+	}
+	if m := olxStateRe.FindStringSubmatch(summary.URL); len(m) > 1 {
+		detail.State = strings.ToUpper(m[1])
+	}
+
+	if m := olxPublishedRe.FindStringSubmatch(html); len(m) > 3 {
+		day, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		year, _ := strconv.Atoi(m[3])
+		if month >= 1 && month <= 12 {
+			t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+			detail.PublishedAt = &t
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range olxPatterns.image.FindAllStringSubmatch(html, 20) {
+		if len(m) > 1 && !seen[m[1]] {
+			seen[m[1]] = true
+			detail.Images = append(detail.Images, m[1])
+		}
+	}
+
+	return applyStructuredData(html, detail)
+}
+
+func (olxSource) CanonicalID(url string) string {
+	if m := olxIDRe.FindStringSubmatch(url); len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+// MatchLocation usa o bairro do card, já que a URL da OLX só traz a região
+func (olxSource) MatchLocation(summaries []ListingSummary, params CollectParams) []ListingSummary {
+	slugs := buildNeighborhoodSlugVariants(normalizeLocationSlug(params.Neighborhood))
+	matches := make([]ListingSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		neighborhood := normalizeLocationSlug(summary.Neighborhood)
+		for _, slug := range slugs {
+			if slug != "" && neighborhood == slug {
+				matches = append(matches, summary)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func parseFirstInt(value string) int {
+	m := firstNumberRe.FindString(value)
+	if m == "" {
+		return -1
+	}
+	n, err := strconv.Atoi(m)
+	if err != nil {
+		return -1
+	}
+	return n
+}
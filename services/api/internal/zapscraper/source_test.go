@@ -0,0 +1,240 @@
+package zapscraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var curitibaVilaIzabel = CollectParams{State: "pr", City: "Curitiba", Neighborhood: "Vila Izabel"}
+
+func loadFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestZAPParseListingsFixture(t *testing.T) {
+	source := zapSource{}
+	summaries := source.ParseListings(loadFixture(t, "zap_listing.html"))
+	if len(summaries) != 3 {
+		t.Fatalf("expected 3 listings, got %d", len(summaries))
+	}
+
+	first := summaries[0]
+	if first.SourceListingID != "2712345678" || first.PriceCents != 52000000 || first.AreaM2 != 82 {
+		t.Fatalf("unexpected first listing: %+v", first)
+	}
+	if first.Bedrooms != 3 || first.Bathrooms != 2 || first.ParkingSpots != 1 {
+		t.Fatalf("unexpected first listing rooms: %+v", first)
+	}
+	if first.CondoFeeCents != 78000 || first.IPTUCents != 15000 || first.Address != "Rua Brasílio Itiberê" {
+		t.Fatalf("unexpected first listing costs/address: %+v", first)
+	}
+	if first.ImageURL != "https://resizedimgs.zapimoveis.com.br/crop/614x297/named.images.sp/aaa111/apartamento.jpg" {
+		t.Fatalf("unexpected image: %s", first.ImageURL)
+	}
+
+	// Os dados de um card não vazam para o próximo
+	second := summaries[1]
+	if second.PriceCents != 34000000 || second.CondoFeeCents != 45000 || second.IPTUCents != 0 || second.ParkingSpots != 0 {
+		t.Fatalf("unexpected second listing: %+v", second)
+	}
+
+	matched := source.MatchLocation(summaries, curitibaVilaIzabel)
+	if len(matched) != 2 {
+		t.Fatalf("expected 2 listings in Curitiba, got %d", len(matched))
+	}
+}
+
+func TestZAPParseDetailFixture(t *testing.T) {
+	source := zapSource{}
+	summary := source.ParseListings(loadFixture(t, "zap_listing.html"))[0]
+	detail := source.ParseDetail(loadFixture(t, "zap_detail.html"), summary)
+
+	if detail.Title != "Apartamento com 3 Quartos à venda, 82m² - Vila Izabel" {
+		t.Fatalf("unexpected title: %q", detail.Title)
+	}
+	if detail.PriceCents != 51500000 || detail.AreaM2 != 82 || detail.Suites != 1 || detail.Floor != 7 {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if detail.CondoFeeCents != 78000 || detail.IPTUCents != 180000 {
+		t.Fatalf("unexpected costs: condo=%d iptu=%d", detail.CondoFeeCents, detail.IPTUCents)
+	}
+	if detail.Neighborhood != "Vila Izabel" || detail.City != "Curitiba" || detail.State != "PR" {
+		t.Fatalf("unexpected location: %q %q %q", detail.Neighborhood, detail.City, detail.State)
+	}
+	if detail.Description != "Apartamento original, precisa de reforma. Aceita proposta." {
+		t.Fatalf("unexpected description: %q", detail.Description)
+	}
+	if detail.PublishedAt == nil || !detail.PublishedAt.Equal(time.Date(2025, time.March, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected published_at: %v", detail.PublishedAt)
+	}
+	if len(detail.Images) != 3 {
+		t.Fatalf("expected card image + 2 gallery images, got %v", detail.Images)
+	}
+}
+
+func TestVivaRealParseListingsFixture(t *testing.T) {
+	source := vivaRealSource{}
+	summaries := source.ParseListings(loadFixture(t, "vivareal_listing.html"))
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 listings, got %d", len(summaries))
+	}
+
+	first := summaries[0]
+	if first.SourceListingID != "2654321987" || first.Title != "Apartamento com 3 Quartos à venda, 75m²" {
+		t.Fatalf("unexpected first listing: %+v", first)
+	}
+	if first.PriceCents != 47000000 || first.AreaM2 != 75 || first.Bedrooms != 3 || first.Bathrooms != 2 || first.ParkingSpots != 1 {
+		t.Fatalf("unexpected first listing values: %+v", first)
+	}
+	if first.CondoFeeCents != 62000 || first.IPTUCents != 11000 {
+		t.Fatalf("unexpected costs: %+v", first)
+	}
+	if summaries[1].PriceCents != 26500000 || summaries[1].Bedrooms != 0 {
+		t.Fatalf("unexpected second listing: %+v", summaries[1])
+	}
+	if got := source.CanonicalID(first.URL); got != "2654321987" {
+		t.Fatalf("unexpected canonical id: %s", got)
+	}
+	if matched := source.MatchLocation(summaries, curitibaVilaIzabel); len(matched) != 2 {
+		t.Fatalf("expected 2 listings in Curitiba, got %d", len(matched))
+	}
+}
+
+func TestVivaRealParseDetailFixture(t *testing.T) {
+	source := vivaRealSource{}
+	summary := source.ParseListings(loadFixture(t, "vivareal_listing.html"))[0]
+	detail := source.ParseDetail(loadFixture(t, "vivareal_detail.html"), summary)
+
+	if detail.PriceCents != 46500000 || detail.AreaM2 != 75 || detail.Bedrooms != 3 || detail.Bathrooms != 2 {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if detail.IPTUCents != 132000 || detail.City != "Curitiba" || detail.State != "PR" {
+		t.Fatalf("unexpected detail costs/location: %+v", detail)
+	}
+	if detail.Description != "Imóvel de inventário, venda rápida." {
+		t.Fatalf("unexpected description: %q", detail.Description)
+	}
+}
+
+func TestVivaRealSearchURLsUseStateName(t *testing.T) {
+	urls := vivaRealSource{}.SearchURLs(curitibaVilaIzabel)
+	want := "https://www.vivareal.com.br/venda/parana/curitiba/bairros/vila-izabel/apartamento_residencial/"
+	if len(urls) == 0 || urls[0] != want {
+		t.Fatalf("unexpected first url: %v", urls)
+	}
+	if !containsCandidate(urls, "https://www.vivareal.com.br/venda/parana/curitiba/bairros/vl-izabel/apartamento_residencial/") {
+		t.Fatalf("expected vila alias in %v", urls)
+	}
+}
+
+func TestOLXParseListingsFixture(t *testing.T) {
+	source := olxSource{}
+	summaries := source.ParseListings(loadFixture(t, "olx_listing.html"))
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 listings, got %d", len(summaries))
+	}
+
+	first := summaries[0]
+	if first.SourceListingID != "1298765432" || first.Title != "Apartamento 3 quartos com sacada" {
+		t.Fatalf("unexpected first listing: %+v", first)
+	}
+	if first.PriceCents != 44900000 || first.AreaM2 != 78 || first.Bedrooms != 3 || first.Bathrooms != 2 || first.ParkingSpots != 1 {
+		t.Fatalf("unexpected first listing values: %+v", first)
+	}
+	if first.CondoFeeCents != 60000 || first.IPTUCents != 9500 || first.Neighborhood != "Vila Izabel" {
+		t.Fatalf("unexpected first listing costs/neighborhood: %+v", first)
+	}
+	if summaries[1].Neighborhood != "Portão" {
+		t.Fatalf("unexpected second neighborhood: %q", summaries[1].Neighborhood)
+	}
+
+	matched := source.MatchLocation(summaries, curitibaVilaIzabel)
+	if len(matched) != 1 || matched[0].SourceListingID != "1298765432" {
+		t.Fatalf("expected only the Vila Izabel listing, got %+v", matched)
+	}
+}
+
+func TestOLXParseDetailFixture(t *testing.T) {
+	source := olxSource{}
+	summary := source.ParseListings(loadFixture(t, "olx_listing.html"))[0]
+	detail := source.ParseDetail(loadFixture(t, "olx_detail.html"), summary)
+
+	if detail.Title != "Apartamento 3 quartos com sacada - Vila Izabel" || detail.PriceCents != 43900000 {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if detail.AreaM2 != 78 || detail.Bedrooms != 3 || detail.Bathrooms != 2 || detail.ParkingSpots != 1 {
+		t.Fatalf("unexpected detail rooms: %+v", detail)
+	}
+	if detail.CondoFeeCents != 60000 || detail.IPTUCents != 114000 {
+		t.Fatalf("unexpected costs: condo=%d iptu=%d", detail.CondoFeeCents, detail.IPTUCents)
+	}
+	if detail.Address != "Rua Castro Alves" || detail.Neighborhood != "Vila Izabel" || detail.City != "Curitiba" || detail.State != "PR" {
+		t.Fatalf("unexpected location: %+v", detail)
+	}
+	if detail.PublishedAt == nil || !detail.PublishedAt.Equal(time.Date(2025, time.September, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected published_at: %v", detail.PublishedAt)
+	}
+	if detail.Description != "Apartamento para reformar, aceita proposta. Sol da manhã." || len(detail.Images) != 3 {
+		t.Fatalf("unexpected description/images: %q %v", detail.Description, detail.Images)
+	}
+}
+
+func TestOLXCanonicalID(t *testing.T) {
+	source := olxSource{}
+	cases := map[string]string{
+		"https://pr.olx.com.br/regiao-de-curitiba-e-paranagua/imoveis/apartamento-3-quartos-1298765432":          "1298765432",
+		"https://pr.olx.com.br/regiao-de-curitiba-e-paranagua/imoveis/apartamento-3-quartos-1298765432?lis=home": "1298765432",
+		"https://pr.olx.com.br/regiao-de-curitiba-e-paranagua/imoveis/apartamento-3-quartos":                     "",
+	}
+	for url, want := range cases {
+		if got := source.CanonicalID(url); got != want {
+			t.Fatalf("CanonicalID(%q)=%q want=%q", url, got, want)
+		}
+	}
+}
+
+func TestNormalizeSetsSourceAndCanonicalID(t *testing.T) {
+	listings := normalize(olxSource{}, []ListingDetails{{
+		URL:        "https://pr.olx.com.br/regiao-de-curitiba-e-paranagua/imoveis/apartamento-1298765432",
+		PriceCents: 43900000,
+		AreaM2:     78,
+	}})
+	if len(listings) != 1 || listings[0].Source != SourceOLX || listings[0].SourceListingID != "1298765432" {
+		t.Fatalf("unexpected normalized listings: %+v", listings)
+	}
+}
+
+func TestResolveSources(t *testing.T) {
+	sources, err := ResolveSources(nil)
+	if err != nil || len(sources) != 1 || sources[0].Name() != SourceZAP {
+		t.Fatalf("expected default ZAP source, got %v (%v)", sources, err)
+	}
+
+	sources, err = ResolveSources([]string{"olx", " VivaReal ", "OLX"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sources) != 2 || sources[0].Name() != SourceOLX || sources[1].Name() != SourceVivaReal {
+		t.Fatalf("unexpected sources: %v", sources)
+	}
+
+	if _, err := ResolveSources([]string{"ZAP", "imovelweb"}); err == nil {
+		t.Fatal("expected unknown source error")
+	}
+}
+
+func TestSameLocation(t *testing.T) {
+	if !SameLocation("Vila Izabel", "vl-izabel") || !SameLocation("São Paulo", "sao-paulo") {
+		t.Fatal("expected slug variants to match")
+	}
+	if SameLocation("Vila Izabel", "Portão") || SameLocation("", "") {
+		t.Fatal("expected different locations not to match")
+	}
+}
@@ -0,0 +1,71 @@
+package zapscraper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// vivaRealSource implementa o VivaReal. O portal é do mesmo grupo do ZAP e usa
+// o mesmo formato de URL de anúncio e de página de detalhe.
+type vivaRealSource struct{}
+
+var vivaRealPatterns = portalPatterns{
+	// Formato: /imovel/apartamento-2-quartos-...-venda-RS450000-id-NNNNNNNNNN/
+	listingLink: regexp.MustCompile(`href="(https://www\.vivareal\.com\.br/imovel/[^"]+id-(\d+)[^"]*)"`),
+	image:       regexp.MustCompile(`src="(https://resizedimgs\.vivareal\.com\.br[^"]+)"`),
+	title:       regexp.MustCompile(`<span[^>]*class="[^"]*property-card__title[^"]*"[^>]*>([^<]+)</span>`),
+}
+
+func (vivaRealSource) Name() string {
+	return SourceVivaReal
+}
+
+func (vivaRealSource) SearchURLs(params CollectParams) []string {
+	state := strings.TrimSpace(strings.ToLower(params.State))
+	if state == "" {
+		state = defaultState
+	}
+	city := normalizeLocationSlug(params.City)
+	neighborhoodVariants := buildNeighborhoodSlugVariants(normalizeLocationSlug(params.Neighborhood))
+
+	// O VivaReal usa o nome da UF por extenso no path (ex.: /venda/parana/curitiba/)
+	stateName := state
+	if candidates := stateSlugCandidates(state); len(candidates) > 1 {
+		stateName = candidates[1]
+	}
+
+	candidates := make([]string, 0, 8)
+	for _, neighborhood := range neighborhoodVariants {
+		candidates = append(candidates, fmt.Sprintf(
+			"https://www.vivareal.com.br/venda/%s/%s/bairros/%s/apartamento_residencial/",
+			stateName, city, neighborhood))
+	}
+
+	// Capitais segmentadas por zona usam a sigla da UF e a zona no path
+	for _, zone := range cityZoneCandidates(state, city) {
+		for _, neighborhood := range neighborhoodVariants {
+			candidates = append(candidates, fmt.Sprintf(
+				"https://www.vivareal.com.br/venda/%s/%s/%s/%s/apartamento_residencial/",
+				state, city, zone, neighborhood))
+		}
+	}
+
+	return dedupeURLCandidates(candidates)
+}
+
+func (vivaRealSource) ParseListings(html string) []ListingSummary {
+	return parseListingsHTML(html, vivaRealPatterns)
+}
+
+func (vivaRealSource) ParseDetail(html string, summary ListingSummary) *ListingDetails {
+	return parseDetailHTML(html, summary, vivaRealPatterns)
+}
+
+func (vivaRealSource) CanonicalID(url string) string {
+	return extractListingID(url)
+}
+
+func (vivaRealSource) MatchLocation(summaries []ListingSummary, params CollectParams) []ListingSummary {
+	return matchLocationByURL(summaries, params)
+}
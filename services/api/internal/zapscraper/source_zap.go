@@ -0,0 +1,36 @@
+package zapscraper
+
+import "regexp"
+
+// zapSource implementa o ZAP Imóveis
+type zapSource struct{}
+
+var zapPatterns = portalPatterns{
+	// Formato: /imovel/venda-apartamento-...-id-NNNNNNNNNN/
+	listingLink: regexp.MustCompile(`href="(https://www\.zapimoveis\.com\.br/imovel/[^"]+id-(\d+)[^"]*)"`),
+	image:       regexp.MustCompile(`src="(https://resizedimgs\.zapimoveis\.com\.br[^"]+)"`),
+}
+
+func (zapSource) Name() string {
+	return SourceZAP
+}
+
+func (zapSource) SearchURLs(params CollectParams) []string {
+	return buildSearchURLCandidates(params)
+}
+
+func (zapSource) ParseListings(html string) []ListingSummary {
+	return parseListingsHTML(html, zapPatterns)
+}
+
+func (zapSource) ParseDetail(html string, summary ListingSummary) *ListingDetails {
+	return parseDetailHTML(html, summary, zapPatterns)
+}
+
+func (zapSource) CanonicalID(url string) string {
+	return extractListingID(url)
+}
+
+func (zapSource) MatchLocation(summaries []ListingSummary, params CollectParams) []ListingSummary {
+	return matchLocationByURL(summaries, params)
+}
@@ -0,0 +1,576 @@
+package zapscraper
+
+import (
+	"encoding/json"
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Origem de cada campo extraído (ListingDetails.FieldSources)
+const (
+	ProvenanceJSONLD    = "json_ld"
+	ProvenanceNextData  = "next_data"
+	ProvenanceHydration = "hydration"
+	ProvenanceHTML      = "html"
+)
+
+// parseQualityWeights define os campos considerados no score de qualidade (soma 100)
+var parseQualityWeights = map[string]int{
+	"price_cents":     25,
+	"area_m2":         20,
+	"bedrooms":        10,
+	"neighborhood":    10,
+	"bathrooms":       5,
+	"parking_spots":   5,
+	"condo_fee_cents": 5,
+	"iptu_cents":      5,
+	"address":         5,
+	"city":            5,
+	"description":     5,
+}
+
+// Campos vindos das heurísticas de HTML valem menos: quebram sempre que o portal
+// muda o markup e podem pegar o valor de outro bloco da página
+const htmlProvenanceReliability = 0.6
+
+var (
+	jsonLDScriptRe   = regexp.MustCompile(`(?is)<script[^>]+type="application/ld\+json"[^>]*>(.*?)</script>`)
+	nextDataScriptRe = regexp.MustCompile(`(?is)<script[^>]+id="__NEXT_DATA__"[^>]*>(.*?)</script>`)
+	hydrationStateRe = regexp.MustCompile(`(?s)window\.(?:__INITIAL_STATE__|__PRELOADED_STATE__|__INITIAL_PROPS__)\s*=\s*(\{.*?\})\s*;?\s*</script>`)
+	dataJSONAttrRe   = regexp.MustCompile(`data-json="([^"]+)"`)
+)
+
+// structuredListing guarda os campos encontrados nos dados estruturados; nil = ausente
+type structuredListing struct {
+	Title         *string
+	Description   *string
+	PriceCents    *int64
+	AreaM2        *float64
+	Bedrooms      *int
+	Bathrooms     *int
+	ParkingSpots  *int
+	Suites        *int
+	CondoFeeCents *int64
+	IPTUCents     *int64
+	Address       *string
+	Neighborhood  *string
+	City          *string
+	State         *string
+	PublishedAt   *time.Time
+	Images        []string
+}
+
+// applyStructuredData sobrescreve os campos extraídos do HTML com os dados
+// estruturados da página (JSON-LD, __NEXT_DATA__ e payloads de hidratação), registra
+// a origem de cada campo e calcula o score de qualidade do parse
+func applyStructuredData(page string, detail *ListingDetails) *ListingDetails {
+	provenance := make(map[string]string)
+	for _, payload := range extractStructuredPayloads(page, detail.SourceListingID) {
+		payload.listing.applyTo(detail, payload.origin, provenance)
+	}
+
+	// O que não veio dos dados estruturados ficou com o valor das heurísticas de HTML
+	for field, present := range detailFieldsPresent(detail) {
+		if _, ok := provenance[field]; !ok && present {
+			provenance[field] = ProvenanceHTML
+		}
+	}
+
+	detail.FieldSources = provenance
+	detail.ParseQuality = parseQualityScore(provenance)
+	return detail
+}
+
+type structuredPayload struct {
+	origin  string
+	listing structuredListing
+}
+
+// extractStructuredPayloads retorna os payloads encontrados, do mais confiável ao menos
+func extractStructuredPayloads(page, listingID string) []structuredPayload {
+	var payloads []structuredPayload
+
+	var jsonLD structuredListing
+	found := false
+	for _, m := range jsonLDScriptRe.FindAllStringSubmatch(page, -1) {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(m[1])), &doc); err != nil {
+			continue
+		}
+		walkJSON(doc, func(node map[string]interface{}) {
+			if fillFromJSONLD(node, &jsonLD) {
+				found = true
+			}
+		})
+	}
+	if found {
+		payloads = append(payloads, structuredPayload{origin: ProvenanceJSONLD, listing: jsonLD})
+	}
+
+	if m := nextDataScriptRe.FindStringSubmatch(page); len(m) > 1 {
+		if listing, ok := listingFromHydration(m[1], listingID); ok {
+			payloads = append(payloads, structuredPayload{origin: ProvenanceNextData, listing: listing})
+		}
+	}
+
+	var hydration []string
+	if m := hydrationStateRe.FindStringSubmatch(page); len(m) > 1 {
+		hydration = append(hydration, m[1])
+	}
+	for _, m := range dataJSONAttrRe.FindAllStringSubmatch(page, -1) {
+		hydration = append(hydration, html.UnescapeString(m[1]))
+	}
+	for _, raw := range hydration {
+		if listing, ok := listingFromHydration(raw, listingID); ok {
+			payloads = append(payloads, structuredPayload{origin: ProvenanceHydration, listing: listing})
+			break
+		}
+	}
+
+	return payloads
+}
+
+// JSON-LD
+
+var jsonLDListingTypes = map[string]bool{
+	"product":               true,
+	"offer":                 true,
+	"aggregateoffer":        true,
+	"realestatelisting":     true,
+	"apartment":             true,
+	"house":                 true,
+	"singlefamilyresidence": true,
+	"residence":             true,
+	"accommodation":         true,
+}
+
+// fillFromJSONLD completa out com um nó schema.org; retorna se o nó é de anúncio
+func fillFromJSONLD(node map[string]interface{}, out *structuredListing) bool {
+	if !jsonLDHasListingType(node["@type"]) {
+		return false
+	}
+
+	setString(&out.Title, node["name"])
+	setString(&out.Description, node["description"])
+	if price, ok := jsonNumber(firstPresent(node, "price", "lowPrice")); ok && price > 0 {
+		setInt64(&out.PriceCents, toCents(price))
+	}
+	if size, ok := node["floorSize"]; ok {
+		if m, isMap := size.(map[string]interface{}); isMap {
+			size = m["value"]
+		}
+		if area, ok := jsonNumber(size); ok && area > 0 {
+			setFloat(&out.AreaM2, area)
+		}
+	}
+	setIntField(&out.Bedrooms, firstPresent(node, "numberOfBedrooms", "numberOfRooms"))
+	setIntField(&out.Bathrooms, firstPresent(node, "numberOfBathroomsTotal", "numberOfFullBathrooms"))
+	if address, ok := node["address"].(map[string]interface{}); ok {
+		setString(&out.Address, address["streetAddress"])
+		setString(&out.City, address["addressLocality"])
+		setString(&out.State, address["addressRegion"])
+	}
+	setTime(&out.PublishedAt, firstPresent(node, "datePosted", "datePublished"))
+	if len(out.Images) == 0 {
+		out.Images = jsonImageURLs(node["image"])
+	}
+	return true
+}
+
+func jsonLDHasListingType(value interface{}) bool {
+	switch t := value.(type) {
+	case string:
+		return jsonLDListingTypes[strings.ToLower(t)]
+	case []interface{}:
+		for _, item := range t {
+			if jsonLDHasListingType(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Hidratação (__NEXT_DATA__, window.__INITIAL_STATE__, data-json)
+
+// hydrationKeys mapeia os nomes usados pelos portais para os campos do anúncio
+var hydrationKeys = map[string][]string{
+	"title":           {"title", "subject"},
+	"description":     {"description", "body"},
+	"price_cents":     {"price", "priceValue", "salePrice"},
+	"area_m2":         {"usableAreas", "usableArea", "size", "area"},
+	"bedrooms":        {"bedrooms", "rooms"},
+	"bathrooms":       {"bathrooms"},
+	"parking_spots":   {"parkingSpaces", "parkingSpots", "garage_spaces"},
+	"suites":          {"suites"},
+	"condo_fee_cents": {"monthlyCondoFee", "condominiumFee", "condominio", "condo"},
+	"iptu_cents":      {"yearlyIptu", "iptu"},
+	"published_at":    {"createdAt", "publishedAt"},
+}
+
+// Um objeto só é tratado como o anúncio com pelo menos 3 campos reconhecidos
+const minHydrationFields = 3
+
+// listingFromHydration procura no payload o objeto que descreve o anúncio: o de
+// ID igual ao anúncio ou, sem ID, o que tem mais campos reconhecidos
+func listingFromHydration(raw, listingID string) (structuredListing, bool) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &doc); err != nil {
+		return structuredListing{}, false
+	}
+
+	var best map[string]interface{}
+	bestScore := 0
+	walkJSON(doc, func(node map[string]interface{}) {
+		node = flattenHydrationNode(node)
+		score := 0
+		for _, keys := range hydrationKeys {
+			if firstPresent(node, keys...) != nil {
+				score++
+			}
+		}
+		if score < minHydrationFields {
+			return
+		}
+		if listingID != "" {
+			for _, key := range []string{"id", "listId", "listingId"} {
+				if id, ok := node[key]; ok && jsonText(id) == listingID {
+					score += len(hydrationKeys)
+				}
+			}
+		}
+		if score > bestScore {
+			best, bestScore = node, score
+		}
+	})
+	if best == nil {
+		return structuredListing{}, false
+	}
+
+	var out structuredListing
+	setString(&out.Title, firstPresent(best, hydrationKeys["title"]...))
+	setString(&out.Description, firstPresent(best, hydrationKeys["description"]...))
+	if price, ok := jsonNumber(firstPresent(best, hydrationKeys["price_cents"]...)); ok && price > 0 {
+		setInt64(&out.PriceCents, toCents(price))
+	}
+	if area, ok := jsonNumber(firstPresent(best, hydrationKeys["area_m2"]...)); ok && area > 0 {
+		setFloat(&out.AreaM2, area)
+	}
+	setIntField(&out.Bedrooms, firstPresent(best, hydrationKeys["bedrooms"]...))
+	setIntField(&out.Bathrooms, firstPresent(best, hydrationKeys["bathrooms"]...))
+	setIntField(&out.ParkingSpots, firstPresent(best, hydrationKeys["parking_spots"]...))
+	setIntField(&out.Suites, firstPresent(best, hydrationKeys["suites"]...))
+	if fee, ok := jsonNumber(firstPresent(best, hydrationKeys["condo_fee_cents"]...)); ok && fee > 0 {
+		setInt64(&out.CondoFeeCents, toCents(fee))
+	}
+	if iptu, ok := jsonNumber(firstPresent(best, hydrationKeys["iptu_cents"]...)); ok && iptu > 0 {
+		setInt64(&out.IPTUCents, toCents(iptu))
+	}
+	setTime(&out.PublishedAt, firstPresent(best, hydrationKeys["published_at"]...))
+
+	if location, ok := firstPresent(best, "address", "location").(map[string]interface{}); ok {
+		street := jsonText(firstPresent(location, "street", "address", "streetAddress"))
+		if number := jsonText(location["streetNumber"]); street != "" && number != "" {
+			street += ", " + number
+		}
+		setString(&out.Address, street)
+		setString(&out.Neighborhood, firstPresent(location, "neighborhood", "neighbourhood", "bairro"))
+		setString(&out.City, firstPresent(location, "city", "municipality"))
+		setString(&out.State, firstPresent(location, "stateAcronym", "uf", "state"))
+	}
+	out.Images = jsonImageURLs(firstPresent(best, "images", "medias"))
+	return out, true
+}
+
+// flattenHydrationNode traz para o nível do anúncio os valores que os portais
+// aninham: pricingInfos do grupo ZAP e a lista properties [{name, value}] da OLX
+func flattenHydrationNode(node map[string]interface{}) map[string]interface{} {
+	pricing, hasPricing := node["pricingInfos"].([]interface{})
+	properties, hasProperties := node["properties"].([]interface{})
+	if !hasPricing && !hasProperties {
+		return node
+	}
+
+	flat := make(map[string]interface{}, len(node)+8)
+	for key, value := range node {
+		flat[key] = value
+	}
+	for _, item := range properties {
+		if property, ok := item.(map[string]interface{}); ok {
+			if name := jsonText(property["name"]); name != "" {
+				if _, exists := flat[name]; !exists {
+					flat[name] = property["value"]
+				}
+			}
+		}
+	}
+	// Anúncios de venda e aluguel trazem um pricingInfo por modalidade
+	var sale map[string]interface{}
+	for _, item := range pricing {
+		info, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if sale == nil || strings.EqualFold(jsonText(info["businessType"]), "SALE") {
+			sale = info
+		}
+	}
+	for key, value := range sale {
+		if _, exists := flat[key]; !exists {
+			flat[key] = value
+		}
+	}
+	return flat
+}
+
+// Aplicação no ListingDetails
+
+func (s structuredListing) applyTo(detail *ListingDetails, origin string, provenance map[string]string) {
+	claim := func(field string) bool {
+		if _, taken := provenance[field]; taken {
+			return false
+		}
+		provenance[field] = origin
+		return true
+	}
+
+	if s.Title != nil && claim("title") {
+		detail.Title = *s.Title
+	}
+	if s.Description != nil && claim("description") {
+		detail.Description = *s.Description
+	}
+	if s.PriceCents != nil && claim("price_cents") {
+		detail.PriceCents = *s.PriceCents
+	}
+	if s.AreaM2 != nil && claim("area_m2") {
+		detail.AreaM2 = *s.AreaM2
+	}
+	if s.Bedrooms != nil && claim("bedrooms") {
+		detail.Bedrooms = *s.Bedrooms
+	}
+	if s.Bathrooms != nil && claim("bathrooms") {
+		detail.Bathrooms = *s.Bathrooms
+	}
+	if s.ParkingSpots != nil && claim("parking_spots") {
+		detail.ParkingSpots = *s.ParkingSpots
+	}
+	if s.Suites != nil && claim("suites") {
+		detail.Suites = *s.Suites
+	}
+	if s.CondoFeeCents != nil && claim("condo_fee_cents") {
+		detail.CondoFeeCents = *s.CondoFeeCents
+	}
+	if s.IPTUCents != nil && claim("iptu_cents") {
+		detail.IPTUCents = *s.IPTUCents
+	}
+	if s.Address != nil && claim("address") {
+		detail.Address = *s.Address
+	}
+	if s.Neighborhood != nil && claim("neighborhood") {
+		detail.Neighborhood = *s.Neighborhood
+	}
+	if s.City != nil && claim("city") {
+		detail.City = *s.City
+	}
+	if s.State != nil && claim("state") {
+		detail.State = strings.ToUpper(*s.State)
+	}
+	if s.PublishedAt != nil && claim("published_at") {
+		detail.PublishedAt = s.PublishedAt
+	}
+	if len(s.Images) > 0 && claim("images") {
+		detail.Images = s.Images
+	}
+}
+
+// detailFieldsPresent indica quais campos o parse de HTML conseguiu preencher
+func detailFieldsPresent(d *ListingDetails) map[string]bool {
+	return map[string]bool{
+		"title":           d.Title != "",
+		"description":     d.Description != "",
+		"price_cents":     d.PriceCents > 0,
+		"area_m2":         d.AreaM2 > 0,
+		"bedrooms":        d.Bedrooms > 0,
+		"bathrooms":       d.Bathrooms > 0,
+		"parking_spots":   d.ParkingSpots > 0,
+		"suites":          d.Suites > 0,
+		"condo_fee_cents": d.CondoFeeCents > 0,
+		"iptu_cents":      d.IPTUCents > 0,
+		"address":         d.Address != "",
+		"neighborhood":    d.Neighborhood != "",
+		"city":            d.City != "",
+		"state":           d.State != "",
+		"published_at":    d.PublishedAt != nil,
+		"images":          len(d.Images) > 0 && d.Images[0] != "",
+	}
+}
+
+// parseQualityScore vai de 0 a 100: campos relevantes presentes, com peso menor
+// para os que vieram das heurísticas de HTML
+func parseQualityScore(provenance map[string]string) int {
+	total := 0.0
+	for field, weight := range parseQualityWeights {
+		origin, ok := provenance[field]
+		if !ok {
+			continue
+		}
+		if origin == ProvenanceHTML {
+			total += float64(weight) * htmlProvenanceReliability
+		} else {
+			total += float64(weight)
+		}
+	}
+	return int(math.Round(total))
+}
+
+// Helpers de JSON
+
+// walkJSON visita todos os objetos do documento em profundidade
+func walkJSON(value interface{}, visit func(map[string]interface{})) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		visit(v)
+		for _, child := range v {
+			walkJSON(child, visit)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkJSON(child, visit)
+		}
+	}
+}
+
+func firstPresent(node map[string]interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		if value, ok := node[key]; ok && value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// jsonText converte strings e números em texto; outros tipos viram ""
+func jsonText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+var structuredThousandsRe = regexp.MustCompile(`^\d{1,3}(\.\d{3})+$`)
+
+// jsonNumber aceita números, strings ("515000", "R$ 439.000", "78m²", "1.800,50")
+// e listas de um valor (o grupo ZAP usa [82] para a área)
+func jsonNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case []interface{}:
+		if len(v) > 0 {
+			return jsonNumber(v[0])
+		}
+	case map[string]interface{}:
+		return jsonNumber(firstPresent(v, "value", "amount"))
+	case string:
+		s := strings.TrimSpace(v)
+		s = strings.TrimPrefix(s, "R$")
+		s = strings.TrimSuffix(s, "m²")
+		s = strings.TrimSpace(s)
+		switch {
+		case strings.Contains(s, ","):
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.ReplaceAll(s, ",", ".")
+		case structuredThousandsRe.MatchString(s):
+			s = strings.ReplaceAll(s, ".", "")
+		}
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func jsonImageURLs(value interface{}) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(url string) {
+		if strings.HasPrefix(url, "http") && !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		add(v)
+	case []interface{}:
+		for _, item := range v {
+			switch image := item.(type) {
+			case string:
+				add(image)
+			case map[string]interface{}:
+				add(jsonText(firstPresent(image, "url", "contentUrl", "original")))
+			}
+		}
+	case map[string]interface{}:
+		add(jsonText(firstPresent(v, "url", "contentUrl")))
+	}
+	return urls
+}
+
+func toCents(value float64) int64 {
+	return int64(math.Round(value * 100))
+}
+
+func setString(dst **string, value interface{}) {
+	if *dst != nil {
+		return
+	}
+	if text := cleanText(jsonText(value)); text != "" {
+		*dst = &text
+	}
+}
+
+func setInt64(dst **int64, value int64) {
+	if *dst == nil {
+		*dst = &value
+	}
+}
+
+func setFloat(dst **float64, value float64) {
+	if *dst == nil {
+		*dst = &value
+	}
+}
+
+// setIntField aceita contagens entre 0 e 50; fora disso o payload está errado
+func setIntField(dst **int, value interface{}) {
+	if *dst != nil {
+		return
+	}
+	if n, ok := jsonNumber(value); ok && n >= 0 && n <= 50 {
+		count := int(n)
+		*dst = &count
+	}
+}
+
+func setTime(dst **time.Time, value interface{}) {
+	if *dst != nil {
+		return
+	}
+	text := jsonText(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			t = t.UTC()
+			*dst = &t
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package zapscraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZAPParseDetailPrefersStructuredData(t *testing.T) {
+	source := zapSource{}
+	summary := source.ParseListings(loadFixture(t, "zap_listing.html"))[0]
+	detail := source.ParseDetail(loadFixture(t, "zap_detail_structured.html"), summary)
+
+	if detail.PriceCents != 51500000 || detail.AreaM2 != 82 || detail.Bedrooms != 3 || detail.Bathrooms != 2 {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if detail.ParkingSpots != 1 || detail.Suites != 1 || detail.CondoFeeCents != 78000 || detail.IPTUCents != 180000 {
+		t.Fatalf("unexpected detail extras: %+v", detail)
+	}
+	if detail.Address != "Rua Brasílio Itiberê, 1200" || detail.Neighborhood != "Vila Izabel" || detail.City != "Curitiba" || detail.State != "PR" {
+		t.Fatalf("unexpected location: %q %q %q %q", detail.Address, detail.Neighborhood, detail.City, detail.State)
+	}
+	if detail.PublishedAt == nil || !detail.PublishedAt.Equal(time.Date(2025, time.March, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected published_at: %v", detail.PublishedAt)
+	}
+	if len(detail.Images) != 2 {
+		t.Fatalf("expected the 2 gallery images from the payload, got %v", detail.Images)
+	}
+
+	// JSON-LD vem antes do __NEXT_DATA__; o que ele não traz sai do __NEXT_DATA__
+	want := map[string]string{
+		"price_cents":     ProvenanceJSONLD,
+		"area_m2":         ProvenanceJSONLD,
+		"bedrooms":        ProvenanceJSONLD,
+		"description":     ProvenanceJSONLD,
+		"parking_spots":   ProvenanceNextData,
+		"condo_fee_cents": ProvenanceNextData,
+		"neighborhood":    ProvenanceNextData,
+	}
+	for field, origin := range want {
+		if got := detail.FieldSources[field]; got != origin {
+			t.Fatalf("provenance[%s]=%q want=%q", field, got, origin)
+		}
+	}
+	if detail.ParseQuality != 100 {
+		t.Fatalf("parse quality=%d want=100", detail.ParseQuality)
+	}
+}
+
+func TestZAPParseDetailFallsBackToHTML(t *testing.T) {
+	source := zapSource{}
+	summary := source.ParseListings(loadFixture(t, "zap_listing.html"))[0]
+	detail := source.ParseDetail(loadFixture(t, "zap_detail.html"), summary)
+
+	if detail.FieldSources["price_cents"] != ProvenanceHTML || detail.FieldSources["neighborhood"] != ProvenanceHTML {
+		t.Fatalf("expected html provenance, got %v", detail.FieldSources)
+	}
+	// Todos os campos pesados presentes, mas só via HTML
+	if detail.ParseQuality != 60 {
+		t.Fatalf("parse quality=%d want=60", detail.ParseQuality)
+	}
+}
+
+func TestOLXParseDetailUsesHydrationPayload(t *testing.T) {
+	source := olxSource{}
+	summary := source.ParseListings(loadFixture(t, "olx_listing.html"))[0]
+	detail := source.ParseDetail(loadFixture(t, "olx_detail_structured.html"), summary)
+
+	if detail.PriceCents != 43900000 || detail.AreaM2 != 78 || detail.Bedrooms != 3 || detail.Bathrooms != 2 || detail.ParkingSpots != 1 {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if detail.CondoFeeCents != 60000 || detail.IPTUCents != 114000 {
+		t.Fatalf("unexpected costs: condo=%d iptu=%d", detail.CondoFeeCents, detail.IPTUCents)
+	}
+	if detail.Address != "Rua Castro Alves" || detail.Neighborhood != "Vila Izabel" || detail.City != "Curitiba" {
+		t.Fatalf("unexpected location: %+v", detail)
+	}
+	if detail.Description != "Apartamento para reformar, aceita proposta. Sol da manhã." {
+		t.Fatalf("unexpected description: %q", detail.Description)
+	}
+	if detail.FieldSources["iptu_cents"] != ProvenanceHydration || detail.ParseQuality != 100 {
+		t.Fatalf("unexpected provenance/quality: %v %d", detail.FieldSources, detail.ParseQuality)
+	}
+}
+
+func TestJSONNumberFormats(t *testing.T) {
+	cases := map[string]float64{
+		"515000":     515000,
+		"R$ 439.000": 439000,
+		"1.800,50":   1800.5,
+		"78m²":       78,
+		"82.5":       82.5,
+	}
+	for input, want := range cases {
+		if got, ok := jsonNumber(input); !ok || got != want {
+			t.Fatalf("jsonNumber(%q)=%v,%v want=%v", input, got, ok, want)
+		}
+	}
+	if got, ok := jsonNumber([]interface{}{float64(82)}); !ok || got != 82 {
+		t.Fatalf("jsonNumber([82])=%v,%v", got, ok)
+	}
+	if _, ok := jsonNumber("sob consulta"); ok {
+		t.Fatal("expected non numeric text to be rejected")
+	}
+}
@@ -53,6 +53,9 @@ type ListingDetails struct {
 	Features        []string
 	PublishedAt     *time.Time
 	RawHTML         string
+	// Origem de cada campo (json_ld, next_data, hydration ou html) e score de 0 a 100
+	FieldSources map[string]string
+	ParseQuality int
 }
 
 // SourceListing representa um anúncio normalizado
@@ -74,7 +77,11 @@ type SourceListing struct {
 	City            string     `json:"city"`
 	State           string     `json:"state"`
 	Images          []string   `json:"images"`
+	ImageHashes     []string   `json:"image_hashes,omitempty"` // dHash das primeiras fotos, usado na deduplicação
 	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	// Proveniência dos campos e qualidade do parse, gravadas com a oportunidade
+	FieldSources map[string]string `json:"field_sources,omitempty"`
+	ParseQuality int               `json:"parse_quality"`
 }
 
 // ScoreBreakdown detalha os pontos de cada categoria
@@ -86,6 +93,7 @@ type ScoreBreakdown struct {
 	Keywords  int `json:"keywords"`
 	Penalties int `json:"penalties"`
 	Decay     int `json:"decay"`
+	PriceCut  int `json:"price_cut"` // aplicado na ingestão, que conhece o histórico de preços
 }
 
 // Opportunity representa uma oportunidade com score